@@ -0,0 +1,99 @@
+package main
+
+// bench runs the repository's Go benchmarks (go test -bench) and compares
+// them against the stored baseline, exiting non-zero when a benchmark
+// regresses beyond tolerance.
+//
+//	go run ./cmd/bench                 # compare against benchmarks/baseline.json
+//	go run ./cmd/bench -update         # record a new baseline
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"whats-convert-api/internal/benchmark"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("[Bench] ")
+
+	baselinePath := flag.String("baseline", "benchmarks/baseline.json", "baseline file to compare against")
+	update := flag.Bool("update", false, "write the results as the new baseline instead of comparing")
+	tolerance := flag.Float64("tolerance", 0.20, "allowed ns/op slowdown before a benchmark counts as a regression (0.20 = 20%)")
+	filter := flag.String("run", ".", "only run benchmarks matching this regular expression (go test -bench)")
+	benchtime := flag.Duration("benchtime", time.Second, "target run time per benchmark")
+	packages := flag.String("packages", "./...", "space-separated packages whose benchmarks run")
+	flag.Parse()
+
+	args := []string{"test", "-run", "^$", "-bench", *filter, "-benchmem", "-benchtime", benchtime.String()}
+	args = append(args, strings.Fields(*packages)...)
+
+	// Show the go test output as it runs and keep it for parsing
+	var output bytes.Buffer
+	cmd := exec.Command("go", args...)
+	cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Fatalf("go %s: %v", strings.Join(args, " "), err)
+	}
+
+	results, err := benchmark.Parse(&output)
+	if err != nil {
+		log.Fatalf("Failed to read benchmark output: %v", err)
+	}
+	if len(results) == 0 {
+		log.Fatalf("No benchmarks matched %q", *filter)
+	}
+
+	if *update {
+		baseline := &benchmark.Baseline{Results: results}
+		if existing, err := benchmark.LoadBaseline(*baselinePath); err == nil {
+			// Keep entries for benchmarks that were filtered out or skipped here
+			for name, result := range existing.Results {
+				if _, ok := baseline.Results[name]; !ok {
+					baseline.Results[name] = result
+				}
+			}
+		}
+		baseline.GoVersion = runtime.Version()
+		baseline.Platform = fmt.Sprintf("%s/%s (%d CPU)", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+		baseline.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+
+		if err := baseline.Save(*baselinePath); err != nil {
+			log.Fatalf("Failed to write baseline: %v", err)
+		}
+		fmt.Printf("\nBaseline written to %s\n", *baselinePath)
+		return
+	}
+
+	baseline, err := benchmark.LoadBaseline(*baselinePath)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("\nNo baseline at %s; run with -update to record one\n", *baselinePath)
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to load baseline: %v", err)
+	}
+
+	regressions := benchmark.Compare(baseline, results, *tolerance)
+	if len(regressions) == 0 {
+		fmt.Printf("\nNo regressions against %s (tolerance %.0f%%)\n", *baselinePath, *tolerance*100)
+		return
+	}
+
+	fmt.Printf("\nRegressions against %s (tolerance %.0f%%, baseline from %s):\n", *baselinePath, *tolerance*100, baseline.Platform)
+	for _, r := range regressions {
+		fmt.Printf("  %-40s %-10s %14.0f -> %14.0f (%+.1f%%)\n", r.Name, r.Metric, r.Baseline, r.Current, r.Change()*100)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,73 @@
+package main
+
+// integration runs the end-to-end suite against a running API and the mock
+// webhook receiver, as started by the integration compose profile.
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"whats-convert-api/internal/integration"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("[Integration] ")
+
+	apiURL := flag.String("api", envOr("INTEGRATION_API_URL", "http://localhost:8080"), "base URL of the API under test")
+	receiverURL := flag.String("receiver", envOr("INTEGRATION_RECEIVER_URL", "http://localhost:9090"), "base URL of the mock webhook receiver")
+	prefix := flag.String("prefix", "integration/", "key prefix for objects written by the suite")
+	wait := flag.Duration("wait", time.Minute, "how long to wait for each asynchronous outcome")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout for the run")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report := integration.Run(ctx, integration.Options{
+		APIURL:      *apiURL,
+		ReceiverURL: *receiverURL,
+		KeyPrefix:   *prefix,
+		Wait:        *wait,
+	})
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+	} else {
+		fmt.Printf("API: %s  Receiver: %s\n\n", *apiURL, *receiverURL)
+		for _, result := range report.Results {
+			status := "PASS"
+			if !result.Passed {
+				status = "FAIL"
+			}
+			fmt.Printf("%-4s %-26s %8s", status, result.Name, result.Duration.Round(time.Millisecond))
+			if result.Error != "" {
+				fmt.Printf("  %s", result.Error)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("\nPassed: %d  Failed: %d\n", report.Passed, report.Failed)
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
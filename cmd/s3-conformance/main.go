@@ -0,0 +1,82 @@
+package main
+
+// s3-conformance runs the provider conformance suite against the S3 provider
+// configured through the usual S3_* environment variables (or .env file).
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"whats-convert-api/internal/config"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/providers/conformance"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("[S3Conformance] ")
+
+	prefix := flag.String("prefix", "conformance/", "key prefix for objects written by the suite")
+	multipartSize := flag.Int64("multipart-size", 6*1024*1024, "payload size in bytes for the multipart check")
+	fetch := flag.Bool("fetch", true, "download presigned URLs to verify their content (ignored for the mock provider)")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall timeout for the run")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	flag.Parse()
+
+	_ = godotenv.Load()
+
+	s3Config := config.LoadS3Config()
+	if s3Config.Provider == providers.ProviderMock {
+		s3Config.ApplyMockMode()
+	}
+
+	provider, err := providers.NewProviderFactory().CreateProvider(s3Config.ToProviderConfig())
+	if err != nil {
+		log.Fatalf("Failed to create %s provider: %v", s3Config.Provider, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	report := conformance.Check(ctx, provider, conformance.Options{
+		KeyPrefix:     *prefix,
+		MultipartSize: *multipartSize,
+		FetchURLs:     *fetch && s3Config.Provider != providers.ProviderMock,
+	})
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+	} else {
+		fmt.Printf("Provider: %s  Bucket: %s\n\n", s3Config.Provider, s3Config.Bucket)
+		for _, result := range report.Results {
+			status := "PASS"
+			switch {
+			case result.Skipped:
+				status = "SKIP"
+			case !result.Passed:
+				status = "FAIL"
+			}
+			fmt.Printf("%-4s %-24s %8s", status, result.Name, result.Duration.Round(time.Millisecond))
+			if result.Error != "" {
+				fmt.Printf("  %s", result.Error)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("\nPassed: %d  Failed: %d  Skipped: %d\n", report.Passed, report.Failed, report.Skipped)
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+// soak loads a running API for hours and fails when goroutines, heap or the
+// upload status map keep growing, as started by scripts/soak-test.sh.
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"whats-convert-api/internal/soak"
+)
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("[Soak] ")
+
+	apiURL := flag.String("api", envOr("SOAK_API_URL", "http://localhost:8080"), "base URL of the API under test")
+	duration := flag.Duration("duration", 2*time.Hour, "how long the load runs")
+	concurrency := flag.Int("concurrency", 8, "clients sending requests back to back")
+	interval := flag.Duration("interval", 30*time.Second, "time between samples of /stats")
+	warmup := flag.Duration("warmup", 15*time.Minute, "start of the load ignored by the growth checks; keep it above S3_UPLOAD_STATUS_TTL and BATCH_JOB_RETENTION")
+	settle := flag.Duration("settle", time.Minute, "idle time after the load before the final sample")
+	heapGrowth := flag.Float64("max-heap-growth", 0.5, "largest accepted growth of the heap floor (0.5 = 50%)")
+	uploadGrowth := flag.Float64("max-upload-growth", 0.5, "largest accepted growth of the upload status map")
+	goroutineGrowth := flag.Int("max-goroutine-growth", 50, "most goroutines the idle API may gain over the run")
+	errorRate := flag.Float64("max-error-rate", 0.01, "largest accepted share of failed requests")
+	jsonOutput := flag.Bool("json", false, "print the report, with every sample, as JSON")
+	flag.Parse()
+
+	// Ctrl-C ends the load early and still evaluates what was sampled
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := soak.Options{
+		APIURL:             *apiURL,
+		Duration:           *duration,
+		Concurrency:        *concurrency,
+		SampleInterval:     *interval,
+		Warmup:             *warmup,
+		Settle:             *settle,
+		MaxHeapGrowth:      *heapGrowth,
+		MaxUploadGrowth:    *uploadGrowth,
+		MaxGoroutineGrowth: *goroutineGrowth,
+		MaxErrorRate:       *errorRate,
+	}
+	if !*jsonOutput {
+		opts.Progress = func(s soak.Sample) {
+			log.Printf("%-8s goroutines=%-5d heap=%-6.1fMB uploads=%-6d requests=%d errors=%d",
+				s.Phase, s.Goroutines, float64(s.HeapAlloc)/(1<<20), s.Uploads, s.Requests, s.Errors)
+		}
+	}
+
+	report := soak.Run(ctx, opts)
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Failed to encode report: %v", err)
+		}
+	} else if report.Error != "" {
+		fmt.Printf("\nSoak run failed to start: %s\n", report.Error)
+	} else {
+		fmt.Printf("\nAPI: %s  Load: %s\n\n", *apiURL, report.Duration.Round(time.Second))
+		for _, name := range slices.Sorted(maps.Keys(report.Operations)) {
+			stats := report.Operations[name]
+			fmt.Printf("%-20s %8d requests %6d errors\n", name, stats.Requests, stats.Errors)
+		}
+		fmt.Println()
+		for _, check := range report.Checks {
+			status := "PASS"
+			if !check.Passed {
+				status = "FAIL"
+			}
+			fmt.Printf("%-4s %-12s %s\n", status, check.Name, check.Detail)
+		}
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
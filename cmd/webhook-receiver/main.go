@@ -0,0 +1,103 @@
+package main
+
+// webhook-receiver is the mock webhook endpoint of the integration compose
+// profile. It records every JSON body POSTed to /hooks for the suite to read
+// back, and answers /status/{code} with that status so the suite has a
+// download host that keeps failing.
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxHooks bounds the deliveries kept; the oldest are dropped first
+const maxHooks = 1000
+
+// delivery is one request received on /hooks
+type delivery struct {
+	ReceivedAt time.Time       `json:"received_at"`
+	Headers    http.Header     `json:"headers"`
+	Body       json.RawMessage `json:"body"`
+}
+
+type receiver struct {
+	mu    sync.Mutex
+	hooks []delivery
+}
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("[WebhookReceiver] ")
+
+	listen := flag.String("listen", ":9090", "address to listen on")
+	flag.Parse()
+
+	r := &receiver{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /hooks", r.record)
+	mux.HandleFunc("GET /hooks", r.list)
+	mux.HandleFunc("DELETE /hooks", r.clear)
+	mux.HandleFunc("GET /status/{code}", status)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("Listening on %s", *listen)
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	log.Fatal(server.ListenAndServe())
+}
+
+// record stores a delivery; bodies that aren't JSON are rejected so a
+// malformed webhook fails the suite rather than passing unnoticed
+func (r *receiver) record(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, 1<<20))
+	if err != nil || !json.Valid(body) {
+		http.Error(w, "body must be JSON", http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	if len(r.hooks) == maxHooks {
+		r.hooks = r.hooks[1:]
+	}
+	r.hooks = append(r.hooks, delivery{ReceivedAt: time.Now().UTC(), Headers: req.Header, Body: body})
+	r.mu.Unlock()
+
+	log.Printf("Received %d bytes from %s", len(body), req.RemoteAddr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// list returns the deliveries received so far, oldest first
+func (r *receiver) list(w http.ResponseWriter, _ *http.Request) {
+	r.mu.Lock()
+	hooks := append([]delivery{}, r.hooks...)
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+// clear forgets the deliveries, so a run doesn't see the previous one's
+func (r *receiver) clear(w http.ResponseWriter, _ *http.Request) {
+	r.mu.Lock()
+	r.hooks = nil
+	r.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// status answers with the status code in the path
+func status(w http.ResponseWriter, req *http.Request) {
+	code, err := strconv.Atoi(req.PathValue("code"))
+	if err != nil || code < 200 || code > 599 {
+		http.Error(w, "status must be between 200 and 599", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(code)
+}
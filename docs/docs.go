@@ -9,11 +9,7 @@ const docTemplate = `{
     "info": {
         "description": "{{escape .Description}}",
         "title": "{{.Title}}",
-        "contact": {
-            "name": "Guilherme Jansen",
-            "url": "https://github.com/guilhermejansen",
-            "email": "suporte@setupautomatizado.com.br"
-        },
+        "contact": {},
         "license": {
             "name": "MIT",
             "url": "https://opensource.org/licenses/MIT"
@@ -23,62 +19,42 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api": {
-            "get": {
-                "description": "Provides API version and available endpoint catalogue.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "General"
-                ],
-                "summary": "API metadata",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.APIInfoResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/convert/audio": {
+        "/admin/audit-stamp": {
             "post": {
-                "description": "Accepts base64 payloads or multipart uploads and returns an optimized Opus data URI.",
+                "description": "Finds the audit stamp embedded in an image converted while AUDIT_STAMP_SECRET was set and checks its signature. The tenant is the tenant ID of the caller's API key, as in GET /usage; the reference is the request ID (X-Request-ID), or the batch job ID and item index. valid is false for stamps not signed with this deployment's secret, which may be forged. Images that were re-encoded since, as WhatsApp does to photos, have lost their stamp.",
                 "consumes": [
-                    "application/json",
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Conversion"
+                    "Admin"
                 ],
-                "summary": "Convert audio to WhatsApp-compatible Opus format",
+                "summary": "Read the audit stamp of an image",
                 "parameters": [
                     {
-                        "description": "Audio conversion request",
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Image",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_services.AudioRequest"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.AuditStampRequest"
                         }
-                    },
-                    {
-                        "type": "file",
-                        "description": "Audio file when using multipart",
-                        "name": "file",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_services.AudioResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_services.AuditStamp"
                         }
                     },
                     "400": {
@@ -87,14 +63,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
-                    "408": {
-                        "description": "Request Timeout",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "The image carries no stamp (code audit_stamp_not_found)",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
@@ -102,48 +78,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/convert/batch/audio": {
-            "post": {
-                "description": "Processes up to 10 audio conversion jobs concurrently.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/maintenance": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Conversion"
+                    "Admin"
                 ],
-                "summary": "Convert a batch of audio payloads",
+                "summary": "List endpoints under maintenance",
                 "parameters": [
                     {
-                        "description": "Batch audio conversion request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/whats-convert-api_internal_services.AudioRequest"
-                            }
-                        }
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.BatchAudioResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.MaintenanceResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
@@ -151,9 +112,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/convert/batch/image": {
-            "post": {
-                "description": "Processes up to 10 image conversion jobs concurrently.",
+        "/admin/maintenance/{endpoint}": {
+            "put": {
+                "description": "Answers requests to the endpoint, and every path below it, with 503 and code maintenance until the window is deleted or its duration passes. /convert/video pauses /convert/video and /convert/video/s3, with or without a version prefix; / pauses the whole API. /health and the maintenance endpoints stay up.",
                 "consumes": [
                     "application/json"
                 ],
@@ -161,20 +122,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Conversion"
+                    "Admin"
                 ],
-                "summary": "Convert a batch of image payloads",
+                "summary": "Put an endpoint under maintenance",
                 "parameters": [
                     {
-                        "description": "Batch image conversion request",
+                        "type": "string",
+                        "description": "Endpoint path, e.g. convert/video",
+                        "name": "endpoint",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Message and duration",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/whats-convert-api_internal_services.ImageRequest"
-                            }
+                            "$ref": "#/definitions/whats-convert-api_internal_models.MaintenanceRequest"
                         }
                     }
                 ],
@@ -182,11 +153,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.BatchImageResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MaintenanceWindow"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid endpoint or duration (code invalid_endpoint or invalid_duration)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
@@ -198,54 +175,52 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/convert/image": {
-            "post": {
-                "description": "Accepts base64 payloads or multipart uploads and returns a compressed JPEG data URI.",
-                "consumes": [
-                    "application/json",
-                    "multipart/form-data"
-                ],
+            },
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Conversion"
+                    "Admin"
                 ],
-                "summary": "Convert image to WhatsApp-optimized JPEG",
+                "summary": "Lift maintenance from an endpoint",
                 "parameters": [
                     {
-                        "description": "Image conversion request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_services.ImageRequest"
-                        }
+                        "type": "string",
+                        "description": "Endpoint path, as put under maintenance",
+                        "name": "endpoint",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "file",
-                        "description": "Image file when using multipart",
-                        "name": "file",
-                        "in": "formData"
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_services.ImageResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid endpoint (code invalid_endpoint)",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
-                    "408": {
-                        "description": "Request Timeout",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Endpoint not under maintenance (code maintenance_not_found)",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
@@ -259,296 +234,369 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/admin/migrations": {
             "get": {
-                "description": "Returns aggregated success metrics for audio and image converters.",
+                "description": "The running migration and the last 20 finished ones, newest first, with the provider names migrations accept.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Monitoring"
+                    "Admin"
                 ],
-                "summary": "Service health snapshot",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.HealthResponse"
-                        }
+                "summary": "List migrations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
                     }
-                }
-            }
-        },
-        "/stats": {
-            "get": {
-                "description": "Exposes raw converter counters for observability integrations.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Monitoring"
                 ],
-                "summary": "Converter statistics",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.StatsResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.MigrationListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/upload/s3": {
+            },
             "post": {
-                "description": "Accepts large media as multipart form-data and dispatches asynchronous upload jobs.",
+                "description": "Copies every object under the prefix from the source provider to the destination in the background, keeping keys, content types and metadata, and answers at once with the migration to poll. With transcode, images become WhatsApp JPEGs and audio Opus, stored with a .jpg or .ogg extension. Objects the destination already has (same key and size) are skipped unless overwrite is set. Once every object was handled, the destination is reconciled against the source and the report lists what is missing or differs. One migration runs at a time.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "S3"
+                    "Admin"
                 ],
-                "summary": "Start multipart upload to S3-compatible storage",
+                "summary": "Migrate objects to another provider",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Binary file to upload",
-                        "name": "file",
-                        "in": "formData",
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "JSON encoded upload options",
-                        "name": "options",
-                        "in": "formData"
+                        "description": "Providers, prefix and throttling",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.MigrationRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "202": {
                         "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Migration"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Unknown provider or invalid option (code invalid_migration)",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "409": {
+                        "description": "Another migration is running (code migration_running)",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "The source can't read objects back",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/upload/s3/base64": {
-            "post": {
-                "description": "Accepts pre-encoded data URIs and dispatches asynchronous upload jobs.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/migrations/{id}": {
+            "get": {
+                "description": "Progress counts, the first failures and, once the migration completed, its reconciliation report.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "S3"
+                    "Admin"
                 ],
-                "summary": "Start base64 upload to S3-compatible storage",
+                "summary": "Get a migration's progress",
                 "parameters": [
                     {
-                        "description": "Base64 upload request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3Base64UploadRequest"
-                        }
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Migration"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/upload/s3/health": {
-            "get": {
+            },
+            "delete": {
+                "description": "Stops the migration once the objects being copied are done with. Objects already copied stay in the destination; run the migration again to resume, as they are skipped.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "S3"
+                    "Admin"
+                ],
+                "summary": "Cancel a migration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Migration ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    }
                 ],
-                "summary": "S3 subsystem health",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3HealthResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Migration"
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3HealthResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The migration already finished (code migration_finished)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/upload/s3/list": {
+        "/admin/recordings": {
             "get": {
+                "description": "Lists the failed requests kept by REQUEST_RECORDING, newest first: sanitized parameters, SHA-256 digests of their inputs and the error they got. Bodies are never returned.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "S3"
+                    "Debug"
                 ],
-                "summary": "List recent upload jobs",
+                "summary": "List recorded failed requests",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filter by upload status (pending|uploading|completed|failed|cancelled)",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 50,
-                        "description": "Maximum number of results",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadListResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.RecordingsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/upload/s3/object/{key}": {
+        "/admin/recordings/{id}": {
             "get": {
+                "description": "Returns the sanitized envelope of a recorded request, as named by the X-Recording-ID header of its response.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "S3"
+                    "Debug"
                 ],
-                "summary": "Retrieve object metadata",
+                "summary": "Get a recorded failed request",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Object key",
-                        "name": "key",
+                        "description": "X-Recording-ID of the failed response",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_providers.ObjectInfo"
+                            "$ref": "#/definitions/whats-convert-api_internal_services.RecordedRequest"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
-                        }
-                    },
-                    "503": {
-                        "description": "Service Unavailable",
+                        "description": "Unknown or expired recording (code recording_not_found)",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/replay/{id}": {
+            "post": {
+                "description": "Re-runs a recorded request through the API in-process, with its original path, query and allow-listed headers, and reports how it fares now. Recordings without a body (REQUEST_RECORDING_BODIES off or over REQUEST_RECORDING_MAX_BODY_SIZE) can be replayed by sending the original file as the request body, provided the recording has a single base64 or file input and the file matches its SHA-256. Send X-API-Key to replay as the original caller. Replays are never recorded.",
+                "consumes": [
+                    "application/octet-stream"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "S3"
+                    "Debug"
                 ],
-                "summary": "Delete object from storage",
+                "summary": "Replay a recorded failed request",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Object key",
-                        "name": "key",
+                        "description": "X-Recording-ID of the failed response",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key to replay the request with",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Original input, for recordings without a body",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.MessageResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.RequestReplayResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Supplied input doesn't match the recording (code input_mismatch)",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "404": {
+                        "description": "Unknown or expired recording (code recording_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The body wasn't recorded and no usable input was sent (code input_not_recorded)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
@@ -556,86 +604,200 @@ const docTemplate = `{
                 }
             }
         },
-        "/upload/s3/stats": {
+        "/api": {
             "get": {
+                "description": "Provides API version, branding and available endpoint catalogue. Every endpoint is also served under its versioned prefix (e.g. /v1/convert/audio).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "S3"
+                    "General"
+                ],
+                "summary": "API metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Preferred description language, e.g. pt-BR (falls back to API_LANGUAGE)",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
                 ],
-                "summary": "S3 provider and upload manager metrics",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3StatsResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.APIInfoResponse"
                         }
                     }
                 }
             }
         },
-        "/upload/s3/status/{id}": {
+        "/cache/stats": {
             "get": {
+                "description": "Usage of the conversion output cache (CONVERSION_CACHE_SIZE, REDIS_URL) and of the convert-on-read rendition cache, with a live check of the shared Redis. Caches that are disabled are left out.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "S3"
+                    "Monitoring"
                 ],
-                "summary": "Retrieve asynchronous upload status",
+                "summary": "Cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.CacheStatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/capabilities": {
+            "get": {
+                "description": "Reports which external tools are installed, how they are sandboxed, which feature flags are on for the caller and, when API key tiers are configured, the caller's tier and its limits.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "Runtime capabilities",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Upload identifier",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Evaluate per-key feature rollouts and the tier of this API key",
+                        "name": "X-API-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadStatusResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_models.CapabilitiesResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/convert/audio": {
+            "post": {
+                "description": "Accepts base64 payloads or multipart uploads and returns an optimized Opus data URI. With output_format mp3/wav or the reverse preset, received voice notes are converted to MP3 or WAV instead.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "multipart/form-data",
+                    "audio/ogg",
+                    "audio/mpeg",
+                    "audio/wav"
                 ],
                 "tags": [
-                    "S3"
+                    "Conversion"
                 ],
-                "summary": "Cancel an in-flight upload job",
+                "summary": "Convert audio to WhatsApp-compatible Opus format",
                 "parameters": [
+                    {
+                        "description": "Audio conversion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.AudioRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Audio file when using multipart",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: false returns plain base64 instead of a data URI",
+                        "name": "data_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: return mono 48kHz Ogg/Opus input without re-encoding",
+                        "name": "skip_if_compliant",
+                        "in": "formData"
+                    },
                     {
                         "type": "string",
-                        "description": "Upload identifier",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Multipart only: opus (default), mp3 or wav",
+                        "name": "output_format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: whatsapp (default), reverse (MP3, mono; 16kHz when WAV) or a PRESETS_FILE audio preset",
+                        "name": "preset",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: also return waveform, 64 voice note amplitudes (JSON and multipart metadata only)",
+                        "name": "include_waveform",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: also return the input's chromaprint in fingerprint (JSON and multipart metadata only)",
+                        "name": "fingerprint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: normalize loudness to the EBU R128 targets (AUDIO_LOUDNORM_*)",
+                        "name": "normalize",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Multipart only: pick the Opus/MP3 bitrate so the output fits in this many MiB",
+                        "name": "target_size_mb",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: br returns Brotli-compressed plain base64 when that is smaller (response sets compression)",
+                        "name": "compress",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "binary returns the converted bytes as the response body",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "multipart/form-data returns a JSON metadata part plus the converted binary part(s); audio/ogg, audio/mpeg, audio/wav or application/octet-stream returns the converted bytes as the body",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg/vips commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)",
+                        "name": "debug_timings",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/whats-convert-api_internal_models.MessageResponse"
+                            "$ref": "#/definitions/whats-convert-api_internal_services.AudioResponse"
                         }
                     },
                     "400": {
@@ -643,588 +805,7119 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
                         }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "whats-convert-api_internal_models.APIInfoResponse": {
-            "type": "object",
-            "properties": {
-                "endpoints": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    }
-                },
-                "name": {
-                    "type": "string",
-                    "example": "WhatsApp Media Converter API"
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Input is an image (code unsupported_input)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Input longer than MAX_AUDIO_DURATION (code duration_limit_exceeded) or too long to fit target_size_mb (code target_size_unreachable)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/audio/s3": {
+            "post": {
+                "description": "Converts like POST /convert/audio and streams FFmpeg's output into the bucket while it encodes, in multipart parts, so memory stays flat whatever the size. WAV outputs and include_waveform requests are uploaded once encoded. The object is stored when the response arrives; a failed conversion aborts the upload.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Convert audio and store it in S3-compatible storage",
+                "parameters": [
+                    {
+                        "description": "Audio conversion request, plus an upload object (key, key_template, on_collision, public, expires_days, metadata, storage_class)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.AudioRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Audio file when using multipart",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: JSON encoded upload options",
+                        "name": "options",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ConvertUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, upload options or key_template ({hash}, {sha256}, {width} and {height} need the output before it is streamed)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Key taken and on_collision is error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Output larger than S3_MAX_FILE_SIZE (code object_too_large)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Input is an image (code unsupported_input) or output type outside S3_ALLOWED_CONTENT_TYPES (code content_type_not_allowed)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The upload failed (code upload_failed), or the stored object didn't match it with S3_VERIFY_UPLOADS (code upload_verification_failed)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/batch/audio": {
+            "post": {
+                "description": "Processes up to BATCH_MAX_SIZE (default 10) audio conversion jobs concurrently, at most BATCH_CONCURRENCY at a time, each bounded by BATCH_ITEM_TIMEOUT. item_timeout and concurrency can lower these per request; the applied values are returned in X-Batch-Item-Timeout and X-Batch-Concurrency.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "multipart/form-data",
+                    "application/zstd"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Convert a batch of audio payloads",
+                "parameters": [
+                    {
+                        "description": "Batch audio conversion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/whats-convert-api_internal_services.AudioRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deadline of each item, e.g. 30s or 30 (capped at BATCH_ITEM_TIMEOUT)",
+                        "name": "item_timeout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items converted at once (capped at BATCH_CONCURRENCY)",
+                        "name": "concurrency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tar.zst returns every output in one Zstandard-compressed tar with a manifest.json instead of base64 results",
+                        "name": "archive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "multipart/form-data returns a JSON metadata part plus the converted binary part(s)",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg/vips commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)",
+                        "name": "debug_timings",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.BatchAudioResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "The batch ran past BATCH_TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "An item is an image (code unsupported_input)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "An input is longer than MAX_AUDIO_DURATION (code duration_limit_exceeded)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/batch/audio/async": {
+            "post": {
+                "description": "Accepts up to BATCH_ASYNC_MAX_SIZE (default 500) audio conversion requests and answers 202 with a job ID at once. Items are converted in the background, at most BATCH_CONCURRENCY (default MAX_WORKERS) at a time and each bounded by BATCH_ITEM_TIMEOUT, through the same worker pool as synchronous conversions. Poll status_url for per-item progress and fetch each converted item from its result_url; finished jobs are kept for BATCH_JOB_RETENTION. With S3 enabled, a report of every item is uploaded when the job finishes and linked from its status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Start an asynchronous audio batch job",
+                "parameters": [
+                    {
+                        "description": "Batch audio conversion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/whats-convert-api_internal_services.AudioRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deadline of each item, e.g. 30s or 30 (capped at BATCH_ITEM_TIMEOUT)",
+                        "name": "item_timeout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items converted at once (capped at BATCH_CONCURRENCY, else MAX_WORKERS)",
+                        "name": "concurrency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Report uploaded to S3 when the job finishes: jsonl, csv or none (default jsonl for jobs of BATCH_REPORT_MIN_ITEMS items or more)",
+                        "name": "report",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tar.zst also uploads every output to S3 in one Zstandard-compressed tar with a manifest.json when the job finishes",
+                        "name": "archive",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.BatchJobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "BATCH_JOB_MAX_ACTIVE jobs are unfinished (code batch_jobs_busy)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/batch/image": {
+            "post": {
+                "description": "Processes up to BATCH_MAX_SIZE (default 10) image conversion jobs concurrently, at most BATCH_CONCURRENCY at a time, each bounded by BATCH_ITEM_TIMEOUT. item_timeout and concurrency can lower these per request; the applied values are returned in X-Batch-Item-Timeout and X-Batch-Concurrency.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "multipart/form-data",
+                    "application/zstd"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Convert a batch of image payloads",
+                "parameters": [
+                    {
+                        "description": "Batch image conversion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/whats-convert-api_internal_services.ImageRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deadline of each item, e.g. 30s or 30 (capped at BATCH_ITEM_TIMEOUT)",
+                        "name": "item_timeout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items converted at once (capped at BATCH_CONCURRENCY)",
+                        "name": "concurrency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tar.zst returns every output in one Zstandard-compressed tar with a manifest.json instead of base64 results",
+                        "name": "archive",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "multipart/form-data returns a JSON metadata part plus the converted binary part(s)",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg/vips commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)",
+                        "name": "debug_timings",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.BatchImageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "The batch ran past BATCH_TIMEOUT",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "An item is audio (code unsupported_input)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "An image exceeds MAX_IMAGE_MEGAPIXELS (code pixel_limit_exceeded), an output scored below IMAGE_MIN_SSIM/IMAGE_MIN_PSNR (code quality_below_threshold) or can't fit max_file_size_kb (code target_size_unreachable)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/batch/image/async": {
+            "post": {
+                "description": "Accepts up to BATCH_ASYNC_MAX_SIZE (default 500) image conversion requests and answers 202 with a job ID at once. Items are converted in the background, at most BATCH_CONCURRENCY (default MAX_WORKERS) at a time and each bounded by BATCH_ITEM_TIMEOUT, through the same worker pool as synchronous conversions. Poll status_url for per-item progress and fetch each converted item from its result_url; finished jobs are kept for BATCH_JOB_RETENTION. With S3 enabled, a report of every item is uploaded when the job finishes and linked from its status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Start an asynchronous image batch job",
+                "parameters": [
+                    {
+                        "description": "Batch image conversion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/whats-convert-api_internal_services.ImageRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deadline of each item, e.g. 30s or 30 (capped at BATCH_ITEM_TIMEOUT)",
+                        "name": "item_timeout",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items converted at once (capped at BATCH_CONCURRENCY, else MAX_WORKERS)",
+                        "name": "concurrency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Report uploaded to S3 when the job finishes: jsonl, csv or none (default jsonl for jobs of BATCH_REPORT_MIN_ITEMS items or more)",
+                        "name": "report",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "tar.zst also uploads every output to S3 in one Zstandard-compressed tar with a manifest.json when the job finishes",
+                        "name": "archive",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.BatchJobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "BATCH_JOB_MAX_ACTIVE jobs are unfinished (code batch_jobs_busy)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/batch/jobs/{id}": {
+            "get": {
+                "description": "Returns the job's aggregate status and item counts and, per item, its status, error and result_url once converted. Finished jobs link their report: report_url downloads it from the API and report describes the copy uploaded to S3. archive_url and archive do the same for the tar.zst of every output.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Retrieve the progress of an asynchronous batch job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch job identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.BatchJobResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stops items still converting, skips those not started and frees the job's results. Finished jobs are otherwise kept for BATCH_JOB_RETENTION.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Cancel and discard an asynchronous batch job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch job identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.MessageResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/batch/jobs/{id}/archive": {
+            "get": {
+                "description": "Returns a Zstandard-compressed tar holding manifest.json, then one file per converted item named after its index (000.jpg, 001.jpg, …). The manifest has the job's counts and, per item, the report row plus the file name of its output; failed items are listed without one. Answers 409 while the job is running.",
+                "produces": [
+                    "application/zstd"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Download the outputs of a finished asynchronous batch job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch job identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "tar.zst archive",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The job hasn't finished (code batch_job_running)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/batch/jobs/{id}/items/{index}": {
+            "get": {
+                "description": "Answers 200 with the same body as POST /convert/audio or /convert/image once the item is converted, 202 with its progress while it is pending or processing, the synchronous endpoints' error (status and code) if it failed, and 409 if the job was cancelled before it ran.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Retrieve one converted item of an asynchronous batch job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch job identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Item index, from 0",
+                        "name": "index",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Converted audio (audio jobs)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.AudioResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Not converted yet",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.BatchJobItem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "The item ran past its item_timeout (code item_timeout)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The job was cancelled (code item_cancelled)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/batch/jobs/{id}/report": {
+            "get": {
+                "description": "Returns one row per item with its status, input (source URL without its query string, or base64 and its decoded size), detected input format, output MIME type, size and dimensions or duration, result_url, error and code, and conversion time. JSON lines (one object per item) or CSV with a header row. Answers 409 while the job is running.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Download the report of a finished asynchronous batch job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Batch job identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jsonl or csv (default: the format of the uploaded report, else jsonl)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The job hasn't finished (code batch_job_running)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/image": {
+            "post": {
+                "description": "Accepts base64 payloads or multipart uploads and returns a compressed JPEG data URI.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json",
+                    "multipart/form-data",
+                    "image/jpeg",
+                    "image/png",
+                    "image/webp"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Convert image to WhatsApp-optimized JPEG",
+                "parameters": [
+                    {
+                        "description": "Image conversion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.ImageRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image file when using multipart",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: false returns plain base64 instead of a data URI",
+                        "name": "data_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: return a JPEG within max_width/max_height without re-encoding",
+                        "name": "skip_if_compliant",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: return SSIM/PSNR of the output against the input",
+                        "name": "quality_check",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: colour transparent areas are flattened onto, e.g. #ffffff",
+                        "name": "background",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: keep transparency by returning WebP or PNG",
+                        "name": "preserve_alpha",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: also return jpeg_thumbnail, a 72px JPEG preview for WhatsApp messages (JSON and multipart metadata only)",
+                        "name": "generate_thumbnail",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: also return the input's perceptual hash in fingerprint (JSON and multipart metadata only)",
+                        "name": "fingerprint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Multipart only: lower the quality until the output fits in this many KB",
+                        "name": "max_file_size_kb",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Multipart only: enlarge smaller images to at least this width (response sets upscaled)",
+                        "name": "min_width",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Multipart only: enlarge smaller images to at least this height (response sets upscaled)",
+                        "name": "min_height",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: br returns Brotli-compressed plain base64 when that is smaller (response sets compression)",
+                        "name": "compress",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: PRESETS_FILE image preset filling the fields left unset",
+                        "name": "preset",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "binary returns the converted bytes as the response body",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "multipart/form-data returns a JSON metadata part plus the converted binary part(s); image/jpeg, image/png, image/webp or application/octet-stream returns the converted bytes as the body",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg/vips commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)",
+                        "name": "debug_timings",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.ImageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or unknown preset (code unknown_preset)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Input is audio (code unsupported_input)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Image exceeds MAX_IMAGE_MEGAPIXELS (code pixel_limit_exceeded), the output scored below IMAGE_MIN_SSIM/IMAGE_MIN_PSNR (code quality_below_threshold) or can't fit max_file_size_kb (code target_size_unreachable)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/sticker": {
+            "post": {
+                "description": "Fits the image onto a transparent 512x512 canvas and encodes a static WebP of at most 100KB. Setting pack_name, publisher, pack_id or emojis embeds sticker pack metadata in the WebP's EXIF.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Convert image to a WhatsApp sticker",
+                "parameters": [
+                    {
+                        "description": "Sticker conversion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.StickerRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image file when using multipart",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: false returns plain base64 instead of a data URI",
+                        "name": "data_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: EXIF sticker pack identifier",
+                        "name": "pack_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: EXIF sticker pack name",
+                        "name": "pack_name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: EXIF sticker pack publisher",
+                        "name": "publisher",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: comma-separated emojis (up to 3)",
+                        "name": "emojis",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: PRESETS_FILE sticker preset filling the fields left unset",
+                        "name": "preset",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "multipart/form-data returns a JSON metadata part plus the converted binary part",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)",
+                        "name": "debug_timings",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.StickerResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, sticker metadata (code invalid_sticker) or unknown preset (code unknown_preset)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Input is audio (code unsupported_input)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Image exceeds MAX_IMAGE_MEGAPIXELS (code pixel_limit_exceeded) or the sticker can't fit 100KB (code sticker_too_large)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/sticker-pack": {
+            "post": {
+                "description": "Converts 3 to 30 images into 512x512 WebP stickers (at most 100KB each) plus a 96x96 PNG tray icon, and returns a contents.json manifest compatible with WhatsApp sticker pack apps.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Convert images to a WhatsApp sticker pack",
+                "parameters": [
+                    {
+                        "description": "Sticker pack request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.StickerPackRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "multipart/form-data returns a JSON metadata part plus the tray icon and stickers as parts named after their manifest files",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)",
+                        "name": "debug_timings",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.StickerPackResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Pack breaks WhatsApp's rules (code invalid_sticker_pack)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Image exceeds MAX_IMAGE_MEGAPIXELS (code pixel_limit_exceeded) or a sticker can't fit 100KB (code sticker_too_large)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/video": {
+            "post": {
+                "description": "Transcodes MOV, MKV, WebM, AVI and other inputs to H.264 baseline + AAC in a faststart MP4 within the configured size and bitrate caps. Variable frame rate recordings (browser MediaRecorder WebM) are normalized to a constant frame rate and their audio resynced. Requires the \"video\" feature flag.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Convert video to WhatsApp-compatible MP4",
+                "parameters": [
+                    {
+                        "description": "Video conversion request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.VideoRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Video file when using multipart",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Multipart only: false returns plain base64 instead of a data URI",
+                        "name": "data_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Multipart only: max width, capped by VIDEO_MAX_WIDTH",
+                        "name": "max_width",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Multipart only: max height, capped by VIDEO_MAX_HEIGHT",
+                        "name": "max_height",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Multipart only: audio track to keep, counted from 0",
+                        "name": "audio_track",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: whatsapp (default), screencast (larger, 15fps, tuned for text) or a PRESETS_FILE video preset",
+                        "name": "preset",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Multipart only: two-pass encode sized to fit in this many MiB",
+                        "name": "target_size_mb",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "multipart/form-data returns a JSON metadata part plus the converted binary part",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Evaluated against per-key rollouts of the video feature flag",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)",
+                        "name": "debug_timings",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.VideoResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, audio_track out of range (code audio_track_not_found) or unknown preset (code unknown_preset)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video feature not enabled (answered as an unknown endpoint)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Input is audio only (code unsupported_input)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Input too long to fit VIDEO_MAX_OUTPUT_SIZE at a watchable bitrate (code output_size_exceeded) or target_size_mb (code target_size_unreachable)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/convert/video/s3": {
+            "post": {
+                "description": "Converts like POST /convert/video and streams the MP4 from the scratch directory into the bucket in multipart parts, so 100MB+ outputs never sit in memory. faststart writes the index once encoding ends, so the upload starts then. Requires the \"video\" feature flag.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Convert video and store it in S3-compatible storage",
+                "parameters": [
+                    {
+                        "description": "Video conversion request, plus an upload object (key, key_template, on_collision, public, expires_days, metadata, storage_class)",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.VideoRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Video file when using multipart",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Multipart only: JSON encoded upload options",
+                        "name": "options",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Evaluated against per-key rollouts of the video feature flag",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ConvertUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, upload options or key_template ({hash}, {sha256}, {width} and {height} need the output before it is streamed)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Video feature not enabled (answered as an unknown endpoint)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Key taken and on_collision is error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Output larger than S3_MAX_FILE_SIZE (code object_too_large)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Input is audio only (code unsupported_input) or output type outside S3_ALLOWED_CONTENT_TYPES (code content_type_not_allowed)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The upload failed (code upload_failed), or the stored object didn't match it with S3_VERIFY_UPLOADS (code upload_verification_failed)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/debug/replay/{id}": {
+            "post": {
+                "description": "Re-runs the conversion of an input retained by RETAIN_FAILED_SOURCES with the original options and a forced command trace.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Debug"
+                ],
+                "summary": "Replay a retained failed conversion",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "source_id from the failed conversion response",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "SOURCE_REPLAY_TOKEN",
+                        "name": "X-Replay-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ReplayResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Returns aggregated success metrics for audio and image converters, and whether the service is read-only (READ_ONLY).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Monitoring"
+                ],
+                "summary": "Service health snapshot",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/inspect": {
+            "post": {
+                "description": "Runs ffprobe on a base64, URL or multipart input and returns its container, codecs, duration, resolution, bitrate, channels and rotation. Useful to validate inputs before converting them; inputs are limited to MAX_VIDEO_SIZE.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Inspect media without converting it",
+                "parameters": [
+                    {
+                        "description": "Media inspection request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.InspectRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Media file when using multipart",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed ffprobe commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)",
+                        "name": "debug_timings",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MediaInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "ffprobe can't read the input (code unrecognized_media)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/inspect/fingerprint": {
+            "post": {
+                "description": "Returns a perceptual hash of a base64, URL or multipart input: a 64-bit pHash for images, or a 32-bit chromaprint SimHash of up to two minutes of audio with the raw sub-fingerprints. Copies of the same media that were recompressed, resized or converted differ by few bits, so compare stored hashes of the same algorithm by Hamming distance (about 10 bits or fewer for phash, 6 for chromaprint). sha256 matches byte-identical files. Inputs are limited to MAX_VIDEO_SIZE.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Conversion"
+                ],
+                "summary": "Fingerprint media to detect resent copies",
+                "parameters": [
+                    {
+                        "description": "Media fingerprint request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.InspectRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "Media file when using multipart",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return executed commands (requires ENABLE_COMMAND_TRACE)",
+                        "name": "X-Debug-Trace",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Fingerprint"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unrecognized input (code unrecognized_media), or video and other media that can't be fingerprinted (code fingerprint_unsupported)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "fpcalc (chromaprint) is not installed (code fingerprint_unavailable)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/{key}": {
+            "get": {
+                "description": "Fetches an original from S3, converts it to WhatsApp-ready Opus or JPEG (optionally resized) and returns the bytes. Renditions are cached in memory and carry an ETag for conditional requests.",
+                "produces": [
+                    "audio/ogg",
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "Convert a stored object on read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key (may contain slashes)",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "opus or jpeg (default: from the object's content type)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum width in pixels (images)",
+                        "name": "w",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum height in pixels (images)",
+                        "name": "h",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "JPEG quality 1-100 (default 95)",
+                        "name": "q",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/presets": {
+            "get": {
+                "description": "Lists the presets defined in PRESETS_FILE. Send a preset's name in the preset field of a conversion of its type (audio, image, video or sticker): the fields the request leaves unset are taken from the preset's options, fields the request sets win.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "List conversion presets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.PresetsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/samples": {
+            "get": {
+                "description": "Lists the tiny embedded files served by GET /samples/{type}, with the conversion endpoint each one suits.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "List sample media",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.SamplesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/samples/{type}": {
+            "get": {
+                "description": "Returns a tiny embedded file for trying the API end-to-end without test files of your own: mp3 (1s of silence, for /convert/audio), jpeg (160×120 gradient, for /convert/image and /convert/sticker) or webm (1s of Opus silence, as browsers record voice notes, for /convert/audio). ?encoding=base64 wraps it in JSON with a data URI ready to paste into a conversion request.",
+                "produces": [
+                    "audio/mpeg",
+                    "image/jpeg",
+                    "audio/webm",
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "Download sample media",
+                "parameters": [
+                    {
+                        "enum": [
+                            "mp3",
+                            "jpeg",
+                            "webm"
+                        ],
+                        "type": "string",
+                        "description": "Sample type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "base64"
+                        ],
+                        "type": "string",
+                        "description": "base64 returns JSON with a data URI instead of the file",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown type (code sample_not_found)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats": {
+            "get": {
+                "description": "Exposes raw converter counters for observability integrations.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Monitoring"
+                ],
+                "summary": "Converter statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.StatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3": {
+            "post": {
+                "description": "Accepts large media as multipart form-data and dispatches asynchronous upload jobs.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Start multipart upload to S3-compatible storage",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Binary file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON encoded upload options",
+                        "name": "options",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, key_template or on_collision",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Key taken and on_collision is error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "All upload slots, or the API key's share of them, are in use",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The bucket couldn't be checked for collisions",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/base64": {
+            "post": {
+                "description": "Accepts pre-encoded data URIs and dispatches asynchronous upload jobs.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Start base64 upload to S3-compatible storage",
+                "parameters": [
+                    {
+                        "description": "Base64 upload request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3Base64UploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, key_template or on_collision",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Key taken and on_collision is error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "All upload slots, or the API key's share of them, are in use",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The bucket couldn't be checked for collisions",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/diagnostics": {
+            "post": {
+                "description": "Checks the provider's clock, then performs a signed PUT, GET and DELETE of a small test object in the configured bucket. Each failed step carries the S3 error code and a reason (clock_skew, signature_mismatch, invalid_access_key, access_denied, bucket_not_found, wrong_region, unreachable, timeout, content_mismatch) with a hint naming the setting to check.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Diagnose the S3 configuration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ADMIN_TOKEN",
+                        "name": "X-Admin-Token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Diagnosis; ok is false when any step failed",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.S3Diagnosis"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/health": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "S3 subsystem health",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/list": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "List recent upload jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by upload status (pending|uploading|completed|failed|cancelled)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Maximum number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadListResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/object/{key}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Retrieve object metadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key; may contain slashes (uploads/2024/01/file.jpg)",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_providers.ObjectInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "With S3_SOFT_DELETE the object is moved under S3_TRASH_PREFIX, where POST /upload/s3/object/{key}/restore can bring it back until S3_TRASH_TTL passes. \"?permanent=true\" deletes it outright, as does deleting a key under the trash prefix.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Delete object from storage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key; may contain slashes (uploads/2024/01/file.jpg)",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Skip the trash",
+                        "name": "permanent",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3DeleteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "The provider can't read objects back, which moving them needs",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/object/{key}/expiration": {
+            "put": {
+                "description": "Makes a stored object expire the given number of days after it was stored, or removes its expiration with 0. On AWS and MinIO the object is tagged for a bucket lifecycle rule, added on first use; on Backblaze B2, which can't match tags, the expiry is recorded in the object's metadata by copying it onto itself (so days count from now) and expired objects are deleted by the expiry sweep (S3_EXPIRY_SWEEP_INTERVAL).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Set when an object expires",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key; may contain slashes (uploads/2024/01/file.jpg)",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Days until the object is deleted",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3ExpirationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3ExpirationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "The provider can't expire objects",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/object/{key}/restore": {
+            "post": {
+                "description": "Moves an object deleted with S3_SOFT_DELETE back from the trash to its original key, keeping its content type and metadata.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Restore a soft-deleted object",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Original object key; may contain slashes (uploads/2024/01/file.jpg)",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_providers.ObjectInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not in the trash (code not_in_trash), or soft delete is off",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Another object was stored at the key since (code restore_conflict)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "The trashed copy outlived S3_TRASH_TTL (code trash_expired)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/object/{key}/share": {
+            "post": {
+                "description": "Returns a presigned GET URL that expires after the TTL, so private media can be exposed briefly without changing its ACL. Every grant is written to the audit log with its ID, key, expiry, client IP, request ID and reason.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Share a private object temporarily",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Object key; may contain slashes (uploads/2024/01/file.jpg)",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "TTL and reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3ShareRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3ShareResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "The provider can't presign URLs",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/objects": {
+            "get": {
+                "description": "Lists the objects in the bucket whose keys start with the prefix, in key order, a page at a time. Follow next_cursor until it is absent to walk the whole listing. Objects in the trash are listed under S3_TRASH_PREFIX.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Browse stored objects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only keys starting with this prefix (uploads/2024/)",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Objects per page (1-1000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3ObjectListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/progress/{id}": {
+            "get": {
+                "description": "Upgrades to a WebSocket that sends the upload's state as a JSON services.UploadProgress message right away, then on every progress change, and a last message with the final status (completed, failed or cancelled) before closing normally. Intermediate events are coalesced for slow readers. Anything the client sends is ignored.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Stream upload progress over a WebSocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching protocols; messages are UploadProgress events",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.UploadProgress"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "426": {
+                        "description": "Not a WebSocket upgrade request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/stats": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "S3 provider and upload manager metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3StatsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/status/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Retrieve asynchronous upload status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Cancel an in-flight upload job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/status/{id}/events": {
+            "get": {
+                "description": "For clients that can't use WebSockets. Sends a \"status\" event with the upload's state right away and on every status transition, \"progress\" events as bytes are transferred, and ends the stream after the final status (completed, failed or cancelled). Each event's data is a JSON services.UploadProgress; comment lines keep idle connections open.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Stream upload progress as Server-Sent Events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream of UploadProgress payloads",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.UploadProgress"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/status/{id}/wait": {
+            "get": {
+                "description": "Long-polls until the upload completes, fails or is cancelled. Answers 200 with the final status, or 202 with the current one when the timeout passes first so the caller can poll again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Wait for an asynchronous upload to finish",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "How long to wait, as a duration (30s, 2m) or seconds; default 30s, max 5m",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload finished",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadStatusResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Still pending or uploading",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/upload/s3/url": {
+            "post": {
+                "description": "Downloads the URL on an upload worker and streams it into the bucket as it arrives, so clients don't proxy large files. Answers with an upload ID at once; follow it through /upload/s3/status/{id}. Without content_type the type is sniffed from the first bytes. {hash}, {sha256}, {width} and {height} are refused in key templates, as the content isn't known before the upload starts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "S3"
+                ],
+                "summary": "Start upload of a remote file to S3-compatible storage",
+                "parameters": [
+                    {
+                        "description": "URL upload request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3URLUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, URL, key_template or on_collision, or a URL host on a loopback, private or link-local address",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Key taken and on_collision is error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "All upload slots, or the API key's share of them, are in use",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The bucket couldn't be checked for collisions",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/usage": {
+            "get": {
+                "description": "Conversions and estimated CPU-seconds (user + system time of the FFmpeg/vips runs) per API key since the process started, heaviest first, for billing or throttling heavy users. Callers see their own X-API-Key's usage; X-Admin-Token returns every key's. Keys are reported as the first 12 hex digits of their SHA-256, never in clear.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "Conversion usage per API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key whose usage is returned (anonymous without one)",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ADMIN_TOKEN: return the usage of every key",
+                        "name": "X-Admin-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.UsageReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Identifies exactly what is deployed: release version, git commit, build date, Go version, encoder versions and the feature flags on for the caller.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "Build information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Evaluate per-key feature rollouts for this API key",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.VersionResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces": {
+            "post": {
+                "description": "Opens an empty workspace and answers 201 with its ID. Files added to it can be converted and composed (e.g. a voice note and its cover into a video) and finally committed to S3 together. A workspace left untouched for WORKSPACE_TTL (default 30m) is discarded with its files; every request on it pushes its expires_at back. Workspaces live on this instance's disk and don't survive a restart.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Open a temporary workspace",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Workspace"
+                        }
+                    },
+                    "429": {
+                        "description": "WORKSPACE_MAX_ACTIVE workspaces are open (code workspaces_busy)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Retrieve a workspace and its files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Workspace"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Discard a workspace and its files",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{id}/commit": {
+            "post": {
+                "description": "Uploads the listed files (default: all of them) under prefix, by default WORKSPACE_COMMIT_PREFIX followed by the workspace ID, tagged with the workspace-id metadata. The workspace is then discarded unless keep is set. If an upload fails the workspace stays open and the commit can be retried.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Store workspace files in S3",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Files and storage options",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceCommitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceCommit"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid prefix or empty workspace (code invalid_workspace_request)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "S3 is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "An upload failed",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{id}/compose": {
+            "post": {
+                "description": "Encodes the image as a still picture over the audio file, as an MP4 WhatsApp plays inline (H.264 baseline and AAC, as long as the audio and within VIDEO_MAX_OUTPUT_SIZE), and stores it as another file (by default the audio's name with .mp4). Requires the video feature.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Compose a video from a workspace image and audio file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Audio and image files",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceComposeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "The workspace is full (code workspace_full)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "An input isn't audio or an image (code unsupported_input)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "The audio is too long for the size limit (code output_size_exceeded)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{id}/convert": {
+            "post": {
+                "description": "Converts an audio file to WhatsApp audio or an image to a WhatsApp image, picked from the file's content, and stores the output as another file (by default the input's name with the output's extension). options takes the fields of a POST /convert/audio or /convert/image request other than data. The input is kept.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Convert a workspace file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "File and conversion options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceConvertRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "408": {
+                        "description": "Request Timeout",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "The workspace is full (code workspace_full)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Neither audio nor an image (code unsupported_input)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{id}/files": {
+            "post": {
+                "description": "Stores a file as-is, from a multipart \"file\" field (named by the \"name\" field, or the upload's file name) or a JSON body with base64 data or a URL. A file of the same name is replaced. Names are 1 to 128 letters, digits, '.', '-' or '_' and start with a letter or digit. A workspace holds at most WORKSPACE_MAX_FILES files and WORKSPACE_MAX_SIZE bytes.",
+                "consumes": [
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Add a file to a workspace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "File as base64 or URL",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.WorkspaceFileRequest"
+                        }
+                    },
+                    {
+                        "type": "file",
+                        "description": "File to store (multipart)",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "File name in the workspace (multipart, default: the upload's file name)",
+                        "name": "name",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceFile"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid name or data (code invalid_workspace_request)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "The workspace is full (code workspace_full)",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{id}/files/{name}": {
+            "get": {
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Download a workspace file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "File content, with its detected MIME type",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Remove a file from a workspace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace identifier",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "whats-convert-api_internal_models.APIContact": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "suporte@setupautomatizado.com.br"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Guilherme Jansen"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://github.com/guilhermejansen"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.APIInfoResponse": {
+            "type": "object",
+            "properties": {
+                "api_versions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "/v1"
+                    ]
+                },
+                "contact": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.APIContact"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "High-performance media conversion API delivering WhatsApp-ready audio and images."
+                },
+                "endpoints": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "language": {
+                    "description": "Language of description, also sent as Content-Language",
+                    "type": "string",
+                    "example": "en"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "WhatsApp Media Converter API"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.0.0"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.AudioHealthMetrics": {
+            "type": "object",
+            "properties": {
+                "avg_conversion_time": {
+                    "type": "integer",
+                    "example": 135
+                },
+                "failed_conversions": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "success_rate": {
+                    "type": "string",
+                    "example": "99.06%"
+                },
+                "total_conversions": {
+                    "type": "integer",
+                    "example": 1280
+                }
+            }
+        },
+        "whats-convert-api_internal_models.AuditStampRequest": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "base64 or data URI of the image as found",
+                    "type": "string",
+                    "example": "data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.BatchAudioResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.AudioResponse"
+                    }
+                },
+                "timings": {
+                    "$ref": "#/definitions/whats-convert-api_internal_services.Timings"
+                },
+                "trace": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.BatchImageResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.ImageResponse"
+                    }
+                },
+                "timings": {
+                    "$ref": "#/definitions/whats-convert-api_internal_services.Timings"
+                },
+                "trace": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.BatchJobItem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "duration_limit_exceeded"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 850
+                },
+                "error": {
+                    "type": "string",
+                    "example": "ffmpeg failed: exit status 1"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "result_url": {
+                    "description": "Converted output of a completed item",
+                    "type": "string",
+                    "example": "/convert/batch/jobs/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a/items/0"
+                },
+                "status": {
+                    "description": "pending, processing, completed, failed or cancelled",
+                    "type": "string",
+                    "example": "completed"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.BatchJobResponse": {
+            "type": "object",
+            "properties": {
+                "archive": {
+                    "description": "Copy of the archive uploaded to S3 (archive=tar.zst)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.BatchReport"
+                        }
+                    ]
+                },
+                "archive_url": {
+                    "description": "tar.zst of every output, once the job finished",
+                    "type": "string",
+                    "example": "/convert/batch/jobs/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a/archive"
+                },
+                "cancelled": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "completed": {
+                    "type": "integer",
+                    "example": 40
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-03-31T12:00:00Z"
+                },
+                "end_time": {
+                    "type": "string",
+                    "example": "2024-03-31T12:03:20Z"
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_models.BatchJobItem"
+                    }
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "audio"
+                },
+                "pending": {
+                    "type": "integer",
+                    "example": 150
+                },
+                "processing": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "progress": {
+                    "description": "Percentage of items finished",
+                    "type": "number",
+                    "example": 21
+                },
+                "report": {
+                    "description": "Copy of the report uploaded to S3",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.BatchReport"
+                        }
+                    ]
+                },
+                "report_url": {
+                    "description": "Report of every item, once the job finished",
+                    "type": "string",
+                    "example": "/convert/batch/jobs/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a/report"
+                },
+                "status": {
+                    "description": "queued, running, completed, failed (every item failed) or cancelled",
+                    "type": "string",
+                    "example": "running"
+                },
+                "status_url": {
+                    "type": "string",
+                    "example": "/convert/batch/jobs/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "whats-convert-api_internal_models.CacheStatsResponse": {
+            "type": "object",
+            "properties": {
+                "conversion": {
+                    "description": "Present while the conversion cache is enabled",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.ConversionCacheStats"
+                        }
+                    ]
+                },
+                "media": {
+                    "description": "Present while the convert-on-read endpoint is enabled",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MediaCacheStats"
+                        }
+                    ]
+                },
+                "redis": {
+                    "description": "Present while conversion outputs are shared through Redis",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.RedisStatus"
+                        }
+                    ]
+                },
+                "timestamp": {
+                    "type": "integer",
+                    "example": 1700000000
+                }
+            }
+        },
+        "whats-convert-api_internal_models.CapabilitiesResponse": {
+            "type": "object",
+            "properties": {
+                "features": {
+                    "description": "Features lists the feature flags as evaluated for the caller's API key",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "mock_mode": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "s3_enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "sandbox": {
+                    "$ref": "#/definitions/whats-convert-api_internal_services.SandboxInfo"
+                },
+                "tier": {
+                    "description": "Tier is the service level of the caller's API key (TIERS_FILE, API_KEY_TIERS)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Tier"
+                        }
+                    ]
+                },
+                "tools": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.ConvertUploadResponse": {
+            "type": "object",
+            "properties": {
+                "audio": {
+                    "description": "Conversion metadata (/convert/audio/s3), without data",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.AudioResponse"
+                        }
+                    ]
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "upload": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResult"
+                },
+                "video": {
+                    "description": "Conversion metadata (/convert/video/s3), without data",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.VideoResponse"
+                        }
+                    ]
+                }
+            }
+        },
+        "whats-convert-api_internal_models.ConverterStats": {
+            "type": "object",
+            "properties": {
+                "avg_conversion_time_ms": {
+                    "type": "integer",
+                    "example": 135
+                },
+                "failed_conversions": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "skipped_conversions": {
+                    "type": "integer",
+                    "example": 40
+                },
+                "total_conversions": {
+                    "type": "integer",
+                    "example": 1280
+                },
+                "variants": {
+                    "description": "Per encoder variant (stable, candidate) while a candidate is configured",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/whats-convert-api_internal_models.EncoderVariantStats"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.DownloadStats": {
+            "type": "object",
+            "properties": {
+                "avg_download_time_ms": {
+                    "type": "integer",
+                    "example": 180
+                },
+                "bytes": {
+                    "type": "integer",
+                    "example": 734003200
+                },
+                "circuit_breaker": {
+                    "description": "DOWNLOAD_BREAKER_THRESHOLD \u003e 0",
+                    "type": "boolean",
+                    "example": true
+                },
+                "circuits": {
+                    "description": "Hosts that failed recently, open circuits first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.HostCircuit"
+                    }
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 37
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 2140
+                }
+            }
+        },
+        "whats-convert-api_internal_models.EncoderVariantStats": {
+            "type": "object",
+            "properties": {
+                "avg_conversion_time_ms": {
+                    "description": "Encode time of successful conversions",
+                    "type": "integer",
+                    "example": 120
+                },
+                "avg_output_size": {
+                    "description": "Bytes",
+                    "type": "integer",
+                    "example": 38912
+                },
+                "conversions": {
+                    "type": "integer",
+                    "example": 128
+                },
+                "failed_conversions": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "whats-convert-api_internal_models.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "pixel_limit_exceeded"
+                },
+                "details": {
+                    "type": "string",
+                    "example": "Missing 'data' field"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Invalid request"
+                },
+                "source_id": {
+                    "description": "SourceID identifies the retained input of a failed conversion (RETAIN_FAILED_SOURCES)",
+                    "type": "string",
+                    "example": "3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c"
+                },
+                "trace": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "audio": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.AudioHealthMetrics"
+                },
+                "image": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.ImageHealthMetrics"
+                },
+                "read_only": {
+                    "description": "Conversions and uploads answer 503 (READ_ONLY)",
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "healthy"
+                },
+                "timestamp": {
+                    "type": "integer",
+                    "example": 1700000000
+                }
+            }
+        },
+        "whats-convert-api_internal_models.ImageConverterStats": {
+            "type": "object",
+            "properties": {
+                "avg_conversion_time_ms": {
+                    "type": "integer",
+                    "example": 110
+                },
+                "failed_conversions": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "ffmpeg_conversions": {
+                    "type": "integer",
+                    "example": 360
+                },
+                "skipped_conversions": {
+                    "type": "integer",
+                    "example": 25
+                },
+                "total_conversions": {
+                    "type": "integer",
+                    "example": 980
+                },
+                "vips_conversions": {
+                    "type": "integer",
+                    "example": 620
+                }
+            }
+        },
+        "whats-convert-api_internal_models.ImageHealthMetrics": {
+            "type": "object",
+            "properties": {
+                "avg_conversion_time": {
+                    "type": "integer",
+                    "example": 110
+                },
+                "failed_conversions": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "success_rate": {
+                    "type": "string",
+                    "example": "99.18%"
+                },
+                "total_conversions": {
+                    "type": "integer",
+                    "example": 980
+                },
+                "vips_available": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "whats-convert-api_internal_models.MaintenanceRequest": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "Lift automatically after this duration or seconds (default: until deleted)",
+                    "type": "string",
+                    "example": "30m"
+                },
+                "message": {
+                    "description": "Returned in details of the 503",
+                    "type": "string",
+                    "example": "Video conversions are paused while we investigate elevated failures"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.MaintenanceResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "endpoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.MaintenanceWindow"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Operation completed successfully"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "whats-convert-api_internal_models.MigrationListResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "migrations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.Migration"
+                    }
+                },
+                "providers": {
+                    "description": "Names source and destination accept",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "default",
+                        "r2"
+                    ]
+                }
+            }
+        },
+        "whats-convert-api_internal_models.MigrationRequest": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "description": "Objects copied at once, 1-32 (default 4)",
+                    "type": "integer",
+                    "example": 4
+                },
+                "destination": {
+                    "description": "Provider to write to, named the same way",
+                    "type": "string",
+                    "example": "r2"
+                },
+                "max_bytes_per_second": {
+                    "description": "Read rate across all workers (default: unthrottled)",
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "overwrite": {
+                    "description": "Copy objects the destination already has (default: skip them)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "prefix": {
+                    "description": "Only keys starting with it (default: the whole bucket)",
+                    "type": "string",
+                    "example": "uploads/2024/"
+                },
+                "source": {
+                    "description": "Provider to read from: default (the API's bucket) or a S3_MIGRATION_PROVIDERS name",
+                    "type": "string",
+                    "example": "default"
+                },
+                "transcode": {
+                    "description": "Convert images to WhatsApp JPEG and audio to Opus on the way; other objects are copied",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "whats-convert-api_internal_models.PresetsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "presets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.Preset"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.QueueStats": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Conversions holding a worker",
+                    "type": "integer",
+                    "example": 8
+                },
+                "fair_queuing": {
+                    "description": "Waiting conversions are served fairly across API keys",
+                    "type": "boolean",
+                    "example": true
+                },
+                "waiting": {
+                    "description": "Conversions waiting for one",
+                    "type": "integer",
+                    "example": 12
+                },
+                "waiting_by_tenant": {
+                    "description": "Waiting conversions per API key, keyed like GET /usage",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "workers": {
+                    "type": "integer",
+                    "example": 8
+                }
+            }
+        },
+        "whats-convert-api_internal_models.RecordingsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "recordings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.RecordedRequest"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.RedisStatus": {
+            "type": "object",
+            "properties": {
+                "latency_ms": {
+                    "type": "number",
+                    "example": 0.42
+                },
+                "reachable": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "whats-convert-api_internal_models.ReplayResponse": {
+            "type": "object",
+            "properties": {
+                "audio": {
+                    "$ref": "#/definitions/whats-convert-api_internal_services.AudioResponse"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "image": {
+                    "$ref": "#/definitions/whats-convert-api_internal_services.ImageResponse"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "audio"
+                },
+                "original_error": {
+                    "type": "string",
+                    "example": "conversion failed: ffmpeg error: exit status 1"
+                },
+                "retained_at": {
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string",
+                    "example": "3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c"
+                },
+                "trace": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.RequestReplayResponse": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "application/json"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 412
+                },
+                "fixed": {
+                    "description": "The replay no longer fails",
+                    "type": "boolean",
+                    "example": true
+                },
+                "method": {
+                    "type": "string",
+                    "example": "POST"
+                },
+                "original_code": {
+                    "type": "string",
+                    "example": "conversion_failed"
+                },
+                "original_error": {
+                    "type": "string",
+                    "example": "Conversion failed"
+                },
+                "original_status": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/v1/convert/audio"
+                },
+                "recorded_at": {
+                    "type": "string"
+                },
+                "recording_id": {
+                    "type": "string",
+                    "example": "3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c"
+                },
+                "response": {
+                    "description": "JSON responses only",
+                    "type": "object"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 5342
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "whats-convert-api_internal_models.RuntimeStats": {
+            "type": "object",
+            "properties": {
+                "goroutines": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "heap_alloc_bytes": {
+                    "description": "Live and not yet collected heap objects",
+                    "type": "integer",
+                    "example": 25165824
+                },
+                "heap_objects": {
+                    "type": "integer",
+                    "example": 120000
+                },
+                "num_gc": {
+                    "type": "integer",
+                    "example": 118
+                },
+                "sys_bytes": {
+                    "description": "Memory obtained from the OS",
+                    "type": "integer",
+                    "example": 73400320
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3Base64UploadRequest": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "audio/ogg"
+                },
+                "data": {
+                    "type": "string",
+                    "example": "data:audio/ogg;base64,T2dnUwACAAAAAAAAAAB"
+                },
+                "expires_days": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "voice-note.opus"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "uploads/audio/voice-note.opus"
+                },
+                "key_template": {
+                    "type": "string",
+                    "example": "{date}/{name}-{hash}.{ext}"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "on_collision": {
+                    "type": "string",
+                    "example": "suffix"
+                },
+                "public": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "storage_class": {
+                    "type": "string",
+                    "example": "STANDARD"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3DeleteResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "When the trashed copy is purged",
+                    "type": "string",
+                    "example": "2024-04-07T12:00:00Z"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Object moved to trash"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "trash_key": {
+                    "type": "string",
+                    "example": "trash/uploads/audio/sample.opus"
+                },
+                "trashed": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3ExpirationRequest": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "description": "Days after the object was stored (on Backblaze B2, after now); 0 removes the expiration",
+                    "type": "integer",
+                    "example": 7
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3ExpirationResponse": {
+            "type": "object",
+            "properties": {
+                "expiration_days": {
+                    "type": "integer",
+                    "example": 7
+                },
+                "expires_at": {
+                    "description": "Midnight UTC after the days pass; absent once removed",
+                    "type": "string",
+                    "example": "2024-04-08T00:00:00Z"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "uploads/audio/sample.opus"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3HealthResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "failed to connect to bucket"
+                },
+                "healthy": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "message": {
+                    "type": "string",
+                    "example": "S3 service is operational"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "healthy"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3ObjectListResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "next_cursor": {
+                    "description": "Pass as cursor to fetch the next page; absent on the last one",
+                    "type": "string",
+                    "example": "1VYhLkzmTZ1mJ8Ad9nB3JXG4f1n"
+                },
+                "objects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_providers.ObjectSummary"
+                    }
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "uploads/2024/"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3ServiceStats": {
+            "type": "object",
+            "properties": {
+                "avg_upload_time": {
+                    "type": "string",
+                    "example": "1.2s"
+                },
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "failed_reconnects": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "failed_uploads": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "health_check_failures": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "last_reconnect": {
+                    "type": "string",
+                    "example": "2024-03-31T11:58:00Z"
+                },
+                "last_upload": {
+                    "type": "string",
+                    "example": "2024-03-31T12:00:00Z"
+                },
+                "provider_healthy": {
+                    "description": "Background health checks and automatic reconnects",
+                    "type": "boolean",
+                    "example": true
+                },
+                "reconnects": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "success_rate": {
+                    "type": "number",
+                    "example": 98.33
+                },
+                "successful_uploads": {
+                    "type": "integer",
+                    "example": 236
+                },
+                "total_bytes": {
+                    "type": "integer",
+                    "example": 73400320
+                },
+                "total_uploads": {
+                    "type": "integer",
+                    "example": 240
+                },
+                "verification_failures": {
+                    "description": "Uploads failed after every retry",
+                    "type": "integer",
+                    "example": 0
+                },
+                "verification_mismatches": {
+                    "description": "Stored objects that didn't match, retried or not",
+                    "type": "integer",
+                    "example": 1
+                },
+                "verified_uploads": {
+                    "description": "Upload verification (S3_VERIFY_UPLOADS)",
+                    "type": "integer",
+                    "example": 236
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3ShareRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "Recorded in the audit log",
+                    "type": "string",
+                    "example": "support ticket #4711"
+                },
+                "ttl": {
+                    "description": "Duration or seconds (default S3_SHARE_DEFAULT_TTL, max S3_SHARE_MAX_TTL)",
+                    "type": "string",
+                    "example": "15m"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3ShareResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-03-31T12:15:00Z"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "uploads/audio/sample.opus"
+                },
+                "share_id": {
+                    "description": "Identifies the grant in the audit log",
+                    "type": "string",
+                    "example": "b7e1c7a4-2f4f-4e8e-9a59-3c0f0d1b2a61"
+                },
+                "ttl_seconds": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://bucket.s3.amazonaws.com/uploads/audio/sample.opus?X-Amz-Expires=900\u0026X-Amz-Signature=..."
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3StatsResponse": {
+            "type": "object",
+            "properties": {
+                "s3_service": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.S3ServiceStats"
+                },
+                "upload_manager": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadManagerStats"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3URLUploadRequest": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "description": "Default: sniffed from the first bytes, else the URL's extension",
+                    "type": "string",
+                    "example": "video/mp4"
+                },
+                "expires_days": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "filename": {
+                    "description": "Names the object (default: the URL's last path segment)",
+                    "type": "string",
+                    "example": "video.mp4"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "uploads/video/video.mp4"
+                },
+                "key_template": {
+                    "type": "string",
+                    "example": "{date}/{name}-{uuid}.{ext}"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "on_collision": {
+                    "type": "string",
+                    "example": "suffix"
+                },
+                "public": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "storage_class": {
+                    "type": "string",
+                    "example": "STANDARD"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://cdn.example.com/media/video.mp4"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3UploadListResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "uploads": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadStatusResponse"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3UploadManagerStats": {
+            "type": "object",
+            "properties": {
+                "active_workers": {
+                    "description": "Uploads running now",
+                    "type": "integer",
+                    "example": 1
+                },
+                "avg_upload_ms": {
+                    "description": "Moving average upload time",
+                    "type": "number",
+                    "example": 850
+                },
+                "capacity_used": {
+                    "type": "number",
+                    "example": 33.33
+                },
+                "current_uploads": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "failed_uploads": {
+                    "description": "Uploads that ended in error or cancellation",
+                    "type": "integer",
+                    "example": 0
+                },
+                "max_concurrent": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "queued_uploads": {
+                    "description": "Accepted uploads waiting for a worker",
+                    "type": "integer",
+                    "example": 0
+                },
+                "status_counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "total_uploads": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "workers": {
+                    "description": "Upload pool size (S3_UPLOAD_WORKERS)",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3UploadResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Failed to start upload"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Upload started successfully"
+                },
+                "result": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResult"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "upload_id": {
+                    "type": "string",
+                    "example": "3f99d60f-bd8d-49e6-9ecf-2fbc9e4adffe"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3UploadResult": {
+            "type": "object",
+            "properties": {
+                "etag": {
+                    "type": "string",
+                    "example": "\"9b2cf535f27731c974343645a3985328\""
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-04-01T12:00:00Z"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "uploads/audio/sample.opus"
+                },
+                "processing_time_ms": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "minio"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 7340032
+                },
+                "upload_id": {
+                    "type": "string",
+                    "example": "44c62b0d-7d55-4c74-9f65-8c7ab1f06642"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://cdn.example.com/uploads/audio/sample.opus"
+                },
+                "version_id": {
+                    "type": "string",
+                    "example": "3/L4kqtJlcpXroDTDmJ+rmSpXd3dIbrHY"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.S3UploadStatusResponse": {
+            "type": "object",
+            "properties": {
+                "bytes_transferred": {
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "end_time": {
+                    "type": "string",
+                    "example": "2024-03-31T12:00:10Z"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "connection reset by peer"
+                },
+                "progress": {
+                    "type": "number",
+                    "example": 42.5
+                },
+                "result": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResult"
+                },
+                "source_url": {
+                    "description": "URL uploads only",
+                    "type": "string",
+                    "example": "https://cdn.example.com/media/video.mp4"
+                },
+                "start_time": {
+                    "type": "string",
+                    "example": "2024-03-31T12:00:00Z"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "uploading"
+                },
+                "total_bytes": {
+                    "type": "integer",
+                    "example": 5242880
+                },
+                "upload_id": {
+                    "type": "string",
+                    "example": "3f99d60f-bd8d-49e6-9ecf-2fbc9e4adffe"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.SampleResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Data URI, with ?encoding=base64 only",
+                    "type": "string",
+                    "example": "data:audio/mpeg;base64,//sQxAAAAAAAAAAAAAAAAAAAAAAA"
+                },
+                "endpoint": {
+                    "description": "Conversion endpoint the sample suits",
+                    "type": "string",
+                    "example": "/convert/audio"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "sample.mp3"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "audio/mpeg"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 3952
+                },
+                "type": {
+                    "type": "string",
+                    "example": "mp3"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/samples/mp3"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.SamplesResponse": {
+            "type": "object",
+            "properties": {
+                "samples": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_models.SampleResponse"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "audio": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.ConverterStats"
+                },
+                "conversion_cache": {
+                    "description": "Present while the conversion cache is enabled",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.ConversionCacheStats"
+                        }
+                    ]
+                },
+                "downloads": {
+                    "description": "URL downloads and their hosts' circuit breakers",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.DownloadStats"
+                        }
+                    ]
+                },
+                "image": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.ImageConverterStats"
+                },
+                "post_processors": {
+                    "description": "Present while POST_PROCESSORS_* are set",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.PostProcessorStats"
+                    }
+                },
+                "queue": {
+                    "description": "Conversions holding or waiting for a worker",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.QueueStats"
+                        }
+                    ]
+                },
+                "runtime": {
+                    "description": "Goroutines and heap, for spotting leaks",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.RuntimeStats"
+                        }
+                    ]
+                },
+                "temp_files": {
+                    "description": "Present while spilling or the temp janitor is enabled",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_models.TempFileStats"
+                        }
+                    ]
+                },
+                "timestamp": {
+                    "type": "integer",
+                    "example": 1700000000
+                },
+                "video": {
+                    "$ref": "#/definitions/whats-convert-api_internal_models.VideoConverterStats"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.TempFileStats": {
+            "type": "object",
+            "properties": {
+                "active_spill_bytes": {
+                    "type": "integer",
+                    "example": 73400320
+                },
+                "active_spills": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "over_budget": {
+                    "description": "Payloads kept in memory because the budget was used up",
+                    "type": "integer",
+                    "example": 1
+                },
+                "spill_budget_bytes": {
+                    "description": "0 = unlimited",
+                    "type": "integer",
+                    "example": 2147483648
+                },
+                "spill_dir": {
+                    "type": "string",
+                    "example": "/tmp"
+                },
+                "spill_enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "spill_threshold_bytes": {
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "spilled": {
+                    "description": "Payloads spilled since start",
+                    "type": "integer",
+                    "example": 38
+                },
+                "swept": {
+                    "description": "Leftover temporary files deleted by the janitor",
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "whats-convert-api_internal_models.VersionResponse": {
+            "type": "object",
+            "properties": {
+                "build_date": {
+                    "type": "string",
+                    "example": "2026-10-17T12:00:00Z"
+                },
+                "commit": {
+                    "type": "string",
+                    "example": "af100262a7c1d6e0f1b5b6c2d9f0e8a4c3b2a1d0"
+                },
+                "features": {
+                    "description": "Features lists the feature flags as evaluated for the caller's API key",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "go_version": {
+                    "type": "string",
+                    "example": "go1.25.5"
+                },
+                "modified": {
+                    "description": "Built from a checkout with uncommitted changes",
+                    "type": "boolean",
+                    "example": false
+                },
+                "tool_versions": {
+                    "description": "ToolVersions maps installed encoders to their reported versions",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.3.1"
+                }
+            }
+        },
+        "whats-convert-api_internal_models.VideoConverterStats": {
+            "type": "object",
+            "properties": {
+                "avg_conversion_time_ms": {
+                    "type": "integer",
+                    "example": 8400
+                },
+                "failed_conversions": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total_conversions": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "variants": {
+                    "description": "Per encoder variant (stable, candidate) while a candidate is configured",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/whats-convert-api_internal_models.EncoderVariantStats"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_models.WorkspaceFileRequest": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Base64 content (plain or data URI), or a URL with is_url",
+                    "type": "string",
+                    "example": "data:image/png;base64,iVBORw0KGgo="
+                },
+                "is_url": {
+                    "description": "Download data instead of decoding it",
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "description": "File name in the workspace: letters, digits, '.', '-' or '_'",
+                    "type": "string",
+                    "example": "cover.png"
+                }
+            }
+        },
+        "whats-convert-api_internal_providers.ObjectInfo": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string"
+                },
+                "etag": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_modified": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "storage_class": {
+                    "type": "string"
+                },
+                "version_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "whats-convert-api_internal_providers.ObjectSummary": {
+            "type": "object",
+            "properties": {
+                "etag": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_modified": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "storage_class": {
+                    "type": "string"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.AudioRequest": {
+            "type": "object",
+            "properties": {
+                "compress": {
+                    "description": "Optional: br Brotli-compresses the output before base64 encoding",
+                    "type": "string",
+                    "example": "br"
+                },
+                "data": {
+                    "description": "base64 or URL",
+                    "type": "string",
+                    "example": "data:audio/aac;base64,T2dnUwACAAAAAAAAAAB"
+                },
+                "data_uri": {
+                    "description": "Optional: false returns plain base64 (default true)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "fingerprint": {
+                    "description": "Optional: also return the input's chromaprint, to spot resent audio",
+                    "type": "boolean",
+                    "example": true
+                },
+                "include_waveform": {
+                    "description": "Optional: also return the voice note waveform",
+                    "type": "boolean",
+                    "example": true
+                },
+                "input_type": {
+                    "description": "Deprecated: the format is detected from the content (see input in the response)",
+                    "type": "string",
+                    "example": "mp3"
+                },
+                "is_url": {
+                    "description": "true if data is URL",
+                    "type": "boolean",
+                    "example": false
+                },
+                "normalize": {
+                    "description": "Optional: normalize loudness to the EBU R128 targets (AUDIO_LOUDNORM_*)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "output_format": {
+                    "description": "Optional: opus, mp3 or wav (default opus, mp3 with the reverse preset)",
+                    "type": "string",
+                    "example": "opus"
+                },
+                "preset": {
+                    "description": "Optional: whatsapp (default), reverse for received voice notes, or a PRESETS_FILE audio preset",
+                    "type": "string",
+                    "example": "whatsapp"
+                },
+                "skip_if_compliant": {
+                    "description": "Optional: return mono 48kHz Ogg/Opus input without re-encoding (default SKIP_COMPLIANT_INPUTS)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "source": {
+                    "description": "Optional: object in the configured bucket to convert instead of data",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.InputSource"
+                        }
+                    ]
+                },
+                "target_size_mb": {
+                    "description": "Optional: pick the bitrate so the output fits in this many MiB (Opus and MP3)",
+                    "type": "number",
+                    "example": 16
+                }
+            }
+        },
+        "whats-convert-api_internal_services.AudioResponse": {
+            "type": "object",
+            "properties": {
+                "bitrate": {
+                    "description": "Encoding bitrate in kbit/s chosen for target_size_mb",
+                    "type": "integer",
+                    "example": 96
+                },
+                "cached": {
+                    "description": "Output was served from the conversion cache",
+                    "type": "boolean",
+                    "example": false
+                },
+                "compression": {
+                    "description": "Set when data is Brotli-compressed plain base64",
+                    "type": "string",
+                    "example": "br"
+                },
+                "data": {
+                    "description": "base64 opus audio (data URI unless data_uri is false)",
+                    "type": "string",
+                    "example": "data:audio/ogg;codecs=opus;base64,T2dnUwACAAAA"
+                },
+                "duration": {
+                    "description": "Duration in seconds",
+                    "type": "integer",
+                    "example": 8
+                },
+                "duration_limit_exceeded": {
+                    "description": "Input was longer than MAX_AUDIO_DURATION (flag policy)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "encoder_variant": {
+                    "description": "stable or candidate encoder options, set while AUDIO_CANDIDATE_PERCENT is above 0",
+                    "type": "string",
+                    "example": "stable"
+                },
+                "fingerprint": {
+                    "description": "Chromaprint of the input (fingerprint only)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Fingerprint"
+                        }
+                    ]
+                },
+                "input": {
+                    "description": "Format detected from the input's content",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MediaType"
+                        }
+                    ]
+                },
+                "mime_type": {
+                    "description": "MIME type of the decoded data (audio/mpeg or audio/wav for those output formats)",
+                    "type": "string",
+                    "example": "audio/ogg;codecs=opus"
+                },
+                "size": {
+                    "description": "Size in bytes",
+                    "type": "integer",
+                    "example": 42144
+                },
+                "skipped": {
+                    "description": "Input was already compliant and returned without re-encoding",
+                    "type": "boolean",
+                    "example": false
+                },
+                "timings": {
+                    "description": "Time spent per stage (debug_timings only)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Timings"
+                        }
+                    ]
+                },
+                "trace": {
+                    "description": "External commands executed (debug trace only)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
+                },
+                "waveform": {
+                    "description": "Plain base64 of 64 amplitudes from 0 to 100, for WhatsApp's voice note waveform (include_waveform only)",
+                    "type": "string",
+                    "example": "AAULEBkhKjQ8RExUW2JocHd9g4mPlZuhpqu"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.AuditStamp": {
+            "type": "object",
+            "properties": {
+                "issued_at": {
+                    "description": "When the image was converted",
+                    "type": "string",
+                    "example": "2024-03-31T12:03:20Z"
+                },
+                "reference": {
+                    "description": "Request ID, or batch job ID and item index",
+                    "type": "string",
+                    "example": "0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a"
+                },
+                "tenant": {
+                    "description": "TenantID of the caller's API key",
+                    "type": "string",
+                    "example": "key_3f2a9c1b7d4e"
+                },
+                "text": {
+                    "description": "The stamp as embedded",
+                    "type": "string",
+                    "example": "wca-audit/1 tenant=key_3f2a9c1b7d4e ref=... sig=..."
+                },
+                "valid": {
+                    "description": "Signed with this deployment's AUDIT_STAMP_SECRET",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "whats-convert-api_internal_services.BatchReport": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "When a presigned url stops working",
+                    "type": "string",
+                    "example": "2024-03-31T13:03:20Z"
+                },
+                "format": {
+                    "type": "string",
+                    "example": "jsonl"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "batch-reports/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a.jsonl"
+                },
+                "status": {
+                    "description": "uploading, uploaded or failed",
+                    "type": "string",
+                    "example": "uploaded"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://bucket.s3.amazonaws.com/batch-reports/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a.jsonl?X-Amz-Signature=..."
+                }
+            }
+        },
+        "whats-convert-api_internal_services.CommandRecord": {
+            "type": "object",
+            "properties": {
+                "command": {
+                    "type": "string",
+                    "example": "ffmpeg -hide_banner -loglevel error -i pipe:0 -c:a libopus pipe:1"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "exit_code": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "stderr_tail": {
+                    "type": "string",
+                    "example": "pipe:0: Invalid data found when processing input"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.ConversionCacheStats": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "description": "Memory used by those outputs",
+                    "type": "integer",
+                    "example": 5242880
+                },
+                "entries": {
+                    "description": "Outputs held in memory",
+                    "type": "integer",
+                    "example": 42
+                },
+                "hit_rate": {
+                    "description": "hits / (hits + misses)",
+                    "type": "number",
+                    "example": 0.24
+                },
+                "hits": {
+                    "description": "Conversions answered from the cache",
+                    "type": "integer",
+                    "example": 120
+                },
+                "max_bytes": {
+                    "description": "CONVERSION_CACHE_SIZE",
+                    "type": "integer",
+                    "example": 67108864
+                },
+                "max_object_size": {
+                    "description": "CONVERSION_CACHE_MAX_OBJECT_SIZE",
+                    "type": "integer",
+                    "example": 8388608
+                },
+                "misses": {
+                    "description": "Conversions that ran",
+                    "type": "integer",
+                    "example": 380
+                },
+                "oversized": {
+                    "description": "Outputs over max_object_size, not cached",
+                    "type": "integer",
+                    "example": 3
+                },
+                "redis": {
+                    "description": "Outputs are shared through Redis",
+                    "type": "boolean",
+                    "example": false
+                },
+                "redis_errors": {
+                    "description": "Failed Redis lookups and writes",
+                    "type": "integer",
+                    "example": 0
+                },
+                "redis_hits": {
+                    "description": "Hits found in Redis but not in memory",
+                    "type": "integer",
+                    "example": 0
+                },
+                "ttl_seconds": {
+                    "description": "CONVERSION_CACHE_TTL (0 = until evicted)",
+                    "type": "number",
+                    "example": 3600
+                }
+            }
+        },
+        "whats-convert-api_internal_services.Fingerprint": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "description": "phash (images) or chromaprint (audio)",
+                    "type": "string",
+                    "example": "phash"
+                },
+                "duration": {
+                    "description": "Seconds of audio fingerprinted",
+                    "type": "number",
+                    "example": 8.2
+                },
+                "hash": {
+                    "description": "Hex perceptual hash: 64 bits for phash, 32 for chromaprint",
+                    "type": "string",
+                    "example": "c3a1e0f0b8d89c8e"
+                },
+                "kind": {
+                    "description": "image or audio",
+                    "type": "string",
+                    "example": "image"
+                },
+                "raw": {
+                    "description": "Chromaprint sub-fingerprints, for precise comparison (POST /inspect/fingerprint only)",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "sha256": {
+                    "description": "Of the input bytes",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.HostCircuit": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "description": "Consecutive failed downloads",
+                    "type": "integer",
+                    "example": 5
+                },
+                "host": {
+                    "type": "string",
+                    "example": "cdn.example.com"
+                },
+                "opened_at": {
+                    "description": "When the circuit last opened",
+                    "type": "string"
+                },
+                "rejected": {
+                    "description": "Downloads refused while open",
+                    "type": "integer",
+                    "example": 37
+                },
+                "retry_at": {
+                    "description": "When an open circuit lets a probe through",
+                    "type": "string",
+                    "example": "2024-03-31T12:03:50Z"
+                },
+                "state": {
+                    "description": "closed, open or half_open",
+                    "type": "string",
+                    "example": "open"
+                },
+                "trips": {
+                    "description": "Times the circuit opened",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "whats-convert-api_internal_services.ImageMetadata": {
+            "type": "object",
+            "properties": {
+                "exif": {
+                    "description": "The input had EXIF",
+                    "type": "boolean",
+                    "example": true
+                },
+                "gps": {
+                    "description": "The input's EXIF had a GPS location",
+                    "type": "boolean",
+                    "example": true
+                },
+                "gps_removed": {
+                    "description": "The input had a GPS location and the output doesn't",
+                    "type": "boolean",
+                    "example": true
+                },
+                "policy": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MetadataPolicy"
+                        }
+                    ],
+                    "example": "strip_gps_only"
+                },
+                "retained": {
+                    "description": "EXIF was written to the output",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "whats-convert-api_internal_services.ImageRequest": {
+            "type": "object",
+            "properties": {
+                "background": {
+                    "description": "Optional: colour transparent areas are flattened onto (default IMAGE_BACKGROUND)",
+                    "type": "string",
+                    "example": "#ffffff"
+                },
+                "compress": {
+                    "description": "Optional: br Brotli-compresses the output before base64 encoding",
+                    "type": "string",
+                    "example": "br"
+                },
+                "data": {
+                    "description": "base64 or URL",
+                    "type": "string",
+                    "example": "data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD"
+                },
+                "data_uri": {
+                    "description": "Optional: false returns plain base64 (default true)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "fingerprint": {
+                    "description": "Optional: also return the input's perceptual hash, to spot resent images",
+                    "type": "boolean",
+                    "example": true
+                },
+                "generate_thumbnail": {
+                    "description": "Optional: also return jpeg_thumbnail, the message preview",
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_url": {
+                    "description": "true if data is URL",
+                    "type": "boolean",
+                    "example": false
+                },
+                "max_file_size_kb": {
+                    "description": "Optional: lower the quality until the output fits in this many KB",
+                    "type": "integer",
+                    "example": 500
+                },
+                "max_height": {
+                    "description": "Optional: max height (default 1920)",
+                    "type": "integer",
+                    "example": 1920
+                },
+                "max_width": {
+                    "description": "Optional: max width (default 1920)",
+                    "type": "integer",
+                    "example": 1920
+                },
+                "min_height": {
+                    "description": "Optional: enlarge smaller inputs to at least this height",
+                    "type": "integer",
+                    "example": 640
+                },
+                "min_width": {
+                    "description": "Optional: enlarge smaller inputs to at least this width",
+                    "type": "integer",
+                    "example": 640
+                },
+                "preserve_alpha": {
+                    "description": "Optional: keep transparency by returning WebP or PNG (ALPHA_OUTPUT_FORMAT)",
+                    "type": "boolean",
+                    "example": false
+                },
+                "preset": {
+                    "description": "Optional: PRESETS_FILE image preset filling the fields left unset",
+                    "type": "string",
+                    "example": "whatsapp_image"
+                },
+                "quality": {
+                    "description": "Optional: JPEG quality 1-100 (default 95)",
+                    "type": "integer",
+                    "example": 90
+                },
+                "quality_check": {
+                    "description": "Optional: return SSIM/PSNR of the output against the input (default IMAGE_QUALITY_CHECK)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "skip_if_compliant": {
+                    "description": "Optional: return JPEG input within bounds without re-encoding (default SKIP_COMPLIANT_INPUTS)",
+                    "type": "boolean",
+                    "example": true
+                },
+                "source": {
+                    "description": "Optional: object in the configured bucket to convert instead of data",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.InputSource"
+                        }
+                    ]
+                }
+            }
+        },
+        "whats-convert-api_internal_services.ImageResponse": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "description": "Output was served from the conversion cache",
+                    "type": "boolean",
+                    "example": false
+                },
+                "compression": {
+                    "description": "Set when data is Brotli-compressed plain base64",
+                    "type": "string",
+                    "example": "br"
+                },
+                "data": {
+                    "description": "base64 jpeg image (data URI unless data_uri is false)",
+                    "type": "string",
+                    "example": "data:image/jpeg;base64,/9j/4AAQSkZJRgABA"
+                },
+                "encoded_quality": {
+                    "description": "Quality the output was encoded at (max_file_size_kb only)",
+                    "type": "integer",
+                    "example": 72
+                },
+                "fingerprint": {
+                    "description": "Perceptual hash of the input (fingerprint only)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Fingerprint"
+                        }
+                    ]
+                },
+                "height": {
+                    "description": "Image height",
+                    "type": "integer",
+                    "example": 600
+                },
+                "input": {
+                    "description": "Format detected from the input's content",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MediaType"
+                        }
+                    ]
+                },
+                "jpeg_thumbnail": {
+                    "description": "Plain base64 JPEG of at most 72px per side and 20KB, for WhatsApp's jpegThumbnail (generate_thumbnail only)",
+                    "type": "string",
+                    "example": "/9j/4AAQSkZJRgABAQAAAQABAAD"
+                },
+                "metadata": {
+                    "description": "What IMAGE_METADATA_POLICY did with the input's EXIF",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.ImageMetadata"
+                        }
+                    ]
+                },
+                "mime_type": {
+                    "description": "MIME type of the decoded data (image/webp or image/png with preserve_alpha)",
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "quality": {
+                    "description": "Similarity to the input when quality checking is on",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.QualityScore"
+                        }
+                    ]
+                },
+                "size": {
+                    "description": "Size in bytes",
+                    "type": "integer",
+                    "example": 20480
+                },
+                "skipped": {
+                    "description": "Input was already compliant and returned without re-encoding",
+                    "type": "boolean",
+                    "example": false
+                },
+                "timings": {
+                    "description": "Time spent per stage (debug_timings only)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Timings"
+                        }
+                    ]
+                },
+                "trace": {
+                    "description": "External commands executed (debug trace only)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
+                },
+                "upscaled": {
+                    "description": "Input was enlarged to reach min_width/min_height",
+                    "type": "boolean",
+                    "example": false
+                },
+                "width": {
+                    "description": "Image width",
+                    "type": "integer",
+                    "example": 800
+                }
+            }
+        },
+        "whats-convert-api_internal_services.InputSource": {
+            "type": "object",
+            "properties": {
+                "s3_key": {
+                    "description": "Key of the object to convert",
+                    "type": "string",
+                    "example": "uploads/2024/01/voice.ogg"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.InspectRequest": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "base64 or URL",
+                    "type": "string",
+                    "example": "data:video/mp4;base64,AAAAIGZ0eXBpc29tAAACAGlzb20"
+                },
+                "is_url": {
+                    "description": "true if data is URL",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "whats-convert-api_internal_services.MaintenanceWindow": {
+            "type": "object",
+            "properties": {
+                "endpoint": {
+                    "type": "string",
+                    "example": "/convert/video"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Video conversions are paused while we investigate elevated failures"
+                },
+                "since": {
+                    "type": "string",
+                    "example": "2024-03-31T12:00:00Z"
+                },
+                "until": {
+                    "type": "string",
+                    "example": "2024-03-31T12:30:00Z"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.MediaCacheStats": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "type": "integer"
+                },
+                "entries": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "max_bytes": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.MediaInfo": {
+            "type": "object",
+            "properties": {
+                "audio_codec": {
+                    "description": "Codec of the default audio stream",
+                    "type": "string",
+                    "example": "aac"
+                },
+                "bitrate": {
+                    "description": "Overall bitrate in kbit/s",
+                    "type": "integer",
+                    "example": 2015
+                },
+                "channels": {
+                    "description": "Channels of the default audio stream",
+                    "type": "integer",
+                    "example": 2
+                },
+                "container": {
+                    "description": "ffprobe format name",
+                    "type": "string",
+                    "example": "mov,mp4,m4a,3gp,3g2,mj2"
+                },
+                "duration": {
+                    "description": "Seconds (absent for still images)",
+                    "type": "number",
+                    "example": 12.48
+                },
+                "height": {
+                    "description": "Coded height of the main video stream",
+                    "type": "integer",
+                    "example": 1080
+                },
+                "kind": {
+                    "description": "audio, image, video or unknown",
+                    "type": "string",
+                    "example": "video"
+                },
+                "mime": {
+                    "description": "Detected from the content",
+                    "type": "string",
+                    "example": "video/mp4"
+                },
+                "rotation": {
+                    "description": "Clockwise degrees players rotate the video by (0, 90, 180 or 270)",
+                    "type": "integer",
+                    "example": 90
+                },
+                "sample_rate": {
+                    "description": "Sample rate in Hz of the default audio stream",
+                    "type": "integer",
+                    "example": 48000
+                },
+                "size": {
+                    "description": "Bytes",
+                    "type": "integer",
+                    "example": 3145728
+                },
+                "streams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.StreamInfo"
+                    }
+                },
+                "timings": {
+                    "description": "Time spent per stage (debug_timings only)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Timings"
+                        }
+                    ]
+                },
+                "trace": {
+                    "description": "External commands executed (debug trace only)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
+                },
+                "video_codec": {
+                    "description": "Codec of the main video stream",
+                    "type": "string",
+                    "example": "h264"
+                },
+                "width": {
+                    "description": "Coded width of the main video stream",
+                    "type": "integer",
+                    "example": 1920
+                }
+            }
+        },
+        "whats-convert-api_internal_services.MediaType": {
+            "type": "object",
+            "properties": {
+                "codec": {
+                    "description": "Main stream codec, when the header names it",
+                    "type": "string",
+                    "example": "opus"
+                },
+                "container": {
+                    "type": "string",
+                    "example": "ogg"
+                },
+                "kind": {
+                    "description": "audio, image, video or unknown",
+                    "type": "string",
+                    "example": "audio"
+                },
+                "mime": {
+                    "type": "string",
+                    "example": "audio/ogg"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.MetadataPolicy": {
+            "type": "string",
+            "enum": [
+                "strip_all",
+                "strip_gps_only",
+                "retain"
+            ],
+            "x-enum-comments": {
+                "MetadataRetain": "EXIF kept as is, GPS included",
+                "MetadataStripAll": "No EXIF, XMP or comments in the output",
+                "MetadataStripGPS": "EXIF kept with its GPS tags erased; XMP dropped, as it may repeat them"
+            },
+            "x-enum-descriptions": [
+                "No EXIF, XMP or comments in the output",
+                "EXIF kept with its GPS tags erased; XMP dropped, as it may repeat them",
+                "EXIF kept as is, GPS included"
+            ],
+            "x-enum-varnames": [
+                "MetadataStripAll",
+                "MetadataStripGPS",
+                "MetadataRetain"
+            ]
+        },
+        "whats-convert-api_internal_services.Migration": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "destination": {
+                    "type": "string",
+                    "example": "r2"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Why a failed migration stopped",
+                    "type": "string"
+                },
+                "failures": {
+                    "description": "First 100 objects that failed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.MigrationFailure"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5d1f3c9e-8a0b-4d51-9a9e-1f2f5f0b6c1a"
+                },
+                "max_bytes_per_second": {
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "overwrite": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "uploads/2024/"
+                },
+                "progress": {
+                    "$ref": "#/definitions/whats-convert-api_internal_services.MigrationProgress"
+                },
+                "report": {
+                    "description": "Set once the migration completed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MigrationReport"
+                        }
+                    ]
+                },
+                "source": {
+                    "type": "string",
+                    "example": "default"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MigrationStatus"
+                        }
+                    ],
+                    "example": "running"
+                },
+                "transcode": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "whats-convert-api_internal_services.MigrationFailure": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "upload failed: connection reset"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "uploads/2024/01/file.jpg"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.MigrationProgress": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "description": "Bytes written to the destination",
+                    "type": "integer",
+                    "example": 1073741824
+                },
+                "bytes_read": {
+                    "description": "Bytes read from the source",
+                    "type": "integer",
+                    "example": 1100000000
+                },
+                "copied": {
+                    "description": "Copied unchanged",
+                    "type": "integer",
+                    "example": 1100
+                },
+                "failed": {
+                    "description": "See failures",
+                    "type": "integer",
+                    "example": 10
+                },
+                "listed": {
+                    "description": "Source objects found so far",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "skipped": {
+                    "description": "Already in the destination",
+                    "type": "integer",
+                    "example": 30
+                },
+                "transcoded": {
+                    "description": "Converted on the way",
+                    "type": "integer",
+                    "example": 60
+                }
+            }
+        },
+        "whats-convert-api_internal_services.MigrationReport": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "description": "Source objects looked up",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "matched": {
+                    "description": "Present in the destination (with the same size for copies)",
+                    "type": "integer",
+                    "example": 1190
+                },
+                "mismatched": {
+                    "description": "Copies whose size differs from the source",
+                    "type": "integer",
+                    "example": 0
+                },
+                "mismatched_keys": {
+                    "description": "First 100 mismatched source keys",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "version": {
-                    "type": "string",
-                    "example": "1.0.0"
+                "missing": {
+                    "description": "Absent from the destination",
+                    "type": "integer",
+                    "example": 10
+                },
+                "missing_keys": {
+                    "description": "First 100 missing source keys",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ok": {
+                    "description": "Every source object matched",
+                    "type": "boolean",
+                    "example": false
                 }
             }
         },
-        "whats-convert-api_internal_models.AudioHealthMetrics": {
+        "whats-convert-api_internal_services.MigrationStatus": {
+            "type": "string",
+            "enum": [
+                "running",
+                "reconciling",
+                "completed",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-comments": {
+                "MigrationStatusCompleted": "Every object was looked up; some may have failed",
+                "MigrationStatusFailed": "The source couldn't be listed"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "Every object was looked up; some may have failed",
+                "The source couldn't be listed",
+                ""
+            ],
+            "x-enum-varnames": [
+                "MigrationStatusRunning",
+                "MigrationStatusReconciling",
+                "MigrationStatusCompleted",
+                "MigrationStatusFailed",
+                "MigrationStatusCancelled"
+            ]
+        },
+        "whats-convert-api_internal_services.PostProcessorStats": {
             "type": "object",
             "properties": {
-                "avg_conversion_time": {
+                "avg_time_ms": {
                     "type": "integer",
-                    "example": 135
+                    "example": 35
                 },
-                "failed_conversions": {
+                "bytes_in": {
+                    "description": "Size of every output it was given",
                     "type": "integer",
-                    "example": 12
+                    "example": 104857600
                 },
-                "success_rate": {
+                "bytes_saved": {
+                    "description": "Bytes removed by the outputs kept",
+                    "type": "integer",
+                    "example": 9437184
+                },
+                "command": {
+                    "description": "Program run, without its arguments",
                     "type": "string",
-                    "example": "99.06%"
+                    "example": "jpegoptim"
                 },
-                "total_conversions": {
+                "failures": {
+                    "description": "Errors and empty, foreign, truncated or resized outputs; the input was kept",
                     "type": "integer",
-                    "example": 1280
+                    "example": 3
+                },
+                "format": {
+                    "type": "string",
+                    "example": "jpeg"
+                },
+                "improved": {
+                    "description": "Runs whose smaller output was kept",
+                    "type": "integer",
+                    "example": 912
+                },
+                "runs": {
+                    "description": "Outputs passed through it",
+                    "type": "integer",
+                    "example": 980
+                },
+                "saved_percent": {
+                    "description": "BytesSaved as a share of BytesIn",
+                    "type": "number",
+                    "example": 9
                 }
             }
         },
-        "whats-convert-api_internal_models.BatchAudioResponse": {
+        "whats-convert-api_internal_services.Preset": {
             "type": "object",
             "properties": {
-                "count": {
+                "description": {
+                    "type": "string",
+                    "example": "Voice notes as WhatsApp plays them"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "whatsapp_voice"
+                },
+                "options": {
+                    "description": "Request fields the preset sets",
+                    "type": "object"
+                },
+                "type": {
+                    "description": "audio, image, video or sticker",
+                    "type": "string",
+                    "example": "audio"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.QualityScore": {
+            "type": "object",
+            "properties": {
+                "psnr": {
+                    "description": "Peak signal-to-noise ratio in dB (higher is better)",
+                    "type": "number",
+                    "example": 38.45
+                },
+                "ssim": {
+                    "description": "Structural similarity of the luma planes (1 = identical)",
+                    "type": "number",
+                    "example": 0.9712
+                }
+            }
+        },
+        "whats-convert-api_internal_services.RecordedInput": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "type": "string",
+                    "example": "audio/ogg"
+                },
+                "field": {
+                    "description": "JSON path (items.0.data) or multipart field",
+                    "type": "string",
+                    "example": "data"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "voice.ogg"
+                },
+                "sha256": {
+                    "description": "Of the decoded bytes (of the URL for URL inputs)",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "size": {
                     "type": "integer",
-                    "example": 2
+                    "example": 48213
                 },
-                "results": {
+                "url": {
+                    "description": "URL inputs, without query string",
+                    "type": "string",
+                    "example": "https://example.com/media/voice.ogg"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.RecordedRequest": {
+            "type": "object",
+            "properties": {
+                "body_recorded": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Conversion failed"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "conversion_failed"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c"
+                },
+                "inputs": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/whats-convert-api_internal_services.AudioResponse"
+                        "$ref": "#/definitions/whats-convert-api_internal_services.RecordedInput"
                     }
+                },
+                "method": {
+                    "type": "string",
+                    "example": "POST"
+                },
+                "params": {
+                    "type": "object"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "/v1/convert/audio"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "format=binary"
+                },
+                "recorded_at": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "1718000000000000000"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 500
+                },
+                "tenant": {
+                    "description": "Hashed X-API-Key",
+                    "type": "string",
+                    "example": "key_4f2a9c1b7d3e"
                 }
             }
         },
-        "whats-convert-api_internal_models.BatchImageResponse": {
+        "whats-convert-api_internal_services.S3Diagnosis": {
             "type": "object",
             "properties": {
-                "count": {
+                "bucket": {
+                    "type": "string",
+                    "example": "media"
+                },
+                "clock_skew_ms": {
+                    "description": "Provider clock minus ours, from its Date header",
                     "type": "integer",
-                    "example": 2
+                    "example": -1250
                 },
-                "results": {
+                "endpoint": {
+                    "type": "string",
+                    "example": "https://s3.amazonaws.com"
+                },
+                "key": {
+                    "description": "Test object, deleted afterwards",
+                    "type": "string",
+                    "example": "uploads/.diagnostics/5f0c....txt"
+                },
+                "ok": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "path_style": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "aws"
+                },
+                "region": {
+                    "type": "string",
+                    "example": "us-east-1"
+                },
+                "steps": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/whats-convert-api_internal_services.ImageResponse"
+                        "$ref": "#/definitions/whats-convert-api_internal_services.S3DiagnosticStep"
                     }
                 }
             }
         },
-        "whats-convert-api_internal_models.ConverterStats": {
+        "whats-convert-api_internal_services.S3DiagnosticStep": {
             "type": "object",
             "properties": {
-                "avg_conversion_time_ms": {
+                "code": {
+                    "description": "S3 error code, when the provider sent one",
+                    "type": "string",
+                    "example": "SignatureDoesNotMatch"
+                },
+                "duration_ms": {
                     "type": "integer",
-                    "example": 135
+                    "example": 84
                 },
-                "failed_conversions": {
+                "error": {
+                    "type": "string",
+                    "example": "S3 aws upload failed for key 'uploads/.diagnostics/...': api error SignatureDoesNotMatch"
+                },
+                "hint": {
+                    "type": "string",
+                    "example": "S3_SECRET_KEY doesn't match S3_ACCESS_KEY, or S3_REGION/S3_PATH_STYLE differ from what the provider expects"
+                },
+                "name": {
+                    "description": "clock, put, get or delete",
+                    "type": "string",
+                    "example": "put"
+                },
+                "ok": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "signature_mismatch"
+                },
+                "status_code": {
                     "type": "integer",
-                    "example": 12
+                    "example": 403
+                }
+            }
+        },
+        "whats-convert-api_internal_services.SandboxInfo": {
+            "type": "object",
+            "properties": {
+                "dedicated_user": {
+                    "type": "boolean",
+                    "example": false
                 },
-                "total_conversions": {
+                "minimal_environment": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.SandboxMode"
+                        }
+                    ],
+                    "example": "bwrap"
+                },
+                "network_isolated": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "read_only_fs": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "seccomp": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "uid": {
                     "type": "integer",
-                    "example": 1280
+                    "example": 65534
                 }
             }
         },
-        "whats-convert-api_internal_models.ErrorResponse": {
+        "whats-convert-api_internal_services.SandboxMode": {
+            "type": "string",
+            "enum": [
+                "none",
+                "namespaces",
+                "bwrap"
+            ],
+            "x-enum-varnames": [
+                "SandboxNone",
+                "SandboxNamespaces",
+                "SandboxBwrap"
+            ]
+        },
+        "whats-convert-api_internal_services.StickerFile": {
             "type": "object",
             "properties": {
-                "details": {
+                "data": {
+                    "description": "base64 file (data URI unless data_uri is false)",
                     "type": "string",
-                    "example": "Missing 'data' field"
+                    "example": "data:image/webp;base64,UklGRiQA"
                 },
-                "error": {
+                "file_name": {
+                    "description": "Name the manifest refers to",
                     "type": "string",
-                    "example": "Invalid request"
+                    "example": "01.webp"
+                },
+                "mime_type": {
+                    "description": "image/webp for stickers, image/png for the tray icon",
+                    "type": "string",
+                    "example": "image/webp"
+                },
+                "size": {
+                    "description": "Size in bytes",
+                    "type": "integer",
+                    "example": 48210
                 }
             }
         },
-        "whats-convert-api_internal_models.HealthResponse": {
+        "whats-convert-api_internal_services.StickerInput": {
             "type": "object",
             "properties": {
-                "audio": {
-                    "$ref": "#/definitions/whats-convert-api_internal_models.AudioHealthMetrics"
+                "data": {
+                    "description": "base64 or URL",
+                    "type": "string",
+                    "example": "data:image/png;base64,iVBORw0KGgo"
                 },
-                "image": {
-                    "$ref": "#/definitions/whats-convert-api_internal_models.ImageHealthMetrics"
+                "emojis": {
+                    "description": "1 to 3 emojis describing the sticker",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "☕",
+                        "🙂"
+                    ]
                 },
-                "status": {
+                "is_url": {
+                    "description": "true if data is URL",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "whats-convert-api_internal_services.StickerPackEntry": {
+            "type": "object",
+            "properties": {
+                "animated_sticker_pack": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "avoid_cache": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "identifier": {
                     "type": "string",
-                    "example": "healthy"
+                    "example": "coffee_break"
                 },
-                "timestamp": {
-                    "type": "integer",
-                    "example": 1700000000
+                "image_data_version": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "license_agreement_website": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Coffee Break"
+                },
+                "privacy_policy_website": {
+                    "type": "string"
+                },
+                "publisher": {
+                    "type": "string",
+                    "example": "Guilherme Jansen"
+                },
+                "publisher_email": {
+                    "type": "string"
+                },
+                "publisher_website": {
+                    "type": "string"
+                },
+                "stickers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.StickerRecord"
+                    }
+                },
+                "tray_image_file": {
+                    "type": "string",
+                    "example": "tray.png"
+                }
+            }
+        },
+        "whats-convert-api_internal_services.StickerPackManifest": {
+            "type": "object",
+            "properties": {
+                "android_play_store_link": {
+                    "type": "string"
+                },
+                "ios_app_store_link": {
+                    "type": "string"
+                },
+                "sticker_packs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.StickerPackEntry"
+                    }
                 }
             }
         },
-        "whats-convert-api_internal_models.ImageConverterStats": {
+        "whats-convert-api_internal_services.StickerPackRequest": {
             "type": "object",
             "properties": {
-                "avg_conversion_time_ms": {
-                    "type": "integer",
-                    "example": 110
+                "data_uri": {
+                    "description": "Optional: false returns plain base64 (default true)",
+                    "type": "boolean",
+                    "example": true
                 },
-                "failed_conversions": {
-                    "type": "integer",
-                    "example": 8
+                "identifier": {
+                    "description": "Optional: pack identifier (default derived from name)",
+                    "type": "string",
+                    "example": "coffee_break"
                 },
-                "ffmpeg_conversions": {
-                    "type": "integer",
-                    "example": 360
+                "name": {
+                    "description": "Pack name shown in WhatsApp",
+                    "type": "string",
+                    "example": "Coffee Break"
                 },
-                "total_conversions": {
-                    "type": "integer",
-                    "example": 980
+                "publisher": {
+                    "description": "Pack publisher shown in WhatsApp",
+                    "type": "string",
+                    "example": "Guilherme Jansen"
                 },
-                "vips_conversions": {
+                "publisher_email": {
+                    "description": "Optional: copied to the manifest",
+                    "type": "string",
+                    "example": "stickers@example.com"
+                },
+                "publisher_website": {
+                    "description": "Optional: copied to the manifest",
+                    "type": "string",
+                    "example": "https://example.com"
+                },
+                "stickers": {
+                    "description": "3 to 30 images",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.StickerInput"
+                    }
+                },
+                "tray_index": {
+                    "description": "Optional: sticker whose image becomes the tray icon (default 0)",
                     "type": "integer",
-                    "example": 620
+                    "example": 0
                 }
             }
         },
-        "whats-convert-api_internal_models.ImageHealthMetrics": {
+        "whats-convert-api_internal_services.StickerPackResponse": {
             "type": "object",
             "properties": {
-                "avg_conversion_time": {
-                    "type": "integer",
-                    "example": 110
+                "manifest": {
+                    "description": "contents.json for sticker pack apps",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.StickerPackManifest"
+                        }
+                    ]
                 },
-                "failed_conversions": {
-                    "type": "integer",
-                    "example": 8
+                "stickers": {
+                    "description": "512×512 WebP stickers in request order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.StickerFile"
+                    }
                 },
-                "success_rate": {
-                    "type": "string",
-                    "example": "99.18%"
+                "timings": {
+                    "description": "Time spent per stage (debug_timings only)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Timings"
+                        }
+                    ]
                 },
-                "total_conversions": {
-                    "type": "integer",
-                    "example": 980
+                "trace": {
+                    "description": "External commands executed (debug trace only)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
                 },
-                "vips_available": {
-                    "type": "boolean",
-                    "example": true
+                "tray_image": {
+                    "description": "96×96 PNG tray icon",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.StickerFile"
+                        }
+                    ]
                 }
             }
         },
-        "whats-convert-api_internal_models.MessageResponse": {
+        "whats-convert-api_internal_services.StickerRecord": {
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Operation completed successfully"
+                "emojis": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "☕",
+                        "🙂"
+                    ]
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "image_file": {
+                    "type": "string",
+                    "example": "01.webp"
                 }
             }
         },
-        "whats-convert-api_internal_models.S3Base64UploadRequest": {
+        "whats-convert-api_internal_services.StickerRequest": {
             "type": "object",
             "properties": {
-                "content_type": {
-                    "type": "string",
-                    "example": "audio/ogg"
-                },
                 "data": {
+                    "description": "base64 or URL",
                     "type": "string",
-                    "example": "data:audio/ogg;base64,T2dnUwACAAAAAAAAAAB"
-                },
-                "expires_days": {
-                    "type": "integer",
-                    "example": 3
-                },
-                "filename": {
-                    "type": "string",
-                    "example": "voice-note.opus"
+                    "example": "data:image/png;base64,iVBORw0KGgo"
                 },
-                "key": {
-                    "type": "string",
-                    "example": "uploads/audio/voice-note.opus"
+                "data_uri": {
+                    "description": "Optional: false returns plain base64 (default true)",
+                    "type": "boolean",
+                    "example": true
                 },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": {
+                "emojis": {
+                    "description": "Optional: up to 3 emojis stored in the EXIF",
+                    "type": "array",
+                    "items": {
                         "type": "string"
-                    }
+                    },
+                    "example": [
+                        "☕",
+                        "🙂"
+                    ]
                 },
-                "public": {
+                "is_url": {
+                    "description": "true if data is URL",
                     "type": "boolean",
                     "example": false
                 },
-                "storage_class": {
+                "pack_id": {
+                    "description": "Optional: EXIF pack identifier (default derived from pack_name)",
                     "type": "string",
-                    "example": "STANDARD"
+                    "example": "coffee_break"
+                },
+                "pack_name": {
+                    "description": "Optional: EXIF pack name shown in WhatsApp",
+                    "type": "string",
+                    "example": "Coffee Break"
+                },
+                "preset": {
+                    "description": "Optional: PRESETS_FILE sticker preset filling the fields left unset",
+                    "type": "string",
+                    "example": "sticker"
+                },
+                "publisher": {
+                    "description": "Optional: EXIF pack publisher shown in WhatsApp",
+                    "type": "string",
+                    "example": "Guilherme Jansen"
                 }
             }
         },
-        "whats-convert-api_internal_models.S3HealthResponse": {
+        "whats-convert-api_internal_services.StickerResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "cached": {
+                    "description": "Output was served from the conversion cache",
+                    "type": "boolean",
+                    "example": false
+                },
+                "data": {
+                    "description": "base64 WebP (data URI unless data_uri is false)",
                     "type": "string",
-                    "example": "failed to connect to bucket"
+                    "example": "data:image/webp;base64,UklGRiQA"
                 },
-                "healthy": {
+                "height": {
+                    "description": "Sticker height",
+                    "type": "integer",
+                    "example": 512
+                },
+                "metadata": {
+                    "description": "Sticker pack EXIF metadata was embedded",
                     "type": "boolean",
                     "example": true
                 },
-                "message": {
+                "mime_type": {
+                    "description": "MIME type of the decoded data",
                     "type": "string",
-                    "example": "S3 service is operational"
+                    "example": "image/webp"
                 },
-                "status": {
-                    "type": "string",
-                    "example": "healthy"
+                "size": {
+                    "description": "Size in bytes",
+                    "type": "integer",
+                    "example": 48210
+                },
+                "timings": {
+                    "description": "Time spent per stage (debug_timings only)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Timings"
+                        }
+                    ]
+                },
+                "trace": {
+                    "description": "External commands executed (debug trace only)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
+                },
+                "width": {
+                    "description": "Sticker width",
+                    "type": "integer",
+                    "example": 512
                 }
             }
         },
-        "whats-convert-api_internal_models.S3ServiceStats": {
+        "whats-convert-api_internal_services.StreamInfo": {
             "type": "object",
             "properties": {
-                "avg_upload_time": {
+                "bitrate": {
+                    "description": "kbit/s",
+                    "type": "integer",
+                    "example": 1850
+                },
+                "channel_layout": {
                     "type": "string",
-                    "example": "1.2s"
+                    "example": "stereo"
                 },
-                "enabled": {
+                "channels": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "codec": {
+                    "type": "string",
+                    "example": "h264"
+                },
+                "cover_art": {
+                    "description": "Picture attached to an audio file",
+                    "type": "boolean",
+                    "example": false
+                },
+                "default": {
                     "type": "boolean",
                     "example": true
                 },
-                "failed_uploads": {
+                "duration": {
+                    "description": "Seconds",
+                    "type": "number",
+                    "example": 12.48
+                },
+                "frame_rate": {
+                    "type": "number",
+                    "example": 29.97
+                },
+                "height": {
                     "type": "integer",
-                    "example": 4
+                    "example": 1080
                 },
-                "last_upload": {
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "language": {
                     "type": "string",
-                    "example": "2024-03-31T12:00:00Z"
+                    "example": "por"
                 },
-                "success_rate": {
-                    "type": "number",
-                    "example": 98.33
+                "pixel_format": {
+                    "type": "string",
+                    "example": "yuv420p"
                 },
-                "successful_uploads": {
+                "profile": {
+                    "type": "string",
+                    "example": "High"
+                },
+                "rotation": {
                     "type": "integer",
-                    "example": 236
+                    "example": 90
                 },
-                "total_bytes": {
+                "sample_rate": {
                     "type": "integer",
-                    "example": 73400320
+                    "example": 48000
                 },
-                "total_uploads": {
+                "type": {
+                    "description": "video, audio, subtitle, data or attachment",
+                    "type": "string",
+                    "example": "video"
+                },
+                "width": {
                     "type": "integer",
-                    "example": 240
+                    "example": 1920
                 }
             }
         },
-        "whats-convert-api_internal_models.S3StatsResponse": {
+        "whats-convert-api_internal_services.TenantUsage": {
             "type": "object",
             "properties": {
-                "s3_service": {
-                    "$ref": "#/definitions/whats-convert-api_internal_models.S3ServiceStats"
+                "conversions": {
+                    "description": "Conversion requests, failed ones included (a batch or stream counts once)",
+                    "type": "integer",
+                    "example": 1520
                 },
-                "upload_manager": {
-                    "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadManagerStats"
+                "cpu_seconds": {
+                    "description": "Estimated CPU-seconds of the FFmpeg/vips runs",
+                    "type": "number",
+                    "example": 412.37
+                },
+                "last_seen": {
+                    "description": "Last conversion",
+                    "type": "string",
+                    "example": "2026-10-17T09:30:00Z"
+                },
+                "tenant": {
+                    "description": "SHA-256 prefix of the API key, anonymous or other",
+                    "type": "string",
+                    "example": "key_3f9a1c0b27de"
                 }
             }
         },
-        "whats-convert-api_internal_models.S3UploadListResponse": {
+        "whats-convert-api_internal_services.Tier": {
             "type": "object",
             "properties": {
-                "count": {
+                "max_batch_jobs": {
+                    "description": "Unfinished asynchronous batch jobs per API key (0 = BATCH_JOB_MAX_ACTIVE)",
                     "type": "integer",
-                    "example": 1
+                    "example": 5
                 },
-                "uploads": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadStatusResponse"
-                    }
+                "max_file_size": {
+                    "description": "Largest request body in bytes (0 = BODY_LIMIT)",
+                    "type": "integer",
+                    "example": 104857600
+                },
+                "name": {
+                    "type": "string",
+                    "example": "pro"
+                },
+                "priority": {
+                    "description": "Higher tiers get busy workers first",
+                    "type": "integer",
+                    "example": 10
+                },
+                "rate_limit": {
+                    "description": "Requests per minute per API key (0 = unlimited)",
+                    "type": "integer",
+                    "example": 600
+                },
+                "weight": {
+                    "description": "Share of busy workers per API key against keys of the same priority (0 = 1)",
+                    "type": "number",
+                    "example": 2
                 }
             }
         },
-        "whats-convert-api_internal_models.S3UploadManagerStats": {
+        "whats-convert-api_internal_services.Timings": {
             "type": "object",
             "properties": {
-                "capacity_used": {
-                    "type": "number",
-                    "example": 33.33
+                "decode_ms": {
+                    "description": "Decoding base64 inputs",
+                    "type": "integer",
+                    "example": 3
                 },
-                "current_uploads": {
+                "download_ms": {
+                    "description": "Fetching URL or S3 inputs",
                     "type": "integer",
-                    "example": 1
+                    "example": 120
                 },
-                "max_concurrent": {
+                "encode_ms": {
+                    "description": "Converting",
                     "type": "integer",
-                    "example": 3
+                    "example": 240
                 },
-                "status_counts": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "integer"
-                    }
+                "probe_ms": {
+                    "description": "Inspecting inputs and outputs",
+                    "type": "integer",
+                    "example": 15
                 },
-                "total_uploads": {
+                "queue_ms": {
+                    "description": "Waiting for a worker slot",
                     "type": "integer",
-                    "example": 5
+                    "example": 0
+                },
+                "total_ms": {
+                    "description": "Whole conversion, including the untimed steps",
+                    "type": "integer",
+                    "example": 380
+                },
+                "upload_ms": {
+                    "description": "Storing outputs in S3",
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "whats-convert-api_internal_services.UploadProgress": {
+            "type": "object",
+            "properties": {
+                "bytes_transferred": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "progress": {
+                    "type": "number"
+                },
+                "status": {
+                    "$ref": "#/definitions/whats-convert-api_internal_services.UploadStatus"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "total_bytes": {
+                    "type": "integer"
+                },
+                "upload_id": {
+                    "type": "string"
                 }
             }
         },
-        "whats-convert-api_internal_models.S3UploadResponse": {
+        "whats-convert-api_internal_services.UploadStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "uploading",
+                "completed",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "UploadStatusPending",
+                "UploadStatusUploading",
+                "UploadStatusCompleted",
+                "UploadStatusFailed",
+                "UploadStatusCancelled"
+            ]
+        },
+        "whats-convert-api_internal_services.UsageReport": {
             "type": "object",
             "properties": {
-                "error": {
+                "since": {
+                    "description": "Start of the counting period (process start)",
                     "type": "string",
-                    "example": "Failed to start upload"
+                    "example": "2026-10-17T08:00:00Z"
                 },
-                "message": {
-                    "type": "string",
-                    "example": "Upload started successfully"
+                "tenants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.TenantUsage"
+                    }
+                }
+            }
+        },
+        "whats-convert-api_internal_services.VideoRequest": {
+            "type": "object",
+            "properties": {
+                "audio_track": {
+                    "description": "Optional: audio track to keep, counted from 0 (default: the track flagged default, else the first)",
+                    "type": "integer",
+                    "example": 0
                 },
-                "result": {
-                    "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResult"
+                "data": {
+                    "description": "base64 or URL",
+                    "type": "string",
+                    "example": "data:video/quicktime;base64,AAAAFGZ0eXBxdCAgAAAAAHF0ICA"
                 },
-                "success": {
+                "data_uri": {
+                    "description": "Optional: false returns plain base64 (default true)",
                     "type": "boolean",
                     "example": true
                 },
-                "upload_id": {
+                "is_url": {
+                    "description": "true if data is URL",
+                    "type": "boolean",
+                    "example": false
+                },
+                "max_height": {
+                    "description": "Optional: max height, capped by VIDEO_MAX_HEIGHT",
+                    "type": "integer",
+                    "example": 1280
+                },
+                "max_width": {
+                    "description": "Optional: max width, capped by VIDEO_MAX_WIDTH",
+                    "type": "integer",
+                    "example": 1280
+                },
+                "preset": {
+                    "description": "Optional: whatsapp (default), screencast for screen recordings, or a PRESETS_FILE video preset",
                     "type": "string",
-                    "example": "3f99d60f-bd8d-49e6-9ecf-2fbc9e4adffe"
+                    "example": "whatsapp"
+                },
+                "source": {
+                    "description": "Optional: object in the configured bucket to convert instead of data",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.InputSource"
+                        }
+                    ]
+                },
+                "target_size_mb": {
+                    "description": "Optional: two-pass encode sized to fit in this many MiB (capped by VIDEO_MAX_OUTPUT_SIZE)",
+                    "type": "number",
+                    "example": 16
                 }
             }
         },
-        "whats-convert-api_internal_models.S3UploadResult": {
+        "whats-convert-api_internal_services.VideoResponse": {
             "type": "object",
             "properties": {
-                "etag": {
+                "data": {
+                    "description": "base64 mp4 video (data URI unless data_uri is false)",
                     "type": "string",
-                    "example": "\"9b2cf535f27731c974343645a3985328\""
+                    "example": "data:video/mp4;base64,AAAAIGZ0eXBpc29tAAACAGlzb20"
                 },
-                "expires_at": {
-                    "type": "string",
-                    "example": "2024-04-01T12:00:00Z"
+                "duration": {
+                    "description": "Duration in seconds",
+                    "type": "integer",
+                    "example": 12
                 },
-                "key": {
+                "encoder_variant": {
+                    "description": "stable or candidate encoder options, set while VIDEO_CANDIDATE_PERCENT is above 0",
                     "type": "string",
-                    "example": "uploads/audio/sample.opus"
+                    "example": "stable"
                 },
-                "processing_time_ms": {
+                "height": {
+                    "description": "Video height",
                     "type": "integer",
-                    "example": 1200
+                    "example": 720
                 },
-                "provider": {
+                "input": {
+                    "description": "Format detected from the input's content",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.MediaType"
+                        }
+                    ]
+                },
+                "mime_type": {
+                    "description": "MIME type of the decoded data",
                     "type": "string",
-                    "example": "minio"
+                    "example": "video/mp4"
+                },
+                "preset": {
+                    "description": "Preset the video was encoded with",
+                    "type": "string",
+                    "example": "whatsapp"
                 },
                 "size": {
+                    "description": "Size in bytes",
                     "type": "integer",
-                    "example": 7340032
+                    "example": 3145728
                 },
-                "upload_id": {
-                    "type": "string",
-                    "example": "44c62b0d-7d55-4c74-9f65-8c7ab1f06642"
+                "timings": {
+                    "description": "Time spent per stage (debug_timings only)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/whats-convert-api_internal_services.Timings"
+                        }
+                    ]
                 },
-                "url": {
-                    "type": "string",
-                    "example": "https://cdn.example.com/uploads/audio/sample.opus"
+                "trace": {
+                    "description": "External commands executed (debug trace only)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.CommandRecord"
+                    }
                 },
-                "version_id": {
-                    "type": "string",
-                    "example": "3/L4kqtJlcpXroDTDmJ+rmSpXd3dIbrHY"
+                "video_bitrate": {
+                    "description": "Target video bitrate in kbit/s",
+                    "type": "integer",
+                    "example": 1850
+                },
+                "width": {
+                    "description": "Video width",
+                    "type": "integer",
+                    "example": 1280
                 }
             }
         },
-        "whats-convert-api_internal_models.S3UploadStatusResponse": {
+        "whats-convert-api_internal_services.Workspace": {
             "type": "object",
             "properties": {
-                "bytes_transferred": {
-                    "type": "integer",
-                    "example": 1048576
-                },
-                "end_time": {
+                "created_at": {
                     "type": "string",
-                    "example": "2024-03-31T12:00:10Z"
+                    "example": "2024-03-31T12:00:00Z"
                 },
-                "error": {
+                "expires_at": {
+                    "description": "Pushed back by every request on the workspace",
                     "type": "string",
-                    "example": "connection reset by peer"
-                },
-                "progress": {
-                    "type": "number",
-                    "example": 42.5
-                },
-                "result": {
-                    "$ref": "#/definitions/whats-convert-api_internal_models.S3UploadResult"
+                    "example": "2024-03-31T12:30:00Z"
                 },
-                "start_time": {
-                    "type": "string",
-                    "example": "2024-03-31T12:00:00Z"
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceFile"
+                    }
                 },
-                "status": {
+                "id": {
                     "type": "string",
-                    "example": "uploading"
+                    "example": "6f2d3c1a-9b8e-4f7d-a6c5-4b3a2d1e0f9c"
                 },
-                "total_bytes": {
+                "size": {
+                    "description": "Bytes of its files",
                     "type": "integer",
-                    "example": 5242880
-                },
-                "upload_id": {
-                    "type": "string",
-                    "example": "3f99d60f-bd8d-49e6-9ecf-2fbc9e4adffe"
+                    "example": 1048576
                 }
             }
         },
-        "whats-convert-api_internal_models.StatsResponse": {
+        "whats-convert-api_internal_services.WorkspaceCommit": {
             "type": "object",
             "properties": {
-                "audio": {
-                    "$ref": "#/definitions/whats-convert-api_internal_models.ConverterStats"
+                "deleted": {
+                    "description": "The workspace was discarded after the commit",
+                    "type": "boolean",
+                    "example": true
                 },
-                "image": {
-                    "$ref": "#/definitions/whats-convert-api_internal_models.ImageConverterStats"
+                "objects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/whats-convert-api_internal_services.WorkspaceObject"
+                    }
                 },
-                "timestamp": {
-                    "type": "integer",
-                    "example": 1700000000
+                "workspace_id": {
+                    "type": "string",
+                    "example": "6f2d3c1a-9b8e-4f7d-a6c5-4b3a2d1e0f9c"
                 }
             }
         },
-        "whats-convert-api_internal_providers.ObjectInfo": {
+        "whats-convert-api_internal_services.WorkspaceCommitRequest": {
             "type": "object",
             "properties": {
-                "content_type": {
-                    "type": "string"
-                },
-                "etag": {
-                    "type": "string"
-                },
-                "key": {
-                    "type": "string"
-                },
-                "last_modified": {
-                    "type": "string"
+                "expires_days": {
+                    "type": "integer",
+                    "example": 7
                 },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": {
+                "files": {
+                    "description": "Default: every file",
+                    "type": "array",
+                    "items": {
                         "type": "string"
-                    }
+                    },
+                    "example": [
+                        "voice.mp4"
+                    ]
                 },
-                "size": {
-                    "type": "integer"
+                "keep": {
+                    "description": "Keep the workspace open after the commit (default: it is deleted)",
+                    "type": "boolean",
+                    "example": false
                 },
-                "storage_class": {
-                    "type": "string"
+                "prefix": {
+                    "description": "Default: WORKSPACE_COMMIT_PREFIX plus the workspace ID",
+                    "type": "string",
+                    "example": "campaigns/2024-03/"
                 },
-                "version_id": {
-                    "type": "string"
+                "public": {
+                    "type": "boolean",
+                    "example": false
                 }
             }
         },
-        "whats-convert-api_internal_services.AudioRequest": {
+        "whats-convert-api_internal_services.WorkspaceComposeRequest": {
             "type": "object",
             "properties": {
-                "data": {
-                    "description": "base64 or URL",
+                "audio": {
                     "type": "string",
-                    "example": "data:audio/aac;base64,T2dnUwACAAAAAAAAAAB"
+                    "example": "voice.ogg"
                 },
-                "input_type": {
-                    "description": "Optional: mp3, wav, m4a, etc.",
+                "image": {
                     "type": "string",
-                    "example": "mp3"
+                    "example": "cover.jpg"
                 },
-                "is_url": {
-                    "description": "true if data is URL",
-                    "type": "boolean",
-                    "example": false
+                "max_height": {
+                    "description": "Optional: capped by VIDEO_MAX_HEIGHT",
+                    "type": "integer",
+                    "example": 1280
+                },
+                "max_width": {
+                    "description": "Optional: capped by VIDEO_MAX_WIDTH",
+                    "type": "integer",
+                    "example": 1280
+                },
+                "output": {
+                    "description": "Default: the audio file's name with .mp4",
+                    "type": "string",
+                    "example": "voice.mp4"
                 }
             }
         },
-        "whats-convert-api_internal_services.AudioResponse": {
+        "whats-convert-api_internal_services.WorkspaceConvertRequest": {
             "type": "object",
             "properties": {
-                "data": {
-                    "description": "base64 opus audio",
+                "file": {
+                    "description": "Workspace file to convert",
                     "type": "string",
-                    "example": "data:audio/ogg;codecs=opus;base64,T2dnUwACAAAA"
+                    "example": "voice.wav"
                 },
-                "duration": {
-                    "description": "Duration in seconds",
-                    "type": "integer",
-                    "example": 8
+                "options": {
+                    "description": "Fields of a /convert/audio or /convert/image request, without data",
+                    "type": "object"
                 },
-                "size": {
-                    "description": "Size in bytes",
-                    "type": "integer",
-                    "example": 42144
+                "output": {
+                    "description": "Name of the converted file (default: file with the output's extension)",
+                    "type": "string",
+                    "example": "voice.ogg"
                 }
             }
         },
-        "whats-convert-api_internal_services.ImageRequest": {
+        "whats-convert-api_internal_services.WorkspaceFile": {
             "type": "object",
             "properties": {
-                "data": {
-                    "description": "base64 or URL",
+                "created_at": {
                     "type": "string",
-                    "example": "data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD"
+                    "example": "2024-03-31T12:00:00Z"
                 },
-                "is_url": {
-                    "description": "true if data is URL",
-                    "type": "boolean",
-                    "example": false
+                "duration": {
+                    "description": "Converted audio and composed videos, in seconds",
+                    "type": "integer",
+                    "example": 8
                 },
-                "max_height": {
-                    "description": "Optional: max height (default 1920)",
+                "height": {
+                    "description": "Converted images and composed videos",
                     "type": "integer",
-                    "example": 1920
+                    "example": 600
                 },
-                "max_width": {
-                    "description": "Optional: max width (default 1920)",
+                "mime_type": {
+                    "description": "Detected from the content",
+                    "type": "string",
+                    "example": "image/jpeg"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "cover.jpg"
+                },
+                "origin": {
+                    "description": "upload, convert or compose",
+                    "type": "string",
+                    "example": "convert"
+                },
+                "size": {
                     "type": "integer",
-                    "example": 1920
+                    "example": 48213
                 },
-                "quality": {
-                    "description": "Optional: JPEG quality 1-100 (default 95)",
+                "sources": {
+                    "description": "Files it was made from",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "cover.png"
+                    ]
+                },
+                "width": {
+                    "description": "Converted images and composed videos",
                     "type": "integer",
-                    "example": 90
+                    "example": 800
                 }
             }
         },
-        "whats-convert-api_internal_services.ImageResponse": {
+        "whats-convert-api_internal_services.WorkspaceObject": {
             "type": "object",
             "properties": {
-                "data": {
-                    "description": "base64 jpeg image",
+                "content_type": {
                     "type": "string",
-                    "example": "data:image/jpeg;base64,/9j/4AAQSkZJRgABA"
+                    "example": "video/mp4"
                 },
-                "height": {
-                    "description": "Image height",
-                    "type": "integer",
-                    "example": 600
+                "file": {
+                    "type": "string",
+                    "example": "voice.mp4"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "workspaces/6f2d3c1a-9b8e-4f7d-a6c5-4b3a2d1e0f9c/voice.mp4"
                 },
                 "size": {
-                    "description": "Size in bytes",
                     "type": "integer",
-                    "example": 20480
+                    "example": 1048576
                 },
-                "width": {
-                    "description": "Image width",
-                    "type": "integer",
-                    "example": 800
+                "url": {
+                    "type": "string",
+                    "example": "https://bucket.s3.amazonaws.com/workspaces/6f2d3c1a-9b8e-4f7d-a6c5-4b3a2d1e0f9c/voice.mp4"
                 }
             }
         }
@@ -1238,7 +7931,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{},
 	Title:            "WhatsApp Media Converter API",
-	Description:      "High-performance media conversion API delivering WhatsApp-ready audio and images.\n\n**Author:** Guilherme Jansen · [GitHub](https://github.com/guilhermejansen)\n**Contato direto:** suporte@setupautomatizado.com.br\n**Coleção Postman pronta:** [Download](./swagger/postman.json)\n\nUse os exemplos documentados em cada endpoint para testar rapidamente conversões de áudio, imagem e operações S3.",
+	Description:      "High-performance media conversion API delivering WhatsApp-ready audio and images.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -0,0 +1,166 @@
+// Package benchmark reads the output of `go test -bench` and compares its
+// results against a stored baseline for cmd/bench.
+package benchmark
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result is the measured outcome of a benchmark
+type Result struct {
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	MBPerSec    float64 `json:"mb_per_sec,omitempty"`
+}
+
+// Parse reads `go test -bench -benchmem` output and returns the results by
+// name: the package's last path element and the benchmark without its
+// Benchmark prefix and GOMAXPROCS suffix (BenchmarkBufferGetPut-8 in
+// internal/pool is "pool/BufferGetPut"). When a benchmark ran several times
+// (-count), the last run wins.
+func Parse(r io.Reader) (map[string]Result, error) {
+	results := make(map[string]Result)
+	pkg := ""
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, found := strings.CutPrefix(line, "pkg: "); found {
+			pkg = path.Base(strings.TrimSpace(name))
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue // A benchmark's log line, not its result
+		}
+
+		name := strings.TrimPrefix(fields[0], "Benchmark")
+		if i := strings.LastIndexByte(name, '-'); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		if pkg != "" {
+			name = pkg + "/" + name
+		}
+
+		var result Result
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, unit := fields[i], fields[i+1]
+			var err error
+			switch unit {
+			case "ns/op":
+				result.NsPerOp, err = strconv.ParseFloat(value, 64)
+			case "MB/s":
+				result.MBPerSec, err = strconv.ParseFloat(value, 64)
+			case "B/op":
+				result.BytesPerOp, err = strconv.ParseInt(value, 10, 64)
+			case "allocs/op":
+				result.AllocsPerOp, err = strconv.ParseInt(value, 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("parse %s of %s: %w", unit, name, err)
+			}
+		}
+		result.NsPerOp = round2(result.NsPerOp)
+		result.MBPerSec = round2(result.MBPerSec)
+		results[name] = result
+	}
+	return results, scanner.Err()
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Baseline is the stored set of results regressions are measured against
+type Baseline struct {
+	GoVersion string            `json:"go_version"`
+	Platform  string            `json:"platform"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Results   map[string]Result `json:"results"`
+}
+
+// LoadBaseline reads a baseline file
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("parse baseline %s: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+// Save writes the baseline as indented JSON
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Regression describes a metric that got worse than the tolerance allows
+type Regression struct {
+	Name     string
+	Metric   string
+	Baseline float64
+	Current  float64
+}
+
+// Change returns the relative change from the baseline (0.25 = 25% worse)
+func (r Regression) Change() float64 {
+	if r.Baseline == 0 {
+		return 0
+	}
+	return (r.Current - r.Baseline) / r.Baseline
+}
+
+// Compare reports every benchmark whose time or allocations grew beyond tolerance.
+// Benchmarks missing from the baseline are ignored. Allocation counts are compared
+// exactly because they are deterministic; time uses the relative tolerance.
+func Compare(baseline *Baseline, current map[string]Result, tolerance float64) []Regression {
+	var regressions []Regression
+
+	names := make([]string, 0, len(current))
+	for name := range current {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		cur := current[name]
+		base, ok := baseline.Results[name]
+		if !ok {
+			continue
+		}
+
+		if base.NsPerOp > 0 && cur.NsPerOp > base.NsPerOp*(1+tolerance) {
+			regressions = append(regressions, Regression{name, "ns/op", base.NsPerOp, cur.NsPerOp})
+		}
+		if cur.AllocsPerOp > base.AllocsPerOp {
+			regressions = append(regressions, Regression{name, "allocs/op", float64(base.AllocsPerOp), float64(cur.AllocsPerOp)})
+		}
+	}
+
+	return regressions
+}
@@ -0,0 +1,70 @@
+package benchmark
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleOutput = `goos: linux
+goarch: amd64
+pkg: whats-convert-api/internal/pool
+cpu: Intel(R) Xeon(R) CPU
+BenchmarkBufferGetPut-8           	11456789	       102.034 ns/op	      24 B/op	       1 allocs/op
+BenchmarkBufferGetPutParallel     	10000000	       101.28 ns/op	      24 B/op	       1 allocs/op
+PASS
+ok  	whats-convert-api/internal/pool	3.021s
+goos: linux
+goarch: amd64
+pkg: whats-convert-api/internal/services
+BenchmarkBase64Decode1MB-8        	     500	   2432797.881 ns/op	 431.01 MB/s	 1048576 B/op	       1 allocs/op
+BenchmarkConvertImageTiny-8
+    bench_test.go:42: ffmpeg not installed
+--- SKIP: BenchmarkConvertImageTiny-8
+PASS
+`
+
+func TestParse(t *testing.T) {
+	results, err := Parse(strings.NewReader(sampleOutput))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := map[string]Result{
+		"pool/BufferGetPut":         {NsPerOp: 102.03, BytesPerOp: 24, AllocsPerOp: 1},
+		"pool/BufferGetPutParallel": {NsPerOp: 101.28, BytesPerOp: 24, AllocsPerOp: 1},
+		"services/Base64Decode1MB":  {NsPerOp: 2432797.88, BytesPerOp: 1048576, AllocsPerOp: 1, MBPerSec: 431.01},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("results = %v, want %d entries", results, len(want))
+	}
+	for name, expected := range want {
+		if got := results[name]; got != expected {
+			t.Errorf("%s = %+v, want %+v", name, got, expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Baseline{Results: map[string]Result{
+		"pool/Fast":   {NsPerOp: 100, AllocsPerOp: 1},
+		"pool/Slow":   {NsPerOp: 100, AllocsPerOp: 1},
+		"pool/Allocs": {NsPerOp: 100, AllocsPerOp: 1},
+	}}
+	current := map[string]Result{
+		"pool/Fast":   {NsPerOp: 115, AllocsPerOp: 1},
+		"pool/Slow":   {NsPerOp: 130, AllocsPerOp: 1},
+		"pool/Allocs": {NsPerOp: 90, AllocsPerOp: 2},
+		"pool/New":    {NsPerOp: 1000, AllocsPerOp: 10},
+	}
+
+	regressions := Compare(baseline, current, 0.20)
+	if len(regressions) != 2 {
+		t.Fatalf("regressions = %+v, want 2", regressions)
+	}
+	if r := regressions[0]; r.Name != "pool/Allocs" || r.Metric != "allocs/op" {
+		t.Errorf("regressions[0] = %+v", r)
+	}
+	if r := regressions[1]; r.Name != "pool/Slow" || r.Metric != "ns/op" || r.Change() < 0.29 {
+		t.Errorf("regressions[1] = %+v", r)
+	}
+}
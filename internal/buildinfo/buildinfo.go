@@ -0,0 +1,68 @@
+// Package buildinfo identifies the running build. Release builds inject the
+// values with -ldflags, e.g.
+//
+//	go build -ldflags "-X whats-convert-api/internal/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// Builds without them fall back to the VCS stamp Go embeds in binaries built
+// from a git checkout.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set with -ldflags "-X whats-convert-api/internal/buildinfo.<Name>=<value>"
+var (
+	Version string // Release version, e.g. 1.3.1
+	Commit  string // Full git commit hash
+	Date    string // Build time, RFC 3339
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version,omitempty" example:"1.3.1"`
+	Commit    string `json:"commit,omitempty" example:"af100262a7c1d6e0f1b5b6c2d9f0e8a4c3b2a1d0"`
+	BuildDate string `json:"build_date,omitempty" example:"2026-10-17T12:00:00Z"`
+	Modified  bool   `json:"modified,omitempty" example:"false"` // Built from a checkout with uncommitted changes
+	GoVersion string `json:"go_version" example:"go1.25.5"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build's identity; values not injected are read from the
+// embedded VCS stamp, and are empty when neither is available
+func Get() Info {
+	once.Do(func() {
+		info = Info{
+			Version:   Version,
+			Commit:    Commit,
+			BuildDate: Date,
+			GoVersion: runtime.Version(),
+		}
+
+		// The stamp describes the checkout, so it only applies when no commit was injected
+		build, ok := debug.ReadBuildInfo()
+		if !ok || info.Commit != "" {
+			return
+		}
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value // Commit time: close enough for builds from a checkout
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	})
+
+	return info
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -21,49 +22,251 @@ type Config struct {
 	IdleTimeout  time.Duration
 	BodyLimit    int
 
+	// Connection tuning for high-throughput clients
+	DisableKeepalive bool   // Close connections after each response
+	MaxConnections   int    // Concurrent connections served (0 = Fiber default)
+	Prefork          bool   // One listener process per CPU sharing PORT (SO_REUSEPORT)
+	TLSCertFile      string // Serve PORT over HTTPS; required by HTTP/3
+	TLSKeyFile       string
+	HTTP3Enabled     bool   // Also serve HTTP/3 over QUIC
+	HTTP3Port        string // UDP port ("" for PORT)
+	HTTP3MaxStreams  int    // Concurrent requests per HTTP/3 connection
+
 	// Worker pool configuration
 	MaxWorkers          int
+	PriorityWorkers     int
+	PriorityMaxSize     int
+	FairQueuing         bool // Share busy workers fairly between API keys
+	FFmpegThreads       int
 	QueueSizeMultiplier int
 	RequestTimeout      time.Duration
+	AudioTimeout        time.Duration // 0 = RequestTimeout
+	ImageTimeout        time.Duration // 0 = RequestTimeout
+	BatchTimeout        time.Duration // 0 = RequestTimeout per item
+	BatchMaxSize        int           // Most items per batch request
+	BatchItemTimeout    time.Duration // Deadline of each batch item
+	BatchConcurrency    int           // Items of a batch converted at once (0 = all)
+	BatchAsyncMaxSize   int           // Most items per asynchronous batch job
+	BatchJobMaxActive   int           // Unfinished asynchronous batch jobs accepted at once
+	BatchJobRetention   time.Duration // How long finished batch jobs and their results are kept
+	BatchReportMinItems int           // Jobs of at least this many items upload a report to S3 unasked (0 = only on request)
+	BatchReportPrefix   string        // Key prefix of uploaded batch reports
+
+	// Temporary workspaces (/workspaces)
+	WorkspaceTTL          time.Duration // Idle time after which a workspace is discarded
+	WorkspaceMaxActive    int           // Workspaces open at once
+	WorkspaceMaxFiles     int           // Files per workspace
+	WorkspaceMaxSize      int64         // Bytes of files per workspace
+	WorkspaceDir          string        // Parent of workspace directories (empty = system temp dir)
+	WorkspaceCommitPrefix string        // Key prefix of committed files, followed by the workspace ID
+
+	CancelOnDisconnect bool // Stop conversions whose client hung up
+
+	// Slow conversions are logged with per-stage timings (0 = off)
+	SlowRequestThreshold time.Duration
+
+	// Conversions and CPU time are counted per API key for GET /usage
+	UsageTracking bool
 
 	// Buffer pool configuration
 	BufferPoolSize int
 	BufferSize     int
 
 	// HTTP client configuration
-	DownloadTimeout     time.Duration
-	MaxIdleConns        int
-	MaxIdleConnsPerHost int
-	IdleConnTimeout     time.Duration
+	DownloadTimeout  time.Duration
+	DownloadProxyURL string // Overrides HTTP_PROXY/HTTPS_PROXY for downloads; NO_PROXY still applies
+	// Lets downloads reach loopback, private and link-local addresses
+	DownloadAllowPrivateHosts bool
+	// Consecutive failed downloads from a host that open its circuit (0 = off)
+	DownloadBreakerThreshold int
+	DownloadBreakerCooldown  time.Duration // How long an open circuit refuses downloads
+	MaxIdleConns             int
+	MaxIdleConnsPerHost      int
+	IdleConnTimeout          time.Duration
 
 	// Performance tuning
 	GOGC       int
 	GoMemLimit string
 
+	// Failed source retention
+	RetainFailedSources bool
+	SourceRetentionDir  string
+	SourceRetentionTTL  time.Duration
+	SourceRetentionKey  string
+	SourceReplayToken   string
+
+	// Failed request recording
+	RequestRecording            bool
+	RequestRecordingDir         string
+	RequestRecordingTTL         time.Duration
+	RequestRecordingKey         string
+	RequestRecordingMinStatus   int
+	RequestRecordingBodies      bool
+	RequestRecordingMaxBodySize int64
+
+	// Convert-on-read media endpoint
+	MediaCacheSize     int64
+	MediaCacheTTL      time.Duration
+	MediaMaxAge        time.Duration
+	MediaMaxSourceSize int64
+
+	// Shared Redis for replicas (REDIS_URL), the default Redis of the conversion cache
+	RedisURL string
+
+	// Conversion output cache
+	ConversionCacheSize          int64
+	ConversionCacheTTL           time.Duration
+	ConversionCacheMaxObjectSize int
+	ConversionCacheRedisURL      string
+
+	// Feature flags
+	FeatureFlags         string
+	FeatureFlagsFile     string
+	FeatureFlagsRedisURL string
+	FeatureFlagsRedisKey string
+	FeatureFlagsRefresh  time.Duration
+
+	// Maintenance windows (admin API toggles, optionally persisted)
+	MaintenanceFile    string
+	MaintenanceRefresh time.Duration
+
+	// Refuse conversions and uploads, serving only reads (processing tier down)
+	ReadOnly bool
+
+	// Return already compliant inputs without re-encoding
+	SkipCompliantInputs bool
+
+	// Operator-defined conversion presets (YAML or JSON)
+	PresetsFile string
+
+	// API key tiers: priority, rate limit and size limits per key
+	TiersFile   string            // Tier limits and key assignments (YAML or JSON)
+	APIKeyTiers map[string]string // API key -> tier name, over the file's
+	DefaultTier string            // Tier of unassigned keys ("" = the file's, or free)
+
+	// Memory admission control
+	MemoryHighWaterPercent int
+	AdmissionMinBodySize   int
+
 	// Audio conversion settings
 	AudioBitrate          string
 	AudioSampleRate       int
 	AudioChannels         int
 	AudioCompressionLevel int
 	MaxAudioSize          int64
+	MaxAudioDuration      time.Duration
+	AudioDurationPolicy   string
+	AudioLoudnormI        float64 // Integrated loudness target in LUFS for normalize requests
+	AudioLoudnormLRA      float64 // Loudness range target in LU
+	AudioLoudnormTP       float64 // True peak ceiling in dBTP
+
+	// Extra libopus options, and a candidate set tried on a share of encodes
+	AudioEncoderArgs          string
+	AudioCandidateEncoderArgs string
+	AudioCandidatePercent     int
 
 	// Image conversion settings
 	DefaultImageQuality int
 	DefaultMaxWidth     int
 	DefaultMaxHeight    int
 	MaxImageSize        int64
+	MaxImageMegapixels  float64
 	ImageEngine         string
+	ImageQualityCheck   bool
+	ImageMinSSIM        float64
+	ImageMinPSNR        float64
+	EmbedSRGBProfile    bool
+	ImageBackground     string
+	AlphaOutputFormat   string
+	ImageMaxUpscale     float64
+	ImageUpscaler       string
+	ImageMetadataPolicy string
+	PostProcessors      map[string]string // Optimiser commands run on outputs, keyed by format (jpeg, png, webp)
+	AuditStampSecret    string            // Signs the audit stamp embedded in converted images (empty disables stamping)
+
+	// Video conversion settings
+	VideoMaxWidth      int
+	VideoMaxHeight     int
+	VideoMaxBitrate    int
+	VideoAudioBitrate  int
+	VideoMaxOutputSize int64
+	MaxVideoSize       int64
+	VideoTempDir       string
+
+	VideoScreencastMaxWidth  int
+	VideoScreencastMaxHeight int
+
+	// Extra libx264 options, and a candidate set tried on a share of encodes
+	VideoEncoderArgs          string
+	VideoCandidateEncoderArgs string
+	VideoCandidatePercent     int
 
 	// Logging configuration
 	LogLevel              string
 	LogFormat             string
 	EnablePerformanceLogs bool
+	EnableCommandTrace    bool
+
+	// Response signing settings
+	ResponseSigningAlgorithm string // hmac-sha256 or ed25519 (empty = off)
+	ResponseSigningKey       string
+	ResponseSigningKeyID     string
+
+	// Admin settings
+	AdminToken string // Guards admin diagnostics (empty disables them)
+
+	// Tracing settings (exporter endpoint via OTEL_EXPORTER_OTLP_*)
+	OTelEnabled     bool
+	OTelServiceName string
+	OTelSampleRatio float64
+
+	// gRPC settings
+	GRPCEnabled bool // Serve ConverterService next to the HTTP API
+	GRPCPort    string
+
+	// Operational alerts (any channel set enables them)
+	NotifySlackWebhookURL string
+	NotifyTelegramToken   string
+	NotifyTelegramChatID  string
+	NotifyTelegramAPIURL  string // Self-hosted Bot API servers
+	NotifyWebhookURL      string
+	NotifyCheckInterval   time.Duration
+	NotifyCooldown        time.Duration // Minimum gap between repeats of one condition
+	NotifyErrorRate       float64       // Share of 5xx responses per check that raises an alert
+	NotifyMinRequests     int           // Requests per check below which the error rate is ignored
+	NotifyQueueWaiting    int           // Conversions waiting for a worker that count as saturation
+	WarmupOnStart         bool          // Convert tiny samples at startup to catch broken encoders
+
+	// API metadata shown in /api, Swagger and the startup banner
+	APITitle        string
+	APILanguage     string            // Language of API_DESCRIPTION and the Swagger page
+	APIDescriptions map[string]string // Description per language tag, e.g. "pt-BR"
+	APIContactName  string
+	APIContactEmail string
+	APIContactURL   string
 
 	// Development settings
 	Debug           bool
 	HotReload       bool
 	EnableProfiling bool
 	EnableSwagger   bool
+	MockMode        bool
+
+	// Chaos testing settings (never enable in production)
+	ChaosEnabled              bool
+	ChaosLatencyPercent       int
+	ChaosLatency              time.Duration
+	ChaosErrorPercent         int
+	ChaosDropPercent          int
+	ChaosS3FailurePercent     int
+	ChaosS3TruncatePercent    int
+	ChaosFFmpegFailurePercent int
+
+	// Subprocess sandbox settings
+	SandboxMode           string
+	SandboxUID            int
+	SandboxGID            int
+	SandboxSeccompProfile string
 
 	// Production settings
 	ProductionMode  bool
@@ -71,6 +274,11 @@ type Config struct {
 	EnableCORS      bool
 	TrustedProxies  []string
 
+	// Web interface settings
+	EnableWebUI bool
+	WebUIPrefix string // Path the interface is mounted under ("" for the root)
+	WebUIPort   string // Serve the interface on its own listener instead ("" for PORT)
+
 	// Monitoring settings
 	EnableHealthCheck   bool
 	EnableStatsEndpoint bool
@@ -88,6 +296,13 @@ type Config struct {
 	UseTmpfs      bool
 	TmpfsSize     string
 
+	// Large payload handling
+	SpillLargePayloads  bool          // Keep large inputs and video outputs in temporary files
+	SpillThresholdMB    int           // Size from which payloads are spilled (0 = BUFFER_SIZE)
+	SpillDir            string        // Directory of spill files (default system temp)
+	TempJanitorMaxAge   time.Duration // Age after which leftover temporary files are deleted (0 = never)
+	TempJanitorInterval time.Duration // Time between temp janitor sweeps
+
 	// S3 upload configuration
 	S3 *S3Configuration
 }
@@ -102,7 +317,7 @@ func Load() *Config {
 		log.Println("✅ Loaded configuration from .env file")
 	}
 
-	return &Config{
+	cfg := &Config{
 		// Server configuration
 		Port:         getEnv("PORT", "8080"),
 		AppEnv:       getEnv("APP_ENV", "development"),
@@ -111,49 +326,242 @@ func Load() *Config {
 		IdleTimeout:  getDuration("IDLE_TIMEOUT", 5*time.Minute),
 		BodyLimit:    getInt("BODY_LIMIT", 500*1024*1024), // 500MB
 
+		DisableKeepalive: getBool("DISABLE_KEEPALIVE", false),
+		MaxConnections:   getInt("MAX_CONNECTIONS", 0),
+		Prefork:          getBool("PREFORK", false),
+		TLSCertFile:      getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:       getEnv("TLS_KEY_FILE", ""),
+		HTTP3Enabled:     getBool("HTTP3_ENABLED", false),
+		HTTP3Port:        getEnv("HTTP3_PORT", ""),
+		HTTP3MaxStreams:  getInt("HTTP3_MAX_STREAMS", 100),
+
 		// Worker pool - smart defaults based on CPU
 		MaxWorkers:          getWorkerCount(),
+		PriorityWorkers:     getInt("PRIORITY_WORKERS", 2),
+		PriorityMaxSize:     getInt("PRIORITY_MAX_SIZE", 1024*1024), // 1MB
+		FairQueuing:         getBool("FAIR_QUEUING", true),
+		FFmpegThreads:       getInt("FFMPEG_THREADS", 0), // 0 = derive from CPUs / workers
 		QueueSizeMultiplier: getInt("QUEUE_SIZE_MULTIPLIER", 10),
 		RequestTimeout:      getDuration("REQUEST_TIMEOUT", 5*time.Minute),
+		AudioTimeout:        getDuration("AUDIO_TIMEOUT", 0),
+		ImageTimeout:        getDuration("IMAGE_TIMEOUT", 0),
+		BatchTimeout:        getDuration("BATCH_TIMEOUT", 0),
+		BatchMaxSize:        getInt("BATCH_MAX_SIZE", 10),
+		BatchItemTimeout:    getDuration("BATCH_ITEM_TIMEOUT", time.Minute),
+		BatchConcurrency:    getInt("BATCH_CONCURRENCY", 0),
+		BatchAsyncMaxSize:   getInt("BATCH_ASYNC_MAX_SIZE", 500),
+		BatchJobMaxActive:   getInt("BATCH_JOB_MAX_ACTIVE", 4),
+		BatchJobRetention:   getDuration("BATCH_JOB_RETENTION", time.Hour),
+		BatchReportMinItems: getInt("BATCH_REPORT_MIN_ITEMS", 100),
+		BatchReportPrefix:   getEnv("BATCH_REPORT_PREFIX", "batch-reports/"),
+
+		WorkspaceTTL:          getDuration("WORKSPACE_TTL", 30*time.Minute),
+		WorkspaceMaxActive:    getInt("WORKSPACE_MAX_ACTIVE", 100),
+		WorkspaceMaxFiles:     getInt("WORKSPACE_MAX_FILES", 20),
+		WorkspaceMaxSize:      getInt64("WORKSPACE_MAX_SIZE", 200*1024*1024), // 200MB
+		WorkspaceDir:          getEnv("WORKSPACE_DIR", ""),
+		WorkspaceCommitPrefix: getEnv("WORKSPACE_COMMIT_PREFIX", "workspaces/"),
+
+		CancelOnDisconnect: getBool("CANCEL_ON_DISCONNECT", true),
+
+		SlowRequestThreshold: getDuration("SLOW_REQUEST_THRESHOLD", 10*time.Second),
+		UsageTracking:        getBool("USAGE_TRACKING", true),
 
 		// Buffer pool - optimized for high throughput
 		BufferPoolSize: getInt("BUFFER_POOL_SIZE", 100),
 		BufferSize:     getInt("BUFFER_SIZE", 10*1024*1024), // 10MB
 
 		// HTTP client settings
-		DownloadTimeout:     getDuration("DOWNLOAD_TIMEOUT", 30*time.Second),
-		MaxIdleConns:        getInt("MAX_IDLE_CONNS", 100),
-		MaxIdleConnsPerHost: getInt("MAX_IDLE_CONNS_PER_HOST", 100),
-		IdleConnTimeout:     getDuration("IDLE_CONN_TIMEOUT", 90*time.Second),
+		DownloadTimeout:           getDuration("DOWNLOAD_TIMEOUT", 30*time.Second),
+		DownloadProxyURL:          getEnv("DOWNLOAD_PROXY_URL", ""),
+		DownloadAllowPrivateHosts: getBool("DOWNLOAD_ALLOW_PRIVATE_HOSTS", false),
+		DownloadBreakerThreshold:  getInt("DOWNLOAD_BREAKER_THRESHOLD", 5),
+		DownloadBreakerCooldown:   getDuration("DOWNLOAD_BREAKER_COOLDOWN", 30*time.Second),
+		MaxIdleConns:              getInt("MAX_IDLE_CONNS", 100),
+		MaxIdleConnsPerHost:       getInt("MAX_IDLE_CONNS_PER_HOST", 100),
+		IdleConnTimeout:           getDuration("IDLE_CONN_TIMEOUT", 90*time.Second),
 
 		// GC and memory tuning
 		GOGC:       getInt("GOGC", 100),
 		GoMemLimit: getEnv("GOMEMLIMIT", "1GiB"),
 
+		// Failed source retention
+		RetainFailedSources: getBool("RETAIN_FAILED_SOURCES", false),
+		SourceRetentionDir:  getEnv("SOURCE_RETENTION_DIR", filepath.Join(os.TempDir(), "whats-convert-failed")),
+		SourceRetentionTTL:  getDuration("SOURCE_RETENTION_TTL", 24*time.Hour),
+		SourceRetentionKey:  getEnv("SOURCE_RETENTION_KEY", ""),
+		SourceReplayToken:   getEnv("SOURCE_REPLAY_TOKEN", ""),
+
+		// Failed request recording
+		RequestRecording:            getBool("REQUEST_RECORDING", false),
+		RequestRecordingDir:         getEnv("REQUEST_RECORDING_DIR", filepath.Join(os.TempDir(), "whats-convert-recordings")),
+		RequestRecordingTTL:         getDuration("REQUEST_RECORDING_TTL", 72*time.Hour),
+		RequestRecordingKey:         getEnv("REQUEST_RECORDING_KEY", ""),
+		RequestRecordingMinStatus:   getInt("REQUEST_RECORDING_MIN_STATUS", 500),
+		RequestRecordingBodies:      getBool("REQUEST_RECORDING_BODIES", false),
+		RequestRecordingMaxBodySize: getInt64("REQUEST_RECORDING_MAX_BODY_SIZE", 10*1024*1024), // 10MB
+
+		// Convert-on-read media endpoint
+		MediaCacheSize:     getInt64("MEDIA_CACHE_SIZE", 64*1024*1024), // 64MB
+		MediaCacheTTL:      getDuration("MEDIA_CACHE_TTL", time.Hour),
+		MediaMaxAge:        getDuration("MEDIA_MAX_AGE", 24*time.Hour),
+		MediaMaxSourceSize: getInt64("MEDIA_MAX_SOURCE_SIZE", 100*1024*1024), // 100MB
+
+		// Conversion output cache
+		RedisURL: getEnv("REDIS_URL", ""),
+
+		ConversionCacheSize:          getInt64("CONVERSION_CACHE_SIZE", 64*1024*1024), // 64MB
+		ConversionCacheTTL:           getDuration("CONVERSION_CACHE_TTL", time.Hour),
+		ConversionCacheMaxObjectSize: getInt("CONVERSION_CACHE_MAX_OBJECT_SIZE", 8*1024*1024), // 8MB
+		ConversionCacheRedisURL:      getEnv("CONVERSION_CACHE_REDIS_URL", getEnv("REDIS_URL", "")),
+
+		// Feature flags
+		FeatureFlags:         getEnv("FEATURE_FLAGS", ""),
+		FeatureFlagsFile:     getEnv("FEATURE_FLAGS_FILE", ""),
+		FeatureFlagsRedisURL: getEnv("FEATURE_FLAGS_REDIS_URL", ""),
+		FeatureFlagsRedisKey: getEnv("FEATURE_FLAGS_REDIS_KEY", "whats-convert:features"),
+		FeatureFlagsRefresh:  getDuration("FEATURE_FLAGS_REFRESH", 30*time.Second),
+
+		// Maintenance windows
+		MaintenanceFile:    getEnv("MAINTENANCE_FILE", ""),
+		MaintenanceRefresh: getDuration("MAINTENANCE_REFRESH", 5*time.Second),
+
+		// Read-only mode
+		ReadOnly: getBool("READ_ONLY", false),
+
+		// Return already compliant inputs without re-encoding
+		SkipCompliantInputs: getBool("SKIP_COMPLIANT_INPUTS", false),
+
+		// Conversion presets
+		PresetsFile: getEnv("PRESETS_FILE", ""),
+
+		// API key tiers
+		TiersFile:   getEnv("TIERS_FILE", ""),
+		APIKeyTiers: getStringMap("API_KEY_TIERS"),
+		DefaultTier: getEnv("DEFAULT_TIER", ""),
+
+		// Memory admission control
+		MemoryHighWaterPercent: getInt("MEMORY_HIGH_WATER_PERCENT", 85),
+		AdmissionMinBodySize:   getInt("ADMISSION_MIN_BODY_SIZE", 1024*1024), // 1MB
+
 		// Audio conversion settings
 		AudioBitrate:          getEnv("AUDIO_BITRATE", "128k"),
 		AudioSampleRate:       getInt("AUDIO_SAMPLE_RATE", 48000),
 		AudioChannels:         getInt("AUDIO_CHANNELS", 1),
 		AudioCompressionLevel: getInt("AUDIO_COMPRESSION_LEVEL", 10),
 		MaxAudioSize:          getInt64("MAX_AUDIO_SIZE", 100*1024*1024), // 100MB
+		MaxAudioDuration:      getDuration("MAX_AUDIO_DURATION", 30*time.Minute),
+		AudioDurationPolicy:   getEnv("AUDIO_DURATION_POLICY", "reject"),
+		AudioLoudnormI:        getFloat("AUDIO_LOUDNORM_I", -16),
+		AudioLoudnormLRA:      getFloat("AUDIO_LOUDNORM_LRA", 11),
+		AudioLoudnormTP:       getFloat("AUDIO_LOUDNORM_TP", -1.5),
+
+		AudioEncoderArgs:          getEnv("AUDIO_ENCODER_ARGS", ""),
+		AudioCandidateEncoderArgs: getEnv("AUDIO_CANDIDATE_ENCODER_ARGS", ""),
+		AudioCandidatePercent:     getInt("AUDIO_CANDIDATE_PERCENT", 0),
 
 		// Image conversion settings
 		DefaultImageQuality: getInt("DEFAULT_IMAGE_QUALITY", 95),
 		DefaultMaxWidth:     getInt("DEFAULT_MAX_WIDTH", 1920),
 		DefaultMaxHeight:    getInt("DEFAULT_MAX_HEIGHT", 1920),
 		MaxImageSize:        getInt64("MAX_IMAGE_SIZE", 200*1024*1024), // 200MB
+		MaxImageMegapixels:  getFloat("MAX_IMAGE_MEGAPIXELS", 100),
 		ImageEngine:         getEnv("IMAGE_ENGINE", "auto"),
+		ImageQualityCheck:   getBool("IMAGE_QUALITY_CHECK", false),
+		ImageMinSSIM:        getFloat("IMAGE_MIN_SSIM", 0),
+		ImageMinPSNR:        getFloat("IMAGE_MIN_PSNR", 0),
+		EmbedSRGBProfile:    getBool("EMBED_SRGB_PROFILE", false),
+		ImageBackground:     getEnv("IMAGE_BACKGROUND", "#ffffff"),
+		AlphaOutputFormat:   getEnv("ALPHA_OUTPUT_FORMAT", "webp"),
+		ImageMaxUpscale:     getFloat("IMAGE_MAX_UPSCALE", 4),
+		ImageUpscaler:       getEnv("IMAGE_UPSCALER_COMMAND", ""),
+		ImageMetadataPolicy: getEnv("IMAGE_METADATA_POLICY", "strip_all"),
+		PostProcessors: map[string]string{
+			"jpeg": getEnv("POST_PROCESSORS_JPEG", ""),
+			"png":  getEnv("POST_PROCESSORS_PNG", ""),
+			"webp": getEnv("POST_PROCESSORS_WEBP", ""),
+		},
+		AuditStampSecret: getEnv("AUDIT_STAMP_SECRET", ""),
+
+		// Video conversion settings
+		VideoMaxWidth:      getInt("VIDEO_MAX_WIDTH", 1280),
+		VideoMaxHeight:     getInt("VIDEO_MAX_HEIGHT", 1280),
+		VideoMaxBitrate:    getInt("VIDEO_MAX_BITRATE", 2000),
+		VideoAudioBitrate:  getInt("VIDEO_AUDIO_BITRATE", 128),
+		VideoMaxOutputSize: getInt64("VIDEO_MAX_OUTPUT_SIZE", 16*1024*1024), // 16MB
+		MaxVideoSize:       getInt64("MAX_VIDEO_SIZE", 200*1024*1024),       // 200MB
+		VideoTempDir:       getEnv("VIDEO_TEMP_DIR", ""),
+
+		VideoScreencastMaxWidth:  getInt("VIDEO_SCREENCAST_MAX_WIDTH", 1920),
+		VideoScreencastMaxHeight: getInt("VIDEO_SCREENCAST_MAX_HEIGHT", 1920),
+
+		VideoEncoderArgs:          getEnv("VIDEO_ENCODER_ARGS", ""),
+		VideoCandidateEncoderArgs: getEnv("VIDEO_CANDIDATE_ENCODER_ARGS", ""),
+		VideoCandidatePercent:     getInt("VIDEO_CANDIDATE_PERCENT", 0),
 
 		// Logging configuration
 		LogLevel:              getEnv("LOG_LEVEL", "info"),
 		LogFormat:             getEnv("LOG_FORMAT", "text"),
 		EnablePerformanceLogs: getBool("ENABLE_PERFORMANCE_LOGS", true),
+		EnableCommandTrace:    getBool("ENABLE_COMMAND_TRACE", false),
+
+		// Response signing settings
+		ResponseSigningAlgorithm: getEnv("RESPONSE_SIGNING_ALGORITHM", ""),
+		ResponseSigningKey:       getEnv("RESPONSE_SIGNING_KEY", ""),
+		ResponseSigningKeyID:     getEnv("RESPONSE_SIGNING_KEY_ID", "default"),
+
+		// Admin settings
+		AdminToken: getEnv("ADMIN_TOKEN", ""),
+
+		// Tracing settings
+		OTelEnabled:     getBool("OTEL_ENABLED", false),
+		OTelServiceName: getEnv("OTEL_SERVICE_NAME", "whats-convert-api"),
+		OTelSampleRatio: getFloat("OTEL_TRACES_SAMPLE_RATIO", 1.0),
+
+		// gRPC settings
+		GRPCEnabled: getBool("GRPC_ENABLED", false),
+		GRPCPort:    getEnv("GRPC_PORT", "9090"),
+
+		// Operational alerts
+		NotifySlackWebhookURL: getEnv("NOTIFY_SLACK_WEBHOOK_URL", ""),
+		NotifyTelegramToken:   getEnv("NOTIFY_TELEGRAM_BOT_TOKEN", ""),
+		NotifyTelegramChatID:  getEnv("NOTIFY_TELEGRAM_CHAT_ID", ""),
+		NotifyTelegramAPIURL:  getEnv("NOTIFY_TELEGRAM_API_URL", "https://api.telegram.org"),
+		NotifyWebhookURL:      getEnv("NOTIFY_WEBHOOK_URL", ""),
+		NotifyCheckInterval:   getDuration("NOTIFY_CHECK_INTERVAL", 30*time.Second),
+		NotifyCooldown:        getDuration("NOTIFY_COOLDOWN", 15*time.Minute),
+		NotifyErrorRate:       getFloat("NOTIFY_ERROR_RATE", 0.2),
+		NotifyMinRequests:     getInt("NOTIFY_MIN_REQUESTS", 20),
+		NotifyQueueWaiting:    getInt("NOTIFY_QUEUE_WAITING", 20),
+		WarmupOnStart:         getBool("WARMUP_ON_START", false),
+
+		// API metadata
+		APITitle:        getEnv("API_TITLE", "WhatsApp Media Converter API"),
+		APILanguage:     getEnv("API_LANGUAGE", "en"),
+		APIDescriptions: getAPIDescriptions(),
 
 		// Development settings
 		Debug:           getBool("DEBUG", false),
 		HotReload:       getBool("HOT_RELOAD", false),
 		EnableProfiling: getBool("ENABLE_PROFILING", false),
 		EnableSwagger:   getBool("ENABLE_SWAGGER", true),
+		MockMode:        getBool("MOCK_MODE", false),
+
+		// Chaos testing settings
+		ChaosEnabled:              getBool("CHAOS_ENABLED", false),
+		ChaosLatencyPercent:       getInt("CHAOS_LATENCY_PERCENT", 0),
+		ChaosLatency:              getDuration("CHAOS_LATENCY", 2*time.Second),
+		ChaosErrorPercent:         getInt("CHAOS_ERROR_PERCENT", 0),
+		ChaosDropPercent:          getInt("CHAOS_DROP_PERCENT", 0),
+		ChaosS3FailurePercent:     getInt("CHAOS_S3_FAILURE_PERCENT", 0),
+		ChaosS3TruncatePercent:    getInt("CHAOS_S3_TRUNCATE_PERCENT", 0),
+		ChaosFFmpegFailurePercent: getInt("CHAOS_FFMPEG_FAILURE_PERCENT", 0),
+
+		// Subprocess sandbox settings
+		SandboxMode:           getEnv("SANDBOX_MODE", "none"),
+		SandboxUID:            getInt("SANDBOX_UID", -1),
+		SandboxGID:            getInt("SANDBOX_GID", -1),
+		SandboxSeccompProfile: getEnv("SANDBOX_SECCOMP_PROFILE", ""),
 
 		// Production settings
 		ProductionMode:  getBool("PRODUCTION_MODE", false),
@@ -161,6 +569,11 @@ func Load() *Config {
 		EnableCORS:      getBool("ENABLE_CORS", true),
 		TrustedProxies:  getStringSlice("TRUSTED_PROXIES", []string{"127.0.0.1", "::1"}),
 
+		// Web interface settings
+		EnableWebUI: getBool("ENABLE_WEB_UI", true),
+		WebUIPrefix: getEnv("WEB_UI_PREFIX", ""),
+		WebUIPort:   getEnv("WEB_UI_PORT", ""),
+
 		// Monitoring settings
 		EnableHealthCheck:   getBool("ENABLE_HEALTH_CHECK", true),
 		EnableStatsEndpoint: getBool("ENABLE_STATS_ENDPOINT", true),
@@ -178,9 +591,33 @@ func Load() *Config {
 		UseTmpfs:      getBool("USE_TMPFS", true),
 		TmpfsSize:     getEnv("TMPFS_SIZE", "2G"),
 
+		// Large payload handling
+		SpillLargePayloads:  getBool("SPILL_LARGE_PAYLOADS", false),
+		SpillThresholdMB:    getInt("SPILL_THRESHOLD_MB", 0),
+		SpillDir:            getEnv("SPILL_DIR", ""),
+		TempJanitorMaxAge:   getDuration("TEMP_JANITOR_MAX_AGE", time.Hour),
+		TempJanitorInterval: getDuration("TEMP_JANITOR_INTERVAL", 10*time.Minute),
+
 		// S3 upload configuration
 		S3: LoadS3Config(),
 	}
+
+	// The contact is replaced as a whole, so white-label deployments setting
+	// only some fields don't show the upstream author's remaining ones
+	cfg.APIContactName, cfg.APIContactEmail, cfg.APIContactURL = "Guilherme Jansen", "suporte@setupautomatizado.com.br", "https://github.com/guilhermejansen"
+	if os.Getenv("API_CONTACT_NAME")+os.Getenv("API_CONTACT_EMAIL")+os.Getenv("API_CONTACT_URL") != "" {
+		cfg.APIContactName = os.Getenv("API_CONTACT_NAME")
+		cfg.APIContactEmail = os.Getenv("API_CONTACT_EMAIL")
+		cfg.APIContactURL = os.Getenv("API_CONTACT_URL")
+	}
+
+	// Mock mode serves canned responses and an in-memory bucket unless
+	// S3 is explicitly disabled
+	if cfg.MockMode && os.Getenv("S3_ENABLED") != "false" {
+		cfg.S3.ApplyMockMode()
+	}
+
+	return cfg
 }
 
 // Helper functions for environment variable parsing
@@ -212,6 +649,16 @@ func getInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+		log.Printf("Warning: Invalid float value for %s: %s, using default: %g", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
 func getBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if parsed, err := strconv.ParseBool(value); err == nil {
@@ -245,6 +692,66 @@ func getStringSlice(key string, defaultValue []string) []string {
 	return defaultValue
 }
 
+// getIntMap parses "name=value" pairs separated by commas, skipping invalid entries
+func getIntMap(key string) map[string]int {
+	result := make(map[string]int)
+	for _, entry := range getStringSlice(key, nil) {
+		name, value, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || name == "" || err != nil {
+			log.Printf("Warning: Invalid entry in %s: %q (want name=number), ignoring", key, entry)
+			continue
+		}
+		result[name] = parsed
+	}
+	return result
+}
+
+// getStringMap parses "name=value" pairs separated by commas, skipping invalid entries
+func getStringMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, entry := range getStringSlice(key, nil) {
+		name, value, ok := strings.Cut(entry, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			log.Printf("Warning: Invalid entry in %s: %q (want name=value), ignoring", key, entry)
+			continue
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// getAPIDescriptions reads API_DESCRIPTION_<TAG> variables, e.g.
+// API_DESCRIPTION_PT_BR for "pt-BR"; API_DESCRIPTION itself is stored under
+// the empty tag and applies to API_LANGUAGE
+func getAPIDescriptions() map[string]string {
+	const prefix = "API_DESCRIPTION"
+
+	descriptions := make(map[string]string)
+	for _, entry := range os.Environ() {
+		key, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(key, prefix) || strings.TrimSpace(value) == "" {
+			continue
+		}
+
+		tag := strings.TrimPrefix(key, prefix)
+		if tag != "" {
+			if tag[0] != '_' || len(tag) < 2 {
+				continue
+			}
+			language, region, hasRegion := strings.Cut(tag[1:], "_")
+			tag = strings.ToLower(language)
+			if hasRegion {
+				tag += "-" + strings.ToUpper(region)
+			}
+		}
+		descriptions[tag] = value
+	}
+	return descriptions
+}
+
 func getWorkerCount() int {
 	// Check if explicitly set
 	if value := os.Getenv("MAX_WORKERS"); value != "" {
@@ -279,11 +786,100 @@ func (c *Config) IsProduction() bool {
 	return c.AppEnv == "production" || c.ProductionMode
 }
 
+// MemoryLimitBytes parses GoMemLimit using the GOMEMLIMIT syntax (e.g. "1GiB",
+// "512MiB", plain bytes). It returns 0 for "off" and for invalid values.
+func (c *Config) MemoryLimitBytes() int64 {
+	value := strings.TrimSpace(c.GoMemLimit)
+	if value == "" || value == "off" {
+		return 0
+	}
+
+	units := []struct {
+		suffix string
+		scale  int64
+	}{
+		{"TiB", 1 << 40},
+		{"GiB", 1 << 30},
+		{"MiB", 1 << 20},
+		{"KiB", 1 << 10},
+		{"B", 1},
+	}
+
+	scale := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSuffix(value, unit.suffix)
+			scale = unit.scale
+			break
+		}
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed <= 0 {
+		log.Printf("Warning: Invalid GOMEMLIMIT value: %s, leaving the memory limit unset", c.GoMemLimit)
+		return 0
+	}
+
+	return parsed * scale
+}
+
+// TmpfsSizeBytes parses TmpfsSize using Docker's size syntax (e.g. "2G",
+// "512m", "1gb", plain bytes). It returns 0 for empty and invalid values.
+func (c *Config) TmpfsSizeBytes() int64 {
+	value := strings.ToLower(strings.TrimSpace(c.TmpfsSize))
+	if value == "" {
+		return 0
+	}
+	value = strings.TrimSuffix(value, "b")
+
+	scale := int64(1)
+	if unit := strings.IndexAny(value, "kmgt"); unit >= 0 && unit == len(value)-1 {
+		scale = map[byte]int64{'k': 1 << 10, 'm': 1 << 20, 'g': 1 << 30, 't': 1 << 40}[value[unit]]
+		value = value[:unit]
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed <= 0 {
+		log.Printf("Warning: Invalid TMPFS_SIZE value: %s, leaving the spill budget unlimited", c.TmpfsSize)
+		return 0
+	}
+
+	return parsed * scale
+}
+
+// SpillThresholdBytes returns the size from which payloads are spilled:
+// SPILL_THRESHOLD_MB, or the pool buffer size, past which payloads already
+// cost a fresh allocation
+func (c *Config) SpillThresholdBytes() int64 {
+	if c.SpillThresholdMB > 0 {
+		return int64(c.SpillThresholdMB) << 20
+	}
+	return int64(c.BufferSize)
+}
+
+// HTTP3ListenPort returns the UDP port of the HTTP/3 listener
+func (c *Config) HTTP3ListenPort() string {
+	if c.HTTP3Port != "" {
+		return c.HTTP3Port
+	}
+	return c.Port
+}
+
 // GetQueueSize returns the calculated queue size
 func (c *Config) GetQueueSize() int {
 	return c.MaxWorkers * c.QueueSizeMultiplier
 }
 
+// BatchJobConcurrency is how many items of an asynchronous batch job are
+// converted at once: BATCH_CONCURRENCY, else MAX_WORKERS so one job of
+// hundreds of items doesn't time them out waiting for workers
+func (c *Config) BatchJobConcurrency() int {
+	if c.BatchConcurrency > 0 {
+		return c.BatchConcurrency
+	}
+	return c.MaxWorkers
+}
+
 // PrintConfig logs the current configuration (without sensitive data)
 func (c *Config) PrintConfig() {
 	log.Println("===========================================")
@@ -291,17 +887,60 @@ func (c *Config) PrintConfig() {
 	log.Println("===========================================")
 	log.Printf("🌍 Environment:      %s", c.AppEnv)
 	log.Printf("🚪 Port:             %s", c.Port)
+	if c.GRPCEnabled {
+		log.Printf("📡 gRPC Port:        %s", c.GRPCPort)
+	}
 	log.Printf("⚡ Workers:          %d (CPU: %d)", c.MaxWorkers, runtime.NumCPU())
+	log.Printf("🏎️ Priority Lane:    %d workers for inputs ≤ %dKB", c.PriorityWorkers, c.PriorityMaxSize/1024)
+	log.Printf("⚖️ Fair Queuing:     %t", c.FairQueuing)
 	log.Printf("📦 Buffer Pool:      %d × %dMB", c.BufferPoolSize, c.BufferSize/1024/1024)
 	log.Printf("🕒 Request Timeout:  %s", c.RequestTimeout)
+	if c.DownloadBreakerThreshold > 0 {
+		log.Printf("🔌 Download Breaker: %d failures, %s cooldown", c.DownloadBreakerThreshold, c.DownloadBreakerCooldown)
+	}
 	log.Printf("📊 Body Limit:       %dMB", c.BodyLimit/1024/1024)
-	log.Printf("🧠 Memory Limit:     %s", c.GoMemLimit)
+	log.Printf("🧠 Memory Limit:     %s (admission at %d%%)", c.GoMemLimit, c.MemoryHighWaterPercent)
 	log.Printf("🔄 GOGC:            %d", c.GOGC)
 	log.Printf("🎵 Audio Max Size:   %dMB", c.MaxAudioSize/1024/1024)
+	log.Printf("⏱️ Audio Max Length: %s (%s)", c.MaxAudioDuration, c.AudioDurationPolicy)
 	log.Printf("🖼️ Image Max Size:   %dMB", c.MaxImageSize/1024/1024)
+	log.Printf("🧩 Image Max Pixels: %.0f MP", c.MaxImageMegapixels)
+	for _, format := range []string{"jpeg", "png", "webp"} {
+		if command := c.PostProcessors[format]; command != "" {
+			log.Printf("🗜️ Post-process %-4s %s", format+":", command)
+		}
+	}
 	log.Printf("📈 Performance Logs: %t", c.EnablePerformanceLogs)
+	log.Printf("🔍 Command Trace:    %t", c.EnableCommandTrace)
+	if c.RetainFailedSources {
+		log.Printf("🗄️ Failed Sources:   retained %s in %s (replay: %t)", c.SourceRetentionTTL, c.SourceRetentionDir, c.SourceReplayToken != "")
+	}
+	if c.RequestRecording {
+		log.Printf("📼 Request Recording: status ≥ %d kept %s in %s (bodies: %t, replay: %t)",
+			c.RequestRecordingMinStatus, c.RequestRecordingTTL, c.RequestRecordingDir, c.RequestRecordingBodies, c.AdminToken != "")
+	}
 	log.Printf("🏥 Health Check:     %t", c.EnableHealthCheck)
 	log.Printf("📊 Stats Endpoint:   %t", c.EnableStatsEndpoint)
+	log.Printf("🧪 Mock Mode:        %t", c.MockMode)
+	if channels := c.NotifyChannels(); len(channels) > 0 {
+		log.Printf("🔔 Alerts:           %s every %s (cooldown %s)", strings.Join(channels, ", "), c.NotifyCheckInterval, c.NotifyCooldown)
+	}
+	log.Printf("🔥 Startup Warm-up:  %t", c.WarmupOnStart)
+	if c.ConversionCacheSize > 0 || c.ConversionCacheRedisURL != "" {
+		log.Printf("♻️ Conversion Cache: %dMB for %s, outputs up to %dMB (redis: %t)", c.ConversionCacheSize/1024/1024, c.ConversionCacheTTL, c.ConversionCacheMaxObjectSize/1024/1024, c.ConversionCacheRedisURL != "")
+	}
+	if c.TiersFile != "" || len(c.APIKeyTiers) > 0 {
+		log.Printf("🎟️ API Key Tiers:    file=%q keys=%d default=%q", c.TiersFile, len(c.APIKeyTiers), c.DefaultTier)
+	}
+	if c.FeatureFlags != "" || c.FeatureFlagsFile != "" || c.FeatureFlagsRedisURL != "" {
+		log.Printf("🚩 Feature Flags:    env=%q file=%q redis=%t", c.FeatureFlags, c.FeatureFlagsFile, c.FeatureFlagsRedisURL != "")
+	}
+	if c.ChaosEnabled {
+		log.Printf("💥 Chaos:            latency %d%% (%s), errors %d%%, drops %d%%, S3 %d%% (truncated %d%%), FFmpeg %d%%",
+			c.ChaosLatencyPercent, c.ChaosLatency, c.ChaosErrorPercent, c.ChaosDropPercent,
+			c.ChaosS3FailurePercent, c.ChaosS3TruncatePercent, c.ChaosFFmpegFailurePercent)
+	}
+	log.Printf("🛡️ Sandbox:          %s", c.SandboxMode)
 	log.Printf("🔐 API Auth:         %t", c.EnableAPIAuth)
 	log.Printf("🚦 Rate Limiting:    %t", c.EnableRateLimit)
 	if c.EnableRateLimit {
@@ -332,5 +971,105 @@ func (c *Config) Validate() error {
 		c.RequestTimeout = 5 * time.Minute
 	}
 
+	if c.BatchMaxSize <= 0 {
+		log.Printf("Warning: BATCH_MAX_SIZE is 0 or negative, setting to default: 10")
+		c.BatchMaxSize = 10
+	}
+
+	if c.BatchItemTimeout <= 0 {
+		log.Printf("Warning: BATCH_ITEM_TIMEOUT is 0 or negative, setting to default: 1m")
+		c.BatchItemTimeout = time.Minute
+	}
+
+	if c.BatchConcurrency < 0 {
+		log.Printf("Warning: BATCH_CONCURRENCY is negative, converting all items at once")
+		c.BatchConcurrency = 0
+	}
+
+	if c.BatchAsyncMaxSize <= 0 {
+		log.Printf("Warning: BATCH_ASYNC_MAX_SIZE is 0 or negative, setting to default: 500")
+		c.BatchAsyncMaxSize = 500
+	}
+
+	if c.BatchJobMaxActive <= 0 {
+		log.Printf("Warning: BATCH_JOB_MAX_ACTIVE is 0 or negative, setting to default: 4")
+		c.BatchJobMaxActive = 4
+	}
+
+	if c.BatchJobRetention <= 0 {
+		log.Printf("Warning: BATCH_JOB_RETENTION is 0 or negative, setting to default: 1h")
+		c.BatchJobRetention = time.Hour
+	}
+
+	if c.BatchReportMinItems < 0 {
+		log.Printf("Warning: BATCH_REPORT_MIN_ITEMS is negative, uploading reports only on request")
+		c.BatchReportMinItems = 0
+	}
+
+	if c.WorkspaceTTL <= 0 {
+		log.Printf("Warning: WORKSPACE_TTL is 0 or negative, setting to default: 30m")
+		c.WorkspaceTTL = 30 * time.Minute
+	}
+
+	if c.WorkspaceMaxActive <= 0 {
+		log.Printf("Warning: WORKSPACE_MAX_ACTIVE is 0 or negative, setting to default: 100")
+		c.WorkspaceMaxActive = 100
+	}
+
+	if c.WorkspaceMaxFiles <= 0 {
+		log.Printf("Warning: WORKSPACE_MAX_FILES is 0 or negative, setting to default: 20")
+		c.WorkspaceMaxFiles = 20
+	}
+
+	if c.WorkspaceMaxSize <= 0 {
+		log.Printf("Warning: WORKSPACE_MAX_SIZE is 0 or negative, setting to default: 200MB")
+		c.WorkspaceMaxSize = 200 * 1024 * 1024
+	}
+
+	if c.WorkspaceCommitPrefix == "" {
+		log.Printf("Warning: WORKSPACE_COMMIT_PREFIX is empty, setting to default: workspaces/")
+		c.WorkspaceCommitPrefix = "workspaces/"
+	}
+
+	if c.MaintenanceRefresh <= 0 {
+		log.Printf("Warning: MAINTENANCE_REFRESH is 0 or negative, setting to default: 5s")
+		c.MaintenanceRefresh = 5 * time.Second
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		log.Printf("Warning: TLS_CERT_FILE and TLS_KEY_FILE must be set together, serving plain HTTP")
+		c.TLSCertFile, c.TLSKeyFile = "", ""
+	}
+
+	if c.HTTP3Enabled && c.TLSCertFile == "" {
+		log.Printf("Warning: HTTP3_ENABLED requires TLS_CERT_FILE and TLS_KEY_FILE, disabling HTTP/3")
+		c.HTTP3Enabled = false
+	}
+
+	if c.HTTP3MaxStreams <= 0 {
+		log.Printf("Warning: HTTP3_MAX_STREAMS is 0 or negative, setting to default: 100")
+		c.HTTP3MaxStreams = 100
+	}
+
+	if c.RequestRecordingMinStatus < 400 || c.RequestRecordingMinStatus > 599 {
+		log.Printf("Warning: REQUEST_RECORDING_MIN_STATUS must be an error status (400-599), setting to default: 500")
+		c.RequestRecordingMinStatus = 500
+	}
+
 	return nil
 }
+
+// NotifyChannels names the configured alert channels
+func (c *Config) NotifyChannels() []string {
+	var channels []string
+	if c.NotifySlackWebhookURL != "" {
+		channels = append(channels, "slack")
+	}
+	if c.NotifyTelegramToken != "" {
+		channels = append(channels, "telegram")
+	}
+	if c.NotifyWebhookURL != "" {
+		channels = append(channels, "webhook")
+	}
+	return channels
+}
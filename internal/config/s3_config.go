@@ -31,29 +31,64 @@ type S3Configuration struct {
 	PublicRead            bool `json:"public_read"`
 	DefaultExpirationDays int  `json:"default_expiration_days"`
 
+	// Temporary sharing of private objects
+	ShareDefaultTTL time.Duration `json:"share_default_ttl"`
+	ShareMaxTTL     time.Duration `json:"share_max_ttl"` // At most 7 days (SigV4 presign limit)
+
+	// Soft delete: DELETE moves objects under TrashPrefix, purged after TrashTTL
+	SoftDelete  bool          `json:"soft_delete"`
+	TrashPrefix string        `json:"trash_prefix"`
+	TrashTTL    time.Duration `json:"trash_ttl"`
+
+	// How often objects past their expiry are deleted on providers whose
+	// lifecycle rules can't match object tags (Backblaze B2, mock); 0 = never
+	ExpirySweepInterval time.Duration `json:"expiry_sweep_interval"`
+
 	// Performance settings
-	MultipartThreshold   int64         `json:"multipart_threshold"`
-	ChunkSize            int64         `json:"chunk_size"`
-	MaxConcurrentUploads int           `json:"max_concurrent_uploads"`
-	UploadTimeout        time.Duration `json:"upload_timeout"`
-	RetryCount           int           `json:"retry_count"`
+	MultipartThreshold   int64          `json:"multipart_threshold"`
+	ChunkSize            int64          `json:"chunk_size"`
+	MaxConcurrentUploads int            `json:"max_concurrent_uploads"`
+	UploadWorkers        int            `json:"upload_workers"`     // Uploads running at once (0 = MaxConcurrentUploads)
+	TenantMaxUploads     int            `json:"tenant_max_uploads"` // Per X-API-Key cap (0 = only the global cap)
+	TenantUploadLimits   map[string]int `json:"-"`                  // Per-key overrides of TenantMaxUploads; keys are secrets
+	UploadTimeout        time.Duration  `json:"upload_timeout"`
+	UploadStatusTTL      time.Duration  `json:"upload_status_ttl"` // How long finished uploads stay in /upload/s3/status
+	RetryCount           int            `json:"retry_count"`
+
+	// Post-upload verification: HEAD each object and compare it with the upload
+	VerifyUploads bool `json:"verify_uploads"`
+	VerifyRetries int  `json:"verify_retries"` // Uploads sent again after a mismatch
 
 	// Key generation settings
 	KeyPrefix         string `json:"key_prefix"`
 	UseTimestampInKey bool   `json:"use_timestamp_in_key"`
 	UseUUIDInKey      bool   `json:"use_uuid_in_key"`
 	PreserveFilename  bool   `json:"preserve_filename"`
+	KeyTemplate       string `json:"key_template"`  // e.g. {date}/{name}-{hash}.{ext}; replaces the settings above
+	KeyCollision      string `json:"key_collision"` // overwrite, suffix or error
 
 	// Security settings
 	AllowedContentTypes []string `json:"allowed_content_types"`
 	MaxFileSize         int64    `json:"max_file_size"`
 	ScanUploads         bool     `json:"scan_uploads"`
 
+	// Provider recovery
+	HealthCheckInterval time.Duration `json:"health_check_interval"` // 0 disables background checks
+	ReconnectThreshold  int           `json:"reconnect_threshold"`   // Consecutive failed checks before reconnecting
+	ReconnectMaxBackoff time.Duration `json:"reconnect_max_backoff"`
+
 	// Monitoring
 	EnableMetrics bool `json:"enable_metrics"`
 	LogUploads    bool `json:"log_uploads"`
+
+	// Other buckets objects can be migrated to or from, by name
+	// (S3_MIGRATION_PROVIDERS); each is configured by S3_MIGRATION_<NAME>_*
+	MigrationProviders map[string]*S3Configuration `json:"-"`
 }
 
+// MigrationDefaultProvider names the API's own bucket in migrations
+const MigrationDefaultProvider = "default"
+
 // LoadS3Config loads S3 configuration from environment variables
 func LoadS3Config() *S3Configuration {
 	config := &S3Configuration{
@@ -70,18 +105,35 @@ func LoadS3Config() *S3Configuration {
 		PathStyle:             getBool("S3_PATH_STYLE", false),
 		PublicRead:            getBool("S3_PUBLIC_READ", true),
 		DefaultExpirationDays: getInt("S3_EXPIRATION_DAYS", 0),
+		ShareDefaultTTL:       getDuration("S3_SHARE_DEFAULT_TTL", 15*time.Minute),
+		ShareMaxTTL:           getDuration("S3_SHARE_MAX_TTL", 24*time.Hour),
+		SoftDelete:            getBool("S3_SOFT_DELETE", false),
+		TrashPrefix:           getEnv("S3_TRASH_PREFIX", "trash/"),
+		TrashTTL:              getDuration("S3_TRASH_TTL", 7*24*time.Hour),
+		ExpirySweepInterval:   getDuration("S3_EXPIRY_SWEEP_INTERVAL", time.Hour),
 		MultipartThreshold:    getInt64("S3_MULTIPART_THRESHOLD", 5*1024*1024), // 5MB
 		ChunkSize:             getInt64("S3_CHUNK_SIZE", 10*1024*1024),         // 10MB
 		MaxConcurrentUploads:  getInt("S3_MAX_CONCURRENT_UPLOADS", 3),
+		UploadWorkers:         getInt("S3_UPLOAD_WORKERS", 0),
+		TenantMaxUploads:      getInt("S3_TENANT_MAX_UPLOADS", 0),
+		TenantUploadLimits:    getIntMap("S3_TENANT_UPLOAD_LIMITS"),
 		UploadTimeout:         getDuration("S3_UPLOAD_TIMEOUT", time.Hour),
+		UploadStatusTTL:       getDuration("S3_UPLOAD_STATUS_TTL", 24*time.Hour),
 		RetryCount:            getInt("S3_RETRY_COUNT", 3),
+		VerifyUploads:         getBool("S3_VERIFY_UPLOADS", false),
+		VerifyRetries:         getInt("S3_VERIFY_RETRIES", 2),
 		KeyPrefix:             getEnv("S3_KEY_PREFIX", "uploads/"),
 		UseTimestampInKey:     getBool("S3_USE_TIMESTAMP_IN_KEY", true),
 		UseUUIDInKey:          getBool("S3_USE_UUID_IN_KEY", true),
 		PreserveFilename:      getBool("S3_PRESERVE_FILENAME", true),
+		KeyTemplate:           getEnv("S3_KEY_TEMPLATE", ""),
+		KeyCollision:          getEnv("S3_KEY_COLLISION", "overwrite"),
 		AllowedContentTypes:   getStringSlice("S3_ALLOWED_CONTENT_TYPES", []string{}),
 		MaxFileSize:           getInt64("S3_MAX_FILE_SIZE", 0), // 0 = no limit
 		ScanUploads:           getBool("S3_SCAN_UPLOADS", false),
+		HealthCheckInterval:   getDuration("S3_HEALTH_CHECK_INTERVAL", 30*time.Second),
+		ReconnectThreshold:    getInt("S3_RECONNECT_THRESHOLD", 3),
+		ReconnectMaxBackoff:   getDuration("S3_RECONNECT_MAX_BACKOFF", 5*time.Minute),
 		EnableMetrics:         getBool("S3_ENABLE_METRICS", true),
 		LogUploads:            getBool("S3_LOG_UPLOADS", true),
 	}
@@ -89,9 +141,47 @@ func LoadS3Config() *S3Configuration {
 	// Set provider-specific defaults
 	config.applyProviderDefaults()
 
+	config.MigrationProviders = make(map[string]*S3Configuration)
+	for _, name := range getStringSlice("S3_MIGRATION_PROVIDERS", []string{}) {
+		if name == "" {
+			continue
+		}
+		config.MigrationProviders[strings.ToLower(name)] = config.loadMigrationProvider(name)
+	}
+
 	return config
 }
 
+// loadMigrationProvider reads the connection of the migration provider
+// name from S3_MIGRATION_<NAME>_*. Upload tuning is shared with the
+// API's own bucket.
+func (c *S3Configuration) loadMigrationProvider(name string) *S3Configuration {
+	prefix := "S3_MIGRATION_" + strings.ToUpper(name) + "_"
+
+	migration := *c
+	migration.Enabled = true
+	migration.MigrationProviders = nil
+	migration.Provider = providers.ProviderType(getEnv(prefix+"PROVIDER", "aws"))
+	migration.Endpoint = getEnv(prefix+"ENDPOINT", "https://s3.amazonaws.com")
+	migration.PublicEndpoint = getEnv(prefix+"PUBLIC_ENDPOINT", "")
+	migration.Region = getEnv(prefix+"REGION", "us-east-1")
+	migration.Bucket = getEnv(prefix+"BUCKET", "")
+	migration.AccessKey = getEnv(prefix+"ACCESS_KEY", "")
+	migration.SecretKey = getEnv(prefix+"SECRET_KEY", "")
+	migration.UseSSL = getBool(prefix+"USE_SSL", true)
+	migration.PathStyle = getBool(prefix+"PATH_STYLE", false)
+	migration.PublicRead = getBool(prefix+"PUBLIC_READ", c.PublicRead)
+	migration.VerifyUploads = false
+
+	if migration.Provider == providers.ProviderMock {
+		migration.ApplyMockMode()
+		migration.Bucket = "mock-" + strings.ToLower(name)
+		return &migration
+	}
+	migration.applyProviderDefaults()
+	return &migration
+}
+
 // applyProviderDefaults sets provider-specific default values
 func (c *S3Configuration) applyProviderDefaults() {
 	switch c.Provider {
@@ -134,6 +224,25 @@ func (c *S3Configuration) applyProviderDefaults() {
 	}
 }
 
+// ApplyMockMode switches the S3 subsystem to the in-memory mock provider so
+// upload endpoints are available without real credentials or a bucket
+func (c *S3Configuration) ApplyMockMode() {
+	c.Enabled = true
+	c.Provider = providers.ProviderMock
+	c.PathStyle = true
+	if c.Bucket == "" {
+		c.Bucket = "mock-bucket"
+	}
+	if c.AccessKey == "" {
+		c.AccessKey = "mock"
+	}
+	if c.SecretKey == "" {
+		c.SecretKey = "mock"
+	}
+	c.Endpoint = "http://mock-s3.local"
+	c.PublicEndpoint = "http://mock-s3.local"
+}
+
 // ToProviderConfig converts S3Configuration to providers.S3Config
 func (c *S3Configuration) ToProviderConfig() *providers.S3Config {
 	return &providers.S3Config{
@@ -212,9 +321,72 @@ func (c *S3Configuration) Validate() error {
 		c.RetryCount = 3
 	}
 
+	if c.VerifyRetries < 0 {
+		c.VerifyRetries = 0
+	}
+
+	if c.HealthCheckInterval < 0 {
+		c.HealthCheckInterval = 0
+	}
+
+	if c.ReconnectThreshold <= 0 {
+		c.ReconnectThreshold = 3
+	}
+
+	if c.ReconnectMaxBackoff < c.HealthCheckInterval {
+		c.ReconnectMaxBackoff = c.HealthCheckInterval
+	}
+
+	if c.DefaultExpirationDays < 0 || c.DefaultExpirationDays > providers.MaxExpirationDays {
+		return fmt.Errorf("S3_EXPIRATION_DAYS must be between 0 and %d", providers.MaxExpirationDays)
+	}
+
+	if c.SoftDelete {
+		if c.TrashTTL <= 0 {
+			return fmt.Errorf("S3_TRASH_TTL must be positive when S3_SOFT_DELETE is on")
+		}
+		if strings.Trim(c.TrashPrefix, "/") == "" {
+			return fmt.Errorf("S3_TRASH_PREFIX can't be empty when S3_SOFT_DELETE is on")
+		}
+		if !strings.HasSuffix(c.TrashPrefix, "/") {
+			c.TrashPrefix += "/"
+		}
+	}
+
+	if c.UploadStatusTTL <= 0 {
+		return fmt.Errorf("S3_UPLOAD_STATUS_TTL must be positive")
+	}
+
+	if c.ShareMaxTTL <= 0 || c.ShareMaxTTL > 7*24*time.Hour {
+		return fmt.Errorf("S3_SHARE_MAX_TTL must be between 0s and 168h (the presigned URL limit)")
+	}
+
+	if c.ShareDefaultTTL <= 0 || c.ShareDefaultTTL > c.ShareMaxTTL {
+		return fmt.Errorf("S3_SHARE_DEFAULT_TTL must be between 0s and S3_SHARE_MAX_TTL")
+	}
+
+	for name, migration := range c.MigrationProviders {
+		if name == MigrationDefaultProvider || !isMigrationProviderName(name) {
+			return fmt.Errorf("S3_MIGRATION_PROVIDERS: %q must be letters, digits and underscores, and not %q", name, MigrationDefaultProvider)
+		}
+		if err := migration.Validate(); err != nil {
+			return fmt.Errorf("S3_MIGRATION_%s: %w", strings.ToUpper(name), err)
+		}
+	}
+
 	return nil
 }
 
+// isMigrationProviderName reports whether name can be part of an environment variable
+func isMigrationProviderName(name string) bool {
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return name != ""
+}
+
 // PrintS3Config logs the current S3 configuration (without sensitive data)
 func (c *S3Configuration) PrintS3Config() {
 	if !c.Enabled {
@@ -233,11 +405,39 @@ func (c *S3Configuration) PrintS3Config() {
 	log.Printf("🔐 Path Style:       %t", c.PathStyle)
 	log.Printf("👁️  Public Read:      %t", c.PublicRead)
 	log.Printf("⏰ Expiration:       %d days", c.DefaultExpirationDays)
+	if c.ExpirySweepInterval > 0 {
+		log.Printf("🧹 Expiry Sweep:     every %s (providers without lifecycle tag rules)", c.ExpirySweepInterval)
+	}
+	log.Printf("🔓 Share TTL:        %s (max %s)", c.ShareDefaultTTL, c.ShareMaxTTL)
+	if c.SoftDelete {
+		log.Printf("🗑️  Soft Delete:      %s (purged after %s)", c.TrashPrefix, c.TrashTTL)
+	}
 	log.Printf("📊 Multipart:        %dMB threshold", c.MultipartThreshold/1024/1024)
 	log.Printf("🧩 Chunk Size:       %dMB", c.ChunkSize/1024/1024)
 	log.Printf("🔄 Concurrent:       %d uploads", c.MaxConcurrentUploads)
+	if c.UploadWorkers > 0 && c.UploadWorkers < c.MaxConcurrentUploads {
+		log.Printf("👷 Upload Workers:   %d (rest queue)", c.UploadWorkers)
+	}
+	if c.TenantMaxUploads > 0 || len(c.TenantUploadLimits) > 0 {
+		log.Printf("👥 Per Tenant:       %d uploads (%d key overrides)", c.TenantMaxUploads, len(c.TenantUploadLimits))
+	}
 	log.Printf("⏱️  Timeout:          %s", c.UploadTimeout)
+	log.Printf("📋 Status Kept:      %s", c.UploadStatusTTL)
 	log.Printf("🔁 Retry Count:      %d", c.RetryCount)
+	if c.VerifyUploads {
+		log.Printf("🔍 Verify Uploads:   on (%d retries)", c.VerifyRetries)
+	}
+	if c.HealthCheckInterval > 0 {
+		log.Printf("🩺 Health Checks:    every %s (reconnect after %d failures, backoff up to %s)", c.HealthCheckInterval, c.ReconnectThreshold, c.ReconnectMaxBackoff)
+	} else {
+		log.Printf("🩺 Health Checks:    disabled")
+	}
+	if c.KeyTemplate != "" {
+		log.Printf("🏷️  Key Template:     %s (collisions: %s)", c.KeyTemplate, c.KeyCollision)
+	}
+	for name, migration := range c.MigrationProviders {
+		log.Printf("🚚 Migration:        %s (%s, bucket %s)", name, migration.Provider, migration.Bucket)
+	}
 	log.Printf("📈 Metrics:          %t", c.EnableMetrics)
 	log.Printf("📝 Logging:          %t", c.LogUploads)
 	log.Println("===========================================")
@@ -0,0 +1,337 @@
+// Package features gates new pipelines behind flags that can be rolled out
+// per deployment or per API key without a redeploy.
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"whats-convert-api/internal/redisclient"
+)
+
+// Known flags gating pipelines that are still being rolled out
+const (
+	Video = "video"
+	Cache = "cache"
+)
+
+// APIKeyHeader identifies the caller for per-key rollouts
+const APIKeyHeader = "X-API-Key"
+
+// Known returns the flags the API consults, in display order
+func Known() []string {
+	return []string{Video, Cache}
+}
+
+// Rule decides whether a flag is on for a caller
+type Rule struct {
+	// Enabled turns the flag on for every caller
+	Enabled bool `json:"enabled"`
+
+	// APIKeys turns the flag on for these callers only
+	APIKeys []string `json:"api_keys,omitempty"`
+
+	// Percent turns the flag on for a stable share (0-100) of API keys
+	Percent int `json:"percent,omitempty"`
+}
+
+// allows reports whether the rule enables the flag for apiKey
+func (r Rule) allows(flag, apiKey string) bool {
+	if r.Enabled {
+		return true
+	}
+	if apiKey == "" {
+		return false
+	}
+	for _, key := range r.APIKeys {
+		if key == apiKey {
+			return true
+		}
+	}
+	if r.Percent <= 0 {
+		return false
+	}
+
+	// Hash flag and key together so each flag rolls out to a different cohort
+	h := fnv.New32a()
+	h.Write([]byte(flag + ":" + apiKey))
+	return int(h.Sum32()%100) < r.Percent
+}
+
+// Options configures the flag sources. Later sources override earlier ones
+// per flag: env < file < Redis.
+type Options struct {
+	// Env is the FEATURE_FLAGS value, e.g. "video=on,cache=25%"
+	Env string
+
+	// File is a JSON object mapping flag names to rules
+	File string
+
+	// RedisURL and RedisKey select a hash whose fields are flag names
+	RedisURL string
+	RedisKey string
+
+	// Refresh is how often the file and Redis sources are reloaded
+	Refresh time.Duration
+}
+
+// Set holds the merged flag rules and keeps them fresh
+type Set struct {
+	opts  Options
+	redis *redisclient.Client
+
+	mu        sync.RWMutex
+	env       map[string]Rule
+	file      map[string]Rule
+	remote    map[string]Rule
+	fileMtime time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New loads every configured source and starts the refresh loop when the
+// file or Redis source is used. Source errors are logged, not fatal: a broken
+// flag source must not take the API down, it just leaves the flags off.
+func New(opts Options) (*Set, error) {
+	env, err := ParseList(opts.Env)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEATURE_FLAGS: %w", err)
+	}
+
+	s := &Set{
+		opts: opts,
+		env:  env,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if opts.RedisURL != "" {
+		if s.redis, err = redisclient.New(opts.RedisURL, 2*time.Second); err != nil {
+			return nil, err
+		}
+	}
+
+	s.reload()
+
+	if (opts.File == "" && s.redis == nil) || opts.Refresh <= 0 {
+		close(s.done)
+		return s, nil
+	}
+
+	go s.refreshLoop()
+	return s, nil
+}
+
+// Enabled reports whether flag is on for the caller identified by apiKey
+// (empty for anonymous callers). Unknown flags are off.
+func (s *Set) Enabled(flag, apiKey string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rule, ok := s.remote[flag]
+	if !ok {
+		rule, ok = s.file[flag]
+	}
+	if !ok {
+		rule, ok = s.env[flag]
+	}
+
+	return ok && rule.allows(flag, apiKey)
+}
+
+// Evaluate returns every known or configured flag as seen by apiKey
+func (s *Set) Evaluate(apiKey string) map[string]bool {
+	names := Known()
+	if s != nil {
+		s.mu.RLock()
+		for _, source := range []map[string]Rule{s.env, s.file, s.remote} {
+			for name := range source {
+				names = append(names, name)
+			}
+		}
+		s.mu.RUnlock()
+	}
+
+	result := make(map[string]bool, len(names))
+	for _, name := range names {
+		result[name] = s.Enabled(name, apiKey)
+	}
+	return result
+}
+
+// Sources lists the configured flag sources for startup logging
+func (s *Set) Sources() []string {
+	sources := []string{"env"}
+	if s.opts.File != "" {
+		sources = append(sources, "file")
+	}
+	if s.redis != nil {
+		sources = append(sources, "redis")
+	}
+	return sources
+}
+
+// Close stops the refresh loop and the Redis connection
+func (s *Set) Close() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+
+	if s.redis != nil {
+		s.redis.Close()
+	}
+}
+
+func (s *Set) refreshLoop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.opts.Refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.reload()
+		}
+	}
+}
+
+func (s *Set) reload() {
+	if s.opts.File != "" {
+		s.reloadFile()
+	}
+	if s.redis != nil {
+		s.reloadRedis()
+	}
+}
+
+func (s *Set) reloadFile() {
+	info, err := os.Stat(s.opts.File)
+	if err != nil {
+		log.Printf("Feature flags file unavailable: %v", err)
+		return
+	}
+
+	s.mu.RLock()
+	unchanged := info.ModTime().Equal(s.fileMtime)
+	s.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	data, err := os.ReadFile(s.opts.File)
+	if err != nil {
+		log.Printf("Feature flags file unreadable: %v", err)
+		return
+	}
+
+	rules := make(map[string]Rule)
+	if err := json.Unmarshal(data, &rules); err != nil {
+		log.Printf("Feature flags file invalid, keeping previous flags: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.file = rules
+	s.fileMtime = info.ModTime()
+	s.mu.Unlock()
+}
+
+func (s *Set) reloadRedis() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	fields, err := s.redis.HGetAll(ctx, s.opts.RedisKey)
+	if err != nil {
+		// Keep the last known flags while Redis is unreachable
+		log.Printf("Feature flags Redis lookup failed, keeping previous flags: %v", err)
+		return
+	}
+
+	rules := make(map[string]Rule, len(fields))
+	for name, value := range fields {
+		rule, err := ParseRule(value)
+		if err != nil {
+			log.Printf("Feature flag %q in Redis ignored: %v", name, err)
+			continue
+		}
+		rules[name] = rule
+	}
+
+	s.mu.Lock()
+	s.remote = rules
+	s.mu.Unlock()
+}
+
+// ParseList parses "name=value,..." where each value is accepted by ParseRule
+func ParseList(list string) (map[string]Rule, error) {
+	rules := make(map[string]Rule)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected name=value, got %q", item)
+		}
+
+		rule, err := ParseRule(value)
+		if err != nil {
+			return nil, fmt.Errorf("flag %q: %w", name, err)
+		}
+		rules[name] = rule
+	}
+	return rules, nil
+}
+
+// ParseRule accepts on/off (and boolean spellings), a rollout percentage
+// such as "25%", or a JSON-encoded Rule
+func ParseRule(value string) (Rule, error) {
+	value = strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, "{") {
+		var rule Rule
+		if err := json.Unmarshal([]byte(value), &rule); err != nil {
+			return Rule{}, err
+		}
+		return rule, nil
+	}
+
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(percent)
+		if err != nil || n < 0 || n > 100 {
+			return Rule{}, fmt.Errorf("invalid percentage %q", value)
+		}
+		return Rule{Percent: n}, nil
+	}
+
+	switch strings.ToLower(value) {
+	case "on", "true", "1", "yes", "enabled":
+		return Rule{Enabled: true}, nil
+	case "off", "false", "0", "no", "disabled":
+		return Rule{}, nil
+	}
+
+	return Rule{}, fmt.Errorf("invalid value %q (use on, off, N%% or a JSON rule)", value)
+}
@@ -0,0 +1,75 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"whats-convert-api/internal/services"
+)
+
+// errorDomain is the ErrorInfo domain of this service's error reasons
+const errorDomain = "whats-convert-api"
+
+// knownErrors maps service errors to status codes and the machine-readable
+// code the HTTP API returns for them
+var knownErrors = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{services.ErrDurationLimitExceeded, codes.InvalidArgument, "duration_limit_exceeded"},
+	{services.ErrUnsupportedOutputFormat, codes.InvalidArgument, "unsupported_output_format"},
+	{services.ErrUnsupportedCompression, codes.InvalidArgument, "unsupported_compression"},
+	{services.ErrUnknownPreset, codes.InvalidArgument, "unknown_preset"},
+	{services.ErrUnsupportedInput, codes.InvalidArgument, "unsupported_input"},
+	{services.ErrPixelLimitExceeded, codes.InvalidArgument, "pixel_limit_exceeded"},
+	{services.ErrInvalidBackground, codes.InvalidArgument, "invalid_background"},
+	{services.ErrQualityBelowThreshold, codes.FailedPrecondition, "quality_below_threshold"},
+	{services.ErrTargetSizeUnreachable, codes.FailedPrecondition, "target_size_unreachable"},
+	{services.ErrInjectedFault, codes.Unavailable, "injected_fault"},
+	{services.ErrDownloadHostUnavailable, codes.Unavailable, "download_host_unavailable"},
+	{services.ErrDownloadAddressForbidden, codes.InvalidArgument, "download_forbidden"},
+}
+
+// toStatus converts a conversion error into a gRPC status. ctx is the
+// conversion's context, whose deadline is the request timeout.
+func toStatus(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, "conversion took too long")
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+
+	code, reason := codes.Internal, ""
+	for _, known := range knownErrors {
+		if errors.Is(err, known.err) {
+			code, reason = known.code, known.reason
+			break
+		}
+	}
+
+	// Failed inputs kept for replay are referenced like the HTTP source_id field
+	sourceID := services.RetainedSourceID(err)
+	if reason == "" && sourceID == "" {
+		return status.Error(code, err.Error())
+	}
+
+	info := &errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}
+	if reason == "" {
+		info.Reason = "conversion_failed"
+	}
+	if sourceID != "" {
+		info.Metadata = map[string]string{"source_id": sourceID}
+	}
+
+	st, detailErr := status.New(code, err.Error()).WithDetails(info)
+	if detailErr != nil {
+		return status.Error(code, err.Error())
+	}
+	return st.Err()
+}
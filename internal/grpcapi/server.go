@@ -0,0 +1,172 @@
+package grpcapi
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"whats-convert-api/internal/features"
+	pb "whats-convert-api/internal/grpcapi/whatsconvertv1"
+	"whats-convert-api/internal/services"
+	"whats-convert-api/internal/tracing"
+)
+
+// NewServer returns a gRPC server exposing service plus the standard health
+// and reflection services. maxMessageSize bounds each received message,
+// calls taking slowThreshold or longer are logged with their conversion
+// stage timings (0 disables), and conversions are charged to the caller's
+// x-api-key in usage when it isn't nil. Callers with the cache feature flag
+// are answered from the conversion cache, and conversions run under the
+// caller's tier when tiers isn't nil.
+func NewServer(service *Service, maxMessageSize int, slowThreshold time.Duration, usage *services.UsageTracker, flags *features.Set, tiers *services.Tiers) *grpc.Server {
+	calls := callObserver{slowThreshold: slowThreshold, usage: usage, flags: flags, tiers: tiers}
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.ChainUnaryInterceptor(calls.unaryInterceptor),
+		grpc.ChainStreamInterceptor(calls.streamInterceptor),
+	)
+
+	pb.RegisterConverterServiceServer(server, service)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+
+	return server
+}
+
+// apiKeyMetadata is the call metadata counterpart of the X-API-Key header
+const apiKeyMetadata = "x-api-key"
+
+// metadataCarrier lets the propagator read traceparent/baggage from call metadata
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	values := metadata.MD(mc).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set is unused: trace context only flows in
+func (mc metadataCarrier) Set(string, string) {}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for key := range mc {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// callObserver traces, logs and meters every call
+type callObserver struct {
+	slowThreshold time.Duration
+	usage         *services.UsageTracker
+	flags         *features.Set
+	tiers         *services.Tiers
+}
+
+// startCall opens the server span of a call, continuing the caller's trace,
+// and times its conversion stages when slow calls are logged
+func (o callObserver) startCall(ctx context.Context, method string) (context.Context, func(error)) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	ctx, span := tracing.StartServer(ctx, method,
+		attribute.String("rpc.system", "grpc"),
+		attribute.String("rpc.method", method),
+	)
+
+	var timings *services.StageTimings
+	if o.slowThreshold > 0 {
+		timings = services.NewStageTimings()
+		ctx = services.WithStageTimings(ctx, timings)
+	}
+
+	var meter *services.CPUMeter
+	if o.usage != nil && strings.Contains(method, "/Convert") {
+		meter = &services.CPUMeter{}
+		ctx = services.WithCPUMeter(ctx, meter)
+	}
+
+	if o.flags.Enabled(features.Cache, metadataCarrier(md).Get(apiKeyMetadata)) {
+		ctx = services.WithConversionCache(ctx)
+	}
+
+	ctx = services.WithTenant(ctx, metadataCarrier(md).Get(apiKeyMetadata))
+
+	if o.tiers != nil {
+		apiKey := metadataCarrier(md).Get(apiKeyMetadata)
+		ctx = services.WithTier(ctx, o.tiers.Tier(apiKey), apiKey)
+	}
+
+	start := time.Now()
+	return ctx, func(err error) {
+		code := status.Code(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(code)))
+		tracing.End(span, err)
+
+		if meter != nil {
+			o.usage.Record(metadataCarrier(md).Get(apiKeyMetadata), meter.Seconds())
+		}
+
+		total := time.Since(start)
+		log.Printf("gRPC %s %s %s", method, code, total.Round(time.Microsecond))
+		if timings != nil && total >= o.slowThreshold {
+			if summary := timings.Summary(total); summary != "" {
+				log.Printf("🐢 Slow conversion: method=%s status=%s total=%s %s", method, code, total.Round(time.Millisecond), summary)
+			}
+		}
+	}
+}
+
+// recoverPanic turns a handler panic into an Internal status, like the HTTP recover middleware
+func recoverPanic(method string, err *error) {
+	if r := recover(); r != nil {
+		log.Printf("gRPC %s panic: %v\n%s", method, r, debug.Stack())
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+func (o callObserver) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx, finish := o.startCall(ctx, info.FullMethod)
+	defer func() { finish(err) }()
+	defer recoverPanic(info.FullMethod, &err)
+
+	if err := o.admitCall(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (o callObserver) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, finish := o.startCall(stream.Context(), info.FullMethod)
+	defer func() { finish(err) }()
+	defer recoverPanic(info.FullMethod, &err)
+
+	if err := o.admitCall(ctx, info.FullMethod, nil); err != nil {
+		return err
+	}
+	return handler(srv, &tracedStream{ServerStream: stream, ctx: ctx})
+}
+
+// tracedStream hands the call's span to stream handlers through Context
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,394 @@
+// Package grpcapi serves the converters over gRPC for internal services that
+// want raw bytes instead of JSON and base64. The API is defined in
+// proto/whatsconvert/v1/converter.proto; whatsconvertv1 holds the generated code.
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "whats-convert-api/internal/grpcapi/whatsconvertv1"
+	"whats-convert-api/internal/services"
+)
+
+// defaultMaxBatchSize matches the HTTP batch endpoints' default
+const defaultMaxBatchSize = 10
+
+// chunkSize is how much output each streamed response message carries
+const chunkSize = 64 * 1024
+
+// Service implements ConverterService with the same converters as the HTTP API
+type Service struct {
+	pb.UnimplementedConverterServiceServer
+
+	audioConverter *services.AudioConverter
+	imageConverter *services.ImageConverter
+	requestTimeout time.Duration
+	maxInputSize   int
+	maxBatchSize   int
+	batchOptions   services.BatchOptions
+}
+
+// NewService creates the gRPC converter service. maxInputSize bounds streamed
+// inputs the way BODY_LIMIT bounds HTTP bodies.
+func NewService(audioConverter *services.AudioConverter, imageConverter *services.ImageConverter, requestTimeout time.Duration, maxInputSize int) *Service {
+	return &Service{
+		audioConverter: audioConverter,
+		imageConverter: imageConverter,
+		requestTimeout: requestTimeout,
+		maxInputSize:   maxInputSize,
+		maxBatchSize:   defaultMaxBatchSize,
+	}
+}
+
+// SetBatchLimits applies BATCH_MAX_SIZE, BATCH_ITEM_TIMEOUT and
+// BATCH_CONCURRENCY to the batch calls
+func (s *Service) SetBatchLimits(maxSize int, opts services.BatchOptions) {
+	if maxSize > 0 {
+		s.maxBatchSize = maxSize
+	}
+	s.batchOptions = opts
+}
+
+// ConvertAudio converts one audio file
+func (s *Service) ConvertAudio(ctx context.Context, in *pb.ConvertAudioRequest) (*pb.ConvertAudioResponse, error) {
+	req, err := audioRequest(in)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	resp, err := s.audioConverter.Convert(ctx, req)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &pb.ConvertAudioResponse{Result: audioResult(resp), Data: resp.Output}, nil
+}
+
+// ConvertImage converts one image
+func (s *Service) ConvertImage(ctx context.Context, in *pb.ConvertImageRequest) (*pb.ConvertImageResponse, error) {
+	req, err := imageRequest(in)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout)
+	defer cancel()
+
+	resp, err := s.imageConverter.Convert(ctx, req)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &pb.ConvertImageResponse{Result: imageResult(resp), Data: resp.Output}, nil
+}
+
+// ConvertAudioBatch converts up to BATCH_MAX_SIZE audio files concurrently
+func (s *Service) ConvertAudioBatch(ctx context.Context, in *pb.ConvertAudioBatchRequest) (*pb.ConvertAudioBatchResponse, error) {
+	if err := s.checkBatchSize(len(in.GetRequests())); err != nil {
+		return nil, err
+	}
+
+	requests := make([]*services.AudioRequest, len(in.GetRequests()))
+	for i, item := range in.GetRequests() {
+		req, err := audioRequest(item)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "request %d: %s", i, status.Convert(err).Message())
+		}
+		requests[i] = req
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout*time.Duration(len(requests)))
+	defer cancel()
+
+	responses, err := s.audioConverter.ConvertBatch(ctx, requests, s.batchOptions)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	out := &pb.ConvertAudioBatchResponse{Results: make([]*pb.ConvertAudioResponse, len(responses))}
+	for i, resp := range responses {
+		out.Results[i] = &pb.ConvertAudioResponse{Result: audioResult(resp), Data: resp.Output}
+	}
+	return out, nil
+}
+
+// ConvertImageBatch converts up to BATCH_MAX_SIZE images concurrently
+func (s *Service) ConvertImageBatch(ctx context.Context, in *pb.ConvertImageBatchRequest) (*pb.ConvertImageBatchResponse, error) {
+	if err := s.checkBatchSize(len(in.GetRequests())); err != nil {
+		return nil, err
+	}
+
+	requests := make([]*services.ImageRequest, len(in.GetRequests()))
+	for i, item := range in.GetRequests() {
+		req, err := imageRequest(item)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "request %d: %s", i, status.Convert(err).Message())
+		}
+		requests[i] = req
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.requestTimeout*time.Duration(len(requests)))
+	defer cancel()
+
+	responses, err := s.imageConverter.ConvertBatch(ctx, requests, s.batchOptions)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	out := &pb.ConvertImageBatchResponse{Results: make([]*pb.ConvertImageResponse, len(responses))}
+	for i, resp := range responses {
+		out.Results[i] = &pb.ConvertImageResponse{Result: imageResult(resp), Data: resp.Output}
+	}
+	return out, nil
+}
+
+// ConvertAudioStream converts audio received in chunks and streams the output back
+func (s *Service) ConvertAudioStream(stream pb.ConverterService_ConvertAudioStreamServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "the first message must be a header")
+	}
+
+	req := newAudioRequest(header.GetOptions())
+	if header.GetUrl() != "" {
+		req.Data, req.IsURL = header.GetUrl(), true
+	} else {
+		req.Input, err = s.receiveInput(func() ([]byte, bool, error) {
+			msg, err := stream.Recv()
+			return msg.GetChunk(), msg.GetHeader() != nil, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(stream.Context(), s.requestTimeout)
+	defer cancel()
+
+	resp, err := s.audioConverter.Convert(ctx, req)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+
+	result := &pb.ConvertAudioStreamResponse{Payload: &pb.ConvertAudioStreamResponse_Result{Result: audioResult(resp)}}
+	if err := stream.Send(result); err != nil {
+		return err
+	}
+	return sendChunks(resp.Output, func(chunk []byte) error {
+		return stream.Send(&pb.ConvertAudioStreamResponse{Payload: &pb.ConvertAudioStreamResponse_Chunk{Chunk: chunk}})
+	})
+}
+
+// ConvertImageStream converts an image received in chunks and streams the output back
+func (s *Service) ConvertImageStream(stream pb.ConverterService_ConvertImageStreamServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	header := first.GetHeader()
+	if header == nil {
+		return status.Error(codes.InvalidArgument, "the first message must be a header")
+	}
+
+	req := newImageRequest(header.GetOptions())
+	if header.GetUrl() != "" {
+		req.Data, req.IsURL = header.GetUrl(), true
+	} else {
+		req.Input, err = s.receiveInput(func() ([]byte, bool, error) {
+			msg, err := stream.Recv()
+			return msg.GetChunk(), msg.GetHeader() != nil, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(stream.Context(), s.requestTimeout)
+	defer cancel()
+
+	resp, err := s.imageConverter.Convert(ctx, req)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+
+	result := &pb.ConvertImageStreamResponse{Payload: &pb.ConvertImageStreamResponse_Result{Result: imageResult(resp)}}
+	if err := stream.Send(result); err != nil {
+		return err
+	}
+	return sendChunks(resp.Output, func(chunk []byte) error {
+		return stream.Send(&pb.ConvertImageStreamResponse{Payload: &pb.ConvertImageStreamResponse_Chunk{Chunk: chunk}})
+	})
+}
+
+// receiveInput collects chunks until the client closes its side of the
+// stream. recv returns the next chunk and whether the message was a header.
+func (s *Service) receiveInput(recv func() ([]byte, bool, error)) ([]byte, error) {
+	var input bytes.Buffer
+	for {
+		chunk, isHeader, err := recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isHeader {
+			return nil, status.Error(codes.InvalidArgument, "only the first message may be a header")
+		}
+		if input.Len()+len(chunk) > s.maxInputSize {
+			return nil, status.Errorf(codes.ResourceExhausted, "input exceeds %d bytes", s.maxInputSize)
+		}
+		input.Write(chunk)
+	}
+
+	if input.Len() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no input chunks received")
+	}
+	return input.Bytes(), nil
+}
+
+// sendChunks sends output in chunkSize pieces
+func sendChunks(output []byte, send func([]byte) error) error {
+	for len(output) > 0 {
+		n := min(chunkSize, len(output))
+		if err := send(output[:n]); err != nil {
+			return err
+		}
+		output = output[n:]
+	}
+	return nil
+}
+
+// checkBatchSize applies the HTTP batch endpoints' limits
+func (s *Service) checkBatchSize(n int) error {
+	if n == 0 {
+		return status.Error(codes.InvalidArgument, "empty batch")
+	}
+	if n > s.maxBatchSize {
+		return status.Errorf(codes.InvalidArgument, "batch too large: maximum %d items per batch", s.maxBatchSize)
+	}
+	return nil
+}
+
+// audioRequest maps a unary request to the converter's request
+func audioRequest(in *pb.ConvertAudioRequest) (*services.AudioRequest, error) {
+	req := newAudioRequest(in.GetOptions())
+	switch source := in.GetSource().(type) {
+	case *pb.ConvertAudioRequest_Data:
+		if len(source.Data) == 0 {
+			return nil, status.Error(codes.InvalidArgument, "empty data")
+		}
+		req.Input = source.Data
+	case *pb.ConvertAudioRequest_Url:
+		req.Data, req.IsURL = source.Url, true
+	default:
+		return nil, status.Error(codes.InvalidArgument, "missing data or url")
+	}
+	return req, nil
+}
+
+// newAudioRequest builds a request returning raw output
+func newAudioRequest(opts *pb.AudioOptions) *services.AudioRequest {
+	if opts == nil {
+		opts = &pb.AudioOptions{}
+	}
+	return &services.AudioRequest{
+		InputType:       opts.GetInputType(),
+		OutputFormat:    opts.GetOutputFormat(),
+		Preset:          opts.GetPreset(),
+		SkipIfCompliant: opts.SkipIfCompliant,
+		IncludeWaveform: opts.GetIncludeWaveform(),
+		Normalize:       opts.GetNormalize(),
+		TargetSizeMB:    opts.GetTargetSizeMb(),
+		RawOutput:       true,
+	}
+}
+
+// audioResult maps the converter's response metadata
+func audioResult(resp *services.AudioResponse) *pb.AudioResult {
+	result := &pb.AudioResult{
+		MimeType:              resp.MimeType,
+		Duration:              int32(resp.Duration),
+		Size:                  int64(resp.Size),
+		Skipped:               resp.Skipped,
+		DurationLimitExceeded: resp.DurationLimitExceeded,
+		Bitrate:               int32(resp.Bitrate),
+		EncoderVariant:        resp.EncoderVariant,
+	}
+	if resp.Waveform != "" {
+		result.Waveform, _ = base64.StdEncoding.DecodeString(resp.Waveform)
+	}
+	return result
+}
+
+// imageRequest maps a unary request to the converter's request
+func imageRequest(in *pb.ConvertImageRequest) (*services.ImageRequest, error) {
+	req := newImageRequest(in.GetOptions())
+	switch source := in.GetSource().(type) {
+	case *pb.ConvertImageRequest_Data:
+		if len(source.Data) == 0 {
+			return nil, status.Error(codes.InvalidArgument, "empty data")
+		}
+		req.Input = source.Data
+	case *pb.ConvertImageRequest_Url:
+		req.Data, req.IsURL = source.Url, true
+	default:
+		return nil, status.Error(codes.InvalidArgument, "missing data or url")
+	}
+	return req, nil
+}
+
+// newImageRequest builds a request returning raw output
+func newImageRequest(opts *pb.ImageOptions) *services.ImageRequest {
+	if opts == nil {
+		opts = &pb.ImageOptions{}
+	}
+	return &services.ImageRequest{
+		MaxWidth:          int(opts.GetMaxWidth()),
+		MaxHeight:         int(opts.GetMaxHeight()),
+		MinWidth:          int(opts.GetMinWidth()),
+		MinHeight:         int(opts.GetMinHeight()),
+		Quality:           int(opts.GetQuality()),
+		SkipIfCompliant:   opts.SkipIfCompliant,
+		QualityCheck:      opts.QualityCheck,
+		Background:        opts.GetBackground(),
+		PreserveAlpha:     opts.GetPreserveAlpha(),
+		GenerateThumbnail: opts.GetGenerateThumbnail(),
+		MaxFileSizeKB:     int(opts.GetMaxFileSizeKb()),
+		RawOutput:         true,
+	}
+}
+
+// imageResult maps the converter's response metadata
+func imageResult(resp *services.ImageResponse) *pb.ImageResult {
+	result := &pb.ImageResult{
+		MimeType: resp.MimeType,
+		Width:    int32(resp.Width),
+		Height:   int32(resp.Height),
+		Size:     int64(resp.Size),
+		Skipped:  resp.Skipped,
+		Upscaled: resp.Upscaled,
+
+		EncodedQuality: int32(resp.EncodedQuality),
+	}
+	if resp.Quality != nil {
+		result.Quality = &pb.QualityScore{Ssim: resp.Quality.SSIM, Psnr: resp.Quality.PSNR}
+	}
+	if resp.JPEGThumbnail != "" {
+		result.JpegThumbnail, _ = base64.StdEncoding.DecodeString(resp.JPEGThumbnail)
+	}
+	return result
+}
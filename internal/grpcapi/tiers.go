@@ -0,0 +1,62 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"whats-convert-api/internal/services"
+)
+
+// admitCall applies the caller's tier to conversion calls like the HTTP
+// tier middleware: the call counts against the tier's rate limit and, for
+// unary calls, req must fit the tier's file size limit. Streamed uploads are
+// only bounded by the message size.
+func (o callObserver) admitCall(ctx context.Context, method string, req any) error {
+	tier := services.TierFrom(ctx)
+	if tier == nil || !strings.Contains(method, "/Convert") {
+		return nil
+	}
+
+	if message, ok := req.(proto.Message); ok && tier.MaxFileSize > 0 && int64(proto.Size(message)) > tier.MaxFileSize {
+		return tierStatus(codes.ResourceExhausted, "tier_file_too_large",
+			fmt.Sprintf("the %s tier accepts requests up to %d bytes", tier.Name, tier.MaxFileSize), nil)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	caller := "key:" + metadataCarrier(md).Get(apiKeyMetadata)
+	if caller == "key:" {
+		caller = "ip:"
+		if p, ok := peer.FromContext(ctx); ok {
+			host, _, _ := net.SplitHostPort(p.Addr.String())
+			caller += host
+		}
+	}
+	decision := o.tiers.Allow(caller, tier)
+	if !decision.Allowed {
+		retryAfter := max(int(math.Ceil(decision.RetryAfter.Seconds())), 1)
+		return tierStatus(codes.ResourceExhausted, "rate_limited",
+			fmt.Sprintf("the %s tier allows %d requests per minute", tier.Name, tier.RateLimit),
+			map[string]string{"retry_after": strconv.Itoa(retryAfter)})
+	}
+	return nil
+}
+
+// tierStatus is a status carrying reason in its ErrorInfo
+func tierStatus(code codes.Code, reason, message string, metadata map[string]string) error {
+	st, err := status.New(code, message).WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain, Metadata: metadata})
+	if err != nil {
+		return status.Error(code, message)
+	}
+	return st.Err()
+}
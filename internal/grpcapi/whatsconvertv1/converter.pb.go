@@ -0,0 +1,1674 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        v6.32.1
+// source: whatsconvert/v1/converter.proto
+
+package whatsconvertv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// AudioOptions mirror the JSON fields of POST /convert/audio
+type AudioOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Deprecated: the input format is detected from its content
+	InputType string `protobuf:"bytes,1,opt,name=input_type,json=inputType,proto3" json:"input_type,omitempty"`
+	// opus (default), mp3 or wav
+	OutputFormat string `protobuf:"bytes,2,opt,name=output_format,json=outputFormat,proto3" json:"output_format,omitempty"`
+	// whatsapp (default) or reverse for received voice notes
+	Preset string `protobuf:"bytes,3,opt,name=preset,proto3" json:"preset,omitempty"`
+	// Return mono 48kHz Ogg/Opus input without re-encoding (default SKIP_COMPLIANT_INPUTS)
+	SkipIfCompliant *bool `protobuf:"varint,4,opt,name=skip_if_compliant,json=skipIfCompliant,proto3,oneof" json:"skip_if_compliant,omitempty"`
+	// Also return waveform, the voice note amplitude bars
+	IncludeWaveform bool `protobuf:"varint,5,opt,name=include_waveform,json=includeWaveform,proto3" json:"include_waveform,omitempty"`
+	// Normalize loudness to the EBU R128 targets (AUDIO_LOUDNORM_*)
+	Normalize bool `protobuf:"varint,6,opt,name=normalize,proto3" json:"normalize,omitempty"`
+	// Pick the Opus/MP3 bitrate so the output fits in this many MiB
+	TargetSizeMb  float64 `protobuf:"fixed64,7,opt,name=target_size_mb,json=targetSizeMb,proto3" json:"target_size_mb,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AudioOptions) Reset() {
+	*x = AudioOptions{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioOptions) ProtoMessage() {}
+
+func (x *AudioOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioOptions.ProtoReflect.Descriptor instead.
+func (*AudioOptions) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AudioOptions) GetInputType() string {
+	if x != nil {
+		return x.InputType
+	}
+	return ""
+}
+
+func (x *AudioOptions) GetOutputFormat() string {
+	if x != nil {
+		return x.OutputFormat
+	}
+	return ""
+}
+
+func (x *AudioOptions) GetPreset() string {
+	if x != nil {
+		return x.Preset
+	}
+	return ""
+}
+
+func (x *AudioOptions) GetSkipIfCompliant() bool {
+	if x != nil && x.SkipIfCompliant != nil {
+		return *x.SkipIfCompliant
+	}
+	return false
+}
+
+func (x *AudioOptions) GetIncludeWaveform() bool {
+	if x != nil {
+		return x.IncludeWaveform
+	}
+	return false
+}
+
+func (x *AudioOptions) GetNormalize() bool {
+	if x != nil {
+		return x.Normalize
+	}
+	return false
+}
+
+func (x *AudioOptions) GetTargetSizeMb() float64 {
+	if x != nil {
+		return x.TargetSizeMb
+	}
+	return 0
+}
+
+// AudioResult describes a converted audio file
+type AudioResult struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	MimeType string                 `protobuf:"bytes,1,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	// Duration in seconds
+	Duration int32 `protobuf:"varint,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	// Output size in bytes
+	Size int64 `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	// Input was already compliant and returned without re-encoding
+	Skipped bool `protobuf:"varint,4,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	// Input was longer than MAX_AUDIO_DURATION (flag policy)
+	DurationLimitExceeded bool `protobuf:"varint,5,opt,name=duration_limit_exceeded,json=durationLimitExceeded,proto3" json:"duration_limit_exceeded,omitempty"`
+	// 64 amplitudes from 0 to 100 for WhatsApp's voice note waveform
+	// (include_waveform only)
+	Waveform []byte `protobuf:"bytes,6,opt,name=waveform,proto3" json:"waveform,omitempty"`
+	// Bitrate in kbit/s chosen for target_size_mb
+	Bitrate int32 `protobuf:"varint,7,opt,name=bitrate,proto3" json:"bitrate,omitempty"`
+	// stable or candidate encoder options, set while AUDIO_CANDIDATE_PERCENT
+	// is above 0
+	EncoderVariant string `protobuf:"bytes,8,opt,name=encoder_variant,json=encoderVariant,proto3" json:"encoder_variant,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AudioResult) Reset() {
+	*x = AudioResult{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioResult) ProtoMessage() {}
+
+func (x *AudioResult) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioResult.ProtoReflect.Descriptor instead.
+func (*AudioResult) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AudioResult) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *AudioResult) GetDuration() int32 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *AudioResult) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *AudioResult) GetSkipped() bool {
+	if x != nil {
+		return x.Skipped
+	}
+	return false
+}
+
+func (x *AudioResult) GetDurationLimitExceeded() bool {
+	if x != nil {
+		return x.DurationLimitExceeded
+	}
+	return false
+}
+
+func (x *AudioResult) GetWaveform() []byte {
+	if x != nil {
+		return x.Waveform
+	}
+	return nil
+}
+
+func (x *AudioResult) GetBitrate() int32 {
+	if x != nil {
+		return x.Bitrate
+	}
+	return 0
+}
+
+func (x *AudioResult) GetEncoderVariant() string {
+	if x != nil {
+		return x.EncoderVariant
+	}
+	return ""
+}
+
+type ConvertAudioRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Source:
+	//
+	//	*ConvertAudioRequest_Data
+	//	*ConvertAudioRequest_Url
+	Source        isConvertAudioRequest_Source `protobuf_oneof:"source"`
+	Options       *AudioOptions                `protobuf:"bytes,3,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertAudioRequest) Reset() {
+	*x = ConvertAudioRequest{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertAudioRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertAudioRequest) ProtoMessage() {}
+
+func (x *ConvertAudioRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertAudioRequest.ProtoReflect.Descriptor instead.
+func (*ConvertAudioRequest) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ConvertAudioRequest) GetSource() isConvertAudioRequest_Source {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *ConvertAudioRequest) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Source.(*ConvertAudioRequest_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *ConvertAudioRequest) GetUrl() string {
+	if x != nil {
+		if x, ok := x.Source.(*ConvertAudioRequest_Url); ok {
+			return x.Url
+		}
+	}
+	return ""
+}
+
+func (x *ConvertAudioRequest) GetOptions() *AudioOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type isConvertAudioRequest_Source interface {
+	isConvertAudioRequest_Source()
+}
+
+type ConvertAudioRequest_Data struct {
+	// Raw input bytes
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3,oneof"`
+}
+
+type ConvertAudioRequest_Url struct {
+	// http(s) URL the server downloads the input from
+	Url string `protobuf:"bytes,2,opt,name=url,proto3,oneof"`
+}
+
+func (*ConvertAudioRequest_Data) isConvertAudioRequest_Source() {}
+
+func (*ConvertAudioRequest_Url) isConvertAudioRequest_Source() {}
+
+type ConvertAudioResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Result *AudioResult           `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	// Converted bytes
+	Data          []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertAudioResponse) Reset() {
+	*x = ConvertAudioResponse{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertAudioResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertAudioResponse) ProtoMessage() {}
+
+func (x *ConvertAudioResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertAudioResponse.ProtoReflect.Descriptor instead.
+func (*ConvertAudioResponse) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ConvertAudioResponse) GetResult() *AudioResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *ConvertAudioResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ConvertAudioBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Requests      []*ConvertAudioRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertAudioBatchRequest) Reset() {
+	*x = ConvertAudioBatchRequest{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertAudioBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertAudioBatchRequest) ProtoMessage() {}
+
+func (x *ConvertAudioBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertAudioBatchRequest.ProtoReflect.Descriptor instead.
+func (*ConvertAudioBatchRequest) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConvertAudioBatchRequest) GetRequests() []*ConvertAudioRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type ConvertAudioBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Results in request order
+	Results       []*ConvertAudioResponse `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertAudioBatchResponse) Reset() {
+	*x = ConvertAudioBatchResponse{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertAudioBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertAudioBatchResponse) ProtoMessage() {}
+
+func (x *ConvertAudioBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertAudioBatchResponse.ProtoReflect.Descriptor instead.
+func (*ConvertAudioBatchResponse) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ConvertAudioBatchResponse) GetResults() []*ConvertAudioResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// ConvertAudioStreamRequest is a header first, then chunks of the input
+// until the client closes its side. A header with a url takes no chunks.
+type ConvertAudioStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ConvertAudioStreamRequest_Header
+	//	*ConvertAudioStreamRequest_Chunk
+	Payload       isConvertAudioStreamRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertAudioStreamRequest) Reset() {
+	*x = ConvertAudioStreamRequest{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertAudioStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertAudioStreamRequest) ProtoMessage() {}
+
+func (x *ConvertAudioStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertAudioStreamRequest.ProtoReflect.Descriptor instead.
+func (*ConvertAudioStreamRequest) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ConvertAudioStreamRequest) GetPayload() isConvertAudioStreamRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ConvertAudioStreamRequest) GetHeader() *AudioStreamHeader {
+	if x != nil {
+		if x, ok := x.Payload.(*ConvertAudioStreamRequest_Header); ok {
+			return x.Header
+		}
+	}
+	return nil
+}
+
+func (x *ConvertAudioStreamRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*ConvertAudioStreamRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isConvertAudioStreamRequest_Payload interface {
+	isConvertAudioStreamRequest_Payload()
+}
+
+type ConvertAudioStreamRequest_Header struct {
+	Header *AudioStreamHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type ConvertAudioStreamRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*ConvertAudioStreamRequest_Header) isConvertAudioStreamRequest_Payload() {}
+
+func (*ConvertAudioStreamRequest_Chunk) isConvertAudioStreamRequest_Payload() {}
+
+// AudioStreamHeader opens a ConvertAudioStream call
+type AudioStreamHeader struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Options *AudioOptions          `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
+	// Download the input from this URL instead of streaming it
+	Url           string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AudioStreamHeader) Reset() {
+	*x = AudioStreamHeader{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioStreamHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioStreamHeader) ProtoMessage() {}
+
+func (x *AudioStreamHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioStreamHeader.ProtoReflect.Descriptor instead.
+func (*AudioStreamHeader) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AudioStreamHeader) GetOptions() *AudioOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *AudioStreamHeader) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// ConvertAudioStreamResponse is the result first, then chunks of the output
+type ConvertAudioStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ConvertAudioStreamResponse_Result
+	//	*ConvertAudioStreamResponse_Chunk
+	Payload       isConvertAudioStreamResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertAudioStreamResponse) Reset() {
+	*x = ConvertAudioStreamResponse{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertAudioStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertAudioStreamResponse) ProtoMessage() {}
+
+func (x *ConvertAudioStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertAudioStreamResponse.ProtoReflect.Descriptor instead.
+func (*ConvertAudioStreamResponse) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ConvertAudioStreamResponse) GetPayload() isConvertAudioStreamResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ConvertAudioStreamResponse) GetResult() *AudioResult {
+	if x != nil {
+		if x, ok := x.Payload.(*ConvertAudioStreamResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *ConvertAudioStreamResponse) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*ConvertAudioStreamResponse_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isConvertAudioStreamResponse_Payload interface {
+	isConvertAudioStreamResponse_Payload()
+}
+
+type ConvertAudioStreamResponse_Result struct {
+	Result *AudioResult `protobuf:"bytes,1,opt,name=result,proto3,oneof"`
+}
+
+type ConvertAudioStreamResponse_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*ConvertAudioStreamResponse_Result) isConvertAudioStreamResponse_Payload() {}
+
+func (*ConvertAudioStreamResponse_Chunk) isConvertAudioStreamResponse_Payload() {}
+
+// ImageOptions mirror the JSON fields of POST /convert/image
+type ImageOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Max width and height (default 1920)
+	MaxWidth  int32 `protobuf:"varint,1,opt,name=max_width,json=maxWidth,proto3" json:"max_width,omitempty"`
+	MaxHeight int32 `protobuf:"varint,2,opt,name=max_height,json=maxHeight,proto3" json:"max_height,omitempty"`
+	// Enlarge smaller inputs to at least this size
+	MinWidth  int32 `protobuf:"varint,3,opt,name=min_width,json=minWidth,proto3" json:"min_width,omitempty"`
+	MinHeight int32 `protobuf:"varint,4,opt,name=min_height,json=minHeight,proto3" json:"min_height,omitempty"`
+	// JPEG quality 1-100 (default 95)
+	Quality int32 `protobuf:"varint,5,opt,name=quality,proto3" json:"quality,omitempty"`
+	// Return JPEG input within bounds without re-encoding (default SKIP_COMPLIANT_INPUTS)
+	SkipIfCompliant *bool `protobuf:"varint,6,opt,name=skip_if_compliant,json=skipIfCompliant,proto3,oneof" json:"skip_if_compliant,omitempty"`
+	// Score the output against the input (default IMAGE_QUALITY_CHECK)
+	QualityCheck *bool `protobuf:"varint,7,opt,name=quality_check,json=qualityCheck,proto3,oneof" json:"quality_check,omitempty"`
+	// Colour transparent areas are flattened onto (default IMAGE_BACKGROUND)
+	Background string `protobuf:"bytes,8,opt,name=background,proto3" json:"background,omitempty"`
+	// Keep transparency by returning WebP or PNG (ALPHA_OUTPUT_FORMAT)
+	PreserveAlpha bool `protobuf:"varint,9,opt,name=preserve_alpha,json=preserveAlpha,proto3" json:"preserve_alpha,omitempty"`
+	// Also return jpeg_thumbnail, the message preview
+	GenerateThumbnail bool `protobuf:"varint,10,opt,name=generate_thumbnail,json=generateThumbnail,proto3" json:"generate_thumbnail,omitempty"`
+	// Lower the quality until the output fits in this many KB
+	MaxFileSizeKb int32 `protobuf:"varint,11,opt,name=max_file_size_kb,json=maxFileSizeKb,proto3" json:"max_file_size_kb,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImageOptions) Reset() {
+	*x = ImageOptions{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImageOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageOptions) ProtoMessage() {}
+
+func (x *ImageOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageOptions.ProtoReflect.Descriptor instead.
+func (*ImageOptions) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ImageOptions) GetMaxWidth() int32 {
+	if x != nil {
+		return x.MaxWidth
+	}
+	return 0
+}
+
+func (x *ImageOptions) GetMaxHeight() int32 {
+	if x != nil {
+		return x.MaxHeight
+	}
+	return 0
+}
+
+func (x *ImageOptions) GetMinWidth() int32 {
+	if x != nil {
+		return x.MinWidth
+	}
+	return 0
+}
+
+func (x *ImageOptions) GetMinHeight() int32 {
+	if x != nil {
+		return x.MinHeight
+	}
+	return 0
+}
+
+func (x *ImageOptions) GetQuality() int32 {
+	if x != nil {
+		return x.Quality
+	}
+	return 0
+}
+
+func (x *ImageOptions) GetSkipIfCompliant() bool {
+	if x != nil && x.SkipIfCompliant != nil {
+		return *x.SkipIfCompliant
+	}
+	return false
+}
+
+func (x *ImageOptions) GetQualityCheck() bool {
+	if x != nil && x.QualityCheck != nil {
+		return *x.QualityCheck
+	}
+	return false
+}
+
+func (x *ImageOptions) GetBackground() string {
+	if x != nil {
+		return x.Background
+	}
+	return ""
+}
+
+func (x *ImageOptions) GetPreserveAlpha() bool {
+	if x != nil {
+		return x.PreserveAlpha
+	}
+	return false
+}
+
+func (x *ImageOptions) GetGenerateThumbnail() bool {
+	if x != nil {
+		return x.GenerateThumbnail
+	}
+	return false
+}
+
+func (x *ImageOptions) GetMaxFileSizeKb() int32 {
+	if x != nil {
+		return x.MaxFileSizeKb
+	}
+	return 0
+}
+
+// QualityScore is the similarity of a converted image to its input
+type QualityScore struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ssim          float64                `protobuf:"fixed64,1,opt,name=ssim,proto3" json:"ssim,omitempty"`
+	Psnr          float64                `protobuf:"fixed64,2,opt,name=psnr,proto3" json:"psnr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QualityScore) Reset() {
+	*x = QualityScore{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QualityScore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QualityScore) ProtoMessage() {}
+
+func (x *QualityScore) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QualityScore.ProtoReflect.Descriptor instead.
+func (*QualityScore) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *QualityScore) GetSsim() float64 {
+	if x != nil {
+		return x.Ssim
+	}
+	return 0
+}
+
+func (x *QualityScore) GetPsnr() float64 {
+	if x != nil {
+		return x.Psnr
+	}
+	return 0
+}
+
+// ImageResult describes a converted image
+type ImageResult struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	MimeType string                 `protobuf:"bytes,1,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Width    int32                  `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height   int32                  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	// Output size in bytes
+	Size int64 `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	// Input was already compliant and returned without re-encoding
+	Skipped bool `protobuf:"varint,5,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	// Input was enlarged to reach min_width/min_height
+	Upscaled bool `protobuf:"varint,6,opt,name=upscaled,proto3" json:"upscaled,omitempty"`
+	// Set when quality checking is on
+	Quality *QualityScore `protobuf:"bytes,7,opt,name=quality,proto3" json:"quality,omitempty"`
+	// JPEG of at most 72px per side and 20KB for WhatsApp's jpegThumbnail
+	// (generate_thumbnail only)
+	JpegThumbnail []byte `protobuf:"bytes,8,opt,name=jpeg_thumbnail,json=jpegThumbnail,proto3" json:"jpeg_thumbnail,omitempty"`
+	// Quality the output was encoded at (max_file_size_kb only)
+	EncodedQuality int32 `protobuf:"varint,9,opt,name=encoded_quality,json=encodedQuality,proto3" json:"encoded_quality,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ImageResult) Reset() {
+	*x = ImageResult{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImageResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageResult) ProtoMessage() {}
+
+func (x *ImageResult) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageResult.ProtoReflect.Descriptor instead.
+func (*ImageResult) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ImageResult) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *ImageResult) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *ImageResult) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ImageResult) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ImageResult) GetSkipped() bool {
+	if x != nil {
+		return x.Skipped
+	}
+	return false
+}
+
+func (x *ImageResult) GetUpscaled() bool {
+	if x != nil {
+		return x.Upscaled
+	}
+	return false
+}
+
+func (x *ImageResult) GetQuality() *QualityScore {
+	if x != nil {
+		return x.Quality
+	}
+	return nil
+}
+
+func (x *ImageResult) GetJpegThumbnail() []byte {
+	if x != nil {
+		return x.JpegThumbnail
+	}
+	return nil
+}
+
+func (x *ImageResult) GetEncodedQuality() int32 {
+	if x != nil {
+		return x.EncodedQuality
+	}
+	return 0
+}
+
+type ConvertImageRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Source:
+	//
+	//	*ConvertImageRequest_Data
+	//	*ConvertImageRequest_Url
+	Source        isConvertImageRequest_Source `protobuf_oneof:"source"`
+	Options       *ImageOptions                `protobuf:"bytes,3,opt,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertImageRequest) Reset() {
+	*x = ConvertImageRequest{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertImageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertImageRequest) ProtoMessage() {}
+
+func (x *ConvertImageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertImageRequest.ProtoReflect.Descriptor instead.
+func (*ConvertImageRequest) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ConvertImageRequest) GetSource() isConvertImageRequest_Source {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *ConvertImageRequest) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Source.(*ConvertImageRequest_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *ConvertImageRequest) GetUrl() string {
+	if x != nil {
+		if x, ok := x.Source.(*ConvertImageRequest_Url); ok {
+			return x.Url
+		}
+	}
+	return ""
+}
+
+func (x *ConvertImageRequest) GetOptions() *ImageOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type isConvertImageRequest_Source interface {
+	isConvertImageRequest_Source()
+}
+
+type ConvertImageRequest_Data struct {
+	// Raw input bytes
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3,oneof"`
+}
+
+type ConvertImageRequest_Url struct {
+	// http(s) URL the server downloads the input from
+	Url string `protobuf:"bytes,2,opt,name=url,proto3,oneof"`
+}
+
+func (*ConvertImageRequest_Data) isConvertImageRequest_Source() {}
+
+func (*ConvertImageRequest_Url) isConvertImageRequest_Source() {}
+
+type ConvertImageResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Result *ImageResult           `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	// Converted bytes
+	Data          []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertImageResponse) Reset() {
+	*x = ConvertImageResponse{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertImageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertImageResponse) ProtoMessage() {}
+
+func (x *ConvertImageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertImageResponse.ProtoReflect.Descriptor instead.
+func (*ConvertImageResponse) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ConvertImageResponse) GetResult() *ImageResult {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *ConvertImageResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ConvertImageBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Requests      []*ConvertImageRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertImageBatchRequest) Reset() {
+	*x = ConvertImageBatchRequest{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertImageBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertImageBatchRequest) ProtoMessage() {}
+
+func (x *ConvertImageBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertImageBatchRequest.ProtoReflect.Descriptor instead.
+func (*ConvertImageBatchRequest) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ConvertImageBatchRequest) GetRequests() []*ConvertImageRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type ConvertImageBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Results in request order
+	Results       []*ConvertImageResponse `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertImageBatchResponse) Reset() {
+	*x = ConvertImageBatchResponse{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertImageBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertImageBatchResponse) ProtoMessage() {}
+
+func (x *ConvertImageBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertImageBatchResponse.ProtoReflect.Descriptor instead.
+func (*ConvertImageBatchResponse) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ConvertImageBatchResponse) GetResults() []*ConvertImageResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// ConvertImageStreamRequest is a header first, then chunks of the input
+// until the client closes its side. A header with a url takes no chunks.
+type ConvertImageStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ConvertImageStreamRequest_Header
+	//	*ConvertImageStreamRequest_Chunk
+	Payload       isConvertImageStreamRequest_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertImageStreamRequest) Reset() {
+	*x = ConvertImageStreamRequest{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertImageStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertImageStreamRequest) ProtoMessage() {}
+
+func (x *ConvertImageStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertImageStreamRequest.ProtoReflect.Descriptor instead.
+func (*ConvertImageStreamRequest) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ConvertImageStreamRequest) GetPayload() isConvertImageStreamRequest_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ConvertImageStreamRequest) GetHeader() *ImageStreamHeader {
+	if x != nil {
+		if x, ok := x.Payload.(*ConvertImageStreamRequest_Header); ok {
+			return x.Header
+		}
+	}
+	return nil
+}
+
+func (x *ConvertImageStreamRequest) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*ConvertImageStreamRequest_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isConvertImageStreamRequest_Payload interface {
+	isConvertImageStreamRequest_Payload()
+}
+
+type ConvertImageStreamRequest_Header struct {
+	Header *ImageStreamHeader `protobuf:"bytes,1,opt,name=header,proto3,oneof"`
+}
+
+type ConvertImageStreamRequest_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*ConvertImageStreamRequest_Header) isConvertImageStreamRequest_Payload() {}
+
+func (*ConvertImageStreamRequest_Chunk) isConvertImageStreamRequest_Payload() {}
+
+// ImageStreamHeader opens a ConvertImageStream call
+type ImageStreamHeader struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Options *ImageOptions          `protobuf:"bytes,1,opt,name=options,proto3" json:"options,omitempty"`
+	// Download the input from this URL instead of streaming it
+	Url           string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImageStreamHeader) Reset() {
+	*x = ImageStreamHeader{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImageStreamHeader) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImageStreamHeader) ProtoMessage() {}
+
+func (x *ImageStreamHeader) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImageStreamHeader.ProtoReflect.Descriptor instead.
+func (*ImageStreamHeader) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ImageStreamHeader) GetOptions() *ImageOptions {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *ImageStreamHeader) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// ConvertImageStreamResponse is the result first, then chunks of the output
+type ConvertImageStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*ConvertImageStreamResponse_Result
+	//	*ConvertImageStreamResponse_Chunk
+	Payload       isConvertImageStreamResponse_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConvertImageStreamResponse) Reset() {
+	*x = ConvertImageStreamResponse{}
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConvertImageStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConvertImageStreamResponse) ProtoMessage() {}
+
+func (x *ConvertImageStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_whatsconvert_v1_converter_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConvertImageStreamResponse.ProtoReflect.Descriptor instead.
+func (*ConvertImageStreamResponse) Descriptor() ([]byte, []int) {
+	return file_whatsconvert_v1_converter_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ConvertImageStreamResponse) GetPayload() isConvertImageStreamResponse_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *ConvertImageStreamResponse) GetResult() *ImageResult {
+	if x != nil {
+		if x, ok := x.Payload.(*ConvertImageStreamResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *ConvertImageStreamResponse) GetChunk() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*ConvertImageStreamResponse_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+type isConvertImageStreamResponse_Payload interface {
+	isConvertImageStreamResponse_Payload()
+}
+
+type ConvertImageStreamResponse_Result struct {
+	Result *ImageResult `protobuf:"bytes,1,opt,name=result,proto3,oneof"`
+}
+
+type ConvertImageStreamResponse_Chunk struct {
+	Chunk []byte `protobuf:"bytes,2,opt,name=chunk,proto3,oneof"`
+}
+
+func (*ConvertImageStreamResponse_Result) isConvertImageStreamResponse_Payload() {}
+
+func (*ConvertImageStreamResponse_Chunk) isConvertImageStreamResponse_Payload() {}
+
+var File_whatsconvert_v1_converter_proto protoreflect.FileDescriptor
+
+const file_whatsconvert_v1_converter_proto_rawDesc = "" +
+	"\n" +
+	"\x1fwhatsconvert/v1/converter.proto\x12\x0fwhatsconvert.v1\"\xa0\x02\n" +
+	"\fAudioOptions\x12\x1d\n" +
+	"\n" +
+	"input_type\x18\x01 \x01(\tR\tinputType\x12#\n" +
+	"\routput_format\x18\x02 \x01(\tR\foutputFormat\x12\x16\n" +
+	"\x06preset\x18\x03 \x01(\tR\x06preset\x12/\n" +
+	"\x11skip_if_compliant\x18\x04 \x01(\bH\x00R\x0fskipIfCompliant\x88\x01\x01\x12)\n" +
+	"\x10include_waveform\x18\x05 \x01(\bR\x0fincludeWaveform\x12\x1c\n" +
+	"\tnormalize\x18\x06 \x01(\bR\tnormalize\x12$\n" +
+	"\x0etarget_size_mb\x18\a \x01(\x01R\ftargetSizeMbB\x14\n" +
+	"\x12_skip_if_compliant\"\x8b\x02\n" +
+	"\vAudioResult\x12\x1b\n" +
+	"\tmime_type\x18\x01 \x01(\tR\bmimeType\x12\x1a\n" +
+	"\bduration\x18\x02 \x01(\x05R\bduration\x12\x12\n" +
+	"\x04size\x18\x03 \x01(\x03R\x04size\x12\x18\n" +
+	"\askipped\x18\x04 \x01(\bR\askipped\x126\n" +
+	"\x17duration_limit_exceeded\x18\x05 \x01(\bR\x15durationLimitExceeded\x12\x1a\n" +
+	"\bwaveform\x18\x06 \x01(\fR\bwaveform\x12\x18\n" +
+	"\abitrate\x18\a \x01(\x05R\abitrate\x12'\n" +
+	"\x0fencoder_variant\x18\b \x01(\tR\x0eencoderVariant\"\x82\x01\n" +
+	"\x13ConvertAudioRequest\x12\x14\n" +
+	"\x04data\x18\x01 \x01(\fH\x00R\x04data\x12\x12\n" +
+	"\x03url\x18\x02 \x01(\tH\x00R\x03url\x127\n" +
+	"\aoptions\x18\x03 \x01(\v2\x1d.whatsconvert.v1.AudioOptionsR\aoptionsB\b\n" +
+	"\x06source\"`\n" +
+	"\x14ConvertAudioResponse\x124\n" +
+	"\x06result\x18\x01 \x01(\v2\x1c.whatsconvert.v1.AudioResultR\x06result\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\\\n" +
+	"\x18ConvertAudioBatchRequest\x12@\n" +
+	"\brequests\x18\x01 \x03(\v2$.whatsconvert.v1.ConvertAudioRequestR\brequests\"\\\n" +
+	"\x19ConvertAudioBatchResponse\x12?\n" +
+	"\aresults\x18\x01 \x03(\v2%.whatsconvert.v1.ConvertAudioResponseR\aresults\"|\n" +
+	"\x19ConvertAudioStreamRequest\x12<\n" +
+	"\x06header\x18\x01 \x01(\v2\".whatsconvert.v1.AudioStreamHeaderH\x00R\x06header\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"^\n" +
+	"\x11AudioStreamHeader\x127\n" +
+	"\aoptions\x18\x01 \x01(\v2\x1d.whatsconvert.v1.AudioOptionsR\aoptions\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"w\n" +
+	"\x1aConvertAudioStreamResponse\x126\n" +
+	"\x06result\x18\x01 \x01(\v2\x1c.whatsconvert.v1.AudioResultH\x00R\x06result\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"\xc2\x03\n" +
+	"\fImageOptions\x12\x1b\n" +
+	"\tmax_width\x18\x01 \x01(\x05R\bmaxWidth\x12\x1d\n" +
+	"\n" +
+	"max_height\x18\x02 \x01(\x05R\tmaxHeight\x12\x1b\n" +
+	"\tmin_width\x18\x03 \x01(\x05R\bminWidth\x12\x1d\n" +
+	"\n" +
+	"min_height\x18\x04 \x01(\x05R\tminHeight\x12\x18\n" +
+	"\aquality\x18\x05 \x01(\x05R\aquality\x12/\n" +
+	"\x11skip_if_compliant\x18\x06 \x01(\bH\x00R\x0fskipIfCompliant\x88\x01\x01\x12(\n" +
+	"\rquality_check\x18\a \x01(\bH\x01R\fqualityCheck\x88\x01\x01\x12\x1e\n" +
+	"\n" +
+	"background\x18\b \x01(\tR\n" +
+	"background\x12%\n" +
+	"\x0epreserve_alpha\x18\t \x01(\bR\rpreserveAlpha\x12-\n" +
+	"\x12generate_thumbnail\x18\n" +
+	" \x01(\bR\x11generateThumbnail\x12'\n" +
+	"\x10max_file_size_kb\x18\v \x01(\x05R\rmaxFileSizeKbB\x14\n" +
+	"\x12_skip_if_compliantB\x10\n" +
+	"\x0e_quality_check\"6\n" +
+	"\fQualityScore\x12\x12\n" +
+	"\x04ssim\x18\x01 \x01(\x01R\x04ssim\x12\x12\n" +
+	"\x04psnr\x18\x02 \x01(\x01R\x04psnr\"\xab\x02\n" +
+	"\vImageResult\x12\x1b\n" +
+	"\tmime_type\x18\x01 \x01(\tR\bmimeType\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x05R\x06height\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x18\n" +
+	"\askipped\x18\x05 \x01(\bR\askipped\x12\x1a\n" +
+	"\bupscaled\x18\x06 \x01(\bR\bupscaled\x127\n" +
+	"\aquality\x18\a \x01(\v2\x1d.whatsconvert.v1.QualityScoreR\aquality\x12%\n" +
+	"\x0ejpeg_thumbnail\x18\b \x01(\fR\rjpegThumbnail\x12'\n" +
+	"\x0fencoded_quality\x18\t \x01(\x05R\x0eencodedQuality\"\x82\x01\n" +
+	"\x13ConvertImageRequest\x12\x14\n" +
+	"\x04data\x18\x01 \x01(\fH\x00R\x04data\x12\x12\n" +
+	"\x03url\x18\x02 \x01(\tH\x00R\x03url\x127\n" +
+	"\aoptions\x18\x03 \x01(\v2\x1d.whatsconvert.v1.ImageOptionsR\aoptionsB\b\n" +
+	"\x06source\"`\n" +
+	"\x14ConvertImageResponse\x124\n" +
+	"\x06result\x18\x01 \x01(\v2\x1c.whatsconvert.v1.ImageResultR\x06result\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\\\n" +
+	"\x18ConvertImageBatchRequest\x12@\n" +
+	"\brequests\x18\x01 \x03(\v2$.whatsconvert.v1.ConvertImageRequestR\brequests\"\\\n" +
+	"\x19ConvertImageBatchResponse\x12?\n" +
+	"\aresults\x18\x01 \x03(\v2%.whatsconvert.v1.ConvertImageResponseR\aresults\"|\n" +
+	"\x19ConvertImageStreamRequest\x12<\n" +
+	"\x06header\x18\x01 \x01(\v2\".whatsconvert.v1.ImageStreamHeaderH\x00R\x06header\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload\"^\n" +
+	"\x11ImageStreamHeader\x127\n" +
+	"\aoptions\x18\x01 \x01(\v2\x1d.whatsconvert.v1.ImageOptionsR\aoptions\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"w\n" +
+	"\x1aConvertImageStreamResponse\x126\n" +
+	"\x06result\x18\x01 \x01(\v2\x1c.whatsconvert.v1.ImageResultH\x00R\x06result\x12\x16\n" +
+	"\x05chunk\x18\x02 \x01(\fH\x00R\x05chunkB\t\n" +
+	"\apayload2\x8a\x05\n" +
+	"\x10ConverterService\x12[\n" +
+	"\fConvertAudio\x12$.whatsconvert.v1.ConvertAudioRequest\x1a%.whatsconvert.v1.ConvertAudioResponse\x12[\n" +
+	"\fConvertImage\x12$.whatsconvert.v1.ConvertImageRequest\x1a%.whatsconvert.v1.ConvertImageResponse\x12j\n" +
+	"\x11ConvertAudioBatch\x12).whatsconvert.v1.ConvertAudioBatchRequest\x1a*.whatsconvert.v1.ConvertAudioBatchResponse\x12j\n" +
+	"\x11ConvertImageBatch\x12).whatsconvert.v1.ConvertImageBatchRequest\x1a*.whatsconvert.v1.ConvertImageBatchResponse\x12q\n" +
+	"\x12ConvertAudioStream\x12*.whatsconvert.v1.ConvertAudioStreamRequest\x1a+.whatsconvert.v1.ConvertAudioStreamResponse(\x010\x01\x12q\n" +
+	"\x12ConvertImageStream\x12*.whatsconvert.v1.ConvertImageStreamRequest\x1a+.whatsconvert.v1.ConvertImageStreamResponse(\x010\x01BBZ@whats-convert-api/internal/grpcapi/whatsconvertv1;whatsconvertv1b\x06proto3"
+
+var (
+	file_whatsconvert_v1_converter_proto_rawDescOnce sync.Once
+	file_whatsconvert_v1_converter_proto_rawDescData []byte
+)
+
+func file_whatsconvert_v1_converter_proto_rawDescGZIP() []byte {
+	file_whatsconvert_v1_converter_proto_rawDescOnce.Do(func() {
+		file_whatsconvert_v1_converter_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_whatsconvert_v1_converter_proto_rawDesc), len(file_whatsconvert_v1_converter_proto_rawDesc)))
+	})
+	return file_whatsconvert_v1_converter_proto_rawDescData
+}
+
+var file_whatsconvert_v1_converter_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_whatsconvert_v1_converter_proto_goTypes = []any{
+	(*AudioOptions)(nil),               // 0: whatsconvert.v1.AudioOptions
+	(*AudioResult)(nil),                // 1: whatsconvert.v1.AudioResult
+	(*ConvertAudioRequest)(nil),        // 2: whatsconvert.v1.ConvertAudioRequest
+	(*ConvertAudioResponse)(nil),       // 3: whatsconvert.v1.ConvertAudioResponse
+	(*ConvertAudioBatchRequest)(nil),   // 4: whatsconvert.v1.ConvertAudioBatchRequest
+	(*ConvertAudioBatchResponse)(nil),  // 5: whatsconvert.v1.ConvertAudioBatchResponse
+	(*ConvertAudioStreamRequest)(nil),  // 6: whatsconvert.v1.ConvertAudioStreamRequest
+	(*AudioStreamHeader)(nil),          // 7: whatsconvert.v1.AudioStreamHeader
+	(*ConvertAudioStreamResponse)(nil), // 8: whatsconvert.v1.ConvertAudioStreamResponse
+	(*ImageOptions)(nil),               // 9: whatsconvert.v1.ImageOptions
+	(*QualityScore)(nil),               // 10: whatsconvert.v1.QualityScore
+	(*ImageResult)(nil),                // 11: whatsconvert.v1.ImageResult
+	(*ConvertImageRequest)(nil),        // 12: whatsconvert.v1.ConvertImageRequest
+	(*ConvertImageResponse)(nil),       // 13: whatsconvert.v1.ConvertImageResponse
+	(*ConvertImageBatchRequest)(nil),   // 14: whatsconvert.v1.ConvertImageBatchRequest
+	(*ConvertImageBatchResponse)(nil),  // 15: whatsconvert.v1.ConvertImageBatchResponse
+	(*ConvertImageStreamRequest)(nil),  // 16: whatsconvert.v1.ConvertImageStreamRequest
+	(*ImageStreamHeader)(nil),          // 17: whatsconvert.v1.ImageStreamHeader
+	(*ConvertImageStreamResponse)(nil), // 18: whatsconvert.v1.ConvertImageStreamResponse
+}
+var file_whatsconvert_v1_converter_proto_depIdxs = []int32{
+	0,  // 0: whatsconvert.v1.ConvertAudioRequest.options:type_name -> whatsconvert.v1.AudioOptions
+	1,  // 1: whatsconvert.v1.ConvertAudioResponse.result:type_name -> whatsconvert.v1.AudioResult
+	2,  // 2: whatsconvert.v1.ConvertAudioBatchRequest.requests:type_name -> whatsconvert.v1.ConvertAudioRequest
+	3,  // 3: whatsconvert.v1.ConvertAudioBatchResponse.results:type_name -> whatsconvert.v1.ConvertAudioResponse
+	7,  // 4: whatsconvert.v1.ConvertAudioStreamRequest.header:type_name -> whatsconvert.v1.AudioStreamHeader
+	0,  // 5: whatsconvert.v1.AudioStreamHeader.options:type_name -> whatsconvert.v1.AudioOptions
+	1,  // 6: whatsconvert.v1.ConvertAudioStreamResponse.result:type_name -> whatsconvert.v1.AudioResult
+	10, // 7: whatsconvert.v1.ImageResult.quality:type_name -> whatsconvert.v1.QualityScore
+	9,  // 8: whatsconvert.v1.ConvertImageRequest.options:type_name -> whatsconvert.v1.ImageOptions
+	11, // 9: whatsconvert.v1.ConvertImageResponse.result:type_name -> whatsconvert.v1.ImageResult
+	12, // 10: whatsconvert.v1.ConvertImageBatchRequest.requests:type_name -> whatsconvert.v1.ConvertImageRequest
+	13, // 11: whatsconvert.v1.ConvertImageBatchResponse.results:type_name -> whatsconvert.v1.ConvertImageResponse
+	17, // 12: whatsconvert.v1.ConvertImageStreamRequest.header:type_name -> whatsconvert.v1.ImageStreamHeader
+	9,  // 13: whatsconvert.v1.ImageStreamHeader.options:type_name -> whatsconvert.v1.ImageOptions
+	11, // 14: whatsconvert.v1.ConvertImageStreamResponse.result:type_name -> whatsconvert.v1.ImageResult
+	2,  // 15: whatsconvert.v1.ConverterService.ConvertAudio:input_type -> whatsconvert.v1.ConvertAudioRequest
+	12, // 16: whatsconvert.v1.ConverterService.ConvertImage:input_type -> whatsconvert.v1.ConvertImageRequest
+	4,  // 17: whatsconvert.v1.ConverterService.ConvertAudioBatch:input_type -> whatsconvert.v1.ConvertAudioBatchRequest
+	14, // 18: whatsconvert.v1.ConverterService.ConvertImageBatch:input_type -> whatsconvert.v1.ConvertImageBatchRequest
+	6,  // 19: whatsconvert.v1.ConverterService.ConvertAudioStream:input_type -> whatsconvert.v1.ConvertAudioStreamRequest
+	16, // 20: whatsconvert.v1.ConverterService.ConvertImageStream:input_type -> whatsconvert.v1.ConvertImageStreamRequest
+	3,  // 21: whatsconvert.v1.ConverterService.ConvertAudio:output_type -> whatsconvert.v1.ConvertAudioResponse
+	13, // 22: whatsconvert.v1.ConverterService.ConvertImage:output_type -> whatsconvert.v1.ConvertImageResponse
+	5,  // 23: whatsconvert.v1.ConverterService.ConvertAudioBatch:output_type -> whatsconvert.v1.ConvertAudioBatchResponse
+	15, // 24: whatsconvert.v1.ConverterService.ConvertImageBatch:output_type -> whatsconvert.v1.ConvertImageBatchResponse
+	8,  // 25: whatsconvert.v1.ConverterService.ConvertAudioStream:output_type -> whatsconvert.v1.ConvertAudioStreamResponse
+	18, // 26: whatsconvert.v1.ConverterService.ConvertImageStream:output_type -> whatsconvert.v1.ConvertImageStreamResponse
+	21, // [21:27] is the sub-list for method output_type
+	15, // [15:21] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_whatsconvert_v1_converter_proto_init() }
+func file_whatsconvert_v1_converter_proto_init() {
+	if File_whatsconvert_v1_converter_proto != nil {
+		return
+	}
+	file_whatsconvert_v1_converter_proto_msgTypes[0].OneofWrappers = []any{}
+	file_whatsconvert_v1_converter_proto_msgTypes[2].OneofWrappers = []any{
+		(*ConvertAudioRequest_Data)(nil),
+		(*ConvertAudioRequest_Url)(nil),
+	}
+	file_whatsconvert_v1_converter_proto_msgTypes[6].OneofWrappers = []any{
+		(*ConvertAudioStreamRequest_Header)(nil),
+		(*ConvertAudioStreamRequest_Chunk)(nil),
+	}
+	file_whatsconvert_v1_converter_proto_msgTypes[8].OneofWrappers = []any{
+		(*ConvertAudioStreamResponse_Result)(nil),
+		(*ConvertAudioStreamResponse_Chunk)(nil),
+	}
+	file_whatsconvert_v1_converter_proto_msgTypes[9].OneofWrappers = []any{}
+	file_whatsconvert_v1_converter_proto_msgTypes[12].OneofWrappers = []any{
+		(*ConvertImageRequest_Data)(nil),
+		(*ConvertImageRequest_Url)(nil),
+	}
+	file_whatsconvert_v1_converter_proto_msgTypes[16].OneofWrappers = []any{
+		(*ConvertImageStreamRequest_Header)(nil),
+		(*ConvertImageStreamRequest_Chunk)(nil),
+	}
+	file_whatsconvert_v1_converter_proto_msgTypes[18].OneofWrappers = []any{
+		(*ConvertImageStreamResponse_Result)(nil),
+		(*ConvertImageStreamResponse_Chunk)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_whatsconvert_v1_converter_proto_rawDesc), len(file_whatsconvert_v1_converter_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_whatsconvert_v1_converter_proto_goTypes,
+		DependencyIndexes: file_whatsconvert_v1_converter_proto_depIdxs,
+		MessageInfos:      file_whatsconvert_v1_converter_proto_msgTypes,
+	}.Build()
+	File_whatsconvert_v1_converter_proto = out.File
+	file_whatsconvert_v1_converter_proto_goTypes = nil
+	file_whatsconvert_v1_converter_proto_depIdxs = nil
+}
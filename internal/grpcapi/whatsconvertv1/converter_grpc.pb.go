@@ -0,0 +1,338 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.32.1
+// source: whatsconvert/v1/converter.proto
+
+package whatsconvertv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ConverterService_ConvertAudio_FullMethodName       = "/whatsconvert.v1.ConverterService/ConvertAudio"
+	ConverterService_ConvertImage_FullMethodName       = "/whatsconvert.v1.ConverterService/ConvertImage"
+	ConverterService_ConvertAudioBatch_FullMethodName  = "/whatsconvert.v1.ConverterService/ConvertAudioBatch"
+	ConverterService_ConvertImageBatch_FullMethodName  = "/whatsconvert.v1.ConverterService/ConvertImageBatch"
+	ConverterService_ConvertAudioStream_FullMethodName = "/whatsconvert.v1.ConverterService/ConvertAudioStream"
+	ConverterService_ConvertImageStream_FullMethodName = "/whatsconvert.v1.ConverterService/ConvertImageStream"
+)
+
+// ConverterServiceClient is the client API for ConverterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ConverterService converts media for WhatsApp without the HTTP/JSON/base64
+// overhead: inputs and outputs travel as raw bytes. Unary and batch calls
+// carry whole files and are bounded by BODY_LIMIT; the streaming
+// calls move large media in chunks instead.
+//
+// Failures use standard status codes. Errors with a matching HTTP error code
+// (duration_limit_exceeded, unsupported_output_format, ...) carry it as the
+// reason of a google.rpc.ErrorInfo detail.
+type ConverterServiceClient interface {
+	// ConvertAudio converts one audio file to WhatsApp Opus (or MP3/WAV)
+	ConvertAudio(ctx context.Context, in *ConvertAudioRequest, opts ...grpc.CallOption) (*ConvertAudioResponse, error)
+	// ConvertImage converts one image to WhatsApp JPEG
+	ConvertImage(ctx context.Context, in *ConvertImageRequest, opts ...grpc.CallOption) (*ConvertImageResponse, error)
+	// ConvertAudioBatch converts up to BATCH_MAX_SIZE audio files concurrently;
+	// the whole batch fails if one item does, like POST /convert/batch/audio
+	ConvertAudioBatch(ctx context.Context, in *ConvertAudioBatchRequest, opts ...grpc.CallOption) (*ConvertAudioBatchResponse, error)
+	// ConvertImageBatch converts up to BATCH_MAX_SIZE images concurrently; the
+	// whole batch fails if one item does, like POST /convert/batch/image
+	ConvertImageBatch(ctx context.Context, in *ConvertImageBatchRequest, opts ...grpc.CallOption) (*ConvertImageBatchResponse, error)
+	// ConvertAudioStream takes a header message followed by input chunks and
+	// answers with a result message followed by output chunks
+	ConvertAudioStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ConvertAudioStreamRequest, ConvertAudioStreamResponse], error)
+	// ConvertImageStream takes a header message followed by input chunks and
+	// answers with a result message followed by output chunks
+	ConvertImageStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ConvertImageStreamRequest, ConvertImageStreamResponse], error)
+}
+
+type converterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConverterServiceClient(cc grpc.ClientConnInterface) ConverterServiceClient {
+	return &converterServiceClient{cc}
+}
+
+func (c *converterServiceClient) ConvertAudio(ctx context.Context, in *ConvertAudioRequest, opts ...grpc.CallOption) (*ConvertAudioResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertAudioResponse)
+	err := c.cc.Invoke(ctx, ConverterService_ConvertAudio_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *converterServiceClient) ConvertImage(ctx context.Context, in *ConvertImageRequest, opts ...grpc.CallOption) (*ConvertImageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertImageResponse)
+	err := c.cc.Invoke(ctx, ConverterService_ConvertImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *converterServiceClient) ConvertAudioBatch(ctx context.Context, in *ConvertAudioBatchRequest, opts ...grpc.CallOption) (*ConvertAudioBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertAudioBatchResponse)
+	err := c.cc.Invoke(ctx, ConverterService_ConvertAudioBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *converterServiceClient) ConvertImageBatch(ctx context.Context, in *ConvertImageBatchRequest, opts ...grpc.CallOption) (*ConvertImageBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConvertImageBatchResponse)
+	err := c.cc.Invoke(ctx, ConverterService_ConvertImageBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *converterServiceClient) ConvertAudioStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ConvertAudioStreamRequest, ConvertAudioStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConverterService_ServiceDesc.Streams[0], ConverterService_ConvertAudioStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ConvertAudioStreamRequest, ConvertAudioStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConverterService_ConvertAudioStreamClient = grpc.BidiStreamingClient[ConvertAudioStreamRequest, ConvertAudioStreamResponse]
+
+func (c *converterServiceClient) ConvertImageStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ConvertImageStreamRequest, ConvertImageStreamResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConverterService_ServiceDesc.Streams[1], ConverterService_ConvertImageStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ConvertImageStreamRequest, ConvertImageStreamResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConverterService_ConvertImageStreamClient = grpc.BidiStreamingClient[ConvertImageStreamRequest, ConvertImageStreamResponse]
+
+// ConverterServiceServer is the server API for ConverterService service.
+// All implementations must embed UnimplementedConverterServiceServer
+// for forward compatibility.
+//
+// ConverterService converts media for WhatsApp without the HTTP/JSON/base64
+// overhead: inputs and outputs travel as raw bytes. Unary and batch calls
+// carry whole files and are bounded by BODY_LIMIT; the streaming
+// calls move large media in chunks instead.
+//
+// Failures use standard status codes. Errors with a matching HTTP error code
+// (duration_limit_exceeded, unsupported_output_format, ...) carry it as the
+// reason of a google.rpc.ErrorInfo detail.
+type ConverterServiceServer interface {
+	// ConvertAudio converts one audio file to WhatsApp Opus (or MP3/WAV)
+	ConvertAudio(context.Context, *ConvertAudioRequest) (*ConvertAudioResponse, error)
+	// ConvertImage converts one image to WhatsApp JPEG
+	ConvertImage(context.Context, *ConvertImageRequest) (*ConvertImageResponse, error)
+	// ConvertAudioBatch converts up to BATCH_MAX_SIZE audio files concurrently;
+	// the whole batch fails if one item does, like POST /convert/batch/audio
+	ConvertAudioBatch(context.Context, *ConvertAudioBatchRequest) (*ConvertAudioBatchResponse, error)
+	// ConvertImageBatch converts up to BATCH_MAX_SIZE images concurrently; the
+	// whole batch fails if one item does, like POST /convert/batch/image
+	ConvertImageBatch(context.Context, *ConvertImageBatchRequest) (*ConvertImageBatchResponse, error)
+	// ConvertAudioStream takes a header message followed by input chunks and
+	// answers with a result message followed by output chunks
+	ConvertAudioStream(grpc.BidiStreamingServer[ConvertAudioStreamRequest, ConvertAudioStreamResponse]) error
+	// ConvertImageStream takes a header message followed by input chunks and
+	// answers with a result message followed by output chunks
+	ConvertImageStream(grpc.BidiStreamingServer[ConvertImageStreamRequest, ConvertImageStreamResponse]) error
+	mustEmbedUnimplementedConverterServiceServer()
+}
+
+// UnimplementedConverterServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConverterServiceServer struct{}
+
+func (UnimplementedConverterServiceServer) ConvertAudio(context.Context, *ConvertAudioRequest) (*ConvertAudioResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConvertAudio not implemented")
+}
+func (UnimplementedConverterServiceServer) ConvertImage(context.Context, *ConvertImageRequest) (*ConvertImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConvertImage not implemented")
+}
+func (UnimplementedConverterServiceServer) ConvertAudioBatch(context.Context, *ConvertAudioBatchRequest) (*ConvertAudioBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConvertAudioBatch not implemented")
+}
+func (UnimplementedConverterServiceServer) ConvertImageBatch(context.Context, *ConvertImageBatchRequest) (*ConvertImageBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConvertImageBatch not implemented")
+}
+func (UnimplementedConverterServiceServer) ConvertAudioStream(grpc.BidiStreamingServer[ConvertAudioStreamRequest, ConvertAudioStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ConvertAudioStream not implemented")
+}
+func (UnimplementedConverterServiceServer) ConvertImageStream(grpc.BidiStreamingServer[ConvertImageStreamRequest, ConvertImageStreamResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ConvertImageStream not implemented")
+}
+func (UnimplementedConverterServiceServer) mustEmbedUnimplementedConverterServiceServer() {}
+func (UnimplementedConverterServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeConverterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConverterServiceServer will
+// result in compilation errors.
+type UnsafeConverterServiceServer interface {
+	mustEmbedUnimplementedConverterServiceServer()
+}
+
+func RegisterConverterServiceServer(s grpc.ServiceRegistrar, srv ConverterServiceServer) {
+	// If the following call pancis, it indicates UnimplementedConverterServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConverterService_ServiceDesc, srv)
+}
+
+func _ConverterService_ConvertAudio_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertAudioRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConverterServiceServer).ConvertAudio(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConverterService_ConvertAudio_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConverterServiceServer).ConvertAudio(ctx, req.(*ConvertAudioRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConverterService_ConvertImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConverterServiceServer).ConvertImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConverterService_ConvertImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConverterServiceServer).ConvertImage(ctx, req.(*ConvertImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConverterService_ConvertAudioBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertAudioBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConverterServiceServer).ConvertAudioBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConverterService_ConvertAudioBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConverterServiceServer).ConvertAudioBatch(ctx, req.(*ConvertAudioBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConverterService_ConvertImageBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConvertImageBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConverterServiceServer).ConvertImageBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConverterService_ConvertImageBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConverterServiceServer).ConvertImageBatch(ctx, req.(*ConvertImageBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConverterService_ConvertAudioStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConverterServiceServer).ConvertAudioStream(&grpc.GenericServerStream[ConvertAudioStreamRequest, ConvertAudioStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConverterService_ConvertAudioStreamServer = grpc.BidiStreamingServer[ConvertAudioStreamRequest, ConvertAudioStreamResponse]
+
+func _ConverterService_ConvertImageStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConverterServiceServer).ConvertImageStream(&grpc.GenericServerStream[ConvertImageStreamRequest, ConvertImageStreamResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConverterService_ConvertImageStreamServer = grpc.BidiStreamingServer[ConvertImageStreamRequest, ConvertImageStreamResponse]
+
+// ConverterService_ServiceDesc is the grpc.ServiceDesc for ConverterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConverterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "whatsconvert.v1.ConverterService",
+	HandlerType: (*ConverterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ConvertAudio",
+			Handler:    _ConverterService_ConvertAudio_Handler,
+		},
+		{
+			MethodName: "ConvertImage",
+			Handler:    _ConverterService_ConvertImage_Handler,
+		},
+		{
+			MethodName: "ConvertAudioBatch",
+			Handler:    _ConverterService_ConvertAudioBatch_Handler,
+		},
+		{
+			MethodName: "ConvertImageBatch",
+			Handler:    _ConverterService_ConvertImageBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ConvertAudioStream",
+			Handler:       _ConverterService_ConvertAudioStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ConvertImageStream",
+			Handler:       _ConverterService_ConvertImageStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "whatsconvert/v1/converter.proto",
+}
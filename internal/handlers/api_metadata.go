@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"whats-convert-api/internal/i18n"
+	"whats-convert-api/internal/models"
+)
+
+// defaultDescriptions describe the API unless API_DESCRIPTION is set
+var defaultDescriptions = map[string]string{
+	"en":    "High-performance media conversion API delivering WhatsApp-ready audio and images.",
+	"pt-BR": "API de conversão de mídia de alto desempenho que entrega áudios e imagens prontos para o WhatsApp.",
+}
+
+// swaggerLabels translate the Swagger page's contact block, by primary language
+var swaggerLabels = map[string]struct{ contact, email, postman, hint string }{
+	"en": {"Contact", "Email", "Ready-made Postman collection", "Use the examples documented on each endpoint to try audio, image and S3 operations quickly."},
+	"pt": {"Contato", "E-mail", "Coleção Postman pronta", "Use os exemplos documentados em cada endpoint para testar rapidamente conversões de áudio, imagem e operações S3."},
+}
+
+// APIMetadata brands the API in GET /api and the Swagger page, for
+// white-label deployments
+type APIMetadata struct {
+	Title        string
+	Language     string            // Default language, used when the client states none we have
+	Descriptions map[string]string // By language tag; the empty tag means Language
+	ContactName  string
+	ContactEmail string
+	ContactURL   string
+}
+
+// normalized resolves the empty tag and falls back to the built-in
+// descriptions when none is configured
+func (m APIMetadata) normalized() APIMetadata {
+	descriptions := make(map[string]string)
+	if len(m.Descriptions) == 0 {
+		for tag, description := range defaultDescriptions {
+			descriptions[tag] = description
+		}
+		if _, ok := descriptions[m.Language]; !ok {
+			m.Language = "en"
+		}
+	} else {
+		for tag, description := range m.Descriptions {
+			if tag != "" {
+				descriptions[tag] = description
+			}
+		}
+		// API_DESCRIPTION_<TAG> for the default language wins over API_DESCRIPTION
+		if description, ok := m.Descriptions[""]; ok {
+			if _, exists := descriptions[m.Language]; !exists {
+				descriptions[m.Language] = description
+			}
+		}
+	}
+	m.Descriptions = descriptions
+	return m
+}
+
+// describe picks the description best matching an Accept-Language header
+func (m APIMetadata) describe(acceptLanguage string) (string, string) {
+	language := i18n.Negotiate(acceptLanguage, m.Descriptions)
+	if language == "" {
+		language = m.Language
+	}
+	return language, m.Descriptions[language]
+}
+
+// contact returns the configured contact, or nil when there is none
+func (m APIMetadata) contact() *models.APIContact {
+	if m.ContactName == "" && m.ContactEmail == "" && m.ContactURL == "" {
+		return nil
+	}
+	return &models.APIContact{Name: m.ContactName, Email: m.ContactEmail, URL: m.ContactURL}
+}
+
+// SwaggerDescription renders the default-language description and contact
+// block as the HTML shown at the top of the Swagger page
+func (m APIMetadata) SwaggerDescription() string {
+	m = m.normalized()
+	primary, _, _ := strings.Cut(strings.ToLower(m.Language), "-")
+	labels, ok := swaggerLabels[primary]
+	if !ok {
+		labels = swaggerLabels["en"]
+	}
+
+	var b strings.Builder
+	b.WriteString(html.EscapeString(m.Descriptions[m.Language]))
+	b.WriteString("<br><br>\n")
+
+	if m.ContactName != "" || m.ContactURL != "" {
+		name := html.EscapeString(m.ContactName)
+		if m.ContactURL != "" {
+			if name == "" {
+				name = html.EscapeString(m.ContactURL)
+			}
+			name = fmt.Sprintf(`<a href="%s" target="_blank">%s</a>`, html.EscapeString(m.ContactURL), name)
+		}
+		fmt.Fprintf(&b, "<strong>%s:</strong> %s<br>\n", labels.contact, name)
+	}
+	if m.ContactEmail != "" {
+		email := html.EscapeString(m.ContactEmail)
+		fmt.Fprintf(&b, `<strong>%s:</strong> <a href="mailto:%s">%s</a><br>`+"\n", labels.email, email, email)
+	}
+	fmt.Fprintf(&b, `<strong>%s:</strong> <a href="/swagger/postman.json" download>Download</a><br><br>`+"\n", labels.postman)
+	b.WriteString(labels.hint)
+
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/services"
+)
+
+// AuditStampHandler traces leaked images back to the API key and request
+// that converted them
+type AuditStampHandler struct {
+	stamper    *services.AuditStamper
+	adminToken string
+}
+
+// NewAuditStampHandler creates an audit stamp reader guarded by adminToken (ADMIN_TOKEN)
+func NewAuditStampHandler(stamper *services.AuditStamper, adminToken string) *AuditStampHandler {
+	return &AuditStampHandler{stamper: stamper, adminToken: adminToken}
+}
+
+// ReadAuditStamp godoc
+// @Summary Read the audit stamp of an image
+// @Description Finds the audit stamp embedded in an image converted while AUDIT_STAMP_SECRET was set and checks its signature. The tenant is the tenant ID of the caller's API key, as in GET /usage; the reference is the request ID (X-Request-ID), or the batch job ID and item index. valid is false for stamps not signed with this deployment's secret, which may be forged. Images that were re-encoded since, as WhatsApp does to photos, have lost their stamp.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Param request body models.AuditStampRequest true "Image"
+// @Success 200 {object} services.AuditStamp
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "The image carries no stamp (code audit_stamp_not_found)"
+// @Router /admin/audit-stamp [post]
+func (h *AuditStampHandler) ReadAuditStamp(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	var req models.AuditStampRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid request body",
+			Details: err.Error(),
+		})
+	}
+	data := req.Data
+	if _, payload, found := strings.Cut(data, ";base64,"); found && strings.HasPrefix(data, "data:") {
+		data = payload
+	}
+	image, err := providers.DecodeBase64(data)
+	if err != nil || len(image) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid image data",
+			Details: "data must be the base64 or data URI of an image",
+		})
+	}
+
+	stamp, err := h.stamper.Read(image)
+	if errors.Is(err, services.ErrAuditStampNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   "No audit stamp found",
+			Code:    "audit_stamp_not_found",
+			Details: "The image was not converted with audit stamps enabled, or was re-encoded since",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to read the audit stamp",
+			Details: err.Error(),
+		})
+	}
+
+	return c.JSON(stamp)
+}
+
+func (h *AuditStampHandler) authorized(c fiber.Ctx) bool {
+	return subtle.ConstantTimeCompare([]byte(c.Get(adminTokenHeader)), []byte(h.adminToken)) == 1
+}
@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// BatchItemErrorCode returns the code a failed item's error is reported with
+func BatchItemErrorCode(err error) string {
+	_, code := batchItemError(err)
+	return code
+}
+
+// batchItemError returns the status and code of a failed item's error, as
+// the synchronous endpoints answer it
+func batchItemError(err error) (int, string) {
+	status, code, _ := conversionStatus(err)
+	return status, code
+}
+
+// SetBatchJobs enables the asynchronous batch endpoints
+func (h *ConverterHandler) SetBatchJobs(batchJobs *services.BatchJobManager) {
+	h.batchJobs = batchJobs
+}
+
+// ConvertBatchAudioAsync godoc
+// @Summary Start an asynchronous audio batch job
+// @Description Accepts up to BATCH_ASYNC_MAX_SIZE (default 500) audio conversion requests and answers 202 with a job ID at once. Items are converted in the background, at most BATCH_CONCURRENCY (default MAX_WORKERS) at a time and each bounded by BATCH_ITEM_TIMEOUT, through the same worker pool as synchronous conversions. Poll status_url for per-item progress and fetch each converted item from its result_url; finished jobs are kept for BATCH_JOB_RETENTION. With S3 enabled, a report of every item is uploaded when the job finishes and linked from its status.
+// @Tags Conversion
+// @Accept json
+// @Produce json
+// @Param request body []services.AudioRequest true "Batch audio conversion request"
+// @Param item_timeout query string false "Deadline of each item, e.g. 30s or 30 (capped at BATCH_ITEM_TIMEOUT)"
+// @Param concurrency query int false "Items converted at once (capped at BATCH_CONCURRENCY, else MAX_WORKERS)"
+// @Param report query string false "Report uploaded to S3 when the job finishes: jsonl, csv or none (default jsonl for jobs of BATCH_REPORT_MIN_ITEMS items or more)"
+// @Param archive query string false "tar.zst also uploads every output to S3 in one Zstandard-compressed tar with a manifest.json when the job finishes"
+// @Success 202 {object} models.BatchJobResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "BATCH_JOB_MAX_ACTIVE jobs are unfinished (code batch_jobs_busy)"
+// @Router /convert/batch/audio/async [post]
+func (h *ConverterHandler) ConvertBatchAudioAsync(c fiber.Ctx) error {
+	var requests []services.AudioRequest
+	if err := c.Bind().Body(&requests); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid request body",
+			Details: err.Error(),
+		})
+	}
+
+	opts, failure := h.asyncBatchOptions(c, services.BatchJobAudio, len(requests))
+	if failure != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(failure)
+	}
+
+	reqPointers := make([]*services.AudioRequest, len(requests))
+	for i := range requests {
+		reqPointers[i] = &requests[i]
+	}
+
+	job, err := h.batchJobs.SubmitAudio(c.Context(), reqPointers, opts)
+	return h.batchJobAccepted(c, job, err)
+}
+
+// ConvertBatchImageAsync godoc
+// @Summary Start an asynchronous image batch job
+// @Description Accepts up to BATCH_ASYNC_MAX_SIZE (default 500) image conversion requests and answers 202 with a job ID at once. Items are converted in the background, at most BATCH_CONCURRENCY (default MAX_WORKERS) at a time and each bounded by BATCH_ITEM_TIMEOUT, through the same worker pool as synchronous conversions. Poll status_url for per-item progress and fetch each converted item from its result_url; finished jobs are kept for BATCH_JOB_RETENTION. With S3 enabled, a report of every item is uploaded when the job finishes and linked from its status.
+// @Tags Conversion
+// @Accept json
+// @Produce json
+// @Param request body []services.ImageRequest true "Batch image conversion request"
+// @Param item_timeout query string false "Deadline of each item, e.g. 30s or 30 (capped at BATCH_ITEM_TIMEOUT)"
+// @Param concurrency query int false "Items converted at once (capped at BATCH_CONCURRENCY, else MAX_WORKERS)"
+// @Param report query string false "Report uploaded to S3 when the job finishes: jsonl, csv or none (default jsonl for jobs of BATCH_REPORT_MIN_ITEMS items or more)"
+// @Param archive query string false "tar.zst also uploads every output to S3 in one Zstandard-compressed tar with a manifest.json when the job finishes"
+// @Success 202 {object} models.BatchJobResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "BATCH_JOB_MAX_ACTIVE jobs are unfinished (code batch_jobs_busy)"
+// @Router /convert/batch/image/async [post]
+func (h *ConverterHandler) ConvertBatchImageAsync(c fiber.Ctx) error {
+	var requests []services.ImageRequest
+	if err := c.Bind().Body(&requests); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid request body",
+			Details: err.Error(),
+		})
+	}
+
+	opts, failure := h.asyncBatchOptions(c, services.BatchJobImage, len(requests))
+	if failure != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(failure)
+	}
+
+	reqPointers := make([]*services.ImageRequest, len(requests))
+	for i := range requests {
+		reqPointers[i] = &requests[i]
+	}
+
+	job, err := h.batchJobs.SubmitImage(c.Context(), reqPointers, opts)
+	return h.batchJobAccepted(c, job, err)
+}
+
+// asyncBatchOptions validates the size of an asynchronous batch of n items
+// of kind and reads its options. A non-nil failure is answered with 400.
+func (h *ConverterHandler) asyncBatchOptions(c fiber.Ctx, kind string, n int) (services.BatchOptions, *models.ErrorResponse) {
+	if n == 0 {
+		return services.BatchOptions{}, &models.ErrorResponse{
+			Error: "Empty batch",
+		}
+	}
+
+	if n > h.batch.AsyncMaxSize {
+		return services.BatchOptions{}, &models.ErrorResponse{
+			Error:   "Batch too large",
+			Code:    "batch_too_large",
+			Details: fmt.Sprintf("Maximum %d items per asynchronous batch", h.batch.AsyncMaxSize),
+		}
+	}
+
+	opts, err := h.batchOptions(c, n, h.batch.AsyncConcurrency)
+	if err != nil {
+		return opts, &models.ErrorResponse{
+			Error:   "Invalid batch options",
+			Code:    "invalid_batch_options",
+			Details: err.Error(),
+		}
+	}
+
+	if opts.Report, err = services.ParseBatchReportFormat(c.Query("report")); err != nil {
+		return opts, &models.ErrorResponse{
+			Error:   "Invalid batch options",
+			Code:    "invalid_batch_options",
+			Details: err.Error(),
+		}
+	}
+
+	if opts.Archive, err = services.ParseBatchArchiveFormat(c.Query("archive")); err != nil {
+		return opts, &models.ErrorResponse{
+			Error:   "Invalid batch options",
+			Code:    "invalid_batch_options",
+			Details: err.Error(),
+		}
+	}
+	opts.JobsURL = batchJobsURL(c, kind)
+	return opts, nil
+}
+
+// batchJobsURL returns the path of the jobs collection. Job URLs keep the
+// API version prefix the job was submitted under.
+func batchJobsURL(c fiber.Ctx, kind string) string {
+	return strings.TrimSuffix(c.Path(), "/"+kind+"/async") + "/jobs/"
+}
+
+// batchJobAccepted answers the submission of a batch job
+func (h *ConverterHandler) batchJobAccepted(c fiber.Ctx, job *services.BatchJob, err error) error {
+	if err != nil {
+		if errors.Is(err, services.ErrBatchJobCapacity) {
+			return c.Status(fiber.StatusTooManyRequests).JSON(models.ErrorResponse{
+				Error:   "Too many batch jobs",
+				Code:    "batch_jobs_busy",
+				Details: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to start batch job",
+			Details: err.Error(),
+		})
+	}
+
+	response := toBatchJobResponse(job, batchJobsURL(c, job.Kind))
+	c.Location(response.StatusURL)
+	return c.Status(fiber.StatusAccepted).JSON(response)
+}
+
+// GetBatchJob godoc
+// @Summary Retrieve the progress of an asynchronous batch job
+// @Description Returns the job's aggregate status and item counts and, per item, its status, error and result_url once converted. Finished jobs link their report: report_url downloads it from the API and report describes the copy uploaded to S3. archive_url and archive do the same for the tar.zst of every output.
+// @Tags Conversion
+// @Produce json
+// @Param id path string true "Batch job identifier"
+// @Success 200 {object} models.BatchJobResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /convert/batch/jobs/{id} [get]
+func (h *ConverterHandler) GetBatchJob(c fiber.Ctx) error {
+	job, err := h.batchJobs.Get(c.Params("id"))
+	if err != nil {
+		return batchJobNotFound(c)
+	}
+
+	return c.JSON(toBatchJobResponse(job, strings.TrimSuffix(c.Path(), job.ID)))
+}
+
+// GetBatchJobReport godoc
+// @Summary Download the report of a finished asynchronous batch job
+// @Description Returns one row per item with its status, input (source URL without its query string, or base64 and its decoded size), detected input format, output MIME type, size and dimensions or duration, result_url, error and code, and conversion time. JSON lines (one object per item) or CSV with a header row. Answers 409 while the job is running.
+// @Tags Conversion
+// @Produce plain
+// @Param id path string true "Batch job identifier"
+// @Param format query string false "jsonl or csv (default: the format of the uploaded report, else jsonl)"
+// @Success 200 {string} string "Report"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "The job hasn't finished (code batch_job_running)"
+// @Router /convert/batch/jobs/{id}/report [get]
+func (h *ConverterHandler) GetBatchJobReport(c fiber.Ctx) error {
+	job, rows, err := h.batchJobs.Report(c.Params("id"))
+	if err != nil {
+		return batchJobNotFound(c)
+	}
+
+	format, err := services.ParseBatchReportFormat(c.Query("format"))
+	if err != nil || format == services.BatchReportNone {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid report format",
+			Code:    "unsupported_report_format",
+			Details: "Supported report formats: jsonl, csv",
+		})
+	}
+	if format == "" {
+		format = services.BatchReportJSONL
+		if job.Report != nil {
+			format = job.Report.Format
+		}
+	}
+
+	if !job.Finished() {
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+			Error:   "Batch job still running",
+			Code:    "batch_job_running",
+			Details: "The report is available once every item finished",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, services.BatchReportContentType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="batch-%s.%s"`, job.ID, format))
+	return services.WriteBatchReport(c.Response().BodyWriter(), format, rows)
+}
+
+// GetBatchJobArchive godoc
+// @Summary Download the outputs of a finished asynchronous batch job
+// @Description Returns a Zstandard-compressed tar holding manifest.json, then one file per converted item named after its index (000.jpg, 001.jpg, …). The manifest has the job's counts and, per item, the report row plus the file name of its output; failed items are listed without one. Answers 409 while the job is running.
+// @Tags Conversion
+// @Produce application/zstd
+// @Param id path string true "Batch job identifier"
+// @Success 200 {file} file "tar.zst archive"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "The job hasn't finished (code batch_job_running)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /convert/batch/jobs/{id}/archive [get]
+func (h *ConverterHandler) GetBatchJobArchive(c fiber.Ctx) error {
+	job, archive, err := h.batchJobs.Archive(c.Params("id"))
+	if errors.Is(err, services.ErrBatchJobNotFound) {
+		return batchJobNotFound(c)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to build archive",
+			Details: err.Error(),
+		})
+	}
+
+	if archive == nil {
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+			Error:   "Batch job still running",
+			Code:    "batch_job_running",
+			Details: "The archive is available once every item finished",
+		})
+	}
+
+	return sendBatchArchive(c, archive, "batch-"+job.ID)
+}
+
+// sendBatchArchive writes archive as an attachment named name plus its extension
+func sendBatchArchive(c fiber.Ctx, archive *services.BatchArchive, name string) error {
+	c.Set(fiber.HeaderContentType, services.BatchArchiveContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, name, services.BatchArchiveTarZstd))
+	return archive.Write(c.Response().BodyWriter())
+}
+
+// GetBatchJobItem godoc
+// @Summary Retrieve one converted item of an asynchronous batch job
+// @Description Answers 200 with the same body as POST /convert/audio or /convert/image once the item is converted, 202 with its progress while it is pending or processing, the synchronous endpoints' error (status and code) if it failed, and 409 if the job was cancelled before it ran.
+// @Tags Conversion
+// @Produce json
+// @Param id path string true "Batch job identifier"
+// @Param index path int true "Item index, from 0"
+// @Success 200 {object} services.AudioResponse "Converted audio (audio jobs)"
+// @Success 202 {object} models.BatchJobItem "Not converted yet"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 408 {object} models.ErrorResponse "The item ran past its item_timeout (code item_timeout)"
+// @Failure 409 {object} models.ErrorResponse "The job was cancelled (code item_cancelled)"
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /convert/batch/jobs/{id}/items/{index} [get]
+func (h *ConverterHandler) GetBatchJobItem(c fiber.Ctx) error {
+	job, err := h.batchJobs.Get(c.Params("id"))
+	if err != nil {
+		return batchJobNotFound(c)
+	}
+
+	index, err := strconv.Atoi(c.Params("index"))
+	if err != nil || index < 0 || index >= len(job.Items) {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   "Batch item not found",
+			Details: fmt.Sprintf("Job has items 0 to %d", len(job.Items)-1),
+		})
+	}
+
+	item := job.Items[index]
+	switch item.Status {
+	case services.BatchItemStatusCompleted:
+		return c.JSON(item.Result)
+
+	case services.BatchItemStatusFailed:
+		status, code := batchItemError(item.Err)
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error:    "Conversion failed",
+			Code:     code,
+			Details:  item.Error,
+			SourceID: services.RetainedSourceID(item.Err),
+		})
+
+	case services.BatchItemStatusCancelled:
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+			Error: "Batch job cancelled",
+			Code:  "item_cancelled",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(toBatchJobItem(item, ""))
+}
+
+// DeleteBatchJob godoc
+// @Summary Cancel and discard an asynchronous batch job
+// @Description Stops items still converting, skips those not started and frees the job's results. Finished jobs are otherwise kept for BATCH_JOB_RETENTION.
+// @Tags Conversion
+// @Produce json
+// @Param id path string true "Batch job identifier"
+// @Success 200 {object} models.MessageResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /convert/batch/jobs/{id} [delete]
+func (h *ConverterHandler) DeleteBatchJob(c fiber.Ctx) error {
+	if err := h.batchJobs.Delete(c.Params("id")); err != nil {
+		return batchJobNotFound(c)
+	}
+
+	return c.JSON(models.MessageResponse{
+		Success: true,
+		Message: "Batch job deleted successfully",
+	})
+}
+
+func batchJobNotFound(c fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+		Error: "Batch job not found",
+	})
+}
+
+// toBatchJobResponse reports a job whose URLs start with base, the path of
+// the jobs collection
+func toBatchJobResponse(job *services.BatchJob, base string) models.BatchJobResponse {
+	counts := job.Counts()
+	total := len(job.Items)
+	statusURL := base + job.ID
+
+	response := models.BatchJobResponse{
+		JobID:      job.ID,
+		Kind:       job.Kind,
+		Status:     string(job.Status),
+		StatusURL:  statusURL,
+		Total:      total,
+		Pending:    counts.Pending,
+		Processing: counts.Processing,
+		Completed:  counts.Completed,
+		Failed:     counts.Failed,
+		Cancelled:  counts.Cancelled,
+		CreatedAt:  job.CreatedAt,
+		EndTime:    job.EndTime,
+		Report:     job.Report,
+		Archive:    job.Archive,
+		Items:      make([]models.BatchJobItem, total),
+	}
+	if job.Finished() {
+		response.ReportURL = statusURL + "/report"
+		response.ArchiveURL = statusURL + "/archive"
+	}
+	if total > 0 {
+		finished := counts.Completed + counts.Failed + counts.Cancelled
+		response.Progress = float64(finished) / float64(total) * 100
+	}
+
+	for i, item := range job.Items {
+		response.Items[i] = toBatchJobItem(item, statusURL+"/items/")
+	}
+	return response
+}
+
+// toBatchJobItem reports an item; completed ones link to itemsURL plus their index
+func toBatchJobItem(item services.BatchItem, itemsURL string) models.BatchJobItem {
+	response := models.BatchJobItem{
+		Index:  item.Index,
+		Status: string(item.Status),
+		Error:  item.Error,
+	}
+	if item.Err != nil {
+		_, response.Code = batchItemError(item.Err)
+	}
+	if item.StartTime != nil && item.EndTime != nil {
+		response.DurationMs = item.EndTime.Sub(*item.StartTime).Milliseconds()
+	}
+	if item.Status == services.BatchItemStatusCompleted && itemsURL != "" {
+		response.ResultURL = itemsURL + strconv.Itoa(item.Index)
+	}
+	return response
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/services"
+)
+
+// Headers reporting the options a batch ran with
+const (
+	batchConcurrencyHeader = "X-Batch-Concurrency"
+	batchItemTimeoutHeader = "X-Batch-Item-Timeout" // Seconds
+)
+
+// BatchLimits are the server-side ceilings of batch requests
+type BatchLimits struct {
+	MaxSize     int           // Most items per batch
+	ItemTimeout time.Duration // Longest deadline per item
+	Concurrency int           // Most items converted at once (0 = all of them)
+
+	AsyncMaxSize     int // Most items per asynchronous batch job
+	AsyncConcurrency int // Most items of a job converted at once
+}
+
+// defaultBatchLimits are the limits of handlers built without SetBatchLimits
+var defaultBatchLimits = BatchLimits{
+	MaxSize:          10,
+	ItemTimeout:      services.DefaultBatchItemTimeout,
+	AsyncMaxSize:     500,
+	AsyncConcurrency: 4,
+}
+
+// SetBatchLimits replaces the batch ceilings
+func (h *ConverterHandler) SetBatchLimits(limits BatchLimits) {
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = defaultBatchLimits.MaxSize
+	}
+	if limits.ItemTimeout <= 0 {
+		limits.ItemTimeout = defaultBatchLimits.ItemTimeout
+	}
+	if limits.AsyncMaxSize <= 0 {
+		limits.AsyncMaxSize = defaultBatchLimits.AsyncMaxSize
+	}
+	if limits.AsyncConcurrency <= 0 {
+		limits.AsyncConcurrency = defaultBatchLimits.AsyncConcurrency
+	}
+	h.batch = limits
+}
+
+// batchOptions reads the item_timeout (e.g. 30s, or seconds) and
+// concurrency query parameters of a batch of n items. They can lower
+// BATCH_ITEM_TIMEOUT and the concurrency ceiling (0 = all items) but never
+// raise them; larger values are capped.
+func (h *ConverterHandler) batchOptions(c fiber.Ctx, n, ceiling int) (services.BatchOptions, error) {
+	opts := services.BatchOptions{
+		ItemTimeout: h.batch.ItemTimeout,
+		Concurrency: ceiling,
+	}
+
+	if value := c.Query("item_timeout"); value != "" {
+		timeout, err := parseTimeout(value)
+		if err != nil || timeout <= 0 {
+			return opts, fmt.Errorf("item_timeout must be a positive duration such as 30s, got %q", value)
+		}
+		opts.ItemTimeout = min(timeout, h.batch.ItemTimeout)
+	}
+
+	if value := c.Query("concurrency"); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err != nil || concurrency <= 0 {
+			return opts, fmt.Errorf("concurrency must be a positive integer, got %q", value)
+		}
+		if opts.Concurrency == 0 || concurrency < opts.Concurrency {
+			opts.Concurrency = concurrency
+		}
+	}
+
+	applied := n
+	if opts.Concurrency > 0 && opts.Concurrency < n {
+		applied = opts.Concurrency
+	}
+	c.Set(batchConcurrencyHeader, strconv.Itoa(applied))
+	c.Set(batchItemTimeoutHeader, strconv.FormatFloat(opts.ItemTimeout.Seconds(), 'f', -1, 64))
+
+	return opts, nil
+}
+
+// parseTimeout accepts a Go duration (30s, 1m30s) or a number of seconds
+func parseTimeout(value string) (time.Duration, error) {
+	if timeout, err := time.ParseDuration(value); err == nil {
+		return timeout, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
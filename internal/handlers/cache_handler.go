@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// redisPingTimeout bounds the Redis check of GET /cache/stats
+const redisPingTimeout = 2 * time.Second
+
+// CacheHandler reports the conversion output cache and the rendition cache
+// of the convert-on-read endpoint
+type CacheHandler struct {
+	conversion *services.ConversionCache
+	media      *MediaHandler
+}
+
+// NewCacheHandler creates a cache handler; either cache may be nil when disabled
+func NewCacheHandler(conversion *services.ConversionCache, media *MediaHandler) *CacheHandler {
+	return &CacheHandler{conversion: conversion, media: media}
+}
+
+// Stats godoc
+// @Summary Cache statistics
+// @Description Usage of the conversion output cache (CONVERSION_CACHE_SIZE, REDIS_URL) and of the convert-on-read rendition cache, with a live check of the shared Redis. Caches that are disabled are left out.
+// @Tags Monitoring
+// @Produce json
+// @Success 200 {object} models.CacheStatsResponse
+// @Router /cache/stats [get]
+func (h *CacheHandler) Stats(c fiber.Ctx) error {
+	response := models.CacheStatsResponse{Timestamp: time.Now().Unix()}
+
+	if h.conversion != nil {
+		stats := h.conversion.Stats()
+		response.Conversion = &stats
+	}
+	if h.conversion.UsesRedis() {
+		ctx, cancel := context.WithTimeout(c.Context(), redisPingTimeout)
+		defer cancel()
+
+		latency, err := h.conversion.PingRedis(ctx)
+		response.Redis = &models.RedisStatus{
+			Reachable: err == nil,
+			LatencyMS: float64(latency.Microseconds()) / 1000,
+		}
+	}
+	if h.media != nil {
+		stats := h.media.CacheStats()
+		response.Media = &stats
+	}
+
+	return c.JSON(response)
+}
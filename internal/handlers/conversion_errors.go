@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// conversionErrors maps conversion failures to the status, code and message
+// every conversion endpoint, batch items included, answers them with
+var conversionErrors = []struct {
+	err     error
+	status  int
+	code    string
+	message string
+}{
+	{services.ErrBatchItemTimeout, fiber.StatusRequestTimeout, "item_timeout", "Item timed out"},
+	{services.ErrDurationLimitExceeded, fiber.StatusUnprocessableEntity, "duration_limit_exceeded", "Audio too long"},
+	{services.ErrUnsupportedOutputFormat, fiber.StatusBadRequest, "unsupported_output_format", "Unsupported output format"},
+	{services.ErrUnsupportedCompression, fiber.StatusBadRequest, "unsupported_compression", "Unsupported compression"},
+	{services.ErrUnknownPreset, fiber.StatusBadRequest, "unknown_preset", "Unknown preset"},
+	{services.ErrUnsupportedInput, fiber.StatusUnsupportedMediaType, "unsupported_input", "Unsupported input"},
+	{services.ErrPixelLimitExceeded, fiber.StatusUnprocessableEntity, "pixel_limit_exceeded", "Image dimensions too large"},
+	{services.ErrInvalidBackground, fiber.StatusBadRequest, "invalid_background", "Invalid background"},
+	{services.ErrQualityBelowThreshold, fiber.StatusUnprocessableEntity, "quality_below_threshold", "Output quality too low"},
+	{services.ErrTargetSizeUnreachable, fiber.StatusUnprocessableEntity, "target_size_unreachable", "Output cannot fit the target size"},
+	{services.ErrDownloadHostUnavailable, fiber.StatusServiceUnavailable, "download_host_unavailable", "Download host unavailable"},
+	{services.ErrDownloadAddressForbidden, fiber.StatusBadRequest, "download_forbidden", "Download address not allowed"},
+	{services.ErrInvalidSource, fiber.StatusBadRequest, "invalid_source", "Invalid source"},
+	{services.ErrSourceObjectNotFound, fiber.StatusNotFound, "source_not_found", "Source object not found"},
+	{services.ErrSourceTooLarge, fiber.StatusRequestEntityTooLarge, "source_too_large", "Source object too large"},
+}
+
+// conversionStatus returns the status, code and message err is answered
+// with; unknown errors get 500 and no code
+func conversionStatus(err error) (status int, code, message string) {
+	for _, known := range conversionErrors {
+		if errors.Is(err, known.err) {
+			return known.status, known.code, known.message
+		}
+	}
+	return fiber.StatusInternalServerError, "", ""
+}
+
+// conversionError answers a failed conversion. ctx is the conversion's
+// context, whose deadline is the request's (or the whole batch's) timeout;
+// failure is the message of errors without a code.
+func conversionError(ctx context.Context, c fiber.Ctx, err error, records []services.CommandRecord, failure string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{
+			Error:   "Request timeout",
+			Details: "Conversion took too long",
+			Trace:   records,
+		})
+	}
+
+	if _, code, _ := conversionStatus(err); code == "" {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:    failure,
+			Details:  err.Error(),
+			Trace:    records,
+			SourceID: services.RetainedSourceID(err),
+		})
+	}
+	if isDownloadError(err) {
+		return downloadError(c, err, records)
+	}
+	return knownError(c, err, records)
+}
+
+// knownError answers err with its conversionErrors entry
+func knownError(c fiber.Ctx, err error, records []services.CommandRecord) error {
+	status, code, message := conversionStatus(err)
+	return c.Status(status).JSON(models.ErrorResponse{
+		Error:   message,
+		Code:    code,
+		Details: err.Error(),
+		Trace:   records,
+	})
+}
+
+// isDownloadError reports whether err is a download the downloader refused
+func isDownloadError(err error) bool {
+	return errors.Is(err, services.ErrDownloadHostUnavailable) ||
+		errors.Is(err, services.ErrDownloadAddressForbidden)
+}
+
+// downloadError answers refused downloads: URLs on internal addresses are
+// the request's fault, and hosts with an open circuit breaker come with when
+// they're probed again
+func downloadError(c fiber.Ctx, err error, records []services.CommandRecord) error {
+	if errors.Is(err, services.ErrDownloadHostUnavailable) {
+		retryAfter := 1
+		var unavailable *services.HostUnavailableError
+		if errors.As(err, &unavailable) {
+			retryAfter = max(int(math.Ceil(unavailable.RetryAfter().Seconds())), 1)
+		}
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	}
+	return knownError(c, err, records)
+}
+
+// isSourceError reports whether err is a failure to read a request's source
+func isSourceError(err error) bool {
+	return errors.Is(err, services.ErrInvalidSource) ||
+		errors.Is(err, services.ErrSourceObjectNotFound) ||
+		errors.Is(err, services.ErrSourceTooLarge)
+}
+
+// sourceError answers requests whose source object can't be converted
+func sourceError(c fiber.Ctx, err error, records []services.CommandRecord) error {
+	return knownError(c, err, records)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/services"
+)
+
+// SetS3Service enables the convert-and-upload endpoints
+func (h *ConverterHandler) SetS3Service(s3Service *services.S3Service) {
+	h.s3Service = s3Service
+}
+
+// ConvertAudioToS3 godoc
+// @Summary Convert audio and store it in S3-compatible storage
+// @Description Converts like POST /convert/audio and streams FFmpeg's output into the bucket while it encodes, in multipart parts, so memory stays flat whatever the size. WAV outputs and include_waveform requests are uploaded once encoded. The object is stored when the response arrives; a failed conversion aborts the upload.
+// @Tags Conversion
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param request body services.AudioRequest true "Audio conversion request, plus an upload object (key, key_template, on_collision, public, expires_days, metadata, storage_class)"
+// @Param file formData file false "Audio file when using multipart"
+// @Param options formData string false "Multipart only: JSON encoded upload options" example:{"public":false}
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)"
+// @Success 200 {object} models.ConvertUploadResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request, upload options or key_template ({hash}, {sha256}, {width} and {height} need the output before it is streamed)"
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Key taken and on_collision is error"
+// @Failure 413 {object} models.ErrorResponse "Output larger than S3_MAX_FILE_SIZE (code object_too_large)"
+// @Failure 415 {object} models.ErrorResponse "Input is an image (code unsupported_input) or output type outside S3_ALLOWED_CONTENT_TYPES (code content_type_not_allowed)"
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse "The upload failed (code upload_failed), or the stored object didn't match it with S3_VERIFY_UPLOADS (code upload_verification_failed)"
+// @Router /convert/audio/s3 [post]
+func (h *ConverterHandler) ConvertAudioToS3(c fiber.Ctx) error {
+	req, err := h.parseAudioRequest(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+	options, err := parseConvertUploadOptions(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+
+	req.Data = sanitizeBase64Data(req.Data)
+	if req.Source == nil && req.Input == nil && req.Upload == nil && strings.TrimSpace(req.Data) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Missing 'data' field",
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.routeTimeout(h.timeouts.Audio))
+	defer cancel()
+
+	contentType, err := req.OutputMimeType()
+	if err != nil {
+		return conversionError(ctx, c, err, nil, "Conversion failed")
+	}
+	var filename string
+	if req.Upload != nil {
+		filename = req.Upload.Filename
+	}
+
+	ctx, trace := h.startTrace(c, ctx)
+	start := time.Now()
+
+	var response *services.AudioResponse
+	var convertErr error
+	result, err := h.convertToS3(ctx, options, filename, contentType, func(ctx context.Context, w io.Writer) error {
+		req.Sink = w
+		response, convertErr = h.audioConverter.Convert(ctx, req)
+		return convertErr
+	})
+	records := h.finishTrace(c, trace)
+	if err != nil {
+		if convertErr != nil && errors.Is(err, convertErr) {
+			return conversionError(ctx, c, err, records, "Conversion failed")
+		}
+		return storeError(c, err, records)
+	}
+	response.Trace = records
+
+	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
+	c.Set("X-Output-Size", fmt.Sprintf("%d", response.Size))
+	if response.EncoderVariant != "" {
+		c.Set("X-Encoder-Variant", response.EncoderVariant)
+	}
+
+	return c.JSON(models.ConvertUploadResponse{
+		Success: true,
+		Audio:   response,
+		Upload:  toS3UploadResult(result),
+	})
+}
+
+// ConvertVideoToS3 godoc
+// @Summary Convert video and store it in S3-compatible storage
+// @Description Converts like POST /convert/video and streams the MP4 from the scratch directory into the bucket in multipart parts, so 100MB+ outputs never sit in memory. faststart writes the index once encoding ends, so the upload starts then. Requires the "video" feature flag.
+// @Tags Conversion
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param request body services.VideoRequest true "Video conversion request, plus an upload object (key, key_template, on_collision, public, expires_days, metadata, storage_class)"
+// @Param file formData file false "Video file when using multipart"
+// @Param options formData string false "Multipart only: JSON encoded upload options" example:{"public":false}
+// @Param X-API-Key header string false "Evaluated against per-key rollouts of the video feature flag"
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)"
+// @Success 200 {object} models.ConvertUploadResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request, upload options or key_template ({hash}, {sha256}, {width} and {height} need the output before it is streamed)"
+// @Failure 404 {object} models.ErrorResponse "Video feature not enabled (answered as an unknown endpoint)"
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Key taken and on_collision is error"
+// @Failure 413 {object} models.ErrorResponse "Output larger than S3_MAX_FILE_SIZE (code object_too_large)"
+// @Failure 415 {object} models.ErrorResponse "Input is audio only (code unsupported_input) or output type outside S3_ALLOWED_CONTENT_TYPES (code content_type_not_allowed)"
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse "The upload failed (code upload_failed), or the stored object didn't match it with S3_VERIFY_UPLOADS (code upload_verification_failed)"
+// @Router /convert/video/s3 [post]
+func (h *ConverterHandler) ConvertVideoToS3(c fiber.Ctx) error {
+	req, err := h.parseVideoRequest(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+	options, err := parseConvertUploadOptions(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+
+	req.Data = sanitizeBase64Data(req.Data)
+	if req.Source == nil && req.Input == nil && req.Upload == nil && strings.TrimSpace(req.Data) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Missing 'data' field",
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	var filename string
+	if req.Upload != nil {
+		filename = req.Upload.Filename
+	}
+
+	ctx, trace := h.startTrace(c, ctx)
+	start := time.Now()
+
+	var response *services.VideoResponse
+	var convertErr error
+	result, err := h.convertToS3(ctx, options, filename, req.OutputMimeType(), func(ctx context.Context, w io.Writer) error {
+		req.Sink = w
+		response, convertErr = h.videoConverter.Convert(ctx, req)
+		return convertErr
+	})
+	records := h.finishTrace(c, trace)
+	if err != nil {
+		if convertErr != nil && errors.Is(err, convertErr) {
+			return videoConversionError(ctx, c, err, records)
+		}
+		return storeError(c, err, records)
+	}
+	response.Trace = records
+
+	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
+	c.Set("X-Output-Size", fmt.Sprintf("%d", response.Size))
+	if response.EncoderVariant != "" {
+		c.Set("X-Encoder-Variant", response.EncoderVariant)
+	}
+
+	return c.JSON(models.ConvertUploadResponse{
+		Success: true,
+		Video:   response,
+		Upload:  toS3UploadResult(result),
+	})
+}
+
+// convertToS3 names the object, then uploads what convert writes while it
+// converts
+func (h *ConverterHandler) convertToS3(ctx context.Context, options models.S3UploadRequest, filename, contentType string, convert func(ctx context.Context, w io.Writer) error) (*providers.UploadResult, error) {
+	key, err := h.s3Service.ObjectKey(ctx, services.KeySource{
+		Key:         options.Key,
+		Filename:    services.OutputFilename(filename, contentType),
+		ContentType: contentType,
+		Template:    options.KeyTemplate,
+		Collision:   options.OnCollision,
+		Streamed:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.s3Service.PipeUpload(ctx, key, providers.UploadOptions{
+		ContentType:    contentType,
+		Public:         options.Public,
+		ExpirationDays: options.ExpirationDays,
+		Metadata:       options.Metadata,
+		StorageClass:   options.StorageClass,
+	}, convert)
+}
+
+// parseConvertUploadOptions reads the upload options: the options form field
+// of multipart requests, else the upload object of the JSON body
+func parseConvertUploadOptions(c fiber.Ctx) (models.S3UploadRequest, error) {
+	var options models.S3UploadRequest
+
+	if strings.HasPrefix(strings.ToLower(c.Get("Content-Type")), "multipart/form-data") {
+		raw := strings.TrimSpace(c.FormValue("options"))
+		if raw == "" {
+			return options, nil
+		}
+		if err := json.Unmarshal([]byte(raw), &options); err != nil {
+			return options, newRequestError(fiber.StatusBadRequest, "Invalid upload options", err.Error())
+		}
+		return options, nil
+	}
+
+	var body struct {
+		Upload models.S3UploadRequest `json:"upload"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return options, newRequestError(fiber.StatusBadRequest, "Invalid upload options", err.Error())
+	}
+	return body.Upload, nil
+}
+
+// storeError maps naming and upload failures of the convert-and-upload endpoints
+func storeError(c fiber.Ctx, err error, records []services.CommandRecord) error {
+	status, response := fiber.StatusBadGateway, models.ErrorResponse{
+		Error:   "Upload failed",
+		Code:    "upload_failed",
+		Details: err.Error(),
+		Trace:   records,
+	}
+
+	switch {
+	case errors.Is(err, services.ErrInvalidKeyTemplate):
+		status, response.Error, response.Code = fiber.StatusBadRequest, "Invalid key template", "invalid_key_template"
+	case errors.Is(err, services.ErrObjectExists):
+		status, response.Error, response.Code = fiber.StatusConflict, "Object already exists", "object_exists"
+	case errors.Is(err, services.ErrObjectTooLarge):
+		status, response.Error, response.Code = fiber.StatusRequestEntityTooLarge, "Output too large to store", "object_too_large"
+	case errors.Is(err, services.ErrContentTypeNotAllowed):
+		status, response.Error, response.Code = fiber.StatusUnsupportedMediaType, "Output type not allowed", "content_type_not_allowed"
+	case errors.Is(err, providers.ErrVerificationFailed):
+		response.Error, response.Code = "Stored object didn't match the upload", "upload_verification_failed"
+	}
+
+	return c.Status(status).JSON(response)
+}
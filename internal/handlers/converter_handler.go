@@ -2,32 +2,50 @@ package handlers
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
-	"io"
-	"path/filepath"
+	"log"
+	"runtime"
+	"runtime/metrics"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
 	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/pool"
 	"whats-convert-api/internal/services"
 )
 
 // ConverterHandler handles HTTP requests for media conversion
 type ConverterHandler struct {
-	audioConverter *services.AudioConverter
-	imageConverter *services.ImageConverter
+	audioConverter services.AudioConverterIface
+	imageConverter services.ImageConverterIface
+	videoConverter services.VideoConverterIface
 	requestTimeout time.Duration
+	timeouts       RouteTimeouts
+	batch          BatchLimits
+	batchJobs      *services.BatchJobManager // Runs the asynchronous batch endpoints
+	commandTrace   bool
+	s3Service      *services.S3Service // Enables the convert-and-upload endpoints (nil = S3 disabled)
+	spillStore     *services.SpillStore
+	tempJanitor    *services.TempJanitor
+	inspector      *services.MediaInspector  // Runs the inspection endpoint
+	cache          *services.ConversionCache // Reported in /stats (nil = disabled)
+	workerPool     *pool.WorkerPool          // Queue reported in /stats (nil = not reported)
+	downloader     *services.Downloader      // Downloads reported in /stats (nil = not reported)
+	postProcessors *services.PostProcessors  // Savings reported in /stats (nil = none configured)
+	readOnly       bool                      // Reported in /health (READ_ONLY)
 }
 
 // NewConverterHandler creates a new converter handler
 func NewConverterHandler(
-	audioConverter *services.AudioConverter,
-	imageConverter *services.ImageConverter,
+	audioConverter services.AudioConverterIface,
+	imageConverter services.ImageConverterIface,
+	videoConverter services.VideoConverterIface,
 	requestTimeout time.Duration,
+	commandTrace bool,
 ) *ConverterHandler {
 	if requestTimeout <= 0 {
 		requestTimeout = 5 * time.Minute
@@ -36,22 +54,44 @@ func NewConverterHandler(
 	return &ConverterHandler{
 		audioConverter: audioConverter,
 		imageConverter: imageConverter,
+		videoConverter: videoConverter,
 		requestTimeout: requestTimeout,
+		batch:          defaultBatchLimits,
+		commandTrace:   commandTrace,
 	}
 }
 
 // ConvertAudio godoc
 // @Summary Convert audio to WhatsApp-compatible Opus format
-// @Description Accepts base64 payloads or multipart uploads and returns an optimized Opus data URI.
+// @Description Accepts base64 payloads or multipart uploads and returns an optimized Opus data URI. With output_format mp3/wav or the reverse preset, received voice notes are converted to MP3 or WAV instead.
 // @Tags Conversion
 // @Accept json
 // @Accept multipart/form-data
 // @Produce json
+// @Produce multipart/form-data
+// @Produce audio/ogg
+// @Produce audio/mpeg
+// @Produce audio/wav
 // @Param request body services.AudioRequest true "Audio conversion request"
 // @Param file formData file false "Audio file when using multipart"
+// @Param data_uri formData bool false "Multipart only: false returns plain base64 instead of a data URI"
+// @Param skip_if_compliant formData bool false "Multipart only: return mono 48kHz Ogg/Opus input without re-encoding"
+// @Param output_format formData string false "Multipart only: opus (default), mp3 or wav"
+// @Param preset formData string false "Multipart only: whatsapp (default), reverse (MP3, mono; 16kHz when WAV) or a PRESETS_FILE audio preset"
+// @Param include_waveform formData bool false "Multipart only: also return waveform, 64 voice note amplitudes (JSON and multipart metadata only)"
+// @Param fingerprint formData bool false "Multipart only: also return the input's chromaprint in fingerprint (JSON and multipart metadata only)"
+// @Param normalize formData bool false "Multipart only: normalize loudness to the EBU R128 targets (AUDIO_LOUDNORM_*)"
+// @Param target_size_mb formData number false "Multipart only: pick the Opus/MP3 bitrate so the output fits in this many MiB"
+// @Param compress formData string false "Multipart only: br returns Brotli-compressed plain base64 when that is smaller (response sets compression)"
+// @Param format query string false "binary returns the converted bytes as the response body"
+// @Param Accept header string false "multipart/form-data returns a JSON metadata part plus the converted binary part(s); audio/ogg, audio/mpeg, audio/wav or application/octet-stream returns the converted bytes as the body"
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg/vips commands (requires ENABLE_COMMAND_TRACE)"
+// @Param debug_timings query bool false "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)"
 // @Success 200 {object} services.AudioResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 408 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "Input longer than MAX_AUDIO_DURATION (code duration_limit_exceeded) or too long to fit target_size_mb (code target_size_unreachable)"
+// @Failure 415 {object} models.ErrorResponse "Input is an image (code unsupported_input)"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /convert/audio [post]
 func (h *ConverterHandler) ConvertAudio(c fiber.Ctx) error {
@@ -70,11 +110,33 @@ func (h *ConverterHandler) ConvertAudio(c fiber.Ctx) error {
 // @Accept json
 // @Accept multipart/form-data
 // @Produce json
+// @Produce multipart/form-data
+// @Produce image/jpeg
+// @Produce image/png
+// @Produce image/webp
 // @Param request body services.ImageRequest true "Image conversion request"
 // @Param file formData file false "Image file when using multipart"
+// @Param data_uri formData bool false "Multipart only: false returns plain base64 instead of a data URI"
+// @Param skip_if_compliant formData bool false "Multipart only: return a JPEG within max_width/max_height without re-encoding"
+// @Param quality_check formData bool false "Multipart only: return SSIM/PSNR of the output against the input"
+// @Param background formData string false "Multipart only: colour transparent areas are flattened onto, e.g. #ffffff"
+// @Param preserve_alpha formData bool false "Multipart only: keep transparency by returning WebP or PNG"
+// @Param generate_thumbnail formData bool false "Multipart only: also return jpeg_thumbnail, a 72px JPEG preview for WhatsApp messages (JSON and multipart metadata only)"
+// @Param fingerprint formData bool false "Multipart only: also return the input's perceptual hash in fingerprint (JSON and multipart metadata only)"
+// @Param max_file_size_kb formData int false "Multipart only: lower the quality until the output fits in this many KB"
+// @Param min_width formData int false "Multipart only: enlarge smaller images to at least this width (response sets upscaled)"
+// @Param min_height formData int false "Multipart only: enlarge smaller images to at least this height (response sets upscaled)"
+// @Param compress formData string false "Multipart only: br returns Brotli-compressed plain base64 when that is smaller (response sets compression)"
+// @Param preset formData string false "Multipart only: PRESETS_FILE image preset filling the fields left unset"
+// @Param format query string false "binary returns the converted bytes as the response body"
+// @Param Accept header string false "multipart/form-data returns a JSON metadata part plus the converted binary part(s); image/jpeg, image/png, image/webp or application/octet-stream returns the converted bytes as the body"
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg/vips commands (requires ENABLE_COMMAND_TRACE)"
+// @Param debug_timings query bool false "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)"
 // @Success 200 {object} services.ImageResponse
-// @Failure 400 {object} models.ErrorResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request or unknown preset (code unknown_preset)"
 // @Failure 408 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "Image exceeds MAX_IMAGE_MEGAPIXELS (code pixel_limit_exceeded), the output scored below IMAGE_MIN_SSIM/IMAGE_MIN_PSNR (code quality_below_threshold) or can't fit max_file_size_kb (code target_size_unreachable)"
+// @Failure 415 {object} models.ErrorResponse "Input is audio (code unsupported_input)"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /convert/image [post]
 func (h *ConverterHandler) ConvertImage(c fiber.Ctx) error {
@@ -88,13 +150,24 @@ func (h *ConverterHandler) ConvertImage(c fiber.Ctx) error {
 
 // ConvertBatchAudio godoc
 // @Summary Convert a batch of audio payloads
-// @Description Processes up to 10 audio conversion jobs concurrently.
+// @Description Processes up to BATCH_MAX_SIZE (default 10) audio conversion jobs concurrently, at most BATCH_CONCURRENCY at a time, each bounded by BATCH_ITEM_TIMEOUT. item_timeout and concurrency can lower these per request; the applied values are returned in X-Batch-Item-Timeout and X-Batch-Concurrency.
 // @Tags Conversion
 // @Accept json
 // @Produce json
+// @Produce multipart/form-data
+// @Produce application/zstd
 // @Param request body []services.AudioRequest true "Batch audio conversion request"
+// @Param item_timeout query string false "Deadline of each item, e.g. 30s or 30 (capped at BATCH_ITEM_TIMEOUT)"
+// @Param concurrency query int false "Items converted at once (capped at BATCH_CONCURRENCY)"
+// @Param archive query string false "tar.zst returns every output in one Zstandard-compressed tar with a manifest.json instead of base64 results"
+// @Param Accept header string false "multipart/form-data returns a JSON metadata part plus the converted binary part(s)"
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg/vips commands (requires ENABLE_COMMAND_TRACE)"
+// @Param debug_timings query bool false "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)"
 // @Success 200 {object} models.BatchAudioResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "An input is longer than MAX_AUDIO_DURATION (code duration_limit_exceeded)"
+// @Failure 415 {object} models.ErrorResponse "An item is an image (code unsupported_input)"
+// @Failure 408 {object} models.ErrorResponse "The batch ran past BATCH_TIMEOUT"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /convert/batch/audio [post]
 func (h *ConverterHandler) ConvertBatchAudio(c fiber.Ctx) error {
@@ -114,52 +187,97 @@ func (h *ConverterHandler) ConvertBatchAudio(c fiber.Ctx) error {
 		})
 	}
 
-	if len(requests) > 10 { // Limit batch size
+	if len(requests) > h.batch.MaxSize {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error:   "Batch too large",
-			Details: "Maximum 10 items per batch",
+			Code:    "batch_too_large",
+			Details: fmt.Sprintf("Maximum %d items per batch", h.batch.MaxSize),
+		})
+	}
+
+	opts, err := h.batchOptions(c, len(requests), h.batch.Concurrency)
+	var archive string
+	if err == nil {
+		archive, err = services.ParseBatchArchiveFormat(c.Query("archive"))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid batch options",
+			Code:    "invalid_batch_options",
+			Details: err.Error(),
 		})
 	}
 
-	// Create context with extended timeout for batch
-	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout*time.Duration(len(requests)))
+	// Bound the whole batch (BATCH_TIMEOUT, else the request timeout per item)
+	ctx, cancel := withDeadline(c, h.batchTimeout(len(requests)))
 	defer cancel()
 
 	// Convert request slice to pointer slice
+	multipartOutput := wantsMultipart(c)
 	reqPointers := make([]*services.AudioRequest, len(requests))
 	for i := range requests {
+		requests[i].RawOutput = multipartOutput || archive != ""
 		reqPointers[i] = &requests[i]
 	}
 
+	ctx, trace := h.startTrace(c, ctx)
+	ctx, timer := startTimings(c, ctx)
+
 	// Process batch conversion
 	start := time.Now()
-	responses, err := h.audioConverter.ConvertBatch(ctx, reqPointers)
+	responses, err := h.audioConverter.ConvertBatch(ctx, reqPointers, opts)
+	records := h.finishTrace(c, trace)
+	timings := finishTimings(c, timer)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error:   "Batch conversion failed",
-			Details: err.Error(),
-		})
+		return conversionError(ctx, c, err, records, "Batch conversion failed")
 	}
 
 	// Set response headers
 	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
 	c.Set("X-Batch-Size", fmt.Sprintf("%d", len(responses)))
 
-	return c.JSON(models.BatchAudioResponse{
+	if archive != "" {
+		return sendBatchArchive(c, services.NewAudioBatchArchive(reqPointers, responses), "batch-audio")
+	}
+
+	batch := models.BatchAudioResponse{
 		Results: responses,
 		Count:   len(responses),
-	})
+		Trace:   records,
+		Timings: timings,
+	}
+
+	if multipartOutput {
+		files := make([]outputFile, len(responses))
+		for i, response := range responses {
+			files[i] = outputFile{field: fmt.Sprintf("file_%d", i), mimeType: response.MimeType, data: response.Output}
+		}
+		return sendMultipart(c, batch, files)
+	}
+
+	return c.JSON(batch)
 }
 
 // ConvertBatchImage godoc
 // @Summary Convert a batch of image payloads
-// @Description Processes up to 10 image conversion jobs concurrently.
+// @Description Processes up to BATCH_MAX_SIZE (default 10) image conversion jobs concurrently, at most BATCH_CONCURRENCY at a time, each bounded by BATCH_ITEM_TIMEOUT. item_timeout and concurrency can lower these per request; the applied values are returned in X-Batch-Item-Timeout and X-Batch-Concurrency.
 // @Tags Conversion
 // @Accept json
 // @Produce json
+// @Produce multipart/form-data
+// @Produce application/zstd
 // @Param request body []services.ImageRequest true "Batch image conversion request"
+// @Param item_timeout query string false "Deadline of each item, e.g. 30s or 30 (capped at BATCH_ITEM_TIMEOUT)"
+// @Param concurrency query int false "Items converted at once (capped at BATCH_CONCURRENCY)"
+// @Param archive query string false "tar.zst returns every output in one Zstandard-compressed tar with a manifest.json instead of base64 results"
+// @Param Accept header string false "multipart/form-data returns a JSON metadata part plus the converted binary part(s)"
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg/vips commands (requires ENABLE_COMMAND_TRACE)"
+// @Param debug_timings query bool false "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)"
 // @Success 200 {object} models.BatchImageResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "An image exceeds MAX_IMAGE_MEGAPIXELS (code pixel_limit_exceeded), an output scored below IMAGE_MIN_SSIM/IMAGE_MIN_PSNR (code quality_below_threshold) or can't fit max_file_size_kb (code target_size_unreachable)"
+// @Failure 415 {object} models.ErrorResponse "An item is audio (code unsupported_input)"
+// @Failure 408 {object} models.ErrorResponse "The batch ran past BATCH_TIMEOUT"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /convert/batch/image [post]
 func (h *ConverterHandler) ConvertBatchImage(c fiber.Ctx) error {
@@ -179,46 +297,80 @@ func (h *ConverterHandler) ConvertBatchImage(c fiber.Ctx) error {
 		})
 	}
 
-	if len(requests) > 10 { // Limit batch size
+	if len(requests) > h.batch.MaxSize {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error:   "Batch too large",
-			Details: "Maximum 10 items per batch",
+			Code:    "batch_too_large",
+			Details: fmt.Sprintf("Maximum %d items per batch", h.batch.MaxSize),
 		})
 	}
 
-	// Create context with extended timeout for batch
-	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout*time.Duration(len(requests)))
+	opts, err := h.batchOptions(c, len(requests), h.batch.Concurrency)
+	var archive string
+	if err == nil {
+		archive, err = services.ParseBatchArchiveFormat(c.Query("archive"))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid batch options",
+			Code:    "invalid_batch_options",
+			Details: err.Error(),
+		})
+	}
+
+	// Bound the whole batch (BATCH_TIMEOUT, else the request timeout per item)
+	ctx, cancel := withDeadline(c, h.batchTimeout(len(requests)))
 	defer cancel()
 
 	// Convert request slice to pointer slice
+	multipartOutput := wantsMultipart(c)
 	reqPointers := make([]*services.ImageRequest, len(requests))
 	for i := range requests {
+		requests[i].RawOutput = multipartOutput || archive != ""
 		reqPointers[i] = &requests[i]
 	}
 
+	ctx, trace := h.startTrace(c, ctx)
+	ctx, timer := startTimings(c, ctx)
+
 	// Process batch conversion
 	start := time.Now()
-	responses, err := h.imageConverter.ConvertBatch(ctx, reqPointers)
+	responses, err := h.imageConverter.ConvertBatch(ctx, reqPointers, opts)
+	records := h.finishTrace(c, trace)
+	timings := finishTimings(c, timer)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error:   "Batch conversion failed",
-			Details: err.Error(),
-		})
+		return conversionError(ctx, c, err, records, "Batch conversion failed")
 	}
 
 	// Set response headers
 	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
 	c.Set("X-Batch-Size", fmt.Sprintf("%d", len(responses)))
 
-	return c.JSON(models.BatchImageResponse{
+	if archive != "" {
+		return sendBatchArchive(c, services.NewImageBatchArchive(reqPointers, responses), "batch-image")
+	}
+
+	batch := models.BatchImageResponse{
 		Results: responses,
 		Count:   len(responses),
-	})
+		Trace:   records,
+		Timings: timings,
+	}
+
+	if multipartOutput {
+		files := make([]outputFile, len(responses))
+		for i, response := range responses {
+			files[i] = outputFile{field: fmt.Sprintf("file_%d", i), mimeType: response.MimeType, data: response.Output}
+		}
+		return sendMultipart(c, batch, files)
+	}
+
+	return c.JSON(batch)
 }
 
 // Health godoc
 // @Summary Service health snapshot
-// @Description Returns aggregated success metrics for audio and image converters.
+// @Description Returns aggregated success metrics for audio and image converters, and whether the service is read-only (READ_ONLY).
 // @Tags Monitoring
 // @Produce json
 // @Success 200 {object} models.HealthResponse
@@ -242,6 +394,7 @@ func (h *ConverterHandler) Health(c fiber.Ctx) error {
 	return c.JSON(models.HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now().Unix(),
+		ReadOnly:  h.readOnly,
 		Audio: models.AudioHealthMetrics{
 			TotalConversions:  audioStats.TotalConversions,
 			FailedConversions: audioStats.FailedConversions,
@@ -253,7 +406,7 @@ func (h *ConverterHandler) Health(c fiber.Ctx) error {
 			FailedConversions: imageStats.FailedConversions,
 			SuccessRate:       fmt.Sprintf("%.2f%%", imageSuccessRate*100),
 			AvgConversionMS:   imageStats.AvgConversionTime.Milliseconds(),
-			VipsAvailable:     h.imageConverter.IsVipsAvailable(),
+			VipsAvailable:     services.IsVipsAvailable(h.imageConverter),
 		},
 	})
 }
@@ -268,12 +421,15 @@ func (h *ConverterHandler) Health(c fiber.Ctx) error {
 func (h *ConverterHandler) Stats(c fiber.Ctx) error {
 	audioStats := h.audioConverter.GetStats()
 	imageStats := h.imageConverter.GetStats()
+	videoStats := h.videoConverter.GetStats()
 
 	return c.JSON(models.StatsResponse{
 		Audio: models.ConverterStats{
 			TotalConversions:    audioStats.TotalConversions,
 			FailedConversions:   audioStats.FailedConversions,
+			SkippedConversions:  audioStats.SkippedConversions,
 			AvgConversionTimeMS: audioStats.AvgConversionTime.Milliseconds(),
+			Variants:            variantStats(audioStats.Variants),
 		},
 		Image: models.ImageConverterStats{
 			TotalConversions:    imageStats.TotalConversions,
@@ -281,11 +437,174 @@ func (h *ConverterHandler) Stats(c fiber.Ctx) error {
 			AvgConversionTimeMS: imageStats.AvgConversionTime.Milliseconds(),
 			VipsConversions:     imageStats.VipsConversions,
 			FFmpegConversions:   imageStats.FFmpegConversions,
+			SkippedConversions:  imageStats.SkippedConversions,
 		},
-		Timestamp: time.Now().Unix(),
+		Video: models.VideoConverterStats{
+			TotalConversions:    videoStats.TotalConversions,
+			FailedConversions:   videoStats.FailedConversions,
+			AvgConversionTimeMS: videoStats.AvgConversionTime.Milliseconds(),
+			Variants:            variantStats(videoStats.Variants),
+		},
+		TempFiles:       h.tempFileStats(),
+		ConversionCache: h.conversionCacheStats(),
+		Queue:           h.queueStats(),
+		Downloads:       h.downloadStats(),
+		PostProcessors:  h.postProcessors.Stats(),
+		Runtime:         runtimeStats(),
+		Timestamp:       time.Now().Unix(),
 	})
 }
 
+// SetConversionCache reports conversion cache hits in /stats
+func (h *ConverterHandler) SetConversionCache(cache *services.ConversionCache) {
+	h.cache = cache
+}
+
+func (h *ConverterHandler) conversionCacheStats() *services.ConversionCacheStats {
+	if h.cache == nil {
+		return nil
+	}
+
+	stats := h.cache.Stats()
+	return &stats
+}
+
+// SetReadOnly reports in /health that conversions are refused (READ_ONLY)
+func (h *ConverterHandler) SetReadOnly(readOnly bool) {
+	h.readOnly = readOnly
+}
+
+// SetWorkerPool reports the conversions holding or waiting for a worker in /stats
+func (h *ConverterHandler) SetWorkerPool(workerPool *pool.WorkerPool) {
+	h.workerPool = workerPool
+}
+
+func (h *ConverterHandler) queueStats() *models.QueueStats {
+	if h.workerPool == nil {
+		return nil
+	}
+
+	stats := h.workerPool.Stats()
+	return &models.QueueStats{
+		Workers:         stats.MaxWorkers,
+		Active:          stats.ActiveConversions,
+		Waiting:         stats.WaitingConversions,
+		FairQueuing:     h.workerPool.FairQueuing(),
+		WaitingByTenant: stats.WaitingByTenant,
+	}
+}
+
+// SetDownloader reports URL downloads and host circuit breakers in /stats
+func (h *ConverterHandler) SetDownloader(downloader *services.Downloader) {
+	h.downloader = downloader
+}
+
+func (h *ConverterHandler) downloadStats() *models.DownloadStats {
+	if h.downloader == nil {
+		return nil
+	}
+
+	stats := h.downloader.GetStats()
+	circuits := h.downloader.Circuits()
+	downloads := &models.DownloadStats{
+		Total:          stats.TotalDownloads,
+		Failed:         stats.FailedDownloads,
+		Bytes:          stats.TotalBytes,
+		AvgDownloadMS:  stats.AvgDownloadTime.Milliseconds(),
+		CircuitBreaker: circuits != nil,
+		Circuits:       circuits,
+	}
+	if downloads.Circuits == nil {
+		downloads.Circuits = []services.HostCircuit{}
+	}
+	return downloads
+}
+
+// SetPostProcessors reports the savings of each output post-processor in /stats
+func (h *ConverterHandler) SetPostProcessors(processors *services.PostProcessors) {
+	h.postProcessors = processors
+}
+
+// Runtime metrics behind RuntimeStats, read without the stop-the-world
+// pause of runtime.ReadMemStats
+const (
+	metricHeapObjectBytes = "/memory/classes/heap/objects:bytes"
+	metricHeapObjects     = "/gc/heap/objects:objects"
+	metricTotalMemory     = "/memory/classes/total:bytes"
+	metricGCCycles        = "/gc/cycles/total:gc-cycles"
+)
+
+func runtimeStats() models.RuntimeStats {
+	samples := []metrics.Sample{
+		{Name: metricHeapObjectBytes},
+		{Name: metricHeapObjects},
+		{Name: metricTotalMemory},
+		{Name: metricGCCycles},
+	}
+	metrics.Read(samples)
+
+	value := func(i int) uint64 {
+		if samples[i].Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+		return samples[i].Value.Uint64()
+	}
+
+	return models.RuntimeStats{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: value(0),
+		HeapObjects:    value(1),
+		SysBytes:       value(2),
+		NumGC:          uint32(value(3)),
+	}
+}
+
+// SetTempFiles reports payload spilling and temp janitor metrics in /stats
+func (h *ConverterHandler) SetTempFiles(spillStore *services.SpillStore, tempJanitor *services.TempJanitor) {
+	h.spillStore = spillStore
+	h.tempJanitor = tempJanitor
+}
+
+func (h *ConverterHandler) tempFileStats() *models.TempFileStats {
+	if h.spillStore == nil && h.tempJanitor == nil {
+		return nil
+	}
+
+	var stats models.TempFileStats
+	if h.spillStore != nil {
+		spill := h.spillStore.GetStats()
+		stats.SpillEnabled = true
+		stats.SpillDir = spill.Dir
+		stats.SpillThreshold = spill.Threshold
+		stats.SpillBudget = spill.Budget
+		stats.ActiveSpills = spill.ActiveFiles
+		stats.ActiveSpillBytes = spill.ActiveBytes
+		stats.Spilled = spill.Spilled
+		stats.OverBudget = spill.OverBudget
+	}
+	if h.tempJanitor != nil {
+		stats.Swept = h.tempJanitor.Swept()
+	}
+	return &stats
+}
+
+func variantStats(variants map[string]services.VariantStats) map[string]models.EncoderVariantStats {
+	if variants == nil {
+		return nil
+	}
+
+	stats := make(map[string]models.EncoderVariantStats, len(variants))
+	for name, variant := range variants {
+		stats[name] = models.EncoderVariantStats{
+			Conversions:         variant.Conversions,
+			FailedConversions:   variant.FailedConversions,
+			AvgConversionTimeMS: variant.AvgConversionTime.Milliseconds(),
+			AvgOutputSize:       variant.AvgOutputSize,
+		}
+	}
+	return stats
+}
+
 func (h *ConverterHandler) parseAudioRequest(c fiber.Ctx) (*services.AudioRequest, error) {
 	contentType := strings.ToLower(c.Get("Content-Type"))
 	if strings.HasPrefix(contentType, "multipart/form-data") {
@@ -322,33 +641,51 @@ func (h *ConverterHandler) processAudioConversion(c fiber.Ctx, req *services.Aud
 	}
 
 	req.Data = sanitizeBase64Data(req.Data)
-	if strings.TrimSpace(req.Data) == "" {
+	if req.Source == nil && req.Upload == nil && strings.TrimSpace(req.Data) == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Missing 'data' field",
 		})
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	ctx, cancel := withDeadline(c, h.routeTimeout(h.timeouts.Audio))
 	defer cancel()
 
+	ctx, trace := h.startTrace(c, ctx)
+	ctx, timer := startTimings(c, ctx)
+	binaryOutput := wantsBinary(c, "audio/ogg", "audio/mpeg", "audio/wav")
+	multipartOutput := !binaryOutput && wantsMultipart(c)
+	req.RawOutput = binaryOutput || multipartOutput
+
 	start := time.Now()
 	response, err := h.audioConverter.Convert(ctx, req)
+	records := h.finishTrace(c, trace)
+	timings := finishTimings(c, timer)
 	if err != nil {
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{
-				Error:   "Request timeout",
-				Details: "Conversion took too long",
-			})
-		}
-
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error:   "Conversion failed",
-			Details: err.Error(),
-		})
+		return conversionError(ctx, c, err, records, "Conversion failed")
 	}
+	response.Trace = records
+	response.Timings = timings
 
 	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
 	c.Set("X-Output-Size", fmt.Sprintf("%d", response.Size))
+	if response.DurationLimitExceeded {
+		c.Set("X-Duration-Limit-Exceeded", "true")
+	}
+	if response.Bitrate > 0 {
+		c.Set("X-Encoded-Bitrate", strconv.Itoa(response.Bitrate))
+	}
+	if response.EncoderVariant != "" {
+		c.Set("X-Encoder-Variant", response.EncoderVariant)
+	}
+
+	if binaryOutput {
+		c.Set("X-Output-Duration", fmt.Sprintf("%d", response.Duration))
+		return sendBinary(c, response.MimeType, response.Output)
+	}
+
+	if multipartOutput {
+		return sendMultipart(c, response, []outputFile{{field: "file", mimeType: response.MimeType, data: response.Output}})
+	}
 
 	return c.JSON(response)
 }
@@ -361,98 +698,167 @@ func (h *ConverterHandler) processImageConversion(c fiber.Ctx, req *services.Ima
 	}
 
 	req.Data = sanitizeBase64Data(req.Data)
-	if strings.TrimSpace(req.Data) == "" {
+	if req.Source == nil && req.Input == nil && strings.TrimSpace(req.Data) == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Missing 'data' field",
 		})
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), h.requestTimeout)
+	ctx, cancel := withDeadline(c, h.routeTimeout(h.timeouts.Image))
 	defer cancel()
 
+	ctx, trace := h.startTrace(c, ctx)
+	ctx, timer := startTimings(c, ctx)
+	binaryOutput := wantsBinary(c, "image/jpeg", "image/png", "image/webp")
+	multipartOutput := !binaryOutput && wantsMultipart(c)
+	req.RawOutput = binaryOutput || multipartOutput
+
 	start := time.Now()
 	response, err := h.imageConverter.Convert(ctx, req)
+	records := h.finishTrace(c, trace)
+	timings := finishTimings(c, timer)
 	if err != nil {
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{
-				Error:   "Request timeout",
-				Details: "Conversion took too long",
-			})
-		}
-
-		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
-			Error:   "Conversion failed",
-			Details: err.Error(),
-		})
+		return conversionError(ctx, c, err, records, "Conversion failed")
 	}
+	response.Trace = records
+	response.Timings = timings
 
 	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
 	c.Set("X-Output-Size", fmt.Sprintf("%d", response.Size))
 	c.Set("X-Output-Dimensions", fmt.Sprintf("%dx%d", response.Width, response.Height))
+	if response.EncodedQuality > 0 {
+		c.Set("X-Encoded-Quality", strconv.Itoa(response.EncodedQuality))
+	}
+
+	if binaryOutput {
+		return sendBinary(c, response.MimeType, response.Output)
+	}
+
+	if multipartOutput {
+		return sendMultipart(c, response, []outputFile{{field: "file", mimeType: response.MimeType, data: response.Output}})
+	}
 
 	return c.JSON(response)
 }
 
+// parseMultipartAudio leaves the upload where the multipart parser put it;
+// the converter streams it to FFmpeg
 func parseMultipartAudio(c fiber.Ctx) (*services.AudioRequest, error) {
-	fileHeader, err := c.FormFile("file")
+	fileHeader, err := formUpload(c)
 	if err != nil {
-		return nil, newRequestError(fiber.StatusBadRequest, "Missing file", "file field is required")
+		return nil, err
 	}
 
-	file, err := fileHeader.Open()
+	dataURI, err := parseBoolForm(c, "data_uri")
 	if err != nil {
-		return nil, newRequestError(fiber.StatusInternalServerError, "Failed to open uploaded file", err.Error())
+		return nil, err
 	}
-	defer file.Close()
-
-	data, err := io.ReadAll(file)
+	skipIfCompliant, err := parseBoolForm(c, "skip_if_compliant")
 	if err != nil {
-		return nil, newRequestError(fiber.StatusInternalServerError, "Failed to read uploaded file", err.Error())
+		return nil, err
 	}
-	if len(data) == 0 {
-		return nil, newRequestError(fiber.StatusBadRequest, "Uploaded file is empty", "")
+	includeWaveform, err := parseBoolForm(c, "include_waveform")
+	if err != nil {
+		return nil, err
 	}
-
-	encoded := base64.StdEncoding.EncodeToString(data)
-	inputType := strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
-	if inputType == "" {
-		inputType = deriveInputTypeFromContentType(fileHeader.Header.Get("Content-Type"))
+	normalize, err := parseBoolForm(c, "normalize")
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := parseBoolForm(c, "fingerprint")
+	if err != nil {
+		return nil, err
 	}
-	if formType := strings.TrimSpace(c.FormValue("input_type")); formType != "" {
-		inputType = formType
+	targetSizeMB, err := parseTargetSizeForm(c)
+	if err != nil {
+		return nil, err
 	}
 
 	return &services.AudioRequest{
-		Data:      encoded,
-		IsURL:     false,
-		InputType: inputType,
+		Upload:          fileHeader,
+		InputType:       strings.TrimSpace(c.FormValue("input_type")),
+		DataURI:         dataURI,
+		SkipIfCompliant: skipIfCompliant,
+		OutputFormat:    strings.TrimSpace(c.FormValue("output_format")),
+		Preset:          strings.TrimSpace(c.FormValue("preset")),
+		IncludeWaveform: includeWaveform != nil && *includeWaveform,
+		Normalize:       normalize != nil && *normalize,
+		Fingerprint:     fingerprint != nil && *fingerprint,
+		TargetSizeMB:    targetSizeMB,
+		Compress:        strings.TrimSpace(c.FormValue("compress")),
 	}, nil
 }
 
-func parseMultipartImage(c fiber.Ctx) (*services.ImageRequest, error) {
-	fileHeader, err := c.FormFile("file")
+// parseTargetSizeForm reads the optional target_size_mb multipart field
+func parseTargetSizeForm(c fiber.Ctx) (float64, error) {
+	sizeStr := strings.TrimSpace(c.FormValue("target_size_mb"))
+	if sizeStr == "" {
+		return 0, nil
+	}
+
+	size, err := strconv.ParseFloat(sizeStr, 64)
 	if err != nil {
-		return nil, newRequestError(fiber.StatusBadRequest, "Missing file", "file field is required")
+		return 0, newRequestError(fiber.StatusBadRequest, "Invalid target_size_mb value", "target_size_mb must be a number")
 	}
+	return size, nil
+}
 
-	file, err := fileHeader.Open()
+// parseMultipartImage reads the upload as raw bytes, which vips and the
+// compliance checks need in memory
+func parseMultipartImage(c fiber.Ctx) (*services.ImageRequest, error) {
+	fileHeader, err := formUpload(c)
 	if err != nil {
-		return nil, newRequestError(fiber.StatusInternalServerError, "Failed to open uploaded file", err.Error())
+		return nil, err
+	}
+	data, err := readUpload(fileHeader)
+	if err != nil {
+		return nil, err
 	}
-	defer file.Close()
 
-	data, err := io.ReadAll(file)
+	dataURI, err := parseBoolForm(c, "data_uri")
+	if err != nil {
+		return nil, err
+	}
+	skipIfCompliant, err := parseBoolForm(c, "skip_if_compliant")
+	if err != nil {
+		return nil, err
+	}
+	qualityCheck, err := parseBoolForm(c, "quality_check")
+	if err != nil {
+		return nil, err
+	}
+	preserveAlpha, err := parseBoolForm(c, "preserve_alpha")
 	if err != nil {
-		return nil, newRequestError(fiber.StatusInternalServerError, "Failed to read uploaded file", err.Error())
+		return nil, err
 	}
-	if len(data) == 0 {
-		return nil, newRequestError(fiber.StatusBadRequest, "Uploaded file is empty", "")
+	generateThumbnail, err := parseBoolForm(c, "generate_thumbnail")
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := parseBoolForm(c, "fingerprint")
+	if err != nil {
+		return nil, err
 	}
 
-	encoded := base64.StdEncoding.EncodeToString(data)
 	req := &services.ImageRequest{
-		Data:  encoded,
-		IsURL: false,
+		Input:             data,
+		DataURI:           dataURI,
+		SkipIfCompliant:   skipIfCompliant,
+		QualityCheck:      qualityCheck,
+		Background:        strings.TrimSpace(c.FormValue("background")),
+		PreserveAlpha:     preserveAlpha != nil && *preserveAlpha,
+		GenerateThumbnail: generateThumbnail != nil && *generateThumbnail,
+		Fingerprint:       fingerprint != nil && *fingerprint,
+		Compress:          strings.TrimSpace(c.FormValue("compress")),
+		Preset:            strings.TrimSpace(c.FormValue("preset")),
+	}
+
+	if sizeStr := strings.TrimSpace(c.FormValue("max_file_size_kb")); sizeStr != "" {
+		size, convErr := strconv.Atoi(sizeStr)
+		if convErr != nil {
+			return nil, newRequestError(fiber.StatusBadRequest, "Invalid max_file_size_kb value", "max_file_size_kb must be an integer")
+		}
+		req.MaxFileSizeKB = size
 	}
 
 	if qualityStr := strings.TrimSpace(c.FormValue("quality")); qualityStr != "" {
@@ -479,9 +885,62 @@ func parseMultipartImage(c fiber.Ctx) (*services.ImageRequest, error) {
 		req.MaxHeight = height
 	}
 
+	if widthStr := strings.TrimSpace(c.FormValue("min_width")); widthStr != "" {
+		width, convErr := strconv.Atoi(widthStr)
+		if convErr != nil {
+			return nil, newRequestError(fiber.StatusBadRequest, "Invalid min_width value", "min_width must be an integer")
+		}
+		req.MinWidth = width
+	}
+
+	if heightStr := strings.TrimSpace(c.FormValue("min_height")); heightStr != "" {
+		height, convErr := strconv.Atoi(heightStr)
+		if convErr != nil {
+			return nil, newRequestError(fiber.StatusBadRequest, "Invalid min_height value", "min_height must be an integer")
+		}
+		req.MinHeight = height
+	}
+
 	return req, nil
 }
 
+// startTrace attaches a command trace to ctx when ENABLE_COMMAND_TRACE is on and
+// the request opts in with "X-Debug-Trace: true" or "?debug=true"
+func (h *ConverterHandler) startTrace(c fiber.Ctx, ctx context.Context) (context.Context, *services.CommandTrace) {
+	if !h.commandTrace {
+		return ctx, nil
+	}
+
+	requested := c.Get("X-Debug-Trace")
+	if requested == "" {
+		requested = c.Query("debug")
+	}
+	if enabled, _ := strconv.ParseBool(requested); !enabled {
+		return ctx, nil
+	}
+
+	trace := services.NewCommandTrace()
+	return services.WithCommandTrace(ctx, trace), trace
+}
+
+// finishTrace logs the traced commands with the request ID and returns them for the response
+func (h *ConverterHandler) finishTrace(c fiber.Ctx, trace *services.CommandTrace) []services.CommandRecord {
+	if trace == nil {
+		return nil
+	}
+
+	records := trace.Records()
+	requestID := requestid.FromContext(c)
+	for _, record := range records {
+		log.Printf("[trace %s] exit=%d %dms %s", requestID, record.ExitCode, record.DurationMS, record.Command)
+		if record.ExitCode != 0 && record.StderrTail != "" {
+			log.Printf("[trace %s] stderr: %s", requestID, record.StderrTail)
+		}
+	}
+
+	return records
+}
+
 type requestError struct {
 	status  int
 	message string
@@ -531,6 +990,20 @@ func respondWithError(c fiber.Ctx, err error) error {
 	})
 }
 
+// parseBoolForm reads an optional boolean form field (nil when absent)
+func parseBoolForm(c fiber.Ctx, field string) (*bool, error) {
+	value := strings.TrimSpace(c.FormValue(field))
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, newRequestError(fiber.StatusBadRequest, "Invalid "+field+" value", field+" must be true or false")
+	}
+	return &parsed, nil
+}
+
 func sanitizeBase64Data(data string) string {
 	trimmed := strings.TrimSpace(data)
 	if trimmed == "" {
@@ -543,14 +1016,3 @@ func sanitizeBase64Data(data string) string {
 	}
 	return trimmed
 }
-
-func deriveInputTypeFromContentType(contentType string) string {
-	if contentType == "" {
-		return ""
-	}
-	parts := strings.Split(contentType, "/")
-	if len(parts) != 2 {
-		return ""
-	}
-	return strings.TrimSpace(parts[1])
-}
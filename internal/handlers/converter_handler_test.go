@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// fakeOgg is returned by fakeAudioConverter: an Ogg page header is enough
+// for content sniffing
+var fakeOgg = append([]byte("OggS\x00\x02"), make([]byte, 32)...)
+
+// fakeJPEG is returned by fakeImageConverter
+var fakeJPEG = []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00, 0xff, 0xd9}
+
+// fakeAudioConverter answers conversions with fakeOgg, or err when set, and
+// records the requests it was given. With wait set, conversions run until
+// their context ends.
+type fakeAudioConverter struct {
+	err      error
+	wait     bool
+	mu       sync.Mutex
+	requests []*services.AudioRequest
+}
+
+func (f *fakeAudioConverter) Convert(ctx context.Context, req *services.AudioRequest) (*services.AudioResponse, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	if f.wait {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	response := &services.AudioResponse{
+		MimeType: "audio/ogg;codecs=opus",
+		Duration: 3,
+		Size:     len(fakeOgg),
+	}
+	if req.RawOutput {
+		response.Output = fakeOgg
+	} else {
+		response.Data = "data:audio/ogg;codecs=opus;base64,T2dnUw=="
+	}
+	return response, nil
+}
+
+func (f *fakeAudioConverter) ConvertBatch(ctx context.Context, requests []*services.AudioRequest, _ services.BatchOptions) ([]*services.AudioResponse, error) {
+	responses := make([]*services.AudioResponse, len(requests))
+	for i, req := range requests {
+		response, err := f.Convert(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		responses[i] = response
+	}
+	return responses, nil
+}
+
+func (f *fakeAudioConverter) GetStats() services.AudioConverterStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return services.AudioConverterStats{TotalConversions: int64(len(f.requests))}
+}
+
+// fakeImageConverter answers conversions with fakeJPEG, or err when set;
+// with wait set, conversions run until their context ends
+type fakeImageConverter struct {
+	err  error
+	wait bool
+}
+
+func (f *fakeImageConverter) Convert(ctx context.Context, req *services.ImageRequest) (*services.ImageResponse, error) {
+	if f.wait {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	response := &services.ImageResponse{
+		MimeType: "image/jpeg",
+		Width:    640,
+		Height:   480,
+		Size:     len(fakeJPEG),
+	}
+	if req.RawOutput {
+		response.Output = fakeJPEG
+	} else {
+		response.Data = "data:image/jpeg;base64,/9j/4AAQ"
+	}
+	return response, nil
+}
+
+func (f *fakeImageConverter) ConvertBatch(ctx context.Context, requests []*services.ImageRequest, _ services.BatchOptions) ([]*services.ImageResponse, error) {
+	responses := make([]*services.ImageResponse, len(requests))
+	for i, req := range requests {
+		response, err := f.Convert(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		responses[i] = response
+	}
+	return responses, nil
+}
+
+func (f *fakeImageConverter) ConvertSticker(context.Context, *services.StickerRequest) (*services.StickerResponse, error) {
+	return nil, errors.New("not implemented by the fake")
+}
+
+func (f *fakeImageConverter) ConvertStickerPack(context.Context, *services.StickerPackRequest) (*services.StickerPackResponse, error) {
+	return nil, errors.New("not implemented by the fake")
+}
+
+func (f *fakeImageConverter) GetStats() services.ImageConverterStats {
+	return services.ImageConverterStats{}
+}
+
+// newTestConverterApp serves the conversion routes of a ConverterHandler
+// built on the given converters
+func newTestConverterApp(audio services.AudioConverterIface, image services.ImageConverterIface, timeouts ...RouteTimeouts) *fiber.App {
+	handler := NewConverterHandler(audio, image, nil, time.Minute, false)
+	for _, routeTimeouts := range timeouts {
+		handler.SetRouteTimeouts(routeTimeouts)
+	}
+
+	app := fiber.New()
+	app.Post("/convert/audio", handler.ConvertAudio)
+	app.Post("/convert/image", handler.ConvertImage)
+	app.Post("/convert/batch/audio", handler.ConvertBatchAudio)
+	app.Post("/convert/batch/image", handler.ConvertBatchImage)
+	return app
+}
+
+// doJSON sends body to app and returns the response status, headers and body
+func doJSON(t *testing.T, app *fiber.App, method, target, body string, headers ...string) (*http.Response, []byte) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s %s: %v", method, target, err)
+	}
+	return resp, data
+}
+
+// decodeError reads an error response body
+func decodeError(t *testing.T, body []byte) models.ErrorResponse {
+	t.Helper()
+
+	var response models.ErrorResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("decode error response %q: %v", body, err)
+	}
+	return response
+}
+
+func TestConvertAudioJSON(t *testing.T) {
+	audio := &fakeAudioConverter{}
+	app := newTestConverterApp(audio, &fakeImageConverter{})
+
+	resp, body := doJSON(t, app, fiber.MethodPost, "/convert/audio", `{"data":"UklGRg==","normalize":true}`)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+
+	var response services.AudioResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if !strings.HasPrefix(response.Data, "data:audio/ogg") || response.Duration != 3 {
+		t.Errorf("response = %+v", response)
+	}
+	if got := resp.Header.Get("X-Output-Size"); got != fmt.Sprint(len(fakeOgg)) {
+		t.Errorf("X-Output-Size = %q", got)
+	}
+
+	if len(audio.requests) != 1 {
+		t.Fatalf("converter called %d times", len(audio.requests))
+	}
+	if req := audio.requests[0]; req.Data != "UklGRg==" || !req.Normalize || req.RawOutput {
+		t.Errorf("converter got %+v", req)
+	}
+}
+
+func TestConvertAudioBinary(t *testing.T) {
+	app := newTestConverterApp(&fakeAudioConverter{}, &fakeImageConverter{})
+
+	resp, body := doJSON(t, app, fiber.MethodPost, "/convert/audio?format=binary", `{"data":"UklGRg=="}`)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	if !bytes.Equal(body, fakeOgg) {
+		t.Errorf("body = %q, want the converted bytes", body)
+	}
+	if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, "audio/ogg") {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func TestConvertAudioErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"missing data", `{}`, nil, fiber.StatusBadRequest, ""},
+		{"invalid body", `{"data":`, nil, fiber.StatusBadRequest, ""},
+		{"too long", `{"data":"UklGRg=="}`, fmt.Errorf("%w: 700s", services.ErrDurationLimitExceeded), fiber.StatusUnprocessableEntity, "duration_limit_exceeded"},
+		{"image input", `{"data":"UklGRg=="}`, fmt.Errorf("%w: image/jpeg", services.ErrUnsupportedInput), fiber.StatusUnsupportedMediaType, "unsupported_input"},
+		{"unknown preset", `{"data":"UklGRg==","preset":"nope"}`, fmt.Errorf("%w: nope", services.ErrUnknownPreset), fiber.StatusBadRequest, "unknown_preset"},
+		{"target size", `{"data":"UklGRg=="}`, services.ErrTargetSizeUnreachable, fiber.StatusUnprocessableEntity, "target_size_unreachable"},
+		{"ffmpeg failure", `{"data":"UklGRg=="}`, errors.New("ffmpeg exited with status 1"), fiber.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestConverterApp(&fakeAudioConverter{err: tt.err}, &fakeImageConverter{})
+
+			resp, body := doJSON(t, app, fiber.MethodPost, "/convert/audio", tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", resp.StatusCode, tt.wantStatus, body)
+			}
+			if got := decodeError(t, body); got.Code != tt.wantCode || got.Error == "" {
+				t.Errorf("error = %+v, want code %q", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestConvertImage(t *testing.T) {
+	app := newTestConverterApp(&fakeAudioConverter{}, &fakeImageConverter{})
+
+	resp, body := doJSON(t, app, fiber.MethodPost, "/convert/image", `{"data":"/9j/4AAQ"}`)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	if got := resp.Header.Get("X-Output-Dimensions"); got != "640x480" {
+		t.Errorf("X-Output-Dimensions = %q", got)
+	}
+
+	var response services.ImageResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.MimeType != "image/jpeg" || response.Width != 640 {
+		t.Errorf("response = %+v", response)
+	}
+}
+
+func TestConvertImageUnsupportedInput(t *testing.T) {
+	app := newTestConverterApp(&fakeAudioConverter{}, &fakeImageConverter{err: fmt.Errorf("%w: audio/ogg", services.ErrUnsupportedInput)})
+
+	resp, body := doJSON(t, app, fiber.MethodPost, "/convert/image", `{"data":"T2dnUw=="}`)
+	if resp.StatusCode != fiber.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	if got := decodeError(t, body); got.Code != "unsupported_input" {
+		t.Errorf("code = %q", got.Code)
+	}
+}
+
+func TestConvertBatchImage(t *testing.T) {
+	app := newTestConverterApp(&fakeAudioConverter{}, &fakeImageConverter{})
+
+	resp, body := doJSON(t, app, fiber.MethodPost, "/convert/batch/image", `[{"data":"/9j/4AAQ"},{"data":"/9j/4AAQ"}]`)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	if !bytes.Contains(body, []byte("data:image/jpeg")) {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestConvertBatchLimits(t *testing.T) {
+	app := newTestConverterApp(&fakeAudioConverter{}, &fakeImageConverter{})
+	items := strings.TrimSuffix(strings.Repeat(`{"data":"UklGRg=="},`, defaultBatchLimits.MaxSize+1), ",")
+
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"empty batch", "/convert/batch/audio", `[]`, fiber.StatusBadRequest, ""},
+		{"too many items", "/convert/batch/audio", "[" + items + "]", fiber.StatusBadRequest, "batch_too_large"},
+		{"invalid archive", "/convert/batch/audio?archive=zip", `[{"data":"UklGRg=="}]`, fiber.StatusBadRequest, "invalid_batch_options"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := doJSON(t, app, fiber.MethodPost, tt.target, tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", resp.StatusCode, tt.wantStatus, body)
+			}
+			if got := decodeError(t, body); got.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", got.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRuntimeStats(t *testing.T) {
+	runtime.GC()
+	stats := runtimeStats()
+
+	if stats.Goroutines <= 0 || stats.HeapObjects == 0 || stats.NumGC == 0 {
+		t.Errorf("runtime stats not read: %+v", stats)
+	}
+	if stats.HeapAllocBytes == 0 || stats.HeapAllocBytes > stats.SysBytes {
+		t.Errorf("heap %d bytes of %d mapped", stats.HeapAllocBytes, stats.SysBytes)
+	}
+}
+
+func TestConvertBatchTimeout(t *testing.T) {
+	app := newTestConverterApp(&fakeAudioConverter{wait: true}, &fakeImageConverter{wait: true},
+		RouteTimeouts{Batch: 50 * time.Millisecond})
+
+	for _, target := range []string{"/convert/batch/audio", "/convert/batch/image"} {
+		t.Run(target, func(t *testing.T) {
+			resp, body := doJSON(t, app, fiber.MethodPost, target, `[{"data":"UklGRg=="},{"data":"UklGRg=="}]`)
+			if resp.StatusCode != fiber.StatusRequestTimeout {
+				t.Fatalf("status = %d, want %d (body %s)", resp.StatusCode, fiber.StatusRequestTimeout, body)
+			}
+			if got := decodeError(t, body); got.Error != "Request timeout" {
+				t.Errorf("error = %q, want Request timeout", got.Error)
+			}
+		})
+	}
+}
+
+// TestConversionErrorsAgree checks single and batch conversions answer a
+// converter error with the same status and code
+func TestConversionErrorsAgree(t *testing.T) {
+	for _, known := range conversionErrors {
+		t.Run(known.code, func(t *testing.T) {
+			err := fmt.Errorf("converting: %w", known.err)
+			app := newTestConverterApp(&fakeAudioConverter{err: err}, &fakeImageConverter{err: err})
+
+			for _, target := range []string{"/convert/audio", "/convert/image", "/convert/batch/audio", "/convert/batch/image"} {
+				body := `{"data":"UklGRg=="}`
+				if strings.Contains(target, "batch") {
+					body = "[" + body + "]"
+				}
+				resp, respBody := doJSON(t, app, fiber.MethodPost, target, body)
+				if resp.StatusCode != known.status {
+					t.Errorf("%s: status = %d, want %d", target, resp.StatusCode, known.status)
+				}
+				if got := decodeError(t, respBody); got.Code != known.code || got.Error != known.message {
+					t.Errorf("%s: %q (%s), want %q (%s)", target, got.Error, got.Code, known.message, known.code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// SetInspector enables the media inspection endpoint
+func (h *ConverterHandler) SetInspector(inspector *services.MediaInspector) {
+	h.inspector = inspector
+}
+
+// Inspect godoc
+// @Summary Inspect media without converting it
+// @Description Runs ffprobe on a base64, URL or multipart input and returns its container, codecs, duration, resolution, bitrate, channels and rotation. Useful to validate inputs before converting them; inputs are limited to MAX_VIDEO_SIZE.
+// @Tags Conversion
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param request body services.InspectRequest true "Media inspection request"
+// @Param file formData file false "Media file when using multipart"
+// @Param X-Debug-Trace header bool false "Return executed ffprobe commands (requires ENABLE_COMMAND_TRACE)"
+// @Param debug_timings query bool false "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)"
+// @Success 200 {object} services.MediaInfo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "ffprobe can't read the input (code unrecognized_media)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /inspect [post]
+func (h *ConverterHandler) Inspect(c fiber.Ctx) error {
+	if h.inspector == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error: "Media inspection is not available",
+		})
+	}
+
+	req, err := parseInspectRequest(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+
+	req.Data = sanitizeBase64Data(req.Data)
+	if req.Upload == nil && strings.TrimSpace(req.Data) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Missing 'data' field",
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	ctx, trace := h.startTrace(c, ctx)
+	ctx, timer := startTimings(c, ctx)
+
+	start := time.Now()
+	info, err := h.inspector.Inspect(ctx, req)
+	records := h.finishTrace(c, trace)
+	timings := finishTimings(c, timer)
+	if err != nil {
+		return inspectionError(ctx, c, err, records)
+	}
+	info.Trace = records
+	info.Timings = timings
+
+	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
+
+	return c.JSON(info)
+}
+
+// Fingerprint godoc
+// @Summary Fingerprint media to detect resent copies
+// @Description Returns a perceptual hash of a base64, URL or multipart input: a 64-bit pHash for images, or a 32-bit chromaprint SimHash of up to two minutes of audio with the raw sub-fingerprints. Copies of the same media that were recompressed, resized or converted differ by few bits, so compare stored hashes of the same algorithm by Hamming distance (about 10 bits or fewer for phash, 6 for chromaprint). sha256 matches byte-identical files. Inputs are limited to MAX_VIDEO_SIZE.
+// @Tags Conversion
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param request body services.InspectRequest true "Media fingerprint request"
+// @Param file formData file false "Media file when using multipart"
+// @Param X-Debug-Trace header bool false "Return executed commands (requires ENABLE_COMMAND_TRACE)"
+// @Success 200 {object} services.Fingerprint
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "Unrecognized input (code unrecognized_media), or video and other media that can't be fingerprinted (code fingerprint_unsupported)"
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse "fpcalc (chromaprint) is not installed (code fingerprint_unavailable)"
+// @Router /inspect/fingerprint [post]
+func (h *ConverterHandler) Fingerprint(c fiber.Ctx) error {
+	if h.inspector == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error: "Media inspection is not available",
+		})
+	}
+
+	req, err := parseInspectRequest(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+
+	req.Data = sanitizeBase64Data(req.Data)
+	if req.Upload == nil && strings.TrimSpace(req.Data) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Missing 'data' field",
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	ctx, trace := h.startTrace(c, ctx)
+
+	start := time.Now()
+	fingerprint, err := h.inspector.Fingerprint(ctx, req)
+	records := h.finishTrace(c, trace)
+	if err != nil {
+		return inspectionError(ctx, c, err, records)
+	}
+
+	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
+
+	return c.JSON(fingerprint)
+}
+
+func parseInspectRequest(c fiber.Ctx) (*services.InspectRequest, error) {
+	contentType := strings.ToLower(c.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		fileHeader, err := formUpload(c)
+		if err != nil {
+			return nil, err
+		}
+		return &services.InspectRequest{Upload: fileHeader}, nil
+	}
+
+	var req services.InspectRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return nil, newRequestError(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	return &req, nil
+}
+
+// inspectionError maps media inspector failures to responses
+func inspectionError(ctx context.Context, c fiber.Ctx, err error, records []services.CommandRecord) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{
+			Error:   "Request timeout",
+			Details: "Inspection took too long",
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrUnrecognizedMedia) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Unrecognized media",
+			Code:    "unrecognized_media",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrFingerprintUnsupported) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Media can't be fingerprinted",
+			Code:    "fingerprint_unsupported",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrFingerprintUnavailable) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error:   "Fingerprinting is not available",
+			Code:    "fingerprint_unavailable",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if isDownloadError(err) {
+		return downloadError(c, err, records)
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error:   "Inspection failed",
+		Details: err.Error(),
+		Trace:   records,
+	})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// MaintenanceHandler lets operators disable endpoints during an incident
+// without redeploying
+type MaintenanceHandler struct {
+	maintenance *services.Maintenance
+	adminToken  string
+}
+
+// NewMaintenanceHandler creates a maintenance handler guarded by adminToken (ADMIN_TOKEN)
+func NewMaintenanceHandler(maintenance *services.Maintenance, adminToken string) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenance: maintenance, adminToken: adminToken}
+}
+
+// ListMaintenance godoc
+// @Summary List endpoints under maintenance
+// @Tags Admin
+// @Produce json
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Success 200 {object} models.MaintenanceResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /admin/maintenance [get]
+func (h *MaintenanceHandler) ListMaintenance(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	windows := h.maintenance.List()
+	return c.JSON(models.MaintenanceResponse{Endpoints: windows, Count: len(windows)})
+}
+
+// EnableMaintenance godoc
+// @Summary Put an endpoint under maintenance
+// @Description Answers requests to the endpoint, and every path below it, with 503 and code maintenance until the window is deleted or its duration passes. /convert/video pauses /convert/video and /convert/video/s3, with or without a version prefix; / pauses the whole API. /health and the maintenance endpoints stay up.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param endpoint path string true "Endpoint path, e.g. convert/video"
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Param request body models.MaintenanceRequest false "Message and duration"
+// @Success 200 {object} services.MaintenanceWindow
+// @Failure 400 {object} models.ErrorResponse "Invalid endpoint or duration (code invalid_endpoint or invalid_duration)"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/maintenance/{endpoint} [put]
+func (h *MaintenanceHandler) EnableMaintenance(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	var req models.MaintenanceRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid request body",
+				Details: err.Error(),
+			})
+		}
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		parsed, err := parseSeconds(req.Duration)
+		if err != nil || parsed <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid duration",
+				Code:    "invalid_duration",
+				Details: "duration must be a positive duration such as 30m, or seconds",
+			})
+		}
+		duration = parsed
+	}
+
+	window, err := h.maintenance.Enable(maintenanceEndpoint(c), req.Message, duration)
+	if err != nil {
+		return maintenanceError(c, err)
+	}
+
+	return c.JSON(window)
+}
+
+// DisableMaintenance godoc
+// @Summary Lift maintenance from an endpoint
+// @Tags Admin
+// @Produce json
+// @Param endpoint path string true "Endpoint path, as put under maintenance"
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Success 200 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid endpoint (code invalid_endpoint)"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Endpoint not under maintenance (code maintenance_not_found)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/maintenance/{endpoint} [delete]
+func (h *MaintenanceHandler) DisableMaintenance(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	if err := h.maintenance.Disable(maintenanceEndpoint(c)); err != nil {
+		return maintenanceError(c, err)
+	}
+
+	return c.JSON(models.MessageResponse{
+		Success: true,
+		Message: "Maintenance lifted",
+	})
+}
+
+// maintenanceEndpoint returns the endpoint in the path; /admin/maintenance/
+// stands for the whole API
+func maintenanceEndpoint(c fiber.Ctx) string {
+	return "/" + c.Params("*")
+}
+
+func (h *MaintenanceHandler) authorized(c fiber.Ctx) bool {
+	return subtle.ConstantTimeCompare([]byte(c.Get(adminTokenHeader)), []byte(h.adminToken)) == 1
+}
+
+func maintenanceError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidEndpoint):
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid endpoint",
+			Code:    "invalid_endpoint",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrMaintenanceNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   "Endpoint not under maintenance",
+			Code:    "maintenance_not_found",
+			Details: err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error:   "Failed to update maintenance",
+		Details: err.Error(),
+	})
+}
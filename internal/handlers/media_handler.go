@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/services"
+)
+
+// maxMediaDimension bounds the w/h query parameters of GET /media
+const maxMediaDimension = 8192
+
+// MediaHandler serves stored originals converted on read, like a resizing CDN
+type MediaHandler struct {
+	s3Service      *services.S3Service
+	audioConverter services.AudioConverterIface
+	imageConverter services.ImageConverterIface
+	cache          *services.MediaCache
+	requestTimeout time.Duration
+	maxSourceSize  int64
+	maxAge         time.Duration
+}
+
+// NewMediaHandler creates a convert-on-read handler. cache may be nil.
+func NewMediaHandler(
+	s3Service *services.S3Service,
+	audioConverter services.AudioConverterIface,
+	imageConverter services.ImageConverterIface,
+	cache *services.MediaCache,
+	requestTimeout time.Duration,
+	maxSourceSize int64,
+	maxAge time.Duration,
+) *MediaHandler {
+	return &MediaHandler{
+		s3Service:      s3Service,
+		audioConverter: audioConverter,
+		imageConverter: imageConverter,
+		cache:          cache,
+		requestTimeout: requestTimeout,
+		maxSourceSize:  maxSourceSize,
+		maxAge:         maxAge,
+	}
+}
+
+// mediaOptions are the rendition parameters of GET /media
+type mediaOptions struct {
+	format  string // "opus" or "jpeg"
+	width   int
+	height  int
+	quality int
+}
+
+// cacheKey identifies a rendition of a specific object version
+func (o mediaOptions) cacheKey(key, sourceETag string) string {
+	return fmt.Sprintf("%s|%s|%s|%dx%d|q%d", key, sourceETag, o.format, o.width, o.height, o.quality)
+}
+
+// GetMedia godoc
+// @Summary Convert a stored object on read
+// @Description Fetches an original from S3, converts it to WhatsApp-ready Opus or JPEG (optionally resized) and returns the bytes. Renditions are cached in memory and carry an ETag for conditional requests.
+// @Tags Media
+// @Produce audio/ogg
+// @Produce image/jpeg
+// @Param key path string true "Object key (may contain slashes)"
+// @Param format query string false "opus or jpeg (default: from the object's content type)"
+// @Param w query int false "Maximum width in pixels (images)"
+// @Param h query int false "Maximum height in pixels (images)"
+// @Param q query int false "JPEG quality 1-100 (default 95)"
+// @Success 200 {file} binary
+// @Success 304 "Not modified"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse
+// @Router /media/{key} [get]
+func (h *MediaHandler) GetMedia(c fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil || key == "" || strings.Contains(key, "..") {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Invalid object key",
+		})
+	}
+
+	opts, err := parseMediaOptions(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	info, err := h.s3Service.GetObjectInfo(ctx, key)
+	if err != nil {
+		return h.storageError(c, err)
+	}
+
+	if opts.format == "" {
+		switch {
+		case strings.HasPrefix(info.ContentType, "audio/"), strings.HasPrefix(info.ContentType, "video/"):
+			opts.format = "opus"
+		case strings.HasPrefix(info.ContentType, "image/"):
+			opts.format = "jpeg"
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Format required",
+				Code:    "format_required",
+				Details: fmt.Sprintf("Can't infer an output format from content type %q; pass format=opus or format=jpeg", info.ContentType),
+			})
+		}
+	}
+
+	if opts.format == "opus" && (opts.width > 0 || opts.height > 0 || opts.quality > 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid parameters",
+			Details: "w, h and q only apply to JPEG output",
+		})
+	}
+
+	cacheKey := opts.cacheKey(key, info.ETag)
+	sum := sha256.Sum256([]byte(cacheKey))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	if data, mimeType, ok := h.cache.Get(cacheKey); ok {
+		c.Set("X-Cache", "HIT")
+		c.Set(fiber.HeaderContentType, mimeType)
+		return c.Send(data)
+	}
+	c.Set("X-Cache", "MISS")
+
+	source, err := h.s3Service.GetObject(ctx, key, h.maxSourceSize)
+	if err != nil {
+		return h.storageError(c, err)
+	}
+
+	var output []byte
+	var mimeType string
+	if opts.format == "opus" {
+		response, convErr := h.audioConverter.Convert(ctx, &services.AudioRequest{
+			Input:     source,
+			RawOutput: true,
+		})
+		if convErr != nil {
+			return h.conversionError(ctx, c, convErr)
+		}
+		output, mimeType = response.Output, response.MimeType
+	} else {
+		response, convErr := h.imageConverter.Convert(ctx, &services.ImageRequest{
+			Input:     source,
+			MaxWidth:  opts.width,
+			MaxHeight: opts.height,
+			Quality:   opts.quality,
+			Resize:    opts.width > 0 || opts.height > 0,
+			RawOutput: true,
+		})
+		if convErr != nil {
+			return h.conversionError(ctx, c, convErr)
+		}
+		output, mimeType = response.Output, response.MimeType
+	}
+
+	h.cache.Put(cacheKey, output, mimeType)
+
+	c.Set(fiber.HeaderContentType, mimeType)
+	return c.Send(output)
+}
+
+// CacheStats reports rendition cache usage
+func (h *MediaHandler) CacheStats() services.MediaCacheStats {
+	return h.cache.Stats()
+}
+
+// parseMediaOptions validates the query parameters of GET /media
+func parseMediaOptions(c fiber.Ctx) (mediaOptions, error) {
+	var opts mediaOptions
+
+	switch format := strings.ToLower(c.Query("format")); format {
+	case "":
+	case "opus", "ogg":
+		opts.format = "opus"
+	case "jpeg", "jpg":
+		opts.format = "jpeg"
+	default:
+		return opts, newRequestError(fiber.StatusBadRequest, "Unsupported format", "format must be opus or jpeg")
+	}
+
+	for _, param := range []struct {
+		name  string
+		max   int
+		value *int
+	}{
+		{"w", maxMediaDimension, &opts.width},
+		{"h", maxMediaDimension, &opts.height},
+		{"q", 100, &opts.quality},
+	} {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > param.max {
+			return opts, newRequestError(fiber.StatusBadRequest, "Invalid '"+param.name+"' parameter",
+				fmt.Sprintf("%s must be an integer between 1 and %d", param.name, param.max))
+		}
+		*param.value = value
+	}
+
+	return opts, nil
+}
+
+// storageError maps S3 failures to HTTP responses
+func (h *MediaHandler) storageError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, providers.ErrObjectNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Object not found",
+		})
+	case errors.Is(err, providers.ErrFileTooLarge):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(models.ErrorResponse{
+			Error:   "Object too large",
+			Details: fmt.Sprintf("Originals larger than %d bytes can't be converted on read", h.maxSourceSize),
+		})
+	default:
+		return c.Status(fiber.StatusBadGateway).JSON(models.ErrorResponse{
+			Error:   "Failed to fetch object",
+			Details: err.Error(),
+		})
+	}
+}
+
+// conversionError maps conversion failures like the /convert endpoints do
+func (h *MediaHandler) conversionError(ctx context.Context, c fiber.Ctx, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{
+			Error:   "Request timeout",
+			Details: "Conversion took too long",
+		})
+	}
+
+	switch {
+	case errors.Is(err, services.ErrDurationLimitExceeded):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Audio too long",
+			Code:    "duration_limit_exceeded",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrPixelLimitExceeded):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Image dimensions too large",
+			Code:    "pixel_limit_exceeded",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrUnsupportedInput):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(models.ErrorResponse{
+			Error:   "Unsupported input",
+			Code:    "unsupported_input",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrQualityBelowThreshold):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Output quality too low",
+			Code:    "quality_below_threshold",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrTargetSizeUnreachable):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Output cannot fit the target size",
+			Code:    "target_size_unreachable",
+			Details: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error:    "Conversion failed",
+		Details:  err.Error(),
+		SourceID: services.RetainedSourceID(err),
+	})
+}
@@ -1,58 +1,214 @@
 package handlers
 
 import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v3"
+	"whats-convert-api/internal/buildinfo"
+	"whats-convert-api/internal/features"
 	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
 )
 
+// capabilityTools lists the external binaries reported by GET /capabilities.
+var capabilityTools = []string{"ffmpeg", "ffprobe", "vips", "jpegoptim", "bwrap"}
+
+// versionTools maps the encoders reported by GET /version to the flag that
+// prints their version.
+var versionTools = map[string]string{"ffmpeg": "-version", "vips": "--version"}
+
+// toolVersionPattern finds the version in "ffmpeg version 6.1.1 Copyright..."
+// or "vips-8.15.1"; distribution builds may append suffixes like "-0ubuntu1".
+var toolVersionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?\S*`)
+
 // MetaHandler exposes informational endpoints about the API surface.
 type MetaHandler struct {
-	version   string
-	s3Enabled bool
+	version     string
+	apiVersions []string
+	s3Enabled   bool
+	mockMode    bool
+	tools       map[string]bool
+	versions    map[string]string
+	features    *features.Set
+	presets     *services.Presets
+	tiers       *services.Tiers
+	metadata    APIMetadata
 }
 
 // NewMetaHandler constructs a metadata handler.
-func NewMetaHandler(version string, s3Enabled bool) *MetaHandler {
+// apiVersions lists the versioned route prefixes (e.g. "/v1") served alongside legacy paths.
+func NewMetaHandler(version string, apiVersions []string, s3Enabled, mockMode bool, flags *features.Set) *MetaHandler {
 	if version == "" {
 		version = "1.0.0"
 	}
 
+	tools := make(map[string]bool, len(capabilityTools))
+	for _, tool := range capabilityTools {
+		_, err := exec.LookPath(tool)
+		tools[tool] = err == nil
+	}
+
+	versions := make(map[string]string, len(versionTools))
+	for tool, flag := range versionTools {
+		if tools[tool] {
+			versions[tool] = toolVersion(tool, flag)
+		}
+	}
+
 	return &MetaHandler{
-		version:   version,
-		s3Enabled: s3Enabled,
+		version:     version,
+		apiVersions: apiVersions,
+		s3Enabled:   s3Enabled,
+		mockMode:    mockMode,
+		tools:       tools,
+		versions:    versions,
+		features:    flags,
+		metadata:    APIMetadata{Title: "WhatsApp Media Converter API", Language: "en"}.normalized(),
 	}
 }
 
+// SetMetadata replaces the title, descriptions and contact reported by GET /api
+func (h *MetaHandler) SetMetadata(metadata APIMetadata) {
+	h.metadata = metadata.normalized()
+}
+
 // APIInfo godoc
 // @Summary API metadata
-// @Description Provides API version and available endpoint catalogue.
+// @Description Provides API version, branding and available endpoint catalogue. Every endpoint is also served under its versioned prefix (e.g. /v1/convert/audio).
 // @Tags General
 // @Produce json
+// @Param Accept-Language header string false "Preferred description language, e.g. pt-BR (falls back to API_LANGUAGE)"
 // @Success 200 {object} models.APIInfoResponse
 // @Router /api [get]
 func (h *MetaHandler) APIInfo(c fiber.Ctx) error {
 	endpoints := map[string]string{
-		"audio":       "/convert/audio",
-		"image":       "/convert/image",
-		"batch_audio": "/convert/batch/audio",
-		"batch_image": "/convert/batch/image",
-		"health":      "/health",
-		"stats":       "/stats",
+		"audio":             "/convert/audio",
+		"image":             "/convert/image",
+		"sticker":           "/convert/sticker",
+		"batch_audio":       "/convert/batch/audio",
+		"batch_image":       "/convert/batch/image",
+		"batch_audio_async": "/convert/batch/audio/async",
+		"batch_image_async": "/convert/batch/image/async",
+		"batch_jobs":        "/convert/batch/jobs/{id}",
+		"batch_job_report":  "/convert/batch/jobs/{id}/report",
+		"batch_job_archive": "/convert/batch/jobs/{id}/archive",
+		"sticker_pack":      "/convert/sticker-pack",
+		"inspect":           "/inspect",
+		"fingerprint":       "/inspect/fingerprint",
+		"health":            "/health",
+		"stats":             "/stats",
+		"cache_stats":       "/cache/stats",
+		"capabilities":      "/capabilities",
+		"version":           "/version",
+		"samples":           "/samples/{type}",
+		"presets":           "/presets",
+		"workspaces":        "/workspaces",
+	}
+
+	if h.features.Enabled(features.Video, c.Get(features.APIKeyHeader)) {
+		endpoints["video"] = "/convert/video"
 	}
 
 	if h.s3Enabled {
 		endpoints["s3_upload_form"] = "/upload/s3"
 		endpoints["s3_upload_base64"] = "/upload/s3/base64"
+		endpoints["s3_upload_url"] = "/upload/s3/url"
 		endpoints["s3_status"] = "/upload/s3/status/{id}"
+		endpoints["s3_wait"] = "/upload/s3/status/{id}/wait"
+		endpoints["s3_progress"] = "/upload/s3/progress/{id}"
+		endpoints["s3_events"] = "/upload/s3/status/{id}/events"
 		endpoints["s3_list"] = "/upload/s3/list"
 		endpoints["s3_object"] = "/upload/s3/object/{key}"
+		endpoints["s3_objects"] = "/upload/s3/objects"
+		endpoints["s3_share"] = "/upload/s3/object/{key}/share"
+		endpoints["s3_expiration"] = "/upload/s3/object/{key}/expiration"
+		endpoints["s3_restore"] = "/upload/s3/object/{key}/restore"
 		endpoints["s3_health"] = "/upload/s3/health"
 		endpoints["s3_stats"] = "/upload/s3/stats"
+		endpoints["media"] = "/media/{key}"
+		endpoints["audio_to_s3"] = "/convert/audio/s3"
+		if h.features.Enabled(features.Video, c.Get(features.APIKeyHeader)) {
+			endpoints["video_to_s3"] = "/convert/video/s3"
+		}
 	}
 
+	language, description := h.metadata.describe(c.Get(fiber.HeaderAcceptLanguage))
+	c.Vary(fiber.HeaderAcceptLanguage)
+	c.Set(fiber.HeaderContentLanguage, language)
+
 	return c.JSON(models.APIInfoResponse{
-		Name:      "WhatsApp Media Converter API",
-		Version:   h.version,
-		Endpoints: endpoints,
+		Name:        h.metadata.Title,
+		Description: description,
+		Language:    language,
+		Contact:     h.metadata.contact(),
+		Version:     h.version,
+		APIVersions: h.apiVersions,
+		Endpoints:   endpoints,
+	})
+}
+
+// Capabilities godoc
+// @Summary Runtime capabilities
+// @Description Reports which external tools are installed, how they are sandboxed, which feature flags are on for the caller and, when API key tiers are configured, the caller's tier and its limits.
+// @Tags General
+// @Produce json
+// @Param X-API-Key header string false "Evaluate per-key feature rollouts and the tier of this API key"
+// @Success 200 {object} models.CapabilitiesResponse
+// @Router /capabilities [get]
+func (h *MetaHandler) Capabilities(c fiber.Ctx) error {
+	apiKey := c.Get(features.APIKeyHeader)
+	return c.JSON(models.CapabilitiesResponse{
+		Tools:     h.tools,
+		Sandbox:   services.CurrentSandbox(),
+		MockMode:  h.mockMode,
+		S3Enabled: h.s3Enabled,
+		Features:  h.features.Evaluate(apiKey),
+		Tier:      h.tiers.Tier(apiKey),
+	})
+}
+
+// SetTiers sets the tiers whose limits GET /capabilities reports to callers
+func (h *MetaHandler) SetTiers(tiers *services.Tiers) {
+	h.tiers = tiers
+}
+
+// Version godoc
+// @Summary Build information
+// @Description Identifies exactly what is deployed: release version, git commit, build date, Go version, encoder versions and the feature flags on for the caller.
+// @Tags General
+// @Produce json
+// @Param X-API-Key header string false "Evaluate per-key feature rollouts for this API key"
+// @Success 200 {object} models.VersionResponse
+// @Router /version [get]
+func (h *MetaHandler) Version(c fiber.Ctx) error {
+	info := buildinfo.Get()
+	info.Version = h.version
+
+	return c.JSON(models.VersionResponse{
+		Info:         info,
+		ToolVersions: h.versions,
+		Features:     h.features.Evaluate(c.Get(features.APIKeyHeader)),
 	})
 }
+
+// toolVersion runs tool with flag and extracts the version from the first
+// line, or returns "unknown"
+func toolVersion(tool, flag string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, tool, flag).Output()
+	if err != nil {
+		return "unknown"
+	}
+
+	line, _, _ := strings.Cut(string(output), "\n")
+	if version := toolVersionPattern.FindString(line); version != "" {
+		return version
+	}
+	return "unknown"
+}
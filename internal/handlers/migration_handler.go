@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/services"
+)
+
+// MigrationHandler lets operators move stored objects to another provider
+type MigrationHandler struct {
+	migrations *services.MigrationManager
+	adminToken string
+}
+
+// NewMigrationHandler creates a migration handler guarded by adminToken (ADMIN_TOKEN)
+func NewMigrationHandler(migrations *services.MigrationManager, adminToken string) *MigrationHandler {
+	return &MigrationHandler{migrations: migrations, adminToken: adminToken}
+}
+
+// StartMigration godoc
+// @Summary Migrate objects to another provider
+// @Description Copies every object under the prefix from the source provider to the destination in the background, keeping keys, content types and metadata, and answers at once with the migration to poll. With transcode, images become WhatsApp JPEGs and audio Opus, stored with a .jpg or .ogg extension. Objects the destination already has (same key and size) are skipped unless overwrite is set. Once every object was handled, the destination is reconciled against the source and the report lists what is missing or differs. One migration runs at a time.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Param request body models.MigrationRequest true "Providers, prefix and throttling"
+// @Success 202 {object} services.Migration
+// @Failure 400 {object} models.ErrorResponse "Unknown provider or invalid option (code invalid_migration)"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Another migration is running (code migration_running)"
+// @Failure 501 {object} models.ErrorResponse "The source can't read objects back"
+// @Failure 502 {object} models.ErrorResponse
+// @Router /admin/migrations [post]
+func (h *MigrationHandler) StartMigration(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	var req models.MigrationRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid request body",
+			Details: err.Error(),
+		})
+	}
+
+	migration, err := h.migrations.Start(services.MigrationOptions{
+		Source:            req.Source,
+		Destination:       req.Destination,
+		Prefix:            req.Prefix,
+		Transcode:         req.Transcode,
+		Overwrite:         req.Overwrite,
+		Concurrency:       req.Concurrency,
+		MaxBytesPerSecond: req.MaxBytesPerSecond,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMigration):
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid migration",
+				Code:    "invalid_migration",
+				Details: err.Error(),
+			})
+		case errors.Is(err, services.ErrMigrationRunning):
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+				Error:   "A migration is already running",
+				Code:    "migration_running",
+				Details: err.Error(),
+			})
+		case errors.Is(err, providers.ErrFeatureNotSupported):
+			return c.Status(fiber.StatusNotImplemented).JSON(models.ErrorResponse{
+				Error:   "The source provider can't read objects back",
+				Details: err.Error(),
+			})
+		default:
+			return c.Status(fiber.StatusBadGateway).JSON(models.ErrorResponse{
+				Error:   "Failed to start migration",
+				Details: err.Error(),
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(migration)
+}
+
+// ListMigrations godoc
+// @Summary List migrations
+// @Description The running migration and the last 20 finished ones, newest first, with the provider names migrations accept.
+// @Tags Admin
+// @Produce json
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Success 200 {object} models.MigrationListResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /admin/migrations [get]
+func (h *MigrationHandler) ListMigrations(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	migrations := h.migrations.List()
+	return c.JSON(models.MigrationListResponse{
+		Migrations: migrations,
+		Providers:  h.migrations.Providers(),
+		Count:      len(migrations),
+	})
+}
+
+// GetMigration godoc
+// @Summary Get a migration's progress
+// @Description Progress counts, the first failures and, once the migration completed, its reconciliation report.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Migration ID"
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Success 200 {object} services.Migration
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/migrations/{id} [get]
+func (h *MigrationHandler) GetMigration(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	migration, err := h.migrations.Get(c.Params("id"))
+	if err != nil {
+		return migrationNotFound(c)
+	}
+	return c.JSON(migration)
+}
+
+// CancelMigration godoc
+// @Summary Cancel a migration
+// @Description Stops the migration once the objects being copied are done with. Objects already copied stay in the destination; run the migration again to resume, as they are skipped.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Migration ID"
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Success 200 {object} services.Migration
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "The migration already finished (code migration_finished)"
+// @Router /admin/migrations/{id} [delete]
+func (h *MigrationHandler) CancelMigration(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	migration, err := h.migrations.Cancel(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrMigrationFinished) {
+			return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+				Error: "Migration already finished",
+				Code:  "migration_finished",
+			})
+		}
+		return migrationNotFound(c)
+	}
+	return c.JSON(migration)
+}
+
+func (h *MigrationHandler) authorized(c fiber.Ctx) bool {
+	return subtle.ConstantTimeCompare([]byte(c.Get(adminTokenHeader)), []byte(h.adminToken)) == 1
+}
+
+func migrationNotFound(c fiber.Ctx) error {
+	return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+		Error: "Migration not found",
+	})
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"whats-convert-api/internal/services"
+)
+
+// metadataPart names the JSON part of a multipart conversion response
+const metadataPart = "metadata"
+
+// headerContentSHA256 carries the hex SHA-256 of a streamed response body.
+// Response signing reads it instead of buffering the stream to hash it.
+const headerContentSHA256 = "X-Content-SHA256"
+
+// outputFile is a binary part of a multipart conversion response
+type outputFile struct {
+	field    string
+	filename string // Defaults to "output" plus the MIME type's extension
+	mimeType string
+	data     []byte
+	file     *services.SpooledFile // Streamed from disk instead of data, and closed once sent
+}
+
+// wantsMultipart reports whether the client prefers multipart/form-data over JSON
+func wantsMultipart(c fiber.Ctx) bool {
+	return c.Accepts(fiber.MIMEApplicationJSON, fiber.MIMEMultipartForm) == fiber.MIMEMultipartForm
+}
+
+// wantsBinary reports whether the client asked for the converted bytes as the
+// whole response body, with ?format=binary or an Accept header preferring one
+// of mimeTypes (or application/octet-stream) over JSON and multipart
+func wantsBinary(c fiber.Ctx, mimeTypes ...string) bool {
+	if strings.EqualFold(c.Query("format"), "binary") {
+		return true
+	}
+
+	offers := append([]string{fiber.MIMEApplicationJSON, fiber.MIMEMultipartForm, fiber.MIMEOctetStream}, mimeTypes...)
+	switch c.Accepts(offers...) {
+	case "", fiber.MIMEApplicationJSON, fiber.MIMEMultipartForm:
+		return false
+	}
+	return true
+}
+
+// sendBinary writes a converted file as the response body. Metadata the JSON
+// response would carry is left to X-Output-* headers.
+func sendBinary(c fiber.Ctx, mimeType string, data []byte) error {
+	c.Set(fiber.HeaderContentType, mimeType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename=%q`, "output"+extensionFor(mimeType)))
+	return c.Send(data)
+}
+
+// sendMultipart writes metadata as a JSON part followed by one part per file,
+// so clients receive the converted binaries without base64 inflation. Parts
+// backed by a spill file are streamed from disk rather than copied into the
+// body; such a body is hashed as it's assembled, reading each spill file once
+// before it is streamed, and the hash is sent as X-Content-SHA256 so response
+// signing doesn't pull the stream into memory.
+func sendMultipart(c fiber.Ctx, metadata any, files []outputFile) error {
+	body := &multipartBody{hash: sha256.New()}
+	defer body.closeUnsent()
+	writer := multipart.NewWriter(body)
+
+	metaHeader := textproto.MIMEHeader{}
+	metaHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q`, metadataPart))
+	metaHeader.Set("Content-Type", fiber.MIMEApplicationJSON)
+	part, err := writer.CreatePart(metaHeader)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(part).Encode(metadata); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.file != nil {
+			body.closers = append(body.closers, file.file)
+		}
+	}
+
+	for _, file := range files {
+		filename := file.filename
+		if filename == "" {
+			filename = "output" + extensionFor(file.mimeType)
+		}
+		fileHeader := textproto.MIMEHeader{}
+		fileHeader.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, file.field, filename))
+		fileHeader.Set("Content-Type", file.mimeType)
+		part, err := writer.CreatePart(fileHeader)
+		if err != nil {
+			return err
+		}
+		if file.file != nil {
+			if err := body.appendFile(file.file); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := part.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, writer.FormDataContentType())
+	if len(body.closers) == 0 {
+		return c.Send(body.current.Bytes())
+	}
+	c.Set(headerContentSHA256, hex.EncodeToString(body.hash.Sum(nil)))
+	return c.SendStream(body.reader(), int(body.size))
+}
+
+// multipartBody collects a multipart body as in-memory segments and files,
+// so large parts are streamed from disk after the handler returns
+type multipartBody struct {
+	segments []io.Reader
+	closers  []io.Closer
+	current  bytes.Buffer
+	size     int64
+	hash     hash.Hash // SHA-256 of everything written and appended so far
+	sent     bool
+}
+
+func (b *multipartBody) Write(p []byte) (int, error) {
+	b.size += int64(len(p))
+	b.hash.Write(p)
+	return b.current.Write(p)
+}
+
+// appendFile ends the current segment and adds the file after it, hashing
+// the file and rewinding it for streaming
+func (b *multipartBody) appendFile(file *services.SpooledFile) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := io.Copy(b.hash, file); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	b.segments = append(b.segments, bytes.NewReader(b.current.Bytes()), file)
+	b.current = bytes.Buffer{}
+	b.size += info.Size()
+	return nil
+}
+
+// reader returns the whole body; the response closes it, and the files with
+// it, once sent or abandoned
+func (b *multipartBody) reader() io.ReadCloser {
+	b.sent = true
+	return &streamedBody{
+		Reader:  io.MultiReader(append(b.segments, bytes.NewReader(b.current.Bytes()))...),
+		closers: b.closers,
+	}
+}
+
+// closeUnsent closes the files when the body was never handed to the response
+func (b *multipartBody) closeUnsent() {
+	if b.sent {
+		return
+	}
+	for _, closer := range b.closers {
+		closer.Close()
+	}
+}
+
+type streamedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *streamedBody) Close() error {
+	for _, closer := range b.closers {
+		closer.Close()
+	}
+	return nil
+}
+
+// extensionFor maps an output MIME type to a file extension
+func extensionFor(mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	switch base {
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/wav":
+		return ".wav"
+	case "image/jpeg":
+		return ".jpg"
+	case "video/mp4":
+		return ".mp4"
+	}
+	if extensions, err := mime.ExtensionsByType(base); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ".bin"
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/services"
+)
+
+func TestSendMultipartSpilledHash(t *testing.T) {
+	spilled, err := os.CreateTemp(t.TempDir(), "spill-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := spilled.WriteString(strings.Repeat("video bytes ", 4096)); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	app.Get("/convert/test", func(c fiber.Ctx) error {
+		return sendMultipart(c, fiber.Map{"ok": true}, []outputFile{
+			{field: "thumbnail", mimeType: "image/jpeg", data: fakeJPEG},
+			{field: "output", mimeType: "video/mp4", file: &services.SpooledFile{File: spilled}},
+		})
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/convert/test", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256(body)
+	if got, want := resp.Header.Get(headerContentSHA256), hex.EncodeToString(digest[:]); got != want {
+		t.Errorf("%s = %q, want %q", headerContentSHA256, got, want)
+	}
+	if !strings.Contains(string(body), "video bytes video bytes") {
+		t.Error("spilled output missing from the body")
+	}
+}
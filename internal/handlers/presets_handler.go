@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// SetPresets sets the presets listed by GET /presets
+func (h *MetaHandler) SetPresets(presets *services.Presets) {
+	h.presets = presets
+}
+
+// Presets godoc
+// @Summary List conversion presets
+// @Description Lists the presets defined in PRESETS_FILE. Send a preset's name in the preset field of a conversion of its type (audio, image, video or sticker): the fields the request leaves unset are taken from the preset's options, fields the request sets win.
+// @Tags General
+// @Produce json
+// @Success 200 {object} models.PresetsResponse
+// @Router /presets [get]
+func (h *MetaHandler) Presets(c fiber.Ctx) error {
+	presets := h.presets.List()
+	if presets == nil {
+		presets = []services.Preset{}
+	}
+	return c.JSON(models.PresetsResponse{Presets: presets, Count: len(presets)})
+}
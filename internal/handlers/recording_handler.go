@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/features"
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// ReplayOfHeader marks a replayed request with the recording it came from,
+// so a repeated failure isn't recorded a second time
+const ReplayOfHeader = "X-Replay-Of"
+
+// Dispatcher runs a request through the API in-process
+type Dispatcher func(req *http.Request, timeout time.Duration) (*http.Response, error)
+
+var (
+	errInputNotRecorded = errors.New("input not recorded")
+	errInputMismatch    = errors.New("input does not match the recorded digest")
+)
+
+// RecordingHandler lists failed requests kept by REQUEST_RECORDING and
+// re-runs them through the API once the failure is believed fixed.
+type RecordingHandler struct {
+	recorder   *services.RequestRecorder
+	dispatch   Dispatcher
+	timeout    time.Duration
+	adminToken string
+}
+
+// NewRecordingHandler creates a recording handler guarded by adminToken
+// (ADMIN_TOKEN); replays are dispatched with timeout
+func NewRecordingHandler(recorder *services.RequestRecorder, dispatch Dispatcher, timeout time.Duration, adminToken string) *RecordingHandler {
+	return &RecordingHandler{
+		recorder:   recorder,
+		dispatch:   dispatch,
+		timeout:    timeout,
+		adminToken: adminToken,
+	}
+}
+
+// ListRecordings godoc
+// @Summary List recorded failed requests
+// @Description Lists the failed requests kept by REQUEST_RECORDING, newest first: sanitized parameters, SHA-256 digests of their inputs and the error they got. Bodies are never returned.
+// @Tags Debug
+// @Produce json
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Success 200 {object} models.RecordingsResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /admin/recordings [get]
+func (h *RecordingHandler) ListRecordings(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	recordings, err := h.recorder.List()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to list recordings",
+			Details: err.Error(),
+		})
+	}
+
+	return c.JSON(models.RecordingsResponse{Recordings: recordings, Count: len(recordings)})
+}
+
+// GetRecording godoc
+// @Summary Get a recorded failed request
+// @Description Returns the sanitized envelope of a recorded request, as named by the X-Recording-ID header of its response.
+// @Tags Debug
+// @Produce json
+// @Param id path string true "X-Recording-ID of the failed response"
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Success 200 {object} services.RecordedRequest
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Unknown or expired recording (code recording_not_found)"
+// @Router /admin/recordings/{id} [get]
+func (h *RecordingHandler) GetRecording(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	rec, err := h.recorder.Load(c.Params("id"))
+	if err != nil {
+		return recordingLoadError(c, err)
+	}
+	rec.Body = nil
+
+	return c.JSON(rec)
+}
+
+// Replay godoc
+// @Summary Replay a recorded failed request
+// @Description Re-runs a recorded request through the API in-process, with its original path, query and allow-listed headers, and reports how it fares now. Recordings without a body (REQUEST_RECORDING_BODIES off or over REQUEST_RECORDING_MAX_BODY_SIZE) can be replayed by sending the original file as the request body, provided the recording has a single base64 or file input and the file matches its SHA-256. Send X-API-Key to replay as the original caller. Replays are never recorded.
+// @Tags Debug
+// @Accept application/octet-stream
+// @Produce json
+// @Param id path string true "X-Recording-ID of the failed response"
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Param X-API-Key header string false "API key to replay the request with"
+// @Param input body string false "Original input, for recordings without a body"
+// @Success 200 {object} models.RequestReplayResponse
+// @Failure 400 {object} models.ErrorResponse "Supplied input doesn't match the recording (code input_mismatch)"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Unknown or expired recording (code recording_not_found)"
+// @Failure 409 {object} models.ErrorResponse "The body wasn't recorded and no usable input was sent (code input_not_recorded)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/replay/{id} [post]
+func (h *RecordingHandler) Replay(c fiber.Ctx) error {
+	if !h.authorized(c) {
+		return invalidAdminToken(c)
+	}
+
+	rec, err := h.recorder.Load(c.Params("id"))
+	if err != nil {
+		return recordingLoadError(c, err)
+	}
+
+	body, contentType, err := replayBody(rec, c.Body())
+	switch {
+	case errors.Is(err, errInputNotRecorded):
+		return c.Status(fiber.StatusConflict).JSON(models.ErrorResponse{
+			Error:   "Input not recorded",
+			Code:    "input_not_recorded",
+			Details: err.Error(),
+		})
+	case errors.Is(err, errInputMismatch):
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Input mismatch",
+			Code:    "input_mismatch",
+			Details: err.Error(),
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to rebuild the request",
+			Details: err.Error(),
+		})
+	}
+
+	target := rec.Path
+	if rec.Query != "" {
+		target += "?" + rec.Query
+	}
+	req, err := http.NewRequest(rec.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to rebuild the request",
+			Details: err.Error(),
+		})
+	}
+	for header, value := range rec.Headers {
+		req.Header.Set(header, value)
+	}
+	req.Header.Set(fiber.HeaderContentType, contentType)
+	req.Header.Set(ReplayOfHeader, rec.ID)
+	req.Header.Set("X-Debug-Trace", "true")
+	if apiKey := c.Get(features.APIKeyHeader); apiKey != "" {
+		req.Header.Set(features.APIKeyHeader, apiKey)
+	}
+
+	start := time.Now()
+	resp, err := h.dispatch(req, h.timeout)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Replay failed",
+			Details: err.Error(),
+		})
+	}
+	defer resp.Body.Close()
+
+	output, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Replay failed",
+			Details: err.Error(),
+		})
+	}
+
+	response := models.RequestReplayResponse{
+		RecordingID:    rec.ID,
+		RecordedAt:     rec.RecordedAt,
+		Method:         rec.Method,
+		Path:           rec.Path,
+		OriginalStatus: rec.Status,
+		OriginalError:  rec.Error,
+		OriginalCode:   rec.ErrorCode,
+		Status:         resp.StatusCode,
+		Fixed:          resp.StatusCode < fiber.StatusBadRequest,
+		ContentType:    resp.Header.Get(fiber.HeaderContentType),
+		Size:           len(output),
+		DurationMS:     time.Since(start).Milliseconds(),
+	}
+	if strings.HasPrefix(response.ContentType, fiber.MIMEApplicationJSON) && json.Valid(output) {
+		response.Response = output
+	}
+
+	return c.JSON(response)
+}
+
+func (h *RecordingHandler) authorized(c fiber.Ctx) bool {
+	return subtle.ConstantTimeCompare([]byte(c.Get(adminTokenHeader)), []byte(h.adminToken)) == 1
+}
+
+func invalidAdminToken(c fiber.Ctx) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+		Error: "Invalid admin token",
+	})
+}
+
+func recordingLoadError(c fiber.Ctx, err error) error {
+	if errors.Is(err, services.ErrRecordingNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   "Recording not found",
+			Code:    "recording_not_found",
+			Details: "The recording ID is unknown or its retention period has expired",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error:   "Failed to load recording",
+		Details: err.Error(),
+	})
+}
+
+// replayBody returns the body and Content-Type to replay rec with: the
+// recorded body, or the request rebuilt around the supplied input
+func replayBody(rec *services.RecordedRequest, supplied []byte) ([]byte, string, error) {
+	contentType := rec.Headers[fiber.HeaderContentType]
+	if rec.BodyRecorded {
+		return rec.Body, contentType, nil
+	}
+
+	if len(supplied) == 0 {
+		return nil, "", fmt.Errorf("%w: send the original file as the replay body", errInputNotRecorded)
+	}
+	if len(rec.Inputs) != 1 || rec.Inputs[0].URL != "" {
+		return nil, "", fmt.Errorf("%w: only recordings with a single base64 or file input can be rebuilt (this one has %d)", errInputNotRecorded, len(rec.Inputs))
+	}
+
+	input := rec.Inputs[0]
+	sum := sha256.Sum256(supplied)
+	if digest := hex.EncodeToString(sum[:]); digest != input.SHA256 {
+		return nil, "", fmt.Errorf("%w: got sha256 %s, recorded %s", errInputMismatch, digest, input.SHA256)
+	}
+
+	if strings.HasPrefix(contentType, fiber.MIMEMultipartForm) {
+		return rebuildForm(rec.Params, input, supplied)
+	}
+	body, err := rebuildJSON(rec.Params, input.Field, base64.StdEncoding.EncodeToString(supplied))
+	return body, contentType, err
+}
+
+// rebuildJSON puts data back at the dotted path of a sanitized JSON body
+func rebuildJSON(params json.RawMessage, path, data string) ([]byte, error) {
+	var value any
+	if err := json.Unmarshal(params, &value); err != nil {
+		return nil, fmt.Errorf("recorded parameters: %w", err)
+	}
+
+	node := value
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		last := i == len(keys)-1
+		switch v := node.(type) {
+		case map[string]any:
+			if last {
+				v[key] = data
+			}
+			node = v[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, fmt.Errorf("input path %s not found in the recorded parameters", path)
+			}
+			if last {
+				v[index] = data
+			}
+			node = v[index]
+		default:
+			return nil, fmt.Errorf("input path %s not found in the recorded parameters", path)
+		}
+	}
+
+	return json.Marshal(value)
+}
+
+// rebuildForm writes the recorded form fields back into a multipart body,
+// with the input as the file (or "data" field) it was sent as
+func rebuildForm(params json.RawMessage, input services.RecordedInput, data []byte) ([]byte, string, error) {
+	var fields map[string][]string
+	if err := json.Unmarshal(params, &fields); err != nil {
+		return nil, "", fmt.Errorf("recorded parameters: %w", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, values := range fields {
+		for _, value := range values {
+			if name == input.Field && value == "sha256:"+input.SHA256 {
+				value = base64.StdEncoding.EncodeToString(data)
+			}
+			if err := writer.WriteField(name, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if _, ok := fields[input.Field]; !ok {
+		header := make(textproto.MIMEHeader)
+		header.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`form-data; name=%q; filename=%q`, input.Field, input.Filename))
+		if input.ContentType != "" {
+			header.Set(fiber.HeaderContentType, input.ContentType)
+		}
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// Shared-secret headers guarding the debug and admin endpoints
+const (
+	replayTokenHeader = "X-Replay-Token" // SOURCE_REPLAY_TOKEN
+	adminTokenHeader  = "X-Admin-Token"  // ADMIN_TOKEN
+)
+
+// ReplayHandler re-runs retained failed conversions so support can reproduce
+// a failure with the exact input instead of asking users to resend media.
+type ReplayHandler struct {
+	store          *services.SourceStore
+	audioConverter services.AudioConverterIface
+	imageConverter services.ImageConverterIface
+	requestTimeout time.Duration
+	token          string
+}
+
+// NewReplayHandler creates a replay handler guarded by token
+func NewReplayHandler(
+	store *services.SourceStore,
+	audioConverter services.AudioConverterIface,
+	imageConverter services.ImageConverterIface,
+	requestTimeout time.Duration,
+	token string,
+) *ReplayHandler {
+	return &ReplayHandler{
+		store:          store,
+		audioConverter: audioConverter,
+		imageConverter: imageConverter,
+		requestTimeout: requestTimeout,
+		token:          token,
+	}
+}
+
+// Replay godoc
+// @Summary Replay a retained failed conversion
+// @Description Re-runs the conversion of an input retained by RETAIN_FAILED_SOURCES with the original options and a forced command trace.
+// @Tags Debug
+// @Produce json
+// @Param id path string true "source_id from the failed conversion response"
+// @Param X-Replay-Token header string true "SOURCE_REPLAY_TOKEN"
+// @Success 200 {object} models.ReplayResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /debug/replay/{id} [post]
+func (h *ReplayHandler) Replay(c fiber.Ctx) error {
+	if subtle.ConstantTimeCompare([]byte(c.Get(replayTokenHeader)), []byte(h.token)) != 1 {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+			Error: "Invalid replay token",
+		})
+	}
+
+	id := c.Params("id")
+	source, err := h.store.Load(id)
+	if err != nil {
+		if errors.Is(err, services.ErrSourceNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+				Error:   "Source not found",
+				Details: "The source ID is unknown or its retention period has expired",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Failed to load source",
+			Details: err.Error(),
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	trace := services.NewCommandTrace()
+	ctx = services.WithReplay(services.WithCommandTrace(ctx, trace))
+
+	response := models.ReplayResponse{
+		SourceID:      source.ID,
+		Kind:          source.Kind,
+		OriginalError: source.Error,
+		RetainedAt:    source.CreatedAt,
+		ExpiresAt:     source.ExpiresAt,
+	}
+
+	data := base64.StdEncoding.EncodeToString(source.Data)
+	switch source.Kind {
+	case "audio":
+		var req services.AudioRequest
+		if err = json.Unmarshal(source.Request, &req); err == nil {
+			req.Data = data
+			response.Audio, err = h.audioConverter.Convert(ctx, &req)
+		}
+	case "image":
+		var req services.ImageRequest
+		if err = json.Unmarshal(source.Request, &req); err == nil {
+			req.Data = data
+			response.Image, err = h.imageConverter.Convert(ctx, &req)
+		}
+	default:
+		err = errors.New("unknown source kind " + source.Kind)
+	}
+
+	response.Trace = trace.Records()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:    "Replay failed",
+			Details:  err.Error(),
+			Trace:    response.Trace,
+			SourceID: source.ID,
+		})
+	}
+
+	return c.JSON(response)
+}
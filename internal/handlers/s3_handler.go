@@ -1,17 +1,23 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"path"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"whats-convert-api/internal/features"
 	"whats-convert-api/internal/models"
 	"whats-convert-api/internal/providers"
 	"whats-convert-api/internal/services"
@@ -21,13 +27,16 @@ import (
 type S3Handler struct {
 	s3Service     *services.S3Service
 	uploadManager *services.UploadManager
+	adminToken    string
 }
 
 // NewS3Handler creates a new S3 handler
-func NewS3Handler(s3Service *services.S3Service, uploadManager *services.UploadManager) *S3Handler {
+// adminToken enables the admin diagnostics; empty leaves them unregistered.
+func NewS3Handler(s3Service *services.S3Service, uploadManager *services.UploadManager, adminToken string) *S3Handler {
 	return &S3Handler{
 		s3Service:     s3Service,
 		uploadManager: uploadManager,
+		adminToken:    adminToken,
 	}
 }
 
@@ -40,8 +49,11 @@ func NewS3Handler(s3Service *services.S3Service, uploadManager *services.UploadM
 // @Param file formData file true "Binary file to upload"
 // @Param options formData string false "JSON encoded upload options" example:{"public":false}
 // @Success 202 {object} models.S3UploadResponse
-// @Failure 400 {object} models.S3UploadResponse
+// @Failure 400 {object} models.S3UploadResponse "Invalid request, key_template or on_collision"
+// @Failure 409 {object} models.S3UploadResponse "Key taken and on_collision is error"
+// @Failure 429 {object} models.S3UploadResponse "All upload slots, or the API key's share of them, are in use"
 // @Failure 500 {object} models.S3UploadResponse
+// @Failure 502 {object} models.S3UploadResponse "The bucket couldn't be checked for collisions"
 // @Failure 503 {object} models.S3UploadResponse
 // @Router /upload/s3 [post]
 func (h *S3Handler) UploadFile(c fiber.Ctx) error {
@@ -71,32 +83,22 @@ func (h *S3Handler) UploadFile(c fiber.Ctx) error {
 	}
 
 	file := files[0]
-
-	// Open uploaded file
-	src, err := file.Open()
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(models.S3UploadResponse{
+	if file.Size == 0 {
+		return c.Status(http.StatusBadRequest).JSON(models.S3UploadResponse{
 			Success: false,
-			Error:   "Failed to open uploaded file: " + err.Error(),
+			Error:   "Uploaded file is empty",
 		})
 	}
 
-	fileBytes, err := io.ReadAll(src)
+	// The upload outlives the request, so it is streamed to the provider from
+	// a temporary file rather than held in memory
+	spooled, err := services.SpoolUpload(file, "")
 	if err != nil {
-		src.Close()
 		return c.Status(http.StatusInternalServerError).JSON(models.S3UploadResponse{
 			Success: false,
 			Error:   "Failed to read uploaded file: " + err.Error(),
 		})
 	}
-	src.Close()
-
-	if len(fileBytes) == 0 {
-		return c.Status(http.StatusBadRequest).JSON(models.S3UploadResponse{
-			Success: false,
-			Error:   "Uploaded file is empty",
-		})
-	}
 
 	// Parse options from form
 	var options models.S3UploadRequest
@@ -106,19 +108,25 @@ func (h *S3Handler) UploadFile(c fiber.Ctx) error {
 		}
 	}
 
-	// Generate key if not provided
-	key := options.Key
-	if key == "" {
-		key = h.s3Service.GenerateKey(file.Filename)
-	}
-
 	// Detect content type if not provided
 	contentType := options.ContentType
 	if contentType == "" {
-		contentType = file.Header.Get("Content-Type")
-		if contentType == "" {
-			contentType = "application/octet-stream"
-		}
+		media, sniffed := services.SniffUpload(file)
+		contentType = detectedContentType(media, sniffed, file.Header.Get("Content-Type"))
+	}
+
+	// Name the object unless a key was provided, then apply the collision policy
+	key, err := h.s3Service.ObjectKey(c.Context(), services.KeySource{
+		Key:         options.Key,
+		Filename:    file.Filename,
+		ContentType: contentType,
+		File:        spooled,
+		Template:    options.KeyTemplate,
+		Collision:   options.OnCollision,
+	})
+	if err != nil {
+		spooled.Close()
+		return objectKeyError(c, err)
 	}
 
 	// Prepare upload options
@@ -130,19 +138,16 @@ func (h *S3Handler) UploadFile(c fiber.Ctx) error {
 		StorageClass:   options.StorageClass,
 	}
 
-	// Start upload using upload manager
-	reader := bytes.NewReader(fileBytes)
-	fileSize := int64(len(fileBytes))
-
+	// Start upload using upload manager; it removes the spooled file when done
 	uploadInfo, err := h.uploadManager.StartUpload(
-		context.TODO(),
+		services.WithTenant(c.Context(), c.Get(features.APIKeyHeader)),
 		key,
-		reader,
-		fileSize,
+		spooled,
+		file.Size,
 		uploadOpts,
 	)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(models.S3UploadResponse{
+		return c.Status(startUploadStatus(err)).JSON(models.S3UploadResponse{
 			Success: false,
 			Error:   "Failed to start upload: " + err.Error(),
 		})
@@ -151,6 +156,11 @@ func (h *S3Handler) UploadFile(c fiber.Ctx) error {
 	// Set original filename
 	uploadInfo.OriginalFilename = file.Filename
 
+	// The upload runs in the background, bounded by S3_UPLOAD_TIMEOUT (a
+	// queued upload's clock starts once a worker picks it up)
+	uploadTimeout := h.s3Service.GetConfig().UploadTimeout
+	setDeadlineHeaders(c, uploadTimeout, uploadInfo.StartTime.Add(uploadTimeout))
+
 	return c.Status(http.StatusAccepted).JSON(models.S3UploadResponse{
 		Success:  true,
 		UploadID: uploadInfo.ID,
@@ -166,8 +176,11 @@ func (h *S3Handler) UploadFile(c fiber.Ctx) error {
 // @Produce json
 // @Param request body models.S3Base64UploadRequest true "Base64 upload request"
 // @Success 202 {object} models.S3UploadResponse
-// @Failure 400 {object} models.S3UploadResponse
+// @Failure 400 {object} models.S3UploadResponse "Invalid request, key_template or on_collision"
+// @Failure 409 {object} models.S3UploadResponse "Key taken and on_collision is error"
+// @Failure 429 {object} models.S3UploadResponse "All upload slots, or the API key's share of them, are in use"
 // @Failure 500 {object} models.S3UploadResponse
+// @Failure 502 {object} models.S3UploadResponse "The bucket couldn't be checked for collisions"
 // @Failure 503 {object} models.S3UploadResponse
 // @Router /upload/s3/base64 [post]
 func (h *S3Handler) UploadBase64(c fiber.Ctx) error {
@@ -194,25 +207,32 @@ func (h *S3Handler) UploadBase64(c fiber.Ctx) error {
 		})
 	}
 
-	// Generate key if not provided
-	key := req.Key
-	if key == "" {
-		filename := req.Filename
-		if filename == "" {
-			filename = "file"
+	// Detect content type if not provided
+	contentType := req.ContentType
+	if contentType == "" {
+		var claimed string
+		if strings.HasPrefix(req.Data, "data:") {
+			claimed, _, _ = strings.Cut(strings.TrimPrefix(req.Data, "data:"), ";")
 		}
-		key = h.s3Service.GenerateKey(filename)
+		media, sniffed := services.SniffBase64(req.Data)
+		contentType = detectedContentType(media, sniffed, claimed)
 	}
 
-	// Detect content type from data URL if not provided
-	contentType := req.ContentType
-	if contentType == "" && strings.HasPrefix(req.Data, "data:") {
-		if parts := strings.Split(req.Data, ";"); len(parts) > 0 {
-			contentType = strings.TrimPrefix(parts[0], "data:")
-		}
+	// Name the object unless a key was provided, then apply the collision policy
+	filename := req.Filename
+	if filename == "" {
+		filename = "file"
 	}
-	if contentType == "" {
-		contentType = "application/octet-stream"
+	key, err := h.s3Service.ObjectKey(c.Context(), services.KeySource{
+		Key:         req.Key,
+		Filename:    filename,
+		ContentType: contentType,
+		Base64:      req.Data,
+		Template:    req.KeyTemplate,
+		Collision:   req.OnCollision,
+	})
+	if err != nil {
+		return objectKeyError(c, err)
 	}
 
 	// Prepare upload options
@@ -226,13 +246,13 @@ func (h *S3Handler) UploadBase64(c fiber.Ctx) error {
 
 	// Start base64 upload using upload manager
 	uploadInfo, err := h.uploadManager.StartBase64Upload(
-		context.TODO(),
+		services.WithTenant(c.Context(), c.Get(features.APIKeyHeader)),
 		key,
 		req.Data,
 		uploadOpts,
 	)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(models.S3UploadResponse{
+		return c.Status(startUploadStatus(err)).JSON(models.S3UploadResponse{
 			Success: false,
 			Error:   "Failed to start upload: " + err.Error(),
 		})
@@ -241,6 +261,118 @@ func (h *S3Handler) UploadBase64(c fiber.Ctx) error {
 	// Set original filename
 	uploadInfo.OriginalFilename = req.Filename
 
+	// The upload runs in the background, bounded by S3_UPLOAD_TIMEOUT (a
+	// queued upload's clock starts once a worker picks it up)
+	uploadTimeout := h.s3Service.GetConfig().UploadTimeout
+	setDeadlineHeaders(c, uploadTimeout, uploadInfo.StartTime.Add(uploadTimeout))
+
+	return c.Status(http.StatusAccepted).JSON(models.S3UploadResponse{
+		Success:  true,
+		UploadID: uploadInfo.ID,
+		Message:  "Upload started successfully",
+	})
+}
+
+// UploadURL godoc
+// @Summary Start upload of a remote file to S3-compatible storage
+// @Description Downloads the URL on an upload worker and streams it into the bucket as it arrives, so clients don't proxy large files. Answers with an upload ID at once; follow it through /upload/s3/status/{id}. Without content_type the type is sniffed from the first bytes. {hash}, {sha256}, {width} and {height} are refused in key templates, as the content isn't known before the upload starts.
+// @Tags S3
+// @Accept json
+// @Produce json
+// @Param request body models.S3URLUploadRequest true "URL upload request"
+// @Success 202 {object} models.S3UploadResponse
+// @Failure 400 {object} models.S3UploadResponse "Invalid request, URL, key_template or on_collision, or a URL host on a loopback, private or link-local address"
+// @Failure 409 {object} models.S3UploadResponse "Key taken and on_collision is error"
+// @Failure 429 {object} models.S3UploadResponse "All upload slots, or the API key's share of them, are in use"
+// @Failure 500 {object} models.S3UploadResponse
+// @Failure 502 {object} models.S3UploadResponse "The bucket couldn't be checked for collisions"
+// @Failure 503 {object} models.S3UploadResponse
+// @Router /upload/s3/url [post]
+func (h *S3Handler) UploadURL(c fiber.Ctx) error {
+	if !h.s3Service.IsEnabled() {
+		return c.Status(http.StatusServiceUnavailable).JSON(models.S3UploadResponse{
+			Success: false,
+			Error:   "S3 upload service is disabled",
+		})
+	}
+
+	var req models.S3URLUploadRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(models.S3UploadResponse{
+			Success: false,
+			Error:   "Invalid JSON payload: " + err.Error(),
+		})
+	}
+
+	// Validate required fields
+	if strings.TrimSpace(req.URL) == "" {
+		return c.Status(http.StatusBadRequest).JSON(models.S3UploadResponse{
+			Success: false,
+			Error:   "Missing required field: url",
+		})
+	}
+	source, err := services.ParseUploadURL(req.URL)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(models.S3UploadResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	// Name the object from the URL's filename; the content isn't downloaded yet
+	filename := req.Filename
+	if filename == "" {
+		filename = services.URLFilename(source)
+	}
+	if filename == "" {
+		filename = "file"
+	}
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(filename))
+	}
+	key, err := h.s3Service.ObjectKey(c.Context(), services.KeySource{
+		Key:         req.Key,
+		Filename:    filename,
+		ContentType: contentType,
+		Template:    req.KeyTemplate,
+		Collision:   req.OnCollision,
+		Streamed:    true,
+	})
+	if err != nil {
+		return objectKeyError(c, err)
+	}
+
+	// Prepare upload options
+	uploadOpts := providers.UploadOptions{
+		ContentType:    req.ContentType,
+		Public:         req.Public,
+		ExpirationDays: req.ExpirationDays,
+		Metadata:       req.Metadata,
+		StorageClass:   req.StorageClass,
+	}
+
+	uploadInfo, err := h.uploadManager.StartURLUpload(
+		services.WithTenant(c.Context(), c.Get(features.APIKeyHeader)),
+		key,
+		source.String(),
+		uploadOpts,
+	)
+	if err != nil {
+		return c.Status(startUploadStatus(err)).JSON(models.S3UploadResponse{
+			Success: false,
+			Error:   "Failed to start upload: " + err.Error(),
+		})
+	}
+
+	// Set original filename
+	uploadInfo.OriginalFilename = filename
+
+	// The upload runs in the background, bounded by S3_UPLOAD_TIMEOUT (a
+	// queued upload's clock starts once a worker picks it up)
+	uploadTimeout := h.s3Service.GetConfig().UploadTimeout
+	setDeadlineHeaders(c, uploadTimeout, uploadInfo.StartTime.Add(uploadTimeout))
+
 	return c.Status(http.StatusAccepted).JSON(models.S3UploadResponse{
 		Success:  true,
 		UploadID: uploadInfo.ID,
@@ -248,6 +380,34 @@ func (h *S3Handler) UploadBase64(c fiber.Ctx) error {
 	})
 }
 
+// objectKeyError maps key naming failures to upload responses
+func objectKeyError(c fiber.Ctx, err error) error {
+	status := http.StatusBadGateway // The bucket couldn't be checked
+	switch {
+	case errors.Is(err, services.ErrInvalidKeyTemplate):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrObjectExists):
+		status = http.StatusConflict
+	}
+
+	return c.Status(status).JSON(models.S3UploadResponse{
+		Success: false,
+		Error:   "Failed to name object: " + err.Error(),
+	})
+}
+
+// startUploadStatus maps upload manager failures to a status; full slots are
+// temporary, so they get 429 for the client to retry
+func startUploadStatus(err error) int {
+	if errors.Is(err, services.ErrUploadCapacity) || errors.Is(err, services.ErrTenantUploadCapacity) {
+		return http.StatusTooManyRequests
+	}
+	if errors.Is(err, services.ErrDownloadAddressForbidden) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // GetUploadStatus godoc
 // @Summary Retrieve asynchronous upload status
 // @Tags S3
@@ -272,7 +432,85 @@ func (h *S3Handler) GetUploadStatus(c fiber.Ctx) error {
 		})
 	}
 
-	response := models.S3UploadStatusResponse{
+	return c.JSON(toS3UploadStatusResponse(uploadInfo))
+}
+
+// WaitForUpload godoc
+// @Summary Wait for an asynchronous upload to finish
+// @Description Long-polls until the upload completes, fails or is cancelled. Answers 200 with the final status, or 202 with the current one when the timeout passes first so the caller can poll again.
+// @Tags S3
+// @Produce json
+// @Param id path string true "Upload identifier"
+// @Param timeout query string false "How long to wait, as a duration (30s, 2m) or seconds; default 30s, max 5m"
+// @Success 200 {object} models.S3UploadStatusResponse "Upload finished"
+// @Success 202 {object} models.S3UploadStatusResponse "Still pending or uploading"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /upload/s3/status/{id}/wait [get]
+func (h *S3Handler) WaitForUpload(c fiber.Ctx) error {
+	timeout, err := parseWaitTimeout(c.Query("timeout"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid timeout",
+			Details: err.Error(),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), timeout)
+	defer cancel()
+
+	uploadInfo, err := h.uploadManager.WaitForUpload(ctx, c.Params("id"))
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Upload not found",
+		})
+	}
+
+	status := http.StatusOK
+	if !uploadInfo.Finished() {
+		status = http.StatusAccepted
+	}
+	return c.Status(status).JSON(toS3UploadStatusResponse(uploadInfo))
+}
+
+// Upload wait bounds
+const (
+	defaultUploadWait = 30 * time.Second
+	maxUploadWait     = 5 * time.Minute
+)
+
+// parseWaitTimeout reads the wait timeout, bounded by maxUploadWait
+func parseWaitTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultUploadWait, nil
+	}
+
+	timeout, err := parseSeconds(value)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 || timeout > maxUploadWait {
+		return 0, fmt.Errorf("timeout must be between 0s and %s", maxUploadWait)
+	}
+	return timeout, nil
+}
+
+// parseSeconds reads a duration ("45s") or a number of seconds ("45")
+func parseSeconds(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("%q is neither a duration nor a number of seconds", value)
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+	return duration, nil
+}
+
+// toS3UploadStatusResponse maps an upload snapshot to its API representation
+func toS3UploadStatusResponse(uploadInfo *services.UploadInfo) models.S3UploadStatusResponse {
+	return models.S3UploadStatusResponse{
 		UploadID:         uploadInfo.ID,
 		Status:           string(uploadInfo.Status),
 		Progress:         uploadInfo.Progress,
@@ -282,9 +520,8 @@ func (h *S3Handler) GetUploadStatus(c fiber.Ctx) error {
 		EndTime:          uploadInfo.EndTime,
 		Error:            uploadInfo.Error,
 		Result:           toS3UploadResult(uploadInfo.Result),
+		SourceURL:        uploadInfo.SourceURL,
 	}
-
-	return c.JSON(response)
 }
 
 // CancelUpload godoc
@@ -346,17 +583,7 @@ func (h *S3Handler) ListUploads(c fiber.Ctx) error {
 	// Convert to response format
 	var response []models.S3UploadStatusResponse
 	for _, upload := range uploads {
-		response = append(response, models.S3UploadStatusResponse{
-			UploadID:         upload.ID,
-			Status:           string(upload.Status),
-			Progress:         upload.Progress,
-			BytesTransferred: upload.BytesTransferred,
-			TotalBytes:       upload.TotalBytes,
-			StartTime:        upload.StartTime,
-			EndTime:          upload.EndTime,
-			Error:            upload.Error,
-			Result:           toS3UploadResult(upload.Result),
-		})
+		response = append(response, toS3UploadStatusResponse(upload))
 	}
 
 	return c.JSON(models.S3UploadListResponse{
@@ -365,6 +592,102 @@ func (h *S3Handler) ListUploads(c fiber.Ctx) error {
 	})
 }
 
+// defaultObjectListLimit is the page size of object listings without a limit
+const defaultObjectListLimit = 100
+
+// ListObjects godoc
+// @Summary Browse stored objects
+// @Description Lists the objects in the bucket whose keys start with the prefix, in key order, a page at a time. Follow next_cursor until it is absent to walk the whole listing. Objects in the trash are listed under S3_TRASH_PREFIX.
+// @Tags S3
+// @Produce json
+// @Param prefix query string false "Only keys starting with this prefix (uploads/2024/)"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param limit query int false "Objects per page (1-1000)" default(100)
+// @Success 200 {object} models.S3ObjectListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 502 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /upload/s3/objects [get]
+func (h *S3Handler) ListObjects(c fiber.Ctx) error {
+	if !h.s3Service.IsEnabled() {
+		return c.Status(http.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error: "S3 upload service is disabled",
+		})
+	}
+
+	limit := defaultObjectListLimit
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > providers.MaxListLimit {
+			return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid limit",
+				Details: fmt.Sprintf("%q must be a number between 1 and %d", raw, providers.MaxListLimit),
+			})
+		}
+	}
+
+	prefix := c.Query("prefix")
+	list, err := h.s3Service.ListObjects(c.Context(), providers.ListOptions{
+		Prefix: prefix,
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	})
+	if err != nil {
+		var s3Err *providers.S3Error
+		if errors.As(err, &s3Err) && s3Err.StatusCode == http.StatusBadRequest {
+			return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid cursor",
+				Details: err.Error(),
+			})
+		}
+		return c.Status(http.StatusBadGateway).JSON(models.ErrorResponse{
+			Error:   "Failed to list objects",
+			Details: err.Error(),
+		})
+	}
+
+	return c.JSON(models.S3ObjectListResponse{
+		Objects:    list.Objects,
+		Count:      len(list.Objects),
+		Prefix:     prefix,
+		NextCursor: list.NextCursor,
+	})
+}
+
+// diagnosticsTimeout bounds the whole test PUT/GET/DELETE sequence
+const diagnosticsTimeout = 30 * time.Second
+
+// Diagnose godoc
+// @Summary Diagnose the S3 configuration
+// @Description Checks the provider's clock, then performs a signed PUT, GET and DELETE of a small test object in the configured bucket. Each failed step carries the S3 error code and a reason (clock_skew, signature_mismatch, invalid_access_key, access_denied, bucket_not_found, wrong_region, unreachable, timeout, content_mismatch) with a hint naming the setting to check.
+// @Tags S3
+// @Produce json
+// @Param X-Admin-Token header string true "ADMIN_TOKEN"
+// @Success 200 {object} services.S3Diagnosis "Diagnosis; ok is false when any step failed"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /upload/s3/diagnostics [post]
+func (h *S3Handler) Diagnose(c fiber.Ctx) error {
+	if subtle.ConstantTimeCompare([]byte(c.Get(adminTokenHeader)), []byte(h.adminToken)) != 1 {
+		return c.Status(http.StatusUnauthorized).JSON(models.ErrorResponse{
+			Error: "Invalid admin token",
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), diagnosticsTimeout)
+	defer cancel()
+
+	diagnosis, err := h.s3Service.Diagnose(ctx)
+	if err != nil {
+		return c.Status(http.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error:   "S3 diagnostics unavailable",
+			Details: err.Error(),
+		})
+	}
+
+	return c.JSON(diagnosis)
+}
+
 // GetS3Stats godoc
 // @Summary S3 provider and upload manager metrics
 // @Tags S3
@@ -391,6 +714,21 @@ func (h *S3Handler) GetS3Stats(c fiber.Ctx) error {
 	if capacity, ok := uploadStats["capacity_used"].(float64); ok {
 		managerStats.CapacityUsed = capacity
 	}
+	if workers, ok := uploadStats["workers"].(int); ok {
+		managerStats.Workers = workers
+	}
+	if active, ok := uploadStats["active_workers"].(int); ok {
+		managerStats.ActiveWorkers = active
+	}
+	if queued, ok := uploadStats["queued_uploads"].(int); ok {
+		managerStats.QueuedUploads = queued
+	}
+	if failed, ok := uploadStats["failed_uploads"].(int64); ok {
+		managerStats.FailedUploads = failed
+	}
+	if avg, ok := uploadStats["avg_upload_ms"].(float64); ok {
+		managerStats.AvgUploadMS = avg
+	}
 
 	if counts, ok := uploadStats["status_counts"].(map[services.UploadStatus]int); ok {
 		for status, count := range counts {
@@ -412,9 +750,21 @@ func (h *S3Handler) GetS3Stats(c fiber.Ctx) error {
 			SuccessRate:       s3Stats.GetSuccessRate(),
 			AvgUploadTime:     s3Stats.GetFormattedAverageTime(),
 			LastUpload:        s3Stats.LastUpload,
+
+			ProviderHealthy:     s3Stats.ProviderHealthy,
+			HealthCheckFailures: s3Stats.HealthCheckFailures,
+			Reconnects:          s3Stats.Reconnects,
+			FailedReconnects:    s3Stats.FailedReconnects,
+
+			VerifiedUploads:        s3Stats.VerifiedUploads,
+			VerificationMismatches: s3Stats.VerificationMismatches,
+			VerificationFailures:   s3Stats.VerificationFailures,
 		},
 		UploadManager: managerStats,
 	}
+	if !s3Stats.LastReconnect.IsZero() {
+		response.S3Service.LastReconnect = &s3Stats.LastReconnect
+	}
 
 	return c.JSON(response)
 }
@@ -435,7 +785,7 @@ func (h *S3Handler) GetS3Health(c fiber.Ctx) error {
 		})
 	}
 
-	err := h.s3Service.HealthCheck(context.TODO())
+	err := h.s3Service.HealthCheck(c.Context())
 	if err != nil {
 		return c.Status(http.StatusServiceUnavailable).JSON(models.S3HealthResponse{
 			Status:  "unhealthy",
@@ -453,12 +803,16 @@ func (h *S3Handler) GetS3Health(c fiber.Ctx) error {
 
 // DeleteObject godoc
 // @Summary Delete object from storage
+// @Description With S3_SOFT_DELETE the object is moved under S3_TRASH_PREFIX, where POST /upload/s3/object/{key}/restore can bring it back until S3_TRASH_TTL passes. "?permanent=true" deletes it outright, as does deleting a key under the trash prefix.
 // @Tags S3
 // @Produce json
-// @Param key path string true "Object key"
-// @Success 200 {object} models.MessageResponse
+// @Param key path string true "Object key; may contain slashes (uploads/2024/01/file.jpg)"
+// @Param permanent query bool false "Skip the trash"
+// @Success 200 {object} models.S3DeleteResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
+// @Failure 501 {object} models.ErrorResponse "The provider can't read objects back, which moving them needs"
 // @Failure 503 {object} models.ErrorResponse
 // @Router /upload/s3/object/{key} [delete]
 func (h *S3Handler) DeleteObject(c fiber.Ctx) error {
@@ -468,31 +822,129 @@ func (h *S3Handler) DeleteObject(c fiber.Ctx) error {
 		})
 	}
 
-	key := c.Params("key")
+	key := objectKey(c)
 	if key == "" {
 		return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Object key is required",
 		})
 	}
 
-	err := h.s3Service.DeleteObject(context.TODO(), key)
+	permanent, _ := strconv.ParseBool(c.Query("permanent"))
+	if h.s3Service.SoftDeleteEnabled() && !permanent && !strings.HasPrefix(key, h.s3Service.GetConfig().TrashPrefix) {
+		trashed, err := h.s3Service.Trash(c.Context(), key)
+		if err != nil {
+			return trashError(c, "Failed to move object to trash", err)
+		}
+		return c.JSON(models.S3DeleteResponse{
+			Success:   true,
+			Message:   "Object moved to trash",
+			Trashed:   true,
+			TrashKey:  trashed.TrashKey,
+			ExpiresAt: &trashed.ExpiresAt,
+		})
+	}
+
+	err := h.s3Service.DeleteObject(c.Context(), key)
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(models.ErrorResponse{
 			Error: "Failed to delete object: " + err.Error(),
 		})
 	}
 
-	return c.JSON(models.MessageResponse{
+	return c.JSON(models.S3DeleteResponse{
 		Success: true,
 		Message: "Object deleted successfully",
 	})
 }
 
+// RestoreObject godoc
+// @Summary Restore a soft-deleted object
+// @Description Moves an object deleted with S3_SOFT_DELETE back from the trash to its original key, keeping its content type and metadata.
+// @Tags S3
+// @Produce json
+// @Param key path string true "Original object key; may contain slashes (uploads/2024/01/file.jpg)"
+// @Success 200 {object} providers.ObjectInfo
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Not in the trash (code not_in_trash), or soft delete is off"
+// @Failure 409 {object} models.ErrorResponse "Another object was stored at the key since (code restore_conflict)"
+// @Failure 410 {object} models.ErrorResponse "The trashed copy outlived S3_TRASH_TTL (code trash_expired)"
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 501 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /upload/s3/object/{key}/restore [post]
+func (h *S3Handler) RestoreObject(c fiber.Ctx) error {
+	if !h.s3Service.IsEnabled() {
+		return c.Status(http.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error: "S3 upload service is disabled",
+		})
+	}
+	if !h.s3Service.SoftDeleteEnabled() {
+		return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   "Soft delete is disabled",
+			Details: "Set S3_SOFT_DELETE=true to move deleted objects to the trash",
+		})
+	}
+
+	key := objectKey(c)
+	if key == "" {
+		return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Object key is required",
+		})
+	}
+
+	if err := h.s3Service.Restore(c.Context(), key); err != nil {
+		return trashError(c, "Failed to restore object", err)
+	}
+
+	info, err := h.s3Service.GetObjectInfo(c.Context(), key)
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error:   "Object restored but its metadata is unavailable",
+			Details: err.Error(),
+		})
+	}
+	return c.JSON(info)
+}
+
+// trashError maps soft delete and restore failures to responses
+func trashError(c fiber.Ctx, message string, err error) error {
+	switch {
+	case errors.Is(err, services.ErrNotInTrash):
+		return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Object not in trash",
+			Code:  "not_in_trash",
+		})
+	case errors.Is(err, services.ErrTrashExpired):
+		return c.Status(http.StatusGone).JSON(models.ErrorResponse{
+			Error: "Object was purged from the trash",
+			Code:  "trash_expired",
+		})
+	case errors.Is(err, services.ErrRestoreBlocked):
+		return c.Status(http.StatusConflict).JSON(models.ErrorResponse{
+			Error:   "Object already exists",
+			Code:    "restore_conflict",
+			Details: "Delete or move the object stored at the key since, then restore again",
+		})
+	case errors.Is(err, providers.ErrObjectNotFound):
+		return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Object not found",
+		})
+	case errors.Is(err, providers.ErrFeatureNotSupported):
+		return c.Status(http.StatusNotImplemented).JSON(models.ErrorResponse{
+			Error: "The S3 provider can't read objects back",
+		})
+	}
+	return c.Status(http.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error:   message,
+		Details: err.Error(),
+	})
+}
+
 // GetObjectInfo godoc
 // @Summary Retrieve object metadata
 // @Tags S3
 // @Produce json
-// @Param key path string true "Object key"
+// @Param key path string true "Object key; may contain slashes (uploads/2024/01/file.jpg)"
 // @Success 200 {object} providers.ObjectInfo
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
@@ -505,14 +957,14 @@ func (h *S3Handler) GetObjectInfo(c fiber.Ctx) error {
 		})
 	}
 
-	key := c.Params("key")
+	key := objectKey(c)
 	if key == "" {
 		return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
 			Error: "Object key is required",
 		})
 	}
 
-	info, err := h.s3Service.GetObjectInfo(context.TODO(), key)
+	info, err := h.s3Service.GetObjectInfo(c.Context(), key)
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
 			Error: "Object not found: " + err.Error(),
@@ -522,27 +974,213 @@ func (h *S3Handler) GetObjectInfo(c fiber.Ctx) error {
 	return c.JSON(info)
 }
 
+// maxShareReasonLength bounds the reason copied into the audit log
+const maxShareReasonLength = 200
+
+// ShareObject godoc
+// @Summary Share a private object temporarily
+// @Description Returns a presigned GET URL that expires after the TTL, so private media can be exposed briefly without changing its ACL. Every grant is written to the audit log with its ID, key, expiry, client IP, request ID and reason.
+// @Tags S3
+// @Accept json
+// @Produce json
+// @Param key path string true "Object key; may contain slashes (uploads/2024/01/file.jpg)"
+// @Param request body models.S3ShareRequest false "TTL and reason"
+// @Success 200 {object} models.S3ShareResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 501 {object} models.ErrorResponse "The provider can't presign URLs"
+// @Failure 502 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /upload/s3/object/{key}/share [post]
+func (h *S3Handler) ShareObject(c fiber.Ctx) error {
+	if !h.s3Service.IsEnabled() {
+		return c.Status(http.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error: "S3 upload service is disabled",
+		})
+	}
+
+	key := objectKey(c)
+	if key == "" {
+		return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Object key is required",
+		})
+	}
+
+	var req models.S3ShareRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid request body",
+				Details: err.Error(),
+			})
+		}
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = parseSeconds(req.TTL); err != nil || ttl <= 0 {
+			return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid ttl",
+				Details: fmt.Sprintf("%q must be a positive duration or number of seconds", req.TTL),
+			})
+		}
+	}
+
+	grant, err := h.s3Service.Share(c.Context(), key, ttl)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidShareTTL):
+			return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid ttl",
+				Details: err.Error(),
+			})
+		case errors.Is(err, providers.ErrObjectNotFound):
+			return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Object not found",
+			})
+		case errors.Is(err, providers.ErrFeatureNotSupported):
+			return c.Status(http.StatusNotImplemented).JSON(models.ErrorResponse{
+				Error: "The S3 provider can't presign URLs",
+			})
+		default:
+			return c.Status(http.StatusBadGateway).JSON(models.ErrorResponse{
+				Error:   "Failed to share object",
+				Details: err.Error(),
+			})
+		}
+	}
+
+	reason := req.Reason
+	if len(reason) > maxShareReasonLength {
+		reason = reason[:maxShareReasonLength]
+	}
+	log.Printf("🔓 S3 Share granted: id=%s key=%q ttl=%s expires=%s ip=%s request_id=%s reason=%q",
+		grant.ID, grant.Key, grant.TTL, grant.ExpiresAt.Format(time.RFC3339), c.IP(), requestid.FromContext(c), reason)
+
+	return c.JSON(models.S3ShareResponse{
+		ShareID:    grant.ID,
+		Key:        grant.Key,
+		URL:        grant.URL,
+		TTLSeconds: int64(grant.TTL / time.Second),
+		ExpiresAt:  grant.ExpiresAt,
+	})
+}
+
+// SetObjectExpiration godoc
+// @Summary Set when an object expires
+// @Description Makes a stored object expire the given number of days after it was stored, or removes its expiration with 0. On AWS and MinIO the object is tagged for a bucket lifecycle rule, added on first use; on Backblaze B2, which can't match tags, the expiry is recorded in the object's metadata by copying it onto itself (so days count from now) and expired objects are deleted by the expiry sweep (S3_EXPIRY_SWEEP_INTERVAL).
+// @Tags S3
+// @Accept json
+// @Produce json
+// @Param key path string true "Object key; may contain slashes (uploads/2024/01/file.jpg)"
+// @Param request body models.S3ExpirationRequest true "Days until the object is deleted"
+// @Success 200 {object} models.S3ExpirationResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 501 {object} models.ErrorResponse "The provider can't expire objects"
+// @Failure 502 {object} models.ErrorResponse
+// @Failure 503 {object} models.ErrorResponse
+// @Router /upload/s3/object/{key}/expiration [put]
+func (h *S3Handler) SetObjectExpiration(c fiber.Ctx) error {
+	if !h.s3Service.IsEnabled() {
+		return c.Status(http.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error: "S3 upload service is disabled",
+		})
+	}
+
+	key := objectKey(c)
+	if key == "" {
+		return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Object key is required",
+		})
+	}
+
+	var req models.S3ExpirationRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid request body",
+			Details: err.Error(),
+		})
+	}
+	if req.Days == nil || *req.Days < 0 || *req.Days > providers.MaxExpirationDays {
+		return c.Status(http.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid days",
+			Details: fmt.Sprintf("days must be between 0 and %d", providers.MaxExpirationDays),
+		})
+	}
+
+	expiresAt, err := h.s3Service.SetExpiration(c.Context(), key, *req.Days)
+	if err != nil {
+		switch {
+		case errors.Is(err, providers.ErrObjectNotFound):
+			return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
+				Error: "Object not found",
+			})
+		case errors.Is(err, providers.ErrFeatureNotSupported):
+			return c.Status(http.StatusNotImplemented).JSON(models.ErrorResponse{
+				Error: "The S3 provider can't expire objects",
+			})
+		default:
+			return c.Status(http.StatusBadGateway).JSON(models.ErrorResponse{
+				Error:   "Failed to set expiration",
+				Details: err.Error(),
+			})
+		}
+	}
+
+	return c.JSON(models.S3ExpirationResponse{
+		Success:        true,
+		Key:            key,
+		ExpirationDays: *req.Days,
+		ExpiresAt:      expiresAt,
+	})
+}
+
 // RegisterS3Routes registers all S3-related routes
-func (h *S3Handler) RegisterS3Routes(app *fiber.App) {
-	s3 := app.Group("/upload/s3")
+func (h *S3Handler) RegisterS3Routes(router fiber.Router) {
+	s3 := router.Group("/upload/s3")
 
 	// Upload endpoints - register both variants to support strict routing
 	s3.Post("/", h.UploadFile)
 	s3.Post("", h.UploadFile)
 	s3.Post("/base64", h.UploadBase64)
+	s3.Post("/url", h.UploadURL)
 
 	// Status and management endpoints
 	s3.Get("/status/:id", h.GetUploadStatus)
+	s3.Get("/status/:id/wait", h.WaitForUpload)
+	s3.Get("/status/:id/events", h.StreamStatusEvents)
+	s3.Get("/progress/:id", h.StreamProgress)
 	s3.Delete("/status/:id", h.CancelUpload)
 	s3.Get("/list", h.ListUploads)
 
-	// Object management endpoints
-	s3.Delete("/object/:key", h.DeleteObject)
-	s3.Get("/object/:key", h.GetObjectInfo)
+	// Object management endpoints; keys may span several path segments
+	s3.Get("/objects", h.ListObjects)
+	s3.Delete("/object/*", h.DeleteObject)
+	s3.Get("/object/*", h.GetObjectInfo)
+	s3.Post("/object/*/share", h.ShareObject)
+	s3.Put("/object/*/expiration", h.SetObjectExpiration)
+	s3.Post("/object/*/restore", h.RestoreObject)
 
 	// Service endpoints
 	s3.Get("/stats", h.GetS3Stats)
 	s3.Get("/health", h.GetS3Health)
+
+	// Admin diagnostics
+	if h.adminToken != "" {
+		s3.Post("/diagnostics", h.Diagnose)
+	}
+}
+
+// objectKey reads the object key from the wildcard path segment. Nested keys
+// may be sent as plain paths (uploads/2024/01/file.jpg) or percent-encoded.
+func objectKey(c fiber.Ctx) string {
+	key := c.Params("*")
+	if unescaped, err := url.PathUnescape(key); err == nil {
+		return unescaped
+	}
+	return key
 }
 
 func toS3UploadResult(res *providers.UploadResult) *models.S3UploadResult {
@@ -568,3 +1206,25 @@ func toS3UploadResult(res *providers.UploadResult) *models.S3UploadResult {
 		ProcessingTimeMS: res.ProcessingTime.Milliseconds(),
 	}
 }
+
+// detectedContentType returns the type sniffed from an upload's content, or
+// the one the client claimed (multipart header, data URI) when the content
+// isn't recognised or only the audio/video prefix differs: an audio-only
+// WebM or MP4 starts like any other.
+func detectedContentType(media services.MediaType, sniffed bool, claimed string) string {
+	claimed = strings.TrimSpace(claimed)
+	if !sniffed {
+		if claimed == "" {
+			return "application/octet-stream"
+		}
+		return claimed
+	}
+
+	base, _, _ := strings.Cut(strings.ToLower(claimed), ";")
+	_, claimedSubtype, _ := strings.Cut(strings.TrimSpace(base), "/")
+	_, sniffedSubtype, _ := strings.Cut(media.MIME, "/")
+	if claimedSubtype == sniffedSubtype {
+		return claimed
+	}
+	return media.MIME
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/config"
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/services"
+)
+
+// newTestS3Service returns an S3Service on the in-memory mock provider,
+// without background monitors; configure adjusts the config before use
+func newTestS3Service(t *testing.T, configure ...func(*config.S3Configuration)) *services.S3Service {
+	t.Helper()
+
+	cfg := config.LoadS3Config()
+	cfg.ApplyMockMode()
+	cfg.HealthCheckInterval = 0
+	cfg.ExpirySweepInterval = 0
+	cfg.SoftDelete = false
+	for _, apply := range configure {
+		apply(cfg)
+	}
+
+	service, err := services.NewS3Service(cfg)
+	if err != nil {
+		t.Fatalf("NewS3Service: %v", err)
+	}
+	t.Cleanup(service.Close)
+	return service
+}
+
+// newTestS3App serves the S3 routes of an S3Handler on the mock provider
+func newTestS3App(t *testing.T, configure ...func(*config.S3Configuration)) (*fiber.App, *services.S3Service) {
+	t.Helper()
+
+	service := newTestS3Service(t, configure...)
+	uploads := services.NewUploadManager(service, 2, 2)
+	t.Cleanup(uploads.Stop)
+
+	app := fiber.New()
+	NewS3Handler(service, uploads, "").RegisterS3Routes(app)
+	return app, service
+}
+
+func TestUploadBase64(t *testing.T) {
+	app, _ := newTestS3App(t)
+
+	resp, body := doJSON(t, app, fiber.MethodPost, "/upload/s3/base64", `{"data":"/9j/4AAQSkZJRgABAQ==","key":"tests/base64.jpg"}`)
+	if resp.StatusCode != fiber.StatusAccepted {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+	var started models.S3UploadResponse
+	if err := json.Unmarshal(body, &started); err != nil || !started.Success || started.UploadID == "" {
+		t.Fatalf("response = %s (%v)", body, err)
+	}
+
+	resp, body = doJSON(t, app, fiber.MethodGet, "/upload/s3/status/"+started.UploadID+"/wait?timeout=5s", "")
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("wait status = %d, body %s", resp.StatusCode, body)
+	}
+	var status models.S3UploadStatusResponse
+	if err := json.Unmarshal(body, &status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if status.Status != "completed" || status.Result == nil || status.Result.Key != "tests/base64.jpg" {
+		t.Errorf("status = %s", body)
+	}
+
+	resp, body = doJSON(t, app, fiber.MethodGet, "/upload/s3/object/tests/base64.jpg", "")
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("object status = %d, body %s", resp.StatusCode, body)
+	}
+}
+
+func TestUploadBase64MissingData(t *testing.T) {
+	app, _ := newTestS3App(t)
+
+	resp, body := doJSON(t, app, fiber.MethodPost, "/upload/s3/base64", `{"key":"tests/empty.jpg"}`)
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+}
+
+func TestS3Disabled(t *testing.T) {
+	cfg := config.LoadS3Config()
+	cfg.Enabled = false
+	service, err := services.NewS3Service(cfg)
+	if err != nil {
+		t.Fatalf("NewS3Service: %v", err)
+	}
+
+	app := fiber.New()
+	NewS3Handler(service, nil, "").RegisterS3Routes(app)
+
+	resp, body := doJSON(t, app, fiber.MethodGet, "/upload/s3/object/tests/a.jpg", "")
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("status = %d, body %s", resp.StatusCode, body)
+	}
+}
+
+// putObject stores a small JPEG at key
+func putObject(t *testing.T, service *services.S3Service, key string) {
+	t.Helper()
+
+	_, err := service.Upload(context.Background(), key, fakeJPEG, providers.UploadOptions{ContentType: "image/jpeg"})
+	if err != nil {
+		t.Fatalf("Upload %s: %v", key, err)
+	}
+}
+
+func TestObjectKey(t *testing.T) {
+	app := fiber.New()
+	app.Get("/object/*", func(c fiber.Ctx) error {
+		return c.SendString(objectKey(c))
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/object/file.jpg", "file.jpg"},
+		{"/object/uploads/2024/01/file.jpg", "uploads/2024/01/file.jpg"},
+		{"/object/uploads%2F2024%2F01%2Ffile.jpg", "uploads/2024/01/file.jpg"},
+		{"/object/uploads/2024%2F01/file.jpg", "uploads/2024/01/file.jpg"},
+		{"/object/with%20space.jpg", "with space.jpg"},
+		{"/object/double%252Fencoded.jpg", "double%2Fencoded.jpg"},
+		{"/object/reports/share", "reports/share"},
+		{"/object/backups/restore", "backups/restore"},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.path, nil))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != tt.want {
+			t.Errorf("objectKey(%s) = %q, want %q", tt.path, body, tt.want)
+		}
+	}
+}
+
+func TestObjectRoutesNestedKeys(t *testing.T) {
+	app, service := newTestS3App(t)
+	for _, key := range []string{"uploads/2024/01/file.jpg", "reports/share", "backups/restore"} {
+		putObject(t, service, key)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{fiber.MethodGet, "/upload/s3/object/uploads/2024/01/file.jpg", "uploads/2024/01/file.jpg"},
+		{fiber.MethodGet, "/upload/s3/object/uploads%2F2024%2F01%2Ffile.jpg", "uploads/2024/01/file.jpg"},
+		{fiber.MethodGet, "/upload/s3/object/reports/share", "reports/share"},
+		{fiber.MethodGet, "/upload/s3/object/backups/restore", "backups/restore"},
+		{fiber.MethodPost, "/upload/s3/object/uploads/2024/01/file.jpg/share", "uploads/2024/01/file.jpg"},
+		{fiber.MethodPost, "/upload/s3/object/uploads%2F2024%2F01%2Ffile.jpg/share", "uploads/2024/01/file.jpg"},
+		{fiber.MethodPost, "/upload/s3/object/reports/share/share", "reports/share"},
+		{fiber.MethodPost, "/upload/s3/object/reports%2Fshare/share", "reports/share"},
+		{fiber.MethodPost, "/upload/s3/object/backups/restore/share", "backups/restore"},
+	}
+
+	for _, tt := range tests {
+		resp, body := doJSON(t, app, tt.method, tt.path, "")
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("%s %s = %d, body %s", tt.method, tt.path, resp.StatusCode, body)
+			continue
+		}
+		var object struct {
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal(body, &object); err != nil || object.Key != tt.want {
+			t.Errorf("%s %s key = %q (%v), want %q", tt.method, tt.path, object.Key, err, tt.want)
+		}
+	}
+}
+
+func TestRestoreNestedKeys(t *testing.T) {
+	app, service := newTestS3App(t, func(cfg *config.S3Configuration) {
+		cfg.SoftDelete = true
+	})
+
+	tests := []struct {
+		key     string
+		restore string
+	}{
+		{"backups/restore", "/upload/s3/object/backups/restore/restore"},
+		{"archive/restore", "/upload/s3/object/archive%2Frestore/restore"},
+		{"reports/2024/share", "/upload/s3/object/reports/2024/share/restore"},
+	}
+
+	for _, tt := range tests {
+		putObject(t, service, tt.key)
+
+		if resp, body := doJSON(t, app, fiber.MethodDelete, "/upload/s3/object/"+tt.key, ""); resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("DELETE %s = %d, body %s", tt.key, resp.StatusCode, body)
+		}
+		if resp, _ := doJSON(t, app, fiber.MethodGet, "/upload/s3/object/"+tt.key, ""); resp.StatusCode != fiber.StatusNotFound {
+			t.Fatalf("GET %s after delete = %d, want 404", tt.key, resp.StatusCode)
+		}
+
+		resp, body := doJSON(t, app, fiber.MethodPost, tt.restore, "")
+		if resp.StatusCode != fiber.StatusOK {
+			t.Errorf("POST %s = %d, body %s", tt.restore, resp.StatusCode, body)
+			continue
+		}
+		var info providers.ObjectInfo
+		if err := json.Unmarshal(body, &info); err != nil || info.Key != tt.key {
+			t.Errorf("POST %s key = %q (%v), want %q", tt.restore, info.Key, err, tt.key)
+		}
+	}
+}
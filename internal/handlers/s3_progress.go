@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v3"
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// Progress socket timings
+const (
+	progressWriteTimeout = 10 * time.Second
+	progressPingInterval = 30 * time.Second
+)
+
+// progressUpgrader accepts same-origin browsers and clients that send no Origin
+var progressUpgrader = websocket.FastHTTPUpgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// StreamProgress godoc
+// @Summary Stream upload progress over a WebSocket
+// @Description Upgrades to a WebSocket that sends the upload's state as a JSON services.UploadProgress message right away, then on every progress change, and a last message with the final status (completed, failed or cancelled) before closing normally. Intermediate events are coalesced for slow readers. Anything the client sends is ignored.
+// @Tags S3
+// @Produce json
+// @Param id path string true "Upload identifier"
+// @Success 101 {object} services.UploadProgress "Switching protocols; messages are UploadProgress events"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 426 {object} models.ErrorResponse "Not a WebSocket upgrade request"
+// @Router /upload/s3/progress/{id} [get]
+func (h *S3Handler) StreamProgress(c fiber.Ctx) error {
+	if !websocket.FastHTTPIsWebSocketUpgrade(c.RequestCtx()) {
+		return c.Status(http.StatusUpgradeRequired).JSON(models.ErrorResponse{
+			Error:   "WebSocket upgrade required",
+			Details: "Connect with a WebSocket client, or poll /upload/s3/status/{id}",
+		})
+	}
+
+	uploadID := c.Params("id")
+	events, done, unsubscribe, err := h.uploadManager.SubscribeProgress(uploadID)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Upload not found",
+		})
+	}
+
+	// The connection is served after this handler returns, so it must not touch c
+	err = progressUpgrader.Upgrade(c.RequestCtx(), func(conn *websocket.Conn) {
+		defer unsubscribe()
+
+		// Reading is required to process pings and notice the client leaving
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		err := h.streamProgress(conn, uploadID, events, done, closed)
+
+		// fasthttp recycles the connection once this function returns
+		conn.Close()
+		<-closed
+
+		if err != nil {
+			log.Printf("Progress stream for upload %s ended: %v", uploadID, err)
+		}
+	})
+	if err != nil {
+		// The upgrader has already answered with a 4xx
+		unsubscribe()
+	}
+	return nil
+}
+
+// streamProgress relays the upload's progress to conn until it finishes or the
+// client goes away (closed), then closes with the final status as the reason
+func (h *S3Handler) streamProgress(conn *websocket.Conn, uploadID string, events <-chan services.UploadProgress, done, closed <-chan struct{}) error {
+	send := func(event services.UploadProgress) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(progressWriteTimeout))
+		return conn.WriteJSON(event)
+	}
+	ping := func() error {
+		return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(progressWriteTimeout))
+	}
+
+	final, err := h.followProgress(uploadID, events, done, closed, send, ping)
+	if err != nil || final == "" {
+		return err
+	}
+
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, string(final))
+	return conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(progressWriteTimeout))
+}
+
+// followProgress hands send the upload's current state, then its progress
+// events, then its outcome, calling ping while nothing happens. It returns the
+// final status, or an empty one when closed fires first.
+func (h *S3Handler) followProgress(uploadID string, events <-chan services.UploadProgress, done, closed <-chan struct{}, send func(services.UploadProgress) error, ping func() error) (services.UploadStatus, error) {
+	current, err := h.uploadManager.GetUploadStatus(uploadID)
+	if err != nil {
+		return "", err
+	}
+	if err := send(current.ProgressEvent()); err != nil {
+		return "", err
+	}
+
+	ticker := time.NewTicker(progressPingInterval)
+	defer ticker.Stop()
+
+	for !current.Finished() {
+		select {
+		case event := <-events:
+			// Skip to the newest event when the client fell behind
+			for drained := false; !drained; {
+				select {
+				case event = <-events:
+				default:
+					drained = true
+				}
+			}
+			if err := send(event); err != nil {
+				return "", err
+			}
+
+		case <-done:
+			if current, err = h.uploadManager.GetUploadStatus(uploadID); err != nil {
+				return "", err
+			}
+			if err := send(current.ProgressEvent()); err != nil {
+				return "", err
+			}
+
+		case <-ticker.C:
+			if err := ping(); err != nil {
+				return "", err
+			}
+
+		case <-closed:
+			return "", nil
+		}
+	}
+
+	return current.Status, nil
+}
+
+// StreamStatusEvents godoc
+// @Summary Stream upload progress as Server-Sent Events
+// @Description For clients that can't use WebSockets. Sends a "status" event with the upload's state right away and on every status transition, "progress" events as bytes are transferred, and ends the stream after the final status (completed, failed or cancelled). Each event's data is a JSON services.UploadProgress; comment lines keep idle connections open.
+// @Tags S3
+// @Produce text/event-stream
+// @Param id path string true "Upload identifier"
+// @Success 200 {object} services.UploadProgress "Event stream of UploadProgress payloads"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /upload/s3/status/{id}/events [get]
+func (h *S3Handler) StreamStatusEvents(c fiber.Ctx) error {
+	uploadID := c.Params("id")
+	events, done, unsubscribe, err := h.uploadManager.SubscribeProgress(uploadID)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Upload not found",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set("X-Accel-Buffering", "no") // Keep reverse proxies from holding events back
+
+	// The stream is written after this handler returns, so it must not touch c
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		var lastStatus services.UploadStatus
+		send := func(event services.UploadProgress) error {
+			name := "progress"
+			if event.Status != lastStatus {
+				name, lastStatus = "status", event.Status
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+			return w.Flush()
+		}
+		ping := func() error {
+			w.WriteString(": ping\n\n")
+			return w.Flush()
+		}
+
+		// A client leaving shows up as a failed flush
+		if _, err := h.followProgress(uploadID, events, done, nil, send, ping); err != nil {
+			log.Printf("Event stream for upload %s ended: %v", uploadID, err)
+		}
+	})
+}
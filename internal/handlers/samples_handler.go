@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/samples"
+)
+
+// Samples godoc
+// @Summary List sample media
+// @Description Lists the tiny embedded files served by GET /samples/{type}, with the conversion endpoint each one suits.
+// @Tags General
+// @Produce json
+// @Success 200 {object} models.SamplesResponse
+// @Router /samples [get]
+func (h *MetaHandler) Samples(c fiber.Ctx) error {
+	var response models.SamplesResponse
+	for _, name := range samples.Types() {
+		sample, _ := samples.Get(name)
+		response.Samples = append(response.Samples, sampleResponse(c, sample))
+	}
+	return c.JSON(response)
+}
+
+// Sample godoc
+// @Summary Download sample media
+// @Description Returns a tiny embedded file for trying the API end-to-end without test files of your own: mp3 (1s of silence, for /convert/audio), jpeg (160×120 gradient, for /convert/image and /convert/sticker) or webm (1s of Opus silence, as browsers record voice notes, for /convert/audio). ?encoding=base64 wraps it in JSON with a data URI ready to paste into a conversion request.
+// @Tags General
+// @Produce audio/mpeg
+// @Produce image/jpeg
+// @Produce audio/webm
+// @Produce json
+// @Param type path string true "Sample type" Enums(mp3, jpeg, webm)
+// @Param encoding query string false "base64 returns JSON with a data URI instead of the file" Enums(base64)
+// @Success 200 {file} binary
+// @Failure 404 {object} models.ErrorResponse "Unknown type (code sample_not_found)"
+// @Router /samples/{type} [get]
+func (h *MetaHandler) Sample(c fiber.Ctx) error {
+	sample, ok := samples.Get(c.Params("type"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   "Sample not found",
+			Code:    "sample_not_found",
+			Details: "Available samples: " + strings.Join(samples.Types(), ", "),
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	if strings.EqualFold(c.Query("encoding"), "base64") {
+		response := sampleResponse(c, sample)
+		response.Data = fmt.Sprintf("data:%s;base64,%s", sample.MimeType, base64.StdEncoding.EncodeToString(sample.Data))
+		return c.JSON(response)
+	}
+
+	c.Set(fiber.HeaderContentType, sample.MimeType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename=%q`, sample.Filename))
+	return c.Send(sample.Data)
+}
+
+// sampleResponse describes a sample, linking it under the prefix it was requested with
+func sampleResponse(c fiber.Ctx, sample samples.Sample) models.SampleResponse {
+	base := strings.TrimSuffix(c.Path(), "/")
+	if !strings.HasSuffix(base, "/samples") {
+		base = base[:strings.LastIndex(base, "/")]
+	}
+
+	return models.SampleResponse{
+		Type:     sample.Type,
+		Filename: sample.Filename,
+		MimeType: sample.MimeType,
+		Size:     len(sample.Data),
+		Endpoint: sample.Endpoint,
+		URL:      base + "/" + sample.Type,
+	}
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// ConvertSticker godoc
+// @Summary Convert image to a WhatsApp sticker
+// @Description Fits the image onto a transparent 512x512 canvas and encodes a static WebP of at most 100KB. Setting pack_name, publisher, pack_id or emojis embeds sticker pack metadata in the WebP's EXIF.
+// @Tags Conversion
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Produce multipart/form-data
+// @Param request body services.StickerRequest true "Sticker conversion request"
+// @Param file formData file false "Image file when using multipart"
+// @Param data_uri formData bool false "Multipart only: false returns plain base64 instead of a data URI"
+// @Param pack_id formData string false "Multipart only: EXIF sticker pack identifier"
+// @Param pack_name formData string false "Multipart only: EXIF sticker pack name"
+// @Param publisher formData string false "Multipart only: EXIF sticker pack publisher"
+// @Param emojis formData string false "Multipart only: comma-separated emojis (up to 3)"
+// @Param preset formData string false "Multipart only: PRESETS_FILE sticker preset filling the fields left unset"
+// @Param Accept header string false "multipart/form-data returns a JSON metadata part plus the converted binary part"
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)"
+// @Param debug_timings query bool false "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)"
+// @Success 200 {object} services.StickerResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request, sticker metadata (code invalid_sticker) or unknown preset (code unknown_preset)"
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "Image exceeds MAX_IMAGE_MEGAPIXELS (code pixel_limit_exceeded) or the sticker can't fit 100KB (code sticker_too_large)"
+// @Failure 415 {object} models.ErrorResponse "Input is audio (code unsupported_input)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /convert/sticker [post]
+func (h *ConverterHandler) ConvertSticker(c fiber.Ctx) error {
+	req, err := h.parseStickerRequest(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+
+	req.Data = sanitizeBase64Data(req.Data)
+	if req.Input == nil && strings.TrimSpace(req.Data) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Missing 'data' field",
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.routeTimeout(h.timeouts.Image))
+	defer cancel()
+
+	ctx, trace := h.startTrace(c, ctx)
+	ctx, timer := startTimings(c, ctx)
+	multipartOutput := wantsMultipart(c)
+	req.RawOutput = multipartOutput
+
+	start := time.Now()
+	response, err := h.imageConverter.ConvertSticker(ctx, req)
+	records := h.finishTrace(c, trace)
+	timings := finishTimings(c, timer)
+	if err != nil {
+		return stickerError(c, ctx, err, records)
+	}
+	response.Trace = records
+	response.Timings = timings
+
+	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
+	c.Set("X-Output-Size", fmt.Sprintf("%d", response.Size))
+
+	if multipartOutput {
+		return sendMultipart(c, response, []outputFile{{field: "file", mimeType: response.MimeType, data: response.Output}})
+	}
+
+	return c.JSON(response)
+}
+
+func (h *ConverterHandler) parseStickerRequest(c fiber.Ctx) (*services.StickerRequest, error) {
+	contentType := strings.ToLower(c.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, "multipart/form-data") {
+		var req services.StickerRequest
+		if err := c.Bind().Body(&req); err != nil {
+			return nil, newRequestError(fiber.StatusBadRequest, "Invalid request body", err.Error())
+		}
+		return &req, nil
+	}
+
+	fileHeader, err := formUpload(c)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readUpload(fileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	dataURI, err := parseBoolForm(c, "data_uri")
+	if err != nil {
+		return nil, err
+	}
+
+	req := &services.StickerRequest{
+		Input:     data,
+		DataURI:   dataURI,
+		PackID:    c.FormValue("pack_id"),
+		PackName:  c.FormValue("pack_name"),
+		Publisher: c.FormValue("publisher"),
+		Preset:    strings.TrimSpace(c.FormValue("preset")),
+	}
+	for _, emoji := range strings.Split(c.FormValue("emojis"), ",") {
+		if emoji = strings.TrimSpace(emoji); emoji != "" {
+			req.Emojis = append(req.Emojis, emoji)
+		}
+	}
+
+	return req, nil
+}
+
+// stickerError maps sticker and sticker pack conversion errors to responses
+func stickerError(c fiber.Ctx, ctx context.Context, err error, records []services.CommandRecord) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{
+			Error:   "Request timeout",
+			Details: "Conversion took too long",
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrInvalidSticker) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid sticker metadata",
+			Code:    "invalid_sticker",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrUnknownPreset) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Unknown preset",
+			Code:    "unknown_preset",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if isDownloadError(err) {
+		return downloadError(c, err, records)
+	}
+
+	if errors.Is(err, services.ErrUnsupportedInput) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(models.ErrorResponse{
+			Error:   "Unsupported input",
+			Code:    "unsupported_input",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrInvalidStickerPack) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid sticker pack",
+			Code:    "invalid_sticker_pack",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrPixelLimitExceeded) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Image dimensions too large",
+			Code:    "pixel_limit_exceeded",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrStickerTooLarge) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Sticker too large",
+			Code:    "sticker_too_large",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error:   "Sticker conversion failed",
+		Details: err.Error(),
+		Trace:   records,
+	})
+}
+
+// ConvertStickerPack godoc
+// @Summary Convert images to a WhatsApp sticker pack
+// @Description Converts 3 to 30 images into 512x512 WebP stickers (at most 100KB each) plus a 96x96 PNG tray icon, and returns a contents.json manifest compatible with WhatsApp sticker pack apps.
+// @Tags Conversion
+// @Accept json
+// @Produce json
+// @Produce multipart/form-data
+// @Param request body services.StickerPackRequest true "Sticker pack request"
+// @Param Accept header string false "multipart/form-data returns a JSON metadata part plus the tray icon and stickers as parts named after their manifest files"
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)"
+// @Param debug_timings query bool false "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)"
+// @Success 200 {object} services.StickerPackResponse
+// @Failure 400 {object} models.ErrorResponse "Pack breaks WhatsApp's rules (code invalid_sticker_pack)"
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "Image exceeds MAX_IMAGE_MEGAPIXELS (code pixel_limit_exceeded) or a sticker can't fit 100KB (code sticker_too_large)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /convert/sticker-pack [post]
+func (h *ConverterHandler) ConvertStickerPack(c fiber.Ctx) error {
+	var req services.StickerPackRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid request body",
+			Details: err.Error(),
+		})
+	}
+
+	// Bound the whole pack like a batch
+	ctx, cancel := withDeadline(c, h.batchTimeout(len(req.Stickers)))
+	defer cancel()
+
+	ctx, trace := h.startTrace(c, ctx)
+	ctx, timer := startTimings(c, ctx)
+	multipartOutput := wantsMultipart(c)
+	req.RawOutput = multipartOutput
+
+	start := time.Now()
+	response, err := h.imageConverter.ConvertStickerPack(ctx, &req)
+	records := h.finishTrace(c, trace)
+	timings := finishTimings(c, timer)
+	if err != nil {
+		return stickerError(c, ctx, err, records)
+	}
+	response.Trace = records
+	response.Timings = timings
+
+	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
+	c.Set("X-Batch-Size", fmt.Sprintf("%d", len(response.Stickers)))
+
+	if multipartOutput {
+		files := make([]outputFile, 0, len(response.Stickers)+1)
+		for _, file := range append([]services.StickerFile{response.TrayImage}, response.Stickers...) {
+			files = append(files, outputFile{field: file.FileName, filename: file.FileName, mimeType: file.MimeType, data: file.Output})
+		}
+		return sendMultipart(c, response, files)
+	}
+
+	return c.JSON(response)
+}
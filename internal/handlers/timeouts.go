@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Headers exposing the deadline applied to a request, so clients can size
+// their own timeouts and tell a server-side timeout from a network one
+const (
+	timeoutHeader  = "X-Request-Timeout"  // Seconds, e.g. 30 or 0.5
+	deadlineHeader = "X-Request-Deadline" // RFC 3339 with milliseconds, UTC
+)
+
+// RouteTimeouts bounds each kind of conversion separately. Zero fields fall
+// back to the request timeout; batches then get it once per item.
+type RouteTimeouts struct {
+	Audio time.Duration
+	Image time.Duration // Also stickers
+	Batch time.Duration // Whole batch or sticker pack
+}
+
+// SetRouteTimeouts replaces the per-route timeouts
+func (h *ConverterHandler) SetRouteTimeouts(timeouts RouteTimeouts) {
+	h.timeouts = timeouts
+}
+
+// routeTimeout returns timeout, or the request timeout when it isn't set
+func (h *ConverterHandler) routeTimeout(timeout time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+	return h.requestTimeout
+}
+
+// batchTimeout bounds a batch of items
+func (h *ConverterHandler) batchTimeout(items int) time.Duration {
+	if h.timeouts.Batch > 0 {
+		return h.timeouts.Batch
+	}
+	return h.requestTimeout * time.Duration(max(1, items))
+}
+
+// withDeadline bounds the request by timeout and reports the applied
+// deadline in the response headers
+func withDeadline(c fiber.Ctx, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.Context(), timeout)
+
+	deadline, _ := ctx.Deadline()
+	setDeadlineHeaders(c, timeout, deadline)
+
+	return ctx, cancel
+}
+
+// setDeadlineHeaders reports a deadline enforced outside the request
+// context, such as a background upload's
+func setDeadlineHeaders(c fiber.Ctx, timeout time.Duration, deadline time.Time) {
+	c.Set(timeoutHeader, strconv.FormatFloat(timeout.Seconds(), 'f', -1, 64))
+	c.Set(deadlineHeader, deadline.UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/services"
+)
+
+// stageTimer measures the stages of a request that asked for debug timings
+type stageTimer struct {
+	timings *services.StageTimings
+	start   time.Time
+}
+
+// startTimings times the conversion stages when the request opts in with
+// "?debug_timings=true" or "X-Debug-Timings: true". The slow-request
+// logger's timings are reused when it already attached some.
+func startTimings(c fiber.Ctx, ctx context.Context) (context.Context, *stageTimer) {
+	requested := c.Get("X-Debug-Timings")
+	if requested == "" {
+		requested = c.Query("debug_timings")
+	}
+	if enabled, _ := strconv.ParseBool(requested); !enabled {
+		return ctx, nil
+	}
+
+	timings := services.StageTimingsFrom(ctx)
+	if timings == nil {
+		timings = services.NewStageTimings()
+		ctx = services.WithStageTimings(ctx, timings)
+	}
+
+	return ctx, &stageTimer{timings: timings, start: time.Now()}
+}
+
+// finishTimings sets the Server-Timing header, so binary responses carry the
+// stages too, and returns them for the response body
+func finishTimings(c fiber.Ctx, timer *stageTimer) *services.Timings {
+	if timer == nil {
+		return nil
+	}
+
+	total := time.Since(timer.start)
+	c.Set("Server-Timing", timer.timings.ServerTiming(total))
+
+	return timer.timings.Report(total)
+}
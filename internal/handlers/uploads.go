@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// formUpload returns the multipart "file" field without reading it, so
+// converters can stream it to FFmpeg or copy it to disk
+func formUpload(c fiber.Ctx) (*multipart.FileHeader, error) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, newRequestError(fiber.StatusBadRequest, "Missing file", "file field is required")
+	}
+	if fileHeader.Size == 0 {
+		return nil, newRequestError(fiber.StatusBadRequest, "Uploaded file is empty", "")
+	}
+	return fileHeader, nil
+}
+
+// readUpload reads an upload into a buffer of its exact size, for converters
+// that need the bytes in memory; io.ReadAll would grow and copy its buffer
+// several times on the way
+func readUpload(fileHeader *multipart.FileHeader) ([]byte, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, newRequestError(fiber.StatusInternalServerError, "Failed to open uploaded file", err.Error())
+	}
+	defer file.Close()
+
+	data := make([]byte, fileHeader.Size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, newRequestError(fiber.StatusInternalServerError, "Failed to read uploaded file", fmt.Sprintf("read %s: %v", fileHeader.Filename, err))
+	}
+	return data, nil
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/features"
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// UsageHandler reports conversions and estimated CPU time per API key
+type UsageHandler struct {
+	tracker    *services.UsageTracker
+	adminToken string
+}
+
+// NewUsageHandler creates a usage handler; adminToken (ADMIN_TOKEN) unlocks
+// the usage of every key, empty leaves each caller seeing only their own
+func NewUsageHandler(tracker *services.UsageTracker, adminToken string) *UsageHandler {
+	return &UsageHandler{tracker: tracker, adminToken: adminToken}
+}
+
+// Usage godoc
+// @Summary Conversion usage per API key
+// @Description Conversions and estimated CPU-seconds (user + system time of the FFmpeg/vips runs) per API key since the process started, heaviest first, for billing or throttling heavy users. Callers see their own X-API-Key's usage; X-Admin-Token returns every key's. Keys are reported as the first 12 hex digits of their SHA-256, never in clear.
+// @Tags General
+// @Produce json
+// @Param X-API-Key header string false "Key whose usage is returned (anonymous without one)"
+// @Param X-Admin-Token header string false "ADMIN_TOKEN: return the usage of every key"
+// @Success 200 {object} services.UsageReport
+// @Failure 401 {object} models.ErrorResponse
+// @Router /usage [get]
+func (h *UsageHandler) Usage(c fiber.Ctx) error {
+	token := c.Get(adminTokenHeader)
+	if token == "" {
+		apiKey := c.Get(features.APIKeyHeader)
+		return c.JSON(h.tracker.Report(&apiKey))
+	}
+
+	if h.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		return c.Status(fiber.StatusUnauthorized).JSON(models.ErrorResponse{
+			Error: "Invalid admin token",
+		})
+	}
+
+	return c.JSON(h.tracker.Report(nil))
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// ConvertVideo godoc
+// @Summary Convert video to WhatsApp-compatible MP4
+// @Description Transcodes MOV, MKV, WebM, AVI and other inputs to H.264 baseline + AAC in a faststart MP4 within the configured size and bitrate caps. Variable frame rate recordings (browser MediaRecorder WebM) are normalized to a constant frame rate and their audio resynced. Requires the "video" feature flag.
+// @Tags Conversion
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Produce multipart/form-data
+// @Param request body services.VideoRequest true "Video conversion request"
+// @Param file formData file false "Video file when using multipart"
+// @Param data_uri formData bool false "Multipart only: false returns plain base64 instead of a data URI"
+// @Param max_width formData int false "Multipart only: max width, capped by VIDEO_MAX_WIDTH"
+// @Param max_height formData int false "Multipart only: max height, capped by VIDEO_MAX_HEIGHT"
+// @Param audio_track formData int false "Multipart only: audio track to keep, counted from 0"
+// @Param preset formData string false "Multipart only: whatsapp (default), screencast (larger, 15fps, tuned for text) or a PRESETS_FILE video preset"
+// @Param target_size_mb formData number false "Multipart only: two-pass encode sized to fit in this many MiB"
+// @Param Accept header string false "multipart/form-data returns a JSON metadata part plus the converted binary part"
+// @Param X-API-Key header string false "Evaluated against per-key rollouts of the video feature flag"
+// @Param X-Debug-Trace header bool false "Return executed ffmpeg commands (requires ENABLE_COMMAND_TRACE)"
+// @Param debug_timings query bool false "Return time spent per stage in timings and the Server-Timing header (also X-Debug-Timings: true)"
+// @Success 200 {object} services.VideoResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid request, audio_track out of range (code audio_track_not_found) or unknown preset (code unknown_preset)"
+// @Failure 404 {object} models.ErrorResponse "Video feature not enabled (answered as an unknown endpoint)"
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 422 {object} models.ErrorResponse "Input too long to fit VIDEO_MAX_OUTPUT_SIZE at a watchable bitrate (code output_size_exceeded) or target_size_mb (code target_size_unreachable)"
+// @Failure 415 {object} models.ErrorResponse "Input is audio only (code unsupported_input)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /convert/video [post]
+func (h *ConverterHandler) ConvertVideo(c fiber.Ctx) error {
+	req, err := h.parseVideoRequest(c)
+	if err != nil {
+		return respondWithError(c, err)
+	}
+
+	return h.processVideoConversion(c, req)
+}
+
+func (h *ConverterHandler) parseVideoRequest(c fiber.Ctx) (*services.VideoRequest, error) {
+	contentType := strings.ToLower(c.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		return parseMultipartVideo(c)
+	}
+
+	var req services.VideoRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return nil, newRequestError(fiber.StatusBadRequest, "Invalid request body", err.Error())
+	}
+
+	return &req, nil
+}
+
+func (h *ConverterHandler) processVideoConversion(c fiber.Ctx, req *services.VideoRequest) error {
+	if req == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Invalid request",
+		})
+	}
+
+	req.Data = sanitizeBase64Data(req.Data)
+	if req.Source == nil && req.Input == nil && req.Upload == nil && strings.TrimSpace(req.Data) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Missing 'data' field",
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	ctx, trace := h.startTrace(c, ctx)
+	ctx, timer := startTimings(c, ctx)
+	multipartOutput := wantsMultipart(c)
+	req.RawOutput = multipartOutput
+
+	start := time.Now()
+	response, err := h.videoConverter.Convert(ctx, req)
+	records := h.finishTrace(c, trace)
+	timings := finishTimings(c, timer)
+	if err != nil {
+		return videoConversionError(ctx, c, err, records)
+	}
+	response.Trace = records
+	response.Timings = timings
+
+	c.Set("X-Processing-Time", fmt.Sprintf("%dms", time.Since(start).Milliseconds()))
+	c.Set("X-Output-Size", fmt.Sprintf("%d", response.Size))
+	c.Set("X-Output-Dimensions", fmt.Sprintf("%dx%d", response.Width, response.Height))
+	if response.EncoderVariant != "" {
+		c.Set("X-Encoder-Variant", response.EncoderVariant)
+	}
+
+	if multipartOutput {
+		return sendMultipart(c, response, []outputFile{{field: "file", mimeType: response.MimeType, data: response.Output, file: response.OutputFile}})
+	}
+
+	return c.JSON(response)
+}
+
+// videoConversionError maps video converter failures to responses
+func videoConversionError(ctx context.Context, c fiber.Ctx, err error, records []services.CommandRecord) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{
+			Error:   "Request timeout",
+			Details: "Conversion took too long",
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrAudioTrackNotFound) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Audio track not found",
+			Code:    "audio_track_not_found",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrUnknownPreset) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Unknown preset",
+			Code:    "unknown_preset",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrUnsupportedInput) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(models.ErrorResponse{
+			Error:   "Unsupported input",
+			Code:    "unsupported_input",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if errors.Is(err, services.ErrOutputSizeExceeded) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Video too long for the size limit",
+			Code:    "output_size_exceeded",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	if isDownloadError(err) {
+		return downloadError(c, err, records)
+	}
+
+	if isSourceError(err) {
+		return sourceError(c, err, records)
+	}
+
+	if errors.Is(err, services.ErrTargetSizeUnreachable) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(models.ErrorResponse{
+			Error:   "Output cannot fit the target size",
+			Code:    "target_size_unreachable",
+			Details: err.Error(),
+			Trace:   records,
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error:   "Conversion failed",
+		Details: err.Error(),
+		Trace:   records,
+	})
+}
+
+// parseMultipartVideo leaves the upload where the multipart parser put it;
+// the converter copies it straight into its scratch directory
+func parseMultipartVideo(c fiber.Ctx) (*services.VideoRequest, error) {
+	fileHeader, err := formUpload(c)
+	if err != nil {
+		return nil, err
+	}
+
+	dataURI, err := parseBoolForm(c, "data_uri")
+	if err != nil {
+		return nil, err
+	}
+
+	targetSizeMB, err := parseTargetSizeForm(c)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &services.VideoRequest{
+		Upload:       fileHeader,
+		DataURI:      dataURI,
+		Preset:       strings.TrimSpace(c.FormValue("preset")),
+		TargetSizeMB: targetSizeMB,
+	}
+
+	for _, field := range []struct {
+		name  string
+		value *int
+	}{
+		{"max_width", &req.MaxWidth},
+		{"max_height", &req.MaxHeight},
+	} {
+		raw := strings.TrimSpace(c.FormValue(field.name))
+		if raw == "" {
+			continue
+		}
+		value, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			return nil, newRequestError(fiber.StatusBadRequest, "Invalid "+field.name+" value", field.name+" must be an integer")
+		}
+		*field.value = value
+	}
+
+	if raw := strings.TrimSpace(c.FormValue("audio_track")); raw != "" {
+		track, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			return nil, newRequestError(fiber.StatusBadRequest, "Invalid audio_track value", "audio_track must be an integer")
+		}
+		req.AudioTrack = &track
+	}
+
+	return req, nil
+}
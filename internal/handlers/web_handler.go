@@ -12,18 +12,24 @@ import (
 // WebHandler handles web interface requests
 type WebHandler struct {
 	templates *template.Template
+	prefix    string // Path the interface is mounted under, e.g. "/console" ("" for the root)
 }
 
-// NewWebHandler creates a new web handler
-func NewWebHandler() (*WebHandler, error) {
+// NewWebHandler creates a new web handler mounted under prefix
+func NewWebHandler(prefix string) (*WebHandler, error) {
 	// Parse templates
 	templates, err := template.ParseGlob("web/templates/*.html")
 	if err != nil {
 		return nil, err
 	}
 
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		prefix = "/" + prefix
+	}
+
 	return &WebHandler{
 		templates: templates,
+		prefix:    prefix,
 	}, nil
 }
 
@@ -32,6 +38,7 @@ type PageData struct {
 	Title   string
 	Content template.HTML
 	Scripts []string
+	Prefix  string // Prepended to static asset paths
 }
 
 // ServeHome serves the main interface page
@@ -63,6 +70,7 @@ func (h *WebHandler) ServeHome(c fiber.Ctx) error {
 		Title:   "Media Converter",
 		Content: template.HTML(contentBuffer),
 		Scripts: scripts,
+		Prefix:  h.prefix,
 	}
 
 	// Execute layout template
@@ -152,13 +160,21 @@ func (h *WebHandler) ServeStatic(c fiber.Ctx) error {
 	return c.SendFile(filePath)
 }
 
-// RegisterWebRoutes registers all web interface routes
+// Prefix returns the path the interface is mounted under ("" for the root)
+func (h *WebHandler) Prefix() string {
+	return h.prefix
+}
+
+// RegisterWebRoutes registers all web interface routes under the prefix
 func (h *WebHandler) RegisterWebRoutes(app *fiber.App) {
-	// Home page
-	app.Get("/", h.ServeHome)
+	// Home page, with and without the trailing slash (routing is strict)
+	app.Get(h.prefix+"/", h.ServeHome)
+	if h.prefix != "" {
+		app.Get(h.prefix, h.ServeHome)
+	}
 
 	// Static files
-	app.Get("/static/*", h.ServeStatic)
+	app.Get(h.prefix+"/static/*", h.ServeStatic)
 
 	// Additional web routes can be added here
 	// For example: app.Get("/docs", h.ServeDocs)
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// WorkspaceHandler serves temporary workspaces, where related files are
+// uploaded, converted and composed before they are committed to S3
+type WorkspaceHandler struct {
+	workspaces     *services.WorkspaceManager
+	requestTimeout time.Duration
+}
+
+// NewWorkspaceHandler creates a workspace handler; conversions and commits
+// are bounded by requestTimeout
+func NewWorkspaceHandler(workspaces *services.WorkspaceManager, requestTimeout time.Duration) *WorkspaceHandler {
+	return &WorkspaceHandler{workspaces: workspaces, requestTimeout: requestTimeout}
+}
+
+// CreateWorkspace godoc
+// @Summary Open a temporary workspace
+// @Description Opens an empty workspace and answers 201 with its ID. Files added to it can be converted and composed (e.g. a voice note and its cover into a video) and finally committed to S3 together. A workspace left untouched for WORKSPACE_TTL (default 30m) is discarded with its files; every request on it pushes its expires_at back. Workspaces live on this instance's disk and don't survive a restart.
+// @Tags Workspaces
+// @Produce json
+// @Success 201 {object} services.Workspace
+// @Failure 429 {object} models.ErrorResponse "WORKSPACE_MAX_ACTIVE workspaces are open (code workspaces_busy)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /workspaces [post]
+func (h *WorkspaceHandler) CreateWorkspace(c fiber.Ctx) error {
+	workspace, err := h.workspaces.Create()
+	if err != nil {
+		return workspaceError(c, err)
+	}
+
+	c.Location(strings.TrimSuffix(c.Path(), "/") + "/" + workspace.ID)
+	return c.Status(fiber.StatusCreated).JSON(workspace)
+}
+
+// GetWorkspace godoc
+// @Summary Retrieve a workspace and its files
+// @Tags Workspaces
+// @Produce json
+// @Param id path string true "Workspace identifier"
+// @Success 200 {object} services.Workspace
+// @Failure 404 {object} models.ErrorResponse
+// @Router /workspaces/{id} [get]
+func (h *WorkspaceHandler) GetWorkspace(c fiber.Ctx) error {
+	workspace, err := h.workspaces.Get(c.Params("id"))
+	if err != nil {
+		return workspaceError(c, err)
+	}
+	return c.JSON(workspace)
+}
+
+// DeleteWorkspace godoc
+// @Summary Discard a workspace and its files
+// @Tags Workspaces
+// @Param id path string true "Workspace identifier"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /workspaces/{id} [delete]
+func (h *WorkspaceHandler) DeleteWorkspace(c fiber.Ctx) error {
+	if err := h.workspaces.Delete(c.Params("id")); err != nil {
+		return workspaceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AddWorkspaceFile godoc
+// @Summary Add a file to a workspace
+// @Description Stores a file as-is, from a multipart "file" field (named by the "name" field, or the upload's file name) or a JSON body with base64 data or a URL. A file of the same name is replaced. Names are 1 to 128 letters, digits, '.', '-' or '_' and start with a letter or digit. A workspace holds at most WORKSPACE_MAX_FILES files and WORKSPACE_MAX_SIZE bytes.
+// @Tags Workspaces
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "Workspace identifier"
+// @Param request body models.WorkspaceFileRequest false "File as base64 or URL"
+// @Param file formData file false "File to store (multipart)"
+// @Param name formData string false "File name in the workspace (multipart, default: the upload's file name)"
+// @Success 201 {object} services.WorkspaceFile
+// @Failure 400 {object} models.ErrorResponse "Invalid name or data (code invalid_workspace_request)"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse "The workspace is full (code workspace_full)"
+// @Failure 503 {object} models.ErrorResponse
+// @Router /workspaces/{id}/files [post]
+func (h *WorkspaceHandler) AddWorkspaceFile(c fiber.Ctx) error {
+	var name string
+	var data []byte
+
+	contentType := strings.ToLower(c.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "multipart/form-data") {
+		fileHeader, err := formUpload(c)
+		if err != nil {
+			return respondWithError(c, err)
+		}
+		if data, err = readUpload(fileHeader); err != nil {
+			return respondWithError(c, err)
+		}
+		name = strings.TrimSpace(c.FormValue("name"))
+		if name == "" {
+			name = fileHeader.Filename
+		}
+	} else {
+		var req models.WorkspaceFileRequest
+		if err := c.Bind().Body(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid request body",
+				Details: err.Error(),
+			})
+		}
+		if strings.TrimSpace(req.Data) == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Missing 'data' field",
+			})
+		}
+
+		ctx, cancel := withDeadline(c, h.requestTimeout)
+		defer cancel()
+
+		var err error
+		if data, err = h.workspaces.Fetch(ctx, req.Data, req.IsURL); err != nil {
+			if isWorkspaceError(err) {
+				return workspaceError(c, err)
+			}
+			if isDownloadError(err) {
+				return downloadError(c, err, nil)
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Failed to download file",
+				Details: err.Error(),
+			})
+		}
+		name = req.Name
+	}
+
+	file, err := h.workspaces.AddFile(c.Params("id"), name, data)
+	if err != nil {
+		return workspaceError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(file)
+}
+
+// GetWorkspaceFile godoc
+// @Summary Download a workspace file
+// @Tags Workspaces
+// @Produce application/octet-stream
+// @Param id path string true "Workspace identifier"
+// @Param name path string true "File name"
+// @Success 200 {file} file "File content, with its detected MIME type"
+// @Failure 404 {object} models.ErrorResponse
+// @Router /workspaces/{id}/files/{name} [get]
+func (h *WorkspaceHandler) GetWorkspaceFile(c fiber.Ctx) error {
+	file, info, err := h.workspaces.OpenFile(c.Params("id"), c.Params("name"))
+	if err != nil {
+		return workspaceError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, info.MimeType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, info.Name))
+	return c.SendStream(file, int(info.Size))
+}
+
+// DeleteWorkspaceFile godoc
+// @Summary Remove a file from a workspace
+// @Tags Workspaces
+// @Param id path string true "Workspace identifier"
+// @Param name path string true "File name"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /workspaces/{id}/files/{name} [delete]
+func (h *WorkspaceHandler) DeleteWorkspaceFile(c fiber.Ctx) error {
+	if err := h.workspaces.DeleteFile(c.Params("id"), c.Params("name")); err != nil {
+		return workspaceError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ConvertWorkspaceFile godoc
+// @Summary Convert a workspace file
+// @Description Converts an audio file to WhatsApp audio or an image to a WhatsApp image, picked from the file's content, and stores the output as another file (by default the input's name with the output's extension). options takes the fields of a POST /convert/audio or /convert/image request other than data. The input is kept.
+// @Tags Workspaces
+// @Accept json
+// @Produce json
+// @Param id path string true "Workspace identifier"
+// @Param request body services.WorkspaceConvertRequest true "File and conversion options"
+// @Success 201 {object} services.WorkspaceFile
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse "The workspace is full (code workspace_full)"
+// @Failure 415 {object} models.ErrorResponse "Neither audio nor an image (code unsupported_input)"
+// @Failure 422 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /workspaces/{id}/convert [post]
+func (h *WorkspaceHandler) ConvertWorkspaceFile(c fiber.Ctx) error {
+	var req services.WorkspaceConvertRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid request body",
+			Details: err.Error(),
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	file, err := h.workspaces.Convert(ctx, c.Params("id"), &req)
+	if err != nil {
+		if isWorkspaceError(err) {
+			return workspaceError(c, err)
+		}
+		return conversionError(ctx, c, err, nil, "Conversion failed")
+	}
+	return c.Status(fiber.StatusCreated).JSON(file)
+}
+
+// ComposeWorkspaceVideo godoc
+// @Summary Compose a video from a workspace image and audio file
+// @Description Encodes the image as a still picture over the audio file, as an MP4 WhatsApp plays inline (H.264 baseline and AAC, as long as the audio and within VIDEO_MAX_OUTPUT_SIZE), and stores it as another file (by default the audio's name with .mp4). Requires the video feature.
+// @Tags Workspaces
+// @Accept json
+// @Produce json
+// @Param id path string true "Workspace identifier"
+// @Param request body services.WorkspaceComposeRequest true "Audio and image files"
+// @Success 201 {object} services.WorkspaceFile
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse "The workspace is full (code workspace_full)"
+// @Failure 415 {object} models.ErrorResponse "An input isn't audio or an image (code unsupported_input)"
+// @Failure 422 {object} models.ErrorResponse "The audio is too long for the size limit (code output_size_exceeded)"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /workspaces/{id}/compose [post]
+func (h *WorkspaceHandler) ComposeWorkspaceVideo(c fiber.Ctx) error {
+	var req services.WorkspaceComposeRequest
+	if err := c.Bind().Body(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid request body",
+			Details: err.Error(),
+		})
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	file, err := h.workspaces.Compose(ctx, c.Params("id"), &req)
+	if err != nil {
+		if isWorkspaceError(err) {
+			return workspaceError(c, err)
+		}
+		return videoConversionError(ctx, c, err, nil)
+	}
+	return c.Status(fiber.StatusCreated).JSON(file)
+}
+
+// CommitWorkspace godoc
+// @Summary Store workspace files in S3
+// @Description Uploads the listed files (default: all of them) under prefix, by default WORKSPACE_COMMIT_PREFIX followed by the workspace ID, tagged with the workspace-id metadata. The workspace is then discarded unless keep is set. If an upload fails the workspace stays open and the commit can be retried.
+// @Tags Workspaces
+// @Accept json
+// @Produce json
+// @Param id path string true "Workspace identifier"
+// @Param request body services.WorkspaceCommitRequest false "Files and storage options"
+// @Success 200 {object} services.WorkspaceCommit
+// @Failure 400 {object} models.ErrorResponse "Invalid prefix or empty workspace (code invalid_workspace_request)"
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 408 {object} models.ErrorResponse
+// @Failure 501 {object} models.ErrorResponse "S3 is not enabled"
+// @Failure 502 {object} models.ErrorResponse "An upload failed"
+// @Router /workspaces/{id}/commit [post]
+func (h *WorkspaceHandler) CommitWorkspace(c fiber.Ctx) error {
+	var req services.WorkspaceCommitRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().Body(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Invalid request body",
+				Details: err.Error(),
+			})
+		}
+	}
+
+	ctx, cancel := withDeadline(c, h.requestTimeout)
+	defer cancel()
+
+	commit, err := h.workspaces.Commit(ctx, c.Params("id"), &req)
+	if err != nil {
+		if isWorkspaceError(err) {
+			return workspaceError(c, err)
+		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return c.Status(fiber.StatusRequestTimeout).JSON(models.ErrorResponse{
+				Error:   "Request timeout",
+				Details: "Commit took too long",
+			})
+		}
+		return c.Status(fiber.StatusBadGateway).JSON(models.ErrorResponse{
+			Error:   "Commit failed",
+			Details: err.Error(),
+		})
+	}
+	return c.JSON(commit)
+}
+
+// isWorkspaceError reports whether err is about the workspace rather than
+// a conversion or an upload
+func isWorkspaceError(err error) bool {
+	return errors.Is(err, services.ErrWorkspaceNotFound) ||
+		errors.Is(err, services.ErrWorkspaceCapacity) ||
+		errors.Is(err, services.ErrWorkspaceFull) ||
+		errors.Is(err, services.ErrWorkspaceFileNotFound) ||
+		errors.Is(err, services.ErrInvalidWorkspaceRequest) ||
+		errors.Is(err, services.ErrWorkspaceStoreDisabled)
+}
+
+// workspaceError maps workspace manager failures to responses
+func workspaceError(c fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrWorkspaceNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   "Workspace not found",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrWorkspaceFileNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error:   "Workspace file not found",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrWorkspaceCapacity):
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(60))
+		return c.Status(fiber.StatusTooManyRequests).JSON(models.ErrorResponse{
+			Error:   "Too many workspaces",
+			Code:    "workspaces_busy",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrWorkspaceFull):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(models.ErrorResponse{
+			Error:   "Workspace is full",
+			Code:    "workspace_full",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidWorkspaceRequest):
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error:   "Invalid workspace request",
+			Code:    "invalid_workspace_request",
+			Details: err.Error(),
+		})
+	case errors.Is(err, services.ErrWorkspaceStoreDisabled):
+		return c.Status(fiber.StatusNotImplemented).JSON(models.ErrorResponse{
+			Error:   "S3 storage is not enabled",
+			Details: "Workspaces are committed to the S3 bucket",
+		})
+	}
+
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+		Error:   "Workspace operation failed",
+		Details: err.Error(),
+	})
+}
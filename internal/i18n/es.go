@@ -0,0 +1,155 @@
+package i18n
+
+// es translates the error messages into Spanish
+var es = map[string]string{
+	// Requests
+	"Invalid request":                    "Solicitud no válida",
+	"Invalid request body":               "Cuerpo de la solicitud no válido",
+	"Invalid JSON payload: %s":           "JSON no válido: %s",
+	"Invalid parameters":                 "Parámetros no válidos",
+	"Invalid %s value":                   "Valor no válido para %s",
+	"Invalid '%s' parameter":             "Parámetro '%s' no válido",
+	"%s must be an integer":              "%s debe ser un número entero",
+	"%s must be a number":                "%s debe ser un número",
+	"%s must be true or false":           "%s debe ser true o false",
+	"Invalid upload options":             "Opciones de carga no válidas",
+	"Missing 'data' field":               "Falta el campo 'data'",
+	"Missing required field: data":       "Falta el campo obligatorio: data",
+	"Missing file":                       "Falta el archivo",
+	"file field is required":             "el campo file es obligatorio",
+	"No file provided":                   "No se envió ningún archivo",
+	"Uploaded file is empty":             "El archivo enviado está vacío",
+	"Failed to open uploaded file":       "No se pudo abrir el archivo enviado",
+	"Failed to read uploaded file":       "No se pudo leer el archivo enviado",
+	"Failed to read uploaded file: %s":   "No se pudo leer el archivo enviado: %s",
+	"Failed to parse multipart form: %s": "No se pudo interpretar el formulario multipart: %s",
+	"Unsupported API version":            "Versión de la API no admitida",
+	"Supported versions: %s":             "Versiones admitidas: %s",
+	"Endpoint not found":                 "Endpoint no encontrado",
+	"Invalid timeout":                    "Tiempo de espera no válido",
+	"Invalid duration":                   "Duración no válida",
+	"Invalid ttl":                        "TTL no válido",
+
+	// Conversions
+	"Conversion failed":                 "Error en la conversión",
+	"Request timeout":                   "Se agotó el tiempo de la solicitud",
+	"Conversion took too long":          "La conversión tardó demasiado",
+	"Client closed request":             "El cliente cerró la solicitud",
+	"Audio too long":                    "Audio demasiado largo",
+	"Audio track not found":             "Pista de audio no encontrada",
+	"Image dimensions too large":        "Dimensiones de la imagen demasiado grandes",
+	"Video too long for the size limit": "Vídeo demasiado largo para el límite de tamaño",
+	"Output cannot fit the target size": "La salida no cabe en el tamaño deseado",
+	"Download host unavailable":         "Servidor de descarga no disponible",
+	"Invalid source":                    "Origen no válido",
+	"Source object not found":           "Objeto de origen no encontrado",
+	"Source object too large":           "Objeto de origen demasiado grande",
+	"Output quality too low":            "Calidad de salida demasiado baja",
+	"Unsupported input":                 "Entrada no admitida",
+	"Unsupported output format":         "Formato de salida no admitido",
+	"Unsupported compression":           "Compresión no admitida",
+	"Unsupported format":                "Formato no admitido",
+	"format must be opus or jpeg":       "format debe ser opus o jpeg",
+	"Format required":                   "Formato obligatorio",
+	"Unknown preset":                    "Preset desconocido",
+	"Invalid background":                "Color de fondo no válido",
+	"Invalid sticker metadata":          "Metadatos de sticker no válidos",
+	"Invalid sticker pack":              "Paquete de stickers no válido",
+	"Sticker too large":                 "Sticker demasiado grande",
+	"Sticker conversion failed":         "Error en la conversión del sticker",
+	"Feature not enabled":               "Función no habilitada",
+	"The '%s' feature is not enabled for this deployment or API key": "La función '%s' no está habilitada para este despliegue o clave de API",
+	"Inspection failed":                 "Error en la inspección",
+	"Inspection took too long":          "La inspección tardó demasiado",
+	"Unrecognized media":                "Medio no reconocido",
+	"Media can't be fingerprinted":      "No se puede generar la huella de este medio",
+	"Fingerprinting is not available":   "La huella de medios no está disponible",
+	"Media inspection is not available": "La inspección de medios no está disponible",
+	"Sample not found":                  "Muestra no encontrada",
+	"Available samples: %s":             "Muestras disponibles: %s",
+
+	// Batches
+	"Empty batch":                                      "Lote vacío",
+	"Batch too large":                                  "Lote demasiado grande",
+	"Maximum %s items per batch":                       "Máximo de %s elementos por lote",
+	"Maximum %s items per asynchronous batch":          "Máximo de %s elementos por lote asíncrono",
+	"Invalid batch options":                            "Opciones de lote no válidas",
+	"Batch conversion failed":                          "Error en la conversión por lotes",
+	"Batch job not found":                              "Trabajo por lotes no encontrado",
+	"Batch item not found":                             "Elemento del lote no encontrado",
+	"Job has items 0 to %s":                            "El trabajo tiene elementos de 0 a %s",
+	"Batch job cancelled":                              "Trabajo por lotes cancelado",
+	"Failed to start batch job":                        "No se pudo iniciar el trabajo por lotes",
+	"Too many batch jobs":                              "Demasiados trabajos por lotes",
+	"Invalid report format":                            "Formato de informe no válido",
+	"Supported report formats: jsonl, csv":             "Formatos de informe admitidos: jsonl, csv",
+	"Batch job still running":                          "El trabajo por lotes sigue en ejecución",
+	"The report is available once every item finished": "El informe está disponible cuando todos los elementos terminan",
+
+	// Storage
+	"Stored object didn't match the upload": "El objeto almacenado no coincide con la carga",
+	"S3 upload service is disabled":         "El servicio de carga a S3 está desactivado",
+	"Failed to start upload: %s":            "No se pudo iniciar la carga: %s",
+	"Upload failed":                         "Error en la carga",
+	"Upload not found":                      "Carga no encontrada",
+	"Upload ID is required":                 "El ID de la carga es obligatorio",
+	"Object key is required":                "La clave del objeto es obligatoria",
+	"Invalid object key":                    "Clave de objeto no válida",
+	"Object not found":                      "Objeto no encontrado",
+	"Object not found: %s":                  "Objeto no encontrado: %s",
+	"Object too large":                      "Objeto demasiado grande",
+	"Failed to fetch object":                "No se pudo obtener el objeto",
+	"Failed to delete object: %s":           "No se pudo eliminar el objeto: %s",
+	"Failed to name object: %s":             "No se pudo nombrar el objeto: %s",
+	"Failed to share object":                "No se pudo compartir el objeto",
+	"Failed to move object to trash":        "No se pudo mover el objeto a la papelera",
+	"Failed to restore object":              "No se pudo restaurar el objeto",
+	"Object not in trash":                   "El objeto no está en la papelera",
+	"Object was purged from the trash":      "El objeto se eliminó definitivamente de la papelera",
+	"Object already exists":                 "El objeto ya existe",
+	"Delete or move the object stored at the key since, then restore again": "Elimine o mueva el objeto guardado en la clave desde entonces y vuelva a restaurar",
+	"Soft delete is disabled":                                         "La eliminación reversible está desactivada",
+	"Set S3_SOFT_DELETE=true to move deleted objects to the trash":    "Configure S3_SOFT_DELETE=true para mover los objetos eliminados a la papelera",
+	"The S3 provider can't read objects back":                         "El proveedor S3 no puede volver a leer los objetos",
+	"Object restored but its metadata is unavailable":                 "Objeto restaurado, pero sus metadatos no están disponibles",
+	"The S3 provider can't presign URLs":                              "El proveedor S3 no puede prefirmar URLs",
+	"S3 diagnostics unavailable":                                      "Diagnóstico de S3 no disponible",
+	"WebSocket upgrade required":                                      "Se requiere WebSocket",
+	"Connect with a WebSocket client, or poll /upload/s3/status/{id}": "Conéctese con un cliente WebSocket o consulte /upload/s3/status/{id}",
+	"Failed to load source":                                           "No se pudo cargar el origen",
+	"Source not found":                                                "Origen no encontrado",
+	"The source ID is unknown or its retention period has expired":    "El ID del origen es desconocido o su período de retención expiró",
+	"Input mismatch":                                                  "La entrada no coincide",
+	"Input not recorded":                                              "La entrada no se grabó",
+	"Originals larger than %s bytes can't be converted on read":       "Los originales de más de %s bytes no se pueden convertir al leerlos",
+
+	// Operations
+	"Service unavailable":           "Servicio no disponible",
+	"Service under memory pressure": "Servicio con presión de memoria",
+	"Memory usage is above the configured high-water mark; retry shortly or send a smaller payload": "El uso de memoria supera el límite configurado; reintente en breve o envíe un contenido más pequeño",
+	"Endpoint under maintenance":                                      "Endpoint en mantenimiento",
+	"This endpoint is temporarily disabled, please retry later":       "Este endpoint está desactivado temporalmente, reintente más tarde",
+	"Endpoint not under maintenance":                                  "El endpoint no está en mantenimiento",
+	"Invalid endpoint":                                                "Endpoint no válido",
+	"Failed to update maintenance":                                    "No se pudo actualizar el mantenimiento",
+	"Invalid admin token":                                             "Token de administrador no válido",
+	"Invalid image data":                                              "Datos de imagen no válidos",
+	"No audit stamp found":                                            "No se encontró ningún sello de auditoría",
+	"Failed to read the audit stamp":                                  "Error al leer el sello de auditoría",
+	"Invalid replay token":                                            "Token de replay no válido",
+	"Recording not found":                                             "Grabación no encontrada",
+	"The recording ID is unknown or its retention period has expired": "El ID de la grabación es desconocido o su período de retención expiró",
+	"Failed to list recordings":                                       "No se pudieron listar las grabaciones",
+	"Failed to load recording":                                        "No se pudo cargar la grabación",
+	"Failed to rebuild the request":                                   "No se pudo reconstruir la solicitud",
+	"Replay failed":                                                   "Error en el replay",
+	"Rate limit exceeded":                                             "Límite de solicitudes superado",
+	"Maximum requests per minute for this tier: %s":                   "Máximo de solicitudes por minuto para este plan: %s",
+	"File too large for this tier":                                    "Archivo demasiado grande para este plan",
+	"Maximum request size for this tier: %s bytes":                    "Tamaño máximo de solicitud para este plan: %s bytes",
+	"API unavailable":                                                 "API no disponible",
+	"Internal Server Error":                                           "Error interno del servidor",
+	"Request Entity Too Large":                                        "Solicitud demasiado grande",
+	"Method Not Allowed":                                              "Método no permitido",
+	"Not Found":                                                       "No encontrado",
+}
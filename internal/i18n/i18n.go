@@ -0,0 +1,137 @@
+// Package i18n translates the user-facing error messages of the API into the
+// language a client asks for with Accept-Language.
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the language messages are written in
+const Default = "en"
+
+// catalogs hold the translations of each supported language, keyed by the
+// English message. A %s in a key matches any text, which the translation
+// repeats at its own %s (field names, wrapped errors).
+var catalogs = map[string]map[string]string{
+	"pt-BR": ptBR,
+	"es":    es,
+}
+
+// pattern is a catalog key with a %s placeholder
+type pattern struct {
+	re          *regexp.Regexp
+	translation string
+}
+
+// patterns are the placeholder keys of each catalog, compiled once
+var patterns = compilePatterns()
+
+func compilePatterns() map[string][]pattern {
+	compiled := make(map[string][]pattern, len(catalogs))
+	for language, catalog := range catalogs {
+		for key, translation := range catalog {
+			before, after, ok := strings.Cut(key, "%s")
+			if !ok {
+				continue
+			}
+			re := regexp.MustCompile("^" + regexp.QuoteMeta(before) + "(.*)" + regexp.QuoteMeta(after) + "$")
+			compiled[language] = append(compiled[language], pattern{re: re, translation: translation})
+		}
+		// Longer keys are more specific: "Invalid %s value" before "Invalid %s"
+		sort.Slice(compiled[language], func(i, j int) bool {
+			return len(compiled[language][i].re.String()) > len(compiled[language][j].re.String())
+		})
+	}
+	return compiled
+}
+
+// Languages returns the supported language tags, Default first
+func Languages() []string {
+	languages := []string{Default}
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages[1:])
+	return languages
+}
+
+// Language returns the supported language best matching an Accept-Language
+// header, Default when none does
+func Language(acceptLanguage string) string {
+	offers := make(map[string]string, len(catalogs)+1)
+	for _, language := range Languages() {
+		offers[language] = language
+	}
+	if language := Negotiate(acceptLanguage, offers); language != "" {
+		return language
+	}
+	return Default
+}
+
+// Translate returns message in language, or message itself when the
+// language or the message isn't in the catalog
+func Translate(language, message string) string {
+	catalog, ok := catalogs[language]
+	if !ok || message == "" {
+		return message
+	}
+	if translation, ok := catalog[message]; ok {
+		return translation
+	}
+	for _, p := range patterns[language] {
+		if match := p.re.FindStringSubmatch(message); match != nil {
+			return strings.Replace(p.translation, "%s", match[1], 1)
+		}
+	}
+	return message
+}
+
+// Negotiate returns the offered tag best matching an Accept-Language
+// header, or "" when none does. Tags match exactly or by primary language, so
+// "pt-PT" still gets "pt-BR" rather than the default.
+func Negotiate(header string, offers map[string]string) string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var ranges []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			quality = parsed
+		}
+		ranges = append(ranges, weighted{tag: tag, quality: quality})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	for _, r := range ranges {
+		var partial string
+		primary, _, _ := strings.Cut(r.tag, "-")
+		for offer := range offers {
+			if strings.EqualFold(offer, r.tag) {
+				return offer
+			}
+			offerPrimary, _, _ := strings.Cut(offer, "-")
+			// Prefer the bare language ("pt") over a sibling region
+			if strings.EqualFold(offerPrimary, primary) && (partial == "" || len(offer) < len(partial) || len(offer) == len(partial) && offer < partial) {
+				partial = offer
+			}
+		}
+		if partial != "" {
+			return partial
+		}
+	}
+	return ""
+}
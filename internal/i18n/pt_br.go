@@ -0,0 +1,155 @@
+package i18n
+
+// ptBR translates the error messages into Brazilian Portuguese
+var ptBR = map[string]string{
+	// Requests
+	"Invalid request":                    "Requisição inválida",
+	"Invalid request body":               "Corpo da requisição inválido",
+	"Invalid JSON payload: %s":           "JSON inválido: %s",
+	"Invalid parameters":                 "Parâmetros inválidos",
+	"Invalid %s value":                   "Valor inválido para %s",
+	"Invalid '%s' parameter":             "Parâmetro '%s' inválido",
+	"%s must be an integer":              "%s deve ser um número inteiro",
+	"%s must be a number":                "%s deve ser um número",
+	"%s must be true or false":           "%s deve ser true ou false",
+	"Invalid upload options":             "Opções de upload inválidas",
+	"Missing 'data' field":               "Campo 'data' ausente",
+	"Missing required field: data":       "Campo obrigatório ausente: data",
+	"Missing file":                       "Arquivo ausente",
+	"file field is required":             "o campo file é obrigatório",
+	"No file provided":                   "Nenhum arquivo enviado",
+	"Uploaded file is empty":             "O arquivo enviado está vazio",
+	"Failed to open uploaded file":       "Falha ao abrir o arquivo enviado",
+	"Failed to read uploaded file":       "Falha ao ler o arquivo enviado",
+	"Failed to read uploaded file: %s":   "Falha ao ler o arquivo enviado: %s",
+	"Failed to parse multipart form: %s": "Falha ao interpretar o formulário multipart: %s",
+	"Unsupported API version":            "Versão da API não suportada",
+	"Supported versions: %s":             "Versões suportadas: %s",
+	"Endpoint not found":                 "Endpoint não encontrado",
+	"Invalid timeout":                    "Tempo limite inválido",
+	"Invalid duration":                   "Duração inválida",
+	"Invalid ttl":                        "TTL inválido",
+
+	// Conversions
+	"Conversion failed":                 "Falha na conversão",
+	"Request timeout":                   "Tempo limite da requisição esgotado",
+	"Conversion took too long":          "A conversão demorou demais",
+	"Client closed request":             "O cliente encerrou a requisição",
+	"Audio too long":                    "Áudio longo demais",
+	"Audio track not found":             "Faixa de áudio não encontrada",
+	"Image dimensions too large":        "Dimensões da imagem grandes demais",
+	"Video too long for the size limit": "Vídeo longo demais para o limite de tamanho",
+	"Output cannot fit the target size": "A saída não cabe no tamanho desejado",
+	"Download host unavailable":         "Servidor de download indisponível",
+	"Invalid source":                    "Origem inválida",
+	"Source object not found":           "Objeto de origem não encontrado",
+	"Source object too large":           "Objeto de origem muito grande",
+	"Output quality too low":            "Qualidade da saída baixa demais",
+	"Unsupported input":                 "Entrada não suportada",
+	"Unsupported output format":         "Formato de saída não suportado",
+	"Unsupported compression":           "Compressão não suportada",
+	"Unsupported format":                "Formato não suportado",
+	"format must be opus or jpeg":       "format deve ser opus ou jpeg",
+	"Format required":                   "Formato obrigatório",
+	"Unknown preset":                    "Preset desconhecido",
+	"Invalid background":                "Cor de fundo inválida",
+	"Invalid sticker metadata":          "Metadados de figurinha inválidos",
+	"Invalid sticker pack":              "Pacote de figurinhas inválido",
+	"Sticker too large":                 "Figurinha grande demais",
+	"Sticker conversion failed":         "Falha na conversão da figurinha",
+	"Feature not enabled":               "Recurso não habilitado",
+	"The '%s' feature is not enabled for this deployment or API key": "O recurso '%s' não está habilitado para esta implantação ou chave de API",
+	"Inspection failed":                 "Falha na inspeção",
+	"Inspection took too long":          "A inspeção demorou demais",
+	"Unrecognized media":                "Mídia não reconhecida",
+	"Media can't be fingerprinted":      "Não é possível gerar a impressão digital desta mídia",
+	"Fingerprinting is not available":   "A impressão digital de mídia não está disponível",
+	"Media inspection is not available": "A inspeção de mídia não está disponível",
+	"Sample not found":                  "Amostra não encontrada",
+	"Available samples: %s":             "Amostras disponíveis: %s",
+
+	// Batches
+	"Empty batch":                                      "Lote vazio",
+	"Batch too large":                                  "Lote grande demais",
+	"Maximum %s items per batch":                       "Máximo de %s itens por lote",
+	"Maximum %s items per asynchronous batch":          "Máximo de %s itens por lote assíncrono",
+	"Invalid batch options":                            "Opções de lote inválidas",
+	"Batch conversion failed":                          "Falha na conversão em lote",
+	"Batch job not found":                              "Tarefa em lote não encontrada",
+	"Batch item not found":                             "Item do lote não encontrado",
+	"Job has items 0 to %s":                            "A tarefa tem itens de 0 a %s",
+	"Batch job cancelled":                              "Tarefa em lote cancelada",
+	"Failed to start batch job":                        "Falha ao iniciar a tarefa em lote",
+	"Too many batch jobs":                              "Tarefas em lote demais",
+	"Invalid report format":                            "Formato de relatório inválido",
+	"Supported report formats: jsonl, csv":             "Formatos de relatório suportados: jsonl, csv",
+	"Batch job still running":                          "A tarefa em lote ainda está em execução",
+	"The report is available once every item finished": "O relatório fica disponível quando todos os itens terminarem",
+
+	// Storage
+	"Stored object didn't match the upload": "O objeto armazenado não corresponde ao upload",
+	"S3 upload service is disabled":         "O serviço de upload S3 está desativado",
+	"Failed to start upload: %s":            "Falha ao iniciar o upload: %s",
+	"Upload failed":                         "Falha no upload",
+	"Upload not found":                      "Upload não encontrado",
+	"Upload ID is required":                 "O ID do upload é obrigatório",
+	"Object key is required":                "A chave do objeto é obrigatória",
+	"Invalid object key":                    "Chave de objeto inválida",
+	"Object not found":                      "Objeto não encontrado",
+	"Object not found: %s":                  "Objeto não encontrado: %s",
+	"Object too large":                      "Objeto grande demais",
+	"Failed to fetch object":                "Falha ao obter o objeto",
+	"Failed to delete object: %s":           "Falha ao excluir o objeto: %s",
+	"Failed to name object: %s":             "Falha ao nomear o objeto: %s",
+	"Failed to share object":                "Falha ao compartilhar o objeto",
+	"Failed to move object to trash":        "Falha ao mover o objeto para a lixeira",
+	"Failed to restore object":              "Falha ao restaurar o objeto",
+	"Object not in trash":                   "O objeto não está na lixeira",
+	"Object was purged from the trash":      "O objeto foi removido definitivamente da lixeira",
+	"Object already exists":                 "O objeto já existe",
+	"Delete or move the object stored at the key since, then restore again": "Exclua ou mova o objeto armazenado na chave desde então e restaure novamente",
+	"Soft delete is disabled":                                         "A exclusão reversível está desativada",
+	"Set S3_SOFT_DELETE=true to move deleted objects to the trash":    "Defina S3_SOFT_DELETE=true para mover os objetos excluídos para a lixeira",
+	"The S3 provider can't read objects back":                         "O provedor S3 não consegue ler os objetos de volta",
+	"Object restored but its metadata is unavailable":                 "Objeto restaurado, mas seus metadados estão indisponíveis",
+	"The S3 provider can't presign URLs":                              "O provedor S3 não consegue pré-assinar URLs",
+	"S3 diagnostics unavailable":                                      "Diagnóstico S3 indisponível",
+	"WebSocket upgrade required":                                      "É necessário usar WebSocket",
+	"Connect with a WebSocket client, or poll /upload/s3/status/{id}": "Conecte-se com um cliente WebSocket ou consulte /upload/s3/status/{id}",
+	"Failed to load source":                                           "Falha ao carregar a origem",
+	"Source not found":                                                "Origem não encontrada",
+	"The source ID is unknown or its retention period has expired":    "O ID da origem é desconhecido ou o período de retenção expirou",
+	"Input mismatch":                                                  "A entrada não confere",
+	"Input not recorded":                                              "A entrada não foi gravada",
+	"Originals larger than %s bytes can't be converted on read":       "Originais maiores que %s bytes não podem ser convertidos na leitura",
+
+	// Operations
+	"Service unavailable":           "Serviço indisponível",
+	"Service under memory pressure": "Serviço sob pressão de memória",
+	"Memory usage is above the configured high-water mark; retry shortly or send a smaller payload": "O uso de memória está acima do limite configurado; tente novamente em instantes ou envie um conteúdo menor",
+	"Endpoint under maintenance":                                      "Endpoint em manutenção",
+	"This endpoint is temporarily disabled, please retry later":       "Este endpoint está temporariamente desativado, tente novamente mais tarde",
+	"Endpoint not under maintenance":                                  "O endpoint não está em manutenção",
+	"Invalid endpoint":                                                "Endpoint inválido",
+	"Failed to update maintenance":                                    "Falha ao atualizar a manutenção",
+	"Invalid admin token":                                             "Token de administrador inválido",
+	"Invalid image data":                                              "Dados de imagem inválidos",
+	"No audit stamp found":                                            "Nenhum selo de auditoria encontrado",
+	"Failed to read the audit stamp":                                  "Falha ao ler o selo de auditoria",
+	"Invalid replay token":                                            "Token de replay inválido",
+	"Recording not found":                                             "Gravação não encontrada",
+	"The recording ID is unknown or its retention period has expired": "O ID da gravação é desconhecido ou o período de retenção expirou",
+	"Failed to list recordings":                                       "Falha ao listar as gravações",
+	"Failed to load recording":                                        "Falha ao carregar a gravação",
+	"Failed to rebuild the request":                                   "Falha ao reconstruir a requisição",
+	"Replay failed":                                                   "Falha no replay",
+	"Rate limit exceeded":                                             "Limite de requisições excedido",
+	"Maximum requests per minute for this tier: %s":                   "Máximo de requisições por minuto para este plano: %s",
+	"File too large for this tier":                                    "Arquivo grande demais para este plano",
+	"Maximum request size for this tier: %s bytes":                    "Tamanho máximo de requisição para este plano: %s bytes",
+	"API unavailable":                                                 "API indisponível",
+	"Internal Server Error":                                           "Erro interno do servidor",
+	"Request Entity Too Large":                                        "Requisição grande demais",
+	"Method Not Allowed":                                              "Método não permitido",
+	"Not Found":                                                       "Não encontrado",
+}
@@ -0,0 +1,497 @@
+// Package integration exercises a running API together with its real
+// dependencies (MinIO, Redis and a webhook receiver), following requests
+// through conversion, upload, alert delivery and status endpoints.
+//
+// The suite only talks HTTP, so it runs against the integration compose
+// profile (make integration-test) or any deployment configured like it.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/notify"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/samples"
+	"whats-convert-api/internal/services"
+)
+
+// defaultKeyPrefix groups every object written by the suite
+const defaultKeyPrefix = "integration/"
+
+// Options tunes an integration run
+type Options struct {
+	// APIURL is the base URL of the API under test
+	APIURL string
+
+	// ReceiverURL is the base URL of the mock webhook receiver, which the
+	// API must reach as NOTIFY_WEBHOOK_URL=<ReceiverURL>/hooks
+	ReceiverURL string
+
+	// KeyPrefix is prepended to every object key written by the suite
+	KeyPrefix string
+
+	// Wait bounds each poll for an asynchronous outcome (upload, batch job,
+	// alert delivery)
+	Wait time.Duration
+
+	// HTTPClient sends the suite's requests
+	HTTPClient *http.Client
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report aggregates every check of a run
+type Report struct {
+	Results []Result `json:"results"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+}
+
+// OK reports whether no check failed
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
+
+type check struct {
+	name string
+	run  func(ctx context.Context, s *suite) error
+}
+
+// suite holds the state shared between checks of a run
+type suite struct {
+	opts    Options
+	runID   string
+	written []string
+}
+
+// checks run in order; the alert check relies on the 5xx responses of the
+// circuit breaker check
+var checks = []check{
+	{"health", checkHealth},
+	{"convert_url_input", checkConvertURLInput},
+	{"conversion_cache_redis", checkConversionCacheRedis},
+	{"convert_and_upload", checkConvertAndUpload},
+	{"upload_status", checkUploadStatus},
+	{"batch_job_status", checkBatchJobStatus},
+	{"download_circuit_breaker", checkDownloadCircuitBreaker},
+	{"error_rate_webhook", checkErrorRateWebhook},
+}
+
+// newSuite fills opts' defaults and starts a run against the API
+func newSuite(opts Options) *suite {
+	opts.APIURL = strings.TrimRight(opts.APIURL, "/")
+	opts.ReceiverURL = strings.TrimRight(opts.ReceiverURL, "/")
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = defaultKeyPrefix
+	}
+	if opts.Wait <= 0 {
+		opts.Wait = time.Minute
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 2 * time.Minute}
+	}
+
+	return &suite{opts: opts, runID: uuid.New().String()}
+}
+
+// Run executes every check against the API and removes the objects it wrote
+func Run(ctx context.Context, opts Options) *Report {
+	s := newSuite(opts)
+	defer s.cleanup()
+
+	report := &Report{Results: make([]Result, 0, len(checks))}
+	for _, c := range checks {
+		start := time.Now()
+		err := c.run(ctx, s)
+
+		result := Result{Name: c.name, Duration: time.Since(start)}
+		if err != nil {
+			result.Error = err.Error()
+			report.Failed++
+		} else {
+			result.Passed = true
+			report.Passed++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+// key returns a unique object key for this run
+func (s *suite) key(name string) string {
+	key := s.opts.KeyPrefix + s.runID + "/" + name
+	s.written = append(s.written, key)
+	return key
+}
+
+// cleanup removes every object written during the run, ignoring errors
+func (s *suite) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, key := range s.written {
+		_, _ = s.do(ctx, http.MethodDelete, s.opts.APIURL+"/upload/s3/object/"+key, nil, nil)
+	}
+}
+
+// response is an answer read in full
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do sends a request, encoding body as JSON when it isn't nil, and decodes a
+// JSON answer into out when it isn't nil
+func (s *suite) do(ctx context.Context, method, target string, body, out any) (*response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: read body: %w", method, target, err)
+	}
+	r := &response{status: resp.StatusCode, header: resp.Header, body: raw}
+	if out != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return r, fmt.Errorf("%s %s: status %d, body isn't JSON: %.200s", method, target, r.status, raw)
+		}
+	}
+	return r, nil
+}
+
+// expect sends a request and fails unless it is answered with status
+func (s *suite) expect(ctx context.Context, status int, method, target string, body, out any) (*response, error) {
+	r, err := s.do(ctx, method, target, body, out)
+	if err != nil {
+		return r, err
+	}
+	if r.status != status {
+		return r, fmt.Errorf("%s %s: status %d, want %d: %.300s", method, target, r.status, status, r.body)
+	}
+	return r, nil
+}
+
+// poll calls probe until it reports done, an error, or opts.Wait passes
+func (s *suite) poll(ctx context.Context, what string, probe func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Wait)
+	defer cancel()
+
+	for {
+		done, err := probe()
+		if err != nil || done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: gave up after %s", what, s.opts.Wait)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// sample returns an embedded sample as plain base64
+func sample(kind string) (string, error) {
+	file, ok := samples.Get(kind)
+	if !ok {
+		return "", fmt.Errorf("no %s sample embedded", kind)
+	}
+	return base64.StdEncoding.EncodeToString(file.Data), nil
+}
+
+func checkHealth(ctx context.Context, s *suite) error {
+	var health models.HealthResponse
+	if _, err := s.expect(ctx, http.StatusOK, http.MethodGet, s.opts.APIURL+"/health", nil, &health); err != nil {
+		return err
+	}
+	if health.Status != "healthy" {
+		return fmt.Errorf("status = %q, want healthy", health.Status)
+	}
+
+	var upload models.S3HealthResponse
+	if _, err := s.expect(ctx, http.StatusOK, http.MethodGet, s.opts.APIURL+"/upload/s3/health", nil, &upload); err != nil {
+		return fmt.Errorf("S3 unreachable: %w", err)
+	}
+	if !upload.Healthy {
+		return fmt.Errorf("S3 unhealthy: %s", upload.Error)
+	}
+
+	// Alerts left over from an earlier run would satisfy the webhook check
+	if _, err := s.expect(ctx, http.StatusNoContent, http.MethodDelete, s.opts.ReceiverURL+"/hooks", nil, nil); err != nil {
+		return fmt.Errorf("webhook receiver unreachable: %w", err)
+	}
+	return nil
+}
+
+// checkConvertURLInput converts the API's own sample through the downloader
+func checkConvertURLInput(ctx context.Context, s *suite) error {
+	var audio services.AudioResponse
+	_, err := s.expect(ctx, http.StatusOK, http.MethodPost, s.opts.APIURL+"/convert/audio",
+		map[string]any{"data": s.opts.APIURL + "/samples/mp3", "is_url": true}, &audio)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(audio.Data, "data:audio/ogg") || audio.Size == 0 {
+		return fmt.Errorf("output = %q (%d bytes), want an Ogg/Opus data URI", audio.Data[:min(len(audio.Data), 40)], audio.Size)
+	}
+	return nil
+}
+
+// checkConversionCacheRedis converts the same image twice; the profile keeps
+// cached outputs in Redis only, so the second answer proves the round trip
+func checkConversionCacheRedis(ctx context.Context, s *suite) error {
+	var stats models.CacheStatsResponse
+	if _, err := s.expect(ctx, http.StatusOK, http.MethodGet, s.opts.APIURL+"/cache/stats", nil, &stats); err != nil {
+		return err
+	}
+	if stats.Redis == nil || !stats.Redis.Reachable {
+		return errors.New("/cache/stats doesn't report a reachable Redis (is REDIS_URL set?)")
+	}
+
+	image, err := sample("jpeg")
+	if err != nil {
+		return err
+	}
+	// A quality unique to the run keeps earlier runs' outputs out of the way
+	request := map[string]any{"data": image, "quality": 60 + time.Now().Nanosecond()%30}
+	for attempt := 1; attempt <= 2; attempt++ {
+		var converted services.ImageResponse
+		if _, err := s.expect(ctx, http.StatusOK, http.MethodPost, s.opts.APIURL+"/convert/image", request, &converted); err != nil {
+			return err
+		}
+		if converted.Cached != (attempt == 2) {
+			return fmt.Errorf("conversion %d: cached = %t, want %t (is the cache feature flag on?)", attempt, converted.Cached, attempt == 2)
+		}
+	}
+	return nil
+}
+
+// checkConvertAndUpload streams a conversion into the bucket and reads the
+// object back through the API
+func checkConvertAndUpload(ctx context.Context, s *suite) error {
+	audio, err := sample("webm")
+	if err != nil {
+		return err
+	}
+	key := s.key("voice.ogg")
+
+	var uploaded models.ConvertUploadResponse
+	_, err = s.expect(ctx, http.StatusOK, http.MethodPost, s.opts.APIURL+"/convert/audio/s3",
+		map[string]any{"data": audio, "upload": map[string]any{"key": key}}, &uploaded)
+	if err != nil {
+		return err
+	}
+	if uploaded.Upload == nil || uploaded.Upload.Key != key || uploaded.Upload.Size == 0 {
+		return fmt.Errorf("upload = %+v, want %s", uploaded.Upload, key)
+	}
+
+	var object providers.ObjectInfo
+	if _, err := s.expect(ctx, http.StatusOK, http.MethodGet, s.opts.APIURL+"/upload/s3/object/"+key, nil, &object); err != nil {
+		return err
+	}
+	if object.Size != uploaded.Upload.Size {
+		return fmt.Errorf("stored size = %d, uploaded %d", object.Size, uploaded.Upload.Size)
+	}
+	return nil
+}
+
+// checkUploadStatus starts a background upload and follows it to completion
+func checkUploadStatus(ctx context.Context, s *suite) error {
+	image, err := sample("jpeg")
+	if err != nil {
+		return err
+	}
+	key := s.key("sample.jpg")
+
+	var started models.S3UploadResponse
+	_, err = s.expect(ctx, http.StatusAccepted, http.MethodPost, s.opts.APIURL+"/upload/s3/base64",
+		map[string]any{"data": "data:image/jpeg;base64," + image, "key": key}, &started)
+	if err != nil {
+		return err
+	}
+	if started.UploadID == "" {
+		return errors.New("no upload_id in the answer")
+	}
+
+	var status models.S3UploadStatusResponse
+	return s.poll(ctx, "upload "+started.UploadID, func() (bool, error) {
+		r, err := s.do(ctx, http.MethodGet, s.opts.APIURL+"/upload/s3/status/"+started.UploadID+"/wait?timeout=10s", nil, &status)
+		if err != nil || r.status == http.StatusAccepted {
+			return false, err
+		}
+		if r.status != http.StatusOK {
+			return false, fmt.Errorf("status %d: %.300s", r.status, r.body)
+		}
+		switch {
+		case status.Status != "completed":
+			return false, fmt.Errorf("upload ended %s: %s", status.Status, status.Error)
+		case status.Result == nil || status.Result.Key != key:
+			return false, fmt.Errorf("result = %+v, want key %s", status.Result, key)
+		}
+		return true, nil
+	})
+}
+
+// checkBatchJobStatus runs an asynchronous batch job and fetches its output
+func checkBatchJobStatus(ctx context.Context, s *suite) error {
+	image, err := sample("jpeg")
+	if err != nil {
+		return err
+	}
+
+	var job models.BatchJobResponse
+	_, err = s.expect(ctx, http.StatusAccepted, http.MethodPost, s.opts.APIURL+"/convert/batch/image/async",
+		[]map[string]any{{"data": image}, {"data": s.opts.APIURL + "/samples/jpeg", "is_url": true}}, &job)
+	if err != nil {
+		return err
+	}
+	defer s.do(context.WithoutCancel(ctx), http.MethodDelete, s.opts.APIURL+job.StatusURL, nil, nil)
+
+	err = s.poll(ctx, "batch job "+job.JobID, func() (bool, error) {
+		if _, err := s.expect(ctx, http.StatusOK, http.MethodGet, s.opts.APIURL+job.StatusURL, nil, &job); err != nil {
+			return false, err
+		}
+		switch job.Status {
+		case string(services.BatchJobStatusQueued), string(services.BatchJobStatusRunning):
+			return false, nil
+		case string(services.BatchJobStatusCompleted):
+			if job.Failed > 0 {
+				return false, fmt.Errorf("%d of %d items failed", job.Failed, job.Total)
+			}
+			return true, nil
+		}
+		return false, fmt.Errorf("job ended %s", job.Status)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range job.Items {
+		var converted services.ImageResponse
+		if _, err := s.expect(ctx, http.StatusOK, http.MethodGet, s.opts.APIURL+item.ResultURL, nil, &converted); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(converted.Data, "data:image/jpeg") {
+			return fmt.Errorf("item %d isn't a JPEG data URI", item.Index)
+		}
+	}
+	return nil
+}
+
+// checkDownloadCircuitBreaker downloads from a host that keeps failing until
+// its circuit opens, then finds the host reported open in /stats
+func checkDownloadCircuitBreaker(ctx context.Context, s *suite) error {
+	failing := s.opts.ReceiverURL + "/status/503"
+	host := strings.ToLower(hostOf(failing))
+
+	var opened *models.ErrorResponse
+	for attempt := 1; attempt <= 20 && opened == nil; attempt++ {
+		var answer models.ErrorResponse
+		r, err := s.do(ctx, http.MethodPost, s.opts.APIURL+"/convert/audio", map[string]any{"data": failing, "is_url": true}, &answer)
+		if err != nil {
+			return err
+		}
+		switch {
+		case r.status == http.StatusServiceUnavailable && answer.Code == "download_host_unavailable":
+			if r.header.Get("Retry-After") == "" {
+				return errors.New("refused download without Retry-After")
+			}
+			opened = &answer
+		case r.status < http.StatusInternalServerError:
+			return fmt.Errorf("download from a failing host answered %d: %.300s", r.status, r.body)
+		}
+	}
+	if opened == nil {
+		return errors.New("circuit never opened (is DOWNLOAD_BREAKER_THRESHOLD 0?)")
+	}
+
+	var stats models.StatsResponse
+	if _, err := s.expect(ctx, http.StatusOK, http.MethodGet, s.opts.APIURL+"/stats", nil, &stats); err != nil {
+		return err
+	}
+	if stats.Downloads == nil {
+		return errors.New("/stats reports no downloads")
+	}
+	for _, circuit := range stats.Downloads.Circuits {
+		if circuit.Host == host {
+			if circuit.State != services.CircuitOpen || circuit.Rejected == 0 {
+				return fmt.Errorf("circuit of %s = %+v, want open with rejected downloads", host, circuit)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("/stats lists no circuit for %s", host)
+}
+
+// checkErrorRateWebhook waits for the error-rate alert the previous check's
+// 5xx burst raises to reach the webhook receiver
+func checkErrorRateWebhook(ctx context.Context, s *suite) error {
+	type delivery struct {
+		Body notify.Alert `json:"body"`
+	}
+
+	return s.poll(ctx, "error_rate alert", func() (bool, error) {
+		var deliveries []delivery
+		if _, err := s.expect(ctx, http.StatusOK, http.MethodGet, s.opts.ReceiverURL+"/hooks", nil, &deliveries); err != nil {
+			return false, err
+		}
+		for _, d := range deliveries {
+			if d.Body.Condition == notify.ErrorRate && d.Body.Severity == notify.Critical {
+				if d.Body.Summary == "" || d.Body.Host == "" {
+					return false, fmt.Errorf("alert without summary or host: %+v", d.Body)
+				}
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// hostOf returns the host:port of rawURL, as the downloader keys circuits
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
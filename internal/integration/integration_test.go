@@ -0,0 +1,31 @@
+package integration
+
+import (
+	"os"
+	"testing"
+)
+
+// TestIntegration runs the suite as subtests against the API at
+// INTEGRATION_API_URL and the receiver at INTEGRATION_RECEIVER_URL, as
+// started by the integration compose profile. Skipped when unset.
+func TestIntegration(t *testing.T) {
+	apiURL := os.Getenv("INTEGRATION_API_URL")
+	if apiURL == "" {
+		t.Skip("INTEGRATION_API_URL not set")
+	}
+	receiverURL := os.Getenv("INTEGRATION_RECEIVER_URL")
+	if receiverURL == "" {
+		receiverURL = "http://localhost:9090"
+	}
+
+	s := newSuite(Options{APIURL: apiURL, ReceiverURL: receiverURL})
+	t.Cleanup(s.cleanup)
+
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.run(t.Context(), s); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
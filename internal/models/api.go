@@ -1,41 +1,131 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
+	"whats-convert-api/internal/buildinfo"
 	"whats-convert-api/internal/services"
 )
 
 // APIInfoResponse describes the metadata returned by GET /api.
 type APIInfoResponse struct {
-	Name      string            `json:"name" example:"WhatsApp Media Converter API"`
-	Version   string            `json:"version" example:"1.0.0"`
-	Endpoints map[string]string `json:"endpoints"`
+	Name        string            `json:"name" example:"WhatsApp Media Converter API"`
+	Description string            `json:"description" example:"High-performance media conversion API delivering WhatsApp-ready audio and images."`
+	Language    string            `json:"language" example:"en"` // Language of description, also sent as Content-Language
+	Contact     *APIContact       `json:"contact,omitempty"`
+	Version     string            `json:"version" example:"1.0.0"`
+	APIVersions []string          `json:"api_versions" example:"/v1"`
+	Endpoints   map[string]string `json:"endpoints"`
+}
+
+// APIContact is who runs this deployment (API_CONTACT_*).
+type APIContact struct {
+	Name  string `json:"name,omitempty" example:"Guilherme Jansen"`
+	Email string `json:"email,omitempty" example:"suporte@setupautomatizado.com.br"`
+	URL   string `json:"url,omitempty" example:"https://github.com/guilhermejansen"`
+}
+
+// CapabilitiesResponse reports the runtime features available on this instance.
+type CapabilitiesResponse struct {
+	Tools     map[string]bool      `json:"tools"`
+	Sandbox   services.SandboxInfo `json:"sandbox"`
+	MockMode  bool                 `json:"mock_mode" example:"false"`
+	S3Enabled bool                 `json:"s3_enabled" example:"true"`
+
+	// Features lists the feature flags as evaluated for the caller's API key
+	Features map[string]bool `json:"features"`
+
+	// Tier is the service level of the caller's API key (TIERS_FILE, API_KEY_TIERS)
+	Tier *services.Tier `json:"tier,omitempty"`
+}
+
+// VersionResponse identifies exactly what is deployed, as returned by GET /version.
+type VersionResponse struct {
+	buildinfo.Info
+
+	// ToolVersions maps installed encoders to their reported versions
+	ToolVersions map[string]string `json:"tool_versions"`
+
+	// Features lists the feature flags as evaluated for the caller's API key
+	Features map[string]bool `json:"features"`
 }
 
 // ErrorResponse represents a generic error payload used across endpoints.
 type ErrorResponse struct {
-	Error   string `json:"error" example:"Invalid request"`
-	Details string `json:"details,omitempty" example:"Missing 'data' field"`
+	Error   string                   `json:"error" example:"Invalid request"`
+	Code    string                   `json:"code,omitempty" example:"pixel_limit_exceeded"`
+	Details string                   `json:"details,omitempty" example:"Missing 'data' field"`
+	Trace   []services.CommandRecord `json:"trace,omitempty"`
+
+	// SourceID identifies the retained input of a failed conversion (RETAIN_FAILED_SOURCES)
+	SourceID string `json:"source_id,omitempty" example:"3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c"`
 }
 
 // BatchAudioResponse models the batch conversion response for audio payloads.
 type BatchAudioResponse struct {
 	Results []*services.AudioResponse `json:"results"`
 	Count   int                       `json:"count" example:"2"`
+	Trace   []services.CommandRecord  `json:"trace,omitempty"`
+	Timings *services.Timings         `json:"timings,omitempty"`
 }
 
 // BatchImageResponse models the batch conversion response for image payloads.
 type BatchImageResponse struct {
 	Results []*services.ImageResponse `json:"results"`
 	Count   int                       `json:"count" example:"2"`
+	Trace   []services.CommandRecord  `json:"trace,omitempty"`
+	Timings *services.Timings         `json:"timings,omitempty"`
+}
+
+// BatchJobResponse reports the progress of an asynchronous batch job.
+type BatchJobResponse struct {
+	JobID      string                `json:"job_id" example:"0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a"`
+	Kind       string                `json:"kind" example:"audio"`
+	Status     string                `json:"status" example:"running"` // queued, running, completed, failed (every item failed) or cancelled
+	StatusURL  string                `json:"status_url" example:"/convert/batch/jobs/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a"`
+	Total      int                   `json:"total" example:"200"`
+	Pending    int                   `json:"pending" example:"150"`
+	Processing int                   `json:"processing" example:"8"`
+	Completed  int                   `json:"completed" example:"40"`
+	Failed     int                   `json:"failed" example:"2"`
+	Cancelled  int                   `json:"cancelled" example:"0"`
+	Progress   float64               `json:"progress" example:"21"` // Percentage of items finished
+	CreatedAt  time.Time             `json:"created_at" example:"2024-03-31T12:00:00Z"`
+	EndTime    *time.Time            `json:"end_time,omitempty" example:"2024-03-31T12:03:20Z"`
+	ReportURL  string                `json:"report_url,omitempty" example:"/convert/batch/jobs/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a/report"`   // Report of every item, once the job finished
+	Report     *services.BatchReport `json:"report,omitempty"`                                                                                 // Copy of the report uploaded to S3
+	ArchiveURL string                `json:"archive_url,omitempty" example:"/convert/batch/jobs/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a/archive"` // tar.zst of every output, once the job finished
+	Archive    *services.BatchReport `json:"archive,omitempty"`                                                                                // Copy of the archive uploaded to S3 (archive=tar.zst)
+	Items      []BatchJobItem        `json:"items"`
+}
+
+// BatchJobItem reports one item of an asynchronous batch job.
+type BatchJobItem struct {
+	Index      int    `json:"index" example:"0"`
+	Status     string `json:"status" example:"completed"` // pending, processing, completed, failed or cancelled
+	Error      string `json:"error,omitempty" example:"ffmpeg failed: exit status 1"`
+	Code       string `json:"code,omitempty" example:"duration_limit_exceeded"`
+	DurationMs int64  `json:"duration_ms,omitempty" example:"850"`
+	ResultURL  string `json:"result_url,omitempty" example:"/convert/batch/jobs/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a/items/0"` // Converted output of a completed item
 }
 
 // ConverterStats provides aggregated counters for conversion services.
 type ConverterStats struct {
 	TotalConversions    int64 `json:"total_conversions" example:"1280"`
 	FailedConversions   int64 `json:"failed_conversions" example:"12"`
+	SkippedConversions  int64 `json:"skipped_conversions" example:"40"`
 	AvgConversionTimeMS int64 `json:"avg_conversion_time_ms" example:"135"`
+
+	Variants map[string]EncoderVariantStats `json:"variants,omitempty"` // Per encoder variant (stable, candidate) while a candidate is configured
+}
+
+// EncoderVariantStats compares the encodes of one encoder variant.
+type EncoderVariantStats struct {
+	Conversions         int64 `json:"conversions" example:"128"`
+	FailedConversions   int64 `json:"failed_conversions" example:"1"`
+	AvgConversionTimeMS int64 `json:"avg_conversion_time_ms" example:"120"` // Encode time of successful conversions
+	AvgOutputSize       int64 `json:"avg_output_size" example:"38912"`      // Bytes
 }
 
 // ImageConverterStats extends ConverterStats with engine breakdown metrics.
@@ -45,6 +135,29 @@ type ImageConverterStats struct {
 	AvgConversionTimeMS int64 `json:"avg_conversion_time_ms" example:"110"`
 	VipsConversions     int64 `json:"vips_conversions" example:"620"`
 	FFmpegConversions   int64 `json:"ffmpeg_conversions" example:"360"`
+	SkippedConversions  int64 `json:"skipped_conversions" example:"25"`
+}
+
+// VideoConverterStats reports video conversion metrics.
+type VideoConverterStats struct {
+	TotalConversions    int64 `json:"total_conversions" example:"42"`
+	FailedConversions   int64 `json:"failed_conversions" example:"2"`
+	AvgConversionTimeMS int64 `json:"avg_conversion_time_ms" example:"8400"`
+
+	Variants map[string]EncoderVariantStats `json:"variants,omitempty"` // Per encoder variant (stable, candidate) while a candidate is configured
+}
+
+// TempFileStats reports payload spilling and temp janitor metrics.
+type TempFileStats struct {
+	SpillEnabled     bool   `json:"spill_enabled" example:"true"`
+	SpillDir         string `json:"spill_dir,omitempty" example:"/tmp"`
+	SpillThreshold   int64  `json:"spill_threshold_bytes,omitempty" example:"10485760"`
+	SpillBudget      int64  `json:"spill_budget_bytes,omitempty" example:"2147483648"` // 0 = unlimited
+	ActiveSpills     int64  `json:"active_spills" example:"2"`
+	ActiveSpillBytes int64  `json:"active_spill_bytes" example:"73400320"`
+	Spilled          int64  `json:"spilled" example:"38"`    // Payloads spilled since start
+	OverBudget       int64  `json:"over_budget" example:"1"` // Payloads kept in memory because the budget was used up
+	Swept            int64  `json:"swept" example:"0"`       // Leftover temporary files deleted by the janitor
 }
 
 // AudioHealthMetrics aggregates health metrics for the audio converter.
@@ -68,6 +181,7 @@ type ImageHealthMetrics struct {
 type HealthResponse struct {
 	Status    string             `json:"status" example:"healthy"`
 	Timestamp int64              `json:"timestamp" example:"1700000000"`
+	ReadOnly  bool               `json:"read_only,omitempty"` // Conversions and uploads answer 503 (READ_ONLY)
 	Audio     AudioHealthMetrics `json:"audio"`
 	Image     ImageHealthMetrics `json:"image"`
 }
@@ -76,7 +190,69 @@ type HealthResponse struct {
 type StatsResponse struct {
 	Audio     ConverterStats      `json:"audio"`
 	Image     ImageConverterStats `json:"image"`
-	Timestamp int64               `json:"timestamp" example:"1700000000"`
+	Video     VideoConverterStats `json:"video"`
+	TempFiles *TempFileStats      `json:"temp_files,omitempty"` // Present while spilling or the temp janitor is enabled
+
+	ConversionCache *services.ConversionCacheStats `json:"conversion_cache,omitempty"` // Present while the conversion cache is enabled
+	Queue           *QueueStats                    `json:"queue,omitempty"`            // Conversions holding or waiting for a worker
+	Downloads       *DownloadStats                 `json:"downloads,omitempty"`        // URL downloads and their hosts' circuit breakers
+	PostProcessors  []services.PostProcessorStats  `json:"post_processors,omitempty"`  // Present while POST_PROCESSORS_* are set
+	Runtime         RuntimeStats                   `json:"runtime"`                    // Goroutines and heap, for spotting leaks
+
+	Timestamp int64 `json:"timestamp" example:"1700000000"`
+}
+
+// QueueStats reports the conversions holding or waiting for a worker.
+type QueueStats struct {
+	Workers         int            `json:"workers" example:"8"`
+	Active          int32          `json:"active" example:"8"`          // Conversions holding a worker
+	Waiting         int32          `json:"waiting" example:"12"`        // Conversions waiting for one
+	FairQueuing     bool           `json:"fair_queuing" example:"true"` // Waiting conversions are served fairly across API keys
+	WaitingByTenant map[string]int `json:"waiting_by_tenant"`           // Waiting conversions per API key, keyed like GET /usage
+}
+
+// RuntimeStats reports the process's goroutines and heap. Soak tests watch
+// them for growth that outlives the load.
+type RuntimeStats struct {
+	Goroutines     int    `json:"goroutines" example:"42"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes" example:"25165824"` // Live and not yet collected heap objects
+	HeapObjects    uint64 `json:"heap_objects" example:"120000"`
+	SysBytes       uint64 `json:"sys_bytes" example:"73400320"` // Memory obtained from the OS
+	NumGC          uint32 `json:"num_gc" example:"118"`
+}
+
+// DownloadStats reports URL downloads and the circuit breakers of their hosts.
+type DownloadStats struct {
+	Total          int64                  `json:"total" example:"2140"`
+	Failed         int64                  `json:"failed" example:"37"`
+	Bytes          int64                  `json:"bytes" example:"734003200"`
+	AvgDownloadMS  int64                  `json:"avg_download_time_ms" example:"180"`
+	CircuitBreaker bool                   `json:"circuit_breaker" example:"true"` // DOWNLOAD_BREAKER_THRESHOLD > 0
+	Circuits       []services.HostCircuit `json:"circuits"`                       // Hosts that failed recently, open circuits first
+}
+
+// CacheStatsResponse reports cache usage, as returned by GET /cache/stats.
+type CacheStatsResponse struct {
+	Conversion *services.ConversionCacheStats `json:"conversion,omitempty"` // Present while the conversion cache is enabled
+	Media      *services.MediaCacheStats      `json:"media,omitempty"`      // Present while the convert-on-read endpoint is enabled
+	Redis      *RedisStatus                   `json:"redis,omitempty"`      // Present while conversion outputs are shared through Redis
+
+	Timestamp int64 `json:"timestamp" example:"1700000000"`
+}
+
+// RedisStatus is the outcome of a Redis round trip
+type RedisStatus struct {
+	Reachable bool    `json:"reachable" example:"true"`
+	LatencyMS float64 `json:"latency_ms" example:"0.42"`
+}
+
+// ConvertUploadResponse is returned by the convert-and-upload endpoints once
+// the converted output is stored.
+type ConvertUploadResponse struct {
+	Success bool                    `json:"success" example:"true"`
+	Audio   *services.AudioResponse `json:"audio,omitempty"` // Conversion metadata (/convert/audio/s3), without data
+	Video   *services.VideoResponse `json:"video,omitempty"` // Conversion metadata (/convert/video/s3), without data
+	Upload  *S3UploadResult         `json:"upload"`
 }
 
 // MessageResponse represents a simple success payload with contextual message.
@@ -95,6 +271,18 @@ type S3ServiceStats struct {
 	SuccessRate       float64   `json:"success_rate" example:"98.33"`
 	AvgUploadTime     string    `json:"avg_upload_time" example:"1.2s"`
 	LastUpload        time.Time `json:"last_upload" example:"2024-03-31T12:00:00Z"`
+
+	// Background health checks and automatic reconnects
+	ProviderHealthy     bool       `json:"provider_healthy" example:"true"`
+	HealthCheckFailures int64      `json:"health_check_failures" example:"0"`
+	Reconnects          int64      `json:"reconnects" example:"1"`
+	FailedReconnects    int64      `json:"failed_reconnects" example:"2"`
+	LastReconnect       *time.Time `json:"last_reconnect,omitempty" example:"2024-03-31T11:58:00Z"`
+
+	// Upload verification (S3_VERIFY_UPLOADS)
+	VerifiedUploads        int64 `json:"verified_uploads" example:"236"`
+	VerificationMismatches int64 `json:"verification_mismatches" example:"1"` // Stored objects that didn't match, retried or not
+	VerificationFailures   int64 `json:"verification_failures" example:"0"`   // Uploads failed after every retry
 }
 
 // S3UploadManagerStats represents queue health for the concurrent upload manager.
@@ -104,6 +292,11 @@ type S3UploadManagerStats struct {
 	MaxConcurrent  int            `json:"max_concurrent" example:"3"`
 	StatusCounts   map[string]int `json:"status_counts"`
 	CapacityUsed   float64        `json:"capacity_used" example:"33.33"`
+	Workers        int            `json:"workers" example:"3"`         // Upload pool size (S3_UPLOAD_WORKERS)
+	ActiveWorkers  int            `json:"active_workers" example:"1"`  // Uploads running now
+	QueuedUploads  int            `json:"queued_uploads" example:"0"`  // Accepted uploads waiting for a worker
+	FailedUploads  int64          `json:"failed_uploads" example:"0"`  // Uploads that ended in error or cancellation
+	AvgUploadMS    float64        `json:"avg_upload_ms" example:"850"` // Moving average upload time
 }
 
 // S3StatsResponse merges provider and upload manager metrics.
@@ -119,3 +312,102 @@ type S3HealthResponse struct {
 	Message string `json:"message,omitempty" example:"S3 service is operational"`
 	Error   string `json:"error,omitempty" example:"failed to connect to bucket"`
 }
+
+// ReplayResponse reports the outcome of re-running a retained failed conversion.
+type ReplayResponse struct {
+	SourceID      string                   `json:"source_id" example:"3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c"`
+	Kind          string                   `json:"kind" example:"audio"`
+	OriginalError string                   `json:"original_error" example:"conversion failed: ffmpeg error: exit status 1"`
+	RetainedAt    time.Time                `json:"retained_at"`
+	ExpiresAt     time.Time                `json:"expires_at"`
+	Audio         *services.AudioResponse  `json:"audio,omitempty"`
+	Image         *services.ImageResponse  `json:"image,omitempty"`
+	Trace         []services.CommandRecord `json:"trace"`
+}
+
+// RecordingsResponse lists the failed requests kept by REQUEST_RECORDING.
+type RecordingsResponse struct {
+	Recordings []services.RecordedRequest `json:"recordings"`
+	Count      int                        `json:"count" example:"1"`
+}
+
+// MaintenanceRequest puts an endpoint under maintenance.
+type MaintenanceRequest struct {
+	Message  string `json:"message,omitempty" example:"Video conversions are paused while we investigate elevated failures"` // Returned in details of the 503
+	Duration string `json:"duration,omitempty" example:"30m"`                                                                // Lift automatically after this duration or seconds (default: until deleted)
+}
+
+// MaintenanceResponse lists the endpoints under maintenance.
+type MaintenanceResponse struct {
+	Endpoints []services.MaintenanceWindow `json:"endpoints"`
+	Count     int                          `json:"count" example:"1"`
+}
+
+// MigrationRequest starts copying objects from one provider to another.
+type MigrationRequest struct {
+	Source            string `json:"source,omitempty" example:"default"`                // Provider to read from: default (the API's bucket) or a S3_MIGRATION_PROVIDERS name
+	Destination       string `json:"destination" example:"r2"`                          // Provider to write to, named the same way
+	Prefix            string `json:"prefix,omitempty" example:"uploads/2024/"`          // Only keys starting with it (default: the whole bucket)
+	Transcode         bool   `json:"transcode,omitempty" example:"false"`               // Convert images to WhatsApp JPEG and audio to Opus on the way; other objects are copied
+	Overwrite         bool   `json:"overwrite,omitempty" example:"false"`               // Copy objects the destination already has (default: skip them)
+	Concurrency       int    `json:"concurrency,omitempty" example:"4"`                 // Objects copied at once, 1-32 (default 4)
+	MaxBytesPerSecond int64  `json:"max_bytes_per_second,omitempty" example:"10485760"` // Read rate across all workers (default: unthrottled)
+}
+
+// MigrationListResponse lists the running and recent migrations.
+type MigrationListResponse struct {
+	Migrations []*services.Migration `json:"migrations"`
+	Providers  []string              `json:"providers" example:"default,r2"` // Names source and destination accept
+	Count      int                   `json:"count" example:"1"`
+}
+
+// AuditStampRequest is an image to read the audit stamp of.
+type AuditStampRequest struct {
+	Data string `json:"data" example:"data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD"` // base64 or data URI of the image as found
+}
+
+// RequestReplayResponse compares a recorded failed request with its replay.
+type RequestReplayResponse struct {
+	RecordingID    string          `json:"recording_id" example:"3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c"`
+	RecordedAt     time.Time       `json:"recorded_at"`
+	Method         string          `json:"method" example:"POST"`
+	Path           string          `json:"path" example:"/v1/convert/audio"`
+	OriginalStatus int             `json:"original_status" example:"500"`
+	OriginalError  string          `json:"original_error,omitempty" example:"Conversion failed"`
+	OriginalCode   string          `json:"original_code,omitempty" example:"conversion_failed"`
+	Status         int             `json:"status" example:"200"`
+	Fixed          bool            `json:"fixed" example:"true"` // The replay no longer fails
+	ContentType    string          `json:"content_type,omitempty" example:"application/json"`
+	Size           int             `json:"size" example:"5342"`
+	DurationMS     int64           `json:"duration_ms" example:"412"`
+	Response       json.RawMessage `json:"response,omitempty" swaggertype:"object"` // JSON responses only
+}
+
+// SampleResponse describes an embedded sample file returned by /samples.
+type SampleResponse struct {
+	Type     string `json:"type" example:"mp3"`
+	Filename string `json:"filename" example:"sample.mp3"`
+	MimeType string `json:"mime_type" example:"audio/mpeg"`
+	Size     int    `json:"size" example:"3952"`
+	Endpoint string `json:"endpoint" example:"/convert/audio"` // Conversion endpoint the sample suits
+	URL      string `json:"url" example:"/samples/mp3"`
+	Data     string `json:"data,omitempty" example:"data:audio/mpeg;base64,//sQxAAAAAAAAAAAAAAAAAAAAAAA"` // Data URI, with ?encoding=base64 only
+}
+
+// SamplesResponse lists the embedded sample files.
+type SamplesResponse struct {
+	Samples []SampleResponse `json:"samples"`
+}
+
+// PresetsResponse lists the conversion presets defined in PRESETS_FILE.
+type PresetsResponse struct {
+	Presets []services.Preset `json:"presets"`
+	Count   int               `json:"count" example:"4"`
+}
+
+// WorkspaceFileRequest adds a file to a workspace from a JSON body.
+type WorkspaceFileRequest struct {
+	Name  string `json:"name" example:"cover.png"`                          // File name in the workspace: letters, digits, '.', '-' or '_'
+	Data  string `json:"data" example:"data:image/png;base64,iVBORw0KGgo="` // Base64 content (plain or data URI), or a URL with is_url
+	IsURL bool   `json:"is_url,omitempty" example:"false"`                  // Download data instead of decoding it
+}
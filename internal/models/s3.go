@@ -1,10 +1,16 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"whats-convert-api/internal/providers"
+)
 
 // S3UploadRequest represents a multipart upload initiation payload.
 type S3UploadRequest struct {
 	Key            string            `json:"key,omitempty" example:"uploads/audio/sample.opus"`
+	KeyTemplate    string            `json:"key_template,omitempty" example:"{date}/{name}-{hash}.{ext}"`
+	OnCollision    string            `json:"on_collision,omitempty" example:"suffix"`
 	Public         bool              `json:"public" example:"false"`
 	ExpirationDays int               `json:"expires_days" example:"7"`
 	ContentType    string            `json:"content_type,omitempty" example:"audio/ogg"`
@@ -17,6 +23,8 @@ type S3Base64UploadRequest struct {
 	Data           string            `json:"data" example:"data:audio/ogg;base64,T2dnUwACAAAAAAAAAAB"`
 	Filename       string            `json:"filename,omitempty" example:"voice-note.opus"`
 	Key            string            `json:"key,omitempty" example:"uploads/audio/voice-note.opus"`
+	KeyTemplate    string            `json:"key_template,omitempty" example:"{date}/{name}-{hash}.{ext}"`
+	OnCollision    string            `json:"on_collision,omitempty" example:"suffix"`
 	Public         bool              `json:"public" example:"false"`
 	ExpirationDays int               `json:"expires_days" example:"3"`
 	ContentType    string            `json:"content_type,omitempty" example:"audio/ogg"`
@@ -24,6 +32,20 @@ type S3Base64UploadRequest struct {
 	StorageClass   string            `json:"storage_class,omitempty" example:"STANDARD"`
 }
 
+// S3URLUploadRequest asks the API to download a remote file into the bucket.
+type S3URLUploadRequest struct {
+	URL            string            `json:"url" example:"https://cdn.example.com/media/video.mp4"`
+	Filename       string            `json:"filename,omitempty" example:"video.mp4"` // Names the object (default: the URL's last path segment)
+	Key            string            `json:"key,omitempty" example:"uploads/video/video.mp4"`
+	KeyTemplate    string            `json:"key_template,omitempty" example:"{date}/{name}-{uuid}.{ext}"`
+	OnCollision    string            `json:"on_collision,omitempty" example:"suffix"`
+	Public         bool              `json:"public" example:"false"`
+	ExpirationDays int               `json:"expires_days" example:"3"`
+	ContentType    string            `json:"content_type,omitempty" example:"video/mp4"` // Default: sniffed from the first bytes, else the URL's extension
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	StorageClass   string            `json:"storage_class,omitempty" example:"STANDARD"`
+}
+
 // S3UploadResponse represents a generic upload acknowledgement payload.
 type S3UploadResponse struct {
 	Success  bool            `json:"success" example:"true"`
@@ -44,6 +66,7 @@ type S3UploadStatusResponse struct {
 	EndTime          *time.Time      `json:"end_time,omitempty" example:"2024-03-31T12:00:10Z"`
 	Error            string          `json:"error,omitempty" example:"connection reset by peer"`
 	Result           *S3UploadResult `json:"result,omitempty"`
+	SourceURL        string          `json:"source_url,omitempty" example:"https://cdn.example.com/media/video.mp4"` // URL uploads only
 }
 
 // S3UploadListResponse wraps paginated upload summaries.
@@ -52,6 +75,52 @@ type S3UploadListResponse struct {
 	Count   int                      `json:"count" example:"1"`
 }
 
+// S3ObjectListResponse is one page of the objects stored in the bucket.
+type S3ObjectListResponse struct {
+	Objects    []providers.ObjectSummary `json:"objects"`
+	Count      int                       `json:"count" example:"1"`
+	Prefix     string                    `json:"prefix,omitempty" example:"uploads/2024/"`
+	NextCursor string                    `json:"next_cursor,omitempty" example:"1VYhLkzmTZ1mJ8Ad9nB3JXG4f1n"` // Pass as cursor to fetch the next page; absent on the last one
+}
+
+// S3ShareRequest asks for temporary public access to a private object.
+type S3ShareRequest struct {
+	TTL    string `json:"ttl,omitempty" example:"15m"`                     // Duration or seconds (default S3_SHARE_DEFAULT_TTL, max S3_SHARE_MAX_TTL)
+	Reason string `json:"reason,omitempty" example:"support ticket #4711"` // Recorded in the audit log
+}
+
+// S3ShareResponse carries a presigned URL that expires on its own.
+type S3ShareResponse struct {
+	ShareID    string    `json:"share_id" example:"b7e1c7a4-2f4f-4e8e-9a59-3c0f0d1b2a61"` // Identifies the grant in the audit log
+	Key        string    `json:"key" example:"uploads/audio/sample.opus"`
+	URL        string    `json:"url" example:"https://bucket.s3.amazonaws.com/uploads/audio/sample.opus?X-Amz-Expires=900&X-Amz-Signature=..."`
+	TTLSeconds int64     `json:"ttl_seconds" example:"900"`
+	ExpiresAt  time.Time `json:"expires_at" example:"2024-03-31T12:15:00Z"`
+}
+
+// S3ExpirationRequest sets when a stored object is deleted.
+type S3ExpirationRequest struct {
+	Days *int `json:"days" example:"7"` // Days after the object was stored (on Backblaze B2, after now); 0 removes the expiration
+}
+
+// S3ExpirationResponse reports when an object will be deleted.
+type S3ExpirationResponse struct {
+	Success        bool       `json:"success" example:"true"`
+	Key            string     `json:"key" example:"uploads/audio/sample.opus"`
+	ExpirationDays int        `json:"expiration_days" example:"7"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty" example:"2024-04-08T00:00:00Z"` // Midnight UTC after the days pass; absent once removed
+}
+
+// S3DeleteResponse is returned by DELETE /upload/s3/object/{key}. With
+// S3_SOFT_DELETE the object is moved to the trash instead of deleted.
+type S3DeleteResponse struct {
+	Success   bool       `json:"success" example:"true"`
+	Message   string     `json:"message" example:"Object moved to trash"`
+	Trashed   bool       `json:"trashed" example:"true"`
+	TrashKey  string     `json:"trash_key,omitempty" example:"trash/uploads/audio/sample.opus"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2024-04-07T12:00:00Z"` // When the trashed copy is purged
+}
+
 // S3UploadResult represents a normalized upload result for documentation.
 type S3UploadResult struct {
 	Key              string     `json:"key" example:"uploads/audio/sample.opus"`
@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// slackChannel posts to a Slack incoming webhook
+type slackChannel struct {
+	url    string
+	client *http.Client
+}
+
+func (c *slackChannel) Name() string { return "slack" }
+
+func (c *slackChannel) Send(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, c.client, c.url, map[string]string{"text": alert.text()})
+}
+
+// telegramChannel sends messages through a Telegram bot
+type telegramChannel struct {
+	apiURL string
+	token  string
+	chatID string
+	client *http.Client
+}
+
+func (c *telegramChannel) Name() string { return "telegram" }
+
+func (c *telegramChannel) Send(ctx context.Context, alert Alert) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", c.apiURL, c.token)
+	return postJSON(ctx, c.client, endpoint, map[string]string{
+		"chat_id": c.chatID,
+		"text":    alert.text(),
+	})
+}
+
+// webhookChannel posts the alert as JSON to any endpoint
+type webhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+func (c *webhookChannel) Name() string { return "webhook" }
+
+func (c *webhookChannel) Send(ctx context.Context, alert Alert) error {
+	return postJSON(ctx, c.client, c.url, alert)
+}
+
+// postJSON posts body and treats any non-2xx answer as a failure
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return errors.New("invalid URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL embeds secrets (webhook paths, bot tokens); keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
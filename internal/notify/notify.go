@@ -0,0 +1,189 @@
+// Package notify delivers operational alerts (error-rate spikes, storage
+// outages, saturation, failed warm-up) to chat and webhook channels.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Severity grades an alert; Resolved announces that a condition cleared
+type Severity string
+
+const (
+	Warning  Severity = "warning"
+	Critical Severity = "critical"
+	Resolved Severity = "resolved"
+)
+
+// Conditions the server raises alerts for
+const (
+	ErrorRate       = "error_rate"
+	S3Outage        = "s3_outage"
+	QueueSaturation = "queue_saturation"
+	WarmupFailed    = "warmup_failed"
+)
+
+// Alert is one notification. Webhooks receive it as JSON.
+type Alert struct {
+	Condition string    `json:"condition"`
+	Severity  Severity  `json:"severity"`
+	Summary   string    `json:"summary"`
+	Details   string    `json:"details,omitempty"`
+	Host      string    `json:"host"`
+	Time      time.Time `json:"time"`
+}
+
+// text renders the alert for chat channels
+func (a Alert) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s on %s: %s", strings.ToUpper(string(a.Severity)), a.Condition, a.Host, a.Summary)
+	if a.Details != "" {
+		b.WriteString("\n")
+		b.WriteString(a.Details)
+	}
+	return b.String()
+}
+
+// Channel sends alerts to one destination
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, alert Alert) error
+}
+
+// Config selects the channels; empty fields leave a channel out
+type Config struct {
+	SlackWebhookURL string
+	TelegramToken   string
+	TelegramChatID  string
+	WebhookURL      string
+	Cooldown        time.Duration // Minimum gap between repeats of one condition
+	Timeout         time.Duration // Per delivery (default 10s)
+	TelegramAPIURL  string        // Default https://api.telegram.org
+}
+
+// queueSize bounds alerts waiting for delivery
+const queueSize = 64
+
+// Notifier fans alerts out to every channel from a background goroutine, so
+// raising one never blocks the caller on a slow endpoint.
+type Notifier struct {
+	channels []Channel
+	cooldown time.Duration
+	timeout  time.Duration
+	host     string
+
+	mu   sync.Mutex
+	last map[string]time.Time // Last firing alert sent per condition
+
+	queue chan Alert
+	done  chan struct{}
+}
+
+// New returns a notifier for the configured channels, or nil when none is set
+func New(cfg Config) (*Notifier, error) {
+	if (cfg.TelegramToken == "") != (cfg.TelegramChatID == "") {
+		return nil, errors.New("NOTIFY_TELEGRAM_BOT_TOKEN and NOTIFY_TELEGRAM_CHAT_ID must be set together")
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	var channels []Channel
+	if cfg.SlackWebhookURL != "" {
+		channels = append(channels, &slackChannel{url: cfg.SlackWebhookURL, client: client})
+	}
+	if cfg.TelegramToken != "" {
+		apiURL := cfg.TelegramAPIURL
+		if apiURL == "" {
+			apiURL = "https://api.telegram.org"
+		}
+		channels = append(channels, &telegramChannel{apiURL: apiURL, token: cfg.TelegramToken, chatID: cfg.TelegramChatID, client: client})
+	}
+	if cfg.WebhookURL != "" {
+		channels = append(channels, &webhookChannel{url: cfg.WebhookURL, client: client})
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+
+	host, _ := os.Hostname()
+	n := &Notifier{
+		channels: channels,
+		cooldown: cfg.Cooldown,
+		timeout:  timeout,
+		host:     host,
+		last:     make(map[string]time.Time),
+		queue:    make(chan Alert, queueSize),
+		done:     make(chan struct{}),
+	}
+	go n.run()
+	return n, nil
+}
+
+// Channels names the destinations alerts go to
+func (n *Notifier) Channels() []string {
+	names := make([]string, len(n.channels))
+	for i, channel := range n.channels {
+		names[i] = channel.Name()
+	}
+	return names
+}
+
+// Notify queues an alert for delivery. Firing alerts of a condition are
+// dropped within the cooldown of the previous one; resolutions always go out.
+// It reports whether the alert was queued.
+func (n *Notifier) Notify(alert Alert) bool {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
+	}
+	alert.Host = n.host
+
+	if alert.Severity != Resolved {
+		n.mu.Lock()
+		if last, ok := n.last[alert.Condition]; ok && alert.Time.Sub(last) < n.cooldown {
+			n.mu.Unlock()
+			return false
+		}
+		n.last[alert.Condition] = alert.Time
+		n.mu.Unlock()
+	}
+
+	select {
+	case n.queue <- alert:
+		return true
+	default:
+		log.Printf("⚠️ Alert queue full, dropping %s %s", alert.Severity, alert.Condition)
+		return false
+	}
+}
+
+// Close delivers the alerts already queued and stops the notifier
+func (n *Notifier) Close() {
+	close(n.queue)
+	<-n.done
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+
+	for alert := range n.queue {
+		for _, channel := range n.channels {
+			ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+			if err := channel.Send(ctx, alert); err != nil {
+				log.Printf("❌ Alert %s via %s failed: %v", alert.Condition, channel.Name(), err)
+			}
+			cancel()
+		}
+	}
+}
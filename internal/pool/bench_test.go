@@ -0,0 +1,38 @@
+package pool
+
+import "testing"
+
+func BenchmarkBufferGetPut(b *testing.B) {
+	bp := NewBufferPool(16, 1024*1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		buf := bp.Get()
+		bp.Put(buf)
+	}
+}
+
+func BenchmarkBufferGetPutParallel(b *testing.B) {
+	bp := NewBufferPool(16, 1024*1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := bp.Get()
+			bp.Put(buf)
+		}
+	})
+}
+
+func BenchmarkBufferGetPutSized(b *testing.B) {
+	bp := NewBufferPool(16, 1024*1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		buf := bp.GetSized(32 * 1024) // Downloader stream chunk size
+		bp.PutSized(buf)
+	}
+}
@@ -0,0 +1,251 @@
+package pool
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// conversionSlots bounds concurrent encoder processes to the worker count.
+// A reserved slice of the slots only serves small inputs, so interactive
+// traffic such as voice notes never waits behind bulk jobs. When every slot
+// is busy, freed slots go to the waiting conversion of highest priority.
+// Within a priority, waiters are served fairly across tenants (self-clocked
+// fair queuing): each waiter is tagged with its tenant's previous tag plus
+// 1/weight, tenants that weren't waiting start from the tag last served, and
+// the lowest tag goes first. A tenant queueing 500 conversions then gets
+// every other slot once a second tenant shows up, not the next 500.
+type conversionSlots struct {
+	mu           sync.Mutex
+	generalFree  int
+	priorityFree int
+	priorityCap  int
+	priorityMax  int
+	waiters      []*slotWaiter // Highest priority first, then by tag
+	active       int32
+	waiting      int32
+	priorityRuns int64
+
+	fair       bool               // Tenants are ignored when false: first come first served
+	clock      float64            // Tag of the waiter served last
+	lastTags   map[string]float64 // Tag of each waiting tenant's last waiter
+	queueDepth map[string]int     // Waiters per tenant
+}
+
+// slotWaiter is a conversion waiting for a slot
+type slotWaiter struct {
+	priority int
+	small    bool
+	tenant   string
+	tag      float64   // Virtual finish time; lower tags are served first
+	granted  chan bool // Receives whether the slot handed over is a reserved one
+}
+
+// Claim describes the conversion a slot is requested for
+type Claim struct {
+	Priority int     // While every slot is busy, higher priorities are served first
+	Tenant   string  // Waiters of a priority share the slots fairly across tenants ("" = anonymous)
+	Weight   float64 // Tenant's share relative to the others (<= 0 counts as 1)
+}
+
+func newConversionSlots(workers int) *conversionSlots {
+	return &conversionSlots{
+		generalFree: workers,
+		fair:        true,
+		lastTags:    make(map[string]float64),
+		queueDepth:  make(map[string]int),
+	}
+}
+
+// SetFairQueuing turns tenant fairness among waiting conversions on (the
+// default) or off, leaving first come first served within a priority
+func (p *WorkerPool) SetFairQueuing(enabled bool) {
+	p.slots.mu.Lock()
+	defer p.slots.mu.Unlock()
+
+	p.slots.fair = enabled
+}
+
+// FairQueuing reports whether waiting conversions are served fairly across tenants
+func (p *WorkerPool) FairQueuing() bool {
+	p.slots.mu.Lock()
+	defer p.slots.mu.Unlock()
+
+	return p.slots.fair
+}
+
+// SetPriorityLane reserves workers slots for inputs of at most maxSize bytes.
+// The reservation is capped so at least one slot remains for bulk work;
+// reserved <= 0 or maxSize <= 0 disables the lane. Call it before serving.
+func (p *WorkerPool) SetPriorityLane(reserved, maxSize int) {
+	if reserved <= 0 || maxSize <= 0 {
+		reserved, maxSize = 0, 0
+	}
+	if reserved > p.maxWorkers-1 {
+		reserved = p.maxWorkers - 1
+	}
+
+	p.slots.mu.Lock()
+	defer p.slots.mu.Unlock()
+
+	p.slots.generalFree = p.maxWorkers - reserved
+	p.slots.priorityFree = reserved
+	p.slots.priorityCap = reserved
+	p.slots.priorityMax = maxSize
+}
+
+// PriorityLane returns the reserved slot count and the input size it serves
+func (p *WorkerPool) PriorityLane() (reserved, maxSize int) {
+	p.slots.mu.Lock()
+	defer p.slots.mu.Unlock()
+
+	return p.slots.priorityCap, p.slots.priorityMax
+}
+
+// Acquire blocks until a conversion slot for an input of size bytes is free
+// and returns the function that frees it. Small inputs take a reserved slot
+// when one is idle and otherwise compete for the shared ones.
+func (p *WorkerPool) Acquire(ctx context.Context, size int) (func(), error) {
+	return p.AcquirePriority(ctx, size, 0)
+}
+
+// AcquirePriority is Acquire for a conversion of the given priority: while
+// every slot is busy, higher priorities are served first.
+func (p *WorkerPool) AcquirePriority(ctx context.Context, size, priority int) (func(), error) {
+	return p.AcquireFor(ctx, size, Claim{Priority: priority})
+}
+
+// AcquireFor is Acquire for the conversion claim describes: while every slot
+// is busy, higher priorities are served first, and tenants of a priority
+// in proportion to their weight.
+func (p *WorkerPool) AcquireFor(ctx context.Context, size int, claim Claim) (func(), error) {
+	reserved, err := p.slots.take(ctx, size, claim)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt32(&p.slots.active, 1)
+	if reserved {
+		atomic.AddInt64(&p.slots.priorityRuns, 1)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			atomic.AddInt32(&p.slots.active, -1)
+			p.slots.release(reserved)
+		})
+	}, nil
+}
+
+// take returns whether the slot taken is a reserved one
+func (s *conversionSlots) take(ctx context.Context, size int, claim Claim) (bool, error) {
+	s.mu.Lock()
+	small := s.priorityCap > 0 && size <= s.priorityMax
+
+	// Freed slots are handed to waiters that can use them, so a free slot
+	// means nobody usable is queued for it
+	if small && s.priorityFree > 0 {
+		s.priorityFree--
+		s.mu.Unlock()
+		return true, nil
+	}
+	if s.generalFree > 0 {
+		s.generalFree--
+		s.mu.Unlock()
+		return false, nil
+	}
+
+	waiter := &slotWaiter{priority: claim.Priority, small: small, tenant: claim.Tenant, granted: make(chan bool, 1)}
+	if s.fair {
+		weight := claim.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		last, waiting := s.lastTags[claim.Tenant]
+		if !waiting {
+			last = s.clock
+		}
+		waiter.tag = last + 1/weight
+		s.lastTags[claim.Tenant] = waiter.tag
+	}
+
+	// Equal tags (and every tag with fairness off) keep arrival order
+	position := len(s.waiters)
+	for i, queued := range s.waiters {
+		if queued.priority < waiter.priority || (queued.priority == waiter.priority && queued.tag > waiter.tag) {
+			position = i
+			break
+		}
+	}
+	s.waiters = append(s.waiters, nil)
+	copy(s.waiters[position+1:], s.waiters[position:])
+	s.waiters[position] = waiter
+	s.queueDepth[claim.Tenant]++
+	atomic.AddInt32(&s.waiting, 1)
+	s.mu.Unlock()
+
+	select {
+	case reserved := <-waiter.granted:
+		return reserved, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for i, queued := range s.waiters {
+		if queued == waiter {
+			s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+			s.dequeued(waiter)
+			s.mu.Unlock()
+			return false, ctx.Err()
+		}
+	}
+	s.mu.Unlock()
+
+	// A slot was handed over as ctx ended; pass it on
+	s.release(<-waiter.granted)
+	return false, ctx.Err()
+}
+
+// release hands a slot to the first waiter that can use it, or frees it
+func (s *conversionSlots) release(reserved bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, waiter := range s.waiters {
+		if reserved && !waiter.small {
+			continue
+		}
+		s.waiters = append(s.waiters[:i], s.waiters[i+1:]...)
+		s.clock = max(s.clock, waiter.tag)
+		s.dequeued(waiter)
+		waiter.granted <- reserved
+		return
+	}
+
+	if reserved {
+		s.priorityFree++
+	} else {
+		s.generalFree++
+	}
+}
+
+// dequeued accounts for a waiter leaving the queue. A tenant with nothing
+// left waiting is forgotten, so it starts again from the clock instead of
+// paying for conversions it gave up on.
+func (s *conversionSlots) dequeued(waiter *slotWaiter) {
+	atomic.AddInt32(&s.waiting, -1)
+	if s.queueDepth[waiter.tenant]--; s.queueDepth[waiter.tenant] <= 0 {
+		delete(s.queueDepth, waiter.tenant)
+		delete(s.lastTags, waiter.tenant)
+	}
+}
+
+// TenantQueueDepth returns the conversions waiting for a slot per tenant
+func (p *WorkerPool) TenantQueueDepth() map[string]int {
+	p.slots.mu.Lock()
+	defer p.slots.mu.Unlock()
+
+	return maps.Clone(p.slots.queueDepth)
+}
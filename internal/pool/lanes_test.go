@@ -0,0 +1,266 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestSlots returns slots for workers conversions, reserved of them for
+// inputs of at most maxSize bytes
+func newTestSlots(workers, reserved, maxSize int) *conversionSlots {
+	s := newConversionSlots(workers)
+	s.generalFree = workers - reserved
+	s.priorityFree = reserved
+	s.priorityCap = reserved
+	s.priorityMax = maxSize
+	return s
+}
+
+// grant is a queued take that returned
+type grant struct {
+	label    string
+	reserved bool
+	err      error
+}
+
+// queue starts a take that must wait and returns once it is queued, so
+// waiters are enqueued in call order. Its result goes to grants.
+func queue(t *testing.T, s *conversionSlots, ctx context.Context, label string, size int, claim Claim, grants chan<- grant) {
+	t.Helper()
+
+	before := atomic.LoadInt32(&s.waiting)
+	go func() {
+		reserved, err := s.take(ctx, size, claim)
+		grants <- grant{label, reserved, err}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&s.waiting) == before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s never queued", label)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// next returns the waiter a release handed the slot to
+func next(t *testing.T, grants <-chan grant) grant {
+	t.Helper()
+
+	select {
+	case g := <-grants:
+		return g
+	case <-time.After(5 * time.Second):
+		t.Fatal("no waiter was granted a slot")
+		return grant{}
+	}
+}
+
+// mustTake takes a free slot without waiting
+func mustTake(t *testing.T, s *conversionSlots, size int) bool {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reserved, err := s.take(ctx, size, Claim{})
+	if err != nil {
+		t.Fatalf("take(%d): %v", size, err)
+	}
+	return reserved
+}
+
+func TestSlotsTenantWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		fair    bool
+		weights map[string]float64
+		queued  []string // Tenants in arrival order, one letter per waiter
+		want    string   // Tenants in the order slots are handed over
+	}{
+		{"equal weights interleave", true, map[string]float64{"a": 1, "b": 1}, strings.Split("aaaabbbb", ""), "abababab"},
+		{"double weight gets two in three", true, map[string]float64{"a": 2, "b": 1}, strings.Split("aaaaaabbb", ""), "aabaabaab"},
+		{"late tenant starts from the clock", true, map[string]float64{"a": 1, "b": 1, "c": 1}, strings.Split("aaaabc", ""), "abcaaa"},
+		{"zero weight counts as one", true, map[string]float64{"a": 0, "b": 1}, strings.Split("aabb", ""), "abab"},
+		{"fairness off is first come first served", false, map[string]float64{"a": 1, "b": 1}, strings.Split("aaaabbbb", ""), "aaaabbbb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSlots(1, 0, 0)
+			s.fair = tt.fair
+			mustTake(t, s, 0)
+
+			grants := make(chan grant, len(tt.queued))
+			for _, tenant := range tt.queued {
+				queue(t, s, context.Background(), tenant, 0, Claim{Tenant: tenant, Weight: tt.weights[tenant]}, grants)
+			}
+
+			var order strings.Builder
+			for range tt.queued {
+				s.release(false)
+				order.WriteString(next(t, grants).label)
+			}
+			if order.String() != tt.want {
+				t.Errorf("order = %s, want %s", order.String(), tt.want)
+			}
+
+			s.release(false)
+			if s.generalFree != 1 || len(s.waiters) != 0 || len(s.lastTags) != 0 || len(s.queueDepth) != 0 {
+				t.Errorf("after draining: free %d, waiters %d, tags %v, depth %v", s.generalFree, len(s.waiters), s.lastTags, s.queueDepth)
+			}
+		})
+	}
+}
+
+func TestSlotsPriorityOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued []Claim
+		want   string
+	}{
+		{"higher priority first", []Claim{{Priority: 0}, {Priority: 5}, {Priority: 1}}, "120"},
+		{"equal priority keeps arrival order", []Claim{{Priority: 1}, {Priority: 1}, {Priority: 1}}, "012"},
+		{"priority beats tenant fairness", []Claim{{Tenant: "a"}, {Tenant: "b"}, {Tenant: "a", Priority: 1}}, "201"},
+		{"negative priority goes last", []Claim{{Priority: -1}, {Priority: 0}}, "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSlots(1, 0, 0)
+			mustTake(t, s, 0)
+
+			grants := make(chan grant, len(tt.queued))
+			for i, claim := range tt.queued {
+				queue(t, s, context.Background(), string(rune('0'+i)), 0, claim, grants)
+			}
+
+			var order strings.Builder
+			for range tt.queued {
+				s.release(false)
+				order.WriteString(next(t, grants).label)
+			}
+			if order.String() != tt.want {
+				t.Errorf("order = %s, want %s", order.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSlotsCancelledWaiterHandsOn(t *testing.T) {
+	s := newTestSlots(1, 0, 0)
+	mustTake(t, s, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	grants := make(chan grant, 2)
+	queue(t, s, ctx, "cancelled", 0, Claim{Tenant: "a"}, grants)
+	queue(t, s, context.Background(), "waiting", 0, Claim{Tenant: "b"}, grants)
+
+	cancel()
+	if g := next(t, grants); g.label != "cancelled" || !errors.Is(g.err, context.Canceled) {
+		t.Fatalf("got %+v, want the cancelled waiter to give up", g)
+	}
+	if s.queueDepth["a"] != 0 {
+		t.Errorf("cancelled tenant still queued: %v", s.queueDepth)
+	}
+
+	s.release(false)
+	if g := next(t, grants); g.label != "waiting" || g.err != nil {
+		t.Fatalf("got %+v, want the slot handed to the remaining waiter", g)
+	}
+}
+
+func TestSlotsReservedNeverLarge(t *testing.T) {
+	s := newTestSlots(3, 1, 100)
+
+	if mustTake(t, s, 1000) || mustTake(t, s, 1000) {
+		t.Fatal("a large input took a reserved slot")
+	}
+
+	// The reserved slot is idle, yet a large input waits for a shared one
+	grants := make(chan grant, 2)
+	queue(t, s, context.Background(), "large", 1000, Claim{}, grants)
+	if !mustTake(t, s, 10) {
+		t.Fatal("a small input didn't take the idle reserved slot")
+	}
+
+	// A freed reserved slot skips the large waiter and goes idle
+	s.release(true)
+	if s.priorityFree != 1 || len(s.waiters) != 1 {
+		t.Fatalf("reserved slot handed on: priorityFree %d, waiters %d", s.priorityFree, len(s.waiters))
+	}
+
+	// A small waiter queued behind the large one gets the reserved slot
+	if !mustTake(t, s, 10) {
+		t.Fatal("a small input didn't take the idle reserved slot")
+	}
+	queue(t, s, context.Background(), "small", 10, Claim{}, grants)
+	s.release(true)
+	if g := next(t, grants); g.label != "small" || !g.reserved {
+		t.Fatalf("got %+v, want the small waiter on the reserved slot", g)
+	}
+
+	s.release(false)
+	if g := next(t, grants); g.label != "large" || g.reserved {
+		t.Fatalf("got %+v, want the large waiter on a shared slot", g)
+	}
+}
+
+// TestSlotsConcurrent races takes, releases and cancellations (run with
+// -race): no slot is lost or duplicated, and large inputs never hold a
+// reserved slot
+func TestSlotsConcurrent(t *testing.T) {
+	const workers, reserved, maxSize = 4, 2, 100
+	s := newTestSlots(workers, reserved, maxSize)
+
+	var active, activeReserved, violations atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(uint64(i), 0))
+			size := rng.IntN(200)
+			claim := Claim{Priority: rng.IntN(3), Tenant: string(rune('a' + rng.IntN(4))), Weight: float64(1 + rng.IntN(3))}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(rng.IntN(5))*time.Millisecond)
+			defer cancel()
+			gotReserved, err := s.take(ctx, size, claim)
+			if err != nil {
+				return
+			}
+
+			if gotReserved && size > maxSize {
+				violations.Add(1)
+			}
+			if active.Add(1) > workers {
+				violations.Add(1)
+			}
+			if gotReserved && activeReserved.Add(1) > reserved {
+				violations.Add(1)
+			}
+			time.Sleep(time.Duration(rng.IntN(500)) * time.Microsecond)
+			if gotReserved {
+				activeReserved.Add(-1)
+			}
+			active.Add(-1)
+			s.release(gotReserved)
+		}()
+	}
+	wg.Wait()
+
+	if n := violations.Load(); n > 0 {
+		t.Errorf("%d slot violations", n)
+	}
+	if s.generalFree != workers-reserved || s.priorityFree != reserved {
+		t.Errorf("slots leaked: general %d/%d, reserved %d/%d", s.generalFree, workers-reserved, s.priorityFree, reserved)
+	}
+	if len(s.waiters) != 0 || s.waiting != 0 || len(s.queueDepth) != 0 || len(s.lastTags) != 0 {
+		t.Errorf("queue not empty: waiters %d, waiting %d, depth %v, tags %v", len(s.waiters), s.waiting, s.queueDepth, s.lastTags)
+	}
+}
@@ -2,12 +2,16 @@ package pool
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// ErrQueueFull is returned by TrySubmit when every queue slot is taken
+var ErrQueueFull = errors.New("worker pool queue full")
+
 // Task represents a unit of work to be executed
 type Task func() error
 
@@ -27,6 +31,7 @@ type WorkerPool struct {
 	avgExecTime  int64 // nanoseconds
 	started      bool
 	mu           sync.RWMutex
+	slots        *conversionSlots
 }
 
 type contextTask struct {
@@ -41,11 +46,24 @@ func NewWorkerPool(maxWorkers int) *WorkerPool {
 		maxWorkers = 1
 	}
 
+	return NewWorkerPoolWithQueue(maxWorkers, maxWorkers*10)
+}
+
+// NewWorkerPoolWithQueue creates a worker pool whose queues hold queueSize tasks
+func NewWorkerPoolWithQueue(maxWorkers, queueSize int) *WorkerPool {
+	if maxWorkers <= 0 {
+		maxWorkers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
 	return &WorkerPool{
 		maxWorkers:   maxWorkers,
-		taskQueue:    make(chan Task, maxWorkers*10), // Buffered queue
-		contextQueue: make(chan contextTask, maxWorkers*10),
+		taskQueue:    make(chan Task, queueSize), // Buffered queue
+		contextQueue: make(chan contextTask, queueSize),
 		quit:         make(chan struct{}),
+		slots:        newConversionSlots(maxWorkers),
 	}
 }
 
@@ -157,6 +175,25 @@ func (p *WorkerPool) Submit(task Task) error {
 	}
 }
 
+// TrySubmit queues a task like Submit, but returns ErrQueueFull instead of
+// running it outside the pool when the queue is full, so the pool's
+// concurrency bound always holds
+func (p *WorkerPool) TrySubmit(task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if !p.started {
+		return fmt.Errorf("worker pool not started")
+	}
+
+	select {
+	case p.taskQueue <- task:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
 // SubmitWithContext submits a task with context and returns error channel
 func (p *WorkerPool) SubmitWithContext(ctx context.Context, task TaskWithContext) (<-chan error, error) {
 	p.mu.RLock()
@@ -278,6 +315,14 @@ type WorkerPoolStats struct {
 	FailedTasks   int64
 	SuccessRate   float64
 	AvgExecTimeMs float64
+
+	// Conversion slots (see Acquire)
+	ActiveConversions  int32
+	WaitingConversions int32
+	PriorityWorkers    int
+	PriorityMaxSize    int
+	PriorityRuns       int64
+	WaitingByTenant    map[string]int // Conversions waiting per tenant (API key ID)
 }
 
 // Stats returns current pool statistics
@@ -285,6 +330,7 @@ func (p *WorkerPool) Stats() WorkerPoolStats {
 	total := atomic.LoadInt64(&p.totalTasks)
 	failed := atomic.LoadInt64(&p.failedTasks)
 	avgNs := atomic.LoadInt64(&p.avgExecTime)
+	priorityWorkers, priorityMaxSize := p.PriorityLane()
 
 	successRate := float64(0)
 	if total > 0 {
@@ -299,5 +345,12 @@ func (p *WorkerPool) Stats() WorkerPoolStats {
 		FailedTasks:   failed,
 		SuccessRate:   successRate,
 		AvgExecTimeMs: float64(avgNs) / 1e6,
+
+		ActiveConversions:  atomic.LoadInt32(&p.slots.active),
+		WaitingConversions: atomic.LoadInt32(&p.slots.waiting),
+		PriorityWorkers:    priorityWorkers,
+		PriorityMaxSize:    priorityMaxSize,
+		PriorityRuns:       atomic.LoadInt64(&p.slots.priorityRuns),
+		WaitingByTenant:    p.TenantQueueDepth(),
 	}
 }
@@ -1,10 +1,11 @@
 package providers
 
 import (
+	"bytes"
 	"context"
-	"encoding/base64"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +20,8 @@ import (
 type AWSS3Provider struct {
 	client *s3.Client
 	config *S3Config
+
+	expirationRules expirationRules // Lifecycle rules known to be in the bucket
 }
 
 // NewAWSProvider creates a new AWS S3 provider
@@ -65,8 +68,8 @@ func NewAWSProvider(cfg *S3Config) (*AWSS3Provider, error) {
 func (p *AWSS3Provider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts UploadOptions) (*UploadResult, error) {
 	startTime := time.Now()
 
-	// Use multipart upload for large files
-	if size >= p.config.MultipartThreshold {
+	// Use multipart upload for large files and streams of unknown size
+	if size < 0 || size >= p.config.MultipartThreshold {
 		return p.MultipartUpload(ctx, key, reader, opts)
 	}
 
@@ -93,6 +96,13 @@ func (p *AWSS3Provider) Upload(ctx context.Context, key string, reader io.Reader
 		input.StorageClass = types.StorageClass(opts.StorageClass)
 	}
 
+	// Tag expiring objects for the lifecycle rule that deletes them
+	var ruleErr error
+	if opts.ExpirationDays > 0 {
+		input.Tagging = aws.String(expirationTagging(opts.ExpirationDays))
+		ruleErr = p.ensureExpirationRule(ctx, opts.ExpirationDays)
+	}
+
 	// Perform upload with retry logic
 	var result *s3.PutObjectOutput
 	var err error
@@ -133,13 +143,9 @@ func (p *AWSS3Provider) Upload(ctx context.Context, key string, reader io.Reader
 		uploadResult.VersionID = aws.ToString(result.VersionId)
 	}
 
-	// Set expiration if configured
+	// Report when the lifecycle rule deletes the object
 	if opts.ExpirationDays > 0 {
-		expiresAt := time.Now().AddDate(0, 0, opts.ExpirationDays)
-		uploadResult.ExpiresAt = &expiresAt
-
-		// Note: AWS S3 expiration is typically set via lifecycle policies
-		// This is just for tracking in our response
+		uploadResult.ExpiresAt = uploadExpiry("aws", key, opts.ExpirationDays, ruleErr)
 	}
 
 	return uploadResult, nil
@@ -171,6 +177,13 @@ func (p *AWSS3Provider) MultipartUpload(ctx context.Context, key string, reader
 		createInput.StorageClass = types.StorageClass(opts.StorageClass)
 	}
 
+	// Tag expiring objects for the lifecycle rule that deletes them
+	var ruleErr error
+	if opts.ExpirationDays > 0 {
+		createInput.Tagging = aws.String(expirationTagging(opts.ExpirationDays))
+		ruleErr = p.ensureExpirationRule(ctx, opts.ExpirationDays)
+	}
+
 	createResult, err := p.client.CreateMultipartUpload(ctx, createInput)
 	if err != nil {
 		return nil, NewS3Error("aws", "create_multipart", key, 0, err)
@@ -189,8 +202,19 @@ func (p *AWSS3Provider) MultipartUpload(ctx context.Context, key string, reader
 	buffer := make([]byte, chunkSize)
 	totalBytesTransferred := int64(0)
 
+	// Parts are filled completely (pipes return short reads) because S3
+	// refuses parts under 5MB other than the last
 	for {
-		n, readErr := reader.Read(buffer)
+		n, readErr := io.ReadFull(reader, buffer)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			// Abort multipart upload on error, so a failed stream never completes
+			_, _ = p.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(p.config.Bucket),
+				Key:      aws.String(key),
+				UploadId: createResult.UploadId,
+			})
+			return nil, NewS3Error("aws", "read_data", key, 0, readErr)
+		}
 		if n == 0 {
 			break
 		}
@@ -201,7 +225,7 @@ func (p *AWSS3Provider) MultipartUpload(ctx context.Context, key string, reader
 			Key:        aws.String(key),
 			PartNumber: aws.Int32(partNumber),
 			UploadId:   createResult.UploadId,
-			Body:       strings.NewReader(string(buffer[:n])),
+			Body:       bytes.NewReader(buffer[:n]),
 		}
 
 		partResult, err := p.client.UploadPart(ctx, partInput)
@@ -227,18 +251,9 @@ func (p *AWSS3Provider) MultipartUpload(ctx context.Context, key string, reader
 			opts.ProgressCallback(totalBytesTransferred, totalBytesTransferred)
 		}
 
-		if readErr == io.EOF {
+		if readErr != nil { // Short final part
 			break
 		}
-		if readErr != nil {
-			// Abort multipart upload on error
-			_, _ = p.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
-				Bucket:   aws.String(p.config.Bucket),
-				Key:      aws.String(key),
-				UploadId: createResult.UploadId,
-			})
-			return nil, NewS3Error("aws", "read_data", key, 0, readErr)
-		}
 
 		partNumber++
 	}
@@ -273,10 +288,9 @@ func (p *AWSS3Provider) MultipartUpload(ctx context.Context, key string, reader
 		uploadResult.VersionID = aws.ToString(completeResult.VersionId)
 	}
 
-	// Set expiration if configured
+	// Report when the lifecycle rule deletes the object
 	if opts.ExpirationDays > 0 {
-		expiresAt := time.Now().AddDate(0, 0, opts.ExpirationDays)
-		uploadResult.ExpiresAt = &expiresAt
+		uploadResult.ExpiresAt = uploadExpiry("aws", key, opts.ExpirationDays, ruleErr)
 	}
 
 	return uploadResult, nil
@@ -307,7 +321,7 @@ func (p *AWSS3Provider) UploadBase64(ctx context.Context, key string, data strin
 	}
 
 	// Decode base64 data
-	decodedData, err := base64.StdEncoding.DecodeString(base64Data)
+	decodedData, err := DecodeBase64(base64Data)
 	if err != nil {
 		return nil, NewS3Error("aws", "decode_base64", key, 0, ErrInvalidBase64)
 	}
@@ -327,11 +341,94 @@ func (p *AWSS3Provider) GetPublicURL(key string) string {
 	return p.config.GetPublicURL(key)
 }
 
-// SetExpiration sets expiration for an object (AWS S3 uses lifecycle policies)
-func (p *AWSS3Provider) SetExpiration(key string, days int) error {
-	// AWS S3 expiration is typically managed via bucket lifecycle policies
-	// This is a placeholder for future implementation
-	return ErrFeatureNotSupported
+// SetExpiration tags the object for the lifecycle rule deleting objects days
+// after their creation, adding the rule to the bucket when missing. Days 0
+// removes the tag; the object's other tags are kept.
+func (p *AWSS3Provider) SetExpiration(ctx context.Context, key string, days int) (*time.Time, error) {
+	info, err := p.GetObjectInfo(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if days > 0 {
+		if err := p.ensureExpirationRule(ctx, days); err != nil {
+			return nil, err
+		}
+	}
+
+	current, err := p.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(p.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, newObjectError("aws", "get_tagging", key, httpStatusCode(err), err)
+	}
+
+	tagSet := make([]types.Tag, 0, len(current.TagSet)+1)
+	for _, tag := range current.TagSet {
+		if aws.ToString(tag.Key) != ExpirationTag {
+			tagSet = append(tagSet, tag)
+		}
+	}
+	if days > 0 {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(ExpirationTag), Value: aws.String(strconv.Itoa(days))})
+	}
+
+	_, err = p.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(p.config.Bucket),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: tagSet},
+	})
+	if err != nil {
+		return nil, newObjectError("aws", "put_tagging", key, httpStatusCode(err), err)
+	}
+
+	if days == 0 {
+		return nil, nil
+	}
+	expiresAt := ExpirationDate(info.LastModified, days)
+	return &expiresAt, nil
+}
+
+// ensureExpirationRule adds the lifecycle rule expiring objects tagged with
+// days to the bucket, keeping its other rules
+func (p *AWSS3Provider) ensureExpirationRule(ctx context.Context, days int) error {
+	return p.expirationRules.ensure(days, func() error {
+		var rules []types.LifecycleRule
+		current, err := p.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+			Bucket: aws.String(p.config.Bucket),
+		})
+		switch {
+		case err == nil:
+			rules = current.Rules
+		case ErrorCode(err) == "NoSuchLifecycleConfiguration":
+		default:
+			return NewS3Error("aws", "get_lifecycle", "", httpStatusCode(err), err)
+		}
+
+		id := expirationRuleID(days)
+		for _, rule := range rules {
+			if aws.ToString(rule.ID) == id {
+				return nil
+			}
+		}
+		rules = append(rules, types.LifecycleRule{
+			ID:     aws.String(id),
+			Status: types.ExpirationStatusEnabled,
+			Filter: &types.LifecycleRuleFilter{
+				Tag: &types.Tag{Key: aws.String(ExpirationTag), Value: aws.String(strconv.Itoa(days))},
+			},
+			Expiration: &types.LifecycleExpiration{Days: aws.Int32(int32(days))},
+		})
+
+		_, err = p.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+			Bucket:                 aws.String(p.config.Bucket),
+			LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: rules},
+		})
+		if err != nil {
+			return NewS3Error("aws", "put_lifecycle", "", httpStatusCode(err), err)
+		}
+		return nil
+	})
 }
 
 // HealthCheck verifies the provider connection and configuration
@@ -354,7 +451,7 @@ func (p *AWSS3Provider) DeleteObject(ctx context.Context, key string) error {
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return NewS3Error("aws", "delete", key, 0, err)
+		return newObjectError("aws", "delete", key, httpStatusCode(err), err)
 	}
 
 	return nil
@@ -367,7 +464,7 @@ func (p *AWSS3Provider) GetObjectInfo(ctx context.Context, key string) (*ObjectI
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, NewS3Error("aws", "head_object", key, 0, err)
+		return nil, newObjectError("aws", "head_object", key, httpStatusCode(err), err)
 	}
 
 	info := &ObjectInfo{
@@ -389,3 +486,66 @@ func (p *AWSS3Provider) GetObjectInfo(ctx context.Context, key string) (*ObjectI
 
 	return info, nil
 }
+
+// ListObjects lists a page of objects with ListObjectsV2; the cursor is its
+// continuation token
+func (p *AWSS3Provider) ListObjects(ctx context.Context, opts ListOptions) (*ObjectList, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(p.config.Bucket),
+		MaxKeys: aws.Int32(int32(listLimit(opts.Limit))),
+	}
+	if opts.Prefix != "" {
+		input.Prefix = aws.String(opts.Prefix)
+	}
+	if opts.Cursor != "" {
+		input.ContinuationToken = aws.String(opts.Cursor)
+	}
+
+	result, err := p.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, NewS3Error("aws", "list_objects", opts.Prefix, httpStatusCode(err), err)
+	}
+
+	list := &ObjectList{Objects: make([]ObjectSummary, 0, len(result.Contents))}
+	for _, object := range result.Contents {
+		list.Objects = append(list.Objects, ObjectSummary{
+			Key:          aws.ToString(object.Key),
+			Size:         aws.ToInt64(object.Size),
+			ETag:         aws.ToString(object.ETag),
+			LastModified: aws.ToTime(object.LastModified),
+			StorageClass: string(object.StorageClass),
+		})
+	}
+	if aws.ToBool(result.IsTruncated) {
+		list.NextCursor = aws.ToString(result.NextContinuationToken)
+	}
+
+	return list, nil
+}
+
+// GetObject opens the object body for reading
+func (p *AWSS3Provider) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	result, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, newObjectError("aws", "get_object", key, httpStatusCode(err), err)
+	}
+
+	return result.Body, nil
+}
+
+// PresignGetURL returns a presigned GET URL valid for the given duration
+func (p *AWSS3Provider) PresignGetURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(p.client)
+	request, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.config.Bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", NewS3Error("aws", "presign", key, 0, err)
+	}
+
+	return request.URL, nil
+}
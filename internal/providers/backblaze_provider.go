@@ -1,10 +1,11 @@
 package providers
 
 import (
+	"bytes"
 	"context"
-	"encoding/base64"
 	"fmt"
 	"io"
+	"net/url"
 	"strings"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 type BackblazeProvider struct {
 	client *s3.Client
 	config *S3Config
+	expiry expirySweep
 }
 
 // NewBackblazeProvider creates a new Backblaze B2 provider
@@ -61,8 +63,8 @@ func NewBackblazeProvider(cfg *S3Config) (*BackblazeProvider, error) {
 func (p *BackblazeProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts UploadOptions) (*UploadResult, error) {
 	startTime := time.Now()
 
-	// Use multipart upload for large files
-	if size >= p.config.MultipartThreshold {
+	// Use multipart upload for large files and streams of unknown size
+	if size < 0 || size >= p.config.MultipartThreshold {
 		return p.MultipartUpload(ctx, key, reader, opts)
 	}
 
@@ -77,6 +79,13 @@ func (p *BackblazeProvider) Upload(ctx context.Context, key string, reader io.Re
 	// Backblaze B2 doesn't support ACLs in the same way as AWS S3
 	// Public access is controlled at the bucket level
 
+	// Record the expiry for SweepExpired, as B2 lifecycle rules can't match tags
+	var expiresAt time.Time
+	if opts.ExpirationDays > 0 {
+		expiresAt = ExpirationDate(time.Now(), opts.ExpirationDays)
+		opts.Metadata = withExpiresAt(opts.Metadata, expiresAt)
+	}
+
 	// Add metadata
 	if len(opts.Metadata) > 0 {
 		input.Metadata = opts.Metadata
@@ -135,11 +144,11 @@ func (p *BackblazeProvider) Upload(ctx context.Context, key string, reader io.Re
 		uploadResult.VersionID = aws.ToString(result.VersionId)
 	}
 
-	// Set expiration if configured
+	// Report when SweepExpired deletes the object
 	if opts.ExpirationDays > 0 {
-		expiresAt := time.Now().AddDate(0, 0, opts.ExpirationDays)
 		uploadResult.ExpiresAt = &expiresAt
 	}
+	p.expiry.track(key, uploadResult.ExpiresAt)
 
 	return uploadResult, nil
 }
@@ -155,6 +164,13 @@ func (p *BackblazeProvider) MultipartUpload(ctx context.Context, key string, rea
 		ContentType: aws.String(opts.ContentType),
 	}
 
+	// Record the expiry for SweepExpired, as B2 lifecycle rules can't match tags
+	var expiresAt time.Time
+	if opts.ExpirationDays > 0 {
+		expiresAt = ExpirationDate(time.Now(), opts.ExpirationDays)
+		opts.Metadata = withExpiresAt(opts.Metadata, expiresAt)
+	}
+
 	// Add metadata
 	if len(opts.Metadata) > 0 {
 		createInput.Metadata = opts.Metadata
@@ -190,8 +206,19 @@ func (p *BackblazeProvider) MultipartUpload(ctx context.Context, key string, rea
 	buffer := make([]byte, chunkSize)
 	totalBytesTransferred := int64(0)
 
+	// Parts are filled completely (pipes return short reads) because S3
+	// refuses parts under 5MB other than the last
 	for {
-		n, readErr := reader.Read(buffer)
+		n, readErr := io.ReadFull(reader, buffer)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			// Abort multipart upload on error, so a failed stream never completes
+			_, _ = p.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(p.config.Bucket),
+				Key:      aws.String(key),
+				UploadId: createResult.UploadId,
+			})
+			return nil, NewS3Error("backblaze", "read_data", key, 0, readErr)
+		}
 		if n == 0 {
 			break
 		}
@@ -202,7 +229,7 @@ func (p *BackblazeProvider) MultipartUpload(ctx context.Context, key string, rea
 			Key:        aws.String(key),
 			PartNumber: aws.Int32(partNumber),
 			UploadId:   createResult.UploadId,
-			Body:       strings.NewReader(string(buffer[:n])),
+			Body:       bytes.NewReader(buffer[:n]),
 		}
 
 		partResult, err := p.client.UploadPart(ctx, partInput)
@@ -228,18 +255,9 @@ func (p *BackblazeProvider) MultipartUpload(ctx context.Context, key string, rea
 			opts.ProgressCallback(totalBytesTransferred, totalBytesTransferred)
 		}
 
-		if readErr == io.EOF {
+		if readErr != nil { // Short final part
 			break
 		}
-		if readErr != nil {
-			// Abort multipart upload on error
-			_, _ = p.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
-				Bucket:   aws.String(p.config.Bucket),
-				Key:      aws.String(key),
-				UploadId: createResult.UploadId,
-			})
-			return nil, NewS3Error("backblaze", "read_data", key, 0, readErr)
-		}
 
 		partNumber++
 	}
@@ -274,11 +292,11 @@ func (p *BackblazeProvider) MultipartUpload(ctx context.Context, key string, rea
 		uploadResult.VersionID = aws.ToString(completeResult.VersionId)
 	}
 
-	// Set expiration if configured
+	// Report when SweepExpired deletes the object
 	if opts.ExpirationDays > 0 {
-		expiresAt := time.Now().AddDate(0, 0, opts.ExpirationDays)
 		uploadResult.ExpiresAt = &expiresAt
 	}
+	p.expiry.track(key, uploadResult.ExpiresAt)
 
 	return uploadResult, nil
 }
@@ -308,7 +326,7 @@ func (p *BackblazeProvider) UploadBase64(ctx context.Context, key string, data s
 	}
 
 	// Decode base64 data
-	decodedData, err := base64.StdEncoding.DecodeString(base64Data)
+	decodedData, err := DecodeBase64(base64Data)
 	if err != nil {
 		return nil, NewS3Error("backblaze", "decode_base64", key, 0, ErrInvalidBase64)
 	}
@@ -328,11 +346,43 @@ func (p *BackblazeProvider) GetPublicURL(key string) string {
 	return p.config.GetPublicURL(key)
 }
 
-// SetExpiration sets expiration for an object
-func (p *BackblazeProvider) SetExpiration(key string, days int) error {
-	// Backblaze B2 supports lifecycle rules for expiration
-	// This is a placeholder for future implementation
-	return ErrFeatureNotSupported
+// SetExpiration records when the object expires in its metadata, for
+// SweepExpired to delete it, by copying the object onto itself: B2 has no
+// object tags for lifecycle rules to match. The copy is a new object, so days
+// count from now. Days 0 removes the expiry.
+func (p *BackblazeProvider) SetExpiration(ctx context.Context, key string, days int) (*time.Time, error) {
+	info, err := p.GetObjectInfo(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt *time.Time
+	metadata := withoutExpiresAt(info.Metadata)
+	if days > 0 {
+		expiry := ExpirationDate(time.Now(), days)
+		metadata = withExpiresAt(metadata, expiry)
+		expiresAt = &expiry
+	}
+
+	_, err = p.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(p.config.Bucket),
+		Key:               aws.String(key),
+		CopySource:        aws.String(p.config.Bucket + "/" + (&url.URL{Path: key}).EscapedPath()),
+		MetadataDirective: types.MetadataDirectiveReplace,
+		Metadata:          metadata,
+		ContentType:       aws.String(info.ContentType),
+	})
+	if err != nil {
+		return nil, newObjectError("backblaze", "copy_object", key, httpStatusCode(err), err)
+	}
+
+	p.expiry.track(key, expiresAt)
+	return expiresAt, nil
+}
+
+// SweepExpired deletes the objects whose recorded expiry has passed
+func (p *BackblazeProvider) SweepExpired(ctx context.Context) (int, error) {
+	return p.expiry.sweep(ctx, p, "backblaze")
 }
 
 // HealthCheck verifies the provider connection and configuration
@@ -355,7 +405,7 @@ func (p *BackblazeProvider) DeleteObject(ctx context.Context, key string) error
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return NewS3Error("backblaze", "delete", key, 0, err)
+		return newObjectError("backblaze", "delete", key, httpStatusCode(err), err)
 	}
 
 	return nil
@@ -368,7 +418,7 @@ func (p *BackblazeProvider) GetObjectInfo(ctx context.Context, key string) (*Obj
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, NewS3Error("backblaze", "head_object", key, 0, err)
+		return nil, newObjectError("backblaze", "head_object", key, httpStatusCode(err), err)
 	}
 
 	info := &ObjectInfo{
@@ -390,3 +440,66 @@ func (p *BackblazeProvider) GetObjectInfo(ctx context.Context, key string) (*Obj
 
 	return info, nil
 }
+
+// ListObjects lists a page of objects with ListObjectsV2; the cursor is its
+// continuation token
+func (p *BackblazeProvider) ListObjects(ctx context.Context, opts ListOptions) (*ObjectList, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(p.config.Bucket),
+		MaxKeys: aws.Int32(int32(listLimit(opts.Limit))),
+	}
+	if opts.Prefix != "" {
+		input.Prefix = aws.String(opts.Prefix)
+	}
+	if opts.Cursor != "" {
+		input.ContinuationToken = aws.String(opts.Cursor)
+	}
+
+	result, err := p.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, NewS3Error("backblaze", "list_objects", opts.Prefix, httpStatusCode(err), err)
+	}
+
+	list := &ObjectList{Objects: make([]ObjectSummary, 0, len(result.Contents))}
+	for _, object := range result.Contents {
+		list.Objects = append(list.Objects, ObjectSummary{
+			Key:          aws.ToString(object.Key),
+			Size:         aws.ToInt64(object.Size),
+			ETag:         aws.ToString(object.ETag),
+			LastModified: aws.ToTime(object.LastModified),
+			StorageClass: string(object.StorageClass),
+		})
+	}
+	if aws.ToBool(result.IsTruncated) {
+		list.NextCursor = aws.ToString(result.NextContinuationToken)
+	}
+
+	return list, nil
+}
+
+// GetObject opens the object body for reading
+func (p *BackblazeProvider) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	result, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, newObjectError("backblaze", "get_object", key, httpStatusCode(err), err)
+	}
+
+	return result.Body, nil
+}
+
+// PresignGetURL returns a presigned GET URL valid for the given duration
+func (p *BackblazeProvider) PresignGetURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(p.client)
+	request, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.config.Bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", NewS3Error("backblaze", "presign", key, 0, err)
+	}
+
+	return request.URL, nil
+}
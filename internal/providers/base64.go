@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// NormalizeBase64 strips whitespace from data and picks the encoding it was
+// written with: standard or URL-safe alphabet, padded or unpadded (raw).
+func NormalizeBase64(data string) (string, *base64.Encoding) {
+	if strings.ContainsAny(data, " \t\r\n") {
+		data = strings.Map(func(r rune) rune {
+			switch r {
+			case ' ', '\t', '\r', '\n':
+				return -1
+			}
+			return r
+		}, data)
+	}
+
+	urlSafe := strings.ContainsAny(data, "-_")
+	padded := strings.HasSuffix(data, "=") || len(data)%4 == 0
+
+	switch {
+	case urlSafe && padded:
+		return data, base64.URLEncoding
+	case urlSafe:
+		return data, base64.RawURLEncoding
+	case padded:
+		return data, base64.StdEncoding
+	default:
+		return data, base64.RawStdEncoding
+	}
+}
+
+// DecodeBase64 decodes std, raw and URL-safe base64, tolerating whitespace and line breaks
+func DecodeBase64(data string) ([]byte, error) {
+	data, encoding := NormalizeBase64(data)
+	return encoding.DecodeString(data)
+}
@@ -0,0 +1,189 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Faults configures the faults a FaultInjectingProvider injects
+type Faults struct {
+	FailurePercent  int // Calls failing with a retryable 503
+	TruncatePercent int // Uploads acknowledged in full but stored truncated to half
+}
+
+// Enabled reports whether any fault is injected
+func (f Faults) Enabled() bool {
+	return f.FailurePercent > 0 || f.TruncatePercent > 0
+}
+
+// FaultInjectingProvider wraps an S3Provider and fails a percentage of calls
+// with a retryable 503, so clients can exercise their retry logic, and
+// truncates a percentage of uploads, so upload verification can be exercised
+type FaultInjectingProvider struct {
+	provider S3Provider
+	faults   Faults
+}
+
+// NewFaultInjectingProvider wraps provider, injecting faults into roughly
+// their percentage of every 100 calls
+func NewFaultInjectingProvider(provider S3Provider, faults Faults) *FaultInjectingProvider {
+	return &FaultInjectingProvider{
+		provider: provider,
+		faults:   faults,
+	}
+}
+
+// fault returns a simulated provider outage when the chaos roll hits
+func (p *FaultInjectingProvider) fault(operation, key string) error {
+	if p.faults.FailurePercent <= 0 || rand.IntN(100) >= p.faults.FailurePercent {
+		return nil
+	}
+	return NewS3Error("chaos", operation, key, http.StatusServiceUnavailable, ErrInjectedFault)
+}
+
+// truncated stores the first half of reader's data under key and answers as
+// if all of it had been stored, like a faulty gateway would
+func (p *FaultInjectingProvider) truncated(ctx context.Context, key string, reader io.Reader, opts UploadOptions) (*UploadResult, bool, error) {
+	if p.faults.TruncatePercent <= 0 || rand.IntN(100) >= p.faults.TruncatePercent {
+		return nil, false, nil
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, true, NewS3Error("chaos", "upload", key, 0, err)
+	}
+	half := data[:len(data)/2]
+	result, err := p.provider.Upload(ctx, key, bytes.NewReader(half), int64(len(half)), opts)
+	if err != nil {
+		return nil, true, err
+	}
+	result.Size = int64(len(data))
+	return result, true, nil
+}
+
+// Upload uploads data unless a fault is injected
+func (p *FaultInjectingProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts UploadOptions) (*UploadResult, error) {
+	if err := p.fault("upload", key); err != nil {
+		return nil, err
+	}
+	if result, ok, err := p.truncated(ctx, key, reader, opts); ok {
+		return result, err
+	}
+	return p.provider.Upload(ctx, key, reader, size, opts)
+}
+
+// MultipartUpload uploads data in parts unless a fault is injected
+func (p *FaultInjectingProvider) MultipartUpload(ctx context.Context, key string, reader io.Reader, opts UploadOptions) (*UploadResult, error) {
+	if err := p.fault("multipart_upload", key); err != nil {
+		return nil, err
+	}
+	if result, ok, err := p.truncated(ctx, key, reader, opts); ok {
+		return result, err
+	}
+	return p.provider.MultipartUpload(ctx, key, reader, opts)
+}
+
+// UploadBase64 uploads base64 data unless a fault is injected
+func (p *FaultInjectingProvider) UploadBase64(ctx context.Context, key string, data string, opts UploadOptions) (*UploadResult, error) {
+	if err := p.fault("upload_base64", key); err != nil {
+		return nil, err
+	}
+	if p.faults.TruncatePercent > 0 {
+		payload := data
+		if _, after, found := strings.Cut(data, ","); found && strings.HasPrefix(data, "data:") {
+			payload = after
+		}
+		if decoded, err := DecodeBase64(payload); err == nil {
+			if result, ok, err := p.truncated(ctx, key, bytes.NewReader(decoded), opts); ok {
+				return result, err
+			}
+		}
+	}
+	return p.provider.UploadBase64(ctx, key, data, opts)
+}
+
+// GetPublicURL is never faulted since it doesn't touch the network
+func (p *FaultInjectingProvider) GetPublicURL(key string) string {
+	return p.provider.GetPublicURL(key)
+}
+
+// SetExpiration sets expiration unless a fault is injected
+func (p *FaultInjectingProvider) SetExpiration(ctx context.Context, key string, days int) (*time.Time, error) {
+	if err := p.fault("set_expiration", key); err != nil {
+		return nil, err
+	}
+	return p.provider.SetExpiration(ctx, key, days)
+}
+
+// HealthCheck checks the wrapped provider unless a fault is injected
+func (p *FaultInjectingProvider) HealthCheck(ctx context.Context) error {
+	if err := p.fault("health_check", ""); err != nil {
+		return err
+	}
+	return p.provider.HealthCheck(ctx)
+}
+
+// DeleteObject deletes an object unless a fault is injected
+func (p *FaultInjectingProvider) DeleteObject(ctx context.Context, key string) error {
+	if err := p.fault("delete", key); err != nil {
+		return err
+	}
+	return p.provider.DeleteObject(ctx, key)
+}
+
+// GetObjectInfo retrieves object metadata unless a fault is injected
+func (p *FaultInjectingProvider) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := p.fault("head_object", key); err != nil {
+		return nil, err
+	}
+	return p.provider.GetObjectInfo(ctx, key)
+}
+
+// ListObjects lists through the wrapped provider unless a fault is injected
+func (p *FaultInjectingProvider) ListObjects(ctx context.Context, opts ListOptions) (*ObjectList, error) {
+	if err := p.fault("list_objects", opts.Prefix); err != nil {
+		return nil, err
+	}
+	return p.provider.ListObjects(ctx, opts)
+}
+
+// GetObject reads through the wrapped provider unless a fault is injected
+func (p *FaultInjectingProvider) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, ok := p.provider.(ObjectReader)
+	if !ok {
+		return nil, ErrFeatureNotSupported
+	}
+	if err := p.fault("get_object", key); err != nil {
+		return nil, err
+	}
+	return reader.GetObject(ctx, key)
+}
+
+// PresignGetURL presigns through the wrapped provider unless a fault is injected
+func (p *FaultInjectingProvider) PresignGetURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	presigner, ok := p.provider.(Presigner)
+	if !ok {
+		return "", ErrFeatureNotSupported
+	}
+	if err := p.fault("presign", key); err != nil {
+		return "", err
+	}
+	return presigner.PresignGetURL(ctx, key, expires)
+}
+
+// SweepExpired sweeps through the wrapped provider unless a fault is injected
+func (p *FaultInjectingProvider) SweepExpired(ctx context.Context) (int, error) {
+	sweeper, ok := p.provider.(ExpirySweeper)
+	if !ok {
+		return 0, ErrFeatureNotSupported
+	}
+	if err := p.fault("sweep_expired", ""); err != nil {
+		return 0, err
+	}
+	return sweeper.SweepExpired(ctx)
+}
@@ -0,0 +1,627 @@
+// Package conformance verifies that an S3Provider honours the interface contract.
+//
+// The suite is provider-agnostic: it only talks to providers.S3Provider (and the
+// optional providers.Presigner and providers.ObjectReader), so every new provider can be run through the
+// same checks before it is wired into the factory.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"whats-convert-api/internal/providers"
+)
+
+const (
+	// defaultMultipartSize exceeds the 5MiB minimum part size enforced by S3
+	defaultMultipartSize = 6 * 1024 * 1024
+
+	// defaultKeyPrefix groups every object written by the suite
+	defaultKeyPrefix = "conformance/"
+)
+
+// Options tunes a conformance run
+type Options struct {
+	// KeyPrefix is prepended to every object key written by the suite
+	KeyPrefix string
+
+	// MultipartSize is the payload size used by the multipart check
+	MultipartSize int64
+
+	// FetchURLs downloads presigned URLs over HTTP to verify their content.
+	// Disable it for providers whose URLs aren't reachable (e.g. the mock provider).
+	FetchURLs bool
+
+	// HTTPClient is used when FetchURLs is enabled
+	HTTPClient *http.Client
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report aggregates every check of a run
+type Report struct {
+	Results []Result `json:"results"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Skipped int      `json:"skipped"`
+}
+
+// OK reports whether no check failed
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
+
+// errSkipped marks a check that doesn't apply to the provider under test
+var errSkipped = errors.New("skipped")
+
+type check struct {
+	name string
+	run  func(ctx context.Context, s *suite) error
+}
+
+// suite holds the state shared between checks of a run
+type suite struct {
+	provider providers.S3Provider
+	opts     Options
+	runID    string
+	payload  []byte
+	written  []string
+}
+
+// checks run in order; later checks rely on objects written by earlier ones
+var checks = []check{
+	{"health_check", checkHealth},
+	{"upload", checkUpload},
+	{"object_info", checkObjectInfo},
+	{"public_url", checkPublicURL},
+	{"presign", checkPresign},
+	{"get_object", checkGetObject},
+	{"multipart_upload", checkMultipartUpload},
+	{"stream_upload", checkStreamUpload},
+	{"stream_upload_failed", checkStreamUploadFailed},
+	{"upload_base64", checkUploadBase64},
+	{"upload_base64_url_safe", checkUploadBase64URLSafe},
+	{"upload_base64_invalid", checkUploadBase64Invalid},
+	{"object_info_missing", checkObjectInfoMissing},
+	{"list_objects", checkListObjects},
+	{"set_expiration", checkSetExpiration},
+	{"delete", checkDelete},
+	{"cancelled_context", checkCancelledContext},
+}
+
+// newSuite fills opts' defaults and starts a run against provider
+func newSuite(provider providers.S3Provider, opts Options) *suite {
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = defaultKeyPrefix
+	}
+	if opts.MultipartSize <= 0 {
+		opts.MultipartSize = defaultMultipartSize
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &suite{
+		provider: provider,
+		opts:     opts,
+		runID:    uuid.New().String(),
+		payload:  []byte("whats-convert-api conformance payload"),
+	}
+}
+
+// Check executes every check against provider, removes the objects it wrote
+// and reports the outcome of each
+func Check(ctx context.Context, provider providers.S3Provider, opts Options) *Report {
+	s := newSuite(provider, opts)
+	defer s.cleanup()
+
+	report := &Report{Results: make([]Result, 0, len(checks))}
+	for _, c := range checks {
+		start := time.Now()
+		err := c.run(ctx, s)
+
+		result := Result{Name: c.name, Duration: time.Since(start)}
+		switch {
+		case errors.Is(err, errSkipped):
+			result.Skipped = true
+			result.Error = err.Error()
+			report.Skipped++
+		case err != nil:
+			result.Error = err.Error()
+			report.Failed++
+		default:
+			result.Passed = true
+			report.Passed++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	return report
+}
+
+// Run executes the suite as subtests of t, one per check, so a provider's
+// tests can assert it honours the contract. Presigned URLs aren't fetched;
+// use RunWithOptions for providers whose URLs are reachable.
+func Run(t *testing.T, provider providers.S3Provider) {
+	t.Helper()
+	RunWithOptions(t, provider, Options{})
+}
+
+// RunWithOptions is Run with the suite tuned by opts
+func RunWithOptions(t *testing.T, provider providers.S3Provider, opts Options) {
+	t.Helper()
+
+	s := newSuite(provider, opts)
+	t.Cleanup(s.cleanup)
+
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.run(t.Context(), s)
+			switch {
+			case errors.Is(err, errSkipped):
+				t.Skip(err)
+			case err != nil:
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// key returns a unique object key for this run
+func (s *suite) key(name string) string {
+	key := s.opts.KeyPrefix + s.runID + "/" + name
+	s.written = append(s.written, key)
+	return key
+}
+
+// cleanup removes every object written during the run, ignoring errors
+func (s *suite) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, key := range s.written {
+		_ = s.provider.DeleteObject(ctx, key)
+	}
+}
+
+func checkHealth(ctx context.Context, s *suite) error {
+	return s.provider.HealthCheck(ctx)
+}
+
+func checkUpload(ctx context.Context, s *suite) error {
+	key := s.key("upload.txt")
+	result, err := s.provider.Upload(ctx, key, bytes.NewReader(s.payload), int64(len(s.payload)), providers.UploadOptions{
+		ContentType: "text/plain",
+		Metadata:    map[string]string{"conformance": "true"},
+	})
+	if err != nil {
+		return err
+	}
+
+	return verifyUploadResult(s.provider, result, key, int64(len(s.payload)))
+}
+
+func checkObjectInfo(ctx context.Context, s *suite) error {
+	key := s.opts.KeyPrefix + s.runID + "/upload.txt"
+	info, err := s.provider.GetObjectInfo(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if info.Size != int64(len(s.payload)) {
+		return fmt.Errorf("size = %d, want %d", info.Size, len(s.payload))
+	}
+	if !strings.HasPrefix(info.ContentType, "text/plain") {
+		return fmt.Errorf("content type = %q, want text/plain", info.ContentType)
+	}
+	if info.ETag == "" {
+		return errors.New("empty ETag")
+	}
+	if info.LastModified.IsZero() {
+		return errors.New("zero LastModified")
+	}
+
+	return nil
+}
+
+func checkPublicURL(ctx context.Context, s *suite) error {
+	key := s.opts.KeyPrefix + s.runID + "/upload.txt"
+	publicURL := s.provider.GetPublicURL(key)
+
+	parsed, err := url.Parse(publicURL)
+	if err != nil {
+		return fmt.Errorf("invalid public URL %q: %w", publicURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("public URL %q is not absolute http(s)", publicURL)
+	}
+	if !strings.HasSuffix(parsed.Path, "/"+key) {
+		return fmt.Errorf("public URL %q does not end with key %q", publicURL, key)
+	}
+
+	return nil
+}
+
+func checkPresign(ctx context.Context, s *suite) error {
+	presigner, ok := s.provider.(providers.Presigner)
+	if !ok {
+		return fmt.Errorf("%w: provider does not implement Presigner", errSkipped)
+	}
+
+	key := s.opts.KeyPrefix + s.runID + "/upload.txt"
+	presignedURL, err := presigner.PresignGetURL(ctx, key, 5*time.Minute)
+	if err != nil {
+		return err
+	}
+	if _, err := url.ParseRequestURI(presignedURL); err != nil {
+		return fmt.Errorf("invalid presigned URL %q: %w", presignedURL, err)
+	}
+
+	if !s.opts.FetchURLs {
+		return nil
+	}
+
+	body, err := fetch(ctx, s.opts.HTTPClient, presignedURL)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(body, s.payload) {
+		return fmt.Errorf("presigned URL returned %d bytes, want the %d uploaded", len(body), len(s.payload))
+	}
+
+	return nil
+}
+
+func checkGetObject(ctx context.Context, s *suite) error {
+	reader, ok := s.provider.(providers.ObjectReader)
+	if !ok {
+		return fmt.Errorf("%w: provider does not implement ObjectReader", errSkipped)
+	}
+
+	body, err := reader.GetObject(ctx, s.opts.KeyPrefix+s.runID+"/upload.txt")
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, s.payload) {
+		return fmt.Errorf("GetObject returned %d bytes, want the %d uploaded", len(data), len(s.payload))
+	}
+
+	_, err = reader.GetObject(ctx, s.opts.KeyPrefix+s.runID+"/does-not-exist")
+	return expectError(err, providers.ErrObjectNotFound)
+}
+
+func checkMultipartUpload(ctx context.Context, s *suite) error {
+	payload := make([]byte, s.opts.MultipartSize)
+	if _, err := rand.Read(payload); err != nil {
+		return err
+	}
+
+	key := s.key("multipart.bin")
+	result, err := s.provider.MultipartUpload(ctx, key, bytes.NewReader(payload), providers.UploadOptions{
+		ContentType: "application/octet-stream",
+		ChunkSize:   5 * 1024 * 1024,
+	})
+	if err != nil {
+		return err
+	}
+	if err := verifyUploadResult(s.provider, result, key, int64(len(payload))); err != nil {
+		return err
+	}
+
+	info, err := s.provider.GetObjectInfo(ctx, key)
+	if err != nil {
+		return err
+	}
+	if info.Size != int64(len(payload)) {
+		return fmt.Errorf("stored size = %d, want %d", info.Size, len(payload))
+	}
+
+	return nil
+}
+
+// checkStreamUpload uploads a stream of unknown size arriving in short
+// writes, the way a converter's output is piped in
+func checkStreamUpload(ctx context.Context, s *suite) error {
+	payload := make([]byte, s.opts.MultipartSize)
+	if _, err := rand.Read(payload); err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		for chunk := range slices.Chunk(payload, 64*1024) {
+			if _, err := writer.Write(chunk); err != nil {
+				return
+			}
+		}
+		writer.Close()
+	}()
+
+	key := s.key("stream.bin")
+	result, err := s.provider.Upload(ctx, key, reader, -1, providers.UploadOptions{
+		ContentType: "application/octet-stream",
+		ChunkSize:   5 * 1024 * 1024,
+	})
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	if err := verifyUploadResult(s.provider, result, key, int64(len(payload))); err != nil {
+		return err
+	}
+
+	info, err := s.provider.GetObjectInfo(ctx, key)
+	if err != nil {
+		return err
+	}
+	if info.Size != int64(len(payload)) {
+		return fmt.Errorf("stored size = %d, want %d", info.Size, len(payload))
+	}
+
+	return nil
+}
+
+// checkStreamUploadFailed breaks a stream midway: the upload must fail and
+// leave no object behind
+func checkStreamUploadFailed(ctx context.Context, s *suite) error {
+	streamErr := errors.New("conversion failed")
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.Write(s.payload)
+		writer.CloseWithError(streamErr)
+	}()
+
+	key := s.key("stream-failed.bin")
+	_, err := s.provider.Upload(ctx, key, reader, -1, providers.UploadOptions{
+		ContentType: "application/octet-stream",
+	})
+	reader.Close()
+	if err == nil {
+		return errors.New("upload of a failed stream succeeded")
+	}
+
+	_, err = s.provider.GetObjectInfo(ctx, key)
+	return expectError(err, providers.ErrObjectNotFound)
+}
+
+func checkUploadBase64(ctx context.Context, s *suite) error {
+	key := s.key("base64.txt")
+	data := "data:text/plain;base64," + base64.StdEncoding.EncodeToString(s.payload)
+
+	result, err := s.provider.UploadBase64(ctx, key, data, providers.UploadOptions{})
+	if err != nil {
+		return err
+	}
+	if err := verifyUploadResult(s.provider, result, key, int64(len(s.payload))); err != nil {
+		return err
+	}
+
+	info, err := s.provider.GetObjectInfo(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(info.ContentType, "text/plain") {
+		return fmt.Errorf("content type = %q, want text/plain detected from data URI", info.ContentType)
+	}
+
+	return nil
+}
+
+func checkUploadBase64URLSafe(ctx context.Context, s *suite) error {
+	key := s.key("base64-url-safe.bin")
+	payload := []byte{0xfb, 0xff, 0xbf, 0x01} // Encodes to '-' and '_' with padding dropped
+	data := base64.RawURLEncoding.EncodeToString(payload)
+
+	result, err := s.provider.UploadBase64(ctx, key, data, providers.UploadOptions{ContentType: "application/octet-stream"})
+	if err != nil {
+		return err
+	}
+
+	return verifyUploadResult(s.provider, result, key, int64(len(payload)))
+}
+
+func checkUploadBase64Invalid(ctx context.Context, s *suite) error {
+	key := s.opts.KeyPrefix + s.runID + "/invalid.txt"
+
+	_, err := s.provider.UploadBase64(ctx, key, "not base64!", providers.UploadOptions{})
+	return expectError(err, providers.ErrInvalidBase64)
+}
+
+func checkObjectInfoMissing(ctx context.Context, s *suite) error {
+	key := s.opts.KeyPrefix + s.runID + "/does-not-exist"
+
+	_, err := s.provider.GetObjectInfo(ctx, key)
+	return expectError(err, providers.ErrObjectNotFound)
+}
+
+// checkListObjects walks the run's objects two at a time; by now the run has
+// written several, so the listing must span pages
+func checkListObjects(ctx context.Context, s *suite) error {
+	prefix := s.opts.KeyPrefix + s.runID + "/"
+
+	var keys []string
+	opts := providers.ListOptions{Prefix: prefix, Limit: 2}
+	for pages := 1; ; pages++ {
+		list, err := s.provider.ListObjects(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if len(list.Objects) > opts.Limit {
+			return fmt.Errorf("page %d has %d objects, limit is %d", pages, len(list.Objects), opts.Limit)
+		}
+		for _, object := range list.Objects {
+			if !strings.HasPrefix(object.Key, prefix) {
+				return fmt.Errorf("listed %q outside prefix %q", object.Key, prefix)
+			}
+			keys = append(keys, object.Key)
+		}
+		if list.NextCursor == "" {
+			if pages < 2 {
+				return fmt.Errorf("%d objects fit in one page of %d", len(keys), opts.Limit)
+			}
+			break
+		}
+		if pages > 100 {
+			return fmt.Errorf("listing didn't end after %d pages", pages)
+		}
+		opts.Cursor = list.NextCursor
+	}
+
+	if !slices.IsSorted(keys) || len(slices.Compact(slices.Clone(keys))) != len(keys) {
+		return fmt.Errorf("keys are not listed once each in order: %v", keys)
+	}
+	if !slices.Contains(keys, prefix+"upload.txt") {
+		return fmt.Errorf("%supload.txt is missing from the listing", prefix)
+	}
+	return nil
+}
+
+func checkSetExpiration(ctx context.Context, s *suite) error {
+	key := s.key("expiring.txt")
+	result, err := s.provider.Upload(ctx, key, bytes.NewReader(s.payload), int64(len(s.payload)), providers.UploadOptions{
+		ContentType:    "text/plain",
+		ExpirationDays: 1,
+	})
+	if err != nil {
+		return err
+	}
+	if result.ExpiresAt == nil || result.ExpiresAt.Before(time.Now().Add(24*time.Hour)) {
+		return fmt.Errorf("upload expiring after 1 day reports expires_at %v (is the lifecycle rule allowed?)", result.ExpiresAt)
+	}
+
+	expiresAt, err := s.provider.SetExpiration(ctx, key, 2)
+	if err != nil {
+		return err
+	}
+	if expiresAt == nil || !expiresAt.After(*result.ExpiresAt) {
+		return fmt.Errorf("expiration set to 2 days reports %v, upload reported %v", expiresAt, result.ExpiresAt)
+	}
+	if expiresAt, err = s.provider.SetExpiration(ctx, key, 0); err != nil {
+		return err
+	}
+	if expiresAt != nil {
+		return fmt.Errorf("removed expiration still reports %v", expiresAt)
+	}
+
+	_, err = s.provider.SetExpiration(ctx, s.opts.KeyPrefix+s.runID+"/missing.txt", 1)
+	if err := expectError(err, providers.ErrObjectNotFound); err != nil {
+		return fmt.Errorf("missing object: %w", err)
+	}
+	return nil
+}
+
+func checkDelete(ctx context.Context, s *suite) error {
+	key := s.key("delete.txt")
+	if _, err := s.provider.Upload(ctx, key, bytes.NewReader(s.payload), int64(len(s.payload)), providers.UploadOptions{
+		ContentType: "text/plain",
+	}); err != nil {
+		return err
+	}
+
+	if err := s.provider.DeleteObject(ctx, key); err != nil {
+		return err
+	}
+
+	_, err := s.provider.GetObjectInfo(ctx, key)
+	if err := expectError(err, providers.ErrObjectNotFound); err != nil {
+		return fmt.Errorf("after delete: %w", err)
+	}
+
+	return nil
+}
+
+func checkCancelledContext(ctx context.Context, s *suite) error {
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	key := s.key("cancelled.txt")
+	_, err := s.provider.Upload(cancelled, key, bytes.NewReader(s.payload), int64(len(s.payload)), providers.UploadOptions{})
+	if err == nil {
+		return errors.New("upload succeeded with a cancelled context")
+	}
+
+	return nil
+}
+
+// verifyUploadResult checks the fields every provider must fill in
+func verifyUploadResult(provider providers.S3Provider, result *providers.UploadResult, key string, size int64) error {
+	if result == nil {
+		return errors.New("nil upload result")
+	}
+	if result.Key != key {
+		return fmt.Errorf("result key = %q, want %q", result.Key, key)
+	}
+	if result.Size != size {
+		return fmt.Errorf("result size = %d, want %d", result.Size, size)
+	}
+	if result.Provider == "" {
+		return errors.New("result provider is empty")
+	}
+	if result.PublicURL != provider.GetPublicURL(key) {
+		return fmt.Errorf("result URL = %q, want GetPublicURL %q", result.PublicURL, provider.GetPublicURL(key))
+	}
+
+	return nil
+}
+
+// expectError checks that err wraps target inside a *providers.S3Error
+func expectError(err, target error) error {
+	if err == nil {
+		return fmt.Errorf("expected %v, got nil", target)
+	}
+
+	var s3Err *providers.S3Error
+	if !errors.As(err, &s3Err) {
+		return fmt.Errorf("error is %T, want *providers.S3Error: %v", err, err)
+	}
+	if !errors.Is(err, target) {
+		return fmt.Errorf("error does not wrap %q: %v", target, err)
+	}
+
+	return nil
+}
+
+func fetch(ctx context.Context, client *http.Client, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET presigned URL returned HTTP %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,58 @@
+package providers_test
+
+import (
+	"os"
+	"testing"
+
+	"whats-convert-api/internal/config"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/providers/conformance"
+)
+
+func TestMockProviderConformance(t *testing.T) {
+	provider, err := providers.NewMockProvider(&providers.S3Config{
+		Provider:  "mock",
+		Endpoint:  "http://mock.local",
+		Bucket:    "tests",
+		AccessKey: "mock",
+		SecretKey: "mock",
+	})
+	if err != nil {
+		t.Fatalf("NewMockProvider: %v", err)
+	}
+
+	conformance.Run(t, provider)
+}
+
+// TestMinIOProviderConformance runs the suite against the MinIO at
+// S3_CONFORMANCE_MINIO_ENDPOINT (e.g. http://localhost:9000 from
+// docker-compose); the bucket must exist. Skipped when unset.
+func TestMinIOProviderConformance(t *testing.T) {
+	endpoint := os.Getenv("S3_CONFORMANCE_MINIO_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_CONFORMANCE_MINIO_ENDPOINT not set")
+	}
+
+	t.Setenv("S3_PROVIDER", "minio")
+	t.Setenv("S3_ENDPOINT", endpoint)
+	t.Setenv("S3_PUBLIC_ENDPOINT", endpoint)
+	t.Setenv("S3_REGION", "us-east-1")
+	t.Setenv("S3_BUCKET", getEnv("S3_CONFORMANCE_BUCKET", "conformance"))
+	t.Setenv("S3_ACCESS_KEY", getEnv("S3_CONFORMANCE_ACCESS_KEY", "minioadmin"))
+	t.Setenv("S3_SECRET_KEY", getEnv("S3_CONFORMANCE_SECRET_KEY", "minioadmin123"))
+	t.Setenv("S3_PATH_STYLE", "true")
+
+	provider, err := providers.NewProviderFactory().CreateProvider(config.LoadS3Config().ToProviderConfig())
+	if err != nil {
+		t.Fatalf("CreateProvider: %v", err)
+	}
+
+	conformance.RunWithOptions(t, provider, conformance.Options{FetchURLs: true})
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
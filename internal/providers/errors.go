@@ -1,6 +1,12 @@
 package providers
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/minio/minio-go/v7"
+)
 
 // Provider errors
 var (
@@ -40,6 +46,9 @@ var (
 	ErrTimeout        = errors.New("operation timed out")
 	ErrNetworkError   = errors.New("network error during S3 operation")
 	ErrRetryExhausted = errors.New("maximum retry attempts exceeded")
+
+	// Chaos testing errors
+	ErrInjectedFault = errors.New("injected fault")
 )
 
 // S3Error wraps provider-specific errors with additional context
@@ -73,6 +82,24 @@ func NewS3Error(provider, operation, key string, statusCode int, err error) *S3E
 	}
 }
 
+// newObjectError creates an S3Error for object operations, wrapping
+// ErrObjectNotFound when the provider answered with HTTP 404
+func newObjectError(provider, operation, key string, statusCode int, err error) *S3Error {
+	if statusCode == http.StatusNotFound && !errors.Is(err, ErrObjectNotFound) {
+		err = fmt.Errorf("%w: %v", ErrObjectNotFound, err)
+	}
+	return NewS3Error(provider, operation, key, statusCode, err)
+}
+
+// httpStatusCode extracts the HTTP status code carried by AWS SDK errors (0 if none)
+func httpStatusCode(err error) int {
+	var responseErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &responseErr) {
+		return responseErr.HTTPStatusCode()
+	}
+	return 0
+}
+
 // IsRetryableError checks if an error should trigger a retry
 func IsRetryableError(err error) bool {
 	if err == nil {
@@ -166,3 +193,34 @@ func IsPermanentError(err error) bool {
 
 	return false
 }
+
+// ErrorCode returns the S3 error code carried by err, such as
+// SignatureDoesNotMatch or AccessDenied, or "" when the provider sent none
+// (HEAD responses have no body to carry one)
+func ErrorCode(err error) string {
+	var apiErr interface{ ErrorCode() string } // AWS SDK (smithy.APIError)
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	var minioErr minio.ErrorResponse
+	if errors.As(err, &minioErr) {
+		return minioErr.Code
+	}
+	return ""
+}
+
+// ErrorStatusCode returns the HTTP status the provider answered err with (0 if none)
+func ErrorStatusCode(err error) int {
+	var s3Err *S3Error
+	if errors.As(err, &s3Err) && s3Err.StatusCode != 0 {
+		return s3Err.StatusCode
+	}
+	if status := httpStatusCode(err); status != 0 {
+		return status
+	}
+	var minioErr minio.ErrorResponse
+	if errors.As(err, &minioErr) {
+		return minioErr.StatusCode
+	}
+	return 0
+}
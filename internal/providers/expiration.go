@@ -0,0 +1,244 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ExpirationTag is the object tag the bucket lifecycle rules of AWS and MinIO
+// match to expire objects; its value is the number of days
+const ExpirationTag = "whats-convert-expire-days"
+
+// ExpiresAtMeta is the metadata holding when an object expires on providers
+// whose lifecycle rules can't match tags (Backblaze B2); SweepExpired deletes
+// the objects past it
+const ExpiresAtMeta = "expires-at"
+
+// MaxExpirationDays bounds UploadOptions.ExpirationDays and SetExpiration
+const MaxExpirationDays = 3650
+
+// ExpirationDate returns when an object created at created is deleted after
+// days: like S3 lifecycle rules, at the first midnight UTC after created+days
+func ExpirationDate(created time.Time, days int) time.Time {
+	return created.UTC().AddDate(0, 0, days).Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// expirationRuleID names the lifecycle rule expiring objects tagged with days
+func expirationRuleID(days int) string {
+	return fmt.Sprintf("whats-convert-expire-%dd", days)
+}
+
+// expirationTagging encodes the tag set of an object expiring after days,
+// as sent in the x-amz-tagging header
+func expirationTagging(days int) string {
+	return url.Values{ExpirationTag: {strconv.Itoa(days)}}.Encode()
+}
+
+// expirationRules remembers the lifecycle rules a provider already ensured,
+// so the bucket configuration is read and written once per distinct days
+type expirationRules struct {
+	mu      sync.Mutex
+	ensured map[int]bool
+}
+
+// ensure runs put unless the rule for days was already ensured. Calls are
+// serialized so concurrent uploads don't overwrite each other's rules.
+func (r *expirationRules) ensure(days int, put func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ensured[days] {
+		return nil
+	}
+	if err := put(); err != nil {
+		return err
+	}
+	if r.ensured == nil {
+		r.ensured = make(map[int]bool)
+	}
+	r.ensured[days] = true
+	return nil
+}
+
+// uploadExpiry returns when an upload expiring after days is deleted, or nil
+// when its lifecycle rule couldn't be set up: the object is still tagged, so
+// a rule added later by hand applies to it
+func uploadExpiry(provider, key string, days int, ruleErr error) *time.Time {
+	if ruleErr != nil {
+		log.Printf("⚠️ S3 %s: no lifecycle rule expires '%s' after %d days: %v", provider, key, days, ruleErr)
+		return nil
+	}
+	expiresAt := ExpirationDate(time.Now(), days)
+	return &expiresAt
+}
+
+// withExpiresAt returns a copy of metadata recording expiresAt in ExpiresAtMeta
+func withExpiresAt(metadata map[string]string, expiresAt time.Time) map[string]string {
+	metadata = withoutExpiresAt(metadata)
+	metadata[ExpiresAtMeta] = expiresAt.UTC().Format(time.RFC3339)
+	return metadata
+}
+
+// withoutExpiresAt returns a copy of metadata without ExpiresAtMeta
+func withoutExpiresAt(metadata map[string]string) map[string]string {
+	metadata = maps.Clone(metadata)
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	delete(metadata, ExpiresAtMeta)
+	return metadata
+}
+
+// expirySweepBatch bounds how many listed objects one sweep looks up, so a
+// large bucket is walked over several sweeps instead of all at once
+const expirySweepBatch = MaxListLimit
+
+// expirySweep is the state a provider's SweepExpired keeps between sweeps:
+// an index of the objects this process gave an expiry, deleted without
+// listing the bucket, and the cursor of the walk that finds the others
+// (written before a restart or by another instance). The walk resumes where
+// the previous sweep stopped and starts over once it reaches the end.
+type expirySweep struct {
+	sweeping sync.Mutex // Serializes sweeps
+
+	mu     sync.Mutex
+	due    map[string]time.Time
+	cursor string
+}
+
+// track records that key expires at expiresAt; nil forgets its expiry
+func (s *expirySweep) track(key string, expiresAt *time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if expiresAt == nil {
+		delete(s.due, key)
+		return
+	}
+	if s.due == nil {
+		s.due = make(map[string]time.Time)
+	}
+	s.due[key] = *expiresAt
+}
+
+// tracked reports whether key is in the index
+func (s *expirySweep) tracked(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.due[key]
+	return ok
+}
+
+// sweep deletes the objects of provider whose ExpiresAtMeta has passed:
+// first the indexed ones that are due, then those found by walking up to
+// expirySweepBatch more objects of the bucket. An object that can't be
+// looked up or deleted is logged and retried on a later sweep; only a
+// failed listing or the context ends the sweep early.
+func (s *expirySweep) sweep(ctx context.Context, provider S3Provider, name string) (int, error) {
+	s.sweeping.Lock()
+	defer s.sweeping.Unlock()
+
+	now := time.Now()
+	deleted := 0
+
+	s.mu.Lock()
+	var due []string
+	for key, expiresAt := range s.due {
+		if !now.Before(expiresAt) {
+			due = append(due, key)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, key := range due {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		expired, expiresAt, err := expireObject(ctx, provider, key, now)
+		if err != nil {
+			log.Printf("⚠️ S3 %s: expiry sweep skipped '%s': %v", name, key, err)
+			continue
+		}
+		if expired {
+			deleted++
+		}
+		// Deleted, gone, or given another expiry since it was indexed
+		s.track(key, expiresAt)
+	}
+
+	// Listings carry no metadata, so each object old enough to have expired
+	// is looked up; none expires within a day of its creation
+	cutoff := now.Add(-24 * time.Hour)
+	s.mu.Lock()
+	cursor := s.cursor
+	s.mu.Unlock()
+
+	for walked := 0; walked < expirySweepBatch; {
+		list, err := provider.ListObjects(ctx, ListOptions{Cursor: cursor})
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, object := range list.Objects {
+			walked++
+			if object.LastModified.After(cutoff) || s.tracked(object.Key) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return deleted, err
+			}
+			expired, expiresAt, err := expireObject(ctx, provider, object.Key, now)
+			if err != nil {
+				log.Printf("⚠️ S3 %s: expiry sweep skipped '%s': %v", name, object.Key, err)
+				continue
+			}
+			if expired {
+				deleted++
+			}
+			s.track(object.Key, expiresAt)
+		}
+
+		cursor = list.NextCursor
+		s.mu.Lock()
+		s.cursor = cursor
+		s.mu.Unlock()
+		if cursor == "" {
+			break
+		}
+	}
+
+	return deleted, nil
+}
+
+// expireObject deletes key when its ExpiresAtMeta has passed. Otherwise it
+// returns the expiry still ahead, or nil when the object doesn't expire or
+// no longer exists.
+func expireObject(ctx context.Context, provider S3Provider, key string, now time.Time) (bool, *time.Time, error) {
+	info, err := provider.GetObjectInfo(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, info.Metadata[ExpiresAtMeta])
+	if err != nil {
+		return false, nil, nil
+	}
+	if now.Before(expiresAt) {
+		return false, &expiresAt, nil
+	}
+
+	if err := provider.DeleteObject(ctx, key); err != nil && !errors.Is(err, ErrObjectNotFound) {
+		return false, nil, err
+	}
+	return true, nil, nil
+}
@@ -0,0 +1,166 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestMockProvider returns an empty in-memory provider
+func newTestMockProvider(t *testing.T) *MockProvider {
+	t.Helper()
+
+	provider, err := NewMockProvider(&S3Config{
+		Provider:  "mock",
+		Endpoint:  "http://mock.local",
+		Bucket:    "tests",
+		AccessKey: "mock",
+		SecretKey: "mock",
+	})
+	if err != nil {
+		t.Fatalf("NewMockProvider: %v", err)
+	}
+	return provider
+}
+
+// putExpiring stores key with ExpiresAtMeta set to expiresAt and, when age is
+// positive, a LastModified that long ago; the sweep index doesn't know it
+func putExpiring(t *testing.T, p *MockProvider, key string, expiresAt time.Time, age time.Duration) {
+	t.Helper()
+
+	if _, err := p.Upload(context.Background(), key, strings.NewReader("data"), 4, UploadOptions{}); err != nil {
+		t.Fatalf("Upload %s: %v", key, err)
+	}
+	p.mu.Lock()
+	info := p.objects[key]
+	info.Metadata = withExpiresAt(info.Metadata, expiresAt)
+	info.LastModified = info.LastModified.Add(-age)
+	p.mu.Unlock()
+}
+
+func (p *MockProvider) exists(key string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.objects[key]
+	return ok
+}
+
+func TestSweepExpiredIndexed(t *testing.T) {
+	p := newTestMockProvider(t)
+	ctx := context.Background()
+
+	if _, err := p.Upload(ctx, "fresh.txt", strings.NewReader("data"), 4, UploadOptions{ExpirationDays: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Upload(ctx, "due.txt", strings.NewReader("data"), 4, UploadOptions{ExpirationDays: 1}); err != nil {
+		t.Fatal(err)
+	}
+	// Expire due.txt in the index and its metadata; it is too new for the walk
+	past := time.Now().Add(-time.Minute)
+	p.mu.Lock()
+	p.objects["due.txt"].Metadata = withExpiresAt(p.objects["due.txt"].Metadata, past)
+	p.mu.Unlock()
+	p.expiry.track("due.txt", &past)
+
+	deleted, err := p.SweepExpired(ctx)
+	if err != nil || deleted != 1 {
+		t.Fatalf("SweepExpired = %d, %v; want 1", deleted, err)
+	}
+	if p.exists("due.txt") || !p.exists("fresh.txt") {
+		t.Errorf("due.txt exists %v, fresh.txt exists %v", p.exists("due.txt"), p.exists("fresh.txt"))
+	}
+	if p.expiry.tracked("due.txt") {
+		t.Error("due.txt still indexed after deletion")
+	}
+}
+
+func TestSweepExpiredWalk(t *testing.T) {
+	p := newTestMockProvider(t)
+	past := time.Now().Add(-time.Hour)
+
+	putExpiring(t, p, "old/expired.txt", past, 48*time.Hour)
+	putExpiring(t, p, "old/later.txt", time.Now().Add(time.Hour), 48*time.Hour)
+	putExpiring(t, p, "new/expired.txt", past, 0)
+
+	deleted, err := p.SweepExpired(context.Background())
+	if err != nil || deleted != 1 {
+		t.Fatalf("SweepExpired = %d, %v; want 1", deleted, err)
+	}
+	if p.exists("old/expired.txt") {
+		t.Error("old/expired.txt was not deleted")
+	}
+	// Objects younger than a day aren't looked up by the walk
+	if !p.exists("new/expired.txt") {
+		t.Error("new/expired.txt was deleted")
+	}
+	// Found with an expiry ahead, so later sweeps don't need the walk for it
+	if !p.expiry.tracked("old/later.txt") {
+		t.Error("old/later.txt was not indexed")
+	}
+}
+
+func TestSweepExpiredResumesWalk(t *testing.T) {
+	p := newTestMockProvider(t)
+	past := time.Now().Add(-time.Hour)
+
+	total := expirySweepBatch + 10
+	for i := range total {
+		putExpiring(t, p, fmt.Sprintf("objects/%05d.txt", i), past, 48*time.Hour)
+	}
+
+	deleted, err := p.SweepExpired(context.Background())
+	if err != nil || deleted != expirySweepBatch {
+		t.Fatalf("first SweepExpired = %d, %v; want %d", deleted, err, expirySweepBatch)
+	}
+	if p.expiry.cursor == "" {
+		t.Fatal("cursor not kept after a partial walk")
+	}
+
+	deleted, err = p.SweepExpired(context.Background())
+	if err != nil || deleted != total-expirySweepBatch {
+		t.Fatalf("second SweepExpired = %d, %v; want %d", deleted, err, total-expirySweepBatch)
+	}
+	if p.expiry.cursor != "" {
+		t.Errorf("cursor = %q after reaching the end, want it reset", p.expiry.cursor)
+	}
+}
+
+// failingInfoProvider fails GetObjectInfo for one key
+type failingInfoProvider struct {
+	*MockProvider
+	failKey string
+}
+
+func (p *failingInfoProvider) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	if key == p.failKey {
+		return nil, errors.New("head failed")
+	}
+	return p.MockProvider.GetObjectInfo(ctx, key)
+}
+
+func TestSweepExpiredSkipsObjectErrors(t *testing.T) {
+	mock := newTestMockProvider(t)
+	past := time.Now().Add(-time.Hour)
+	putExpiring(t, mock, "a.txt", past, 48*time.Hour)
+	putExpiring(t, mock, "b.txt", past, 48*time.Hour)
+	putExpiring(t, mock, "c.txt", past, 48*time.Hour)
+
+	p := &failingInfoProvider{MockProvider: mock, failKey: "b.txt"}
+	deleted, err := mock.expiry.sweep(context.Background(), p, "mock")
+	if err != nil || deleted != 2 {
+		t.Fatalf("sweep = %d, %v; want 2", deleted, err)
+	}
+	if mock.exists("a.txt") || !mock.exists("b.txt") || mock.exists("c.txt") {
+		t.Errorf("a.txt %v, b.txt %v, c.txt %v; want only b.txt left",
+			mock.exists("a.txt"), mock.exists("b.txt"), mock.exists("c.txt"))
+	}
+
+	// The skipped object is retried on the next sweep
+	p.failKey = ""
+	if deleted, err := mock.expiry.sweep(context.Background(), p, "mock"); err != nil || deleted != 1 {
+		t.Fatalf("retry sweep = %d, %v; want 1", deleted, err)
+	}
+}
@@ -38,6 +38,9 @@ func (f *ProviderFactory) CreateProvider(config *S3Config) (S3Provider, error) {
 	case ProviderWasabi:
 		// Wasabi is S3-compatible, use AWS provider with custom endpoint
 		return NewWasabiProvider(config)
+	case ProviderMock:
+		// In-memory provider used by MOCK_MODE
+		return NewMockProvider(config)
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrProviderNotSupported, config.Provider)
 	}
@@ -88,6 +91,8 @@ func (f *ProviderFactory) ValidateProviderConfig(config *S3Config) error {
 		return f.validateCloudflareConfig(config)
 	case ProviderWasabi:
 		return f.validateWasabiConfig(config)
+	case ProviderMock:
+		return config.Validate()
 	default:
 		return fmt.Errorf("%w: %s", ErrProviderNotSupported, config.Provider)
 	}
@@ -2,20 +2,25 @@ package providers
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"io"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
+	"github.com/minio/minio-go/v7/pkg/tags"
 )
 
 // MinIOProvider implements the S3Provider interface for MinIO
 type MinIOProvider struct {
 	client *minio.Client
 	config *S3Config
+
+	expirationRules expirationRules // Lifecycle rules known to be in the bucket
 }
 
 // NewMinIOProvider creates a new MinIO provider
@@ -54,8 +59,8 @@ func NewMinIOProvider(cfg *S3Config) (*MinIOProvider, error) {
 func (p *MinIOProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts UploadOptions) (*UploadResult, error) {
 	startTime := time.Now()
 
-	// Use multipart upload for large files
-	if size >= p.config.MultipartThreshold {
+	// Use multipart upload for large files and streams of unknown size
+	if size < 0 || size >= p.config.MultipartThreshold {
 		return p.MultipartUpload(ctx, key, reader, opts)
 	}
 
@@ -77,6 +82,13 @@ func (p *MinIOProvider) Upload(ctx context.Context, key string, reader io.Reader
 		baseOpts.StorageClass = opts.StorageClass
 	}
 
+	// Tag expiring objects for the lifecycle rule that deletes them
+	var ruleErr error
+	if opts.ExpirationDays > 0 {
+		baseOpts.UserTags = map[string]string{ExpirationTag: strconv.Itoa(opts.ExpirationDays)}
+		ruleErr = p.ensureExpirationRule(ctx, opts.ExpirationDays)
+	}
+
 	// Perform upload with retry logic
 	var info minio.UploadInfo
 	var err error
@@ -133,10 +145,9 @@ func (p *MinIOProvider) Upload(ctx context.Context, key string, reader io.Reader
 		uploadResult.VersionID = info.VersionID
 	}
 
-	// Set expiration if configured
+	// Report when the lifecycle rule deletes the object
 	if opts.ExpirationDays > 0 {
-		expiresAt := time.Now().AddDate(0, 0, opts.ExpirationDays)
-		uploadResult.ExpiresAt = &expiresAt
+		uploadResult.ExpiresAt = uploadExpiry("minio", key, opts.ExpirationDays, ruleErr)
 	}
 
 	return uploadResult, nil
@@ -166,6 +177,13 @@ func (p *MinIOProvider) MultipartUpload(ctx context.Context, key string, reader
 		putOpts.StorageClass = opts.StorageClass
 	}
 
+	// Tag expiring objects for the lifecycle rule that deletes them
+	var ruleErr error
+	if opts.ExpirationDays > 0 {
+		putOpts.UserTags = map[string]string{ExpirationTag: strconv.Itoa(opts.ExpirationDays)}
+		ruleErr = p.ensureExpirationRule(ctx, opts.ExpirationDays)
+	}
+
 	// Add progress callback if provided
 	if opts.ProgressCallback != nil {
 		putOpts.Progress = &progressReader{
@@ -196,10 +214,9 @@ func (p *MinIOProvider) MultipartUpload(ctx context.Context, key string, reader
 		uploadResult.VersionID = info.VersionID
 	}
 
-	// Set expiration if configured
+	// Report when the lifecycle rule deletes the object
 	if opts.ExpirationDays > 0 {
-		expiresAt := time.Now().AddDate(0, 0, opts.ExpirationDays)
-		uploadResult.ExpiresAt = &expiresAt
+		uploadResult.ExpiresAt = uploadExpiry("minio", key, opts.ExpirationDays, ruleErr)
 	}
 
 	return uploadResult, nil
@@ -230,7 +247,7 @@ func (p *MinIOProvider) UploadBase64(ctx context.Context, key string, data strin
 	}
 
 	// Decode base64 data
-	decodedData, err := base64.StdEncoding.DecodeString(base64Data)
+	decodedData, err := DecodeBase64(base64Data)
 	if err != nil {
 		return nil, NewS3Error("minio", "decode_base64", key, 0, ErrInvalidBase64)
 	}
@@ -250,11 +267,82 @@ func (p *MinIOProvider) GetPublicURL(key string) string {
 	return p.config.GetPublicURL(key)
 }
 
-// SetExpiration sets expiration for an object
-func (p *MinIOProvider) SetExpiration(key string, days int) error {
-	// MinIO supports object expiration through lifecycle policies
-	// This is a placeholder for future implementation
-	return ErrFeatureNotSupported
+// SetExpiration tags the object for the lifecycle rule deleting objects days
+// after their creation, adding the rule to the bucket when missing. Days 0
+// removes the tag; the object's other tags are kept.
+func (p *MinIOProvider) SetExpiration(ctx context.Context, key string, days int) (*time.Time, error) {
+	info, err := p.GetObjectInfo(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if days > 0 {
+		if err := p.ensureExpirationRule(ctx, days); err != nil {
+			return nil, err
+		}
+	}
+
+	current, err := p.client.GetObjectTagging(ctx, p.config.Bucket, key, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		return nil, newObjectError("minio", "get_tagging", key, minio.ToErrorResponse(err).StatusCode, err)
+	}
+	tagMap := current.ToMap()
+	delete(tagMap, ExpirationTag)
+	if days > 0 {
+		tagMap[ExpirationTag] = strconv.Itoa(days)
+	}
+
+	if len(tagMap) == 0 {
+		err = p.client.RemoveObjectTagging(ctx, p.config.Bucket, key, minio.RemoveObjectTaggingOptions{})
+	} else {
+		var objectTags *tags.Tags
+		if objectTags, err = tags.NewTags(tagMap, true); err != nil {
+			return nil, NewS3Error("minio", "put_tagging", key, 0, err)
+		}
+		err = p.client.PutObjectTagging(ctx, p.config.Bucket, key, objectTags, minio.PutObjectTaggingOptions{})
+	}
+	if err != nil {
+		return nil, newObjectError("minio", "put_tagging", key, minio.ToErrorResponse(err).StatusCode, err)
+	}
+
+	if days == 0 {
+		return nil, nil
+	}
+	expiresAt := ExpirationDate(info.LastModified, days)
+	return &expiresAt, nil
+}
+
+// ensureExpirationRule adds the lifecycle rule expiring objects tagged with
+// days to the bucket, keeping its other rules
+func (p *MinIOProvider) ensureExpirationRule(ctx context.Context, days int) error {
+	return p.expirationRules.ensure(days, func() error {
+		config, err := p.client.GetBucketLifecycle(ctx, p.config.Bucket)
+		if err != nil {
+			if ErrorCode(err) != "NoSuchLifecycleConfiguration" {
+				return NewS3Error("minio", "get_lifecycle", "", minio.ToErrorResponse(err).StatusCode, err)
+			}
+			config = lifecycle.NewConfiguration()
+		}
+
+		id := expirationRuleID(days)
+		for _, rule := range config.Rules {
+			if rule.ID == id {
+				return nil
+			}
+		}
+		config.Rules = append(config.Rules, lifecycle.Rule{
+			ID:     id,
+			Status: "Enabled",
+			RuleFilter: lifecycle.Filter{
+				Tag: lifecycle.Tag{Key: ExpirationTag, Value: strconv.Itoa(days)},
+			},
+			Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(days)},
+		})
+
+		if err := p.client.SetBucketLifecycle(ctx, p.config.Bucket, config); err != nil {
+			return NewS3Error("minio", "put_lifecycle", "", minio.ToErrorResponse(err).StatusCode, err)
+		}
+		return nil
+	})
 }
 
 // HealthCheck verifies the provider connection and configuration
@@ -276,7 +364,7 @@ func (p *MinIOProvider) HealthCheck(ctx context.Context) error {
 func (p *MinIOProvider) DeleteObject(ctx context.Context, key string) error {
 	err := p.client.RemoveObject(ctx, p.config.Bucket, key, minio.RemoveObjectOptions{})
 	if err != nil {
-		return NewS3Error("minio", "delete", key, 0, err)
+		return newObjectError("minio", "delete", key, minio.ToErrorResponse(err).StatusCode, err)
 	}
 
 	return nil
@@ -286,7 +374,7 @@ func (p *MinIOProvider) DeleteObject(ctx context.Context, key string) error {
 func (p *MinIOProvider) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
 	objInfo, err := p.client.StatObject(ctx, p.config.Bucket, key, minio.StatObjectOptions{})
 	if err != nil {
-		return nil, NewS3Error("minio", "stat_object", key, 0, err)
+		return nil, newObjectError("minio", "stat_object", key, minio.ToErrorResponse(err).StatusCode, err)
 	}
 
 	info := &ObjectInfo{
@@ -306,6 +394,67 @@ func (p *MinIOProvider) GetObjectInfo(ctx context.Context, key string) (*ObjectI
 	return info, nil
 }
 
+// ListObjects lists a page of objects; the cursor is the last key of the
+// previous page, as minio-go pages by StartAfter
+func (p *MinIOProvider) ListObjects(ctx context.Context, opts ListOptions) (*ObjectList, error) {
+	limit := listLimit(opts.Limit)
+
+	// Stop the listing goroutine once the page is read
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	list := &ObjectList{Objects: make([]ObjectSummary, 0, limit)}
+	for object := range p.client.ListObjects(ctx, p.config.Bucket, minio.ListObjectsOptions{
+		Prefix:     opts.Prefix,
+		Recursive:  true,
+		MaxKeys:    limit + 1,
+		StartAfter: opts.Cursor,
+	}) {
+		if object.Err != nil {
+			return nil, NewS3Error("minio", "list_objects", opts.Prefix, minio.ToErrorResponse(object.Err).StatusCode, object.Err)
+		}
+		if len(list.Objects) == limit {
+			list.NextCursor = list.Objects[limit-1].Key
+			break
+		}
+		list.Objects = append(list.Objects, ObjectSummary{
+			Key:          object.Key,
+			Size:         object.Size,
+			ETag:         object.ETag,
+			LastModified: object.LastModified,
+			StorageClass: object.StorageClass,
+		})
+	}
+
+	return list, nil
+}
+
+// GetObject opens the object body for reading
+func (p *MinIOProvider) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	object, err := p.client.GetObject(ctx, p.config.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, newObjectError("minio", "get_object", key, minio.ToErrorResponse(err).StatusCode, err)
+	}
+
+	// minio-go opens objects lazily; stat now so a missing key fails here
+	if _, err := object.Stat(); err != nil {
+		object.Close()
+		return nil, newObjectError("minio", "get_object", key, minio.ToErrorResponse(err).StatusCode, err)
+	}
+
+	return object, nil
+}
+
+// PresignGetURL returns a presigned GET URL valid for the given duration
+func (p *MinIOProvider) PresignGetURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	presignedURL, err := p.client.PresignedGetObject(ctx, p.config.Bucket, key, expires, url.Values{})
+	if err != nil {
+		return "", NewS3Error("minio", "presign", key, 0, err)
+	}
+
+	return presignedURL.String(), nil
+}
+
 // progressReader wraps an io.Reader to provide progress callbacks
 type progressReader struct {
 	reader   io.Reader
@@ -0,0 +1,257 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockProvider implements the S3Provider interface in memory.
+// It is used by MOCK_MODE so integrators can exercise the upload API without a bucket.
+type MockProvider struct {
+	config  *S3Config
+	mu      sync.RWMutex
+	objects map[string]*ObjectInfo
+	bodies  map[string][]byte
+	expiry  expirySweep
+}
+
+// NewMockProvider creates a new in-memory provider
+func NewMockProvider(cfg *S3Config) (*MockProvider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid mock provider config: %w", err)
+	}
+
+	return &MockProvider{
+		config:  cfg,
+		objects: make(map[string]*ObjectInfo),
+		bodies:  make(map[string][]byte),
+	}, nil
+}
+
+// Upload consumes the reader and keeps the object in memory
+func (p *MockProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts UploadOptions) (*UploadResult, error) {
+	startTime := time.Now()
+
+	var body bytes.Buffer
+	hash := md5.New()
+	written, err := io.Copy(io.MultiWriter(hash, &body), &contextReader{ctx: ctx, reader: reader})
+	if err != nil {
+		return nil, NewS3Error("mock", "upload", key, 0, err)
+	}
+
+	if opts.ProgressCallback != nil {
+		opts.ProgressCallback(written, written)
+	}
+
+	etag := "\"" + hex.EncodeToString(hash.Sum(nil)) + "\""
+
+	// Record the expiry for SweepExpired, which stands in for lifecycle rules
+	var expiresAt time.Time
+	if opts.ExpirationDays > 0 {
+		expiresAt = ExpirationDate(time.Now(), opts.ExpirationDays)
+		opts.Metadata = withExpiresAt(opts.Metadata, expiresAt)
+	}
+
+	p.mu.Lock()
+	p.objects[key] = &ObjectInfo{
+		Key:          key,
+		Size:         written,
+		ETag:         etag,
+		ContentType:  opts.ContentType,
+		LastModified: time.Now().UTC(),
+		Metadata:     opts.Metadata,
+		StorageClass: opts.StorageClass,
+	}
+	p.bodies[key] = body.Bytes()
+	p.mu.Unlock()
+
+	uploadResult := &UploadResult{
+		Key:            key,
+		PublicURL:      p.GetPublicURL(key),
+		Size:           written,
+		ETag:           etag,
+		Provider:       "mock",
+		ProcessingTime: time.Since(startTime),
+	}
+
+	if opts.ExpirationDays > 0 {
+		uploadResult.ExpiresAt = &expiresAt
+	}
+	p.expiry.track(key, uploadResult.ExpiresAt)
+
+	return uploadResult, nil
+}
+
+// MultipartUpload behaves like Upload since no parts are transferred
+func (p *MockProvider) MultipartUpload(ctx context.Context, key string, reader io.Reader, opts UploadOptions) (*UploadResult, error) {
+	return p.Upload(ctx, key, reader, -1, opts)
+}
+
+// UploadBase64 decodes the payload and records it like Upload
+func (p *MockProvider) UploadBase64(ctx context.Context, key string, data string, opts UploadOptions) (*UploadResult, error) {
+	base64Data := data
+	if strings.HasPrefix(data, "data:") {
+		header, payload, found := strings.Cut(data, ",")
+		if !found {
+			return nil, NewS3Error("mock", "parse_base64", key, 0, ErrInvalidBase64)
+		}
+		if opts.ContentType == "" && strings.Contains(header, ";base64") {
+			opts.ContentType = strings.TrimPrefix(strings.Split(header, ";")[0], "data:")
+		}
+		base64Data = payload
+	}
+
+	decodedData, err := DecodeBase64(base64Data)
+	if err != nil {
+		return nil, NewS3Error("mock", "decode_base64", key, 0, ErrInvalidBase64)
+	}
+
+	return p.Upload(ctx, key, strings.NewReader(string(decodedData)), int64(len(decodedData)), opts)
+}
+
+// GetPublicURL returns the public URL for accessing the uploaded object
+func (p *MockProvider) GetPublicURL(key string) string {
+	return p.config.GetPublicURL(key)
+}
+
+// SetExpiration records in the object's metadata that it expires days after
+// its creation, like a lifecycle rule would; days 0 removes the expiry
+func (p *MockProvider) SetExpiration(ctx context.Context, key string, days int) (*time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, exists := p.objects[key]
+	if !exists {
+		return nil, NewS3Error("mock", "set_expiration", key, 404, ErrObjectNotFound)
+	}
+
+	if days == 0 {
+		info.Metadata = withoutExpiresAt(info.Metadata)
+		p.expiry.track(key, nil)
+		return nil, nil
+	}
+	expiresAt := ExpirationDate(info.LastModified, days)
+	info.Metadata = withExpiresAt(info.Metadata, expiresAt)
+	p.expiry.track(key, &expiresAt)
+	return &expiresAt, nil
+}
+
+// SweepExpired deletes the objects whose recorded expiry has passed
+func (p *MockProvider) SweepExpired(ctx context.Context) (int, error) {
+	return p.expiry.sweep(ctx, p, "mock")
+}
+
+// HealthCheck always succeeds
+func (p *MockProvider) HealthCheck(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// DeleteObject removes an object from memory
+func (p *MockProvider) DeleteObject(ctx context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.objects[key]; !exists {
+		return NewS3Error("mock", "delete", key, 404, ErrObjectNotFound)
+	}
+	delete(p.objects, key)
+	delete(p.bodies, key)
+
+	return nil
+}
+
+// GetObjectInfo retrieves metadata about an object
+func (p *MockProvider) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	info, exists := p.objects[key]
+	if !exists {
+		return nil, NewS3Error("mock", "head_object", key, 404, ErrObjectNotFound)
+	}
+
+	copyInfo := *info
+	return &copyInfo, nil
+}
+
+// ListObjects pages through the stored keys in order; the cursor is the last
+// key of the previous page
+func (p *MockProvider) ListObjects(ctx context.Context, opts ListOptions) (*ObjectList, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	limit := listLimit(opts.Limit)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	keys := make([]string, 0, len(p.objects))
+	for key := range p.objects {
+		if strings.HasPrefix(key, opts.Prefix) && key > opts.Cursor {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	list := &ObjectList{Objects: make([]ObjectSummary, 0, min(limit, len(keys)))}
+	for _, key := range keys {
+		if len(list.Objects) == limit {
+			list.NextCursor = list.Objects[limit-1].Key
+			break
+		}
+		info := p.objects[key]
+		list.Objects = append(list.Objects, ObjectSummary{
+			Key:          info.Key,
+			Size:         info.Size,
+			ETag:         info.ETag,
+			LastModified: info.LastModified,
+			StorageClass: info.StorageClass,
+		})
+	}
+
+	return list, nil
+}
+
+// GetObject returns the stored bytes
+func (p *MockProvider) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	body, exists := p.bodies[key]
+	if !exists {
+		return nil, NewS3Error("mock", "get_object", key, 404, ErrObjectNotFound)
+	}
+
+	return io.NopCloser(bytes.NewReader(body)), nil
+}
+
+// PresignGetURL returns the public URL with a fake expiry signature
+func (p *MockProvider) PresignGetURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if _, err := p.GetObjectInfo(ctx, key); err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(expires).Unix()
+	return fmt.Sprintf("%s?X-Mock-Expires=%d", p.GetPublicURL(key), expiresAt), nil
+}
+
+// contextReader aborts reads once the context is cancelled
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
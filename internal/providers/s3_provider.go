@@ -8,7 +8,8 @@ import (
 
 // S3Provider defines the interface for all S3-compatible storage providers
 type S3Provider interface {
-	// Upload uploads data from a reader to the specified key
+	// Upload uploads data from a reader to the specified key. A negative
+	// size streams the reader to EOF in multipart parts.
 	Upload(ctx context.Context, key string, reader io.Reader, size int64, opts UploadOptions) (*UploadResult, error)
 
 	// MultipartUpload handles large file uploads using multipart upload
@@ -20,8 +21,9 @@ type S3Provider interface {
 	// GetPublicURL returns the public URL for accessing the uploaded object
 	GetPublicURL(key string) string
 
-	// SetExpiration sets expiration for an object (if supported by provider)
-	SetExpiration(key string, days int) error
+	// SetExpiration makes the object expire days after it was created (0
+	// removes the expiration) and returns when it will be deleted
+	SetExpiration(ctx context.Context, key string, days int) (*time.Time, error)
 
 	// HealthCheck verifies the provider connection and configuration
 	HealthCheck(ctx context.Context) error
@@ -31,6 +33,29 @@ type S3Provider interface {
 
 	// GetObjectInfo retrieves metadata about an object
 	GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error)
+
+	// ListObjects returns a page of the objects under a prefix, in key order
+	ListObjects(ctx context.Context, opts ListOptions) (*ObjectList, error)
+}
+
+// Presigner is implemented by providers that can issue time-limited download URLs
+type Presigner interface {
+	// PresignGetURL returns a URL granting read access to key until expires elapses
+	PresignGetURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// ObjectReader is implemented by providers that can stream stored objects back
+type ObjectReader interface {
+	// GetObject opens key for reading; the caller closes the returned body
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// ExpirySweeper is implemented by providers whose buckets can't expire
+// objects on their own; SweepExpired deletes the objects past their
+// ExpiresAtMeta and is called periodically by the S3 service
+type ExpirySweeper interface {
+	// SweepExpired deletes the expired objects and returns how many it deleted
+	SweepExpired(ctx context.Context) (int, error)
 }
 
 // UploadOptions contains options for upload operations
@@ -102,6 +127,48 @@ type ObjectInfo struct {
 	VersionID    string            `json:"version_id,omitempty"`
 }
 
+// ListOptions selects a page of a bucket listing
+type ListOptions struct {
+	// Prefix restricts the listing to keys starting with it
+	Prefix string
+
+	// Cursor continues a listing from the NextCursor of its previous page.
+	// It is opaque: a continuation token or a key, depending on the provider.
+	Cursor string
+
+	// Limit is the most objects returned (MaxListLimit when 0 or above it)
+	Limit int
+}
+
+// MaxListLimit is the largest page S3 returns for one listing request
+const MaxListLimit = 1000
+
+// listLimit clamps a requested page size to (0, MaxListLimit]
+func listLimit(limit int) int {
+	if limit <= 0 || limit > MaxListLimit {
+		return MaxListLimit
+	}
+	return limit
+}
+
+// ObjectList is one page of a bucket listing
+type ObjectList struct {
+	Objects []ObjectSummary `json:"objects"`
+
+	// NextCursor fetches the following page; empty on the last one
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ObjectSummary describes a listed object. Listings carry no content type or
+// metadata; GetObjectInfo returns those.
+type ObjectSummary struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	StorageClass string    `json:"storage_class,omitempty"`
+}
+
 // ProviderType represents the supported S3 provider types
 type ProviderType string
 
@@ -112,6 +179,7 @@ const (
 	ProviderDigitalOcean ProviderType = "digitalocean"
 	ProviderCloudflare   ProviderType = "cloudflare"
 	ProviderWasabi       ProviderType = "wasabi"
+	ProviderMock         ProviderType = "mock"
 )
 
 // S3Config contains configuration for S3 providers
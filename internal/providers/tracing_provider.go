@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"whats-convert-api/internal/tracing"
+)
+
+// TracingProvider wraps an S3Provider and records a span for every call that
+// reaches the network
+type TracingProvider struct {
+	provider S3Provider
+	name     string
+}
+
+// NewTracingProvider wraps provider; name is its S3_PROVIDER value
+func NewTracingProvider(provider S3Provider, name string) *TracingProvider {
+	return &TracingProvider{
+		provider: provider,
+		name:     name,
+	}
+}
+
+// start opens a span for one provider operation
+func (p *TracingProvider) start(ctx context.Context, operation, key string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("s3.provider", p.name),
+		attribute.String("s3.operation", operation),
+	}
+	if key != "" {
+		attrs = append(attrs, attribute.String("s3.key", key))
+	}
+	return tracing.Start(ctx, "s3."+operation, attrs...)
+}
+
+// Upload uploads data inside an s3.upload span
+func (p *TracingProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts UploadOptions) (*UploadResult, error) {
+	ctx, span := p.start(ctx, "upload", key)
+	span.SetAttributes(attribute.Int64("s3.size", size))
+	result, err := p.provider.Upload(ctx, key, reader, size, opts)
+	tracing.End(span, err)
+	return result, err
+}
+
+// MultipartUpload uploads data in parts inside an s3.multipart_upload span
+func (p *TracingProvider) MultipartUpload(ctx context.Context, key string, reader io.Reader, opts UploadOptions) (*UploadResult, error) {
+	ctx, span := p.start(ctx, "multipart_upload", key)
+	result, err := p.provider.MultipartUpload(ctx, key, reader, opts)
+	if result != nil {
+		span.SetAttributes(attribute.Int64("s3.size", result.Size))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+// UploadBase64 uploads base64 data inside an s3.upload_base64 span
+func (p *TracingProvider) UploadBase64(ctx context.Context, key string, data string, opts UploadOptions) (*UploadResult, error) {
+	ctx, span := p.start(ctx, "upload_base64", key)
+	result, err := p.provider.UploadBase64(ctx, key, data, opts)
+	if result != nil {
+		span.SetAttributes(attribute.Int64("s3.size", result.Size))
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+// GetPublicURL is not traced since it doesn't touch the network
+func (p *TracingProvider) GetPublicURL(key string) string {
+	return p.provider.GetPublicURL(key)
+}
+
+// SetExpiration sets an object's expiration inside an s3.set_expiration span
+func (p *TracingProvider) SetExpiration(ctx context.Context, key string, days int) (*time.Time, error) {
+	ctx, span := p.start(ctx, "set_expiration", key)
+	expiresAt, err := p.provider.SetExpiration(ctx, key, days)
+	tracing.End(span, err)
+	return expiresAt, err
+}
+
+// HealthCheck checks the wrapped provider inside an s3.health_check span
+func (p *TracingProvider) HealthCheck(ctx context.Context) error {
+	ctx, span := p.start(ctx, "health_check", "")
+	err := p.provider.HealthCheck(ctx)
+	tracing.End(span, err)
+	return err
+}
+
+// DeleteObject deletes an object inside an s3.delete span
+func (p *TracingProvider) DeleteObject(ctx context.Context, key string) error {
+	ctx, span := p.start(ctx, "delete", key)
+	err := p.provider.DeleteObject(ctx, key)
+	tracing.End(span, err)
+	return err
+}
+
+// GetObjectInfo retrieves object metadata inside an s3.head_object span
+func (p *TracingProvider) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	ctx, span := p.start(ctx, "head_object", key)
+	info, err := p.provider.GetObjectInfo(ctx, key)
+	tracing.End(span, err)
+	return info, err
+}
+
+// ListObjects lists a page of objects inside an s3.list_objects span keyed by the prefix
+func (p *TracingProvider) ListObjects(ctx context.Context, opts ListOptions) (*ObjectList, error) {
+	ctx, span := p.start(ctx, "list_objects", opts.Prefix)
+	list, err := p.provider.ListObjects(ctx, opts)
+	tracing.End(span, err)
+	return list, err
+}
+
+// GetObject opens an object inside an s3.get_object span; the span covers
+// opening the body, not reading it
+func (p *TracingProvider) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, ok := p.provider.(ObjectReader)
+	if !ok {
+		return nil, ErrFeatureNotSupported
+	}
+	ctx, span := p.start(ctx, "get_object", key)
+	body, err := reader.GetObject(ctx, key)
+	tracing.End(span, err)
+	return body, err
+}
+
+// PresignGetURL presigns through the wrapped provider inside an s3.presign span
+func (p *TracingProvider) PresignGetURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	presigner, ok := p.provider.(Presigner)
+	if !ok {
+		return "", ErrFeatureNotSupported
+	}
+	ctx, span := p.start(ctx, "presign", key)
+	url, err := presigner.PresignGetURL(ctx, key, expires)
+	tracing.End(span, err)
+	return url, err
+}
+
+// SweepExpired deletes expired objects inside an s3.sweep_expired span
+func (p *TracingProvider) SweepExpired(ctx context.Context) (int, error) {
+	sweeper, ok := p.provider.(ExpirySweeper)
+	if !ok {
+		return 0, ErrFeatureNotSupported
+	}
+	ctx, span := p.start(ctx, "sweep_expired", "")
+	deleted, err := sweeper.SweepExpired(ctx)
+	span.SetAttributes(attribute.Int("s3.deleted", deleted))
+	tracing.End(span, err)
+	return deleted, err
+}
@@ -0,0 +1,205 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrVerificationFailed is returned when a stored object still doesn't match
+// the upload after every retry
+var ErrVerificationFailed = errors.New("upload verification failed")
+
+// VerificationStats counts upload verifications. It outlives the providers
+// that share it, so reconnects don't reset it.
+type VerificationStats struct {
+	Verified   atomic.Int64 // Uploads whose object matched
+	Mismatches atomic.Int64 // Objects found truncated, altered or missing, including retried ones
+	Failures   atomic.Int64 // Uploads given up on
+}
+
+// VerifyingProvider wraps an S3Provider and, after every upload, reads the
+// stored object's metadata back (HEAD) to compare its size and ETag with the
+// acknowledged upload. Some S3-compatible gateways acknowledge an upload but
+// persist a truncated object. On a mismatch the upload is sent again, up to
+// retries times, when its reader can be rewound.
+type VerifyingProvider struct {
+	provider S3Provider
+	retries  int
+	stats    *VerificationStats
+}
+
+// NewVerifyingProvider wraps provider, re-sending mismatched uploads up to
+// retries times and counting outcomes in stats
+func NewVerifyingProvider(provider S3Provider, retries int, stats *VerificationStats) *VerifyingProvider {
+	if stats == nil {
+		stats = &VerificationStats{}
+	}
+	return &VerifyingProvider{
+		provider: provider,
+		retries:  max(retries, 0),
+		stats:    stats,
+	}
+}
+
+// Unwrap returns the wrapped provider
+func (p *VerifyingProvider) Unwrap() S3Provider {
+	return p.provider
+}
+
+// Upload uploads and verifies data; seekable readers are re-sent on a mismatch
+func (p *VerifyingProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts UploadOptions) (*UploadResult, error) {
+	return p.verified(ctx, key, reader, size, func() (*UploadResult, error) {
+		return p.provider.Upload(ctx, key, reader, size, opts)
+	})
+}
+
+// MultipartUpload uploads data in parts and verifies the assembled object
+func (p *VerifyingProvider) MultipartUpload(ctx context.Context, key string, reader io.Reader, opts UploadOptions) (*UploadResult, error) {
+	return p.verified(ctx, key, reader, -1, func() (*UploadResult, error) {
+		return p.provider.MultipartUpload(ctx, key, reader, opts)
+	})
+}
+
+// UploadBase64 uploads and verifies base64 data, which can always be re-sent
+func (p *VerifyingProvider) UploadBase64(ctx context.Context, key string, data string, opts UploadOptions) (*UploadResult, error) {
+	return p.verified(ctx, key, nil, -1, func() (*UploadResult, error) {
+		return p.provider.UploadBase64(ctx, key, data, opts)
+	})
+}
+
+// verified runs upload and checks the stored object, rewinding reader
+// (nil when upload can simply be called again) before each retry
+func (p *VerifyingProvider) verified(ctx context.Context, key string, reader io.Reader, size int64, upload func() (*UploadResult, error)) (*UploadResult, error) {
+	rewind := func() error { return nil }
+	if reader != nil {
+		seeker, ok := reader.(io.Seeker)
+		start, err := int64(0), error(nil)
+		if ok {
+			start, err = seeker.Seek(0, io.SeekCurrent)
+		}
+		if !ok || err != nil {
+			rewind = func() error { return errors.New("the upload's stream can't be replayed") }
+		} else {
+			rewind = func() error {
+				_, err := seeker.Seek(start, io.SeekStart)
+				return err
+			}
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		result, err := upload()
+		if err != nil {
+			return nil, err
+		}
+
+		mismatch := p.check(ctx, key, size, result)
+		if mismatch == nil {
+			p.stats.Verified.Add(1)
+			return result, nil
+		}
+		p.stats.Mismatches.Add(1)
+
+		if attempt == p.retries {
+			p.stats.Failures.Add(1)
+			return nil, NewS3Error("verify", "upload", key, 0, fmt.Errorf("%w after %d attempts: %v", ErrVerificationFailed, attempt+1, mismatch))
+		}
+		if err := rewind(); err != nil {
+			p.stats.Failures.Add(1)
+			return nil, NewS3Error("verify", "upload", key, 0, fmt.Errorf("%w: %v; %v", ErrVerificationFailed, mismatch, err))
+		}
+		log.Printf("⚠️ S3 upload of '%s' didn't verify (%v), uploading again (%d/%d)", key, mismatch, attempt+1, p.retries)
+
+		select {
+		case <-ctx.Done():
+			return nil, NewS3Error("verify", "upload", key, 0, ctx.Err())
+		case <-time.After(time.Duration(attempt+1) * 500 * time.Millisecond):
+		}
+	}
+}
+
+// check compares the stored object with the acknowledged upload of size
+// bytes (negative when only the provider knows the size)
+func (p *VerifyingProvider) check(ctx context.Context, key string, size int64, result *UploadResult) error {
+	info, err := p.provider.GetObjectInfo(ctx, key)
+	if err != nil {
+		return fmt.Errorf("object metadata unavailable: %w", err)
+	}
+
+	expected := size
+	if expected < 0 {
+		expected = result.Size
+	}
+	if expected >= 0 && info.Size != expected {
+		return fmt.Errorf("stored %d bytes, sent %d", info.Size, expected)
+	}
+
+	sent, stored := strings.Trim(result.ETag, `"`), strings.Trim(info.ETag, `"`)
+	if sent != "" && stored != "" && sent != stored {
+		return fmt.Errorf("stored ETag %s, acknowledged %s", stored, sent)
+	}
+	return nil
+}
+
+// GetPublicURL returns the wrapped provider's URL for key
+func (p *VerifyingProvider) GetPublicURL(key string) string {
+	return p.provider.GetPublicURL(key)
+}
+
+// SetExpiration sets expiration through the wrapped provider
+func (p *VerifyingProvider) SetExpiration(ctx context.Context, key string, days int) (*time.Time, error) {
+	return p.provider.SetExpiration(ctx, key, days)
+}
+
+// HealthCheck checks the wrapped provider
+func (p *VerifyingProvider) HealthCheck(ctx context.Context) error {
+	return p.provider.HealthCheck(ctx)
+}
+
+// DeleteObject deletes through the wrapped provider
+func (p *VerifyingProvider) DeleteObject(ctx context.Context, key string) error {
+	return p.provider.DeleteObject(ctx, key)
+}
+
+// GetObjectInfo retrieves object metadata through the wrapped provider
+func (p *VerifyingProvider) GetObjectInfo(ctx context.Context, key string) (*ObjectInfo, error) {
+	return p.provider.GetObjectInfo(ctx, key)
+}
+
+// ListObjects lists through the wrapped provider
+func (p *VerifyingProvider) ListObjects(ctx context.Context, opts ListOptions) (*ObjectList, error) {
+	return p.provider.ListObjects(ctx, opts)
+}
+
+// GetObject reads through the wrapped provider
+func (p *VerifyingProvider) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, ok := p.provider.(ObjectReader)
+	if !ok {
+		return nil, ErrFeatureNotSupported
+	}
+	return reader.GetObject(ctx, key)
+}
+
+// PresignGetURL presigns through the wrapped provider
+func (p *VerifyingProvider) PresignGetURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	presigner, ok := p.provider.(Presigner)
+	if !ok {
+		return "", ErrFeatureNotSupported
+	}
+	return presigner.PresignGetURL(ctx, key, expires)
+}
+
+// SweepExpired sweeps through the wrapped provider
+func (p *VerifyingProvider) SweepExpired(ctx context.Context) (int, error) {
+	sweeper, ok := p.provider.(ExpirySweeper)
+	if !ok {
+		return 0, ErrFeatureNotSupported
+	}
+	return sweeper.SweepExpired(ctx)
+}
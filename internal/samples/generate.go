@@ -0,0 +1,178 @@
+//go:build ignore
+
+// generate writes the embedded sample media. The files are built from code
+// rather than recorded, so they stay tiny, carry no third-party content and
+// can be rebuilt without FFmpeg:
+//
+//	go generate ./internal/samples
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	files := map[string][]byte{
+		"sample.mp3":  mp3Silence(time1s),
+		"sample.jpg":  jpegGradient(160, 120),
+		"sample.webm": webmOpusSilence(time1s),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join("media", name), data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s: %d bytes", name, len(data))
+	}
+}
+
+const time1s = 1000 // milliseconds
+
+// mp3Silence returns MPEG-1 Layer III frames (32kbit/s, 44.1kHz, mono)
+// whose side information declares no main data, which decoders play as silence
+func mp3Silence(ms int) []byte {
+	const frameSize = 144 * 32000 / 44100 // 104 bytes, no padding
+	frames := ms * 44100 / 1152 / 1000
+
+	var out bytes.Buffer
+	for range frames {
+		frame := make([]byte, frameSize)
+		copy(frame, []byte{
+			0xFF, 0xFB, // Sync, MPEG-1, Layer III, no CRC
+			0x10, // 32kbit/s, 44.1kHz, no padding
+			0xC4, // Mono, original
+		})
+		out.Write(frame)
+	}
+	return out.Bytes()
+}
+
+// jpegGradient returns a small colour gradient, so resizing and quality
+// changes stay visible
+func jpegGradient(width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{
+				R: uint8(255 * x / width),
+				G: uint8(255 * y / height),
+				B: uint8(128 + 127*math.Sin(float64(x+y)/16)),
+				A: 255,
+			})
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: 85}); err != nil {
+		log.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+// webmOpusSilence returns a WebM holding mono Opus silence in 20ms frames,
+// the shape browsers' MediaRecorder gives voice recordings (audio/webm;codecs=opus)
+func webmOpusSilence(ms int) []byte {
+	// TOC 0xF8 (CELT fullband, 20ms, one frame) followed by a silent frame
+	silentFrame := []byte{0xF8, 0xFF, 0xFE}
+
+	opusHead := []byte("OpusHead")
+	opusHead = append(opusHead, 1, 1)                          // Version, channels
+	opusHead = binary.LittleEndian.AppendUint16(opusHead, 312) // Pre-skip
+	opusHead = binary.LittleEndian.AppendUint32(opusHead, 48000)
+	opusHead = append(opusHead, 0, 0, 0) // Output gain, channel mapping family
+
+	header := ebml(0x1A45DFA3,
+		uintElement(0x4286, 1),       // EBMLVersion
+		uintElement(0x42F7, 1),       // EBMLReadVersion
+		uintElement(0x42F2, 4),       // EBMLMaxIDLength
+		uintElement(0x42F3, 8),       // EBMLMaxSizeLength
+		ebml(0x4282, []byte("webm")), // DocType
+		uintElement(0x4287, 4),       // DocTypeVersion
+		uintElement(0x4285, 2),       // DocTypeReadVersion
+	)
+
+	info := ebml(0x1549A966,
+		uintElement(0x2AD7B1, 1000000),            // TimestampScale: 1ms
+		floatElement(0x4489, float64(ms)),         // Duration
+		ebml(0x4D80, []byte("whats-convert-api")), // MuxingApp
+		ebml(0x5741, []byte("whats-convert-api")), // WritingApp
+	)
+
+	tracks := ebml(0x1654AE6B,
+		ebml(0xAE, // TrackEntry
+			uintElement(0xD7, 1),          // TrackNumber
+			uintElement(0x73C5, 1),        // TrackUID
+			uintElement(0x83, 2),          // TrackType: audio
+			ebml(0x86, []byte("A_OPUS")),  // CodecID
+			ebml(0x63A2, opusHead),        // CodecPrivate
+			uintElement(0x56AA, 6500000),  // CodecDelay (ns)
+			uintElement(0x56BB, 80000000), // SeekPreRoll (ns)
+			ebml(0xE1, // Audio
+				floatElement(0xB5, 48000), // SamplingFrequency
+				uintElement(0x9F, 1),      // Channels
+			),
+		),
+	)
+
+	blocks := [][]byte{uintElement(0xE7, 0)} // Cluster timestamp
+	for timestamp := 0; timestamp < ms; timestamp += 20 {
+		block := []byte{0x81} // Track 1
+		block = binary.BigEndian.AppendUint16(block, uint16(timestamp))
+		block = append(block, 0x80) // Keyframe
+		blocks = append(blocks, ebml(0xA3, append(block, silentFrame...)))
+	}
+	cluster := ebml(0x1F43B675, blocks...)
+
+	segment := ebml(0x18538067, info, tracks, cluster)
+	return append(header, segment...)
+}
+
+// ebml encodes an element whose payload is the concatenation of children
+func ebml(id uint32, children ...[]byte) []byte {
+	payload := bytes.Join(children, nil)
+
+	var out []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if b := byte(id >> shift); b != 0 || len(out) > 0 {
+			out = append(out, b)
+		}
+	}
+	out = append(out, vint(len(payload))...)
+	return append(out, payload...)
+}
+
+// vint encodes an element size in the shortest EBML variable-length form
+func vint(n int) []byte {
+	for length := 1; length <= 8; length++ {
+		if n < 1<<(7*length)-1 {
+			out := make([]byte, length)
+			value := uint64(n) | 1<<(7*length)
+			for i := length - 1; i >= 0; i-- {
+				out[i] = byte(value)
+				value >>= 8
+			}
+			return out
+		}
+	}
+	log.Fatalf("element of %d bytes too large", n)
+	return nil
+}
+
+func uintElement(id uint32, value uint64) []byte {
+	payload := binary.BigEndian.AppendUint64(nil, value)
+	for len(payload) > 1 && payload[0] == 0 {
+		payload = payload[1:]
+	}
+	return ebml(id, payload)
+}
+
+func floatElement(id uint32, value float64) []byte {
+	return ebml(id, binary.BigEndian.AppendUint64(nil, math.Float64bits(value)))
+}
@@ -0,0 +1,60 @@
+// Package samples embeds tiny media files for exercising the API end-to-end.
+package samples
+
+import (
+	"embed"
+	"sort"
+	"strings"
+)
+
+//go:generate go run generate.go
+
+//go:embed media
+var media embed.FS
+
+// Sample is an embedded media file
+type Sample struct {
+	Type     string // Name used in /samples/{type}
+	Filename string
+	MimeType string
+	Endpoint string // Conversion endpoint the sample suits
+	Data     []byte
+}
+
+var catalog = map[string]Sample{
+	"mp3":  {Type: "mp3", Filename: "sample.mp3", MimeType: "audio/mpeg", Endpoint: "/convert/audio"},
+	"jpeg": {Type: "jpeg", Filename: "sample.jpg", MimeType: "image/jpeg", Endpoint: "/convert/image"},
+	"webm": {Type: "webm", Filename: "sample.webm", MimeType: "audio/webm;codecs=opus", Endpoint: "/convert/audio"},
+}
+
+// aliases are accepted in place of a type
+var aliases = map[string]string{"jpg": "jpeg"}
+
+// Get returns the sample of a type, case-insensitively
+func Get(name string) (Sample, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+
+	sample, ok := catalog[name]
+	if !ok {
+		return Sample{}, false
+	}
+	data, err := media.ReadFile("media/" + sample.Filename)
+	if err != nil {
+		return Sample{}, false
+	}
+	sample.Data = data
+	return sample, true
+}
+
+// Types returns the sample types in alphabetical order
+func Types() []string {
+	types := make([]string, 0, len(catalog))
+	for name := range catalog {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
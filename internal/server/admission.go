@@ -0,0 +1,160 @@
+package server
+
+import (
+	"log"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+)
+
+const (
+	// memorySampleInterval is how often the memory monitor reads runtime metrics
+	memorySampleInterval = 500 * time.Millisecond
+
+	// memoryReclaimInterval rate-limits the forced collections that confirm
+	// usage above the high-water mark isn't just garbage (e.g. idle pool buffers)
+	memoryReclaimInterval = 10 * time.Second
+)
+
+// Runtime metrics that make up the memory counted against the Go memory limit
+const (
+	metricTotalMemory  = "/memory/classes/total:bytes"
+	metricHeapReleased = "/memory/classes/heap/released:bytes"
+)
+
+// memoryMonitor samples process memory in the background so the admission
+// check on the request path is a single atomic load.
+type memoryMonitor struct {
+	limit     int64
+	highWater int64
+	usage     atomic.Int64
+	over      atomic.Bool
+	rejected  atomic.Int64
+	samples   []metrics.Sample
+	reclaimed time.Time
+	stop      chan struct{}
+}
+
+// newMemoryMonitor watches usage against the runtime memory limit.
+// It returns nil when no limit is set or percent disables admission control.
+func newMemoryMonitor(percent int) *memoryMonitor {
+	limit := debug.SetMemoryLimit(-1) // Read without changing
+	if limit <= 0 || limit == math.MaxInt64 || percent <= 0 || percent >= 100 {
+		return nil
+	}
+
+	m := &memoryMonitor{
+		limit:     limit,
+		highWater: limit / 100 * int64(percent),
+		samples: []metrics.Sample{
+			{Name: metricTotalMemory},
+			{Name: metricHeapReleased},
+		},
+		stop: make(chan struct{}),
+	}
+	m.sample()
+
+	go m.run()
+	return m
+}
+
+func (m *memoryMonitor) run() {
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.sample()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// sample refreshes usage and logs high-water crossings
+func (m *memoryMonitor) sample() {
+	usage := m.read()
+	if usage >= m.highWater && time.Since(m.reclaimed) >= memoryReclaimInterval {
+		// Two cycles empty sync.Pool's victim cache; FreeOSMemory returns the pages
+		m.reclaimed = time.Now()
+		runtime.GC()
+		debug.FreeOSMemory()
+		usage = m.read()
+	}
+	m.usage.Store(usage)
+
+	over := usage >= m.highWater
+	if m.over.Swap(over) != over {
+		if over {
+			log.Printf("⚠️  Memory above high-water mark (%dMB of %dMB): rejecting large requests", usage>>20, m.limit>>20)
+		} else {
+			log.Printf("Memory back below high-water mark (%dMB of %dMB)", usage>>20, m.limit>>20)
+		}
+	}
+}
+
+// read returns the memory counted against the limit: everything mapped minus released heap
+func (m *memoryMonitor) read() int64 {
+	metrics.Read(m.samples)
+
+	var usage int64
+	if m.samples[0].Value.Kind() == metrics.KindUint64 {
+		usage = int64(m.samples[0].Value.Uint64())
+	}
+	if m.samples[1].Value.Kind() == metrics.KindUint64 {
+		usage -= int64(m.samples[1].Value.Uint64())
+	}
+	return usage
+}
+
+// Stop ends background sampling
+func (m *memoryMonitor) Stop() {
+	close(m.stop)
+}
+
+// Stats reports the last sample for /stats-style diagnostics
+func (m *memoryMonitor) Stats() map[string]interface{} {
+	return map[string]interface{}{
+		"usage_mb":      m.usage.Load() >> 20,
+		"high_water_mb": m.highWater >> 20,
+		"limit_mb":      m.limit >> 20,
+		"over":          m.over.Load(),
+		"rejected":      m.rejected.Load(),
+	}
+}
+
+// admissionMiddleware answers 503 to large conversion and upload requests while
+// memory sits above the high-water mark, so the process sheds load instead of
+// being OOM-killed. Small requests and read-only routes are always admitted.
+func admissionMiddleware(m *memoryMonitor, minBodySize int) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if c.Method() != fiber.MethodPost || !m.over.Load() || !isAdmissionPath(c.Path()) {
+			return c.Next()
+		}
+		if c.Request().Header.ContentLength() < minBodySize {
+			return c.Next()
+		}
+
+		m.rejected.Add(1)
+		c.Set(fiber.HeaderRetryAfter, "5")
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error:   "Service under memory pressure",
+			Code:    "memory_pressure",
+			Details: "Memory usage is above the configured high-water mark; retry shortly or send a smaller payload",
+		})
+	}
+}
+
+// isAdmissionPath matches the routes that buffer and transform large payloads
+func isAdmissionPath(path string) bool {
+	return strings.Contains(path, "/convert/") || strings.Contains(path, "/upload/s3")
+}
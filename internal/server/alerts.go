@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/notify"
+	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/services"
+)
+
+// alertWatcher checks operational conditions on an interval and raises
+// alerts when they start and resolutions when they clear
+type alertWatcher struct {
+	notifier *notify.Notifier
+	interval time.Duration
+
+	errorRate    float64
+	minRequests  int64
+	queueWaiting int32
+
+	workerPool    *pool.WorkerPool
+	s3Service     *services.S3Service
+	uploadManager *services.UploadManager
+
+	requests     atomic.Int64 // Since the last check
+	serverErrors atomic.Int64
+
+	firing map[string]bool // Conditions whose alert went out; only touched by run
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// countResponses tallies responses for the error-rate check
+func (w *alertWatcher) countResponses(c fiber.Ctx) error {
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	if err != nil {
+		// The error handler writes the response after this middleware returns
+		status = fiber.StatusInternalServerError
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		}
+	}
+
+	w.requests.Add(1)
+	if status >= fiber.StatusInternalServerError {
+		w.serverErrors.Add(1)
+	}
+	return err
+}
+
+func (w *alertWatcher) start() {
+	w.firing = make(map[string]bool)
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	go w.run()
+}
+
+func (w *alertWatcher) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.checkErrorRate()
+			w.checkS3()
+			w.checkSaturation()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// Stop ends the checks; alerts already raised are still delivered
+func (w *alertWatcher) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// update raises the condition's alert when active and resolves it once it
+// clears. A firing alert suppressed by the cooldown is retried next check.
+func (w *alertWatcher) update(condition string, active bool, severity notify.Severity, summary, details string) {
+	switch {
+	case active && !w.firing[condition]:
+		w.firing[condition] = w.notifier.Notify(notify.Alert{
+			Condition: condition,
+			Severity:  severity,
+			Summary:   summary,
+			Details:   details,
+		})
+	case !active && w.firing[condition]:
+		w.firing[condition] = false
+		w.notifier.Notify(notify.Alert{
+			Condition: condition,
+			Severity:  notify.Resolved,
+			Summary:   summary,
+		})
+	}
+}
+
+func (w *alertWatcher) checkErrorRate() {
+	requests := w.requests.Swap(0)
+	serverErrors := w.serverErrors.Swap(0)
+
+	rate := 0.0
+	if requests > 0 {
+		rate = float64(serverErrors) / float64(requests)
+	}
+	active := requests >= w.minRequests && rate >= w.errorRate
+
+	summary := fmt.Sprintf("%.0f%% of responses were 5xx in the last %s (%d of %d)", rate*100, w.interval, serverErrors, requests)
+	w.update(notify.ErrorRate, active, notify.Critical, summary, "")
+}
+
+func (w *alertWatcher) checkS3() {
+	if w.s3Service == nil {
+		return
+	}
+
+	stats := w.s3Service.GetStats()
+	summary := "S3 provider is reachable again"
+	if !stats.ProviderHealthy {
+		summary = fmt.Sprintf("S3 provider failed %d health checks in a row", stats.HealthCheckFailures)
+	}
+	details := fmt.Sprintf("Reconnects: %d succeeded, %d failed", stats.Reconnects, stats.FailedReconnects)
+	w.update(notify.S3Outage, !stats.ProviderHealthy, notify.Critical, summary, details)
+}
+
+func (w *alertWatcher) checkSaturation() {
+	stats := w.workerPool.Stats()
+	conversionsFull := stats.WaitingConversions >= w.queueWaiting
+
+	uploadsFull := false
+	var uploads, maxUploads int
+	if w.uploadManager != nil {
+		uploadStats := w.uploadManager.GetStats()
+		uploads, _ = uploadStats["current_uploads"].(int)
+		maxUploads, _ = uploadStats["max_concurrent"].(int)
+		uploadsFull = maxUploads > 0 && uploads >= maxUploads
+	}
+
+	summary := "Conversion queue and upload slots are back below their limits"
+	switch {
+	case conversionsFull:
+		summary = fmt.Sprintf("%d conversions waiting for %d workers", stats.WaitingConversions, stats.MaxWorkers)
+	case uploadsFull:
+		summary = fmt.Sprintf("All %d upload slots are in use", maxUploads)
+	}
+	details := fmt.Sprintf("Conversions: %d running, %d waiting. Uploads: %d of %d slots.",
+		stats.ActiveConversions, stats.WaitingConversions, uploads, maxUploads)
+	w.update(notify.QueueSaturation, conversionsFull || uploadsFull, notify.Warning, summary, details)
+}
+
+// warmup runs the startup self-test and alerts when it fails
+func (s *Server) warmup() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.RequestTimeout)
+	defer cancel()
+
+	start := time.Now()
+	if err := services.Warmup(ctx, s.audioConverter, s.imageConverter); err != nil {
+		log.Printf("❌ Warm-up failed: %v", err)
+		if s.notifier != nil {
+			s.notifier.Notify(notify.Alert{
+				Condition: notify.WarmupFailed,
+				Severity:  notify.Critical,
+				Summary:   "Startup test conversion failed; conversions will likely fail",
+				Details:   err.Error(),
+			})
+		}
+		return
+	}
+	log.Printf("🔥 Warm-up completed in %s", time.Since(start).Round(time.Millisecond))
+}
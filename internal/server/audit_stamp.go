@@ -0,0 +1,16 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+
+	"whats-convert-api/internal/features"
+	"whats-convert-api/internal/services"
+)
+
+// auditOwnerMiddleware has the images converted by a request stamped with
+// the caller's API key (as its tenant ID) and the request ID
+func auditOwnerMiddleware(c fiber.Ctx) error {
+	c.SetContext(services.WithAuditOwner(c.Context(), c.Get(features.APIKeyHeader), requestid.FromContext(c)))
+	return c.Next()
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/pool"
+)
+
+// Back-pressure headers on conversion responses
+const (
+	headerQueueDepth    = "X-Queue-Depth"    // Conversions waiting for a worker
+	headerActiveWorkers = "X-Active-Workers" // Conversions holding a worker
+	headerServerLoad    = "X-Server-Load"    // Busy share of the workers, or of the memory high-water mark
+)
+
+// backPressureMiddleware reports how busy the server is on every /convert/
+// response, rejections included, so clients can slow down before requests
+// queue for long or get shed. The headers are read once the request is done,
+// describing the server the client's next request would meet.
+func backPressureMiddleware(workers *pool.WorkerPool, memory func() *memoryMonitor) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		err := c.Next()
+
+		path := c.Path()
+		if version := versionFromPath(path); version != "" {
+			path = strings.TrimPrefix(path, "/v"+version)
+		}
+		if !strings.HasPrefix(path, "/convert/") {
+			return err
+		}
+
+		stats := workers.Stats()
+		c.Set(headerQueueDepth, strconv.Itoa(int(stats.WaitingConversions)))
+		c.Set(headerActiveWorkers, strconv.Itoa(int(stats.ActiveConversions)))
+		c.Set(headerServerLoad, strconv.FormatFloat(serverLoad(stats, memory()), 'f', 2, 64))
+		return err
+	}
+}
+
+// serverLoad is the conversions holding or waiting for a worker per worker,
+// or memory use relative to the admission high-water mark when that is
+// higher. At 1 every worker is busy (or large requests are about to be shed);
+// above 1 conversions queue.
+func serverLoad(stats pool.WorkerPoolStats, memory *memoryMonitor) float64 {
+	var load float64
+	if stats.MaxWorkers > 0 {
+		load = float64(stats.ActiveConversions+stats.WaitingConversions) / float64(stats.MaxWorkers)
+	}
+	if memory != nil && memory.highWater > 0 {
+		load = max(load, float64(memory.usage.Load())/float64(memory.highWater))
+	}
+	return load
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/config"
+	"whats-convert-api/internal/models"
+)
+
+// chaosFaultHeader names the fault injected into a response.
+const chaosFaultHeader = "X-Chaos-Fault"
+
+// chaosMiddleware injects latency, 503 responses and dropped connections into
+// a percentage of API requests so clients can validate their retry logic.
+// Health probes are exempt so orchestrators don't restart the container.
+func chaosMiddleware(cfg *config.Config) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if isHealthPath(c.Path()) {
+			return c.Next()
+		}
+
+		if chaosHit(cfg.ChaosDropPercent) {
+			// Close the socket without writing a response
+			return c.RequestCtx().Conn().Close()
+		}
+
+		if chaosHit(cfg.ChaosLatencyPercent) {
+			c.Set(chaosFaultHeader, "latency")
+			select {
+			case <-time.After(cfg.ChaosLatency):
+			case <-c.Context().Done():
+			}
+		}
+
+		if chaosHit(cfg.ChaosErrorPercent) {
+			c.Set(chaosFaultHeader, "error")
+			c.Set(fiber.HeaderRetryAfter, "1")
+			return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{
+				Error:   "Service unavailable",
+				Details: "Injected fault (CHAOS_ENABLED)",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// chaosHit reports true for roughly percent out of every 100 calls
+func chaosHit(percent int) bool {
+	return percent > 0 && rand.IntN(100) < percent
+}
+
+// isHealthPath matches /health with or without a version prefix
+func isHealthPath(path string) bool {
+	if version := versionFromPath(path); version != "" {
+		path = strings.TrimPrefix(path, "/v"+version)
+	}
+	return path == "/health"
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+
+	"whats-convert-api/internal/models"
+)
+
+// statusClientClosedRequest is nginx's status for requests the client
+// abandoned before the response was ready; it only reaches logs and metrics
+const statusClientClosedRequest = 499
+
+// errClientDisconnected is the cancellation cause of abandoned requests
+var errClientDisconnected = errors.New("client disconnected")
+
+// disconnectMiddleware derives the request context from the one Fiber
+// carries and cancels it when the client hangs up, so FFmpeg/vips processes,
+// downloads, queued jobs and streamed S3 uploads stop instead of finishing
+// work nobody will receive. HTTP/3 requests are cancelled with their stream.
+// Abandoned requests are answered with 499 for the logs. Background work that
+// outlives its request (async S3 uploads) must detach itself with
+// context.WithoutCancel.
+func disconnectMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		ctx, cancel := context.WithCancelCause(c.Context())
+		defer cancel(nil)
+
+		var stop func()
+		if stream, ok := c.RequestCtx().UserValue(streamContextKey{}).(context.Context); ok {
+			stopStream := context.AfterFunc(stream, func() { cancel(errClientDisconnected) })
+			stop = func() { stopStream() }
+		} else {
+			stop = watchDisconnect(c.RequestCtx().Conn(), func() { cancel(errClientDisconnected) })
+		}
+		c.SetContext(ctx)
+
+		err := c.Next()
+		stop()
+
+		if !errors.Is(context.Cause(ctx), errClientDisconnected) {
+			return err
+		}
+		log.Printf("Client disconnected, cancelled %s %s request_id=%s", c.Method(), c.Path(), requestid.FromContext(c))
+		return c.Status(statusClientClosedRequest).JSON(models.ErrorResponse{
+			Error: "Client closed request",
+			Code:  "client_closed_request",
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// tlsClosePollInterval is how often a TLS connection's TCP state is checked
+// once the client has sent something mid-request
+const tlsClosePollInterval = 250 * time.Millisecond
+
+// watchDisconnect calls disconnected once the peer closes conn while the
+// request runs, and returns a func that stops watching. The socket is peeked,
+// never read, so a pipelined request stays for the server; seeing one ends
+// the watch, since the client is evidently still there. TLS clients send a
+// close_notify record before hanging up, so on TLS connections seeing data
+// switches to polling the TCP state for the client's FIN instead.
+func watchDisconnect(conn net.Conn, disconnected func()) (stop func()) {
+	tlsConn, isTLS := conn.(*tls.Conn)
+	if isTLS {
+		conn = tlsConn.NetConn() // Peeking sees records, never decrypts them
+	}
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return func() {} // In-process test connections
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return func() {}
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var closed bool
+		buf := make([]byte, 1)
+		err := raw.Read(func(fd uintptr) bool {
+			n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+			switch {
+			case errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.EINTR):
+				return false // Nothing sent yet, wait until the socket is readable
+			case err != nil, n == 0:
+				closed = true // Reset, or EOF
+			}
+			return true
+		})
+		if err == nil && !closed && isTLS {
+			closed = waitPeerClosed(raw, quit)
+		}
+		if err == nil && closed {
+			disconnected()
+		}
+	}()
+
+	return func() {
+		close(quit)
+		// An expired deadline wakes the poller; the server sets its own
+		// deadline before reading the next request
+		_ = conn.SetReadDeadline(time.Now())
+		<-done
+		_ = conn.SetReadDeadline(time.Time{})
+	}
+}
+
+// waitPeerClosed polls the socket's TCP state until the peer has sent FIN or
+// reset the connection, or quit is closed
+func waitPeerClosed(raw syscall.RawConn, quit <-chan struct{}) bool {
+	ticker := time.NewTicker(tlsClosePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return false
+		case <-ticker.C:
+		}
+
+		var state uint8
+		err := raw.Control(func(fd uintptr) {
+			if info, err := unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO); err == nil {
+				state = info.State
+			}
+		})
+		if err != nil {
+			return false
+		}
+		if state == unix.BPF_TCP_CLOSE_WAIT || state == unix.BPF_TCP_CLOSE {
+			return true
+		}
+	}
+}
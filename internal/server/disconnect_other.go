@@ -0,0 +1,11 @@
+//go:build !linux
+
+package server
+
+import "net"
+
+// watchDisconnect is Linux-only: elsewhere requests run to completion (or
+// their deadline) after the client leaves
+func watchDisconnect(conn net.Conn, disconnected func()) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/features"
+	"whats-convert-api/internal/services"
+)
+
+// tenantMiddleware has a request's conversions wait for workers as the
+// caller's API key, so busy workers are shared fairly between keys
+// (FAIR_QUEUING)
+func tenantMiddleware(c fiber.Ctx) error {
+	c.SetContext(services.WithTenant(c.Context(), c.Get(features.APIKeyHeader)))
+	return c.Next()
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/features"
+	"whats-convert-api/internal/services"
+)
+
+// requireFeature hides a route unless flag is enabled for the caller's API key.
+// Disabled routes answer exactly as unknown ones, so clients can't tell
+// gated pipelines from missing ones.
+func (s *Server) requireFeature(flag string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !s.features.Enabled(flag, c.Get(features.APIKeyHeader)) {
+			return endpointNotFound(c)
+		}
+		return c.Next()
+	}
+}
+
+// cacheConversions lets conversions use the conversion cache while the cache
+// flag is enabled for the caller's API key
+func (s *Server) cacheConversions(c fiber.Ctx) error {
+	if s.features.Enabled(features.Cache, c.Get(features.APIKeyHeader)) {
+		c.SetContext(services.WithConversionCache(c.Context()))
+	}
+	return c.Next()
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"whats-convert-api/internal/grpcapi"
+	"whats-convert-api/internal/services"
+)
+
+// initializeGRPC builds the gRPC server on top of the HTTP API's converters
+func (s *Server) initializeGRPC() {
+	service := grpcapi.NewService(s.audioConverter, s.imageConverter, s.config.RequestTimeout, s.config.BodyLimit)
+	service.SetBatchLimits(s.config.BatchMaxSize, services.BatchOptions{
+		ItemTimeout: s.config.BatchItemTimeout,
+		Concurrency: s.config.BatchConcurrency,
+	})
+	s.grpcServer = grpcapi.NewServer(service, s.config.BodyLimit, s.config.SlowRequestThreshold, s.usage, s.features, s.tiers)
+}
+
+// startGRPC serves gRPC on GRPC_PORT in the background
+func (s *Server) startGRPC() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", s.config.GRPCPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+
+	go func() {
+		if err := s.grpcServer.Serve(listener); err != nil {
+			log.Printf("gRPC server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// stopGRPC lets in-flight calls finish until ctx expires, then closes them
+func (s *Server) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+	log.Println("gRPC server stopped")
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/valyala/fasthttp"
+)
+
+// streamContextKey carries the context of an HTTP/3 request stream, which
+// is cancelled when the client resets the stream or drops the connection
+type streamContextKey struct{}
+
+// hopHeaders are connection-specific HTTP/1.1 headers HTTP/3 forbids
+var hopHeaders = map[string]bool{
+	fiber.HeaderConnection:       true,
+	fiber.HeaderKeepAlive:        true,
+	fiber.HeaderTransferEncoding: true,
+	fiber.HeaderUpgrade:          true,
+}
+
+// startHTTP3 serves the API over QUIC on HTTP3_PORT in the background. Each
+// connection multiplexes up to HTTP3_MAX_STREAMS requests, so a colocated
+// client needs a single handshake however many conversions it has in flight.
+func (s *Server) startHTTP3() {
+	s.http3Server = &http3.Server{
+		Addr:    fmt.Sprintf(":%s", s.config.HTTP3ListenPort()),
+		Handler: http3Handler(s.app, s.config.BodyLimit),
+		QUICConfig: &quic.Config{
+			MaxIncomingStreams: int64(s.config.HTTP3MaxStreams),
+			MaxIdleTimeout:     s.config.IdleTimeout,
+		},
+		IdleTimeout: s.config.IdleTimeout,
+	}
+
+	go func() {
+		err := s.http3Server.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, quic.ErrServerClosed) {
+			log.Printf("HTTP/3 server error: %v", err)
+		}
+	}()
+}
+
+// stopHTTP3 lets in-flight requests finish until ctx expires, then closes
+// their connections
+func (s *Server) stopHTTP3(ctx context.Context) {
+	if err := s.http3Server.Shutdown(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		log.Printf("Error shutting down HTTP/3: %v", err)
+	}
+	_ = s.http3Server.Close()
+}
+
+// altSvcMiddleware advertises the HTTP/3 listener, so clients that speak it
+// switch over after their first TCP request
+func altSvcMiddleware(port string) fiber.Handler {
+	value := fmt.Sprintf(`h3=":%s"; ma=86400`, port)
+	return func(c fiber.Ctx) error {
+		c.Set("Alt-Svc", value)
+		return c.Next()
+	}
+}
+
+// http3Handler runs Fiber requests arriving over HTTP/3. Unlike Fiber's
+// net/http adaptor it honours BODY_LIMIT, streams response bodies, keeps the
+// client address for rate limits and usage, and hands the stream context to
+// disconnectMiddleware.
+func http3Handler(app *fiber.App, bodyLimit int) http.HandlerFunc {
+	handler := app.Handler()
+
+	// Oversized bodies get the same answer as over TCP
+	reject := func(fctx *fasthttp.RequestCtx) {
+		c := app.AcquireCtx(fctx)
+		defer app.ReleaseCtx(c)
+		_ = app.Config().ErrorHandler(c, fiber.ErrRequestEntityTooLarge)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var fctx fasthttp.RequestCtx
+		fctx.Init(&fasthttp.Request{}, remoteTCPAddr(r.RemoteAddr), nil)
+		defer fctx.Response.Reset()
+
+		req := &fctx.Request
+		req.Header.SetMethod(r.Method)
+		req.SetRequestURI(r.RequestURI)
+		req.SetHost(r.Host)
+		for key, values := range r.Header {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+
+		tooLarge := r.ContentLength > int64(bodyLimit)
+		if !tooLarge && r.Body != nil {
+			n, err := io.Copy(req.BodyWriter(), io.LimitReader(r.Body, int64(bodyLimit)+1))
+			if err != nil {
+				return // The client reset the stream
+			}
+			tooLarge = n > int64(bodyLimit)
+			req.Header.SetContentLength(int(n))
+		}
+
+		if tooLarge {
+			reject(&fctx)
+		} else {
+			fctx.SetUserValue(streamContextKey{}, r.Context())
+			handler(&fctx)
+		}
+
+		for key, value := range fctx.Response.Header.All() {
+			if name := string(key); !hopHeaders[name] {
+				w.Header().Add(name, string(value))
+			}
+		}
+		w.WriteHeader(fctx.Response.StatusCode())
+
+		stream := fctx.Response.BodyStream()
+		if stream == nil {
+			_, _ = w.Write(fctx.Response.Body())
+			return
+		}
+		// Flush as the body is produced; the stream is closed by Reset
+		_, _ = io.Copy(flushWriter{w}, stream)
+	}
+}
+
+// flushWriter pushes every write of a streamed body to the client
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// remoteTCPAddr presents the client's UDP address as a TCP one, the only
+// kind fasthttp reports in c.IP()
+func remoteTCPAddr(remote string) net.Addr {
+	addrPort, err := netip.ParseAddrPort(remote)
+	if err != nil {
+		return nil
+	}
+	return net.TCPAddrFromAddrPort(addrPort)
+}
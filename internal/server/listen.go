@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// listenConfig turns the connection settings into Fiber's listener options:
+// HTTPS when a certificate is configured, and one process per CPU sharing
+// PORT with PREFORK
+func (s *Server) listenConfig() fiber.ListenConfig {
+	return fiber.ListenConfig{
+		CertFile:              s.config.TLSCertFile,
+		CertKeyFile:           s.config.TLSKeyFile,
+		EnablePrefork:         s.config.Prefork,
+		DisableStartupMessage: fiber.IsChild(),
+	}
+}
+
+// preforkChildren tracks the listener processes of PREFORK so the parent
+// can drain them on shutdown. Fiber kills the remaining children as soon as
+// one exits, so a drained child reports back and waits for the parent
+// instead of exiting.
+type preforkChildren struct {
+	mu      sync.Mutex
+	pids    []int
+	drained chan os.Signal
+}
+
+func newPreforkChildren() *preforkChildren {
+	p := &preforkChildren{drained: make(chan os.Signal, runtime.GOMAXPROCS(0))}
+	notifyDrained(p.drained)
+	return p
+}
+
+// add is Fiber's OnFork hook
+func (p *preforkChildren) add(pid int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pids = append(p.pids, pid)
+	return nil
+}
+
+// stop asks every child to shut down gracefully and waits until they have
+// drained or ctx expires
+func (p *preforkChildren) stop(ctx context.Context) {
+	p.mu.Lock()
+	pids := append([]int(nil), p.pids...)
+	p.mu.Unlock()
+
+	pending := 0
+	for _, pid := range pids {
+		process, err := os.FindProcess(pid)
+		if err != nil {
+			continue
+		}
+		if err := process.Signal(syscall.SIGTERM); err == nil {
+			pending++
+		}
+	}
+
+	for ; pending > 0; pending-- {
+		select {
+		case <-p.drained:
+		case <-ctx.Done():
+			log.Printf("%d prefork children still draining, stopping them", pending)
+			return
+		}
+	}
+}
+
+// waitForPreforkParent tells the parent this child has drained, then blocks
+// until the parent exits and Fiber's watchdog ends the process
+func waitForPreforkParent() {
+	reportDrained()
+	select {}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/i18n"
+)
+
+// localizedFields are the error body fields translated for the client. The
+// code field stays stable so clients can keep matching on it.
+var localizedFields = []string{"error", "details"}
+
+// localizeErrorsMiddleware translates JSON error bodies into the language the
+// client asks for with Accept-Language. Messages missing from the catalog,
+// such as raw ffmpeg output in details, are left in English.
+func localizeErrorsMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			// The app error handler localizes these itself
+			return err
+		}
+
+		if c.Response().StatusCode() < fiber.StatusBadRequest {
+			return nil
+		}
+		contentType := string(c.Response().Header.ContentType())
+		if !strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) {
+			return nil
+		}
+		c.Vary(fiber.HeaderAcceptLanguage)
+
+		language := i18n.Language(c.Get(fiber.HeaderAcceptLanguage))
+		if language == i18n.Default {
+			return nil
+		}
+		if body, ok := localizeErrorBody(c.Response().Body(), language); ok {
+			c.Response().SetBodyRaw(body)
+			c.Set(fiber.HeaderContentLanguage, language)
+		}
+		return nil
+	}
+}
+
+// localizeErrorBody translates the message fields of a JSON error object,
+// reporting false when body isn't one
+func localizeErrorBody(body []byte, language string) ([]byte, bool) {
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, false
+	}
+
+	var message string
+	if raw, ok := fields["error"]; !ok || json.Unmarshal(raw, &message) != nil {
+		return nil, false
+	}
+
+	for _, name := range localizedFields {
+		var text string
+		if raw, ok := fields[name]; !ok || json.Unmarshal(raw, &text) != nil {
+			continue
+		}
+		translated, err := json.Marshal(i18n.Translate(language, text))
+		if err != nil {
+			return nil, false
+		}
+		fields[name] = translated
+	}
+
+	localized, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false
+	}
+	return localized, true
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// maintenanceMiddleware answers requests to endpoints under maintenance with
+// 503, telling clients when to come back if the window has an end.
+func maintenanceMiddleware(maintenance *services.Maintenance) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		path := c.Path()
+		if version := versionFromPath(path); version != "" {
+			path = strings.TrimPrefix(path, "/v"+version)
+		}
+
+		window, ok := maintenance.Match(path)
+		if !ok {
+			return c.Next()
+		}
+
+		details := window.Message
+		if details == "" {
+			details = "This endpoint is temporarily disabled, please retry later"
+		}
+		if window.Until != nil {
+			seconds := math.Ceil(time.Until(*window.Until).Seconds())
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(int(seconds), 1)))
+		}
+
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error:   "Endpoint under maintenance",
+			Code:    "maintenance",
+			Details: details,
+		})
+	}
+}
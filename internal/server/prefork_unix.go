@@ -0,0 +1,18 @@
+//go:build !windows
+
+package server
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyDrained delivers the children's drained reports (SIGUSR1) to ch
+func notifyDrained(ch chan os.Signal) {
+	signal.Notify(ch, syscall.SIGUSR1)
+}
+
+func reportDrained() {
+	_ = syscall.Kill(os.Getppid(), syscall.SIGUSR1)
+}
@@ -0,0 +1,10 @@
+package server
+
+import "os"
+
+// Windows has no SIGTERM to relay: prefork children stop with the parent
+// without draining
+
+func notifyDrained(ch chan os.Signal) {}
+
+func reportDrained() {}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+)
+
+// readOnlyMiddleware refuses every request that would convert or store
+// something with 503, leaving status, listings, object info and the media
+// proxy available while the processing tier is down (READ_ONLY).
+func readOnlyMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if readOnlyAllowed(c.Method(), c.Path()) {
+			return c.Next()
+		}
+
+		return c.Status(fiber.StatusServiceUnavailable).JSON(models.ErrorResponse{
+			Error:   "Service is read-only",
+			Code:    "read_only",
+			Details: "Conversions and uploads are paused; status, listing and download endpoints remain available",
+		})
+	}
+}
+
+// readOnlyAllowed reports whether a request is served in read-only mode:
+// reads, maintenance toggles, presigning a share link, cancelling work that
+// is already running (migrations included) and discarding workspaces.
+// Admin routes that start work, such as migrations and replays, are refused.
+func readOnlyAllowed(method, path string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return true
+	}
+
+	if version := versionFromPath(path); version != "" {
+		path = strings.TrimPrefix(path, "/v"+version)
+	}
+
+	switch {
+	case (method == fiber.MethodPut || method == fiber.MethodDelete) && strings.HasPrefix(path, "/admin/maintenance/"):
+		return true
+	case method == fiber.MethodDelete && strings.HasPrefix(path, "/admin/migrations/"):
+		return true
+	case method == fiber.MethodPost && strings.HasPrefix(path, "/upload/s3/object/") && strings.HasSuffix(path, "/share"):
+		return true
+	case method == fiber.MethodDelete && strings.HasPrefix(path, "/upload/s3/status/"):
+		return true
+	case method == fiber.MethodDelete && strings.HasPrefix(path, "/convert/batch/jobs/"):
+		return true
+	case method == fiber.MethodDelete && strings.HasPrefix(path, "/workspaces/"):
+		return true
+	}
+	return false
+}
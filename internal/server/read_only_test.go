@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestReadOnlyAllowed(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{fiber.MethodGet, "/admin/migrations", true},
+		{fiber.MethodGet, "/v1/admin/recordings/abc", true},
+		{fiber.MethodPut, "/admin/maintenance/convert", true},
+		{fiber.MethodDelete, "/v1/admin/maintenance/convert", true},
+		{fiber.MethodDelete, "/admin/migrations/mig-1", true},
+		{fiber.MethodPost, "/admin/migrations", false},
+		{fiber.MethodPost, "/v1/admin/migrations", false},
+		{fiber.MethodPost, "/admin/replay/abc", false},
+		{fiber.MethodPost, "/upload/s3/object/a/b.jpg/share", true},
+		{fiber.MethodPost, "/upload/s3/base64", false},
+		{fiber.MethodDelete, "/upload/s3/status/up-1", true},
+		{fiber.MethodDelete, "/upload/s3/object/a.jpg", false},
+		{fiber.MethodPost, "/convert/audio", false},
+	}
+
+	for _, tt := range tests {
+		if got := readOnlyAllowed(tt.method, tt.path); got != tt.want {
+			t.Errorf("readOnlyAllowed(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestReadOnlyMiddlewareRefusesMigrations(t *testing.T) {
+	app := fiber.New()
+	app.Use(readOnlyMiddleware())
+	app.Post("/admin/migrations", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusAccepted) })
+	app.Delete("/admin/migrations/:id", func(c fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/admin/migrations", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("POST /admin/migrations = %d, want 503", resp.StatusCode)
+	}
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodDelete, "/admin/migrations/mig-1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Errorf("DELETE /admin/migrations/mig-1 = %d, want 204", resp.StatusCode)
+	}
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+
+	"whats-convert-api/internal/features"
+	"whats-convert-api/internal/handlers"
+	"whats-convert-api/internal/providers"
+	"whats-convert-api/internal/services"
+)
+
+// recordingIDHeader names the recording of a failed request
+const recordingIDHeader = "X-Recording-ID"
+
+// recordedHeaders are the request headers kept in recordings; everything
+// else, credentials included, is dropped
+var recordedHeaders = []string{
+	fiber.HeaderContentType,
+	fiber.HeaderAccept,
+	fiber.HeaderAcceptLanguage,
+	fiber.HeaderUserAgent,
+	apiVersionHeader,
+	"Accept-Version",
+	"X-Debug-Trace",
+	"X-Debug-Timings",
+}
+
+// recordingMiddleware saves a sanitized envelope of every /convert and
+// /upload/s3 request answered with minStatus or above, and names it in
+// X-Recording-ID so the failure can be replayed with POST /admin/replay/{id}.
+// Replays are never recorded again.
+func recordingMiddleware(recorder *services.RequestRecorder, minStatus int) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		err := c.Next()
+
+		if c.Method() != fiber.MethodPost || c.Get(handlers.ReplayOfHeader) != "" {
+			return err
+		}
+		path := c.Path()
+		if version := versionFromPath(path); version != "" {
+			path = strings.TrimPrefix(path, "/v"+version)
+		}
+		if !strings.HasPrefix(path, "/convert/") && !strings.HasPrefix(path, "/upload/s3") {
+			return err
+		}
+
+		// Errors returned to Fiber are only written by its error handler
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			}
+		}
+		if status < minStatus || status == statusClientClosedRequest {
+			return err
+		}
+
+		rec := recordRequest(c, status)
+		if err != nil && rec.Error == "" {
+			rec.Error = err.Error()
+		}
+		id, saveErr := recorder.Save(rec)
+		if saveErr != nil {
+			log.Printf("Failed to record %s %s: %v", c.Method(), c.Path(), saveErr)
+			return err
+		}
+		c.Set(recordingIDHeader, id)
+
+		return err
+	}
+}
+
+// recordRequest builds the envelope of the current request
+func recordRequest(c fiber.Ctx, status int) services.RecordedRequest {
+	rec := services.RecordedRequest{
+		RequestID: requestid.FromContext(c),
+		Method:    c.Method(),
+		Path:      c.Path(),
+		Query:     string(c.Request().URI().QueryString()),
+		Headers:   make(map[string]string),
+		Status:    status,
+		Body:      c.Body(),
+	}
+	if apiKey := c.Get(features.APIKeyHeader); apiKey != "" {
+		rec.Tenant = services.TenantID(apiKey)
+	}
+	for _, header := range recordedHeaders {
+		if value := c.Get(header); value != "" {
+			rec.Headers[header] = value
+		}
+	}
+
+	var failure struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if json.Unmarshal(c.Response().Body(), &failure) == nil {
+		rec.Error, rec.ErrorCode = failure.Error, failure.Code
+	}
+
+	if form, err := c.MultipartForm(); err == nil {
+		rec.Params, rec.Inputs = recordForm(form.Value, form.File)
+	} else if len(rec.Body) > 0 {
+		rec.Params, rec.Inputs = recordJSON(rec.Body)
+	}
+	sort.Slice(rec.Inputs, func(i, j int) bool { return rec.Inputs[i].Field < rec.Inputs[j].Field })
+
+	return rec
+}
+
+// recordJSON replaces every "data" payload of a JSON body with its digest.
+// Bodies that aren't JSON are reduced to a digest of their own.
+func recordJSON(body []byte) (json.RawMessage, []services.RecordedInput) {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, []services.RecordedInput{digestBytes("body", body)}
+	}
+
+	var inputs []services.RecordedInput
+	value = redactPayloads(value, "", &inputs)
+
+	params, err := json.Marshal(value)
+	if err != nil {
+		return nil, inputs
+	}
+	return params, inputs
+}
+
+// redactPayloads walks a decoded JSON value, replacing "data" strings with
+// "sha256:<hex>" and collecting their digests under their dotted path
+func redactPayloads(value any, path string, inputs *[]services.RecordedInput) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			childPath := joinPath(path, key)
+			if data, ok := child.(string); ok && key == "data" && data != "" {
+				input := digestPayload(childPath, data)
+				*inputs = append(*inputs, input)
+				v[key] = "sha256:" + input.SHA256
+				continue
+			}
+			v[key] = redactPayloads(child, childPath, inputs)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactPayloads(child, joinPath(path, strconv.Itoa(i)), inputs)
+		}
+	}
+	return value
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// recordForm keeps the text fields of a multipart form, redacting "data"
+// payloads, and digests its files
+func recordForm(values map[string][]string, files map[string][]*multipart.FileHeader) (json.RawMessage, []services.RecordedInput) {
+	var inputs []services.RecordedInput
+	fields := make(map[string][]string, len(values))
+	for name, list := range values {
+		kept := make([]string, len(list))
+		for i, value := range list {
+			if name != "data" || value == "" {
+				kept[i] = value
+				continue
+			}
+			input := digestPayload(name, value)
+			inputs = append(inputs, input)
+			kept[i] = "sha256:" + input.SHA256
+		}
+		fields[name] = kept
+	}
+
+	for name, headers := range files {
+		for _, header := range headers {
+			input := services.RecordedInput{
+				Field:       name,
+				Filename:    header.Filename,
+				ContentType: header.Header.Get(fiber.HeaderContentType),
+				Size:        header.Size,
+			}
+			if file, err := header.Open(); err == nil {
+				hash := sha256.New()
+				if _, err := io.Copy(hash, file); err == nil {
+					input.SHA256 = hex.EncodeToString(hash.Sum(nil))
+				}
+				file.Close()
+			}
+			inputs = append(inputs, input)
+		}
+	}
+
+	params, err := json.Marshal(fields)
+	if err != nil {
+		return nil, inputs
+	}
+	return params, inputs
+}
+
+// digestPayload digests a "data" value: URLs as sent (keeping the URL
+// without its query string, which may hold credentials), base64 by its
+// decoded bytes so the digest matches the caller's file
+func digestPayload(field, data string) services.RecordedInput {
+	trimmed := strings.TrimSpace(data)
+	if parsed, err := url.Parse(trimmed); err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != "" {
+		input := digestBytes(field, []byte(trimmed))
+		input.URL = fmt.Sprintf("%s://%s%s", parsed.Scheme, parsed.Host, parsed.Path)
+		return input
+	}
+
+	payload := trimmed
+	var contentType string
+	if strings.HasPrefix(strings.ToLower(payload), "data:") {
+		if header, encoded, ok := strings.Cut(payload, ","); ok {
+			contentType, _, _ = strings.Cut(header[len("data:"):], ";")
+			payload = encoded
+		}
+	}
+
+	decoded, err := providers.DecodeBase64(payload)
+	if err != nil {
+		decoded = []byte(data)
+	}
+	input := digestBytes(field, decoded)
+	input.ContentType = contentType
+	return input
+}
+
+func digestBytes(field string, data []byte) services.RecordedInput {
+	sum := sha256.Sum256(data)
+	return services.RecordedInput{
+		Field:  field,
+		Size:   int64(len(data)),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+}
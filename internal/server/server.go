@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"runtime"
@@ -18,30 +19,73 @@ import (
 	"github.com/gofiber/fiber/v3/middleware/logger"
 	"github.com/gofiber/fiber/v3/middleware/recover"
 	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"github.com/quic-go/quic-go/http3"
 	swaggerFiles "github.com/swaggo/files"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"google.golang.org/grpc"
 
+	docs "whats-convert-api/docs"
+	"whats-convert-api/internal/buildinfo"
 	"whats-convert-api/internal/config"
+	"whats-convert-api/internal/features"
 	"whats-convert-api/internal/handlers"
+	"whats-convert-api/internal/i18n"
+	"whats-convert-api/internal/notify"
 	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/providers"
 	"whats-convert-api/internal/services"
+	"whats-convert-api/internal/tracing"
 )
 
 // Server represents the HTTP server
 type Server struct {
-	app            *fiber.App
-	config         *config.Config
-	workerPool     *pool.WorkerPool
-	bufferPool     *pool.BufferPool
-	downloader     *services.Downloader
-	audioConverter *services.AudioConverter
-	imageConverter *services.ImageConverter
-	handler        *handlers.ConverterHandler
-	s3Service      *services.S3Service
-	uploadManager  *services.UploadManager
-	s3Handler      *handlers.S3Handler
-	webHandler     *handlers.WebHandler
-	metaHandler    *handlers.MetaHandler
+	app             *fiber.App
+	config          *config.Config
+	workerPool      *pool.WorkerPool
+	bufferPool      *pool.BufferPool
+	downloader      *services.Downloader
+	audioConverter  *services.AudioConverter
+	imageConverter  *services.ImageConverter
+	videoConverter  *services.VideoConverter
+	inspector       *services.MediaInspector
+	signer          *responseSigner
+	stopTracing     func(context.Context) error
+	handler         *handlers.ConverterHandler
+	s3Service       *services.S3Service
+	uploadManager   *services.UploadManager
+	batchJobs       *services.BatchJobManager
+	workspaces      *services.WorkspaceManager
+	workspaceAPI    *handlers.WorkspaceHandler
+	migrations      *services.MigrationManager
+	migrationAPI    *handlers.MigrationHandler
+	s3Handler       *handlers.S3Handler
+	mediaHandler    *handlers.MediaHandler
+	webHandler      *handlers.WebHandler
+	webApp          *fiber.App // Web interface on its own port (WEB_UI_PORT)
+	http3Server     *http3.Server
+	prefork         *preforkChildren // Listener processes, in the PREFORK parent
+	metaHandler     *handlers.MetaHandler
+	replayHandler   *handlers.ReplayHandler
+	recorder        *services.RequestRecorder
+	recordings      *handlers.RecordingHandler
+	maintenance     *services.Maintenance
+	maintenanceAPI  *handlers.MaintenanceHandler
+	auditStamper    *services.AuditStamper
+	auditStampAPI   *handlers.AuditStampHandler
+	usage           *services.UsageTracker
+	usageHandler    *handlers.UsageHandler
+	cacheHandler    *handlers.CacheHandler
+	sourceStore     *services.SourceStore
+	spillStore      *services.SpillStore
+	tempJanitor     *services.TempJanitor
+	memoryMonitor   *memoryMonitor
+	features        *features.Set
+	presets         *services.Presets
+	tiers           *services.Tiers
+	conversionCache *services.ConversionCache
+	grpcServer      *grpc.Server
+	notifier        *notify.Notifier
+	alerts          *alertWatcher
 }
 
 // New creates a new server instance
@@ -53,7 +97,9 @@ func New(cfg *config.Config) *Server {
 	// Set runtime optimizations
 	runtime.GOMAXPROCS(runtime.NumCPU())
 	debug.SetGCPercent(cfg.GOGC)
-	debug.SetMemoryLimit(int64(1024 * 1024 * 1024)) // 1GB memory limit
+	if limit := cfg.MemoryLimitBytes(); limit > 0 {
+		debug.SetMemoryLimit(limit)
+	}
 
 	return &Server{
 		config: cfg,
@@ -69,19 +115,299 @@ func (s *Server) Initialize() error {
 	// Initialize worker pool
 	log.Printf("Initializing worker pool with %d workers", s.config.MaxWorkers)
 	s.workerPool = pool.NewWorkerPool(s.config.MaxWorkers)
+	s.workerPool.SetPriorityLane(s.config.PriorityWorkers, s.config.PriorityMaxSize)
+	s.workerPool.SetFairQueuing(s.config.FairQueuing)
+
+	// Share the CPUs between concurrent ffmpeg/vips processes
+	services.ConfigureEncoderThreads(s.config.FFmpegThreads, s.config.MaxWorkers)
 	if err := s.workerPool.Start(); err != nil {
 		return fmt.Errorf("failed to start worker pool: %w", err)
 	}
 
+	// Isolate ffmpeg/vips before any conversion runs
+	if err := services.ConfigureSandbox(services.SandboxConfig{
+		Mode:           services.SandboxMode(s.config.SandboxMode),
+		UID:            s.config.SandboxUID,
+		GID:            s.config.SandboxGID,
+		SeccompProfile: s.config.SandboxSeccompProfile,
+	}); err != nil {
+		return fmt.Errorf("failed to configure subprocess sandbox: %w", err)
+	}
+	log.Printf("Subprocess sandbox: %s", s.config.SandboxMode)
+
 	// Initialize downloader
 	s.downloader = services.NewDownloader(s.bufferPool, int64(s.config.BodyLimit))
+	if err := s.downloader.SetProxy(s.config.DownloadProxyURL); err != nil {
+		return fmt.Errorf("invalid DOWNLOAD_PROXY_URL: %w", err)
+	}
+	s.downloader.SetCircuitBreaker(s.config.DownloadBreakerThreshold, s.config.DownloadBreakerCooldown)
+	s.downloader.SetAllowPrivateHosts(s.config.DownloadAllowPrivateHosts)
 
 	// Initialize converters
 	s.audioConverter = services.NewAudioConverter(s.workerPool, s.bufferPool, s.downloader)
 	s.imageConverter = services.NewImageConverter(s.workerPool, s.bufferPool, s.downloader)
+	s.imageConverter.SetMaxMegapixels(s.config.MaxImageMegapixels)
+	s.audioConverter.SetMaxDuration(s.config.MaxAudioDuration, services.DurationPolicy(s.config.AudioDurationPolicy))
+	s.audioConverter.SetSkipCompliant(s.config.SkipCompliantInputs)
+	s.audioConverter.SetLoudnessTarget(services.LoudnessTarget{
+		Integrated: s.config.AudioLoudnormI,
+		Range:      s.config.AudioLoudnormLRA,
+		TruePeak:   s.config.AudioLoudnormTP,
+	})
+	s.imageConverter.SetSkipCompliant(s.config.SkipCompliantInputs)
+	s.imageConverter.SetQualityGuard(s.config.ImageQualityCheck, s.config.ImageMinSSIM, s.config.ImageMinPSNR)
+	s.imageConverter.SetEmbedSRGBProfile(s.config.EmbedSRGBProfile)
+	s.imageConverter.SetAlphaHandling(s.config.ImageBackground, services.AlphaFormat(s.config.AlphaOutputFormat))
+	s.imageConverter.SetUpscaling(s.config.ImageMaxUpscale, s.config.ImageUpscaler)
+	metadataPolicy, err := services.ParseMetadataPolicy(s.config.ImageMetadataPolicy)
+	if err != nil {
+		return fmt.Errorf("invalid IMAGE_METADATA_POLICY: %w", err)
+	}
+	s.imageConverter.SetMetadataPolicy(metadataPolicy)
+	postProcessors, err := services.ParsePostProcessors(s.config.PostProcessors)
+	if err != nil {
+		return fmt.Errorf("invalid POST_PROCESSORS: %w", err)
+	}
+	s.imageConverter.SetPostProcessors(postProcessors)
+
+	// Leaked images can be traced to the API key that converted them
+	if s.auditStamper = services.NewAuditStamper(s.config.AuditStampSecret); s.auditStamper != nil {
+		s.imageConverter.SetAuditStamper(s.auditStamper)
+		if s.config.AdminToken != "" {
+			s.auditStampAPI = handlers.NewAuditStampHandler(s.auditStamper, s.config.AdminToken)
+		} else {
+			log.Println("⚠️  ADMIN_TOKEN not set: /admin/audit-stamp is disabled, images are still stamped")
+		}
+	}
+	s.videoConverter = services.NewVideoConverter(s.workerPool, s.bufferPool, s.downloader, services.VideoLimits{
+		MaxWidth:      s.config.VideoMaxWidth,
+		MaxHeight:     s.config.VideoMaxHeight,
+		MaxBitrate:    s.config.VideoMaxBitrate,
+		AudioBitrate:  s.config.VideoAudioBitrate,
+		MaxOutputSize: s.config.VideoMaxOutputSize,
+		MaxInputSize:  s.config.MaxVideoSize,
+		TempDir:       s.config.VideoTempDir,
+
+		ScreencastMaxWidth:  s.config.VideoScreencastMaxWidth,
+		ScreencastMaxHeight: s.config.VideoScreencastMaxHeight,
+	})
+	s.inspector = services.NewMediaInspector(s.workerPool, s.bufferPool, s.downloader, s.config.MaxVideoSize, s.config.VideoTempDir)
+
+	// Split encodes between stable and candidate FFmpeg options
+	if err := s.audioConverter.SetEncoderVariants(services.EncoderVariants{
+		Stable:           strings.Fields(s.config.AudioEncoderArgs),
+		Candidate:        strings.Fields(s.config.AudioCandidateEncoderArgs),
+		CandidatePercent: s.config.AudioCandidatePercent,
+	}); err != nil {
+		return fmt.Errorf("invalid audio encoder variants: %w", err)
+	}
+	if err := s.videoConverter.SetEncoderVariants(services.EncoderVariants{
+		Stable:           strings.Fields(s.config.VideoEncoderArgs),
+		Candidate:        strings.Fields(s.config.VideoCandidateEncoderArgs),
+		CandidatePercent: s.config.VideoCandidatePercent,
+	}); err != nil {
+		return fmt.Errorf("invalid video encoder variants: %w", err)
+	}
+	if s.config.AudioCandidatePercent > 0 || s.config.VideoCandidatePercent > 0 {
+		log.Printf("Encoder candidates: audio %d%%, video %d%% of encodes", s.config.AudioCandidatePercent, s.config.VideoCandidatePercent)
+	}
+
+	// Named option sets clients select with the preset field
+	presets, err := services.LoadPresets(s.config.PresetsFile)
+	if err != nil {
+		return fmt.Errorf("failed to load presets: %w", err)
+	}
+	s.audioConverter.SetPresets(presets)
+	s.imageConverter.SetPresets(presets)
+	s.videoConverter.SetPresets(presets)
+	s.presets = presets
+
+	// Per-key tiers: worker priority, rate limit and size limits
+	tiers, err := services.LoadTiers(s.config.TiersFile, s.config.APIKeyTiers, s.config.DefaultTier)
+	if err != nil {
+		return fmt.Errorf("failed to load tiers: %w", err)
+	}
+	s.tiers = tiers
+
+	// Answer repeated conversions of the same input from cache
+	cache, err := services.NewConversionCache(services.ConversionCacheOptions{
+		MaxBytes:      s.config.ConversionCacheSize,
+		TTL:           s.config.ConversionCacheTTL,
+		MaxObjectSize: s.config.ConversionCacheMaxObjectSize,
+		RedisURL:      s.config.ConversionCacheRedisURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize the conversion cache: %w", err)
+	}
+	s.audioConverter.SetConversionCache(cache)
+	s.imageConverter.SetConversionCache(cache)
+	s.conversionCache = cache
+
+	// Keep large payloads in temporary files, within the tmpfs size when
+	// the temp directory is one
+	if s.config.SpillLargePayloads {
+		var budget int64
+		if s.config.UseTmpfs {
+			budget = s.config.TmpfsSizeBytes()
+		}
+		store, err := services.NewSpillStore(s.config.SpillDir, s.config.SpillThresholdBytes(), budget)
+		if err != nil {
+			return fmt.Errorf("failed to initialize payload spilling: %w", err)
+		}
+		s.spillStore = store
+		s.audioConverter.SetSpillStore(store)
+		s.videoConverter.SetSpillStore(store)
+		s.inspector.SetSpillStore(store)
+		log.Printf("Spilling payloads of %dMB and more to %s (budget: %d bytes, 0 = unlimited)",
+			s.config.SpillThresholdBytes()>>20, store.Dir(), budget)
+	}
+
+	// Delete temporary files crashed or killed requests left behind
+	if s.config.TempJanitorMaxAge > 0 {
+		if s.config.TempJanitorMaxAge < s.config.RequestTimeout {
+			log.Printf("⚠️  TEMP_JANITOR_MAX_AGE (%s) is shorter than REQUEST_TIMEOUT (%s): files of running conversions may be swept",
+				s.config.TempJanitorMaxAge, s.config.RequestTimeout)
+		}
+		dirs := []string{os.TempDir(), s.config.VideoTempDir}
+		if s.spillStore != nil {
+			dirs = append(dirs, s.spillStore.Dir())
+		}
+		janitor, err := services.NewTempJanitor(dirs, s.config.TempJanitorMaxAge, s.config.TempJanitorInterval)
+		if err != nil {
+			return fmt.Errorf("failed to start the temp janitor: %w", err)
+		}
+		s.tempJanitor = janitor
+	}
+
+	if s.config.MockMode {
+		log.Println("⚠️  MOCK_MODE enabled: conversions and uploads return canned responses")
+		s.audioConverter.SetMockMode(true)
+		s.imageConverter.SetMockMode(true)
+	}
+
+	if s.config.ChaosEnabled {
+		log.Println("⚠️  CHAOS_ENABLED: injecting faults into requests, conversions and uploads")
+		s.audioConverter.SetFaultInjection(s.config.ChaosFFmpegFailurePercent)
+		s.imageConverter.SetFaultInjection(s.config.ChaosFFmpegFailurePercent)
+		s.videoConverter.SetFaultInjection(s.config.ChaosFFmpegFailurePercent)
+	}
+
+	if s.config.RetainFailedSources {
+		store, err := services.NewSourceStore(s.config.SourceRetentionDir, s.config.SourceRetentionTTL, s.config.SourceRetentionKey)
+		if err != nil {
+			return fmt.Errorf("failed to initialize source retention: %w", err)
+		}
+		if s.config.SourceRetentionKey == "" {
+			log.Println("⚠️  SOURCE_RETENTION_KEY not set: retained sources use an ephemeral key and can't be replayed after a restart")
+		}
+		s.sourceStore = store
+		s.audioConverter.SetSourceStore(store)
+		s.imageConverter.SetSourceStore(store)
+
+		if s.config.SourceReplayToken != "" {
+			s.replayHandler = handlers.NewReplayHandler(store, s.audioConverter, s.imageConverter, s.config.RequestTimeout, s.config.SourceReplayToken)
+		} else {
+			log.Println("⚠️  SOURCE_REPLAY_TOKEN not set: /debug/replay is disabled")
+		}
+	}
+
+	if s.config.RequestRecording {
+		var maxBodySize int64
+		if s.config.RequestRecordingBodies {
+			maxBodySize = s.config.RequestRecordingMaxBodySize
+		}
+		recorder, err := services.NewRequestRecorder(s.config.RequestRecordingDir, s.config.RequestRecordingTTL, s.config.RequestRecordingKey, maxBodySize)
+		if err != nil {
+			return fmt.Errorf("failed to initialize request recording: %w", err)
+		}
+		if s.config.RequestRecordingKey == "" {
+			log.Println("⚠️  REQUEST_RECORDING_KEY not set: recordings use an ephemeral key and can't be replayed after a restart")
+		}
+		s.recorder = recorder
+
+		if s.config.AdminToken != "" {
+			// Replays run the whole middleware chain in-process, under the longest route deadline
+			timeout := max(s.config.RequestTimeout, s.config.AudioTimeout, s.config.ImageTimeout, s.config.BatchTimeout) + 5*time.Second
+			dispatch := func(req *http.Request, timeout time.Duration) (*http.Response, error) {
+				return s.app.Test(req, fiber.TestConfig{Timeout: timeout, FailOnTimeout: true})
+			}
+			s.recordings = handlers.NewRecordingHandler(recorder, dispatch, timeout, s.config.AdminToken)
+		} else {
+			log.Println("⚠️  ADMIN_TOKEN not set: /admin/recordings and /admin/replay are disabled")
+		}
+	}
+
+	if s.config.UsageTracking {
+		s.usage = services.NewUsageTracker()
+		s.usageHandler = handlers.NewUsageHandler(s.usage, s.config.AdminToken)
+	}
+
+	flags, err := features.New(features.Options{
+		Env:      s.config.FeatureFlags,
+		File:     s.config.FeatureFlagsFile,
+		RedisURL: s.config.FeatureFlagsRedisURL,
+		RedisKey: s.config.FeatureFlagsRedisKey,
+		Refresh:  s.config.FeatureFlagsRefresh,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize feature flags: %w", err)
+	}
+	s.features = flags
+
+	// Maintenance windows are toggled through the admin API; a file carries
+	// them across restarts and processes, and can be edited without it
+	if s.config.AdminToken != "" || s.config.MaintenanceFile != "" {
+		s.maintenance = services.NewMaintenance(s.config.MaintenanceFile, s.config.MaintenanceRefresh)
+		if s.config.AdminToken != "" {
+			s.maintenanceAPI = handlers.NewMaintenanceHandler(s.maintenance, s.config.AdminToken)
+		} else {
+			log.Println("⚠️  ADMIN_TOKEN not set: /admin/maintenance is disabled, edit MAINTENANCE_FILE instead")
+		}
+	}
 
 	// Initialize handler
-	s.handler = handlers.NewConverterHandler(s.audioConverter, s.imageConverter, s.config.RequestTimeout)
+	// gRPC only converts, so it stays down in read-only mode
+	if s.config.GRPCEnabled && !s.config.ReadOnly {
+		s.initializeGRPC()
+	} else if s.config.GRPCEnabled {
+		log.Println("⚠️  READ_ONLY set: gRPC is not started")
+	}
+
+	s.handler = handlers.NewConverterHandler(s.audioConverter, s.imageConverter, s.videoConverter, s.config.RequestTimeout, s.config.EnableCommandTrace)
+	s.handler.SetTempFiles(s.spillStore, s.tempJanitor)
+	s.handler.SetConversionCache(s.conversionCache)
+	s.handler.SetReadOnly(s.config.ReadOnly)
+	s.handler.SetWorkerPool(s.workerPool)
+	s.handler.SetDownloader(s.downloader)
+	s.handler.SetPostProcessors(postProcessors)
+	s.handler.SetRouteTimeouts(handlers.RouteTimeouts{
+		Audio: s.config.AudioTimeout,
+		Image: s.config.ImageTimeout,
+		Batch: s.config.BatchTimeout,
+	})
+	s.handler.SetBatchLimits(handlers.BatchLimits{
+		MaxSize:     s.config.BatchMaxSize,
+		ItemTimeout: s.config.BatchItemTimeout,
+		Concurrency: s.config.BatchConcurrency,
+
+		AsyncMaxSize:     s.config.BatchAsyncMaxSize,
+		AsyncConcurrency: s.config.BatchJobConcurrency(),
+	})
+	s.batchJobs = services.NewBatchJobManager(s.audioConverter, s.imageConverter, s.config.BatchJobMaxActive, s.config.BatchJobRetention)
+	s.handler.SetBatchJobs(s.batchJobs)
+	s.batchJobs.SetReports(services.BatchReportConfig{ErrorCode: handlers.BatchItemErrorCode})
+	s.handler.SetInspector(s.inspector)
+
+	// Temporary workspaces, committed to S3 when it is enabled
+	s.workspaces = services.NewWorkspaceManager(s.audioConverter, s.imageConverter, s.videoConverter, s.downloader, services.WorkspaceLimits{
+		TTL:          s.config.WorkspaceTTL,
+		MaxActive:    s.config.WorkspaceMaxActive,
+		MaxFiles:     s.config.WorkspaceMaxFiles,
+		MaxSize:      s.config.WorkspaceMaxSize,
+		Dir:          s.config.WorkspaceDir,
+		CommitPrefix: s.config.WorkspaceCommitPrefix,
+	})
+	s.workspaceAPI = handlers.NewWorkspaceHandler(s.workspaces, s.config.RequestTimeout)
 
 	// Initialize S3 services if enabled
 	if s.config.S3.Enabled {
@@ -93,22 +419,118 @@ func (s *Server) Initialize() error {
 		}
 		s.s3Service = s3Service
 
+		if s.config.ChaosEnabled {
+			s.s3Service.SetFaultInjection(providers.Faults{
+				FailurePercent:  s.config.ChaosS3FailurePercent,
+				TruncatePercent: s.config.ChaosS3TruncatePercent,
+			})
+		}
+
 		// Initialize upload manager
-		s.uploadManager = services.NewUploadManager(s.s3Service, s.config.S3.MaxConcurrentUploads)
+		s.uploadManager = services.NewUploadManager(s.s3Service, s.config.S3.MaxConcurrentUploads, s.config.S3.UploadWorkers)
+		s.uploadManager.SetTenantLimits(s.config.S3.TenantMaxUploads, s.config.S3.TenantUploadLimits)
+		s.uploadManager.SetStatusTTL(s.config.S3.UploadStatusTTL)
+		s.uploadManager.SetDownloader(s.downloader)
 
 		// Initialize S3 handler
-		s.s3Handler = handlers.NewS3Handler(s.s3Service, s.uploadManager)
+		s.s3Handler = handlers.NewS3Handler(s.s3Service, s.uploadManager, s.config.AdminToken)
+		s.handler.SetS3Service(s.s3Service)
+
+		// Conversions may read their input from the bucket
+		s.audioConverter.SetObjectStore(s.s3Service)
+		s.imageConverter.SetObjectStore(s.s3Service)
+		s.videoConverter.SetObjectStore(s.s3Service)
+
+		// Reports of finished batch jobs, linked for as long as the job is kept
+		s.batchJobs.SetReports(services.BatchReportConfig{
+			Store:     s.s3Service,
+			Prefix:    s.config.BatchReportPrefix,
+			MinItems:  s.config.BatchReportMinItems,
+			LinkTTL:   min(s.config.BatchJobRetention, s.config.S3.ShareMaxTTL),
+			ErrorCode: handlers.BatchItemErrorCode,
+		})
+		s.workspaces.SetStore(s.s3Service)
+
+		// Bulk copies to and from the S3_MIGRATION_PROVIDERS buckets
+		if len(s.config.S3.MigrationProviders) > 0 {
+			if s.config.AdminToken != "" {
+				s.migrations = services.NewMigrationManager(s.s3Service, s.audioConverter, s.imageConverter)
+				s.migrationAPI = handlers.NewMigrationHandler(s.migrations, s.config.AdminToken)
+			} else {
+				log.Println("⚠️  ADMIN_TOKEN not set: /admin/migrations is disabled, S3_MIGRATION_PROVIDERS is unused")
+			}
+		}
+
+		// Convert-on-read for stored originals
+		s.mediaHandler = handlers.NewMediaHandler(
+			s.s3Service, s.audioConverter, s.imageConverter,
+			services.NewMediaCache(s.config.MediaCacheSize, s.config.MediaCacheTTL),
+			s.config.RequestTimeout, s.config.MediaMaxSourceSize, s.config.MediaMaxAge,
+		)
+	}
+
+	// Operational alerts (only when a channel is configured)
+	notifier, err := notify.New(notify.Config{
+		SlackWebhookURL: s.config.NotifySlackWebhookURL,
+		TelegramToken:   s.config.NotifyTelegramToken,
+		TelegramChatID:  s.config.NotifyTelegramChatID,
+		TelegramAPIURL:  s.config.NotifyTelegramAPIURL,
+		WebhookURL:      s.config.NotifyWebhookURL,
+		Cooldown:        s.config.NotifyCooldown,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to configure notifications: %w", err)
+	}
+	if notifier != nil {
+		s.notifier = notifier
+		s.alerts = &alertWatcher{
+			notifier:      notifier,
+			interval:      s.config.NotifyCheckInterval,
+			errorRate:     s.config.NotifyErrorRate,
+			minRequests:   int64(s.config.NotifyMinRequests),
+			queueWaiting:  int32(s.config.NotifyQueueWaiting),
+			workerPool:    s.workerPool,
+			s3Service:     s.s3Service,
+			uploadManager: s.uploadManager,
+		}
+	}
+
+	// Export request spans over OTLP; spans are no-ops when disabled
+	stopTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     s.config.OTelEnabled,
+		ServiceName: s.config.OTelServiceName,
+		Version:     readAPIVersion(),
+		SampleRatio: s.config.OTelSampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to configure tracing: %w", err)
 	}
+	s.stopTracing = stopTracing
 
-	// Initialize web handler
-	webHandler, err := handlers.NewWebHandler()
+	// Sign conversion responses for downstream verification
+	signer, err := newResponseSigner(s.config.ResponseSigningAlgorithm, s.config.ResponseSigningKey, s.config.ResponseSigningKeyID)
 	if err != nil {
-		return fmt.Errorf("failed to initialize web handler: %w", err)
+		return fmt.Errorf("failed to configure response signing: %w", err)
 	}
-	s.webHandler = webHandler
+	s.signer = signer
+
+	// Initialize web handler unless only the JSON API is exposed
+	if s.config.EnableWebUI {
+		webHandler, err := handlers.NewWebHandler(s.config.WebUIPrefix)
+		if err != nil {
+			return fmt.Errorf("failed to initialize web handler: %w", err)
+		}
+		s.webHandler = webHandler
+	}
+
+	// Conversion output and rendition cache usage
+	s.cacheHandler = handlers.NewCacheHandler(s.conversionCache, s.mediaHandler)
 
 	// Initialize metadata handler with API version
-	s.metaHandler = handlers.NewMetaHandler(readAPIVersion(), s.s3Handler != nil)
+	s.metaHandler = handlers.NewMetaHandler(readAPIVersion(), apiVersionPrefixes(), s.s3Handler != nil, s.config.MockMode, s.features)
+	s.metaHandler.SetMetadata(s.apiMetadata())
+	s.metaHandler.SetPresets(s.presets)
+	s.metaHandler.SetTiers(s.tiers)
 
 	// Initialize Fiber app with v3 config
 	s.app = fiber.New(fiber.Config{
@@ -119,10 +541,11 @@ func (s *Server) Initialize() error {
 		BodyLimit:        s.config.BodyLimit,
 		ReadTimeout:      s.config.ReadTimeout,
 		WriteTimeout:     s.config.WriteTimeout,
-		IdleTimeout:      s.config.WriteTimeout,
+		IdleTimeout:      s.config.IdleTimeout,
 		ReadBufferSize:   16 * 1024, // 16KB
 		WriteBufferSize:  16 * 1024, // 16KB
-		DisableKeepalive: false,
+		DisableKeepalive: s.config.DisableKeepalive,
+		Concurrency:      s.config.MaxConnections,
 		ErrorHandler: func(c fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			message := "Internal Server Error"
@@ -132,6 +555,12 @@ func (s *Server) Initialize() error {
 				message = e.Message
 			}
 
+			c.Vary(fiber.HeaderAcceptLanguage)
+			if language := i18n.Language(c.Get(fiber.HeaderAcceptLanguage)); language != i18n.Default {
+				message = i18n.Translate(language, message)
+				c.Set(fiber.HeaderContentLanguage, language)
+			}
+
 			return c.Status(code).JSON(fiber.Map{
 				"error":     message,
 				"timestamp": time.Now().Unix(),
@@ -139,6 +568,12 @@ func (s *Server) Initialize() error {
 		},
 	})
 
+	// Track the listener processes to drain them on shutdown
+	if s.config.Prefork && !fiber.IsChild() {
+		s.prefork = newPreforkChildren()
+		s.app.Hooks().OnFork(s.prefork.add)
+	}
+
 	// Setup middleware
 	s.setupMiddleware()
 
@@ -158,6 +593,26 @@ func (s *Server) setupMiddleware() {
 		},
 	}))
 
+	// Point clients at the HTTP/3 listener
+	if s.config.HTTP3Enabled {
+		s.app.Use(altSvcMiddleware(s.config.HTTP3ListenPort()))
+	}
+
+	// Response tally for the error-rate alert
+	if s.alerts != nil {
+		s.app.Use(s.alerts.countResponses)
+	}
+
+	// Server span per request, continuing the caller's trace
+	if s.config.OTelEnabled {
+		s.app.Use(tracingMiddleware())
+	}
+
+	// Stage breakdown of conversions slower than SLOW_REQUEST_THRESHOLD
+	if s.config.SlowRequestThreshold > 0 {
+		s.app.Use(slowRequestMiddleware(s.config.SlowRequestThreshold))
+	}
+
 	// Logger middleware (minimal for performance)
 	s.app.Use(logger.New(logger.Config{
 		Format:     "${time} | ${status} | ${latency} | ${method} ${path}\n",
@@ -168,55 +623,244 @@ func (s *Server) setupMiddleware() {
 	s.app.Use(cors.New(cors.Config{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "X-Request-ID"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Accept", "X-Request-ID", apiVersionHeader, "Accept-Version", "X-Debug-Trace", "X-Debug-Timings", "X-Replay-Token", "X-Admin-Token", "traceparent", "tracestate", features.APIKeyHeader},
 		MaxAge:       86400,
 	}))
 
 	// Recover middleware
 	s.app.Use(recover.New())
+
+	// Error messages in the client's language
+	s.app.Use(localizeErrorsMiddleware())
+
+	// API version negotiation
+	s.app.Use(apiVersionMiddleware())
+
+	// Queue depth and load on conversion responses, so clients can throttle
+	s.app.Use(backPressureMiddleware(s.workerPool, func() *memoryMonitor { return s.memoryMonitor }))
+
+	// Endpoints disabled by operators during an incident
+	if s.maintenance != nil {
+		s.app.Use(maintenanceMiddleware(s.maintenance))
+	}
+
+	// Only reads while the processing tier is restored
+	if s.config.ReadOnly {
+		s.app.Use(readOnlyMiddleware())
+	}
+
+	// Detached signatures over the final conversion responses
+	if s.signer != nil {
+		s.app.Use(s.signer.middleware())
+	}
+
+	// Flag canned responses so integrators never mistake them for real output
+	if s.config.MockMode {
+		s.app.Use(func(c fiber.Ctx) error {
+			c.Set("X-Mock-Mode", "true")
+			return c.Next()
+		})
+	}
+
+	// Conversions queue for workers as the caller's API key
+	s.app.Use(tenantMiddleware)
+
+	// Priority, rate and size limits of the caller's tier
+	if s.tiers != nil {
+		s.app.Use(tierMiddleware(s.tiers))
+	}
+
+	// Converted images are stamped with the caller's API key and request ID
+	if s.auditStamper != nil {
+		s.app.Use(auditOwnerMiddleware)
+	}
+
+	// Shed large requests before the process hits its memory limit
+	if s.memoryMonitor = newMemoryMonitor(s.config.MemoryHighWaterPercent); s.memoryMonitor != nil {
+		s.app.Use(admissionMiddleware(s.memoryMonitor, s.config.AdmissionMinBodySize))
+	}
+
+	// Fault injection for client resilience testing
+	if s.config.ChaosEnabled {
+		s.app.Use(chaosMiddleware(s.config))
+	}
+
+	// Answer repeated conversions from cache for callers with the cache flag
+	if s.conversionCache != nil {
+		s.app.Use(s.cacheConversions)
+	}
+
+	// Record failed conversions and uploads for replay
+	if s.recorder != nil {
+		s.app.Use(recordingMiddleware(s.recorder, s.config.RequestRecordingMinStatus))
+	}
+
+	// Cancel the work of clients that hang up; last, so the logs, alerts
+	// and recordings above see the 499
+	if s.config.CancelOnDisconnect {
+		s.app.Use(disconnectMiddleware())
+	}
 }
 
 // setupRoutes configures all API routes
 func (s *Server) setupRoutes() {
 
-	// Web interface routes
-	s.webHandler.RegisterWebRoutes(s.app)
+	// Web interface routes, on the API port unless WEB_UI_PORT moves them
+	if s.webHandler != nil {
+		if s.config.WebUIPort != "" && s.config.WebUIPort != s.config.Port {
+			s.webApp = s.newWebUIApp()
+		} else {
+			s.webHandler.RegisterWebRoutes(s.app)
+		}
+	}
+
+	// Legacy unversioned routes are kept as aliases of the current version
+	s.registerAPIRoutes(s.app)
+	for _, prefix := range apiVersionPrefixes() {
+		s.registerAPIRoutes(s.app.Group(prefix))
+	}
+
+	if s.config.EnableSwagger {
+		s.registerSwaggerRoutes()
+	}
+
+	// 404 handler
+	s.app.Use(endpointNotFound)
+}
+
+// endpointNotFound answers requests no route matched
+func endpointNotFound(c fiber.Ctx) error {
+	return c.Status(404).JSON(fiber.Map{
+		"error": "Endpoint not found",
+		"path":  c.Path(),
+	})
+}
 
+// registerAPIRoutes registers the JSON API surface on the given router
+func (s *Server) registerAPIRoutes(router fiber.Router) {
 	if s.metaHandler != nil {
-		s.app.Get("/api", s.metaHandler.APIInfo)
+		router.Get("/api", s.metaHandler.APIInfo)
+		router.Get("/capabilities", s.metaHandler.Capabilities)
+		router.Get("/version", s.metaHandler.Version)
+		router.Get("/samples", s.metaHandler.Samples)
+		router.Get("/samples/:type", s.metaHandler.Sample)
+		router.Get("/presets", s.metaHandler.Presets)
+	}
+
+	// Public key for verifying signed responses
+	if s.signer != nil && s.signer.algorithm == signingEd25519 {
+		router.Get("/signing-key", s.signer.signingKey)
 	}
 
 	// Health check
-	s.app.Get("/health", s.handler.Health)
-	s.app.Get("/stats", s.handler.Stats)
+	router.Get("/health", s.handler.Health)
+	router.Get("/stats", s.handler.Stats)
+	router.Get("/cache/stats", s.cacheHandler.Stats)
+
+	// Per-key conversions and CPU time (if enabled)
+	if s.usageHandler != nil {
+		router.Get("/usage", s.usageHandler.Usage)
+	}
 
 	// Single conversion endpoints
-	s.app.Post("/convert/audio", s.handler.ConvertAudio)
-	s.app.Post("/convert/image", s.handler.ConvertImage)
+	router.Post("/convert/audio", s.trackUsage, s.handler.ConvertAudio)
+	router.Post("/convert/image", s.trackUsage, s.handler.ConvertImage)
+	router.Post("/convert/sticker", s.trackUsage, s.handler.ConvertSticker)
+	router.Post("/convert/video", s.requireFeature(features.Video), s.trackUsage, s.handler.ConvertVideo)
+
+	// Metadata of an input, without converting it
+	router.Post("/inspect", s.handler.Inspect)
+	router.Post("/inspect/fingerprint", s.handler.Fingerprint)
 
 	// Batch conversion endpoints
-	s.app.Post("/convert/batch/audio", s.handler.ConvertBatchAudio)
-	s.app.Post("/convert/batch/image", s.handler.ConvertBatchImage)
+	router.Post("/convert/batch/audio", s.trackUsage, s.handler.ConvertBatchAudio)
+	router.Post("/convert/batch/image", s.trackUsage, s.handler.ConvertBatchImage)
+	router.Post("/convert/sticker-pack", s.trackUsage, s.handler.ConvertStickerPack)
+
+	// Asynchronous batch jobs
+	router.Post("/convert/batch/audio/async", s.trackUsage, s.handler.ConvertBatchAudioAsync)
+	router.Post("/convert/batch/image/async", s.trackUsage, s.handler.ConvertBatchImageAsync)
+	router.Get("/convert/batch/jobs/:id", s.handler.GetBatchJob)
+	router.Get("/convert/batch/jobs/:id/items/:index", s.handler.GetBatchJobItem)
+	router.Get("/convert/batch/jobs/:id/report", s.handler.GetBatchJobReport)
+	router.Get("/convert/batch/jobs/:id/archive", s.handler.GetBatchJobArchive)
+	router.Delete("/convert/batch/jobs/:id", s.handler.DeleteBatchJob)
+
+	// Temporary workspaces
+	router.Post("/workspaces", s.workspaceAPI.CreateWorkspace)
+	router.Get("/workspaces/:id", s.workspaceAPI.GetWorkspace)
+	router.Delete("/workspaces/:id", s.workspaceAPI.DeleteWorkspace)
+	router.Post("/workspaces/:id/files", s.workspaceAPI.AddWorkspaceFile)
+	router.Get("/workspaces/:id/files/:name", s.workspaceAPI.GetWorkspaceFile)
+	router.Delete("/workspaces/:id/files/:name", s.workspaceAPI.DeleteWorkspaceFile)
+	router.Post("/workspaces/:id/convert", s.trackUsage, s.workspaceAPI.ConvertWorkspaceFile)
+	router.Post("/workspaces/:id/compose", s.requireFeature(features.Video), s.trackUsage, s.workspaceAPI.ComposeWorkspaceVideo)
+	router.Post("/workspaces/:id/commit", s.workspaceAPI.CommitWorkspace)
+
+	// Replay of retained failed conversions (if enabled)
+	if s.replayHandler != nil {
+		router.Post("/debug/replay/:id", s.replayHandler.Replay)
+	}
+
+	// Recorded failed requests (if enabled)
+	if s.recordings != nil {
+		router.Get("/admin/recordings", s.recordings.ListRecordings)
+		router.Get("/admin/recordings/:id", s.recordings.GetRecording)
+		router.Post("/admin/replay/:id", s.recordings.Replay)
+	}
+
+	// Maintenance windows (if enabled)
+	if s.maintenanceAPI != nil {
+		router.Get("/admin/maintenance", s.maintenanceAPI.ListMaintenance)
+		router.Put("/admin/maintenance/*", s.maintenanceAPI.EnableMaintenance)
+		router.Delete("/admin/maintenance/*", s.maintenanceAPI.DisableMaintenance)
+	}
+
+	// Provider migrations (if enabled)
+	if s.migrationAPI != nil {
+		router.Get("/admin/migrations", s.migrationAPI.ListMigrations)
+		router.Post("/admin/migrations", s.migrationAPI.StartMigration)
+		router.Get("/admin/migrations/:id", s.migrationAPI.GetMigration)
+		router.Delete("/admin/migrations/:id", s.migrationAPI.CancelMigration)
+	}
+
+	// Audit stamps of leaked images (if enabled)
+	if s.auditStampAPI != nil {
+		router.Post("/admin/audit-stamp", s.auditStampAPI.ReadAuditStamp)
+	}
 
 	// S3 upload endpoints (if enabled)
 	if s.s3Handler != nil {
-		s.s3Handler.RegisterS3Routes(s.app)
+		s.s3Handler.RegisterS3Routes(router)
+
+		// Convert-and-upload, streaming the output into the bucket
+		router.Post("/convert/audio/s3", s.trackUsage, s.handler.ConvertAudioToS3)
+		router.Post("/convert/video/s3", s.requireFeature(features.Video), s.trackUsage, s.handler.ConvertVideoToS3)
 	}
 
-	if s.config.EnableSwagger {
-		s.registerSwaggerRoutes()
+	// Convert-on-read of stored originals (requires S3)
+	if s.mediaHandler != nil {
+		router.Get("/media/*", s.trackUsage, s.mediaHandler.GetMedia)
 	}
+}
 
-	// 404 handler
-	s.app.Use(func(c fiber.Ctx) error {
-		return c.Status(404).JSON(fiber.Map{
-			"error": "Endpoint not found",
-			"path":  c.Path(),
-		})
-	})
+// apiMetadata brands /api and the Swagger page from the API_* settings
+func (s *Server) apiMetadata() handlers.APIMetadata {
+	return handlers.APIMetadata{
+		Title:        s.config.APITitle,
+		Language:     s.config.APILanguage,
+		Descriptions: s.config.APIDescriptions,
+		ContactName:  s.config.APIContactName,
+		ContactEmail: s.config.APIContactEmail,
+		ContactURL:   s.config.APIContactURL,
+	}
 }
 
 func (s *Server) registerSwaggerRoutes() {
+	metadata := s.apiMetadata()
+	docs.SwaggerInfo.Title = metadata.Title
+	docs.SwaggerInfo.Description = metadata.SwaggerDescription()
+
 	swaggerFiles.Handler.Prefix = "/swagger"
 	s.app.Get("/swagger", func(c fiber.Ctx) error {
 		return c.Redirect().Status(fiber.StatusTemporaryRedirect).To("/swagger/index.html")
@@ -259,17 +903,44 @@ func (s *Server) registerSwaggerRoutes() {
 
 // Start starts the server
 func (s *Server) Start() error {
+	// Prefork children only serve PORT; the parent runs everything else
+	child := fiber.IsChild()
+
 	// Print startup information
-	s.printStartupInfo()
+	if !child {
+		s.printStartupInfo()
+	}
 
 	// Create shutdown channel
 	shutdownCh := make(chan os.Signal, 1)
 	signal.Notify(shutdownCh, syscall.SIGINT, syscall.SIGTERM)
 
+	if s.grpcServer != nil && !child {
+		if err := s.startGRPC(); err != nil {
+			return err
+		}
+	}
+
+	if s.webApp != nil && !child {
+		s.startWebUI()
+	}
+
+	if s.config.HTTP3Enabled && !child {
+		s.startHTTP3()
+	}
+
+	if s.alerts != nil && !child {
+		s.alerts.start()
+		log.Printf("🔔 Alerts via %s", strings.Join(s.notifier.Channels(), ", "))
+	}
+	if s.config.WarmupOnStart {
+		go s.warmup()
+	}
+
 	// Start server in goroutine
 	go func() {
 		addr := fmt.Sprintf(":%s", s.config.Port)
-		if err := s.app.Listen(addr); err != nil {
+		if err := s.app.Listen(addr, s.listenConfig()); err != nil {
 			log.Printf("Server error: %v", err)
 		}
 	}()
@@ -278,7 +949,11 @@ func (s *Server) Start() error {
 	<-shutdownCh
 
 	log.Println("Shutting down server...")
-	return s.Shutdown()
+	err := s.Shutdown()
+	if child {
+		waitForPreforkParent()
+	}
+	return err
 }
 
 // Shutdown gracefully shuts down the server
@@ -286,23 +961,120 @@ func (s *Server) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Stop the console first; it forwards to the API listener
+	if s.webApp != nil {
+		if err := s.webApp.ShutdownWithContext(ctx); err != nil {
+			log.Printf("Error shutting down web UI: %v", err)
+		}
+	}
+
+	// Drain the listener processes; the parent itself serves no TCP
+	if s.prefork != nil {
+		s.prefork.stop(ctx)
+	}
+
 	// Shutdown Fiber app
 	if err := s.app.ShutdownWithContext(ctx); err != nil {
 		log.Printf("Error shutting down server: %v", err)
 	}
 
+	// Stop HTTP/3 and gRPC before the workers their requests run on
+	if s.http3Server != nil {
+		s.stopHTTP3(ctx)
+	}
+	if s.grpcServer != nil {
+		s.stopGRPC(ctx)
+	}
+
+	// Cancel batch jobs before their items lose the workers
+	if s.batchJobs != nil {
+		s.batchJobs.Stop()
+		log.Println("Batch jobs stopped")
+	}
+
+	// Discard workspaces; their files don't survive a restart
+	if s.workspaces != nil {
+		s.workspaces.Stop()
+		log.Println("Workspaces discarded")
+	}
+
+	// Cancel the running migration before its transcodes lose the workers
+	if s.migrations != nil {
+		s.migrations.Stop()
+		log.Println("Migrations stopped")
+	}
+
 	// Stop worker pool
 	if s.workerPool != nil {
 		s.workerPool.Stop()
 		log.Println("Worker pool stopped")
 	}
 
+	// Stop upload workers after cancelling in-flight uploads
+	if s.uploadManager != nil {
+		s.uploadManager.Stop()
+		log.Println("Upload workers stopped")
+	}
+
+	// Stop S3 health checks once no upload needs the provider
+	if s.s3Service != nil {
+		s.s3Service.Close()
+	}
+
+	// Stop alert checks, then deliver what they raised
+	if s.alerts != nil {
+		s.alerts.Stop()
+	}
+	if s.notifier != nil {
+		s.notifier.Close()
+	}
+
+	// Stop retained source expiry
+	if s.sourceStore != nil {
+		s.sourceStore.Close()
+	}
+
+	// Stop recorded request expiry
+	if s.recorder != nil {
+		s.recorder.Close()
+	}
+
+	// Stop temporary file sweeps
+	if s.tempJanitor != nil {
+		s.tempJanitor.Close()
+	}
+
+	// Stop memory sampling
+	if s.memoryMonitor != nil {
+		s.memoryMonitor.Stop()
+	}
+
+	// Stop feature flag refresh
+	if s.features != nil {
+		s.features.Close()
+	}
+
+	// Close the conversion cache's Redis connection
+	s.conversionCache.Close()
+
+	// Stop maintenance file refresh
+	if s.maintenance != nil {
+		s.maintenance.Close()
+	}
+
 	// Close downloader
 	if s.downloader != nil {
 		s.downloader.Close()
 		log.Println("Downloader closed")
 	}
 
+	// Flush pending spans
+	if s.stopTracing != nil {
+		if err := s.stopTracing(ctx); err != nil {
+			log.Printf("Error flushing traces: %v", err)
+		}
+	}
+
 	log.Println("Server shutdown complete")
 	return nil
 }
@@ -310,10 +1082,35 @@ func (s *Server) Shutdown() error {
 // printStartupInfo prints server configuration
 func (s *Server) printStartupInfo() {
 	log.Println("========================================")
-	log.Println("WhatsApp Media Converter API")
+	log.Println(s.config.APITitle)
 	log.Println("========================================")
+	log.Printf("Version:        %s", readAPIVersion())
+	if build := buildinfo.Get(); build.Commit != "" {
+		log.Printf("Commit:         %s (built %s)", build.Commit, build.BuildDate)
+	}
 	log.Printf("Port:           %s", s.config.Port)
+	if s.config.TLSCertFile != "" {
+		log.Printf("TLS:            %s", s.config.TLSCertFile)
+	}
+	if s.config.HTTP3Enabled {
+		log.Printf("HTTP/3 Port:    %s/udp (%d streams per connection)", s.config.HTTP3ListenPort(), s.config.HTTP3MaxStreams)
+	}
+	if s.prefork != nil {
+		log.Printf("Prefork:        %d listener processes", runtime.GOMAXPROCS(0))
+	}
+	if s.config.DisableKeepalive {
+		log.Printf("Keep-Alive:     disabled")
+	} else {
+		log.Printf("Keep-Alive:     %s idle", s.config.IdleTimeout)
+	}
+	if s.grpcServer != nil {
+		log.Printf("gRPC Port:      %s", s.config.GRPCPort)
+	}
 	log.Printf("Workers:        %d", s.config.MaxWorkers)
+	if reserved, maxSize := s.workerPool.PriorityLane(); reserved > 0 {
+		log.Printf("Priority Lane:  %d workers for inputs <= %dKB", reserved, maxSize/1024)
+	}
+	log.Printf("Encoder Threads: %d per process", services.EncoderThreads())
 	log.Printf("Buffer Pool:    %d x %dMB", s.config.BufferPoolSize, s.config.BufferSize/1024/1024)
 	log.Printf("Request Timeout: %s", s.config.RequestTimeout)
 	log.Printf("Body Limit:     %dMB", s.config.BodyLimit/1024/1024)
@@ -322,18 +1119,64 @@ func (s *Server) printStartupInfo() {
 	log.Printf("GOGC:           %d", s.config.GOGC)
 	log.Printf("Memory Limit:   %s", s.config.GoMemLimit)
 	log.Printf("Swagger:        %t", s.config.EnableSwagger)
+	switch {
+	case s.webHandler == nil:
+		log.Printf("Web UI:         disabled")
+	case s.webApp != nil:
+		log.Printf("Web UI:         port %s at %s/ (API requests forwarded)", s.config.WebUIPort, s.webHandler.Prefix())
+	default:
+		log.Printf("Web UI:         %s/", s.webHandler.Prefix())
+	}
+	log.Printf("Mock Mode:      %t", s.config.MockMode)
+	log.Printf("Chaos Mode:     %t", s.config.ChaosEnabled)
+	if s.signer != nil {
+		log.Printf("Signing:        %s (key %s)", s.signer.algorithm, s.signer.keyID)
+	}
+	if s.config.OTelEnabled {
+		log.Printf("Tracing:        OTLP as %s (sample ratio %.2f)", s.config.OTelServiceName, s.config.OTelSampleRatio)
+	}
+	log.Printf("Feature Flags:  %s", strings.Join(s.features.Sources(), ", "))
+	if presets := s.presets.List(); len(presets) > 0 {
+		names := make([]string, len(presets))
+		for i, preset := range presets {
+			names[i] = preset.Name
+		}
+		log.Printf("Presets:        %s", strings.Join(names, ", "))
+	}
+	if s.tiers != nil {
+		log.Printf("Tiers:          %s", s.tiers.Summary())
+	}
+	if windows := s.maintenance.List(); len(windows) > 0 {
+		endpoints := make([]string, len(windows))
+		for i, window := range windows {
+			endpoints[i] = window.Endpoint
+		}
+		log.Printf("Maintenance:    %s", strings.Join(endpoints, ", "))
+	}
+	if s.config.ReadOnly {
+		log.Printf("Read-only:      conversions and uploads answer 503")
+	}
 	log.Println("========================================")
 	log.Printf("Ready to handle 1000+ requests/second!")
 	log.Println("========================================")
-	log.Printf("Author:         Guilherme Jansen")
-	log.Printf("Email:          suporte@setupautomatizado.com.br")
-	log.Println("========================================")
-	log.Printf("GitHub:         https://github.com/guilhermejansen")
+	if s.config.APIContactName != "" {
+		log.Printf("Contact:        %s", s.config.APIContactName)
+	}
+	if s.config.APIContactEmail != "" {
+		log.Printf("Email:          %s", s.config.APIContactEmail)
+	}
+	if s.config.APIContactURL != "" {
+		log.Printf("URL:            %s", s.config.APIContactURL)
+	}
 	log.Println("========================================")
 }
 
 func readAPIVersion() string {
 	const fallbackVersion = "1.0.0"
+	if buildinfo.Version != "" {
+		return buildinfo.Version
+	}
+
 	data, err := os.ReadFile("VERSION")
 	if err != nil {
 		return fallbackVersion
@@ -352,7 +1195,7 @@ func (s *Server) GetStats() map[string]interface{} {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"worker_pool": s.workerPool.Stats(),
 		"buffer_pool": s.bufferPool.Stats(),
 		"memory": map[string]interface{}{
@@ -363,4 +1206,12 @@ func (s *Server) GetStats() map[string]interface{} {
 		},
 		"goroutines": runtime.NumGoroutine(),
 	}
+	if s.memoryMonitor != nil {
+		stats["admission"] = s.memoryMonitor.Stats()
+	}
+	if s.mediaHandler != nil {
+		stats["media_cache"] = s.mediaHandler.CacheStats()
+	}
+
+	return stats
 }
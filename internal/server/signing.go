@@ -0,0 +1,162 @@
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+
+	"whats-convert-api/internal/providers"
+)
+
+// Response signing algorithms
+const (
+	signingHMAC    = "hmac-sha256"
+	signingEd25519 = "ed25519"
+)
+
+// signingVersion opens the signed payload so its layout can change later
+const signingVersion = "whats-convert-signature-v1"
+
+// Response headers carrying the detached signature
+const (
+	headerContentSHA256      = "X-Content-SHA256"
+	headerSignature          = "X-Signature"
+	headerSignatureAlgorithm = "X-Signature-Algorithm"
+	headerSignatureKeyID     = "X-Signature-Key-Id"
+	headerSignatureTimestamp = "X-Signature-Timestamp"
+)
+
+// responseSigner adds a detached signature to successful conversion
+// responses so downstream services can check the media came from this
+// converter unmodified
+type responseSigner struct {
+	algorithm  string
+	keyID      string
+	hmacKey    []byte
+	privateKey ed25519.PrivateKey
+}
+
+// newResponseSigner returns nil when algorithm is empty. HMAC keys are used as
+// given; Ed25519 keys are base64 32-byte seeds or 64-byte private keys.
+func newResponseSigner(algorithm, key, keyID string) (*responseSigner, error) {
+	algorithm = strings.ToLower(strings.TrimSpace(algorithm))
+	if algorithm == "" || algorithm == "off" {
+		return nil, nil
+	}
+	if key == "" {
+		return nil, fmt.Errorf("RESPONSE_SIGNING_KEY is required for %s signing", algorithm)
+	}
+	if keyID == "" {
+		keyID = "default"
+	}
+
+	signer := &responseSigner{algorithm: algorithm, keyID: keyID}
+	switch algorithm {
+	case signingHMAC:
+		signer.hmacKey = []byte(key)
+	case signingEd25519:
+		raw, err := providers.DecodeBase64(key)
+		if err != nil {
+			return nil, fmt.Errorf("RESPONSE_SIGNING_KEY is not base64: %w", err)
+		}
+		switch len(raw) {
+		case ed25519.SeedSize:
+			signer.privateKey = ed25519.NewKeyFromSeed(raw)
+		case ed25519.PrivateKeySize:
+			signer.privateKey = ed25519.PrivateKey(raw)
+		default:
+			return nil, fmt.Errorf("RESPONSE_SIGNING_KEY must decode to %d or %d bytes, got %d",
+				ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+		}
+	default:
+		return nil, fmt.Errorf("unknown RESPONSE_SIGNING_ALGORITHM %q (use %s or %s)", algorithm, signingHMAC, signingEd25519)
+	}
+
+	return signer, nil
+}
+
+// middleware signs 2xx responses of the /convert routes after they're built.
+// Streamed bodies (multipart responses with spilled outputs) are never read
+// here, which would buffer them whole: their handler sends the body's hash
+// as X-Content-SHA256 and that hash is signed. A stream without one is left
+// unsigned.
+func (rs *responseSigner) middleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		path := c.Path()
+		if version := versionFromPath(path); version != "" {
+			path = strings.TrimPrefix(path, "/v"+version)
+		}
+		status := c.Response().StatusCode()
+		if !strings.HasPrefix(path, "/convert/") || status < 200 || status > 299 {
+			return nil
+		}
+
+		var bodyHash string
+		if c.Response().IsBodyStream() {
+			bodyHash = string(c.Response().Header.Peek(headerContentSHA256))
+			if bodyHash == "" {
+				return nil
+			}
+		} else {
+			digest := sha256.Sum256(c.Response().Body())
+			bodyHash = hex.EncodeToString(digest[:])
+		}
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		payload := signingPayload(timestamp, requestid.FromContext(c), c.Method(), c.Path(), status,
+			string(c.Response().Header.ContentType()), bodyHash)
+
+		c.Set(headerContentSHA256, bodyHash)
+		c.Set(headerSignature, rs.sign(payload))
+		c.Set(headerSignatureAlgorithm, rs.algorithm)
+		c.Set(headerSignatureKeyID, rs.keyID)
+		c.Set(headerSignatureTimestamp, timestamp)
+		return nil
+	}
+}
+
+// sign returns the base64 signature of payload
+func (rs *responseSigner) sign(payload []byte) string {
+	if rs.algorithm == signingEd25519 {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(rs.privateKey, payload))
+	}
+	mac := hmac.New(sha256.New, rs.hmacKey)
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signingPayload is the newline-joined text verifiers rebuild from the
+// response headers and body
+func signingPayload(timestamp, requestID, method, path string, status int, contentType, bodyHash string) []byte {
+	return []byte(strings.Join([]string{
+		signingVersion,
+		timestamp,
+		requestID,
+		method + " " + path,
+		strconv.Itoa(status),
+		contentType,
+		bodyHash,
+	}, "\n"))
+}
+
+// signingKey serves the Ed25519 public key verifiers need
+func (rs *responseSigner) signingKey(c fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"algorithm":  rs.algorithm,
+		"key_id":     rs.keyID,
+		"public_key": base64.StdEncoding.EncodeToString(rs.privateKey.Public().(ed25519.PublicKey)),
+	})
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestSignerStreamedBody(t *testing.T) {
+	signer, err := newResponseSigner(signingHMAC, "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := bytes.Repeat([]byte("spilled"), 1024)
+	digest := sha256.Sum256(payload)
+	hash := hex.EncodeToString(digest[:])
+
+	app := fiber.New()
+	app.Use(signer.middleware())
+	app.Get("/convert/hashed", func(c fiber.Ctx) error {
+		c.Set(headerContentSHA256, hash)
+		return c.SendStream(bytes.NewReader(payload), len(payload))
+	})
+	app.Get("/convert/unhashed", func(c fiber.Ctx) error {
+		return c.SendStream(bytes.NewReader(payload), len(payload))
+	})
+	app.Get("/convert/buffered", func(c fiber.Ctx) error {
+		return c.Send(payload)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/convert/hashed", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get(headerSignature) == "" || resp.Header.Get(headerContentSHA256) != hash {
+		t.Errorf("hashed stream: signature %q, hash %q", resp.Header.Get(headerSignature), resp.Header.Get(headerContentSHA256))
+	}
+	if body, _ := io.ReadAll(resp.Body); !bytes.Equal(body, payload) {
+		t.Error("hashed stream body changed")
+	}
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/convert/unhashed", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get(headerSignature) != "" {
+		t.Error("stream without a hash was signed")
+	}
+	if body, _ := io.ReadAll(resp.Body); !bytes.Equal(body, payload) {
+		t.Error("unhashed stream body changed")
+	}
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/convert/buffered", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get(headerSignature) == "" || resp.Header.Get(headerContentSHA256) != hash {
+		t.Errorf("buffered body: signature %q, hash %q", resp.Header.Get(headerSignature), resp.Header.Get(headerContentSHA256))
+	}
+}
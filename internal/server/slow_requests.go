@@ -0,0 +1,36 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+
+	"whats-convert-api/internal/services"
+)
+
+// slowRequestMiddleware times the conversion stages of every request and logs
+// the ones that take threshold or longer with a per-stage breakdown, so the
+// bottleneck (download, decode, queue, probe or encode) is obvious. Requests
+// that ran no conversion stage are never logged.
+func slowRequestMiddleware(threshold time.Duration) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		timings := services.NewStageTimings()
+		c.SetContext(services.WithStageTimings(c.Context(), timings))
+
+		start := time.Now()
+		err := c.Next()
+
+		total := time.Since(start)
+		if total < threshold {
+			return err
+		}
+		if summary := timings.Summary(total); summary != "" {
+			log.Printf("🐢 Slow conversion: method=%s path=%s status=%d total=%s %s request_id=%s",
+				c.Method(), c.Path(), c.Response().StatusCode(), total.Round(time.Millisecond), summary, requestid.FromContext(c))
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/features"
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/services"
+)
+
+// tierHeader names the caller's tier on every response
+const tierHeader = "X-API-Tier"
+
+// tierMiddleware applies the tier of the caller's API key. Conversions run at
+// the tier's worker priority and batch jobs count against its job limit;
+// requests that do work (conversions, inspections and uploads) must also fit
+// its file size limit and count against its rate limit. Metadata, health and
+// job polling requests are never limited.
+func tierMiddleware(tiers *services.Tiers) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		apiKey := c.Get(features.APIKeyHeader)
+		tier := tiers.Tier(apiKey)
+		c.Set(tierHeader, tier.Name)
+		c.SetContext(services.WithTier(c.Context(), tier, apiKey))
+
+		if !isTierMeteredPath(c.Method(), c.Path()) {
+			return c.Next()
+		}
+
+		if tier.MaxFileSize > 0 {
+			size := int64(c.Request().Header.ContentLength())
+			if size < 0 {
+				size = int64(len(c.Body()))
+			}
+			if size > tier.MaxFileSize {
+				return c.Status(fiber.StatusRequestEntityTooLarge).JSON(models.ErrorResponse{
+					Error:   "File too large for this tier",
+					Code:    "tier_file_too_large",
+					Details: "Maximum request size for this tier: " + strconv.FormatInt(tier.MaxFileSize, 10) + " bytes",
+				})
+			}
+		}
+
+		// Callers without a key are limited by address, not all together
+		caller := "key:" + apiKey
+		if apiKey == "" {
+			caller = "ip:" + c.IP()
+		}
+		decision := tiers.Allow(caller, tier)
+		if decision.Limit > 0 {
+			c.Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+			c.Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		}
+		if !decision.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(int(math.Ceil(decision.RetryAfter.Seconds())), 1)))
+			return c.Status(fiber.StatusTooManyRequests).JSON(models.ErrorResponse{
+				Error:   "Rate limit exceeded",
+				Code:    "rate_limited",
+				Details: "Maximum requests per minute for this tier: " + strconv.Itoa(decision.Limit),
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// isTierMeteredPath matches the requests that do work for the caller
+func isTierMeteredPath(method, path string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut:
+		return isAdmissionPath(path) || strings.HasSuffix(path, "/inspect") || strings.HasSuffix(path, "/inspect/fingerprint")
+	case fiber.MethodGet:
+		return strings.Contains(path, "/media/")
+	}
+	return false
+}
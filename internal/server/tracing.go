@@ -0,0 +1,82 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"whats-convert-api/internal/tracing"
+)
+
+// headerTraceID returns the request's trace ID so callers can look it up
+const headerTraceID = "X-Trace-Id"
+
+// requestHeaderCarrier lets the propagator read traceparent/baggage from a request
+type requestHeaderCarrier struct {
+	c fiber.Ctx
+}
+
+func (rc requestHeaderCarrier) Get(key string) string { return rc.c.Get(key) }
+
+// Set is unused: responses carry the trace ID in X-Trace-Id instead
+func (rc requestHeaderCarrier) Set(string, string) {}
+
+func (rc requestHeaderCarrier) Keys() []string {
+	headers := rc.c.GetReqHeaders()
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// tracingMiddleware opens the server span of every request, continuing the
+// caller's trace when it sends a traceparent header. Handlers pick the span up
+// through c.Context(), so converter, ffmpeg/vips and S3 spans nest under it.
+func tracingMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.Context(), requestHeaderCarrier{c})
+		ctx, span := tracing.StartServer(ctx, c.Method()+" "+c.Path(),
+			attribute.String("http.request.method", c.Method()),
+			attribute.String("url.path", c.Path()),
+			attribute.String("request.id", requestid.FromContext(c)),
+		)
+		defer span.End()
+
+		c.SetContext(ctx)
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			c.Set(headerTraceID, spanContext.TraceID().String())
+		}
+
+		err := c.Next()
+
+		// Errors returned up the chain become responses in the app's
+		// ErrorHandler, after this middleware has finished
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+			span.RecordError(err)
+		}
+
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/features"
+	"whats-convert-api/internal/services"
+)
+
+// cpuSecondsHeader reports the estimated CPU time a conversion used
+const cpuSecondsHeader = "X-CPU-Seconds"
+
+// trackUsage charges a conversion and the CPU time of its external commands
+// to the caller's API key for GET /usage. It is a no-op when USAGE_TRACKING
+// is off.
+func (s *Server) trackUsage(c fiber.Ctx) error {
+	if s.usage == nil {
+		return c.Next()
+	}
+
+	meter := &services.CPUMeter{}
+	c.SetContext(services.WithCPUMeter(c.Context(), meter))
+
+	err := c.Next()
+
+	cpuSeconds := meter.Seconds()
+	s.usage.Record(c.Get(features.APIKeyHeader), cpuSeconds)
+	c.Set(cpuSecondsHeader, strconv.FormatFloat(cpuSeconds, 'f', 3, 64))
+
+	return err
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"whats-convert-api/internal/models"
+)
+
+const (
+	// apiVersionHeader carries the negotiated API version on requests and responses.
+	apiVersionHeader = "X-API-Version"
+
+	// currentAPIVersion is served by legacy (unprefixed) routes.
+	currentAPIVersion = "1"
+
+	// apiVersionLocal is the Fiber locals key holding the negotiated version.
+	apiVersionLocal = "api_version"
+)
+
+// supportedAPIVersions lists every version the server can answer.
+var supportedAPIVersions = []string{currentAPIVersion}
+
+// apiVersionMiddleware negotiates the API version for each request.
+// A /vN path prefix takes precedence over the X-API-Version (or Accept-Version)
+// request header; requests without either are served by the current version.
+func apiVersionMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		version := versionFromPath(c.Path())
+		if version == "" {
+			version = normalizeAPIVersion(c.Get(apiVersionHeader))
+		}
+		if version == "" {
+			version = normalizeAPIVersion(c.Get("Accept-Version"))
+		}
+		if version == "" {
+			version = currentAPIVersion
+		}
+
+		if !isSupportedAPIVersion(version) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error:   "Unsupported API version",
+				Details: "Supported versions: v" + strings.Join(supportedAPIVersions, ", v"),
+			})
+		}
+
+		c.Locals(apiVersionLocal, version)
+		c.Set(apiVersionHeader, version)
+
+		return c.Next()
+	}
+}
+
+// versionFromPath extracts the version from a /vN/... request path.
+func versionFromPath(path string) string {
+	trimmed := strings.TrimPrefix(path, "/")
+	segment, _, _ := strings.Cut(trimmed, "/")
+	if len(segment) < 2 || segment[0] != 'v' {
+		return ""
+	}
+	for _, r := range segment[1:] {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+	return segment[1:]
+}
+
+// normalizeAPIVersion accepts "1", "v1" or "V1" and returns "1".
+func normalizeAPIVersion(value string) string {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "v"), "V")
+	return value
+}
+
+func isSupportedAPIVersion(version string) bool {
+	for _, supported := range supportedAPIVersions {
+		if supported == version {
+			return true
+		}
+	}
+	return false
+}
+
+// apiVersionPrefixes returns the path prefixes of every supported version.
+func apiVersionPrefixes() []string {
+	prefixes := make([]string, len(supportedAPIVersions))
+	for i, version := range supportedAPIVersions {
+		prefixes[i] = "/v" + version
+	}
+	return prefixes
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/proxy"
+	"github.com/gofiber/fiber/v3/middleware/recover"
+	"github.com/valyala/fasthttp"
+)
+
+// newWebUIApp serves the web interface on WEB_UI_PORT and forwards every
+// other request to the API listener, so the console keeps working while the
+// public port exposes only the JSON API. Responses are buffered by the
+// forwarder, so the console falls back to polling for upload progress.
+func (s *Server) newWebUIApp() *fiber.App {
+	app := fiber.New(fiber.Config{
+		ServerHeader:  "MediaConverter",
+		StrictRouting: true,
+		CaseSensitive: true,
+		AppName:       "WhatsApp Media Converter Console",
+		BodyLimit:     s.config.BodyLimit,
+		ReadTimeout:   s.config.ReadTimeout,
+		WriteTimeout:  s.config.WriteTimeout,
+		IdleTimeout:   s.config.IdleTimeout,
+	})
+	app.Use(recover.New())
+
+	s.webHandler.RegisterWebRoutes(app)
+
+	apiURL := fmt.Sprintf("http://127.0.0.1:%s", s.config.Port)
+	var clients []*fasthttp.Client
+	if s.config.TLSCertFile != "" {
+		// The certificate names the public host, not the loopback address
+		apiURL = fmt.Sprintf("https://127.0.0.1:%s", s.config.Port)
+		clients = append(clients, &fasthttp.Client{
+			NoDefaultUserAgentHeader: true,
+			DisablePathNormalizing:   true,
+			TLSConfig:                &tls.Config{InsecureSkipVerify: true},
+		})
+	}
+	app.Use(func(c fiber.Ctx) error {
+		c.Request().Header.Set(fiber.HeaderXForwardedFor, c.IP())
+		if err := proxy.Do(c, apiURL+c.OriginalURL(), clients...); err != nil {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error":   "API unavailable",
+				"details": err.Error(),
+			})
+		}
+		return nil
+	})
+
+	return app
+}
+
+// startWebUI listens on WEB_UI_PORT
+func (s *Server) startWebUI() {
+	go func() {
+		addr := fmt.Sprintf(":%s", s.config.WebUIPort)
+		if err := s.webApp.Listen(addr, fiber.ListenConfig{DisableStartupMessage: true}); err != nil {
+			log.Printf("Web UI server error: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidBackground is returned when a request's background isn't a hex colour
+var ErrInvalidBackground = errors.New("invalid background colour")
+
+// AlphaFormat is the output format of preserve_alpha conversions
+type AlphaFormat string
+
+const (
+	AlphaFormatWebP AlphaFormat = "webp"
+	AlphaFormatPNG  AlphaFormat = "png"
+)
+
+// MimeType returns the MIME type of images encoded in the format
+func (f AlphaFormat) MimeType() string {
+	return "image/" + string(f)
+}
+
+// rgbColor is an opaque background colour transparent pixels are flattened onto
+type rgbColor struct {
+	r, g, b uint8
+}
+
+var white = rgbColor{255, 255, 255}
+
+// parseColor accepts #rgb, #rrggbb (the # is optional), "white" and "black"
+func parseColor(value string) (rgbColor, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "white":
+		return white, nil
+	case "black":
+		return rgbColor{}, nil
+	}
+
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return rgbColor{}, fmt.Errorf("%w: %q (use #rrggbb)", ErrInvalidBackground, value)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgbColor{}, fmt.Errorf("%w: %q (use #rrggbb)", ErrInvalidBackground, value)
+	}
+
+	return rgbColor{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb)}, nil
+}
+
+// ffmpeg formats the colour for FFmpeg filter options
+func (c rgbColor) ffmpeg() string {
+	return fmt.Sprintf("0x%02x%02x%02x", c.r, c.g, c.b)
+}
+
+// vips formats the colour as a vips array of doubles
+func (c rgbColor) vips() string {
+	return fmt.Sprintf("%d %d %d", c.r, c.g, c.b)
+}
+
+// SetAlphaHandling sets the colour transparent inputs are flattened onto when
+// a request sets none and the format preserve_alpha requests produce.
+// An invalid background falls back to white and an unknown format to WebP.
+func (ic *ImageConverter) SetAlphaHandling(background string, format AlphaFormat) {
+	color, err := parseColor(background)
+	if err != nil {
+		color = white
+	}
+	if format != AlphaFormatPNG {
+		format = AlphaFormatWebP
+	}
+
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.background = color
+	ic.alphaFormat = format
+}
+
+// alphaHandling resolves the request's background and, when the request
+// preserves alpha and the input has any, the format to keep it in
+func (ic *ImageConverter) alphaHandling(req *ImageRequest, input []byte) (rgbColor, AlphaFormat, error) {
+	ic.mu.RLock()
+	background, format := ic.background, ic.alphaFormat
+	ic.mu.RUnlock()
+
+	if req.Background != "" {
+		color, err := parseColor(req.Background)
+		if err != nil {
+			return rgbColor{}, "", err
+		}
+		background = color
+	}
+
+	if !req.PreserveAlpha || !inputHasAlpha(input) {
+		format = ""
+	}
+
+	return background, format, nil
+}
+
+// inputHasAlpha reports from the headers whether a PNG, WebP or GIF input may
+// contain transparent pixels. Other formats have no alpha channel.
+func inputHasAlpha(data []byte) bool {
+	switch {
+	case len(data) > 33 && string(data[1:4]) == "PNG":
+		return pngHasAlpha(data)
+	case len(data) > 30 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return webpHasAlpha(data)
+	case len(data) > 6 && string(data[0:4]) == "GIF8":
+		return gifHasAlpha(data)
+	}
+	return false
+}
+
+// pngHasAlpha checks the IHDR colour type (4 = grey+alpha, 6 = RGBA) and
+// looks for a tRNS chunk before the image data
+func pngHasAlpha(data []byte) bool {
+	if colorType := data[25]; colorType == 4 || colorType == 6 {
+		return true
+	}
+
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		switch string(data[pos+4 : pos+8]) {
+		case "tRNS":
+			return true
+		case "IDAT":
+			return false
+		}
+		if length < 0 || pos+12+length > len(data) {
+			return false
+		}
+		pos += 12 + length
+	}
+	return false
+}
+
+// webpHasAlpha reads the VP8X alpha flag or the VP8L alpha hint
+func webpHasAlpha(data []byte) bool {
+	chunk := data[12:]
+	switch string(chunk[0:4]) {
+	case "VP8X":
+		return chunk[8]&0x10 != 0
+	case "VP8L":
+		// alpha_is_used follows the 14-bit width and height
+		return binary.LittleEndian.Uint32(chunk[9:13])&(1<<28) != 0
+	}
+	return false
+}
+
+// gifHasAlpha looks for a graphic control extension with the transparency flag
+func gifHasAlpha(data []byte) bool {
+	extension := []byte{0x21, 0xf9, 0x04}
+	for {
+		i := bytes.Index(data, extension)
+		if i < 0 || i+3 >= len(data) {
+			return false
+		}
+		if data[i+3]&0x01 != 0 {
+			return true
+		}
+		data = data[i+3:]
+	}
+}
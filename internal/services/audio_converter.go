@@ -1,45 +1,100 @@
 package services
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
 	"fmt"
-	"os/exec"
+	"io"
+	"mime/multipart"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/tracing"
 )
 
 // AudioConverter handles audio conversion using FFmpeg
 type AudioConverter struct {
-	workerPool *pool.WorkerPool
-	bufferPool *pool.BufferPool
-	downloader *Downloader
-	mu         sync.RWMutex
-	stats      AudioConverterStats
+	workerPool     *pool.WorkerPool
+	bufferPool     *pool.BufferPool
+	downloader     *Downloader
+	mockMode       bool             // Return canned output without running FFmpeg
+	faultPercent   int              // Chaos testing: percentage of conversions to fail
+	maxDuration    time.Duration    // Longest accepted input (0 = unlimited)
+	durationPolicy DurationPolicy   // Reject or flag inputs over maxDuration
+	sourceStore    *SourceStore     // Retains failed inputs for replay (nil = disabled)
+	skipCompliant  bool             // Return ready Ogg/Opus inputs without re-encoding
+	loudness       *LoudnessTarget  // Targets for normalize requests (nil = DefaultLoudnessTarget)
+	encoders       encoderSplit     // Stable/candidate libopus options
+	spill          *SpillStore      // Keeps large inputs out of memory (nil = disabled)
+	presets        *Presets         // Operator-defined presets (nil = built-in only)
+	cache          *ConversionCache // Outputs of earlier conversions (nil = disabled)
+	objects        *S3Service       // Bucket read by requests with a source (nil = S3 disabled)
+	mu             sync.RWMutex
+	stats          AudioConverterStats
 }
 
 // AudioConverterStats tracks conversion metrics
 type AudioConverterStats struct {
-	TotalConversions  int64
-	FailedConversions int64
-	AvgConversionTime time.Duration
+	TotalConversions   int64
+	FailedConversions  int64
+	SkippedConversions int64 // Already compliant inputs returned without re-encoding
+	AvgConversionTime  time.Duration
+
+	Variants map[string]VariantStats // Opus encodes per encoder variant (nil without a candidate)
 }
 
 // AudioRequest represents an audio conversion request
 type AudioRequest struct {
-	Data      string `json:"data" example:"data:audio/aac;base64,T2dnUwACAAAAAAAAAAB"` // base64 or URL
-	IsURL     bool   `json:"is_url" example:"false"`                                   // true if data is URL
-	InputType string `json:"input_type" example:"mp3"`                                 // Optional: mp3, wav, m4a, etc.
+	Data      string       `json:"data" example:"data:audio/aac;base64,T2dnUwACAAAAAAAAAAB"` // base64 or URL
+	IsURL     bool         `json:"is_url" example:"false"`                                   // true if data is URL
+	Source    *InputSource `json:"source,omitempty"`                                         // Optional: object in the configured bucket to convert instead of data
+	InputType string       `json:"input_type" example:"mp3"`                                 // Deprecated: the format is detected from the content (see input in the response)
+	DataURI   *bool        `json:"data_uri,omitempty" example:"true"`                        // Optional: false returns plain base64 (default true)
+	Compress  string       `json:"compress,omitempty" example:"br"`                          // Optional: br Brotli-compresses the output before base64 encoding
+
+	SkipIfCompliant *bool `json:"skip_if_compliant,omitempty" example:"true"` // Optional: return mono 48kHz Ogg/Opus input without re-encoding (default SKIP_COMPLIANT_INPUTS)
+
+	OutputFormat string `json:"output_format,omitempty" example:"opus"` // Optional: opus, mp3 or wav (default opus, mp3 with the reverse preset)
+	Preset       string `json:"preset,omitempty" example:"whatsapp"`    // Optional: whatsapp (default), reverse for received voice notes, or a PRESETS_FILE audio preset
+
+	IncludeWaveform bool `json:"include_waveform,omitempty" example:"true"` // Optional: also return the voice note waveform
+	Normalize       bool `json:"normalize,omitempty" example:"true"`        // Optional: normalize loudness to the EBU R128 targets (AUDIO_LOUDNORM_*)
+	Fingerprint     bool `json:"fingerprint,omitempty" example:"true"`      // Optional: also return the input's chromaprint, to spot resent audio
+
+	TargetSizeMB float64 `json:"target_size_mb,omitempty" example:"16"` // Optional: pick the bitrate so the output fits in this many MiB (Opus and MP3)
+
+	RawOutput bool                  `json:"-"` // Set by the HTTP layer: return bytes in Output instead of encoding Data
+	Input     []byte                `json:"-"` // Set by the HTTP layer: raw input bytes, used instead of Data
+	Upload    *multipart.FileHeader `json:"-"` // Set by the HTTP layer: multipart upload streamed to FFmpeg, used instead of Input
+	Sink      io.Writer             `json:"-"` // Set by convert-and-upload flows: receives the output instead of Data/Output
 }
 
 // AudioResponse represents the conversion response
 type AudioResponse struct {
-	Data     string `json:"data" example:"data:audio/ogg;codecs=opus;base64,T2dnUwACAAAA"` // base64 opus audio
-	Duration int    `json:"duration" example:"8"`                                          // Duration in seconds
-	Size     int    `json:"size" example:"42144"`                                          // Size in bytes
+	Data     string `json:"data,omitempty" example:"data:audio/ogg;codecs=opus;base64,T2dnUwACAAAA"` // base64 opus audio (data URI unless data_uri is false)
+	MimeType string `json:"mime_type" example:"audio/ogg;codecs=opus"`                               // MIME type of the decoded data (audio/mpeg or audio/wav for those output formats)
+	Duration int    `json:"duration" example:"8"`                                                    // Duration in seconds
+	Size     int    `json:"size" example:"42144"`                                                    // Size in bytes
+	Skipped  bool   `json:"skipped" example:"false"`                                                 // Input was already compliant and returned without re-encoding
+	Bitrate  int    `json:"bitrate,omitempty" example:"96"`                                          // Encoding bitrate in kbit/s chosen for target_size_mb
+
+	EncoderVariant string `json:"encoder_variant,omitempty" example:"stable"` // stable or candidate encoder options, set while AUDIO_CANDIDATE_PERCENT is above 0
+
+	Compression string `json:"compression,omitempty" example:"br"` // Set when data is Brotli-compressed plain base64
+
+	Waveform string `json:"waveform,omitempty" example:"AAULEBkhKjQ8RExUW2JocHd9g4mPlZuhpqu"` // Plain base64 of 64 amplitudes from 0 to 100, for WhatsApp's voice note waveform (include_waveform only)
+
+	Fingerprint *Fingerprint `json:"fingerprint,omitempty"` // Chromaprint of the input (fingerprint only)
+
+	DurationLimitExceeded bool            `json:"duration_limit_exceeded,omitempty" example:"false"` // Input was longer than MAX_AUDIO_DURATION (flag policy)
+	Cached                bool            `json:"cached,omitempty" example:"false"`                  // Output was served from the conversion cache
+	Input                 *MediaType      `json:"input,omitempty"`                                   // Format detected from the input's content
+	Trace                 []CommandRecord `json:"trace,omitempty"`                                   // External commands executed (debug trace only)
+	Timings               *Timings        `json:"timings,omitempty"`                                 // Time spent per stage (debug_timings only)
+
+	Output []byte `json:"-"` // Converted bytes when the request set RawOutput
 }
 
 // NewAudioConverter creates a new audio converter
@@ -52,78 +107,281 @@ func NewAudioConverter(workerPool *pool.WorkerPool, bufferPool *pool.BufferPool,
 }
 
 // Convert processes an audio conversion request
-func (ac *AudioConverter) Convert(ctx context.Context, req *AudioRequest) (*AudioResponse, error) {
+func (ac *AudioConverter) Convert(ctx context.Context, req *AudioRequest) (resp *AudioResponse, err error) {
+	ctx, span := tracing.Start(ctx, "convert.audio",
+		attribute.Bool("media.is_url", req.IsURL), attribute.String("media.format", req.OutputFormat))
+	defer func() { tracing.End(span, err) }()
+
+	if err := ac.injectFault(); err != nil {
+		return nil, err
+	}
+
+	if err := ac.applyPreset(req); err != nil {
+		return nil, err
+	}
+
+	if err := checkCompression(req.Compress); err != nil {
+		return nil, err
+	}
+
+	if err := checkSource(req.Source, req.Data); err != nil {
+		return nil, err
+	}
+
+	if ac.isMockMode() {
+		return ac.mockConvert(ctx, req)
+	}
+
 	start := time.Now()
 
-	// Get input data
-	var inputData []byte
-	var err error
+	format, preset, err := audioOutput(req)
+	if err != nil {
+		ac.recordFailure()
+		return nil, err
+	}
 
-	if req.IsURL {
-		// Download from URL
-		inputData, err = ac.downloader.Download(ctx, req.Data)
+	// Get input data
+	var input mediaInput
+
+	if req.Upload != nil {
+		input.file = req.Upload
+	} else if req.Input != nil {
+		input.data = req.Input
+	} else if req.Source != nil {
+		// Stream the object from the bucket, to a spill file once it's large
+		var release func()
+		input, release, err = objectInput(ctx, ac.objectStore(), ac.spillStore(), req.Source.S3Key, ac.downloader.maxSize)
+		if err != nil {
+			ac.recordFailure()
+			return nil, err
+		}
+		defer release()
+	} else if req.IsURL {
+		// Download from URL, to a spill file once it's large
+		var release func()
+		input, release, err = downloadInput(ctx, ac.downloader, ac.spillStore(), req.Data)
 		if err != nil {
 			ac.recordFailure()
 			return nil, fmt.Errorf("download failed: %w", err)
 		}
+		defer release()
 	} else {
-		// Decode base64
-		inputData, err = base64.StdEncoding.DecodeString(req.Data)
+		// Decode base64 into a pooled buffer (or a spill file when large),
+		// returned once FFmpeg is done with it
+		var release func()
+		input, release, err = decodeInput(ctx, ac.bufferPool, ac.spillStore(), req.Data)
 		if err != nil {
 			ac.recordFailure()
 			return nil, fmt.Errorf("base64 decode failed: %w", err)
 		}
+		defer release()
 	}
 
 	// Validate input size
-	if len(inputData) == 0 {
+	if input.size() == 0 {
 		ac.recordFailure()
 		return nil, fmt.Errorf("empty input data")
 	}
 
-	if len(inputData) > 100*1024*1024 { // 100MB max for audio
+	if input.size() > 100*1024*1024 { // 100MB max for audio
 		ac.recordFailure()
-		return nil, fmt.Errorf("audio file too large: %d bytes", len(inputData))
+		return nil, fmt.Errorf("audio file too large: %d bytes", input.size())
+	}
+
+	// Settled before the cache lookup, since both change the output
+	var filter string
+	if req.Normalize {
+		filter = ac.loudnessFilter()
+	}
+	var variant string
+	var encoderArgs []string
+	if format == AudioFormatOpus {
+		variant, encoderArgs = ac.encoders.pick()
+	}
+
+	// Answer repeated conversions of the same input from cache
+	cache := ac.conversionCache(ctx)
+	cacheKey := cache.key("audio", audioCacheParams(req, filter, encoderArgs), input)
+	var cached AudioResponse
+	if output, ok := cache.get(ctx, cacheKey, &cached); ok {
+		return cached.fromCache(output, req)
 	}
 
-	// Convert to Opus
-	outputData, err := ac.convertToOpus(ctx, inputData)
+	// Wait for an encoder slot; short voice notes use the priority lane
+	releaseSlot, err := acquireWorker(ctx, ac.workerPool, input.size())
 	if err != nil {
 		ac.recordFailure()
-		return nil, fmt.Errorf("conversion failed: %w", err)
+		return nil, fmt.Errorf("waiting for a worker: %w", err)
 	}
+	defer releaseSlot()
 
-	// Get audio duration (optional, adds slight overhead)
-	duration := ac.getAudioDuration(ctx, outputData)
+	// Fail fast on inputs FFmpeg would only reject after a full read
+	media := sniffInput(ctx, input)
+	if err := checkInputKind(media, "audio", MediaKindImage); err != nil {
+		ac.recordFailure()
+		return nil, err
+	}
 
-	// Record success
-	ac.recordSuccess(time.Since(start))
+	// Avoid tying up a worker on podcast-length inputs
+	overDuration, err := ac.checkDuration(ctx, input)
+	if err != nil {
+		ac.recordFailure()
+		return nil, err
+	}
 
-	// Encode output to Data URI with Opus codec
-	base64Data := base64.StdEncoding.EncodeToString(outputData)
-	dataURI := fmt.Sprintf("data:audio/ogg;codecs=opus;base64,%s", base64Data)
+	// Hashed from the input, so resent copies match whatever they're converted to
+	var fingerprint *Fingerprint
+	if req.Fingerprint {
+		fingerprint = conversionFingerprint(ctx, input, MediaKindAudio)
+	}
+
+	// Skip re-encoding inputs that are already WhatsApp-ready (and small enough)
+	targetBytes := targetSizeBytes(req.TargetSizeMB)
+	var outputData []byte
+	skipped := false
+	if format == AudioFormatOpus && !req.Normalize && ac.shouldSkipCompliant(req) &&
+		(targetBytes == 0 || int64(input.size()) <= targetBytes) {
+		outputData, skipped = ac.compliantAudio(ctx, input)
+	}
+	if skipped {
+		variant = "" // No encoder ran
+	}
+
+	// Opus and MP3 are streamed into a sink as FFmpeg writes them; WAV
+	// headers, waveforms and skipped inputs are written whole afterwards
+	var stream *countingWriter
+	if req.Sink != nil && !skipped && format != AudioFormatWAV && !req.IncludeWaveform {
+		stream = &countingWriter{w: req.Sink}
+	}
+	var sink io.Writer
+	if stream != nil {
+		sink = stream
+	}
+
+	// Convert to Opus, or MP3/WAV for the reverse direction
+	var bitrate int
+	if !skipped {
+		if targetBytes > 0 {
+			if bitrate, err = targetAudioBitrate(ctx, input, format, targetBytes); err != nil {
+				ac.recordFailure()
+				return nil, err
+			}
+		}
+
+		if format == AudioFormatOpus {
+			span.SetAttributes(attribute.String("media.encoder_variant", variant))
+
+			encodeStart := time.Now()
+			outputData, err = ac.convertToOpus(ctx, input, filter, bitrate, encoderArgs, sink)
+			ac.encoders.record(variant, time.Since(encodeStart), outputSize(outputData, stream), err)
+		} else {
+			outputData, err = ac.convertToFormat(ctx, input, format, preset, filter, bitrate, sink)
+		}
+		if err != nil {
+			ac.recordFailure()
+			return nil, ac.retainAudio(ctx, req, input, fmt.Errorf("conversion failed: %w", err))
+		}
+	}
+
+	// Rate control overshoots on short clips, and WAV has no bitrate to pick.
+	// A streamed output is refused before its sink is closed.
+	size := outputSize(outputData, stream)
+	if targetBytes > 0 && int64(size) > targetBytes {
+		ac.recordFailure()
+		return nil, fmt.Errorf("%w: output is %d bytes, target %d bytes",
+			ErrTargetSizeUnreachable, size, targetBytes)
+	}
+
+	// Drawn from the output, so it matches what the recipient plays
+	var waveform string
+	if req.IncludeWaveform {
+		if waveform, err = ac.waveform(ctx, outputData); err != nil {
+			ac.recordFailure()
+			return nil, err
+		}
+	}
+
+	// Get audio duration (optional, adds slight overhead); a streamed output
+	// is gone, so the input's is reported
+	var duration int
+	if stream != nil {
+		if inputDuration, probeErr := probeDuration(ctx, input); probeErr == nil {
+			duration = int(inputDuration.Seconds())
+		}
+	} else {
+		duration = ac.getAudioDuration(ctx, outputData)
+	}
+
+	if req.Sink != nil && stream == nil {
+		if _, err := req.Sink.Write(outputData); err != nil {
+			ac.recordFailure()
+			return nil, fmt.Errorf("write output: %w", err)
+		}
+	}
+
+	// Record success
+	ac.recordSuccess(time.Since(start), skipped)
 
 	response := &AudioResponse{
-		Data:     dataURI,
-		Duration: duration,
-		Size:     len(outputData),
+		MimeType:              format.MimeType(),
+		Duration:              duration,
+		Size:                  size,
+		Skipped:               skipped,
+		Bitrate:               bitrate,
+		EncoderVariant:        variant,
+		Waveform:              waveform,
+		DurationLimitExceeded: overDuration,
+		Input:                 &media,
+		Fingerprint:           fingerprint,
+	}
+	if stream == nil {
+		cache.put(ctx, cacheKey, response, outputData)
+	}
+	if req.Sink == nil {
+		response.setOutput(outputData, req)
 	}
 
 	return response, nil
 }
 
-// convertToOpus converts audio to Opus format optimized for WhatsApp
-func (ac *AudioConverter) convertToOpus(ctx context.Context, input []byte) ([]byte, error) {
-	// FFmpeg command optimized for WhatsApp Opus
-	cmd := exec.CommandContext(ctx, "ffmpeg",
+// outputSize returns the size of an output held in memory or streamed
+func outputSize(output []byte, stream *countingWriter) int {
+	if stream != nil {
+		return int(stream.n)
+	}
+	return len(output)
+}
+
+// convertToOpus converts audio to Opus format optimized for WhatsApp,
+// applying filter (e.g. loudness normalization) when it isn't empty. A
+// positive bitrate (kbit/s) replaces the 128k default and constrains VBR so
+// the output stays within a target size. encoderArgs come last, so an
+// encoder variant can override any of the options below. With a sink the
+// output is streamed into it and nil is returned.
+func (ac *AudioConverter) convertToOpus(ctx context.Context, input mediaInput, filter string, bitrate int, encoderArgs []string, sink io.Writer) ([]byte, error) {
+	args := []string{
 		"-hide_banner",       // Hide FFmpeg banner
 		"-loglevel", "error", // Only show errors
 		"-i", "pipe:0", // Input from stdin
 		"-vn",           // Ignore video streams (important for WebM)
 		"-map", "0:a:0", // Select only first audio stream
+	}
+	if filter != "" {
+		args = append(args, "-filter:a", filter)
+	}
+
+	vbr := "on" // Variable bitrate for better quality
+	if bitrate > 0 {
+		vbr = "constrained"
+	} else {
+		bitrate = defaultAudioBitrate // 128kbps (WhatsApp standard)
+	}
+
+	// FFmpeg command optimized for WhatsApp Opus
+	args = append(args,
 		"-c:a", "libopus", // Opus codec
-		"-b:a", "128k", // Bitrate 128kbps (WhatsApp standard)
-		"-vbr", "on", // Variable bitrate for better quality
+		"-b:a", fmt.Sprintf("%dk", bitrate),
+		"-vbr", vbr,
 		"-compression_level", "10", // Maximum compression quality
 		"-application", "voip", // Optimized for voice (WhatsApp voice notes)
 		"-frame_duration", "20", // Frame duration in ms
@@ -132,28 +390,33 @@ func (ac *AudioConverter) convertToOpus(ctx context.Context, input []byte) ([]by
 		"-ar", "48000", // Sample rate 48kHz (Opus standard)
 		"-ac", "1", // Mono (WhatsApp uses mono for voice)
 		"-f", "ogg", // OGG container (WhatsApp compatible)
-		"-threads", "0", // Use all available CPU threads
-		"pipe:1", // Output to stdout
+		"-threads", ffmpegThreadsArg(), // Per-process thread budget
 	)
+	args = append(args, encoderArgs...)
 
-	// Set up pipes
-	cmd.Stdin = bytes.NewReader(input)
-
-	// Get buffers from pool for output
-	var outputBuffer bytes.Buffer
-	var errorBuffer bytes.Buffer
+	return encodeAudio(ctx, input, sink, append(args, "pipe:1")) // Output to stdout
+}
 
-	cmd.Stdout = &outputBuffer
-	cmd.Stderr = &errorBuffer
+// encodeAudio runs an FFmpeg encode of input and returns its output, or
+// streams it into sink and returns nil when there is one
+func encodeAudio(ctx context.Context, input mediaInput, sink io.Writer, args []string) ([]byte, error) {
+	if sink != nil {
+		counter := &countingWriter{w: sink}
+		if stderr, err := input.stream(ctx, counter, "ffmpeg", args...); err != nil {
+			return nil, fmt.Errorf("ffmpeg error: %v, stderr: %s", err, stderr)
+		}
+		if counter.n == 0 {
+			return nil, fmt.Errorf("ffmpeg produced no output")
+		}
+		return nil, nil
+	}
 
-	// Run conversion
-	err := cmd.Run()
+	output, stderr, err := input.run(ctx, "ffmpeg", args...)
 	if err != nil {
 		// Include FFmpeg error output for debugging
-		return nil, fmt.Errorf("ffmpeg error: %v, stderr: %s", err, errorBuffer.String())
+		return nil, fmt.Errorf("ffmpeg error: %v, stderr: %s", err, stderr)
 	}
 
-	output := outputBuffer.Bytes()
 	if len(output) == 0 {
 		return nil, fmt.Errorf("ffmpeg produced no output")
 	}
@@ -168,7 +431,7 @@ func (ac *AudioConverter) convertToOpusAdvanced(ctx context.Context, input []byt
 		channels = "1"
 	}
 
-	cmd := exec.CommandContext(ctx, "ffmpeg",
+	output, stderr, err := runCommand(ctx, input, "ffmpeg",
 		"-hide_banner",
 		"-loglevel", "error",
 		"-i", "pipe:0",
@@ -183,40 +446,34 @@ func (ac *AudioConverter) convertToOpusAdvanced(ctx context.Context, input []byt
 		"-ac", channels, // Custom channel config
 		"-filter:a", "loudnorm=I=-16:LRA=11:TP=-1.5", // Normalize audio levels
 		"-f", "ogg", // OGG container (WhatsApp compatible)
-		"-threads", "0",
+		"-threads", ffmpegThreadsArg(),
 		"pipe:1",
 	)
-
-	cmd.Stdin = bytes.NewReader(input)
-	var outputBuffer bytes.Buffer
-	var errorBuffer bytes.Buffer
-	cmd.Stdout = &outputBuffer
-	cmd.Stderr = &errorBuffer
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("ffmpeg error: %v, stderr: %s", err, errorBuffer.String())
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg error: %v, stderr: %s", err, stderr)
 	}
 
-	return outputBuffer.Bytes(), nil
+	return output, nil
 }
 
-// getAudioDuration gets the duration of audio in seconds
+// getAudioDuration gets the duration of audio in seconds, from the container
+// for Ogg and WAV outputs
 func (ac *AudioConverter) getAudioDuration(ctx context.Context, audioData []byte) int {
+	if duration, ok := headerDuration(audioData); ok {
+		return int(duration.Seconds())
+	}
+
 	// Use ffprobe to get duration without re-encoding
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "ffprobe",
+	output, _, err := runCommand(ctx, audioData, "ffprobe",
 		"-hide_banner",
 		"-loglevel", "error",
 		"-i", "pipe:0",
 		"-show_entries", "format=duration",
 		"-of", "default=noprint_wrappers=1:nokey=1",
 	)
-
-	cmd.Stdin = bytes.NewReader(audioData)
-
-	output, err := cmd.Output()
 	if err != nil {
 		// Duration is optional, don't fail the conversion
 		return 0
@@ -230,53 +487,24 @@ func (ac *AudioConverter) getAudioDuration(ctx context.Context, audioData []byte
 }
 
 // ConvertBatch processes multiple audio conversions in parallel
-func (ac *AudioConverter) ConvertBatch(ctx context.Context, requests []*AudioRequest) ([]*AudioResponse, error) {
+func (ac *AudioConverter) ConvertBatch(ctx context.Context, requests []*AudioRequest, opts BatchOptions) ([]*AudioResponse, error) {
 	responses := make([]*AudioResponse, len(requests))
-	errors := make([]error, len(requests))
-	var wg sync.WaitGroup
-
-	for i, req := range requests {
-		wg.Add(1)
-		go func(index int, request *AudioRequest) {
-			defer wg.Done()
-
-			// Create individual context with timeout
-			convertCtx, cancel := context.WithTimeout(ctx, 1*time.Minute)
-			defer cancel()
-
-			resp, err := ac.Convert(convertCtx, request)
-			if err != nil {
-				errors[index] = err
-			} else {
-				responses[index] = resp
-			}
-		}(i, req)
-	}
-
-	wg.Wait()
-
-	// Check for any errors
-	for i, err := range errors {
-		if err != nil {
-			return responses, fmt.Errorf("conversion %d failed: %w", i, err)
-		}
-	}
-
-	return responses, nil
+	err := runBatch(ctx, len(requests), opts, func(ctx context.Context, index int) error {
+		resp, err := ac.Convert(ctx, requests[index])
+		responses[index] = resp
+		return err
+	})
+	return responses, err
 }
 
 // ValidateInput checks if the input data is valid audio
 func (ac *AudioConverter) ValidateInput(ctx context.Context, data []byte) error {
 	// Use ffprobe to validate
-	cmd := exec.CommandContext(ctx, "ffprobe",
+	if _, _, err := runCommand(ctx, data, "ffprobe",
 		"-hide_banner",
 		"-loglevel", "error",
 		"-i", "pipe:0",
-	)
-
-	cmd.Stdin = bytes.NewReader(data)
-
-	if err := cmd.Run(); err != nil {
+	); err != nil {
 		return fmt.Errorf("invalid audio data: %w", err)
 	}
 
@@ -284,11 +512,14 @@ func (ac *AudioConverter) ValidateInput(ctx context.Context, data []byte) error
 }
 
 // Stats recording
-func (ac *AudioConverter) recordSuccess(duration time.Duration) {
+func (ac *AudioConverter) recordSuccess(duration time.Duration, skipped bool) {
 	ac.mu.Lock()
 	defer ac.mu.Unlock()
 
 	ac.stats.TotalConversions++
+	if skipped {
+		ac.stats.SkippedConversions++
+	}
 
 	// Update average conversion time
 	if ac.stats.AvgConversionTime == 0 {
@@ -309,7 +540,9 @@ func (ac *AudioConverter) recordFailure() {
 // GetStats returns conversion statistics
 func (ac *AudioConverter) GetStats() AudioConverterStats {
 	ac.mu.RLock()
-	defer ac.mu.RUnlock()
+	stats := ac.stats
+	ac.mu.RUnlock()
 
-	return ac.stats
+	stats.Variants = ac.encoders.snapshot()
+	return stats
 }
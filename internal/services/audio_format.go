@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedOutputFormat is returned for an output_format other than opus, mp3 or wav
+	ErrUnsupportedOutputFormat = errors.New("unsupported output format")
+
+	// ErrUnknownPreset is returned for a preset the endpoint doesn't define
+	ErrUnknownPreset = errors.New("unknown preset")
+)
+
+// AudioFormat is the output format of an audio conversion
+type AudioFormat string
+
+const (
+	AudioFormatOpus AudioFormat = "opus" // Ogg/Opus voice note for WhatsApp (default)
+	AudioFormatMP3  AudioFormat = "mp3"  // MP3 for CRMs and players that can't read Ogg
+	AudioFormatWAV  AudioFormat = "wav"  // 16-bit PCM WAV for transcription vendors
+)
+
+// Audio presets
+const (
+	// AudioPresetWhatsApp sends audio to WhatsApp: Opus voice notes (default)
+	AudioPresetWhatsApp = "whatsapp"
+
+	// AudioPresetReverse handles voice notes received from WhatsApp: MP3 unless
+	// output_format says otherwise, mono, and 16kHz when the output is WAV
+	AudioPresetReverse = "reverse"
+)
+
+// MimeType returns the MIME type of audio encoded in the format
+func (f AudioFormat) MimeType() string {
+	switch f {
+	case AudioFormatMP3:
+		return "audio/mpeg"
+	case AudioFormatWAV:
+		return "audio/wav"
+	}
+	return audioMimeType
+}
+
+// OutputMimeType returns the MIME type the request's output will have
+func (req *AudioRequest) OutputMimeType() (string, error) {
+	format, _, err := audioOutput(req)
+	if err != nil {
+		return "", err
+	}
+	return format.MimeType(), nil
+}
+
+// audioOutput resolves the request's preset and output_format
+func audioOutput(req *AudioRequest) (AudioFormat, string, error) {
+	preset := strings.ToLower(strings.TrimSpace(req.Preset))
+	format := AudioFormat(strings.ToLower(strings.TrimSpace(req.OutputFormat)))
+
+	switch preset {
+	case "", AudioPresetWhatsApp:
+		preset = AudioPresetWhatsApp
+		if format == "" {
+			format = AudioFormatOpus
+		}
+	case AudioPresetReverse:
+		if format == "" {
+			format = AudioFormatMP3
+		}
+	default:
+		return "", "", fmt.Errorf("%w: %q (use %s or %s)", ErrUnknownPreset, req.Preset, AudioPresetWhatsApp, AudioPresetReverse)
+	}
+
+	switch format {
+	case AudioFormatOpus, AudioFormatMP3, AudioFormatWAV:
+		return format, preset, nil
+	}
+	return "", "", fmt.Errorf("%w: %q (use opus, mp3 or wav)", ErrUnsupportedOutputFormat, req.OutputFormat)
+}
+
+// convertToFormat decodes audio (typically a received Ogg/Opus voice note)
+// to MP3 or WAV, applying filter when it isn't empty. A positive bitrate
+// (kbit/s) replaces the MP3 default; WAV ignores it. MP3 can be streamed
+// into a sink; WAV can't, its header sizes are filled in afterwards.
+func (ac *AudioConverter) convertToFormat(ctx context.Context, input mediaInput, format AudioFormat, preset, filter string, bitrate int, sink io.Writer) ([]byte, error) {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", "pipe:0", // Input from stdin
+		"-vn",           // Ignore video streams and cover art
+		"-map", "0:a:0", // Select only first audio stream
+		"-map_metadata", "-1", // No tags: some CRM importers choke on ID3/LIST chunks
+	}
+	if preset == AudioPresetReverse {
+		args = append(args, "-ac", "1") // Voice notes are mono
+	}
+	if filter != "" {
+		args = append(args, "-filter:a", filter)
+	}
+
+	switch format {
+	case AudioFormatMP3:
+		if filter != "" {
+			args = append(args, "-ar", "44100") // loudnorm outputs 192kHz
+		}
+		if bitrate <= 0 {
+			bitrate = defaultAudioBitrate
+		}
+		args = append(args,
+			"-c:a", "libmp3lame",
+			"-b:a", fmt.Sprintf("%dk", bitrate), // Constant bitrate plays everywhere
+			"-f", "mp3",
+		)
+	case AudioFormatWAV:
+		if preset == AudioPresetReverse {
+			args = append(args, "-ar", "16000") // What speech-to-text engines expect
+		} else if filter != "" {
+			args = append(args, "-ar", "48000") // loudnorm outputs 192kHz
+		}
+		args = append(args,
+			"-c:a", "pcm_s16le",
+			"-bitexact", // No LIST/INFO chunk
+			"-f", "wav",
+		)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedOutputFormat, format)
+	}
+	args = append(args,
+		"-threads", ffmpegThreadsArg(), // Per-process thread budget
+		"pipe:1", // Output to stdout
+	)
+
+	output, err := encodeAudio(ctx, input, sink, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == AudioFormatWAV {
+		fixWAVSizes(output)
+	}
+
+	return output, nil
+}
+
+// fixWAVSizes fills in the RIFF and data chunk sizes FFmpeg can't seek back
+// to write when the output is a pipe
+func fixWAVSizes(wav []byte) {
+	if len(wav) < 12 || string(wav[0:4]) != "RIFF" || string(wav[8:12]) != "WAVE" {
+		return
+	}
+	binary.LittleEndian.PutUint32(wav[4:8], uint32(len(wav)-8))
+
+	for pos := 12; pos+8 <= len(wav); {
+		if string(wav[pos:pos+4]) == "data" {
+			binary.LittleEndian.PutUint32(wav[pos+4:pos+8], uint32(len(wav)-pos-8))
+			return
+		}
+		size := int(binary.LittleEndian.Uint32(wav[pos+4 : pos+8]))
+		pos += 8 + size + size&1
+	}
+}
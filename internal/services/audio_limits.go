@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDurationLimitExceeded is returned when an input is longer than the configured maximum
+var ErrDurationLimitExceeded = errors.New("audio exceeds the maximum duration")
+
+// DurationPolicy decides what happens to inputs longer than the maximum duration
+type DurationPolicy string
+
+const (
+	// DurationPolicyReject fails the conversion before FFmpeg runs
+	DurationPolicyReject DurationPolicy = "reject"
+
+	// DurationPolicyFlag converts anyway and marks the response
+	DurationPolicyFlag DurationPolicy = "flag"
+)
+
+// SetMaxDuration caps input duration; zero or negative disables the check
+func (ac *AudioConverter) SetMaxDuration(maxDuration time.Duration, policy DurationPolicy) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	if policy != DurationPolicyFlag {
+		policy = DurationPolicyReject
+	}
+
+	ac.maxDuration = maxDuration
+	ac.durationPolicy = policy
+}
+
+// checkDuration probes the input and applies the duration policy.
+// It reports whether the input exceeded the limit under the flag policy.
+func (ac *AudioConverter) checkDuration(ctx context.Context, input mediaInput) (bool, error) {
+	ac.mu.RLock()
+	maxDuration, policy := ac.maxDuration, ac.durationPolicy
+	ac.mu.RUnlock()
+
+	if maxDuration <= 0 {
+		return false, nil
+	}
+
+	duration, err := probeDuration(ctx, input)
+	if err != nil || duration <= maxDuration {
+		// Unknown durations are left to FFmpeg
+		return false, nil
+	}
+
+	if policy == DurationPolicyFlag {
+		return true, nil
+	}
+
+	return false, fmt.Errorf("%w: input is %s, limit is %s",
+		ErrDurationLimitExceeded, duration.Round(time.Second), maxDuration)
+}
+
+// probeDuration reads the container duration without decoding the stream:
+// natively for Ogg and WAV inputs held in memory, with ffprobe otherwise
+func probeDuration(ctx context.Context, input mediaInput) (time.Duration, error) {
+	if input.file == nil && input.path == "" {
+		if duration, ok := headerDuration(input.data); ok {
+			return duration, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	output, _, err := input.run(ctx, "ffprobe",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected ffprobe duration %q: %w", output, err)
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrAuditStampNotFound is returned for images that carry no audit stamp
+var ErrAuditStampNotFound = errors.New("no audit stamp found")
+
+const (
+	auditStampPrefix    = "wca-audit/1 " // Versioned marker the stamp starts with
+	auditStampSigLen    = 24             // Hex characters of the HMAC kept (96 bits)
+	auditStampMaxRef    = 64             // Longest reference stored, in bytes
+	auditStampMaxLen    = 256            // Longest stamp text
+	auditStampReserve   = 320            // Bytes kept free for the stamp under max_file_size_kb
+	webpAuditStampChunk = "AUDT"         // Private WebP chunk, ignored by decoders
+)
+
+// AuditStamp is the provenance embedded in a converted image
+type AuditStamp struct {
+	Tenant    string    `json:"tenant" example:"key_3f2a9c1b7d4e"`                                  // TenantID of the caller's API key
+	Reference string    `json:"reference" example:"0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a"`           // Request ID, or batch job ID and item index
+	IssuedAt  time.Time `json:"issued_at" example:"2024-03-31T12:03:20Z"`                           // When the image was converted
+	Valid     bool      `json:"valid" example:"true"`                                               // Signed with this deployment's AUDIT_STAMP_SECRET
+	Text      string    `json:"text" example:"wca-audit/1 tenant=key_3f2a9c1b7d4e ref=... sig=..."` // The stamp as embedded
+}
+
+// AuditStamper embeds signed audit stamps in converted images, so a leaked
+// file can be traced back to the API key and request that produced it. The
+// stamp is a JPEG comment, a PNG tEXt chunk or a WebP chunk: it doesn't alter
+// a pixel, but re-encoding the file (as WhatsApp does to photos) drops it.
+type AuditStamper struct {
+	secret []byte
+}
+
+// NewAuditStamper creates a stamper signing with secret (AUDIT_STAMP_SECRET),
+// nil when secret is empty
+func NewAuditStamper(secret string) *AuditStamper {
+	if secret == "" {
+		return nil
+	}
+	return &AuditStamper{secret: []byte(secret)}
+}
+
+type auditOwnerKey struct{}
+
+// auditOwner is who the images converted under a context are stamped for
+type auditOwner struct {
+	tenant    string
+	reference string
+}
+
+// WithAuditOwner returns a context whose converted images are stamped with
+// apiKey's tenant and reference (usually the request ID)
+func WithAuditOwner(ctx context.Context, apiKey, reference string) context.Context {
+	return context.WithValue(ctx, auditOwnerKey{}, auditOwner{tenant: TenantID(apiKey), reference: reference})
+}
+
+// withAuditReference keeps ctx's tenant and replaces its reference, so batch
+// job items are stamped with their job rather than the submitting request
+func withAuditReference(ctx context.Context, reference string) context.Context {
+	owner := auditOwnerFrom(ctx)
+	owner.reference = reference
+	return context.WithValue(ctx, auditOwnerKey{}, owner)
+}
+
+// auditOwnerFrom returns the owner attached to ctx; conversions without one
+// (gRPC, warmup) are stamped as anonymous
+func auditOwnerFrom(ctx context.Context) auditOwner {
+	owner, ok := ctx.Value(auditOwnerKey{}).(auditOwner)
+	if !ok {
+		owner.tenant = AnonymousTenant
+	}
+	return owner
+}
+
+// text returns the signed stamp of an image converted for owner at issued
+func (s *AuditStamper) text(owner auditOwner, issued time.Time) string {
+	body := auditStampPrefix +
+		"tenant=" + stampField(owner.tenant, auditStampMaxRef) +
+		" ref=" + stampField(owner.reference, auditStampMaxRef) +
+		" issued=" + strconv.FormatInt(issued.Unix(), 10)
+	return body + " sig=" + s.sign(body)
+}
+
+// sign returns the truncated hex HMAC-SHA256 of a stamp's fields
+func (s *AuditStamper) sign(body string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))[:auditStampSigLen]
+}
+
+// stampField keeps a stamp field to printable ASCII without spaces, which
+// separate the fields, and to at most limit bytes
+func stampField(value string, limit int) string {
+	if value == "" {
+		return "-"
+	}
+	field := []byte(value[:min(len(value), limit)])
+	for i, c := range field {
+		if c <= ' ' || c > '~' {
+			field[i] = '_'
+		}
+	}
+	return string(field)
+}
+
+// Stamp embeds the stamp of ctx's owner in a JPEG, PNG or WebP image. Other
+// formats, and files too odd to edit, are returned unchanged with false.
+func (s *AuditStamper) Stamp(ctx context.Context, image []byte) ([]byte, bool) {
+	text := []byte(s.text(auditOwnerFrom(ctx), time.Now()))
+
+	switch {
+	case len(image) > 4 && image[0] == 0xff && image[1] == 0xd8:
+		return insertJPEGComment(image, text)
+	case bytes.HasPrefix(image, []byte("\x89PNG\r\n\x1a\n")):
+		return insertPNGChunk(image, "tEXt", append([]byte("Comment\x00"), text...))
+	case len(image) > 12 && string(image[0:4]) == "RIFF" && string(image[8:12]) == "WEBP":
+		webp, err := setWebPChunk(image, webpAuditStampChunk, text, 0)
+		if err != nil {
+			return image, false
+		}
+		return webp, true
+	}
+	return image, false
+}
+
+// Read finds the audit stamp in an image and checks its signature
+func (s *AuditStamper) Read(image []byte) (*AuditStamp, error) {
+	start := bytes.Index(image, []byte(auditStampPrefix))
+	if start < 0 {
+		return nil, ErrAuditStampNotFound
+	}
+	raw := string(image[start:min(len(image), start+auditStampMaxLen)])
+
+	// The signature is the last field and has a fixed length; whatever follows
+	// it belongs to the container
+	sigAt := strings.Index(raw, " sig=")
+	if sigAt < 0 || len(raw) < sigAt+len(" sig=")+auditStampSigLen {
+		return nil, ErrAuditStampNotFound
+	}
+	body := raw[:sigAt]
+	sig := raw[sigAt+len(" sig=") : sigAt+len(" sig=")+auditStampSigLen]
+
+	stamp := &AuditStamp{Text: body + " sig=" + sig}
+	for _, field := range strings.Fields(strings.TrimPrefix(body, auditStampPrefix)) {
+		name, value, _ := strings.Cut(field, "=")
+		switch name {
+		case "tenant":
+			stamp.Tenant = value
+		case "ref":
+			if value != "-" {
+				stamp.Reference = value
+			}
+		case "issued":
+			if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+				stamp.IssuedAt = time.Unix(unix, 0).UTC()
+			}
+		}
+	}
+	if stamp.Tenant == "" {
+		return nil, ErrAuditStampNotFound
+	}
+	stamp.Valid = hmac.Equal([]byte(sig), []byte(s.sign(body)))
+
+	return stamp, nil
+}
+
+// insertJPEGComment adds a COM segment after SOI and the APPn segments
+func insertJPEGComment(jpeg, comment []byte) ([]byte, bool) {
+	if len(comment)+2 > 0xffff {
+		return jpeg, false
+	}
+
+	pos := 2
+	for pos+4 <= len(jpeg) && jpeg[pos] == 0xff && jpeg[pos+1] >= 0xe0 && jpeg[pos+1] <= 0xef {
+		pos += 2 + int(binary.BigEndian.Uint16(jpeg[pos+2:pos+4]))
+	}
+	if pos > len(jpeg) {
+		return jpeg, false
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(jpeg)+len(comment)+4))
+	out.Write(jpeg[:pos])
+	out.Write([]byte{0xff, 0xfe})
+	binary.Write(out, binary.BigEndian, uint16(len(comment)+2))
+	out.Write(comment)
+	out.Write(jpeg[pos:])
+
+	return out.Bytes(), true
+}
+
+// SetAuditStamper embeds an audit stamp in every converted image (nil disables)
+func (ic *ImageConverter) SetAuditStamper(stamper *AuditStamper) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.stamper = stamper
+}
+
+func (ic *ImageConverter) auditStamper() *AuditStamper {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	return ic.stamper
+}
+
+// stampOutput embeds ctx's audit stamp in a response's output. Outputs are
+// cached unstamped, so every caller gets its own stamp.
+func (ic *ImageConverter) stampOutput(ctx context.Context, output []byte) []byte {
+	stamper := ic.auditStamper()
+	if stamper == nil {
+		return output
+	}
+	if stamped, ok := stamper.Stamp(ctx, output); ok {
+		return stamped
+	}
+	return output
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+
+	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/providers"
+)
+
+// decodeBase64 decodes std, raw or URL-safe base64 (see providers.NormalizeBase64)
+// into a buffer borrowed from bufferPool, so large
+// payloads don't allocate a fresh decoded copy per request. Payloads bigger than
+// a pool buffer fall back to a single allocation. The returned release func must
+// be called once the decoded bytes are no longer referenced; it is never nil.
+// The time spent is recorded as the decode stage of the request in ctx.
+func decodeBase64(ctx context.Context, bufferPool *pool.BufferPool, data string) ([]byte, func(), error) {
+	defer timeStage(ctx, StageDecode)()
+
+	data, encoding := providers.NormalizeBase64(data)
+	if bufferPool == nil {
+		decoded, err := encoding.DecodeString(data)
+		return decoded, func() {}, err
+	}
+
+	buf := bufferPool.GetSized(encoding.DecodedLen(len(data)))
+	release := func() { bufferPool.PutSized(buf) }
+
+	// The []byte conversion doesn't escape, so the compiler skips the copy
+	n, err := encoding.Decode(buf, []byte(data))
+	if err != nil {
+		release()
+		return nil, func() {}, err
+	}
+
+	return buf[:n], release, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultBatchItemTimeout bounds each batch item when no timeout is set
+const DefaultBatchItemTimeout = time.Minute
+
+// BatchOptions tunes how the items of one batch are converted
+type BatchOptions struct {
+	ItemTimeout time.Duration // Deadline of each item (0 = DefaultBatchItemTimeout)
+	Concurrency int           // Items converted at once (0 = all of them)
+
+	// Asynchronous jobs only
+	Report  string // Report format uploaded when the job finishes ("" = BATCH_REPORT_MIN_ITEMS decides, none = never)
+	Archive string // Archive format uploaded when the job finishes ("" = none)
+	JobsURL string // Path of the jobs collection, for the result URLs of reports
+}
+
+// runBatch calls convert for items 0..n-1, at most opts.Concurrency at a
+// time, each under its own deadline. Items still waiting when ctx ends fail
+// with its error. The error of the first failed item is returned.
+func runBatch(ctx context.Context, n int, opts BatchOptions, convert func(ctx context.Context, index int) error) error {
+	timeout := opts.ItemTimeout
+	if timeout <= 0 {
+		timeout = DefaultBatchItemTimeout
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 || concurrency > n {
+		concurrency = n
+	}
+
+	errs := make([]error, n)
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			itemCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			errs[index] = convert(itemCtx, index)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("conversion %d failed: %w", i, err)
+		}
+	}
+	return nil
+}
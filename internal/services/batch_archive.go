@@ -0,0 +1,259 @@
+package services
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrUnsupportedArchiveFormat is returned for archive formats other than tar.zst
+var ErrUnsupportedArchiveFormat = errors.New("unsupported archive format")
+
+// BatchArchiveTarZstd packs a batch's outputs and manifest into a
+// Zstandard-compressed tar file
+const BatchArchiveTarZstd = "tar.zst"
+
+// BatchArchiveContentType is the MIME type of tar.zst archives
+const BatchArchiveContentType = "application/zstd"
+
+// BatchArchiveManifestName is the archive entry describing every item,
+// written before the outputs
+const BatchArchiveManifestName = "manifest.json"
+
+// BatchArchiveManifest describes the items of a batch archive
+type BatchArchiveManifest struct {
+	JobID     string              `json:"job_id,omitempty"` // Asynchronous jobs only
+	Kind      string              `json:"kind"`
+	Count     int                 `json:"count"`
+	Completed int                 `json:"completed"`
+	Failed    int                 `json:"failed"`
+	CreatedAt time.Time           `json:"created_at"`
+	Items     []BatchArchiveEntry `json:"items"`
+}
+
+// BatchArchiveEntry describes one item of a batch archive: its report row
+// and the file holding its output
+type BatchArchiveEntry struct {
+	File string `json:"file,omitempty"` // Entry name of the converted output, e.g. 007.jpg
+	BatchReportRow
+}
+
+// BatchArchive is the content of a batch archive
+type BatchArchive struct {
+	Manifest BatchArchiveManifest
+	Outputs  [][]byte // Converted output per item, nil for items that have none
+}
+
+// ParseBatchArchiveFormat validates an archive format; "" and none ask for
+// no archive
+func ParseBatchArchiveFormat(format string) (string, error) {
+	switch format = strings.ToLower(strings.TrimSpace(format)); format {
+	case "", "none":
+		return "", nil
+	case BatchArchiveTarZstd, "tar.zstd", "tzst":
+		return BatchArchiveTarZstd, nil
+	}
+	return "", fmt.Errorf("%w %q (tar.zst)", ErrUnsupportedArchiveFormat, format)
+}
+
+// NewAudioBatchArchive packs the outputs of a synchronous audio batch,
+// converted with RawOutput
+func NewAudioBatchArchive(requests []*AudioRequest, responses []*AudioResponse) *BatchArchive {
+	archive := newBatchArchive(BatchJobAudio, len(responses))
+	for i, response := range responses {
+		archive.Manifest.Items[i].BatchReportRow = syncArchiveRow(i, requests[i].Data, requests[i].IsURL, response)
+		archive.Outputs[i] = response.Output
+	}
+	return archive.count()
+}
+
+// NewImageBatchArchive packs the outputs of a synchronous image batch,
+// converted with RawOutput
+func NewImageBatchArchive(requests []*ImageRequest, responses []*ImageResponse) *BatchArchive {
+	archive := newBatchArchive(BatchJobImage, len(responses))
+	for i, response := range responses {
+		archive.Manifest.Items[i].BatchReportRow = syncArchiveRow(i, requests[i].Data, requests[i].IsURL, response)
+		archive.Outputs[i] = response.Output
+	}
+	return archive.count()
+}
+
+func newBatchArchive(kind string, n int) *BatchArchive {
+	return &BatchArchive{
+		Manifest: BatchArchiveManifest{
+			Kind:      kind,
+			Count:     n,
+			CreatedAt: time.Now().UTC(),
+			Items:     make([]BatchArchiveEntry, n),
+		},
+		Outputs: make([][]byte, n),
+	}
+}
+
+// syncArchiveRow describes a converted item of a synchronous batch
+func syncArchiveRow(index int, data string, isURL bool, result any) BatchReportRow {
+	input := describeBatchInput(data, isURL)
+	row := BatchReportRow{
+		Index:     index,
+		Status:    string(BatchItemStatusCompleted),
+		Input:     input.Source,
+		InputSize: input.Size,
+	}
+	row.setResult(result)
+	return row
+}
+
+// count fills in the completed and failed totals and the entry names
+func (a *BatchArchive) count() *BatchArchive {
+	digits := max(3, len(strconv.Itoa(len(a.Outputs)-1)))
+	for i := range a.Manifest.Items {
+		entry := &a.Manifest.Items[i]
+		switch entry.Status {
+		case string(BatchItemStatusCompleted):
+			a.Manifest.Completed++
+		case string(BatchItemStatusFailed):
+			a.Manifest.Failed++
+		}
+		if a.Outputs[i] != nil {
+			entry.File = fmt.Sprintf("%0*d.%s", digits, entry.Index, extensionForContentType(entry.OutputMIME))
+		}
+	}
+	return a
+}
+
+// Write streams the archive as a Zstandard-compressed tar: the manifest
+// first, then one entry per converted output
+func (a *BatchArchive) Write(w io.Writer) error {
+	encoder, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return err
+	}
+	archive := tar.NewWriter(encoder)
+
+	manifest, err := json.MarshalIndent(a.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := a.writeEntry(archive, BatchArchiveManifestName, append(manifest, '\n')); err != nil {
+		return err
+	}
+	for i, entry := range a.Manifest.Items {
+		if entry.File == "" {
+			continue
+		}
+		if err := a.writeEntry(archive, entry.File, a.Outputs[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func (a *BatchArchive) writeEntry(archive *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: a.Manifest.CreatedAt,
+		Format:  tar.FormatPAX,
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(data)
+	return err
+}
+
+// archiveFormat returns the format of the archive uploaded for job, "" for none
+func (bm *BatchJobManager) archiveFormat(job *BatchJob) string {
+	bm.mu.RLock()
+	cfg := bm.reports
+	bm.mu.RUnlock()
+
+	if cfg.Store == nil || !cfg.Store.IsEnabled() {
+		return ""
+	}
+	return job.Options.Archive
+}
+
+// Archive packs the outputs of a finished job; the archive is nil while the
+// job runs
+func (bm *BatchJobManager) Archive(jobID string) (*BatchJob, *BatchArchive, error) {
+	job, err := bm.Get(jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !job.Finished() {
+		return job, nil, nil
+	}
+
+	bm.mu.RLock()
+	errorCode := bm.reports.ErrorCode
+	bm.mu.RUnlock()
+
+	archive, err := job.archive(errorCode)
+	return job, archive, err
+}
+
+// archive packs the outputs of a job copy, decoding them from the results
+func (job *BatchJob) archive(errorCode func(error) string) (*BatchArchive, error) {
+	archive := newBatchArchive(job.Kind, len(job.Items))
+	archive.Manifest.JobID = job.ID
+
+	for i, row := range job.reportRows(errorCode) {
+		archive.Manifest.Items[i].BatchReportRow = row
+
+		var err error
+		switch result := job.Items[i].Result.(type) {
+		case *AudioResponse:
+			archive.Outputs[i], err = decodeOutput(result.Data, result.Compression)
+		case *ImageResponse:
+			archive.Outputs[i], err = decodeOutput(result.Data, result.Compression)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+	return archive.count(), nil
+}
+
+// uploadArchive stores the archive of a finished job in the bucket and
+// links it from the job's status
+func (bm *BatchJobManager) uploadArchive(ctx context.Context, job *BatchJob, format string) {
+	bm.mu.RLock()
+	cfg := bm.reports
+	bm.mu.RUnlock()
+
+	key := cfg.Prefix + job.ID + "." + format
+
+	job.mu.Lock()
+	job.Archive.Key = key
+	job.mu.Unlock()
+
+	var body bytes.Buffer
+	archive, err := job.snapshot().archive(cfg.ErrorCode)
+	if err == nil {
+		err = archive.Write(&body)
+	}
+	upload := cfg.upload(ctx, job.ID, key, format, BatchArchiveContentType, body.Bytes(), err)
+	if upload.Status == BatchReportFailed {
+		log.Printf("Batch job %s: archive upload failed: %s", job.ID, upload.Error)
+	}
+
+	job.mu.Lock()
+	job.Archive = upload
+	job.mu.Unlock()
+}
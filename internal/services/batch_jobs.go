@@ -0,0 +1,550 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrBatchJobNotFound is returned for unknown or expired batch job IDs
+	ErrBatchJobNotFound = errors.New("batch job not found")
+
+	// ErrBatchJobCapacity is returned when BATCH_JOB_MAX_ACTIVE jobs are unfinished
+	ErrBatchJobCapacity = errors.New("maximum active batch jobs reached")
+
+	// ErrBatchJobFinished is returned when cancelling a job that already finished
+	ErrBatchJobFinished = errors.New("batch job already finished")
+
+	// ErrBatchItemTimeout marks items that ran past their item timeout
+	ErrBatchItemTimeout = errors.New("batch item timed out")
+)
+
+// BatchJobStatus represents the aggregate status of a batch job
+type BatchJobStatus string
+
+const (
+	BatchJobStatusQueued    BatchJobStatus = "queued"
+	BatchJobStatusRunning   BatchJobStatus = "running"
+	BatchJobStatusCompleted BatchJobStatus = "completed" // Every item finished; some may have failed
+	BatchJobStatusFailed    BatchJobStatus = "failed"    // Every item failed
+	BatchJobStatusCancelled BatchJobStatus = "cancelled"
+)
+
+// BatchItemStatus represents the status of one item of a batch job
+type BatchItemStatus string
+
+const (
+	BatchItemStatusPending    BatchItemStatus = "pending"
+	BatchItemStatusProcessing BatchItemStatus = "processing"
+	BatchItemStatusCompleted  BatchItemStatus = "completed"
+	BatchItemStatusFailed     BatchItemStatus = "failed"
+	BatchItemStatusCancelled  BatchItemStatus = "cancelled"
+)
+
+// Kinds of batch jobs
+const (
+	BatchJobAudio = "audio"
+	BatchJobImage = "image"
+)
+
+// BatchItem is the progress of one item of a batch job
+type BatchItem struct {
+	Index     int             `json:"index"`
+	Status    BatchItemStatus `json:"status"`
+	StartTime *time.Time      `json:"start_time,omitempty"`
+	EndTime   *time.Time      `json:"end_time,omitempty"`
+	Error     string          `json:"error,omitempty"`
+
+	Err    error `json:"-"` // Conversion error of a failed item
+	Result any   `json:"-"` // *AudioResponse or *ImageResponse of a completed item
+}
+
+// BatchJob tracks an asynchronous batch conversion
+type BatchJob struct {
+	ID        string         `json:"id"`
+	Kind      string         `json:"kind"`
+	Status    BatchJobStatus `json:"status"`
+	Options   BatchOptions   `json:"-"`
+	CreatedAt time.Time      `json:"created_at"`
+	EndTime   *time.Time     `json:"end_time,omitempty"`
+	Items     []BatchItem    `json:"items"`
+	Report    *BatchReport   `json:"report,omitempty"`  // Report uploaded once the job finished
+	Archive   *BatchReport   `json:"archive,omitempty"` // Archive of the outputs uploaded once the job finished
+
+	// Internal fields
+	inputs     []BatchInput
+	cancel     context.CancelFunc
+	done       chan struct{} // Closed once every item finished or the job was cancelled
+	finishOnce sync.Once
+	mu         sync.RWMutex
+}
+
+// BatchJobCounts sums the items of a job by status
+type BatchJobCounts struct {
+	Pending    int
+	Processing int
+	Completed  int
+	Failed     int
+	Cancelled  int
+}
+
+// Counts sums the job's items by status. Call it on the copies Get returns.
+func (job *BatchJob) Counts() BatchJobCounts {
+	var counts BatchJobCounts
+	for _, item := range job.Items {
+		switch item.Status {
+		case BatchItemStatusPending:
+			counts.Pending++
+		case BatchItemStatusProcessing:
+			counts.Processing++
+		case BatchItemStatusCompleted:
+			counts.Completed++
+		case BatchItemStatusFailed:
+			counts.Failed++
+		case BatchItemStatusCancelled:
+			counts.Cancelled++
+		}
+	}
+	return counts
+}
+
+// Finished reports whether the job reached a final status. Call it on the
+// copies Get returns.
+func (job *BatchJob) Finished() bool {
+	switch job.Status {
+	case BatchJobStatusCompleted, BatchJobStatusFailed, BatchJobStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// BatchJobManager runs asynchronous batch conversions. Each job converts its
+// items BatchOptions.Concurrency at a time, every item taking a slot of the
+// shared conversion worker pool like a synchronous request would, and keeps
+// per-item status and results until retention after it finishes.
+type BatchJobManager struct {
+	audioConverter AudioConverterIface
+	imageConverter ImageConverterIface
+	maxActive      int           // Unfinished jobs accepted at once
+	retention      time.Duration // How long finished jobs and their results are kept
+	jobs           map[string]*BatchJob
+	active         int
+	activeByTenant map[string]int // Unfinished jobs of callers with a tier job limit
+	mu             sync.RWMutex
+	wg             sync.WaitGroup
+	cleanupTicker  *time.Ticker
+	stopCleanup    chan bool
+	reports        BatchReportConfig
+}
+
+// NewBatchJobManager creates a batch job manager accepting up to maxActive
+// unfinished jobs and keeping finished ones for retention
+func NewBatchJobManager(audioConverter AudioConverterIface, imageConverter ImageConverterIface, maxActive int, retention time.Duration) *BatchJobManager {
+	if maxActive <= 0 {
+		maxActive = 4 // Default
+	}
+	if retention <= 0 {
+		retention = time.Hour
+	}
+
+	manager := &BatchJobManager{
+		audioConverter: audioConverter,
+		imageConverter: imageConverter,
+		maxActive:      maxActive,
+		retention:      retention,
+		jobs:           make(map[string]*BatchJob),
+		activeByTenant: make(map[string]int),
+		stopCleanup:    make(chan bool),
+	}
+
+	// Start cleanup routine for finished jobs
+	manager.startCleanupRoutine()
+
+	return manager
+}
+
+// SubmitAudio starts converting requests in the background. The job keeps
+// ctx's values but not its cancellation.
+func (bm *BatchJobManager) SubmitAudio(ctx context.Context, requests []*AudioRequest, opts BatchOptions) (*BatchJob, error) {
+	inputs := make([]BatchInput, len(requests))
+	for i, req := range requests {
+		inputs[i] = describeBatchInput(req.Data, req.IsURL)
+	}
+	return bm.submit(ctx, BatchJobAudio, inputs, opts, func(ctx context.Context, index int) (any, error) {
+		return bm.audioConverter.Convert(ctx, requests[index])
+	})
+}
+
+// SubmitImage starts converting requests in the background. The job keeps
+// ctx's values but not its cancellation.
+func (bm *BatchJobManager) SubmitImage(ctx context.Context, requests []*ImageRequest, opts BatchOptions) (*BatchJob, error) {
+	inputs := make([]BatchInput, len(requests))
+	for i, req := range requests {
+		inputs[i] = describeBatchInput(req.Data, req.IsURL)
+	}
+	return bm.submit(ctx, BatchJobImage, inputs, opts, func(ctx context.Context, index int) (any, error) {
+		return bm.imageConverter.Convert(ctx, requests[index])
+	})
+}
+
+// submit registers a job of an item per input and runs it in the background
+func (bm *BatchJobManager) submit(parent context.Context, kind string, inputs []BatchInput, opts BatchOptions, convert func(ctx context.Context, index int) (any, error)) (*BatchJob, error) {
+	bm.mu.Lock()
+	if bm.active >= bm.maxActive {
+		bm.mu.Unlock()
+		return nil, fmt.Errorf("%w (%d)", ErrBatchJobCapacity, bm.maxActive)
+	}
+
+	// The caller's tier may cap the unfinished jobs of each API key
+	tenant := ""
+	if tier := TierFrom(parent); tier != nil && tier.MaxBatchJobs > 0 {
+		tenant = tierTenant(parent)
+		if bm.activeByTenant[tenant] >= tier.MaxBatchJobs {
+			bm.mu.Unlock()
+			return nil, fmt.Errorf("%w: the %s tier allows %d per API key", ErrBatchJobCapacity, tier.Name, tier.MaxBatchJobs)
+		}
+	}
+
+	// Jobs outlive the request that submitted them
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+
+	job := &BatchJob{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Status:    BatchJobStatusQueued,
+		Options:   opts,
+		CreatedAt: time.Now(),
+		Items:     make([]BatchItem, len(inputs)),
+		inputs:    inputs,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	for i := range job.Items {
+		job.Items[i] = BatchItem{Index: i, Status: BatchItemStatusPending}
+	}
+
+	bm.jobs[job.ID] = job
+	bm.active++
+	if tenant != "" {
+		bm.activeByTenant[tenant]++
+	}
+	bm.wg.Add(1)
+	bm.mu.Unlock()
+
+	snapshot := job.snapshot()
+
+	go func() {
+		defer bm.wg.Done()
+		defer cancel()
+
+		bm.run(ctx, job, convert)
+
+		// Free the job's slot before waiters see it finished
+		bm.mu.Lock()
+		bm.active--
+		if tenant != "" {
+			if bm.activeByTenant[tenant]--; bm.activeByTenant[tenant] == 0 {
+				delete(bm.activeByTenant, tenant)
+			}
+		}
+		bm.mu.Unlock()
+
+		// Waiters see the report and archive coming; they are uploaded even
+		// for jobs cancelled by shutdown
+		format, archive := bm.reportFormat(job), bm.archiveFormat(job)
+		job.mu.Lock()
+		if format != "" {
+			job.Report = &BatchReport{Format: format, Status: BatchReportUploading}
+		}
+		if archive != "" {
+			job.Archive = &BatchReport{Format: archive, Status: BatchReportUploading}
+		}
+		job.mu.Unlock()
+
+		job.complete()
+
+		if format != "" {
+			uploadCtx, cancelUpload := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+			bm.uploadReport(uploadCtx, job, format)
+			cancelUpload()
+		}
+		if archive != "" {
+			uploadCtx, cancelUpload := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+			bm.uploadArchive(uploadCtx, job, archive)
+			cancelUpload()
+		}
+	}()
+
+	return snapshot, nil
+}
+
+// run converts the job's items and records each outcome
+func (bm *BatchJobManager) run(ctx context.Context, job *BatchJob, convert func(ctx context.Context, index int) (any, error)) {
+	job.mu.Lock()
+	if job.Status == BatchJobStatusQueued {
+		job.Status = BatchJobStatusRunning
+	}
+	job.mu.Unlock()
+
+	_ = runBatch(ctx, len(job.Items), job.Options, func(itemCtx context.Context, index int) error {
+		now := time.Now()
+		job.mu.Lock()
+		job.Items[index].Status = BatchItemStatusProcessing
+		job.Items[index].StartTime = &now
+		job.mu.Unlock()
+
+		// Images are stamped with the job and item they came from
+		result, err := convert(withAuditReference(itemCtx, job.ID+"/"+strconv.Itoa(index)), index)
+		if err != nil && errors.Is(itemCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fmt.Errorf("%w: %v", ErrBatchItemTimeout, err)
+		}
+		job.recordItem(ctx, index, result, err)
+		return err
+	})
+}
+
+// recordItem stores an item's outcome. Items interrupted by the job's
+// cancellation are cancelled rather than failed.
+func (job *BatchJob) recordItem(jobCtx context.Context, index int, result any, err error) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	item := &job.Items[index]
+	now := time.Now()
+	item.EndTime = &now
+
+	switch {
+	case err == nil:
+		item.Status = BatchItemStatusCompleted
+		item.Result = result
+	case jobCtx.Err() != nil:
+		item.Status = BatchItemStatusCancelled
+	default:
+		item.Status = BatchItemStatusFailed
+		item.Err = err
+		item.Error = err.Error()
+	}
+}
+
+// complete settles the aggregate status once run returned
+func (job *BatchJob) complete() {
+	job.mu.Lock()
+	// Items runBatch never started (the job was cancelled) stay pending
+	for i := range job.Items {
+		if job.Items[i].Status == BatchItemStatusPending {
+			job.Items[i].Status = BatchItemStatusCancelled
+		}
+	}
+
+	if job.Status != BatchJobStatusCancelled {
+		job.Status = BatchJobStatusFailed
+		for _, item := range job.Items {
+			if item.Status == BatchItemStatusCompleted {
+				job.Status = BatchJobStatusCompleted
+				break
+			}
+		}
+	}
+	if job.EndTime == nil {
+		now := time.Now()
+		job.EndTime = &now
+	}
+	job.mu.Unlock()
+
+	job.finishOnce.Do(func() { close(job.done) })
+}
+
+// Get returns a copy of a job, its items and their results
+func (bm *BatchJobManager) Get(jobID string) (*BatchJob, error) {
+	bm.mu.RLock()
+	job, exists := bm.jobs[jobID]
+	bm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrBatchJobNotFound, jobID)
+	}
+
+	return job.snapshot(), nil
+}
+
+// Wait blocks until the job finishes or ctx ends and returns its status at
+// that point. Callers tell the two apart with Finished.
+func (bm *BatchJobManager) Wait(ctx context.Context, jobID string) (*BatchJob, error) {
+	bm.mu.RLock()
+	job, exists := bm.jobs[jobID]
+	bm.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrBatchJobNotFound, jobID)
+	}
+
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+	}
+
+	return job.snapshot(), nil
+}
+
+// Cancel stops a job: items being converted are interrupted and those not
+// started are never converted. Completed items keep their results.
+func (bm *BatchJobManager) Cancel(jobID string) error {
+	bm.mu.RLock()
+	job, exists := bm.jobs[jobID]
+	bm.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrBatchJobNotFound, jobID)
+	}
+
+	job.mu.Lock()
+	if job.Status != BatchJobStatusQueued && job.Status != BatchJobStatusRunning {
+		status := job.Status
+		job.mu.Unlock()
+		return fmt.Errorf("%w (%s)", ErrBatchJobFinished, status)
+	}
+	job.Status = BatchJobStatusCancelled
+	now := time.Now()
+	job.EndTime = &now
+	job.mu.Unlock()
+
+	job.cancel()
+	return nil
+}
+
+// Delete cancels a job if it is still running and forgets it, freeing its results
+func (bm *BatchJobManager) Delete(jobID string) error {
+	if err := bm.Cancel(jobID); err != nil && !errors.Is(err, ErrBatchJobFinished) {
+		return err
+	}
+
+	bm.mu.Lock()
+	delete(bm.jobs, jobID)
+	bm.mu.Unlock()
+
+	return nil
+}
+
+// snapshot returns a copy of the job's public fields and items
+func (job *BatchJob) snapshot() *BatchJob {
+	job.mu.RLock()
+	defer job.mu.RUnlock()
+
+	items := make([]BatchItem, len(job.Items))
+	copy(items, job.Items)
+
+	var report, archive *BatchReport
+	if job.Report != nil {
+		copied := *job.Report
+		report = &copied
+	}
+	if job.Archive != nil {
+		copied := *job.Archive
+		archive = &copied
+	}
+
+	return &BatchJob{
+		ID:        job.ID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		Options:   job.Options,
+		CreatedAt: job.CreatedAt,
+		EndTime:   job.EndTime,
+		Items:     items,
+		Report:    report,
+		Archive:   archive,
+		inputs:    job.inputs,
+	}
+}
+
+// GetStats returns batch job manager statistics
+func (bm *BatchJobManager) GetStats() map[string]interface{} {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	statusCounts := make(map[BatchJobStatus]int)
+	for _, job := range bm.jobs {
+		job.mu.RLock()
+		statusCounts[job.Status]++
+		job.mu.RUnlock()
+	}
+
+	return map[string]interface{}{
+		"total_jobs":    len(bm.jobs),
+		"active_jobs":   bm.active,
+		"max_active":    bm.maxActive,
+		"status_counts": statusCounts,
+	}
+}
+
+// startCleanupRoutine starts a routine to forget jobs past their retention
+func (bm *BatchJobManager) startCleanupRoutine() {
+	bm.cleanupTicker = time.NewTicker(min(bm.retention, time.Minute))
+
+	go func() {
+		for {
+			select {
+			case <-bm.cleanupTicker.C:
+				bm.cleanupOldJobs()
+			case <-bm.stopCleanup:
+				bm.cleanupTicker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// cleanupOldJobs removes jobs that finished more than retention ago
+func (bm *BatchJobManager) cleanupOldJobs() {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	cutoff := time.Now().Add(-bm.retention)
+	removed := 0
+
+	for id, job := range bm.jobs {
+		job.mu.RLock()
+		expired := job.EndTime != nil && job.EndTime.Before(cutoff)
+		job.mu.RUnlock()
+
+		// Cancelled jobs get their EndTime before their items settle
+		if expired {
+			select {
+			case <-job.done:
+				delete(bm.jobs, id)
+				removed++
+			default:
+			}
+		}
+	}
+
+	if removed > 0 {
+		fmt.Printf("🧹 Cleaned up %d expired batch jobs\n", removed)
+	}
+}
+
+// Stop cancels unfinished jobs and waits for their conversions to return
+func (bm *BatchJobManager) Stop() {
+	close(bm.stopCleanup)
+
+	bm.mu.RLock()
+	for _, job := range bm.jobs {
+		job.mu.Lock()
+		if job.Status == BatchJobStatusQueued || job.Status == BatchJobStatusRunning {
+			job.Status = BatchJobStatusCancelled
+			now := time.Now()
+			job.EndTime = &now
+		}
+		job.mu.Unlock()
+		job.cancel()
+	}
+	bm.mu.RUnlock()
+
+	bm.wg.Wait()
+}
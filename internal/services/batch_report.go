@@ -0,0 +1,307 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"whats-convert-api/internal/providers"
+)
+
+// ErrUnsupportedReportFormat is returned for report formats other than jsonl and csv
+var ErrUnsupportedReportFormat = errors.New("unsupported report format")
+
+// Batch report formats
+const (
+	BatchReportJSONL = "jsonl"
+	BatchReportCSV   = "csv"
+	BatchReportNone  = "none" // No report is uploaded, whatever the batch size
+)
+
+// Upload statuses of a batch report
+const (
+	BatchReportUploading = "uploading"
+	BatchReportUploaded  = "uploaded"
+	BatchReportFailed    = "failed"
+)
+
+// batchReportColumns are the CSV header, in the order of BatchReportRow
+var batchReportColumns = []string{
+	"index", "status", "input", "input_size", "input_format",
+	"output_mime", "output_size", "width", "height", "duration",
+	"result_url", "error", "code", "duration_ms",
+}
+
+// BatchReportRow summarizes one item of a finished batch job
+type BatchReportRow struct {
+	Index       int    `json:"index"`
+	Status      string `json:"status"`
+	Input       string `json:"input"`                  // Source URL without its query string, or "base64"
+	InputSize   int64  `json:"input_size,omitempty"`   // Decoded size of base64 inputs
+	InputFormat string `json:"input_format,omitempty"` // MIME type detected from the input's content
+	OutputMIME  string `json:"output_mime,omitempty"`
+	OutputSize  int    `json:"output_size,omitempty"`
+	Width       int    `json:"width,omitempty"`    // Images
+	Height      int    `json:"height,omitempty"`   // Images
+	Duration    int    `json:"duration,omitempty"` // Audio, in seconds
+	ResultURL   string `json:"result_url,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Code        string `json:"code,omitempty"`
+	DurationMs  int64  `json:"duration_ms,omitempty"`
+}
+
+// csvRecord returns the row's fields in batchReportColumns order
+func (r BatchReportRow) csvRecord() []string {
+	number := func(n int64) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.FormatInt(n, 10)
+	}
+	return []string{
+		strconv.Itoa(r.Index), r.Status, r.Input, number(r.InputSize), r.InputFormat,
+		r.OutputMIME, number(int64(r.OutputSize)), number(int64(r.Width)), number(int64(r.Height)), number(int64(r.Duration)),
+		r.ResultURL, r.Error, r.Code, number(r.DurationMs),
+	}
+}
+
+// BatchReport is the upload of a finished job's report or archive to S3
+type BatchReport struct {
+	Format    string     `json:"format" example:"jsonl"`
+	Status    string     `json:"status" example:"uploaded"` // uploading, uploaded or failed
+	Key       string     `json:"key,omitempty" example:"batch-reports/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a.jsonl"`
+	URL       string     `json:"url,omitempty" example:"https://bucket.s3.amazonaws.com/batch-reports/0b6f1f0e-5d3a-4d0c-a7a4-6c8d1f2e3b4a.jsonl?X-Amz-Signature=..."`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2024-03-31T13:03:20Z"` // When a presigned url stops working
+	Error     string     `json:"error,omitempty"`
+}
+
+// BatchReportConfig configures the reports and archives uploaded for
+// finished batch jobs
+type BatchReportConfig struct {
+	Store     *S3Service             // Bucket reports and archives are uploaded to (nil = download only)
+	Prefix    string                 // Key prefix of uploaded reports and archives
+	MinItems  int                    // Jobs of at least this many items get a JSONL report unasked (0 = only on request)
+	LinkTTL   time.Duration          // Lifetime of the presigned report URL
+	ErrorCode func(err error) string // Error code of a failed item, as the API reports it
+}
+
+// SetReports uploads a report of every finished job that asked for one, or
+// has at least cfg.MinItems items, to cfg.Store
+func (bm *BatchJobManager) SetReports(cfg BatchReportConfig) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	bm.reports = cfg
+}
+
+// ParseBatchReportFormat validates a report format; "" is left for the
+// manager to decide
+func ParseBatchReportFormat(format string) (string, error) {
+	switch format = strings.ToLower(strings.TrimSpace(format)); format {
+	case "", BatchReportJSONL, BatchReportCSV, BatchReportNone:
+		return format, nil
+	case "ndjson":
+		return BatchReportJSONL, nil
+	}
+	return "", fmt.Errorf("%w %q (jsonl, csv or none)", ErrUnsupportedReportFormat, format)
+}
+
+// BatchReportContentType returns the MIME type of a report format
+func BatchReportContentType(format string) string {
+	if format == BatchReportCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// BatchInput describes the input of a batch item for its report
+type BatchInput struct {
+	Source string // Source URL without its query string, or "base64"
+	Size   int64  // Decoded size of base64 inputs
+}
+
+// describeBatchInput summarizes a request's data. Query strings and user
+// info are dropped from URLs: they often carry credentials or signatures.
+func describeBatchInput(data string, isURL bool) BatchInput {
+	if isURL {
+		parsed, err := url.Parse(strings.TrimSpace(data))
+		if err != nil {
+			return BatchInput{Source: "url"}
+		}
+		parsed.User, parsed.RawQuery, parsed.ForceQuery, parsed.Fragment = nil, "", false, ""
+		return BatchInput{Source: parsed.String()}
+	}
+
+	if _, payload, found := strings.Cut(data, ";base64,"); found && strings.HasPrefix(data, "data:") {
+		data = payload
+	}
+	data = strings.TrimRight(strings.TrimSpace(data), "=")
+	return BatchInput{Source: "base64", Size: int64(base64.RawStdEncoding.DecodedLen(len(data)))}
+}
+
+// reportFormat returns the format of the report uploaded for job, "" for none
+func (bm *BatchJobManager) reportFormat(job *BatchJob) string {
+	bm.mu.RLock()
+	cfg := bm.reports
+	bm.mu.RUnlock()
+
+	if cfg.Store == nil || !cfg.Store.IsEnabled() {
+		return ""
+	}
+	switch job.Options.Report {
+	case BatchReportJSONL, BatchReportCSV:
+		return job.Options.Report
+	case "":
+		if cfg.MinItems > 0 && len(job.Items) >= cfg.MinItems {
+			return BatchReportJSONL
+		}
+	}
+	return ""
+}
+
+// Report returns a row per item of a finished job
+func (bm *BatchJobManager) Report(jobID string) (*BatchJob, []BatchReportRow, error) {
+	job, err := bm.Get(jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !job.Finished() {
+		return job, nil, nil
+	}
+
+	bm.mu.RLock()
+	errorCode := bm.reports.ErrorCode
+	bm.mu.RUnlock()
+
+	return job, job.reportRows(errorCode), nil
+}
+
+// reportRows summarizes the items of a job copy
+func (job *BatchJob) reportRows(errorCode func(error) string) []BatchReportRow {
+	rows := make([]BatchReportRow, len(job.Items))
+	for i, item := range job.Items {
+		row := BatchReportRow{
+			Index:  item.Index,
+			Status: string(item.Status),
+			Error:  item.Error,
+		}
+		if i < len(job.inputs) {
+			row.Input, row.InputSize = job.inputs[i].Source, job.inputs[i].Size
+		}
+		if item.StartTime != nil && item.EndTime != nil {
+			row.DurationMs = item.EndTime.Sub(*item.StartTime).Milliseconds()
+		}
+		if item.Err != nil && errorCode != nil {
+			row.Code = errorCode(item.Err)
+		}
+
+		row.setResult(item.Result)
+		if item.Status == BatchItemStatusCompleted && job.Options.JobsURL != "" {
+			row.ResultURL = job.Options.JobsURL + job.ID + "/items/" + strconv.Itoa(item.Index)
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// setResult describes the output of a completed item
+func (r *BatchReportRow) setResult(result any) {
+	switch result := result.(type) {
+	case *AudioResponse:
+		r.OutputMIME, r.OutputSize, r.Duration = result.MimeType, result.Size, result.Duration
+		if result.Input != nil {
+			r.InputFormat = result.Input.MIME
+		}
+	case *ImageResponse:
+		r.OutputMIME, r.OutputSize = result.MimeType, result.Size
+		r.Width, r.Height = result.Width, result.Height
+		if result.Input != nil {
+			r.InputFormat = result.Input.MIME
+		}
+	}
+}
+
+// WriteBatchReport encodes rows as JSON lines or CSV
+func WriteBatchReport(w io.Writer, format string, rows []BatchReportRow) error {
+	if format == BatchReportCSV {
+		writer := csv.NewWriter(w)
+		if err := writer.Write(batchReportColumns); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := writer.Write(row.csvRecord()); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadReport stores the report of a finished job in the bucket and links
+// it from the job's status
+func (bm *BatchJobManager) uploadReport(ctx context.Context, job *BatchJob, format string) {
+	bm.mu.RLock()
+	cfg := bm.reports
+	bm.mu.RUnlock()
+
+	key := cfg.Prefix + job.ID + "." + format
+
+	job.mu.Lock()
+	job.Report.Key = key
+	job.mu.Unlock()
+
+	var body bytes.Buffer
+	err := WriteBatchReport(&body, format, job.snapshot().reportRows(cfg.ErrorCode))
+	report := cfg.upload(ctx, job.ID, key, format, BatchReportContentType(format), body.Bytes(), err)
+	if report.Status == BatchReportFailed {
+		log.Printf("Batch job %s: report upload failed: %s", job.ID, report.Error)
+	}
+
+	job.mu.Lock()
+	job.Report = report
+	job.mu.Unlock()
+}
+
+// upload stores a file of a finished job in the bucket; err reports a
+// failure to build body
+func (cfg BatchReportConfig) upload(ctx context.Context, jobID, key, format, contentType string, body []byte, err error) *BatchReport {
+	report := &BatchReport{Format: format, Status: BatchReportFailed, Key: key}
+	if err == nil {
+		var result *providers.UploadResult
+		result, err = cfg.Store.Upload(ctx, key, body, providers.UploadOptions{
+			ContentType: contentType,
+			Metadata:    map[string]string{"batch-job-id": jobID},
+		})
+		if err == nil {
+			report.Status, report.URL = BatchReportUploaded, result.PublicURL
+
+			// A presigned URL works on private buckets as long as the job is kept
+			if grant, shareErr := cfg.Store.Share(ctx, key, cfg.LinkTTL); shareErr == nil {
+				report.URL, report.ExpiresAt = grant.URL, &grant.ExpiresAt
+			}
+		}
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	return report
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"os/exec"
+	"testing"
+
+	"whats-convert-api/internal/pool"
+)
+
+func BenchmarkBase64Decode1MB(b *testing.B) {
+	payload := bytes.Repeat([]byte("whats-convert-api"), 1024*1024/17)
+	encoded := base64.StdEncoding.EncodeToString(payload)
+
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := base64.StdEncoding.DecodeString(encoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBase64DecodePooled1MB(b *testing.B) {
+	payload := bytes.Repeat([]byte("whats-convert-api"), 1024*1024/17)
+	encoded := base64.StdEncoding.EncodeToString(payload)
+	bp := pool.NewBufferPool(4, 2*1024*1024)
+
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, release, err := decodeBase64(context.Background(), bp, encoded)
+		if err != nil {
+			b.Fatal(err)
+		}
+		release()
+	}
+}
+
+func BenchmarkProgressReader4MB(b *testing.B) {
+	payload := make([]byte, 4*1024*1024)
+	buf := make([]byte, 32*1024)
+
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		reader := &progressReader{
+			reader:   bytes.NewReader(payload),
+			total:    int64(len(payload)),
+			callback: func(int64, int64) {},
+		}
+		if _, err := io.CopyBuffer(io.Discard, reader, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProgressReadSeeker4MB(b *testing.B) {
+	payload := make([]byte, 4*1024*1024)
+	buf := make([]byte, 32*1024)
+
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		reader := &progressReadSeeker{
+			reader:   bytes.NewReader(payload),
+			total:    int64(len(payload)),
+			callback: func(int64, int64) {},
+		}
+		if _, err := io.CopyBuffer(io.Discard, reader, buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkConvertImageMock measures the request pipeline without external tools
+func BenchmarkConvertImageMock(b *testing.B) {
+	converter := newBenchImageConverter()
+	converter.SetMockMode(true)
+	req := &ImageRequest{Data: base64.StdEncoding.EncodeToString(tinyPNG())}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := converter.Convert(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertImageTiny(b *testing.B) {
+	requireTool(b, "ffmpeg")
+	converter := newBenchImageConverter()
+	req := &ImageRequest{Data: base64.StdEncoding.EncodeToString(tinyPNG())}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := converter.Convert(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConvertAudioTiny(b *testing.B) {
+	requireTool(b, "ffmpeg")
+	bp := pool.NewBufferPool(4, 1024*1024)
+	converter := NewAudioConverter(pool.NewWorkerPool(1), bp, NewDownloader(bp, 10*1024*1024))
+	req := &AudioRequest{Data: base64.StdEncoding.EncodeToString(tinyWAV())}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := converter.Convert(context.Background(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func newBenchImageConverter() *ImageConverter {
+	bp := pool.NewBufferPool(4, 1024*1024)
+	return NewImageConverter(pool.NewWorkerPool(1), bp, NewDownloader(bp, 10*1024*1024))
+}
+
+// requireTool skips benchmarks of external tools that aren't on PATH
+func requireTool(b *testing.B, tool string) {
+	b.Helper()
+
+	if _, err := exec.LookPath(tool); err != nil {
+		b.Skipf("%s not found", tool)
+	}
+}
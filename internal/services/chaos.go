@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+// ErrInjectedFault marks failures simulated by chaos testing
+var ErrInjectedFault = errors.New("injected fault")
+
+// SetFaultInjection makes the given percentage of conversions fail as if FFmpeg had crashed
+func (ac *AudioConverter) SetFaultInjection(percent int) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.faultPercent = percent
+}
+
+// SetFaultInjection makes the given percentage of conversions fail as if vips/FFmpeg had crashed
+func (ic *ImageConverter) SetFaultInjection(percent int) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.faultPercent = percent
+}
+
+// SetFaultInjection makes the given percentage of conversions fail as if FFmpeg had crashed
+func (vc *VideoConverter) SetFaultInjection(percent int) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.faultPercent = percent
+}
+
+// injectFault returns a simulated FFmpeg failure when the chaos roll hits
+func (ac *AudioConverter) injectFault() error {
+	ac.mu.RLock()
+	percent := ac.faultPercent
+	ac.mu.RUnlock()
+
+	if !chaosRoll(percent) {
+		return nil
+	}
+
+	ac.recordFailure()
+	return fmt.Errorf("ffmpeg conversion failed: %w", ErrInjectedFault)
+}
+
+// injectFault returns a simulated conversion failure when the chaos roll hits
+func (ic *ImageConverter) injectFault() error {
+	ic.mu.RLock()
+	percent := ic.faultPercent
+	ic.mu.RUnlock()
+
+	if !chaosRoll(percent) {
+		return nil
+	}
+
+	ic.recordFailure()
+	return fmt.Errorf("ffmpeg conversion failed: %w", ErrInjectedFault)
+}
+
+// injectFault returns a simulated FFmpeg failure when the chaos roll hits
+func (vc *VideoConverter) injectFault() error {
+	vc.mu.RLock()
+	percent := vc.faultPercent
+	vc.mu.RUnlock()
+
+	if !chaosRoll(percent) {
+		return nil
+	}
+
+	vc.recordFailure()
+	return fmt.Errorf("ffmpeg conversion failed: %w", ErrInjectedFault)
+}
+
+// chaosRoll reports true for roughly percent out of every 100 calls
+func chaosRoll(percent int) bool {
+	return percent > 0 && rand.IntN(100) < percent
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// maxICCProfileSize bounds embedded profiles read from PNG iCCP chunks
+const maxICCProfileSize = 4 * 1024 * 1024
+
+// colorSpace classifies the ICC profile embedded in an input image
+type colorSpace int
+
+const (
+	colorSpaceSRGB      colorSpace = iota // No profile, or an sRGB one: nothing to convert
+	colorSpaceDisplayP3                   // Display P3 (iPhone/Android wide-gamut photos)
+	colorSpaceOther                       // Any other profile: Adobe RGB, BT.2020, CMYK, LUT-based, ...
+)
+
+// iccPrimaries are the D50-adapted red and green colorant X values that tell
+// the common matrix/TRC RGB profiles apart
+var iccPrimaries = []struct {
+	space  colorSpace
+	redX   float64
+	greenX float64
+}{
+	{colorSpaceSRGB, 0.4361, 0.3851},
+	{colorSpaceDisplayP3, 0.5151, 0.2920},
+}
+
+// SetEmbedSRGBProfile embeds an sRGB ICC profile in every JPEG output instead
+// of leaving it untagged. Inputs are converted to sRGB either way.
+func (ic *ImageConverter) SetEmbedSRGBProfile(enabled bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.embedSRGB = enabled
+}
+
+func (ic *ImageConverter) embedsSRGB() bool {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	return ic.embedSRGB
+}
+
+// inputColorSpace classifies the ICC profile embedded in a JPEG, PNG or WebP
+// input. Untagged inputs are treated as sRGB.
+func inputColorSpace(input []byte) colorSpace {
+	profile := embeddedICCProfile(input)
+	if profile == nil {
+		return colorSpaceSRGB
+	}
+	return classifyICCProfile(profile)
+}
+
+// embeddedICCProfile extracts the ICC profile from image headers without decoding pixels
+func embeddedICCProfile(data []byte) []byte {
+	switch {
+	case len(data) > 4 && data[0] == 0xff && data[1] == 0xd8:
+		return jpegICCProfile(data)
+	case len(data) > 8 && string(data[1:4]) == "PNG":
+		return pngICCProfile(data)
+	case len(data) > 16 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return webpICCProfile(data)
+	}
+	return nil
+}
+
+// jpegICCProfile reassembles the APP2 ICC_PROFILE segments, which may be
+// split across several markers numbered from 1
+func jpegICCProfile(data []byte) []byte {
+	const iccMarker = "ICC_PROFILE\x00"
+
+	var chunks [][]byte
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xff {
+			return nil
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xff:
+			// Fill byte before a marker
+			pos++
+			continue
+		case marker == 0xda || marker == 0xd9:
+			// Profiles precede the scan data
+			return joinICCChunks(chunks)
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			// Standalone markers carry no length
+			pos += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil
+		}
+		payload := data[pos+4 : pos+2+length]
+		if marker == 0xe2 && len(payload) > len(iccMarker)+2 && string(payload[:len(iccMarker)]) == iccMarker {
+			seq, count := int(payload[len(iccMarker)]), int(payload[len(iccMarker)+1])
+			if seq < 1 || seq > count {
+				return nil
+			}
+			if chunks == nil {
+				chunks = make([][]byte, count)
+			}
+			if count != len(chunks) {
+				return nil
+			}
+			chunks[seq-1] = payload[len(iccMarker)+2:]
+		}
+		pos += 2 + length
+	}
+
+	return joinICCChunks(chunks)
+}
+
+func joinICCChunks(chunks [][]byte) []byte {
+	if len(chunks) == 0 {
+		return nil
+	}
+	for _, chunk := range chunks {
+		if chunk == nil {
+			return nil
+		}
+	}
+	return bytes.Join(chunks, nil)
+}
+
+// pngICCProfile inflates the iCCP chunk, which must precede the image data
+func pngICCProfile(data []byte) []byte {
+	pos := 8
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) || chunkType == "IDAT" {
+			return nil
+		}
+
+		if chunkType == "iCCP" {
+			// Profile name, NUL, compression method (0 = zlib), compressed profile
+			body := data[pos+8 : pos+8+length]
+			nameEnd := bytes.IndexByte(body, 0)
+			if nameEnd < 0 || nameEnd+2 > len(body) || body[nameEnd+1] != 0 {
+				return nil
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(body[nameEnd+2:]))
+			if err != nil {
+				return nil
+			}
+			defer reader.Close()
+			profile, err := io.ReadAll(io.LimitReader(reader, maxICCProfileSize))
+			if err != nil {
+				return nil
+			}
+			return profile
+		}
+		pos += 12 + length
+	}
+	return nil
+}
+
+// webpICCProfile returns the ICCP chunk of an extended (VP8X) WebP
+func webpICCProfile(data []byte) []byte {
+	pos := 12
+	for pos+8 <= len(data) {
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		if size < 0 || pos+8+size > len(data) {
+			return nil
+		}
+		switch fourCC {
+		case "ICCP":
+			return data[pos+8 : pos+8+size]
+		case "VP8 ", "VP8L", "ANIM":
+			// The profile chunk comes before any image data
+			return nil
+		}
+		pos += 8 + size + size&1
+	}
+	return nil
+}
+
+// classifyICCProfile matches an RGB matrix/TRC profile's colorants against
+// known colour spaces; anything unrecognised is colorSpaceOther
+func classifyICCProfile(profile []byte) colorSpace {
+	if len(profile) < 132 || string(profile[36:40]) != "acsp" {
+		return colorSpaceOther
+	}
+	if string(profile[16:20]) != "RGB " {
+		return colorSpaceOther
+	}
+
+	redX, okRed := iccColorantX(profile, "rXYZ")
+	greenX, okGreen := iccColorantX(profile, "gXYZ")
+	if !okRed || !okGreen {
+		return colorSpaceOther
+	}
+
+	for _, known := range iccPrimaries {
+		if math.Abs(redX-known.redX) < 0.01 && math.Abs(greenX-known.greenX) < 0.01 {
+			return known.space
+		}
+	}
+	return colorSpaceOther
+}
+
+// iccColorantX reads the X component of an XYZType colorant tag
+func iccColorantX(profile []byte, signature string) (float64, bool) {
+	count := int(binary.BigEndian.Uint32(profile[128:132]))
+	for i := 0; i < count; i++ {
+		entry := 132 + i*12
+		if entry+12 > len(profile) {
+			return 0, false
+		}
+		if string(profile[entry:entry+4]) != signature {
+			continue
+		}
+
+		offset := int(binary.BigEndian.Uint32(profile[entry+4 : entry+8]))
+		if offset < 0 || offset+12 > len(profile) || string(profile[offset:offset+4]) != "XYZ " {
+			return 0, false
+		}
+		// s15Fixed16Number
+		raw := int32(binary.BigEndian.Uint32(profile[offset+8 : offset+12]))
+		return float64(raw) / 65536, true
+	}
+	return 0, false
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"whats-convert-api/internal/tracing"
+)
+
+// stderrTailSize caps how much stderr is kept per traced command
+const stderrTailSize = 2048
+
+// CommandRecord describes one external command executed for a request
+type CommandRecord struct {
+	Command    string `json:"command" example:"ffmpeg -hide_banner -loglevel error -i pipe:0 -c:a libopus pipe:1"`
+	ExitCode   int    `json:"exit_code" example:"1"`
+	StderrTail string `json:"stderr_tail,omitempty" example:"pipe:0: Invalid data found when processing input"`
+	DurationMS int64  `json:"duration_ms" example:"42"`
+}
+
+// CommandTrace collects the external commands executed while serving a request.
+// It is safe for concurrent use so batch conversions can share one trace.
+type CommandTrace struct {
+	mu      sync.Mutex
+	records []CommandRecord
+}
+
+// NewCommandTrace creates an empty trace
+func NewCommandTrace() *CommandTrace {
+	return &CommandTrace{}
+}
+
+// Records returns a copy of the commands recorded so far
+func (t *CommandTrace) Records() []CommandRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]CommandRecord(nil), t.records...)
+}
+
+func (t *CommandTrace) add(record CommandRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.records = append(t.records, record)
+}
+
+type commandTraceKey struct{}
+
+// WithCommandTrace returns a context whose external commands are recorded into trace
+func WithCommandTrace(ctx context.Context, trace *CommandTrace) context.Context {
+	return context.WithValue(ctx, commandTraceKey{}, trace)
+}
+
+func commandTraceFrom(ctx context.Context) *CommandTrace {
+	trace, _ := ctx.Value(commandTraceKey{}).(*CommandTrace)
+	return trace
+}
+
+// runCommand executes an external tool with stdin, returning its stdout and stderr.
+// Every execution is recorded into the request's CommandTrace when one is attached,
+// into an exec span named after the tool, and into the probe or encode stage.
+func runCommand(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, []byte, error) {
+	var reader io.Reader
+	if stdin != nil {
+		reader = bytes.NewReader(stdin)
+	}
+	return runCommandReader(ctx, reader, int64(len(stdin)), name, args...)
+}
+
+// runCommandReader is runCommand streaming size bytes of stdin from a reader,
+// so uploads reach the tool without being held in memory
+func runCommandReader(ctx context.Context, stdin io.Reader, size int64, name string, args ...string) ([]byte, []byte, error) {
+	var output bytes.Buffer
+	stderr, err := runCommandStream(ctx, stdin, size, &output, name, args...)
+	return output.Bytes(), stderr, err
+}
+
+// runCommandStream is runCommandReader writing stdout into a writer as the
+// tool produces it, so outputs can be passed on (e.g. to S3) without being
+// held in memory. It returns stderr.
+func runCommandStream(ctx context.Context, stdin io.Reader, size int64, stdout io.Writer, name string, args ...string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "exec "+name,
+		attribute.String("process.executable.name", name),
+		attribute.String("process.command_line", formatCommand(name, args)),
+		attribute.Int64("process.stdin.size", size))
+
+	cmd, cleanup, err := sandboxedCommand(ctx, name, args...)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	defer cleanup()
+
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+
+	output := &countingWriter{w: stdout}
+	var errorBuffer bytes.Buffer
+	cmd.Stdout = output
+	cmd.Stderr = &errorBuffer
+
+	// ffprobe only inspects inputs; every other tool produces output
+	stage := StageEncode
+	if name == "ffprobe" {
+		stage = StageProbe
+	}
+	stopStage := timeStage(ctx, stage)
+
+	start := time.Now()
+	err = cmd.Run()
+	stopStage()
+
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+		if meter := CPUMeterFrom(ctx); meter != nil {
+			meter.add(cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime())
+		}
+	}
+	span.SetAttributes(attribute.Int("process.exit.code", exitCode), attribute.Int64("process.stdout.size", output.n))
+	tracing.End(span, err)
+
+	if trace := commandTraceFrom(ctx); trace != nil {
+		trace.add(CommandRecord{
+			Command:    formatCommand(cmd.Args[0], cmd.Args[1:]),
+			ExitCode:   exitCode,
+			StderrTail: tail(errorBuffer.String(), stderrTailSize),
+			DurationMS: time.Since(start).Milliseconds(),
+		})
+	}
+
+	return errorBuffer.Bytes(), err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// formatCommand renders a command line, quoting arguments that need it
+func formatCommand(name string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, name)
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`()") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// tail returns at most the last n bytes of s
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return "…" + s[len(s)-n:]
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"strings"
+	"time"
+)
+
+// maxCompliantImageSize is the largest JPEG returned untouched; bigger files
+// are re-encoded since WhatsApp would recompress them anyway
+const maxCompliantImageSize = 5 * 1024 * 1024
+
+// SetSkipCompliant sets whether inputs that are already WhatsApp-ready are
+// returned without re-encoding when the request doesn't say otherwise
+func (ac *AudioConverter) SetSkipCompliant(enabled bool) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.skipCompliant = enabled
+}
+
+// SetSkipCompliant sets whether inputs that are already WhatsApp-ready are
+// returned without re-encoding when the request doesn't say otherwise
+func (ic *ImageConverter) SetSkipCompliant(enabled bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.skipCompliant = enabled
+}
+
+func (ac *AudioConverter) shouldSkipCompliant(req *AudioRequest) bool {
+	if req.SkipIfCompliant != nil {
+		return *req.SkipIfCompliant
+	}
+
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+
+	return ac.skipCompliant
+}
+
+func (ic *ImageConverter) shouldSkipCompliant(req *ImageRequest) bool {
+	if req.SkipIfCompliant != nil {
+		return *req.SkipIfCompliant
+	}
+
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	return ic.skipCompliant
+}
+
+// audioProbe is the subset of ffprobe's JSON output used for compliance checks
+type audioProbe struct {
+	Streams []struct {
+		CodecType  string `json:"codec_type"`
+		CodecName  string `json:"codec_name"`
+		SampleRate string `json:"sample_rate"`
+		Channels   int    `json:"channels"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+	} `json:"format"`
+}
+
+// compliantAudio returns the input when it already is a mono 48kHz Opus
+// stream in an Ogg container. Extra streams (cover art, a second track) are
+// dropped by a stream-copy remux. Probe or remux failures report false so
+// the caller falls back to a full conversion.
+func (ac *AudioConverter) compliantAudio(ctx context.Context, input mediaInput) ([]byte, bool) {
+	probeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	output, _, err := input.run(probeCtx, "ffprobe",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-show_entries", "stream=codec_type,codec_name,sample_rate,channels:format=format_name",
+		"-of", "json",
+	)
+	if err != nil {
+		return nil, false
+	}
+
+	var probe audioProbe
+	if err := json.Unmarshal(output, &probe); err != nil || len(probe.Streams) == 0 {
+		return nil, false
+	}
+	if !strings.Contains(probe.Format.FormatName, "ogg") {
+		return nil, false
+	}
+
+	first := probe.Streams[0]
+	if first.CodecType != "audio" || first.CodecName != "opus" || first.Channels != 1 || first.SampleRate != "48000" {
+		return nil, false
+	}
+
+	if len(probe.Streams) == 1 {
+		if input.file != nil || input.path != "" {
+			data, err := input.bytes()
+			return data, err == nil
+		}
+		// The input may live in a pooled buffer released after the response
+		return bytes.Clone(input.data), true
+	}
+
+	remuxed, _, err := input.run(ctx, "ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-map", "0:a:0",
+		"-c", "copy",
+		"-f", "ogg",
+		"pipe:1",
+	)
+	if err != nil || len(remuxed) == 0 {
+		return nil, false
+	}
+
+	return remuxed, true
+}
+
+// compliantImage reports whether the input can be returned as-is: an sRGB
+// JPEG in a colour model WhatsApp renders, within the requested bounds and size.
+// Requests that set an explicit quality or a resize always re-encode.
+func compliantImage(input []byte, req *ImageRequest, explicitQuality bool) (int, int, bool) {
+	if explicitQuality || req.Resize || len(input) > maxCompliantImageSize {
+		return 0, 0, false
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(input))
+	if err != nil || format != "jpeg" {
+		return 0, 0, false
+	}
+	if cfg.Width > req.MaxWidth || cfg.Height > req.MaxHeight {
+		return 0, 0, false
+	}
+
+	// CMYK JPEGs display with inverted or washed-out colours on most clients
+	if cfg.ColorModel == color.CMYKModel {
+		return 0, 0, false
+	}
+
+	// Wide-gamut profiles are lost by clients that strip metadata
+	if inputColorSpace(input) != colorSpaceSRGB {
+		return 0, 0, false
+	}
+
+	return cfg.Width, cfg.Height, true
+}
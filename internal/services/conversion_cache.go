@@ -0,0 +1,335 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"whats-convert-api/internal/redisclient"
+)
+
+// conversionCachePrefix namespaces conversion outputs in Redis
+const conversionCachePrefix = "whats-convert:conversion:"
+
+// ConversionCache remembers conversion outputs by the SHA-256 of the decoded
+// input and the parameters that shape the output, so the same sticker or
+// voice note sent again is answered without running FFmpeg. Outputs are kept
+// in memory and, when a Redis URL is configured, shared through Redis. A nil
+// cache is valid and never hits.
+type ConversionCache struct {
+	memory        *MediaCache
+	redis         *redisclient.Client
+	ttl           time.Duration
+	maxObjectSize int
+
+	hits        atomic.Int64
+	misses      atomic.Int64
+	oversized   atomic.Int64
+	redisHits   atomic.Int64
+	redisErrors atomic.Int64
+}
+
+// ConversionCacheOptions configures a ConversionCache
+type ConversionCacheOptions struct {
+	MaxBytes      int64         // Memory budget for outputs (0 = Redis only)
+	TTL           time.Duration // How long outputs are kept (0 = until evicted)
+	MaxObjectSize int           // Largest output cached, in bytes (0 = any)
+	RedisURL      string        // Share outputs through Redis ("" = memory only)
+}
+
+// ConversionCacheStats reports conversion cache usage
+type ConversionCacheStats struct {
+	Entries       int     `json:"entries" example:"42"`              // Outputs held in memory
+	Bytes         int64   `json:"bytes" example:"5242880"`           // Memory used by those outputs
+	MaxBytes      int64   `json:"max_bytes" example:"67108864"`      // CONVERSION_CACHE_SIZE
+	MaxObjectSize int     `json:"max_object_size" example:"8388608"` // CONVERSION_CACHE_MAX_OBJECT_SIZE
+	TTLSeconds    float64 `json:"ttl_seconds" example:"3600"`        // CONVERSION_CACHE_TTL (0 = until evicted)
+	Hits          int64   `json:"hits" example:"120"`                // Conversions answered from the cache
+	Misses        int64   `json:"misses" example:"380"`              // Conversions that ran
+	HitRate       float64 `json:"hit_rate" example:"0.24"`           // hits / (hits + misses)
+	Oversized     int64   `json:"oversized" example:"3"`             // Outputs over max_object_size, not cached
+	Redis         bool    `json:"redis" example:"false"`             // Outputs are shared through Redis
+	RedisHits     int64   `json:"redis_hits" example:"0"`            // Hits found in Redis but not in memory
+	RedisErrors   int64   `json:"redis_errors" example:"0"`          // Failed Redis lookups and writes
+}
+
+// NewConversionCache creates a cache holding outputs in memory, backed by
+// Redis when opts.RedisURL is set. It returns nil when both are disabled.
+func NewConversionCache(opts ConversionCacheOptions) (*ConversionCache, error) {
+	cache := &ConversionCache{
+		memory:        NewMediaCache(opts.MaxBytes, opts.TTL),
+		ttl:           opts.TTL,
+		maxObjectSize: opts.MaxObjectSize,
+	}
+	if opts.RedisURL != "" {
+		client, err := redisclient.New(opts.RedisURL, 2*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		cache.redis = client
+	}
+	if cache.memory == nil && cache.redis == nil {
+		return nil, nil
+	}
+	return cache, nil
+}
+
+// key identifies a conversion of input with params (the request without its
+// payload and output encoding), or returns "" when the cache is off or the
+// input can't be read
+func (c *ConversionCache) key(kind string, params any, input mediaInput) string {
+	if c == nil {
+		return ""
+	}
+
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n", kind, encoded)
+	if input.file == nil && input.path == "" {
+		hash.Write(input.data)
+	} else {
+		file, err := input.open()
+		if err != nil {
+			return ""
+		}
+		defer file.Close()
+		if _, err := io.Copy(hash, file); err != nil {
+			return ""
+		}
+	}
+
+	return kind + ":" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// get fills response with the cached metadata and returns the cached output
+func (c *ConversionCache) get(ctx context.Context, key string, response any) ([]byte, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+
+	entry, _, ok := c.memory.Get(key)
+	if !ok && c.redis != nil {
+		value, err := c.redis.Get(ctx, conversionCachePrefix+key)
+		switch {
+		case err == nil:
+			entry, ok = []byte(value), true
+			c.redisHits.Add(1)
+			c.memory.Put(key, entry, "")
+		case !errors.Is(err, redisclient.ErrNil):
+			c.redisError("lookup", err)
+		}
+	}
+
+	if ok {
+		// An entry is the response metadata as JSON, a newline, then the
+		// output; JSON never contains a raw newline
+		metadata, output, found := bytes.Cut(entry, []byte("\n"))
+		if found && json.Unmarshal(metadata, response) == nil {
+			c.hits.Add(1)
+			return output, true
+		}
+	}
+
+	c.misses.Add(1)
+	return nil, false
+}
+
+// put stores the metadata and output of a successful conversion
+func (c *ConversionCache) put(ctx context.Context, key string, response any, output []byte) {
+	if c == nil || key == "" {
+		return
+	}
+	if c.maxObjectSize > 0 && len(output) > c.maxObjectSize {
+		c.oversized.Add(1)
+		return
+	}
+
+	metadata, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	entry := make([]byte, 0, len(metadata)+1+len(output))
+	entry = append(append(append(entry, metadata...), '\n'), output...)
+
+	c.memory.Put(key, entry, "")
+	if c.redis != nil {
+		if err := c.redis.Set(ctx, conversionCachePrefix+key, string(entry), c.ttl); err != nil {
+			c.redisError("write", err)
+		}
+	}
+}
+
+// redisError counts a failed Redis command; the conversion goes on without it
+func (c *ConversionCache) redisError(operation string, err error) {
+	if c.redisErrors.Add(1) == 1 {
+		log.Printf("Conversion cache Redis %s failed (further failures are only counted): %v", operation, err)
+	}
+}
+
+// Stats returns current cache usage
+func (c *ConversionCache) Stats() ConversionCacheStats {
+	if c == nil {
+		return ConversionCacheStats{}
+	}
+
+	memory := c.memory.Stats()
+	stats := ConversionCacheStats{
+		Entries:       memory.Entries,
+		Bytes:         memory.Bytes,
+		MaxBytes:      memory.MaxBytes,
+		MaxObjectSize: c.maxObjectSize,
+		TTLSeconds:    c.ttl.Seconds(),
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Oversized:     c.oversized.Load(),
+		Redis:         c.redis != nil,
+		RedisHits:     c.redisHits.Load(),
+		RedisErrors:   c.redisErrors.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// PingRedis checks the Redis connection and returns its round trip. It
+// returns 0 and no error when the cache doesn't use Redis.
+func (c *ConversionCache) PingRedis(ctx context.Context) (time.Duration, error) {
+	if c == nil || c.redis == nil {
+		return 0, nil
+	}
+
+	start := time.Now()
+	if err := c.redis.Ping(ctx); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// UsesRedis reports whether outputs are shared through Redis
+func (c *ConversionCache) UsesRedis() bool {
+	return c != nil && c.redis != nil
+}
+
+// Close closes the Redis connection
+func (c *ConversionCache) Close() {
+	if c != nil && c.redis != nil {
+		c.redis.Close()
+	}
+}
+
+type conversionCacheKey struct{}
+
+// WithConversionCache returns a context whose conversions are answered from,
+// and stored in, the conversion cache (the cache feature flag is on for the
+// caller)
+func WithConversionCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, conversionCacheKey{}, true)
+}
+
+// conversionCacheAllowed reports whether ctx was returned by WithConversionCache
+func conversionCacheAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(conversionCacheKey{}).(bool)
+	return allowed
+}
+
+// SetConversionCache answers repeated conversions from cache
+func (ac *AudioConverter) SetConversionCache(cache *ConversionCache) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.cache = cache
+}
+
+// conversionCache returns the cache ctx's conversion may use, or nil
+func (ac *AudioConverter) conversionCache(ctx context.Context) *ConversionCache {
+	if !conversionCacheAllowed(ctx) {
+		return nil
+	}
+
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+
+	return ac.cache
+}
+
+// SetConversionCache answers repeated image and sticker conversions from cache
+func (ic *ImageConverter) SetConversionCache(cache *ConversionCache) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.cache = cache
+}
+
+// conversionCache returns the cache ctx's conversion may use, or nil
+func (ic *ImageConverter) conversionCache(ctx context.Context) *ConversionCache {
+	if !conversionCacheAllowed(ctx) {
+		return nil
+	}
+
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	return ic.cache
+}
+
+// audioCacheParams is the request without its payload and output encoding,
+// which don't change the converted bytes, plus the loudness filter and
+// encoder options the conversion runs with
+func audioCacheParams(req *AudioRequest, filter string, encoderArgs []string) any {
+	params := *req
+	params.Data, params.IsURL, params.Source, params.InputType = "", false, nil, ""
+	params.DataURI, params.Compress = nil, ""
+	return struct {
+		AudioRequest
+		Filter      string   `json:"filter,omitempty"`
+		EncoderArgs []string `json:"encoder_args,omitempty"`
+	}{params, filter, encoderArgs}
+}
+
+// imageCacheParams is the request without its payload and output encoding
+func imageCacheParams(req *ImageRequest, policy MetadataPolicy) any {
+	params := *req
+	params.Data, params.IsURL, params.Source = "", false, nil
+	params.DataURI, params.Compress = nil, ""
+	// Resize isn't serialized but changes the output, as does the metadata
+	// policy, which instances sharing the cache may not agree on
+	return struct {
+		ImageRequest
+		Resize   bool           `json:"resize"`
+		Metadata MetadataPolicy `json:"metadata_policy"`
+	}{params, req.Resize, policy}
+}
+
+// stickerCacheParams is the request without its payload and output encoding
+func stickerCacheParams(req *StickerRequest) StickerRequest {
+	params := *req
+	params.Data, params.IsURL, params.DataURI = "", false, nil
+	return params
+}
+
+// fromCache completes a cached audio response, writing the output to the
+// request's sink when it has one
+func (r *AudioResponse) fromCache(output []byte, req *AudioRequest) (*AudioResponse, error) {
+	r.Cached = true
+	if req.Sink != nil {
+		if _, err := req.Sink.Write(output); err != nil {
+			return nil, fmt.Errorf("write output: %w", err)
+		}
+		return r, nil
+	}
+	r.setOutput(output, req)
+	return r, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAudioCacheKeyCoversLoudnessAndEncoder(t *testing.T) {
+	cache, err := NewConversionCache(ConversionCacheOptions{MaxBytes: 1 << 20, TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := mediaInput{data: []byte("voice note")}
+	req := &AudioRequest{Normalize: true}
+
+	key := func(filter string, encoderArgs []string) string {
+		return cache.key("audio", audioCacheParams(req, filter, encoderArgs), input)
+	}
+
+	base := key("loudnorm=I=-16:LRA=11:TP=-1.5", []string{"-application", "voip"})
+	if base == "" {
+		t.Fatal("empty cache key")
+	}
+	if base != key("loudnorm=I=-16:LRA=11:TP=-1.5", []string{"-application", "voip"}) {
+		t.Error("same parameters gave different keys")
+	}
+	if base == key("loudnorm=I=-23:LRA=7:TP=-2", []string{"-application", "voip"}) {
+		t.Error("loudness target left out of the key")
+	}
+	if base == key("loudnorm=I=-16:LRA=11:TP=-1.5", []string{"-application", "audio"}) {
+		t.Error("encoder options left out of the key")
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDownloadHostUnavailable is returned without contacting a download host
+// whose circuit breaker is open
+var ErrDownloadHostUnavailable = errors.New("download host unavailable")
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // Downloads go through
+	CircuitOpen     = "open"      // Downloads fail at once until the cooldown passes
+	CircuitHalfOpen = "half_open" // One probe download decides whether to close again
+)
+
+// maxBreakerHosts is the number of failing hosts tracked; healthy hosts
+// aren't tracked at all
+const maxBreakerHosts = 1000
+
+// HostUnavailableError is returned for downloads refused by an open circuit
+type HostUnavailableError struct {
+	Host    string
+	RetryAt time.Time // When the next download may probe the host
+}
+
+func (e *HostUnavailableError) Error() string {
+	return fmt.Sprintf("%v: %s keeps failing, retry after %s", ErrDownloadHostUnavailable, e.Host, e.RetryAt.UTC().Format(time.RFC3339))
+}
+
+func (e *HostUnavailableError) Unwrap() error {
+	return ErrDownloadHostUnavailable
+}
+
+// RetryAfter returns how long until the host may be probed again
+func (e *HostUnavailableError) RetryAfter() time.Duration {
+	return max(time.Until(e.RetryAt), 0)
+}
+
+// HostCircuit is the circuit breaker state of a download host
+type HostCircuit struct {
+	Host     string     `json:"host" example:"cdn.example.com"`
+	State    string     `json:"state" example:"open"`                              // closed, open or half_open
+	Failures int        `json:"failures" example:"5"`                              // Consecutive failed downloads
+	Trips    int64      `json:"trips" example:"2"`                                 // Times the circuit opened
+	Rejected int64      `json:"rejected" example:"37"`                             // Downloads refused while open
+	OpenedAt *time.Time `json:"opened_at,omitempty"`                               // When the circuit last opened
+	RetryAt  *time.Time `json:"retry_at,omitempty" example:"2024-03-31T12:03:50Z"` // When an open circuit lets a probe through
+}
+
+// hostBreakers trips a circuit per download host after threshold consecutive
+// failures (connection errors, timeouts, 429 and 5xx responses), so a
+// flapping CDN fails requests at once instead of holding workers until the
+// download times out.
+// After cooldown one download probes the host: success closes the circuit,
+// failure opens it again.
+type hostBreakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*HostCircuit
+}
+
+func newHostBreakers(threshold int, cooldown time.Duration) *hostBreakers {
+	if threshold <= 0 {
+		return nil
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &hostBreakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		hosts:     make(map[string]*HostCircuit),
+	}
+}
+
+// allow returns a HostUnavailableError while host's circuit is open, and lets
+// a single probe through once the cooldown has passed
+func (b *hostBreakers) allow(host string) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circuit := b.hosts[host]
+	if circuit == nil {
+		return nil
+	}
+	switch circuit.State {
+	case CircuitOpen:
+		if time.Now().Before(*circuit.RetryAt) {
+			circuit.Rejected++
+			return &HostUnavailableError{Host: host, RetryAt: *circuit.RetryAt}
+		}
+		circuit.State = CircuitHalfOpen
+	case CircuitHalfOpen:
+		// A probe is in flight
+		circuit.Rejected++
+		return &HostUnavailableError{Host: host, RetryAt: time.Now().Add(time.Second)}
+	}
+	return nil
+}
+
+// record settles a download allowed through: failed counts against the
+// host, success closes its circuit. Downloads abandoned by the caller count
+// as neither, but free the probe of a half-open circuit.
+func (b *hostBreakers) record(host string, failed, abandoned bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	circuit := b.hosts[host]
+	switch {
+	case abandoned:
+		if circuit != nil && circuit.State == CircuitHalfOpen {
+			circuit.State = CircuitOpen
+		}
+		return
+	case !failed:
+		if circuit == nil {
+			return
+		}
+		if circuit.State == CircuitHalfOpen {
+			log.Printf("✅ Download circuit for %s closed", host)
+		}
+		// Hosts that never tripped are forgotten; the others keep their counters
+		if circuit.Trips == 0 {
+			delete(b.hosts, host)
+			return
+		}
+		circuit.State, circuit.Failures, circuit.RetryAt = CircuitClosed, 0, nil
+		return
+	}
+
+	if circuit == nil {
+		if len(b.hosts) >= maxBreakerHosts {
+			return
+		}
+		circuit = &HostCircuit{Host: host, State: CircuitClosed}
+		b.hosts[host] = circuit
+	}
+	circuit.Failures++
+	if circuit.State == CircuitHalfOpen || (circuit.State == CircuitClosed && circuit.Failures >= b.threshold) {
+		now := time.Now()
+		retryAt := now.Add(b.cooldown)
+		circuit.State, circuit.OpenedAt, circuit.RetryAt = CircuitOpen, &now, &retryAt
+		circuit.Trips++
+		log.Printf("⚡ Download circuit for %s opened after %d consecutive failures, retrying after %s", host, circuit.Failures, b.cooldown)
+	}
+}
+
+// circuits returns the tracked hosts, open circuits first
+func (b *hostBreakers) circuits() []HostCircuit {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	circuits := make([]HostCircuit, 0, len(b.hosts))
+	for _, circuit := range b.hosts {
+		circuits = append(circuits, *circuit)
+	}
+	b.mu.Unlock()
+
+	rank := map[string]int{CircuitOpen: 0, CircuitHalfOpen: 1, CircuitClosed: 2}
+	sort.Slice(circuits, func(i, j int) bool {
+		if rank[circuits[i].State] != rank[circuits[j].State] {
+			return rank[circuits[i].State] < rank[circuits[j].State]
+		}
+		return circuits[i].Host < circuits[j].Host
+	})
+	return circuits
+}
+
+// SetCircuitBreaker opens a host's circuit after threshold consecutive failed
+// downloads from it, failing further downloads at once for cooldown
+// (threshold <= 0 disables the breaker). Call it before serving.
+func (d *Downloader) SetCircuitBreaker(threshold int, cooldown time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.breakers = newHostBreakers(threshold, cooldown)
+}
+
+// Circuits returns the circuit breaker state of every download host that
+// failed recently or tripped its breaker, nil while the breaker is disabled
+func (d *Downloader) Circuits() []HostCircuit {
+	return d.hostBreakers().circuits()
+}
+
+func (d *Downloader) hostBreakers() *hostBreakers {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.breakers
+}
+
+// hostStatusFailed reports whether a download's status counts against its
+// host: overload and server errors do, client errors are the URL's fault
+func hostStatusFailed(status int) bool {
+	return status >= 500 || status == 429
+}
+
+// breakerHost is the key of rawURL's circuit
+func breakerHost(rawURL string) string {
+	return strings.ToLower(urlHost(rawURL))
+}
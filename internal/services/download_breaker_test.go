@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// circuit returns host's tracked circuit, or nil
+func (b *hostBreakers) circuit(host string) *HostCircuit {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if circuit := b.hosts[host]; circuit != nil {
+		copied := *circuit
+		return &copied
+	}
+	return nil
+}
+
+// expireCooldown lets host's open circuit probe now
+func (b *hostBreakers) expireCooldown(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	past := time.Now().Add(-time.Second)
+	b.hosts[host].RetryAt = &past
+}
+
+func TestHostBreakersTransitions(t *testing.T) {
+	const host = "cdn.example.com"
+	b := newHostBreakers(3, time.Hour)
+
+	// Failures below the threshold keep the circuit closed
+	for range 2 {
+		if err := b.allow(host); err != nil {
+			t.Fatalf("allow while closed: %v", err)
+		}
+		b.record(host, true, false)
+	}
+	if c := b.circuit(host); c == nil || c.State != CircuitClosed || c.Failures != 2 {
+		t.Fatalf("after 2 failures: %+v", c)
+	}
+
+	// The third consecutive failure opens it
+	b.allow(host)
+	b.record(host, true, false)
+	c := b.circuit(host)
+	if c.State != CircuitOpen || c.Trips != 1 || c.RetryAt == nil {
+		t.Fatalf("after 3 failures: %+v", c)
+	}
+
+	// Open: refused at once with when to retry
+	err := b.allow(host)
+	var unavailable *HostUnavailableError
+	if !errors.As(err, &unavailable) || !errors.Is(err, ErrDownloadHostUnavailable) {
+		t.Fatalf("allow while open = %v", err)
+	}
+	if unavailable.RetryAfter() <= 0 || b.circuit(host).Rejected != 1 {
+		t.Errorf("retry after %s, rejected %d", unavailable.RetryAfter(), b.circuit(host).Rejected)
+	}
+
+	// Cooldown over: one probe goes through and the circuit is half-open
+	b.expireCooldown(host)
+	if err := b.allow(host); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if state := b.circuit(host).State; state != CircuitHalfOpen {
+		t.Fatalf("state during probe = %s, want %s", state, CircuitHalfOpen)
+	}
+
+	// A failed probe opens it again
+	b.record(host, true, false)
+	if c := b.circuit(host); c.State != CircuitOpen || c.Trips != 2 {
+		t.Fatalf("after failed probe: %+v", c)
+	}
+
+	// A successful probe closes it and resets the failures
+	b.expireCooldown(host)
+	b.allow(host)
+	b.record(host, false, false)
+	c = b.circuit(host)
+	if c.State != CircuitClosed || c.Failures != 0 || c.RetryAt != nil || c.Trips != 2 {
+		t.Fatalf("after successful probe: %+v", c)
+	}
+	if err := b.allow(host); err != nil {
+		t.Errorf("allow after closing: %v", err)
+	}
+}
+
+func TestHostBreakersSuccessForgetsUntrippedHost(t *testing.T) {
+	const host = "flaky.example.com"
+	b := newHostBreakers(3, time.Hour)
+
+	b.record(host, true, false)
+	b.record(host, false, false)
+	if c := b.circuit(host); c != nil {
+		t.Errorf("host that never tripped still tracked: %+v", c)
+	}
+}
+
+func TestHostBreakersSingleProbe(t *testing.T) {
+	const host = "cdn.example.com"
+	b := newHostBreakers(1, time.Hour)
+	b.record(host, true, false)
+	b.expireCooldown(host)
+
+	// Of many concurrent downloads, exactly one is let through as the probe
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if b.allow(host) == nil {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := allowed.Load(); n != 1 {
+		t.Fatalf("%d probes allowed, want 1", n)
+	}
+	if c := b.circuit(host); c.State != CircuitHalfOpen || c.Rejected != 49 {
+		t.Errorf("during probe: %+v", c)
+	}
+}
+
+func TestHostBreakersAbandonedProbe(t *testing.T) {
+	const host = "cdn.example.com"
+	b := newHostBreakers(1, time.Hour)
+	b.record(host, true, false)
+	b.expireCooldown(host)
+
+	if err := b.allow(host); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if err := b.allow(host); err == nil {
+		t.Fatal("second download allowed while the probe is in flight")
+	}
+
+	// The caller gave up on the probe: it counts neither way, and the next
+	// download probes instead of the host staying half-open forever
+	b.record(host, false, true)
+	c := b.circuit(host)
+	if c.State != CircuitOpen || c.Trips != 1 || c.Failures != 1 {
+		t.Fatalf("after abandoned probe: %+v", c)
+	}
+	if err := b.allow(host); err != nil {
+		t.Fatalf("next probe refused: %v", err)
+	}
+	b.record(host, false, false)
+	if state := b.circuit(host).State; state != CircuitClosed {
+		t.Errorf("state after successful probe = %s", state)
+	}
+}
+
+func TestHostBreakersDisabled(t *testing.T) {
+	b := newHostBreakers(0, 0)
+	if b != nil {
+		t.Fatal("threshold 0 returned a breaker")
+	}
+	b.record("host", true, false)
+	if err := b.allow("host"); err != nil || b.circuits() != nil {
+		t.Errorf("disabled breaker: allow %v, circuits %v", err, b.circuits())
+	}
+}
+
+func TestDownloaderCircuitBreaker(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	d := NewDownloader(nil, 0)
+	defer d.Close()
+	d.SetAllowPrivateHosts(true)
+	d.SetCircuitBreaker(2, time.Hour)
+
+	for range 2 {
+		if _, err := d.Download(context.Background(), server.URL+"/media.jpg"); err == nil || errors.Is(err, ErrDownloadHostUnavailable) {
+			t.Fatalf("download = %v, want the upstream error", err)
+		}
+	}
+	if _, err := d.Download(context.Background(), server.URL+"/media.jpg"); !errors.Is(err, ErrDownloadHostUnavailable) {
+		t.Fatalf("download with open circuit = %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("host received %d requests, want 2", n)
+	}
+
+	circuits := d.Circuits()
+	if len(circuits) != 1 || circuits[0].State != CircuitOpen || circuits[0].Rejected != 1 {
+		t.Errorf("circuits = %+v", circuits)
+	}
+
+	// A download cancelled mid-probe frees the probe
+	d.hostBreakers().expireCooldown(circuits[0].Host)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Download(ctx, server.URL+"/media.jpg")
+	if state := d.Circuits()[0].State; state != CircuitOpen {
+		t.Errorf("state after cancelled probe = %s, want %s", state, CircuitOpen)
+	}
+	if _, err := d.Download(context.Background(), server.URL+"/media.jpg"); errors.Is(err, ErrDownloadHostUnavailable) {
+		t.Errorf("probe after a cancelled one refused: %v", err)
+	}
+}
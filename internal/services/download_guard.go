@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	neturl "net/url"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrDownloadAddressForbidden is returned when a download would connect to a
+// loopback, private, link-local or unspecified address
+var ErrDownloadAddressForbidden = errors.New("download address not allowed")
+
+// proxyPorts are the ports proxies listen on when their URL has none, as
+// net/http dials them
+var proxyPorts = map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}
+
+// dialGuard refuses download connections to addresses internal to the host
+// or its network. It checks the address actually dialled, after DNS and on
+// every redirect, so neither a rebinding resolver nor a redirect to
+// 169.254.169.254 gets past it. Proxies are the operator's and are dialled
+// unchecked; they reach the target on our behalf.
+type dialGuard struct {
+	allowPrivate atomic.Bool
+	mu           sync.RWMutex
+	proxies      map[string]bool // host:port of proxies returned by the transport's Proxy
+	checked      *net.Dialer
+	direct       *net.Dialer
+}
+
+func newDialGuard() *dialGuard {
+	g := &dialGuard{
+		proxies: make(map[string]bool),
+		direct:  &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	g.checked = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: g.control}
+	return g
+}
+
+// control runs after resolution, before connecting to address
+func (g *dialGuard) control(network, address string, _ syscall.RawConn) error {
+	if g.allowPrivate.Load() {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrDownloadAddressForbidden, address)
+	}
+	if forbiddenDownloadAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrDownloadAddressForbidden, addrPort.Addr())
+	}
+	return nil
+}
+
+// forbiddenDownloadAddr reports whether addr is loopback, private (RFC 1918
+// or IPv6 ULA), link-local or unspecified
+func forbiddenDownloadAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsUnspecified()
+}
+
+func (g *dialGuard) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	g.mu.RLock()
+	proxy := g.proxies[address]
+	g.mu.RUnlock()
+
+	if proxy {
+		return g.direct.DialContext(ctx, network, address)
+	}
+	return g.checked.DialContext(ctx, network, address)
+}
+
+// trackProxies wraps a transport Proxy func, remembering the proxies it
+// returns so dialContext lets their connections through
+func (g *dialGuard) trackProxies(proxy func(*http.Request) (*neturl.URL, error)) func(*http.Request) (*neturl.URL, error) {
+	return func(req *http.Request) (*neturl.URL, error) {
+		proxyURL, err := proxy(req)
+		if err != nil || proxyURL == nil {
+			return proxyURL, err
+		}
+
+		address := proxyURL.Host
+		if proxyURL.Port() == "" {
+			address = net.JoinHostPort(proxyURL.Hostname(), proxyPorts[proxyURL.Scheme])
+		}
+		g.mu.Lock()
+		g.proxies[address] = true
+		g.mu.Unlock()
+		return proxyURL, nil
+	}
+}
+
+// checkHost refuses host up front when it resolves to a forbidden address, so
+// a URL upload fails before it's queued. It's advisory: the download is
+// checked again as it connects, wherever host resolves to by then.
+func (d *Downloader) checkHost(ctx context.Context, host string) error {
+	if d.guard.allowPrivate.Load() {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil // The download reports hosts that don't resolve
+	}
+	for _, addr := range addrs {
+		if forbiddenDownloadAddr(addr) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrDownloadAddressForbidden, host, addr)
+		}
+	}
+	return nil
+}
+
+// SetAllowPrivateHosts lets downloads reach loopback, private and link-local
+// addresses, for deployments that fetch media from their own network
+func (d *Downloader) SetAllowPrivateHosts(allow bool) {
+	d.guard.allowPrivate.Store(allow)
+	if allow {
+		log.Printf("⚠️  Downloads may reach loopback, private and link-local addresses")
+	}
+}
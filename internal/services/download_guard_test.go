@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"whats-convert-api/internal/pool"
+)
+
+func TestForbiddenDownloadAddr(t *testing.T) {
+	tests := []struct {
+		addr      string
+		forbidden bool
+	}{
+		{"127.0.0.1", true},
+		{"127.8.9.10", true},
+		{"::1", true},
+		{"10.0.0.1", true},
+		{"172.16.5.4", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fd00:ec2::254", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.1.2.3", true},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"2606:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := forbiddenDownloadAddr(netip.MustParseAddr(tt.addr)); got != tt.forbidden {
+				t.Errorf("forbiddenDownloadAddr(%s) = %v, want %v", tt.addr, got, tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestDownloaderRefusesInternalAddresses(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	d := NewDownloader(pool.NewBufferPool(1, 1024), 0)
+	defer d.Close()
+	d.SetCircuitBreaker(1, time.Hour)
+
+	urls := []string{
+		server.URL + "/media.jpg",
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/media.jpg",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]:1/media.jpg",
+		"http://0.0.0.0:1/media.jpg",
+	}
+	for _, url := range urls {
+		if _, err := d.Download(context.Background(), url); !errors.Is(err, ErrDownloadAddressForbidden) {
+			t.Errorf("Download(%s) = %v, want %v", url, err, ErrDownloadAddressForbidden)
+		}
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("internal server received %d requests", n)
+	}
+	// Refusals are the URL's fault and don't trip the host's breaker
+	if circuits := d.Circuits(); len(circuits) != 0 {
+		t.Errorf("circuits = %+v, want none", circuits)
+	}
+
+	d.SetAllowPrivateHosts(true)
+	if body, err := d.Download(context.Background(), server.URL+"/media.jpg"); err != nil || string(body) != "internal" {
+		t.Errorf("Download with private hosts allowed = %q, %v", body, err)
+	}
+}
+
+func TestDownloaderRefusesRedirectToInternalAddress(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect target was fetched")
+	}))
+	defer internal.Close()
+
+	// The proxy stands in for a public host: its connection is trusted, and
+	// it answers every proxied request with a redirect to the internal server
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		http.Redirect(w, r, internal.URL+"/secret", http.StatusFound)
+	}))
+	defer proxy.Close()
+
+	d := NewDownloader(nil, 0)
+	defer d.Close()
+	if err := d.SetProxy(proxy.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := d.Download(context.Background(), "http://media.example.com/photo.jpg")
+	if !errors.Is(err, ErrDownloadAddressForbidden) {
+		t.Fatalf("Download = %v, want %v", err, ErrDownloadAddressForbidden)
+	}
+	if n := proxied.Load(); n != 1 {
+		t.Errorf("proxy received %d requests, want 1", n)
+	}
+}
+
+func TestDownloaderCheckHost(t *testing.T) {
+	d := NewDownloader(nil, 0)
+	defer d.Close()
+	ctx := context.Background()
+
+	for _, host := range []string{"127.0.0.1", "localhost", "169.254.169.254", "::1"} {
+		if err := d.checkHost(ctx, host); !errors.Is(err, ErrDownloadAddressForbidden) {
+			t.Errorf("checkHost(%s) = %v, want %v", host, err, ErrDownloadAddressForbidden)
+		}
+	}
+	if err := d.checkHost(ctx, "203.0.113.7"); err != nil {
+		t.Errorf("checkHost(public) = %v", err)
+	}
+
+	d.SetAllowPrivateHosts(true)
+	if err := d.checkHost(ctx, "127.0.0.1"); err != nil {
+		t.Errorf("checkHost with private hosts allowed = %v", err)
+	}
+}
@@ -3,13 +3,21 @@ package services
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	neturl "net/url"
+	"os"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/http/httpproxy"
+
 	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/tracing"
 )
 
 // Downloader handles HTTP downloads with optimized connection pooling
@@ -17,6 +25,8 @@ type Downloader struct {
 	httpClient *http.Client
 	bufferPool *pool.BufferPool
 	maxSize    int64
+	breakers   *hostBreakers // Per-host circuit breakers (nil = disabled)
+	guard      *dialGuard    // Refuses internal addresses unless allowed
 	mu         sync.RWMutex
 	stats      DownloaderStats
 }
@@ -35,10 +45,13 @@ func NewDownloader(bufferPool *pool.BufferPool, maxSize int64) *Downloader {
 		maxSize = 500 * 1024 * 1024 // 500MB default
 	}
 
+	guard := newDialGuard()
 	return &Downloader{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second, // Aggressive timeout for downloads
 			Transport: &http.Transport{
+				Proxy:                 guard.trackProxies(http.ProxyFromEnvironment), // HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+				DialContext:           guard.dialContext,
 				MaxIdleConns:          100,
 				MaxIdleConnsPerHost:   100,
 				MaxConnsPerHost:       100,
@@ -55,19 +68,88 @@ func NewDownloader(bufferPool *pool.BufferPool, maxSize int64) *Downloader {
 		},
 		bufferPool: bufferPool,
 		maxSize:    maxSize,
+		guard:      guard,
+	}
+}
+
+// SetProxy sends downloads through proxyURL (http, https or socks5; the
+// userinfo authenticates to it) instead of HTTP_PROXY/HTTPS_PROXY. Hosts
+// in NO_PROXY still connect directly. Empty keeps the environment's proxies.
+func (d *Downloader) SetProxy(proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+
+	parsed, err := neturl.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (http, https or socks5)", parsed.Scheme)
 	}
+	if parsed.Host == "" {
+		return fmt.Errorf("proxy URL %q has no host", parsed.Redacted())
+	}
+
+	transport, ok := d.httpClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("downloader transport can't use a proxy")
+	}
+
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+	transport.Proxy = d.guard.trackProxies(func(req *http.Request) (*neturl.URL, error) {
+		return proxyFunc(req.URL)
+	})
+
+	log.Printf("🌐 Downloads go through %s (NO_PROXY: %q)", parsed.Redacted(), noProxy)
+	return nil
 }
 
 // Download fetches content from URL with context support
 func (d *Downloader) Download(ctx context.Context, url string) ([]byte, error) {
+	var result bytes.Buffer
+	if _, err := d.DownloadTo(ctx, url, &result); err != nil {
+		return nil, err
+	}
+	return result.Bytes(), nil
+}
+
+// DownloadTo fetches content from URL into w and returns its size
+func (d *Downloader) DownloadTo(ctx context.Context, url string, w io.Writer) (written int64, err error) {
+	defer timeStage(ctx, StageDownload)()
+
+	ctx, span := tracing.Start(ctx, "download", attribute.String("server.address", urlHost(url)))
+	defer func() {
+		span.SetAttributes(attribute.Int64("download.size", written))
+		tracing.End(span, err)
+	}()
+
 	start := time.Now()
 
 	// Create request with context
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		d.recordFailure()
-		return nil, fmt.Errorf("create request: %w", err)
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+
+	// Hosts that keep failing are refused without waiting for their timeout
+	host, breakers := breakerHost(url), d.hostBreakers()
+	if err := breakers.allow(host); err != nil {
+		return 0, err
 	}
+	hostFailed := false
+	defer func() { breakers.record(host, hostFailed, ctx.Err() != nil) }()
 
 	// Set headers for better compatibility
 	req.Header.Set("User-Agent", "WhatsApp-Media-Converter/1.0")
@@ -77,20 +159,23 @@ func (d *Downloader) Download(ctx context.Context, url string) ([]byte, error) {
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
 		d.recordFailure()
-		return nil, fmt.Errorf("http request: %w", err)
+		// A refused address is the URL's fault, not the host's
+		hostFailed = !errors.Is(err, ErrDownloadAddressForbidden)
+		return 0, fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
 		d.recordFailure()
-		return nil, fmt.Errorf("http status %d", resp.StatusCode)
+		hostFailed = hostStatusFailed(resp.StatusCode)
+		return 0, fmt.Errorf("http status %d", resp.StatusCode)
 	}
 
 	// Check content length if provided
 	if resp.ContentLength > 0 && resp.ContentLength > d.maxSize {
 		d.recordFailure()
-		return nil, fmt.Errorf("content too large: %d bytes (max: %d)", resp.ContentLength, d.maxSize)
+		return 0, fmt.Errorf("content too large: %d bytes (max: %d)", resp.ContentLength, d.maxSize)
 	}
 
 	// Get buffer from pool for efficient copying
@@ -98,14 +183,14 @@ func (d *Downloader) Download(ctx context.Context, url string) ([]byte, error) {
 	defer d.bufferPool.Put(buffer)
 
 	// Read response body with size limit
-	var result bytes.Buffer
 	limitReader := io.LimitReader(resp.Body, d.maxSize)
 
 	// Use buffer for efficient copying
-	written, err := io.CopyBuffer(&result, limitReader, buffer)
+	written, err = io.CopyBuffer(w, limitReader, buffer)
 	if err != nil {
 		d.recordFailure()
-		return nil, fmt.Errorf("read response: %w", err)
+		hostFailed = true
+		return 0, fmt.Errorf("read response: %w", err)
 	}
 
 	// Check if we hit the size limit
@@ -114,14 +199,14 @@ func (d *Downloader) Download(ctx context.Context, url string) ([]byte, error) {
 		var testByte [1]byte
 		if n, _ := resp.Body.Read(testByte[:]); n > 0 {
 			d.recordFailure()
-			return nil, fmt.Errorf("content exceeds maximum size of %d bytes", d.maxSize)
+			return 0, fmt.Errorf("content exceeds maximum size of %d bytes", d.maxSize)
 		}
 	}
 
 	// Record success
 	d.recordSuccess(written, time.Since(start))
 
-	return result.Bytes(), nil
+	return written, nil
 }
 
 // DownloadWithTimeout downloads with a custom timeout
@@ -142,18 +227,27 @@ func (d *Downloader) StreamDownload(ctx context.Context, url string, processor f
 		return fmt.Errorf("create request: %w", err)
 	}
 
+	host, breakers := breakerHost(url), d.hostBreakers()
+	if err := breakers.allow(host); err != nil {
+		return err
+	}
+	hostFailed := false
+	defer func() { breakers.record(host, hostFailed, ctx.Err() != nil) }()
+
 	req.Header.Set("User-Agent", "WhatsApp-Media-Converter/1.0")
 	req.Header.Set("Accept", "*/*")
 
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
 		d.recordFailure()
+		hostFailed = true
 		return fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		d.recordFailure()
+		hostFailed = hostStatusFailed(resp.StatusCode)
 		return fmt.Errorf("http status %d", resp.StatusCode)
 	}
 
@@ -180,6 +274,7 @@ func (d *Downloader) StreamDownload(ctx context.Context, url string, processor f
 
 		if err != nil {
 			d.recordFailure()
+			hostFailed = true
 			return fmt.Errorf("read stream: %w", err)
 		}
 
@@ -281,3 +376,13 @@ func (d *Downloader) Close() {
 		d.httpClient.CloseIdleConnections()
 	}
 }
+
+// urlHost returns the host of rawURL for span attributes, leaving out paths
+// and query strings that may carry credentials
+func urlHost(rawURL string) string {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Encoder variant names, as reported in responses and /stats
+const (
+	EncoderStable    = "stable"
+	EncoderCandidate = "candidate"
+)
+
+// EncoderVariants are the extra FFmpeg output options of an encoder. A
+// candidate set takes CandidatePercent of the conversions, so new flags can
+// be compared against the stable ones on real traffic before switching over.
+// Options are appended after the built-in ones, which they override.
+type EncoderVariants struct {
+	Stable           []string // Options for every conversion outside the candidate share
+	Candidate        []string // Options under evaluation
+	CandidatePercent int      // Share of conversions using Candidate (0-100)
+}
+
+// VariantStats are the metrics of one encoder variant
+type VariantStats struct {
+	Conversions       int64         // Encodes attempted with the variant
+	FailedConversions int64         // Encodes that failed
+	AvgConversionTime time.Duration // Mean time of the successful conversions
+	AvgOutputSize     int64         // Mean output size in bytes
+}
+
+// encoderSplit picks a variant per conversion and keeps its metrics
+type encoderSplit struct {
+	mu       sync.Mutex
+	variants EncoderVariants
+	stats    map[string]*variantTotals
+}
+
+type variantTotals struct {
+	conversions int64
+	failed      int64
+	totalTime   time.Duration
+	outputBytes int64
+}
+
+// set replaces the variants and resets their metrics
+func (s *encoderSplit) set(variants EncoderVariants) error {
+	if variants.CandidatePercent < 0 || variants.CandidatePercent > 100 {
+		return fmt.Errorf("candidate percent %d outside 0..100", variants.CandidatePercent)
+	}
+	if variants.CandidatePercent > 0 && len(variants.Candidate) == 0 {
+		return fmt.Errorf("candidate percent %d set without candidate options", variants.CandidatePercent)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.variants = variants
+	s.stats = nil
+	if variants.CandidatePercent > 0 {
+		s.stats = map[string]*variantTotals{
+			EncoderStable:    {},
+			EncoderCandidate: {},
+		}
+	}
+	return nil
+}
+
+// pick returns the variant for one conversion and its options. The name is
+// empty when no candidate is configured.
+func (s *encoderSplit) pick() (string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.variants.CandidatePercent == 0 {
+		return "", s.variants.Stable
+	}
+	if rand.IntN(100) < s.variants.CandidatePercent {
+		return EncoderCandidate, s.variants.Candidate
+	}
+	return EncoderStable, s.variants.Stable
+}
+
+// record counts one encode with variant; output is its size when it succeeded
+func (s *encoderSplit) record(variant string, duration time.Duration, output int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totals, ok := s.stats[variant]
+	if !ok {
+		return
+	}
+
+	totals.conversions++
+	if err != nil {
+		totals.failed++
+		return
+	}
+	totals.totalTime += duration
+	totals.outputBytes += int64(output)
+}
+
+// snapshot returns the metrics per variant, nil when no candidate is configured
+func (s *encoderSplit) snapshot() map[string]VariantStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats == nil {
+		return nil
+	}
+
+	stats := make(map[string]VariantStats, len(s.stats))
+	for name, totals := range s.stats {
+		variant := VariantStats{
+			Conversions:       totals.conversions,
+			FailedConversions: totals.failed,
+		}
+		if succeeded := totals.conversions - totals.failed; succeeded > 0 {
+			variant.AvgConversionTime = totals.totalTime / time.Duration(succeeded)
+			variant.AvgOutputSize = totals.outputBytes / succeeded
+		}
+		stats[name] = variant
+	}
+	return stats
+}
+
+// SetEncoderVariants sets the extra libopus options of Opus encodes
+func (ac *AudioConverter) SetEncoderVariants(variants EncoderVariants) error {
+	return ac.encoders.set(variants)
+}
+
+// SetEncoderVariants sets the extra libx264 options of video encodes
+func (vc *VideoConverter) SetEncoderVariants(variants EncoderVariants) error {
+	return vc.encoders.set(variants)
+}
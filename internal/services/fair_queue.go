@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+
+	"whats-convert-api/internal/pool"
+)
+
+// workerClaim describes ctx's conversions to the worker pool: their tier's
+// priority and weight, and the tenant (WithTenant) they queue as, so busy
+// workers are shared fairly between API keys instead of going to whoever
+// queued the most
+func workerClaim(ctx context.Context) pool.Claim {
+	claim := pool.Claim{
+		Priority: tierPriority(ctx),
+		Tenant:   TenantID(tenantFrom(ctx)),
+	}
+	if tier := TierFrom(ctx); tier != nil {
+		claim.Weight = tier.Weight
+	}
+	return claim
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"math"
+	"os/exec"
+	"slices"
+	"time"
+)
+
+// Fingerprinting errors
+var (
+	ErrFingerprintUnsupported = errors.New("media can't be fingerprinted")
+	ErrFingerprintUnavailable = errors.New("fingerprinting tool not installed")
+)
+
+// Fingerprint algorithms
+const (
+	FingerprintPHash       = "phash"
+	FingerprintChromaprint = "chromaprint"
+)
+
+const (
+	// phashSize is the edge of the luma plane the DCT runs on
+	phashSize = 32
+
+	// phashLowFrequencies is the edge of the DCT block the hash is read from
+	phashLowFrequencies = 8
+
+	// fingerprintMaxPixels bounds images decoded for a hash (pixel-bomb guard)
+	fingerprintMaxPixels = 100_000_000
+
+	// chromaprintLength is how many seconds of audio fpcalc listens to
+	chromaprintLength = 120
+)
+
+// Fingerprint identifies media perceptually, so the same picture or sound
+// resent after recompression, resizing or a format change can be recognised.
+// Compare hashes of the same algorithm by Hamming distance: for phash, up to
+// about 10 of the 64 bits differ between copies of one image; for
+// chromaprint, up to about 6 of the 32. SHA256 matches byte-identical files only.
+type Fingerprint struct {
+	Kind      string  `json:"kind" example:"image"`                                                              // image or audio
+	Algorithm string  `json:"algorithm" example:"phash"`                                                         // phash (images) or chromaprint (audio)
+	Hash      string  `json:"hash" example:"c3a1e0f0b8d89c8e"`                                                   // Hex perceptual hash: 64 bits for phash, 32 for chromaprint
+	SHA256    string  `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // Of the input bytes
+	Duration  float64 `json:"duration,omitempty" example:"8.2"`                                                  // Seconds of audio fingerprinted
+	Raw       []int32 `json:"raw,omitempty"`                                                                     // Chromaprint sub-fingerprints, for precise comparison (POST /inspect/fingerprint only)
+}
+
+// fingerprintInput fingerprints an input of a sniffed kind. Chromaprint's raw
+// sub-fingerprints are kept only when raw is set, as they add about 8 numbers
+// per second of audio.
+func fingerprintInput(ctx context.Context, input mediaInput, kind string, raw bool) (*Fingerprint, error) {
+	defer timeStage(ctx, StageProbe)()
+
+	checksum, err := inputSHA256(input)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case MediaKindImage:
+		data, err := input.bytes()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := imagePHash(ctx, data)
+		if err != nil {
+			return nil, err
+		}
+		return &Fingerprint{
+			Kind:      MediaKindImage,
+			Algorithm: FingerprintPHash,
+			Hash:      fmt.Sprintf("%016x", hash),
+			SHA256:    checksum,
+		}, nil
+
+	case MediaKindAudio:
+		hash, subprints, duration, err := chromaprint(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		fingerprint := &Fingerprint{
+			Kind:      MediaKindAudio,
+			Algorithm: FingerprintChromaprint,
+			Hash:      fmt.Sprintf("%08x", hash),
+			SHA256:    checksum,
+			Duration:  duration,
+		}
+		if raw {
+			fingerprint.Raw = subprints
+		}
+		return fingerprint, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s inputs are not supported (images and audio are)", ErrFingerprintUnsupported, kind)
+}
+
+// conversionFingerprint fingerprints a conversion's input for its response.
+// A conversion doesn't fail for want of a fingerprint: errors are logged and
+// the response goes without.
+func conversionFingerprint(ctx context.Context, input mediaInput, kind string) *Fingerprint {
+	fingerprint, err := fingerprintInput(ctx, input, kind, false)
+	if err != nil {
+		log.Printf("Fingerprint skipped: %v", err)
+		return nil
+	}
+	return fingerprint
+}
+
+// inputSHA256 hashes an input held in memory, an upload or a spill file
+func inputSHA256(input mediaInput) (string, error) {
+	hash := sha256.New()
+	if input.file == nil && input.path == "" {
+		hash.Write(input.data)
+	} else {
+		file, err := input.open()
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", fmt.Errorf("read input: %w", err)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// imagePHash is the DCT perceptual hash of an image: its luma shrunk to
+// 32×32, transformed, and one bit per low frequency set when the coefficient
+// is above their median. JPEG, PNG and GIF are decoded in process, other
+// formats (WebP, HEIC, TIFF…) by FFmpeg.
+func imagePHash(ctx context.Context, data []byte) (uint64, error) {
+	plane, err := phashPlane(ctx, data)
+	if err != nil {
+		return 0, err
+	}
+
+	coefficients := dct2D(plane, phashSize)
+	low := make([]float64, 0, phashLowFrequencies*phashLowFrequencies)
+	for y := 0; y < phashLowFrequencies; y++ {
+		for x := 0; x < phashLowFrequencies; x++ {
+			low = append(low, coefficients[y*phashSize+x])
+		}
+	}
+
+	// The DC term is the average brightness; it would skew the median
+	sorted := slices.Clone(low[1:])
+	slices.Sort(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, coefficient := range low {
+		if coefficient > median {
+			hash |= 1 << (63 - i)
+		}
+	}
+	return hash, nil
+}
+
+// phashPlane returns the image's luma averaged down to 32×32
+func phashPlane(ctx context.Context, data []byte) ([]float64, error) {
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		if int64(config.Width)*int64(config.Height) > fingerprintMaxPixels {
+			return nil, fmt.Errorf("%w: image has more than %d pixels", ErrFingerprintUnsupported, fingerprintMaxPixels)
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err == nil && !img.Bounds().Empty() {
+			return lumaPlane(img, phashSize, phashSize), nil
+		}
+	}
+
+	output, stderr, err := runCommand(ctx, data, "ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:%d:flags=area,format=gray", phashSize, phashSize),
+		"-f", "rawvideo",
+		"pipe:1",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ffmpeg couldn't decode the image: %v, stderr: %s", ErrFingerprintUnsupported, err, stderr)
+	}
+	if len(output) != phashSize*phashSize {
+		return nil, fmt.Errorf("%w: ffmpeg returned %d bytes of luma", ErrFingerprintUnsupported, len(output))
+	}
+
+	plane := make([]float64, len(output))
+	for i, luma := range output {
+		plane[i] = float64(luma)
+	}
+	return plane, nil
+}
+
+// dct2D is the DCT-II of a size×size plane, rows then columns
+func dct2D(plane []float64, size int) []float64 {
+	cosines := make([]float64, size*size)
+	for k := 0; k < size; k++ {
+		for n := 0; n < size; n++ {
+			cosines[k*size+n] = math.Cos(math.Pi / float64(size) * (float64(n) + 0.5) * float64(k))
+		}
+	}
+
+	rows := make([]float64, size*size)
+	for y := 0; y < size; y++ {
+		for k := 0; k < size; k++ {
+			var sum float64
+			for n := 0; n < size; n++ {
+				sum += plane[y*size+n] * cosines[k*size+n]
+			}
+			rows[y*size+k] = sum
+		}
+	}
+
+	out := make([]float64, size*size)
+	for x := 0; x < size; x++ {
+		for k := 0; k < size; k++ {
+			var sum float64
+			for n := 0; n < size; n++ {
+				sum += rows[n*size+x] * cosines[k*size+n]
+			}
+			out[k*size+x] = sum
+		}
+	}
+	return out
+}
+
+// fpcalcOutput is what "fpcalc -raw -json" prints
+type fpcalcOutput struct {
+	Duration    float64 `json:"duration"`
+	Fingerprint []int32 `json:"fingerprint"`
+}
+
+// chromaprint runs fpcalc on up to two minutes of the input and returns the
+// 32-bit SimHash of its sub-fingerprints (as chromaprint_hash_fingerprint
+// computes it), the sub-fingerprints and the duration of the input
+func chromaprint(ctx context.Context, input mediaInput) (uint32, []int32, float64, error) {
+	if _, err := exec.LookPath("fpcalc"); err != nil {
+		return 0, nil, 0, fmt.Errorf("%w: audio fingerprints need fpcalc (chromaprint)", ErrFingerprintUnavailable)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	output, stderr, err := input.run(ctx, "fpcalc",
+		"-raw",
+		"-signed",
+		"-json",
+		"-length", fmt.Sprint(chromaprintLength),
+		"-",
+	)
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("%w: fpcalc error: %v, stderr: %s", ErrFingerprintUnsupported, err, stderr)
+	}
+
+	var result fpcalcOutput
+	if err := json.Unmarshal(output, &result); err != nil {
+		return 0, nil, 0, fmt.Errorf("parse fpcalc output: %w", err)
+	}
+	if len(result.Fingerprint) == 0 {
+		return 0, nil, 0, fmt.Errorf("%w: the audio is too short to fingerprint", ErrFingerprintUnsupported)
+	}
+
+	return simHash(result.Fingerprint), result.Fingerprint, result.Duration, nil
+}
+
+// simHash sets each bit that is set in more than half of the sub-fingerprints
+func simHash(subprints []int32) uint32 {
+	var votes [32]int
+	for _, subprint := range subprints {
+		for bit := range votes {
+			if uint32(subprint)&(1<<bit) != 0 {
+				votes[bit]++
+			} else {
+				votes[bit]--
+			}
+		}
+	}
+
+	var hash uint32
+	for bit, vote := range votes {
+		if vote > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash
+}
@@ -3,50 +3,121 @@ package services
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/tracing"
 )
 
+// maxImageInputSize is the largest image input accepted
+const maxImageInputSize = 200 * 1024 * 1024 // 200MB
+
 // ImageConverter handles image conversion using libvips or FFmpeg
 type ImageConverter struct {
-	workerPool *pool.WorkerPool
-	bufferPool *pool.BufferPool
-	downloader *Downloader
-	useVips    bool // Whether vips is available
-	mu         sync.RWMutex
-	stats      ImageConverterStats
+	workerPool     *pool.WorkerPool
+	bufferPool     *pool.BufferPool
+	downloader     *Downloader
+	useVips        bool             // Whether vips is available
+	mockMode       bool             // Return canned output without running vips/FFmpeg
+	sourceStore    *SourceStore     // Retains failed inputs for replay (nil = disabled)
+	faultPercent   int              // Chaos testing: percentage of conversions to fail
+	maxPixels      int64            // Reject images with more decoded pixels (0 = unlimited)
+	skipCompliant  bool             // Return ready JPEG inputs without re-encoding
+	qualityCheck   bool             // Score every output against its input
+	minSSIM        float64          // Refuse outputs below this SSIM (0 = no floor)
+	minPSNR        float64          // Refuse outputs below this PSNR in dB (0 = no floor)
+	embedSRGB      bool             // Tag outputs with an sRGB ICC profile
+	background     rgbColor         // Default colour transparent inputs are flattened onto
+	alphaFormat    AlphaFormat      // Output format of preserve_alpha conversions
+	maxUpscale     float64          // Largest enlargement of inputs below min_width/min_height
+	upscaler       []string         // External upscaler command template (nil = Lanczos only)
+	presets        *Presets         // Operator-defined presets (nil = none)
+	cache          *ConversionCache // Outputs of earlier conversions (nil = disabled)
+	metadataPolicy MetadataPolicy   // What happens to the input's EXIF (IMAGE_METADATA_POLICY)
+	stamper        *AuditStamper    // Embeds the caller's audit stamp in outputs (nil = disabled)
+	objects        *S3Service       // Bucket read by requests with a source (nil = S3 disabled)
+	postProcessors *PostProcessors  // External optimisers run on outputs (nil = none)
+	mu             sync.RWMutex
+	stats          ImageConverterStats
 }
 
 // ImageConverterStats tracks conversion metrics
 type ImageConverterStats struct {
-	TotalConversions  int64
-	FailedConversions int64
-	AvgConversionTime time.Duration
-	VipsConversions   int64
-	FFmpegConversions int64
+	TotalConversions   int64
+	FailedConversions  int64
+	AvgConversionTime  time.Duration
+	VipsConversions    int64
+	FFmpegConversions  int64
+	SkippedConversions int64 // Already compliant inputs returned without re-encoding
 }
 
 // ImageRequest represents an image conversion request
 type ImageRequest struct {
-	Data      string `json:"data" example:"data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD"` // base64 or URL
-	IsURL     bool   `json:"is_url" example:"false"`                                            // true if data is URL
-	MaxWidth  int    `json:"max_width" example:"1920"`                                          // Optional: max width (default 1920)
-	MaxHeight int    `json:"max_height" example:"1920"`                                         // Optional: max height (default 1920)
-	Quality   int    `json:"quality" example:"90"`                                              // Optional: JPEG quality 1-100 (default 95)
+	Data      string       `json:"data" example:"data:image/jpeg;base64,/9j/4AAQSkZJRgABAQAAAQABAAD"` // base64 or URL
+	IsURL     bool         `json:"is_url" example:"false"`                                            // true if data is URL
+	Source    *InputSource `json:"source,omitempty"`                                                  // Optional: object in the configured bucket to convert instead of data
+	MaxWidth  int          `json:"max_width" example:"1920"`                                          // Optional: max width (default 1920)
+	MaxHeight int          `json:"max_height" example:"1920"`                                         // Optional: max height (default 1920)
+	MinWidth  int          `json:"min_width,omitempty" example:"640"`                                 // Optional: enlarge smaller inputs to at least this width
+	MinHeight int          `json:"min_height,omitempty" example:"640"`                                // Optional: enlarge smaller inputs to at least this height
+	Quality   int          `json:"quality" example:"90"`                                              // Optional: JPEG quality 1-100 (default 95)
+	DataURI   *bool        `json:"data_uri,omitempty" example:"true"`                                 // Optional: false returns plain base64 (default true)
+	Compress  string       `json:"compress,omitempty" example:"br"`                                   // Optional: br Brotli-compresses the output before base64 encoding
+
+	SkipIfCompliant *bool `json:"skip_if_compliant,omitempty" example:"true"` // Optional: return JPEG input within bounds without re-encoding (default SKIP_COMPLIANT_INPUTS)
+	QualityCheck    *bool `json:"quality_check,omitempty" example:"true"`     // Optional: return SSIM/PSNR of the output against the input (default IMAGE_QUALITY_CHECK)
+
+	Background    string `json:"background,omitempty" example:"#ffffff"`   // Optional: colour transparent areas are flattened onto (default IMAGE_BACKGROUND)
+	PreserveAlpha bool   `json:"preserve_alpha,omitempty" example:"false"` // Optional: keep transparency by returning WebP or PNG (ALPHA_OUTPUT_FORMAT)
+
+	GenerateThumbnail bool `json:"generate_thumbnail,omitempty" example:"true"` // Optional: also return jpeg_thumbnail, the message preview
+
+	MaxFileSizeKB int `json:"max_file_size_kb,omitempty" example:"500"` // Optional: lower the quality until the output fits in this many KB
+
+	Preset string `json:"preset,omitempty" example:"whatsapp_image"` // Optional: PRESETS_FILE image preset filling the fields left unset
+
+	Fingerprint bool `json:"fingerprint,omitempty" example:"true"` // Optional: also return the input's perceptual hash, to spot resent images
+
+	RawOutput bool   `json:"-"` // Set by the HTTP layer: return bytes in Output instead of encoding Data
+	Input     []byte `json:"-"` // Set by the HTTP layer: raw input bytes, used instead of Data
+	Resize    bool   `json:"-"` // Set by the HTTP layer: always honour MaxWidth/MaxHeight (vips doesn't scale)
 }
 
 // ImageResponse represents the conversion response
 type ImageResponse struct {
-	Data   string `json:"data" example:"data:image/jpeg;base64,/9j/4AAQSkZJRgABA"` // base64 jpeg image
-	Width  int    `json:"width" example:"800"`                                     // Image width
-	Height int    `json:"height" example:"600"`                                    // Image height
-	Size   int    `json:"size" example:"20480"`                                    // Size in bytes
+	Data     string `json:"data,omitempty" example:"data:image/jpeg;base64,/9j/4AAQSkZJRgABA"` // base64 jpeg image (data URI unless data_uri is false)
+	MimeType string `json:"mime_type" example:"image/jpeg"`                                    // MIME type of the decoded data (image/webp or image/png with preserve_alpha)
+	Width    int    `json:"width" example:"800"`                                               // Image width
+	Height   int    `json:"height" example:"600"`                                              // Image height
+	Size     int    `json:"size" example:"20480"`                                              // Size in bytes
+	Skipped  bool   `json:"skipped" example:"false"`                                           // Input was already compliant and returned without re-encoding
+	Upscaled bool   `json:"upscaled" example:"false"`                                          // Input was enlarged to reach min_width/min_height
+
+	Compression string `json:"compression,omitempty" example:"br"` // Set when data is Brotli-compressed plain base64
+
+	Quality *QualityScore `json:"quality,omitempty"` // Similarity to the input when quality checking is on
+
+	EncodedQuality int `json:"encoded_quality,omitempty" example:"72"` // Quality the output was encoded at (max_file_size_kb only)
+
+	JPEGThumbnail string `json:"jpeg_thumbnail,omitempty" example:"/9j/4AAQSkZJRgABAQAAAQABAAD"` // Plain base64 JPEG of at most 72px per side and 20KB, for WhatsApp's jpegThumbnail (generate_thumbnail only)
+
+	Input       *MediaType     `json:"input,omitempty"`                  // Format detected from the input's content
+	Metadata    *ImageMetadata `json:"metadata,omitempty"`               // What IMAGE_METADATA_POLICY did with the input's EXIF
+	Fingerprint *Fingerprint   `json:"fingerprint,omitempty"`            // Perceptual hash of the input (fingerprint only)
+	Cached      bool           `json:"cached,omitempty" example:"false"` // Output was served from the conversion cache
+
+	Trace   []CommandRecord `json:"trace,omitempty"`   // External commands executed (debug trace only)
+	Timings *Timings        `json:"timings,omitempty"` // Time spent per stage (debug_timings only)
+
+	Output []byte `json:"-"` // Converted bytes when the request set RawOutput
 }
 
 // NewImageConverter creates a new image converter
@@ -58,17 +129,47 @@ func NewImageConverter(workerPool *pool.WorkerPool, bufferPool *pool.BufferPool,
 	}
 
 	return &ImageConverter{
-		workerPool: workerPool,
-		bufferPool: bufferPool,
-		downloader: downloader,
-		useVips:    useVips,
+		workerPool:  workerPool,
+		bufferPool:  bufferPool,
+		downloader:  downloader,
+		useVips:     useVips,
+		background:  white,
+		alphaFormat: AlphaFormatWebP,
+		maxUpscale:  defaultMaxUpscale,
 	}
 }
 
 // Convert processes an image conversion request
-func (ic *ImageConverter) Convert(ctx context.Context, req *ImageRequest) (*ImageResponse, error) {
+func (ic *ImageConverter) Convert(ctx context.Context, req *ImageRequest) (resp *ImageResponse, err error) {
+	ctx, span := tracing.Start(ctx, "convert.image",
+		attribute.Bool("media.is_url", req.IsURL))
+	defer func() { tracing.End(span, err) }()
+
+	if err := ic.injectFault(); err != nil {
+		return nil, err
+	}
+
+	if err := ic.applyPreset(PresetTypeImage, &req.Preset, req); err != nil {
+		return nil, err
+	}
+
+	if err := checkCompression(req.Compress); err != nil {
+		return nil, err
+	}
+
+	if err := checkSource(req.Source, req.Data); err != nil {
+		return nil, err
+	}
+
+	if ic.isMockMode() {
+		return ic.mockConvert(ctx, req)
+	}
+
 	start := time.Now()
 
+	// An explicit quality asks for a re-encode even when the input is compliant
+	explicitQuality := req.Quality > 0 && req.Quality <= 100
+
 	// Set defaults
 	if req.MaxWidth <= 0 {
 		req.MaxWidth = 1920
@@ -82,9 +183,19 @@ func (ic *ImageConverter) Convert(ctx context.Context, req *ImageRequest) (*Imag
 
 	// Get input data
 	var inputData []byte
-	var err error
 
-	if req.IsURL {
+	if req.Input != nil {
+		inputData = req.Input
+	} else if req.Source != nil {
+		// Read the object from the bucket
+		var input mediaInput
+		input, _, err = objectInput(ctx, ic.objectStore(), nil, req.Source.S3Key, maxImageInputSize)
+		if err != nil {
+			ic.recordFailure()
+			return nil, err
+		}
+		inputData = input.data
+	} else if req.IsURL {
 		// Download from URL
 		inputData, err = ic.downloader.Download(ctx, req.Data)
 		if err != nil {
@@ -92,12 +203,14 @@ func (ic *ImageConverter) Convert(ctx context.Context, req *ImageRequest) (*Imag
 			return nil, fmt.Errorf("download failed: %w", err)
 		}
 	} else {
-		// Decode base64
-		inputData, err = base64.StdEncoding.DecodeString(req.Data)
+		// Decode base64 into a pooled buffer, returned once FFmpeg is done with it
+		var release func()
+		inputData, release, err = decodeBase64(ctx, ic.bufferPool, req.Data)
 		if err != nil {
 			ic.recordFailure()
 			return nil, fmt.Errorf("base64 decode failed: %w", err)
 		}
+		defer release()
 	}
 
 	// Validate input size
@@ -106,56 +219,237 @@ func (ic *ImageConverter) Convert(ctx context.Context, req *ImageRequest) (*Imag
 		return nil, fmt.Errorf("empty input data")
 	}
 
-	if len(inputData) > 200*1024*1024 { // 200MB max for images
+	if len(inputData) > maxImageInputSize {
 		ic.recordFailure()
 		return nil, fmt.Errorf("image file too large: %d bytes", len(inputData))
 	}
 
-	// Convert to JPEG
-	var outputData []byte
-	if ic.useVips {
-		outputData, err = ic.convertWithVips(ctx, inputData, req.Quality)
-		if err == nil {
-			ic.recordVipsSuccess(time.Since(start))
-		} else {
+	// Answer repeated conversions of the same input from cache
+	policy := ic.imageMetadataPolicy()
+	cache := ic.conversionCache(ctx)
+	cacheKey := cache.key("image", imageCacheParams(req, policy), mediaInput{data: inputData})
+	var cached ImageResponse
+	if output, ok := cache.get(ctx, cacheKey, &cached); ok {
+		cached.Cached = true
+		output = ic.stampOutput(ctx, output)
+		cached.Size = len(output)
+		cached.setOutput(output, req)
+		return &cached, nil
+	}
+
+	// Audio gets a clear error instead of a decoder's
+	media := sniffInput(ctx, mediaInput{data: inputData})
+	if err := checkInputKind(media, "an image", MediaKindAudio); err != nil {
+		ic.recordFailure()
+		return nil, err
+	}
+
+	// Reject decompression bombs before decoding
+	if err := ic.checkPixelLimit(ctx, inputData); err != nil {
+		ic.recordFailure()
+		return nil, err
+	}
+
+	// Hashed from the input, so resent copies match whatever they're converted to
+	var fingerprint *Fingerprint
+	if req.Fingerprint {
+		fingerprint = conversionFingerprint(ctx, mediaInput{data: inputData}, MediaKindImage)
+	}
+
+	// Transparent inputs are flattened onto the background unless alpha is preserved
+	background, alphaFormat, err := ic.alphaHandling(req, inputData)
+	if err != nil {
+		ic.recordFailure()
+		return nil, err
+	}
+
+	// Tiny inputs are enlarged so they don't look terrible full-screen
+	upscale := ic.planUpscale(ctx, req, inputData)
+
+	// Return inputs that are already WhatsApp-ready without re-encoding
+	maxBytes := max(req.MaxFileSizeKB, 0) * 1024
+	if maxBytes > 0 && ic.auditStamper() != nil {
+		// Room for the stamp added after encoding
+		maxBytes = max(maxBytes-auditStampReserve, 1)
+	}
+	if upscale == nil && (maxBytes == 0 || len(inputData) <= maxBytes) && ic.shouldSkipCompliant(req) {
+		// Its metadata segments are filtered in place of the encoders'
+		// stripping; a JPEG too odd to filter is re-encoded instead
+		var output []byte
+		var metadata *ImageMetadata
+		width, height, ok := compliantImage(inputData, req, explicitQuality)
+		if ok {
+			output, metadata = filterJPEGMetadata(bytes.Clone(inputData), policy)
+		}
+		if output != nil {
+			output = ic.stampOutput(ctx, output)
+			response := &ImageResponse{
+				MimeType: imageMimeType,
+				Width:    width,
+				Height:   height,
+				Size:     len(output),
+				Skipped:  true,
+				Input:    &media,
+				Metadata: metadata,
+
+				Fingerprint: fingerprint,
+			}
+			if req.GenerateThumbnail {
+				releaseSlot, err := acquireWorker(ctx, ic.workerPool, len(inputData))
+				if err != nil {
+					ic.recordFailure()
+					return nil, fmt.Errorf("waiting for a worker: %w", err)
+				}
+				response.JPEGThumbnail, err = ic.thumbnail(ctx, inputData, background)
+				releaseSlot()
+				if err != nil {
+					ic.recordFailure()
+					return nil, err
+				}
+			}
+			ic.recordSkipped(time.Since(start))
+
+			// A copy: the input may live in a pooled buffer released after the response
+			response.setOutput(output, req)
+
+			return response, nil
+		}
+	}
+
+	// Wait for an encoder slot; small images use the priority lane
+	releaseSlot, err := acquireWorker(ctx, ic.workerPool, len(inputData))
+	if err != nil {
+		ic.recordFailure()
+		return nil, fmt.Errorf("waiting for a worker: %w", err)
+	}
+	defer releaseSlot()
+
+	// Enlarging replaces the bounding-box scale (vips doesn't scale)
+	source, scale := inputData, fitFilter(req.MaxWidth, req.MaxHeight)
+	if upscale != nil {
+		source, scale = ic.upscale(ctx, inputData, upscale)
+	}
+
+	// Encoders strip all metadata; the policy decides what EXIF goes back in
+	exif, metadata := readImageMetadata(inputData, policy)
+
+	// Convert to JPEG, or WebP/PNG when alpha is preserved
+	usedVips := false
+	encode := func(quality int) (output []byte, err error) {
+		usedVips = false
+		defer func() {
+			if err == nil && exif != nil {
+				output, metadata.Retained = embedImageEXIF(output, exif, !usedVips)
+			}
+		}()
+		switch {
+		case alphaFormat != "":
+			output, err = ic.convertAlphaWithFFmpeg(ctx, source, scale, quality, alphaFormat)
+		case ic.useVips && !req.Resize && upscale == nil:
+			if output, err = ic.convertWithVips(ctx, inputData, quality, background); err == nil {
+				usedVips = true
+				return output, nil
+			}
 			// Fallback to FFmpeg if vips fails
-			outputData, err = ic.convertWithFFmpeg(ctx, inputData, req.MaxWidth, req.MaxHeight, req.Quality)
+			output, err = ic.convertWithFFmpeg(ctx, source, scale, quality, background)
+		default:
+			output, err = ic.convertWithFFmpeg(ctx, source, scale, quality, background)
+		}
+		return output, err
+	}
+
+	outputData, err := encode(req.Quality)
+	if err != nil {
+		ic.recordFailure()
+		return nil, ic.retainImage(ctx, req, inputData, fmt.Errorf("conversion failed: %w", err))
+	}
+
+	// Re-encode at lower qualities until the output fits max_file_size_kb
+	encodedQuality := 0
+	if maxBytes > 0 {
+		if alphaFormat == AlphaFormatPNG {
+			// Lossless, so quality can't shrink it
+			if len(outputData) > maxBytes {
+				ic.recordFailure()
+				return nil, targetSizeError(len(outputData), maxBytes)
+			}
+		} else {
+			outputData, encodedQuality, err = fitTargetSize(outputData, req.Quality, maxBytes, encode)
 			if err != nil {
 				ic.recordFailure()
-				return nil, fmt.Errorf("conversion failed: %w", err)
+				if errors.Is(err, ErrTargetSizeUnreachable) {
+					return nil, err
+				}
+				return nil, ic.retainImage(ctx, req, inputData, fmt.Errorf("conversion failed: %w", err))
 			}
-			ic.recordFFmpegSuccess(time.Since(start))
 		}
-	} else {
-		outputData, err = ic.convertWithFFmpeg(ctx, inputData, req.MaxWidth, req.MaxHeight, req.Quality)
-		if err != nil {
-			ic.recordFailure()
-			return nil, fmt.Errorf("conversion failed: %w", err)
-		}
-		ic.recordFFmpegSuccess(time.Since(start))
+	}
+
+	mimeType, format := imageMimeType, "jpeg"
+	if alphaFormat != "" {
+		mimeType, format = alphaFormat.MimeType(), string(alphaFormat)
+	}
+
+	// Squeeze the output with the configured optimisers; they only ever shrink it
+	quality := req.Quality
+	if encodedQuality > 0 {
+		quality = encodedQuality
+	}
+	outputData = ic.outputPostProcessors().Run(ctx, format, outputData, quality)
+
+	// Refuse outputs that lost too much of the input (bad quality/size combinations)
+	score, err := ic.checkQuality(req, inputData, outputData)
+	if err != nil {
+		ic.recordFailure()
+		return nil, err
 	}
 
 	// Get image dimensions (optional)
 	width, height := ic.getImageDimensions(ctx, outputData)
 
-	// Encode output to Data URI JPEG format
-	base64Data := base64.StdEncoding.EncodeToString(outputData)
-	dataURI := fmt.Sprintf("data:image/jpeg;base64,%s", base64Data)
-
 	response := &ImageResponse{
-		Data:   dataURI,
-		Width:  width,
-		Height: height,
-		Size:   len(outputData),
+		MimeType: mimeType,
+		Width:    width,
+		Height:   height,
+		Size:     len(outputData),
+		Upscaled: upscale != nil,
+		Quality:  score,
+		Input:    &media,
+		Metadata: metadata,
+
+		EncodedQuality: encodedQuality,
+		Fingerprint:    fingerprint,
+	}
+	if req.GenerateThumbnail {
+		// Rendered from the output, so it matches what the recipient sees
+		if response.JPEGThumbnail, err = ic.thumbnail(ctx, outputData, background); err != nil {
+			ic.recordFailure()
+			return nil, err
+		}
+	}
+
+	if usedVips {
+		ic.recordVipsSuccess(time.Since(start))
+	} else {
+		ic.recordFFmpegSuccess(time.Since(start))
 	}
+	cache.put(ctx, cacheKey, response, outputData)
+	outputData = ic.stampOutput(ctx, outputData)
+	response.Size = len(outputData)
+	response.setOutput(outputData, req)
 
 	return response, nil
 }
 
 // convertWithVips uses libvips for fast image conversion
-func (ic *ImageConverter) convertWithVips(ctx context.Context, input []byte, quality int) ([]byte, error) {
+func (ic *ImageConverter) convertWithVips(ctx context.Context, input []byte, quality int, background rgbColor) ([]byte, error) {
+	// Tagged wide-gamut inputs are converted to sRGB before the profile goes
+	if embed := ic.embedsSRGB(); embed || inputColorSpace(input) != colorSpaceSRGB {
+		return ic.convertWithVipsICC(ctx, input, quality, embed, background)
+	}
+
 	// vips is significantly faster than ImageMagick for image processing
-	cmd := exec.CommandContext(ctx, "vips",
+	args := []string{
 		"jpegsave_buffer",
 		"-",                            // Input from stdin
 		"-",                            // Output to stdout
@@ -167,20 +461,48 @@ func (ic *ImageConverter) convertWithVips(ctx context.Context, input []byte, qua
 		"--overshoot-deringing",        // Reduce ringing artifacts
 		"--optimize-scans",             // Optimize progressive scan layers
 		"--quant-table=3",              // Use high quality quantization table
-	)
+	}
+	args = append(args, "--background="+background.vips()) // Flatten alpha onto the background
+	args = append(args, vipsConcurrencyArgs()...)
+
+	output, stderr, err := runCommand(ctx, input, "vips", args...)
+	if err != nil {
+		return nil, fmt.Errorf("vips error: %v, stderr: %s", err, stderr)
+	}
 
-	cmd.Stdin = bytes.NewReader(input)
+	if len(output) == 0 {
+		return nil, fmt.Errorf("vips produced no output")
+	}
 
-	var outputBuffer bytes.Buffer
-	var errorBuffer bytes.Buffer
-	cmd.Stdout = &outputBuffer
-	cmd.Stderr = &errorBuffer
+	return output, nil
+}
 
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("vips error: %v, stderr: %s", err, errorBuffer.String())
+// convertWithVipsICC transforms the embedded profile (sRGB if untagged) to
+// sRGB and saves the JPEG with the same options as convertWithVips, keeping
+// only the new sRGB profile when embed is set
+func (ic *ImageConverter) convertWithVipsICC(ctx context.Context, input []byte, quality int, embed bool, background rgbColor) ([]byte, error) {
+	// keep=icc needs vips 8.15+; older versions fail here and fall back to FFmpeg
+	metadata := "strip"
+	if embed {
+		metadata = "keep=icc"
+	}
+
+	args := []string{
+		"icc_transform",
+		"stdin", // Input from stdin
+		fmt.Sprintf(".jpg[Q=%d,optimize_coding,%s,interlace,trellis_quant,overshoot_deringing,optimize_scans,quant_table=3,background=%s]",
+			quality, metadata, background.vips()), // JPEG to stdout
+		"srgb",       // Built-in sRGB output profile
+		"--embedded", // Convert from the input's own profile
+		"--intent=perceptual",
+	}
+	args = append(args, vipsConcurrencyArgs()...)
+
+	output, stderr, err := runCommand(ctx, input, "vips", args...)
+	if err != nil {
+		return nil, fmt.Errorf("vips error: %v, stderr: %s", err, stderr)
 	}
 
-	output := outputBuffer.Bytes()
 	if len(output) == 0 {
 		return nil, fmt.Errorf("vips produced no output")
 	}
@@ -188,45 +510,59 @@ func (ic *ImageConverter) convertWithVips(ctx context.Context, input []byte, qua
 	return output, nil
 }
 
-// convertWithFFmpeg uses FFmpeg as fallback for image conversion
-func (ic *ImageConverter) convertWithFFmpeg(ctx context.Context, input []byte, maxWidth, maxHeight, quality int) ([]byte, error) {
+// convertWithFFmpeg uses FFmpeg as fallback for image conversion. scale is
+// the Lanczos scale filter, usually fitFilter(maxWidth, maxHeight).
+func (ic *ImageConverter) convertWithFFmpeg(ctx context.Context, input []byte, scale string, quality int, background rgbColor) ([]byte, error) {
 	// Calculate quality value for FFmpeg (2-31, lower is better)
 	ffmpegQuality := 31 - (quality * 29 / 100)
 	if ffmpegQuality < 2 {
 		ffmpegQuality = 2
 	}
 
-	// Build scale filter
-	scaleFilter := fmt.Sprintf(
-		"scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease:flags=lanczos",
-		maxWidth, maxHeight,
-	)
+	// FFmpeg ignores ICC profiles, so map Display P3 primaries to sRGB explicitly.
+	// Other profiles (Adobe RGB, CMYK, ...) are only converted by vips.
+	filters := []string{scale}
+	if inputHasAlpha(input) {
+		// mjpeg drops alpha and keeps whatever colour hides under it, so
+		// overlay the image on an opaque canvas of the background colour
+		filters = append([]string{
+			"split[fg][canvas];[canvas]drawbox=c=" + background.ffmpeg() + ":replace=1:t=fill[bg];[bg][fg]overlay=format=auto",
+		}, filters...)
+	}
+	if inputColorSpace(input) == colorSpaceDisplayP3 {
+		filters = append([]string{
+			"colorspace=iprimaries=smpte432:itrc=srgb:ispace=bt470bg:irange=pc:primaries=bt709:trc=srgb:space=bt470bg:range=pc",
+		}, filters...)
+	}
+	if ic.embedsSRGB() {
+		// Attach an sRGB profile for the mjpeg encoder to write (FFmpeg 6.1+)
+		filters = append(filters, "iccgen=color_primaries=bt709:color_trc=iec61966-2-1:force=1")
+	}
 
-	cmd := exec.CommandContext(ctx, "ffmpeg",
+	args := []string{
 		"-hide_banner",
 		"-loglevel", "error",
+	}
+	if limit := ic.pixelLimit(); limit > 0 {
+		// Decoder-level guard for formats the header probe can't read
+		args = append(args, "-max_pixels", strconv.FormatInt(limit, 10))
+	}
+	args = append(args,
 		"-i", "pipe:0", // Input from stdin
-		"-vf", scaleFilter, // Scale filter with Lanczos resampling
+		"-vf", strings.Join(filters, ","), // Colour conversion and Lanczos scaling
 		"-q:v", fmt.Sprintf("%d", ffmpegQuality), // Quality setting
 		"-vcodec", "mjpeg", // JPEG codec
 		"-pix_fmt", "yuvj444p", // High quality pixel format
 		"-f", "image2pipe", // Output format
-		"-threads", "0", // Use all available threads
+		"-threads", ffmpegThreadsArg(), // Per-process thread budget
 		"pipe:1", // Output to stdout
 	)
 
-	cmd.Stdin = bytes.NewReader(input)
-
-	var outputBuffer bytes.Buffer
-	var errorBuffer bytes.Buffer
-	cmd.Stdout = &outputBuffer
-	cmd.Stderr = &errorBuffer
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("ffmpeg error: %v, stderr: %s", err, errorBuffer.String())
+	output, stderr, err := runCommand(ctx, input, "ffmpeg", args...)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg error: %v, stderr: %s", err, stderr)
 	}
 
-	output := outputBuffer.Bytes()
 	if len(output) == 0 {
 		return nil, fmt.Errorf("ffmpeg produced no output")
 	}
@@ -234,44 +570,63 @@ func (ic *ImageConverter) convertWithFFmpeg(ctx context.Context, input []byte, m
 	return output, nil
 }
 
-// convertWithOptimization applies additional optimizations
-func (ic *ImageConverter) convertWithOptimization(ctx context.Context, input []byte, req *ImageRequest) ([]byte, error) {
-	// First pass: Convert and resize
-	resized, err := ic.convertWithFFmpeg(ctx, input, req.MaxWidth, req.MaxHeight, req.Quality)
-	if err != nil {
-		return nil, err
+// convertAlphaWithFFmpeg resizes like convertWithFFmpeg but keeps the alpha
+// channel, encoding lossy WebP or PNG
+func (ic *ImageConverter) convertAlphaWithFFmpeg(ctx context.Context, input []byte, scale string, quality int, format AlphaFormat) ([]byte, error) {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
 	}
-
-	// Second pass: Optimize with jpegoptim if available
-	if _, err := exec.LookPath("jpegoptim"); err == nil {
-		cmd := exec.CommandContext(ctx, "jpegoptim",
-			"--stdin",
-			"--stdout",
-			fmt.Sprintf("--max=%d", req.Quality),
-			"--strip-all",
-			"--all-progressive",
+	if limit := ic.pixelLimit(); limit > 0 {
+		args = append(args, "-max_pixels", strconv.FormatInt(limit, 10))
+	}
+	args = append(args,
+		"-i", "pipe:0", // Input from stdin
+		"-vf", scale, // Scale filter with Lanczos resampling
+		"-frames:v", "1", // First frame of animated inputs
+	)
+	if format == AlphaFormatPNG {
+		args = append(args,
+			"-vcodec", "png",
+			"-pix_fmt", "rgba",
+			"-f", "image2pipe",
 		)
+	} else {
+		args = append(args,
+			"-vcodec", "libwebp",
+			"-quality", strconv.Itoa(quality), // libwebp quality 0-100
+			"-pix_fmt", "yuva420p", // Lossy colour with a lossless alpha plane
+			"-f", "webp",
+		)
+	}
+	args = append(args,
+		"-threads", ffmpegThreadsArg(), // Per-process thread budget
+		"pipe:1", // Output to stdout
+	)
 
-		cmd.Stdin = bytes.NewReader(resized)
-
-		var outputBuffer bytes.Buffer
-		cmd.Stdout = &outputBuffer
+	output, stderr, err := runCommand(ctx, input, "ffmpeg", args...)
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg error: %v, stderr: %s", err, stderr)
+	}
 
-		if err := cmd.Run(); err == nil && outputBuffer.Len() > 0 {
-			return outputBuffer.Bytes(), nil
-		}
+	if len(output) == 0 {
+		return nil, fmt.Errorf("ffmpeg produced no output")
 	}
 
-	return resized, nil
+	return output, nil
 }
 
-// getImageDimensions gets the dimensions of an image
+// getImageDimensions gets the dimensions of an image, from its header when
+// the format is known
 func (ic *ImageConverter) getImageDimensions(ctx context.Context, imageData []byte) (int, int) {
+	if width, height, ok := headerDimensions(imageData); ok {
+		return width, height
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	// Try with ffprobe first
-	cmd := exec.CommandContext(ctx, "ffprobe",
+	output, _, err := runCommand(ctx, imageData, "ffprobe",
 		"-hide_banner",
 		"-loglevel", "error",
 		"-i", "pipe:0",
@@ -279,10 +634,6 @@ func (ic *ImageConverter) getImageDimensions(ctx context.Context, imageData []by
 		"-show_entries", "stream=width,height",
 		"-of", "csv=p=0",
 	)
-
-	cmd.Stdin = bytes.NewReader(imageData)
-
-	output, err := cmd.Output()
 	if err != nil {
 		return 0, 0
 	}
@@ -301,60 +652,32 @@ func (ic *ImageConverter) getImageDimensions(ctx context.Context, imageData []by
 }
 
 // ConvertBatch processes multiple image conversions in parallel
-func (ic *ImageConverter) ConvertBatch(ctx context.Context, requests []*ImageRequest) ([]*ImageResponse, error) {
+func (ic *ImageConverter) ConvertBatch(ctx context.Context, requests []*ImageRequest, opts BatchOptions) ([]*ImageResponse, error) {
 	responses := make([]*ImageResponse, len(requests))
-	errors := make([]error, len(requests))
-	var wg sync.WaitGroup
-
-	for i, req := range requests {
-		wg.Add(1)
-		go func(index int, request *ImageRequest) {
-			defer wg.Done()
-
-			// Create individual context with timeout
-			convertCtx, cancel := context.WithTimeout(ctx, 1*time.Minute)
-			defer cancel()
-
-			resp, err := ic.Convert(convertCtx, request)
-			if err != nil {
-				errors[index] = err
-			} else {
-				responses[index] = resp
-			}
-		}(i, req)
-	}
-
-	wg.Wait()
-
-	// Check for any errors
-	for i, err := range errors {
-		if err != nil {
-			return responses, fmt.Errorf("conversion %d failed: %w", i, err)
-		}
-	}
-
-	return responses, nil
+	err := runBatch(ctx, len(requests), opts, func(ctx context.Context, index int) error {
+		resp, err := ic.Convert(ctx, requests[index])
+		responses[index] = resp
+		return err
+	})
+	return responses, err
 }
 
 // ValidateInput checks if the input data is a valid image
 func (ic *ImageConverter) ValidateInput(ctx context.Context, data []byte) error {
 	// Use ffprobe to validate
-	cmd := exec.CommandContext(ctx, "ffprobe",
+	output, stderr, err := runCommand(ctx, data, "ffprobe",
 		"-hide_banner",
 		"-loglevel", "error",
 		"-i", "pipe:0",
 		"-select_streams", "v:0",
 		"-show_entries", "stream=codec_type",
 	)
-
-	cmd.Stdin = bytes.NewReader(data)
-
-	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("invalid image data: %w", err)
 	}
 
-	if !strings.Contains(string(output), "video") && !strings.Contains(string(output), "image") {
+	combined := string(output) + string(stderr)
+	if !strings.Contains(combined, "video") && !strings.Contains(combined, "image") {
 		return fmt.Errorf("input is not an image")
 	}
 
@@ -380,6 +703,15 @@ func (ic *ImageConverter) recordFFmpegSuccess(duration time.Duration) {
 	ic.updateAvgTime(duration)
 }
 
+func (ic *ImageConverter) recordSkipped(duration time.Duration) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.stats.TotalConversions++
+	ic.stats.SkippedConversions++
+	ic.updateAvgTime(duration)
+}
+
 func (ic *ImageConverter) updateAvgTime(duration time.Duration) {
 	if ic.stats.AvgConversionTime == 0 {
 		ic.stats.AvgConversionTime = duration
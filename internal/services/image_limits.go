@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrPixelLimitExceeded is returned when an image's decoded size exceeds the configured limit
+var ErrPixelLimitExceeded = errors.New("image exceeds the maximum pixel count")
+
+// SetMaxMegapixels rejects images whose width×height exceeds the limit before decoding.
+// Zero or negative disables the check.
+func (ic *ImageConverter) SetMaxMegapixels(megapixels float64) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.maxPixels = int64(megapixels * 1_000_000)
+}
+
+func (ic *ImageConverter) pixelLimit() int64 {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	return ic.maxPixels
+}
+
+// checkPixelLimit probes the image header and rejects decompression bombs
+// (e.g. a few-KB PNG declaring 100000x100000 pixels) before any full decode
+func (ic *ImageConverter) checkPixelLimit(ctx context.Context, input []byte) error {
+	limit := ic.pixelLimit()
+	if limit <= 0 {
+		return nil
+	}
+
+	width, height, err := probeImageDimensions(ctx, input)
+	if err != nil {
+		// Unknown formats are left to the decoder, which enforces -max_pixels
+		return nil
+	}
+
+	if pixels := int64(width) * int64(height); pixels > limit {
+		return fmt.Errorf("%w: %dx%d is %.1f MP, limit is %.1f MP",
+			ErrPixelLimitExceeded, width, height, float64(pixels)/1_000_000, float64(limit)/1_000_000)
+	}
+
+	return nil
+}
+
+// probeImageDimensions reads only the image header: native parsers for
+// JPEG/WebP/PNG/GIF and ffprobe for everything else
+func probeImageDimensions(ctx context.Context, input []byte) (int, int, error) {
+	if width, height, ok := headerDimensions(input); ok {
+		return width, height, nil
+	}
+
+	output, _, err := runCommand(ctx, input, "ffprobe",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=width,height",
+		"-of", "csv=p=0",
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	widthStr, heightStr, found := strings.Cut(strings.TrimSpace(string(output)), ",")
+	if !found {
+		return 0, 0, fmt.Errorf("unexpected ffprobe output %q", output)
+	}
+	width, err := strconv.Atoi(widthStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(heightStr))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return width, height, nil
+}
+
+// webpDimensions parses the canvas size from VP8, VP8L and VP8X WebP headers
+func webpDimensions(data []byte) (int, int, bool) {
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, false
+	}
+
+	chunk := data[12:]
+	switch string(chunk[0:4]) {
+	case "VP8X":
+		// 24-bit canvas width-1 and height-1 after 4 bytes of flags
+		width := int(chunk[12]) | int(chunk[13])<<8 | int(chunk[14])<<16
+		height := int(chunk[15]) | int(chunk[16])<<8 | int(chunk[17])<<16
+		return width + 1, height + 1, true
+
+	case "VP8L":
+		// 14-bit width-1 and height-1 after the 0x2f signature byte
+		if chunk[8] != 0x2f {
+			return 0, 0, false
+		}
+		bits := binary.LittleEndian.Uint32(chunk[9:13])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, true
+
+	case "VP8 ":
+		// Keyframe start code followed by 14-bit width and height
+		if chunk[11] != 0x9d || chunk[12] != 0x01 || chunk[13] != 0x2a {
+			return 0, 0, false
+		}
+		width := int(binary.LittleEndian.Uint16(chunk[14:16]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(chunk[16:18]) & 0x3fff)
+		return width, height, true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// MetadataPolicy is what image conversions do with the input's EXIF (IMAGE_METADATA_POLICY)
+type MetadataPolicy string
+
+// Image metadata policies
+const (
+	MetadataStripAll MetadataPolicy = "strip_all"      // No EXIF, XMP or comments in the output
+	MetadataStripGPS MetadataPolicy = "strip_gps_only" // EXIF kept with its GPS tags erased; XMP dropped, as it may repeat them
+	MetadataRetain   MetadataPolicy = "retain"         // EXIF kept as is, GPS included
+)
+
+// EXIF tags read or rewritten here
+const (
+	exifOrientationTag = 0x0112
+	exifGPSTag         = 0x8825 // IFD0 pointer to the GPS IFD
+)
+
+// exifHeader prefixes EXIF in JPEG APP1 segments (and some WebP EXIF chunks)
+const exifHeader = "Exif\x00\x00"
+
+// ImageMetadata reports what the metadata policy did with an input's EXIF
+type ImageMetadata struct {
+	Policy     MetadataPolicy `json:"policy" example:"strip_gps_only"`
+	EXIF       bool           `json:"exif" example:"true"`        // The input had EXIF
+	GPS        bool           `json:"gps" example:"true"`         // The input's EXIF had a GPS location
+	Retained   bool           `json:"retained" example:"true"`    // EXIF was written to the output
+	GPSRemoved bool           `json:"gps_removed" example:"true"` // The input had a GPS location and the output doesn't
+}
+
+// ParseMetadataPolicy validates an IMAGE_METADATA_POLICY value
+func ParseMetadataPolicy(policy string) (MetadataPolicy, error) {
+	switch p := MetadataPolicy(strings.ToLower(strings.TrimSpace(policy))); p {
+	case MetadataStripAll, MetadataStripGPS, MetadataRetain:
+		return p, nil
+	case "":
+		return MetadataStripAll, nil
+	}
+	return "", fmt.Errorf("unknown image metadata policy %q (strip_all, strip_gps_only or retain)", policy)
+}
+
+// SetMetadataPolicy sets what conversions do with the input's EXIF. Both
+// engines strip all metadata while encoding; under strip_gps_only and
+// retain the input's EXIF is written back into the output afterwards.
+func (ic *ImageConverter) SetMetadataPolicy(policy MetadataPolicy) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.metadataPolicy = policy
+}
+
+func (ic *ImageConverter) imageMetadataPolicy() MetadataPolicy {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	if ic.metadataPolicy == "" {
+		return MetadataStripAll
+	}
+	return ic.metadataPolicy
+}
+
+// readImageMetadata returns the EXIF the policy carries over from input to
+// the output (nil for none) and the report describing it
+func readImageMetadata(input []byte, policy MetadataPolicy) ([]byte, *ImageMetadata) {
+	report := &ImageMetadata{Policy: policy}
+
+	exif := imageEXIF(input)
+	if exif == nil {
+		return nil, report
+	}
+	report.EXIF = true
+	report.GPS = exifHasGPS(exif)
+	report.GPSRemoved = report.GPS && policy != MetadataRetain
+
+	switch policy {
+	case MetadataRetain:
+		return bytes.Clone(exif), report
+	case MetadataStripGPS:
+		// EXIF that can't be parsed can't be vouched GPS-free
+		return stripEXIFGPS(exif), report
+	}
+	return nil, report
+}
+
+// embedImageEXIF writes exif into an encoded JPEG, WebP or PNG. FFmpeg
+// applies the EXIF orientation while decoding, so resetOrientation marks
+// it as done; vips keeps the pixels as stored. ok is false when the
+// output format can't carry it.
+func embedImageEXIF(output, exif []byte, resetOrientation bool) (result []byte, ok bool) {
+	if resetOrientation {
+		exif = setEXIFOrientation(bytes.Clone(exif), 1)
+	}
+
+	switch {
+	case len(output) > 4 && output[0] == 0xff && output[1] == 0xd8:
+		return insertJPEGEXIF(output, exif)
+	case len(output) > 16 && string(output[0:4]) == "RIFF" && string(output[8:12]) == "WEBP":
+		webp, err := setWebPEXIF(output, exif)
+		if err != nil {
+			return output, false
+		}
+		return webp, true
+	case len(output) > 8 && string(output[1:4]) == "PNG":
+		return insertPNGEXIF(output, exif)
+	}
+	return output, false
+}
+
+// filterJPEGMetadata applies the policy to a JPEG returned without
+// re-encoding, editing its segments in place of the encoders
+func filterJPEGMetadata(input []byte, policy MetadataPolicy) ([]byte, *ImageMetadata) {
+	_, report := readImageMetadata(input, policy)
+	if policy == MetadataRetain {
+		report.Retained = report.EXIF
+		return input, report
+	}
+
+	output, ok := rewriteJPEGSegments(input, func(marker byte, payload []byte) []byte {
+		switch {
+		case marker == 0xe1 && policy == MetadataStripGPS && bytes.HasPrefix(payload, []byte(exifHeader)):
+			exif := stripEXIFGPS(payload[len(exifHeader):])
+			if exif == nil {
+				return nil
+			}
+			report.Retained = true
+			return append([]byte(exifHeader), exif...)
+		case marker == 0xe1, marker >= 0xe3 && marker <= 0xed, marker == 0xef, marker == 0xfe:
+			// EXIF, XMP, IPTC, vendor segments and comments
+			return nil
+		}
+		// APP0 (JFIF), APP2 (ICC profile) and APP14 (Adobe colour transform) affect decoding
+		return payload
+	})
+	if !ok {
+		// Not worth guessing at: the caller re-encodes instead
+		return nil, report
+	}
+	return output, report
+}
+
+// imageEXIF returns the TIFF-structured EXIF of a JPEG, PNG or WebP, or nil
+func imageEXIF(data []byte) []byte {
+	switch {
+	case len(data) > 4 && data[0] == 0xff && data[1] == 0xd8:
+		var exif []byte
+		walkJPEGSegments(data, func(marker byte, payload []byte) {
+			if exif == nil && marker == 0xe1 && bytes.HasPrefix(payload, []byte(exifHeader)) {
+				exif = payload[len(exifHeader):]
+			}
+		})
+		return exif
+	case len(data) > 8 && string(data[1:4]) == "PNG":
+		for pos := 8; pos+12 <= len(data); {
+			length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+			chunkType := string(data[pos+4 : pos+8])
+			if length < 0 || pos+12+length > len(data) || chunkType == "IEND" {
+				return nil
+			}
+			if chunkType == "eXIf" {
+				return data[pos+8 : pos+8+length]
+			}
+			pos += 12 + length
+		}
+	case len(data) > 16 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		for pos := 12; pos+8 <= len(data); {
+			size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+			if size < 0 || pos+8+size > len(data) {
+				return nil
+			}
+			if string(data[pos:pos+4]) == "EXIF" {
+				return bytes.TrimPrefix(data[pos+8:pos+8+size], []byte(exifHeader))
+			}
+			pos += 8 + size + size&1
+		}
+	}
+	return nil
+}
+
+// walkJPEGSegments calls visit for each marker segment before the scan data
+// and returns the offset of the start-of-scan marker (0 if never reached)
+func walkJPEGSegments(data []byte, visit func(marker byte, payload []byte)) int {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xff {
+			return 0
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xff:
+			// Fill byte before a marker
+			pos++
+			continue
+		case marker == 0xda:
+			return pos
+		case marker == 0xd9:
+			return 0
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			// Standalone markers carry no length
+			pos += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return 0
+		}
+		visit(marker, data[pos+4:pos+2+length])
+		pos += 2 + length
+	}
+	return 0
+}
+
+// rewriteJPEGSegments rebuilds a JPEG with each segment before the scan
+// replaced by edit's payload, or dropped when it returns nil
+func rewriteJPEGSegments(data []byte, edit func(marker byte, payload []byte) []byte) ([]byte, bool) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	failed := false
+	scan := walkJPEGSegments(data, func(marker byte, payload []byte) {
+		payload = edit(marker, payload)
+		if payload == nil {
+			return
+		}
+		if len(payload)+2 > 0xffff {
+			failed = true
+			return
+		}
+		out.Write([]byte{0xff, marker})
+		binary.Write(out, binary.BigEndian, uint16(len(payload)+2))
+		out.Write(payload)
+	})
+	if scan == 0 || failed {
+		return nil, false
+	}
+	out.Write(data[scan:])
+
+	return out.Bytes(), true
+}
+
+// insertJPEGEXIF adds an APP1 EXIF segment after SOI and any JFIF APP0
+func insertJPEGEXIF(jpeg, exif []byte) ([]byte, bool) {
+	if len(exifHeader)+len(exif)+2 > 0xffff {
+		return jpeg, false
+	}
+
+	pos := 2
+	if len(jpeg) > pos+4 && jpeg[pos] == 0xff && jpeg[pos+1] == 0xe0 {
+		pos += 2 + int(binary.BigEndian.Uint16(jpeg[pos+2:pos+4]))
+		if pos > len(jpeg) {
+			return jpeg, false
+		}
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(jpeg)+len(exif)+10))
+	out.Write(jpeg[:pos])
+	out.Write([]byte{0xff, 0xe1})
+	binary.Write(out, binary.BigEndian, uint16(len(exifHeader)+len(exif)+2))
+	out.WriteString(exifHeader)
+	out.Write(exif)
+	out.Write(jpeg[pos:])
+
+	return out.Bytes(), true
+}
+
+// insertPNGEXIF adds an eXIf chunk before the first IDAT
+func insertPNGEXIF(png, exif []byte) ([]byte, bool) {
+	return insertPNGChunk(png, "eXIf", exif)
+}
+
+// insertPNGChunk adds a chunkType chunk before the first IDAT
+func insertPNGChunk(png []byte, chunkType string, data []byte) ([]byte, bool) {
+	for pos := 8; pos+12 <= len(png); {
+		length := int(binary.BigEndian.Uint32(png[pos : pos+4]))
+		if length < 0 || pos+12+length > len(png) {
+			return png, false
+		}
+		if string(png[pos+4:pos+8]) != "IDAT" {
+			pos += 12 + length
+			continue
+		}
+
+		chunk := make([]byte, 0, len(data)+12)
+		chunk = binary.BigEndian.AppendUint32(chunk, uint32(len(data)))
+		chunk = append(chunk, chunkType...)
+		chunk = append(chunk, data...)
+		chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+
+		out := make([]byte, 0, len(png)+len(chunk))
+		out = append(out, png[:pos]...)
+		out = append(out, chunk...)
+		return append(out, png[pos:]...), true
+	}
+	return png, false
+}
+
+// tiffTypeSizes are the byte sizes of TIFF field types 1 to 13
+var tiffTypeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8, 4}
+
+// tiffIFD0 returns the byte order and IFD0 offset of a TIFF structure
+func tiffIFD0(tiff []byte) (binary.ByteOrder, int, bool) {
+	if len(tiff) < 8 {
+		return nil, 0, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[0:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return nil, 0, false
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return nil, 0, false
+	}
+	return order, ifd, true
+}
+
+// tiffEntry returns the offset of tag's 12-byte entry in the IFD at ifd, or -1
+func tiffEntry(tiff []byte, order binary.ByteOrder, ifd int, tag uint16) int {
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return -1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == tag {
+			return entry
+		}
+	}
+	return -1
+}
+
+// exifHasGPS reports whether IFD0 points to a GPS IFD
+func exifHasGPS(tiff []byte) bool {
+	order, ifd, ok := tiffIFD0(tiff)
+	return ok && tiffEntry(tiff, order, ifd, exifGPSTag) >= 0
+}
+
+// stripEXIFGPS returns a copy of the EXIF with the GPS IFD zeroed out and
+// its pointer removed from IFD0, or nil if the structure can't be parsed.
+// Offsets elsewhere stay valid because nothing moves but IFD0's tail.
+func stripEXIFGPS(exif []byte) []byte {
+	tiff := bytes.Clone(exif)
+	order, ifd, ok := tiffIFD0(tiff)
+	if !ok {
+		return nil
+	}
+	entry := tiffEntry(tiff, order, ifd, exifGPSTag)
+	if entry < 0 {
+		return tiff
+	}
+
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	end := ifd + 2 + count*12
+	if end+4 > len(tiff) {
+		return nil
+	}
+
+	// Erase the GPS IFD and the values it points to
+	if gps := int(order.Uint32(tiff[entry+8 : entry+12])); gps >= 8 && gps+2 <= len(tiff) {
+		gpsCount := int(order.Uint16(tiff[gps : gps+2]))
+		gpsEnd := min(gps+2+gpsCount*12+4, len(tiff))
+		for field := gps + 2; field+12 <= gpsEnd; field += 12 {
+			fieldType, n := int(order.Uint16(tiff[field+2:field+4])), int(order.Uint32(tiff[field+4:field+8]))
+			if fieldType <= 0 || fieldType >= len(tiffTypeSizes) {
+				continue
+			}
+			if size := tiffTypeSizes[fieldType] * n; size > 4 {
+				offset := int(order.Uint32(tiff[field+8 : field+12]))
+				if offset >= 8 && offset < len(tiff) {
+					clear(tiff[offset:min(offset+size, len(tiff))])
+				}
+			}
+		}
+		clear(tiff[gps:gpsEnd])
+	}
+
+	// Drop the pointer: shift the later entries and the next-IFD offset up
+	copy(tiff[entry:], tiff[entry+12:end+4])
+	clear(tiff[end-8 : end+4])
+	order.PutUint16(tiff[ifd:ifd+2], uint16(count-1))
+
+	return tiff
+}
+
+// setEXIFOrientation rewrites the IFD0 orientation tag, if present
+func setEXIFOrientation(tiff []byte, orientation uint16) []byte {
+	order, ifd, ok := tiffIFD0(tiff)
+	if !ok {
+		return tiff
+	}
+	if entry := tiffEntry(tiff, order, ifd, exifOrientationTag); entry >= 0 && order.Uint16(tiff[entry+2:entry+4]) == 3 {
+		order.PutUint16(tiff[entry+8:entry+10], orientation)
+	}
+	return tiff
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+)
+
+// ErrQualityBelowThreshold is returned when the converted image degrades
+// below the configured SSIM/PSNR floor
+var ErrQualityBelowThreshold = errors.New("converted image quality below threshold")
+
+// qualityCompareSize is the longest edge both images are downsampled to
+// before comparing: enough to catch visible damage at a bounded cost
+const qualityCompareSize = 512
+
+// QualityScore compares a converted image with its input
+type QualityScore struct {
+	SSIM float64 `json:"ssim" example:"0.9712"` // Structural similarity of the luma planes (1 = identical)
+	PSNR float64 `json:"psnr" example:"38.45"`  // Peak signal-to-noise ratio in dB (higher is better)
+}
+
+// SetQualityGuard scores every conversion against its input when enabled and
+// refuses outputs below minSSIM or minPSNR (zero disables either floor).
+// Setting a floor implies scoring.
+func (ic *ImageConverter) SetQualityGuard(enabled bool, minSSIM, minPSNR float64) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.qualityCheck = enabled || minSSIM > 0 || minPSNR > 0
+	ic.minSSIM = minSSIM
+	ic.minPSNR = minPSNR
+}
+
+// checkQuality scores output against input when requested. Inputs Go can't
+// decode (WebP, HEIC, ...) or whose orientation changed are not scored.
+func (ic *ImageConverter) checkQuality(req *ImageRequest, input, output []byte) (*QualityScore, error) {
+	ic.mu.RLock()
+	enabled, minSSIM, minPSNR := ic.qualityCheck, ic.minSSIM, ic.minPSNR
+	ic.mu.RUnlock()
+
+	if req.QualityCheck != nil && *req.QualityCheck {
+		enabled = true
+	}
+	if !enabled {
+		return nil, nil
+	}
+
+	score, ok := compareImages(input, output)
+	if !ok {
+		return nil, nil
+	}
+
+	if minSSIM > 0 && score.SSIM < minSSIM {
+		return score, fmt.Errorf("%w: SSIM %.4f < %.4f", ErrQualityBelowThreshold, score.SSIM, minSSIM)
+	}
+	if minPSNR > 0 && score.PSNR < minPSNR {
+		return score, fmt.Errorf("%w: PSNR %.2fdB < %.2fdB", ErrQualityBelowThreshold, score.PSNR, minPSNR)
+	}
+
+	return score, nil
+}
+
+// compareImages computes SSIM and PSNR between the luma planes of two images
+// after downsampling both to the same size
+func compareImages(input, output []byte) (*QualityScore, bool) {
+	src, _, err := image.Decode(bytes.NewReader(input))
+	if err != nil {
+		return nil, false
+	}
+	dst, _, err := image.Decode(bytes.NewReader(output))
+	if err != nil {
+		return nil, false
+	}
+
+	srcBounds, dstBounds := src.Bounds(), dst.Bounds()
+	if srcBounds.Empty() || dstBounds.Empty() {
+		return nil, false
+	}
+
+	// A rotated output (EXIF orientation applied) isn't comparable pixel by pixel
+	srcAspect := float64(srcBounds.Dx()) / float64(srcBounds.Dy())
+	dstAspect := float64(dstBounds.Dx()) / float64(dstBounds.Dy())
+	if math.Abs(srcAspect-dstAspect)/srcAspect > 0.02 {
+		return nil, false
+	}
+
+	// Compare at the smaller size: an upscaled output measured against a
+	// blocky enlargement of its input would score as damaged
+	width, height := dstBounds.Dx(), dstBounds.Dy()
+	if srcBounds.Dx() < width {
+		width, height = srcBounds.Dx(), srcBounds.Dy()
+	}
+	if longest := max(width, height); longest > qualityCompareSize {
+		width = max(1, width*qualityCompareSize/longest)
+		height = max(1, height*qualityCompareSize/longest)
+	}
+
+	a := lumaPlane(src, width, height)
+	b := lumaPlane(dst, width, height)
+
+	return &QualityScore{
+		SSIM: math.Round(ssim(a, b, width, height)*10000) / 10000,
+		PSNR: math.Round(psnr(a, b)*100) / 100,
+	}, true
+}
+
+// lumaPlane returns the BT.601 luma of img box-averaged down to width×height
+func lumaPlane(img image.Image, width, height int) []float64 {
+	bounds := img.Bounds()
+	plane := make([]float64, width*height)
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var sum float64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					r, g, b, _ := img.At(sx, sy).RGBA()
+					sum += 0.299*float64(r>>8) + 0.587*float64(g>>8) + 0.114*float64(b>>8)
+				}
+			}
+			plane[y*width+x] = sum / float64((y1-y0)*(x1-x0))
+		}
+	}
+
+	return plane
+}
+
+// psnr returns the peak signal-to-noise ratio in dB, capped at 100 for identical planes
+func psnr(a, b []float64) float64 {
+	var mse float64
+	for i := range a {
+		d := a[i] - b[i]
+		mse += d * d
+	}
+	mse /= float64(len(a))
+	if mse == 0 {
+		return 100
+	}
+	return math.Min(100, 10*math.Log10(255*255/mse))
+}
+
+// ssim returns the mean structural similarity over 8×8 windows with a stride of 4
+func ssim(a, b []float64, width, height int) float64 {
+	const (
+		window = 8
+		stride = 4
+		c1     = (0.01 * 255) * (0.01 * 255)
+		c2     = (0.03 * 255) * (0.03 * 255)
+	)
+
+	// Images smaller than a window are compared as a single window
+	winW, winH := min(window, width), min(window, height)
+
+	var total float64
+	var count int
+	for y := 0; y+winH <= height; y += stride {
+		for x := 0; x+winW <= width; x += stride {
+			var sumA, sumB, sumAA, sumBB, sumAB float64
+			for wy := y; wy < y+winH; wy++ {
+				for wx := x; wx < x+winW; wx++ {
+					va, vb := a[wy*width+wx], b[wy*width+wx]
+					sumA += va
+					sumB += vb
+					sumAA += va * va
+					sumBB += vb * vb
+					sumAB += va * vb
+				}
+			}
+
+			n := float64(winW * winH)
+			meanA, meanB := sumA/n, sumB/n
+			varA := sumAA/n - meanA*meanA
+			varB := sumBB/n - meanB*meanB
+			cov := sumAB/n - meanA*meanB
+
+			total += ((2*meanA*meanB + c1) * (2*cov + c2)) /
+				((meanA*meanA + meanB*meanB + c1) * (varA + varB + c2))
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 1
+	}
+	return total / float64(count)
+}
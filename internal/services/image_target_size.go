@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+)
+
+// minTargetQuality is the lowest quality the target-size search goes to;
+// below it JPEG artefacts make photos unusable
+const minTargetQuality = 10
+
+// fitTargetSize returns output when it fits in maxBytes, otherwise it
+// binary-searches the highest quality below quality whose re-encode fits.
+// Each step costs one encode, so the search takes at most 7 of them.
+func fitTargetSize(output []byte, quality, maxBytes int, encode func(quality int) ([]byte, error)) ([]byte, int, error) {
+	if len(output) <= maxBytes {
+		return output, quality, nil
+	}
+
+	var best []byte
+	bestQuality, smallest := 0, len(output)
+
+	low, high := minTargetQuality, quality-1
+	for low <= high {
+		mid := (low + high) / 2
+		candidate, err := encode(mid)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if len(candidate) <= maxBytes {
+			best, bestQuality = candidate, mid
+			low = mid + 1
+		} else {
+			smallest = min(smallest, len(candidate))
+			high = mid - 1
+		}
+	}
+
+	if best == nil {
+		return nil, 0, targetSizeError(smallest, maxBytes)
+	}
+
+	return best, bestQuality, nil
+}
+
+// targetSizeError reports the smallest image achieved against the target
+func targetSizeError(smallest, maxBytes int) error {
+	return fmt.Errorf("%w: smallest output %d bytes, target %d bytes; lower max_width/max_height",
+		ErrTargetSizeUnreachable, smallest, maxBytes)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+)
+
+// mediaInput is a conversion input held in memory (decoded base64, a
+// download), left in the multipart upload it arrived in, or spilled to a
+// temporary file. Uploads and spill files are opened afresh and streamed to
+// every command that reads them, so large files never sit in memory next to
+// their converted output.
+type mediaInput struct {
+	data []byte
+	file *multipart.FileHeader
+	path string // Spill file holding the input
+	n    int64  // Size of the spill file
+}
+
+// size returns the input length in bytes
+func (in mediaInput) size() int {
+	switch {
+	case in.file != nil:
+		return int(in.file.Size)
+	case in.path != "":
+		return int(in.n)
+	}
+	return len(in.data)
+}
+
+// run executes an external tool with the input on stdin
+func (in mediaInput) run(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var output bytes.Buffer
+	stderr, err := in.stream(ctx, &output, name, args...)
+	return output.Bytes(), stderr, err
+}
+
+// stream is run writing the tool's stdout into a writer, returning stderr
+func (in mediaInput) stream(ctx context.Context, stdout io.Writer, name string, args ...string) ([]byte, error) {
+	if in.file == nil && in.path == "" {
+		return runCommandStream(ctx, bytes.NewReader(in.data), int64(len(in.data)), stdout, name, args...)
+	}
+
+	file, err := in.open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return runCommandStream(ctx, file, int64(in.size()), stdout, name, args...)
+}
+
+// open opens an input kept in an upload or a spill file
+func (in mediaInput) open() (io.ReadCloser, error) {
+	if in.path != "" {
+		file, err := os.Open(in.path)
+		if err != nil {
+			return nil, fmt.Errorf("open spill file: %w", err)
+		}
+		return file, nil
+	}
+
+	file, err := in.file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open upload: %w", err)
+	}
+	return file, nil
+}
+
+// bytes returns the input in memory, reading an upload only when a caller
+// needs all of it (returning it unchanged, retaining it)
+func (in mediaInput) bytes() ([]byte, error) {
+	switch {
+	case in.file != nil:
+		return readUpload(in.file)
+	case in.path != "":
+		return os.ReadFile(in.path)
+	}
+	return in.data, nil
+}
+
+// readUpload reads a multipart upload into a buffer of its exact size
+func readUpload(fileHeader *multipart.FileHeader) ([]byte, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open upload: %w", err)
+	}
+	defer file.Close()
+
+	data := make([]byte, fileHeader.Size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, fmt.Errorf("read upload: %w", err)
+	}
+	return data, nil
+}
+
+// spoolPrefix starts the names of spooled uploads, for the temp janitor
+const spoolPrefix = "whats-convert-upload-"
+
+// SpooledFile is a temporary file (a copy of an upload, a spilled payload)
+// that deletes itself on Close
+type SpooledFile struct {
+	*os.File
+	release func() // Returns the file's share of the spill budget
+}
+
+// Close closes and removes the file
+func (f *SpooledFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	if f.release != nil {
+		f.release()
+		f.release = nil
+	}
+	return err
+}
+
+// SpoolUpload copies a multipart upload into a temporary file in dir
+// (os.TempDir() when empty), so background work can stream it after the
+// request, and the multipart parser's copy, are gone
+func SpoolUpload(fileHeader *multipart.FileHeader, dir string) (*SpooledFile, error) {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open upload: %w", err)
+	}
+	defer src.Close()
+
+	file, err := os.CreateTemp(dir, spoolPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("create spool file: %w", err)
+	}
+	spooled := &SpooledFile{File: file}
+
+	if _, err := io.Copy(file, src); err != nil {
+		spooled.Close()
+		return nil, fmt.Errorf("spool upload: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, fmt.Errorf("spool upload: %w", err)
+	}
+
+	return spooled, nil
+}
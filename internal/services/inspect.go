@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime/multipart"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/tracing"
+)
+
+// ErrUnrecognizedMedia is returned when ffprobe can't read the input
+var ErrUnrecognizedMedia = errors.New("unrecognized media")
+
+// Media kinds reported by inspection
+const (
+	MediaKindAudio   = "audio"
+	MediaKindImage   = "image"
+	MediaKindVideo   = "video"
+	MediaKindUnknown = "unknown"
+)
+
+// InspectRequest represents a media inspection request
+type InspectRequest struct {
+	Data  string `json:"data" example:"data:video/mp4;base64,AAAAIGZ0eXBpc29tAAACAGlzb20"` // base64 or URL
+	IsURL bool   `json:"is_url" example:"false"`                                           // true if data is URL
+
+	Input  []byte                `json:"-"` // Set by the HTTP layer: raw input bytes, used instead of Data
+	Upload *multipart.FileHeader `json:"-"` // Set by the HTTP layer: multipart upload copied to disk without buffering, used instead of Input
+}
+
+// MediaInfo is what ffprobe reports about an input. The top-level fields
+// summarize the main video and audio streams; streams lists all of them.
+type MediaInfo struct {
+	Kind       string  `json:"kind" example:"video"`                        // audio, image, video or unknown
+	MIME       string  `json:"mime" example:"video/mp4"`                    // Detected from the content
+	Container  string  `json:"container" example:"mov,mp4,m4a,3gp,3g2,mj2"` // ffprobe format name
+	Duration   float64 `json:"duration,omitempty" example:"12.48"`          // Seconds (absent for still images)
+	Size       int64   `json:"size" example:"3145728"`                      // Bytes
+	Bitrate    int     `json:"bitrate,omitempty" example:"2015"`            // Overall bitrate in kbit/s
+	Width      int     `json:"width,omitempty" example:"1920"`              // Coded width of the main video stream
+	Height     int     `json:"height,omitempty" example:"1080"`             // Coded height of the main video stream
+	Rotation   int     `json:"rotation,omitempty" example:"90"`             // Clockwise degrees players rotate the video by (0, 90, 180 or 270)
+	VideoCodec string  `json:"video_codec,omitempty" example:"h264"`        // Codec of the main video stream
+	AudioCodec string  `json:"audio_codec,omitempty" example:"aac"`         // Codec of the default audio stream
+	Channels   int     `json:"channels,omitempty" example:"2"`              // Channels of the default audio stream
+	SampleRate int     `json:"sample_rate,omitempty" example:"48000"`       // Sample rate in Hz of the default audio stream
+
+	Streams []StreamInfo `json:"streams"`
+
+	Trace   []CommandRecord `json:"trace,omitempty"`   // External commands executed (debug trace only)
+	Timings *Timings        `json:"timings,omitempty"` // Time spent per stage (debug_timings only)
+}
+
+// StreamInfo describes one stream of an inspected input
+type StreamInfo struct {
+	Index         int     `json:"index" example:"0"`
+	Type          string  `json:"type" example:"video"` // video, audio, subtitle, data or attachment
+	Codec         string  `json:"codec" example:"h264"`
+	Profile       string  `json:"profile,omitempty" example:"High"`
+	Duration      float64 `json:"duration,omitempty" example:"12.48"` // Seconds
+	Bitrate       int     `json:"bitrate,omitempty" example:"1850"`   // kbit/s
+	Language      string  `json:"language,omitempty" example:"por"`
+	Default       bool    `json:"default" example:"true"`
+	CoverArt      bool    `json:"cover_art,omitempty" example:"false"` // Picture attached to an audio file
+	Width         int     `json:"width,omitempty" example:"1920"`
+	Height        int     `json:"height,omitempty" example:"1080"`
+	Rotation      int     `json:"rotation,omitempty" example:"90"`
+	FrameRate     float64 `json:"frame_rate,omitempty" example:"29.97"`
+	PixelFormat   string  `json:"pixel_format,omitempty" example:"yuv420p"`
+	Channels      int     `json:"channels,omitempty" example:"2"`
+	ChannelLayout string  `json:"channel_layout,omitempty" example:"stereo"`
+	SampleRate    int     `json:"sample_rate,omitempty" example:"48000"`
+}
+
+// MediaInspector reads the metadata of inputs without converting them
+type MediaInspector struct {
+	workerPool *pool.WorkerPool
+	bufferPool *pool.BufferPool
+	downloader *Downloader
+	maxSize    int64  // Largest accepted input in bytes
+	tempDir    string // Parent of scratch directories (default os.TempDir())
+	spill      *SpillStore
+	mu         sync.RWMutex
+}
+
+// NewMediaInspector creates an inspector accepting inputs of up to maxSize
+// bytes, copied to scratch directories under tempDir
+func NewMediaInspector(workerPool *pool.WorkerPool, bufferPool *pool.BufferPool, downloader *Downloader, maxSize int64, tempDir string) *MediaInspector {
+	return &MediaInspector{
+		workerPool: workerPool,
+		bufferPool: bufferPool,
+		downloader: downloader,
+		maxSize:    maxSize,
+		tempDir:    tempDir,
+	}
+}
+
+// SetSpillStore moves large base64 and URL inputs to disk while they are read
+func (mi *MediaInspector) SetSpillStore(store *SpillStore) {
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+
+	mi.spill = store
+}
+
+func (mi *MediaInspector) spillStore() *SpillStore {
+	mi.mu.RLock()
+	defer mi.mu.RUnlock()
+
+	return mi.spill
+}
+
+// Inspect runs ffprobe on the input and returns its container and streams
+func (mi *MediaInspector) Inspect(ctx context.Context, req *InspectRequest) (info *MediaInfo, err error) {
+	ctx, span := tracing.Start(ctx, "inspect", attribute.Bool("media.is_url", req.IsURL))
+	defer func() { tracing.End(span, err) }()
+
+	input, release, err := mi.readInput(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	releaseSlot, err := acquireWorker(ctx, mi.workerPool, input.size())
+	if err != nil {
+		return nil, fmt.Errorf("waiting for a worker: %w", err)
+	}
+	defer releaseSlot()
+
+	// MP4/MOV inputs often keep their index at the end, out of reach of a pipe
+	ctx, scratch, err := newScratchDir(ctx, mi.tempDir)
+	if err != nil {
+		return nil, err
+	}
+	defer scratch.remove()
+
+	path, err := scratch.writeInput("input", input)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err = probeMedia(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	info.Size = int64(input.size())
+	info.MIME = containerMIME(info.Container, info.Kind)
+	if head, err := input.head(); err == nil {
+		if media, ok := SniffBytes(head); ok {
+			info.MIME = mimeForKind(media.MIME, info.Kind)
+		}
+	}
+	span.SetAttributes(attribute.String("media.kind", info.Kind), attribute.String("media.container", info.Container))
+
+	return info, nil
+}
+
+// Fingerprint returns the perceptual hash of an image or audio input, with
+// chromaprint's raw sub-fingerprints for audio
+func (mi *MediaInspector) Fingerprint(ctx context.Context, req *InspectRequest) (fingerprint *Fingerprint, err error) {
+	ctx, span := tracing.Start(ctx, "fingerprint", attribute.Bool("media.is_url", req.IsURL))
+	defer func() { tracing.End(span, err) }()
+
+	input, release, err := mi.readInput(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	releaseSlot, err := acquireWorker(ctx, mi.workerPool, input.size())
+	if err != nil {
+		return nil, fmt.Errorf("waiting for a worker: %w", err)
+	}
+	defer releaseSlot()
+
+	media := sniffInput(ctx, input)
+	if media.Kind == MediaKindUnknown {
+		return nil, fmt.Errorf("%w: format not recognised", ErrUnrecognizedMedia)
+	}
+	span.SetAttributes(attribute.String("media.kind", media.Kind))
+
+	return fingerprintInput(ctx, input, media.Kind, true)
+}
+
+// readInput loads the request's upload, bytes, URL or base64 payload,
+// checking its size. The returned release func is never nil.
+func (mi *MediaInspector) readInput(ctx context.Context, req *InspectRequest) (mediaInput, func(), error) {
+	var input mediaInput
+	release := func() {}
+	var err error
+	if req.Upload != nil {
+		input.file = req.Upload
+	} else if req.Input != nil {
+		input.data = req.Input
+	} else if req.IsURL {
+		input, release, err = downloadInput(ctx, mi.downloader, mi.spillStore(), req.Data)
+		if err != nil {
+			return mediaInput{}, func() {}, fmt.Errorf("download failed: %w", err)
+		}
+	} else {
+		input, release, err = decodeInput(ctx, mi.bufferPool, mi.spillStore(), req.Data)
+		if err != nil {
+			return mediaInput{}, func() {}, fmt.Errorf("base64 decode failed: %w", err)
+		}
+	}
+
+	if input.size() == 0 {
+		release()
+		return mediaInput{}, func() {}, fmt.Errorf("empty input data")
+	}
+	if mi.maxSize > 0 && int64(input.size()) > mi.maxSize {
+		release()
+		return mediaInput{}, func() {}, fmt.Errorf("input too large: %d bytes", input.size())
+	}
+
+	return input, release, nil
+}
+
+// ffprobeOutput is the part of "ffprobe -show_format -show_streams" read
+type ffprobeOutput struct {
+	Streams []struct {
+		Index         int               `json:"index"`
+		CodecType     string            `json:"codec_type"`
+		CodecName     string            `json:"codec_name"`
+		Profile       string            `json:"profile"`
+		Duration      string            `json:"duration"`
+		BitRate       string            `json:"bit_rate"`
+		Width         int               `json:"width"`
+		Height        int               `json:"height"`
+		AvgFrameRate  string            `json:"avg_frame_rate"`
+		RFrameRate    string            `json:"r_frame_rate"`
+		PixFmt        string            `json:"pix_fmt"`
+		Channels      int               `json:"channels"`
+		ChannelLayout string            `json:"channel_layout"`
+		SampleRate    string            `json:"sample_rate"`
+		Tags          map[string]string `json:"tags"`
+		Disposition   struct {
+			Default     int `json:"default"`
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+		SideDataList []struct {
+			Rotation float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// probeMedia reads the container and every stream of the file at path
+func probeMedia(ctx context.Context, path string) (*MediaInfo, error) {
+	output, stderr, err := runCommand(ctx, nil, "ffprobe",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-show_format",
+		"-show_streams",
+		"-of", "json",
+		path,
+	)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnrecognizedMedia, strings.TrimSpace(strings.ReplaceAll(string(stderr), path+": ", "")))
+		}
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var probe ffprobeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("unexpected ffprobe output: %w", err)
+	}
+	if len(probe.Streams) == 0 {
+		return nil, fmt.Errorf("%w: no audio or video streams", ErrUnrecognizedMedia)
+	}
+
+	info := &MediaInfo{
+		Container: probe.Format.FormatName,
+		Duration:  parseSeconds(probe.Format.Duration),
+		Bitrate:   parseKbps(probe.Format.BitRate),
+		Streams:   make([]StreamInfo, 0, len(probe.Streams)),
+	}
+
+	var video, audio *StreamInfo
+	for _, s := range probe.Streams {
+		stream := StreamInfo{
+			Index:    s.Index,
+			Type:     s.CodecType,
+			Codec:    s.CodecName,
+			Profile:  s.Profile,
+			Duration: parseSeconds(s.Duration),
+			Bitrate:  parseKbps(s.BitRate),
+			Language: s.Tags["language"],
+			Default:  s.Disposition.Default == 1,
+			CoverArt: s.Disposition.AttachedPic == 1,
+		}
+		if stream.Language == "und" {
+			stream.Language = ""
+		}
+
+		switch s.CodecType {
+		case "video":
+			stream.Width, stream.Height = s.Width, s.Height
+			stream.PixelFormat = s.PixFmt
+			stream.Rotation = streamRotation(s.Tags["rotate"], s.SideDataList)
+			stream.FrameRate = parseFrameRate(s.AvgFrameRate)
+			if stream.FrameRate == 0 {
+				stream.FrameRate = parseFrameRate(s.RFrameRate)
+			}
+			stream.FrameRate = math.Round(stream.FrameRate*100) / 100
+		case "audio":
+			stream.Channels = s.Channels
+			stream.ChannelLayout = s.ChannelLayout
+			stream.SampleRate, _ = strconv.Atoi(s.SampleRate)
+		}
+		info.Streams = append(info.Streams, stream)
+	}
+
+	// The main video stream skips cover art; the main audio stream is the
+	// one flagged default, else the first
+	for i := range info.Streams {
+		stream := &info.Streams[i]
+		switch {
+		case stream.Type == "video" && !stream.CoverArt && video == nil:
+			video = stream
+		case stream.Type == "audio" && (audio == nil || stream.Default && !audio.Default):
+			audio = stream
+		}
+	}
+
+	if video != nil {
+		info.Width, info.Height = video.Width, video.Height
+		info.Rotation = video.Rotation
+		info.VideoCodec = video.Codec
+	}
+	if audio != nil {
+		info.AudioCodec = audio.Codec
+		info.Channels = audio.Channels
+		info.SampleRate = audio.SampleRate
+	}
+
+	switch {
+	case video != nil && audio == nil && isStillImage(info.Container):
+		info.Kind = MediaKindImage
+		info.Duration = 0
+	case video != nil:
+		info.Kind = MediaKindVideo
+	case audio != nil:
+		info.Kind = MediaKindAudio
+	default:
+		info.Kind = MediaKindUnknown
+	}
+
+	// MediaRecorder WebM has no duration in its header
+	if info.Duration == 0 && info.Kind != MediaKindImage && info.Kind != MediaKindUnknown {
+		main := video
+		if main == nil {
+			main = audio
+		}
+		info.Duration = math.Round(lastPacketTime(ctx, path, main.Index)*1000) / 1000
+	}
+
+	return info, nil
+}
+
+// isStillImage reports whether a video-only input is a picture: image
+// demuxers (image2, jpeg_pipe, png_pipe, webp_pipe, ...) and GIFs
+func isStillImage(container string) bool {
+	return container == "image2" || container == "gif" || strings.HasSuffix(container, "_pipe")
+}
+
+// streamRotation returns the clockwise rotation players apply, from the
+// legacy rotate tag or the display matrix (counter-clockwise degrees)
+func streamRotation(tag string, sideData []struct {
+	Rotation float64 `json:"rotation"`
+}) int {
+	degrees := 0
+	if value, err := strconv.Atoi(tag); err == nil {
+		degrees = value
+	} else {
+		for _, data := range sideData {
+			if data.Rotation != 0 {
+				degrees = -int(math.Round(data.Rotation))
+				break
+			}
+		}
+	}
+	return ((degrees % 360) + 360) % 360
+}
+
+// parseSeconds parses an ffprobe duration, 0 when absent ("N/A")
+func parseSeconds(value string) float64 {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return seconds
+}
+
+// parseKbps converts an ffprobe bit_rate in bit/s to kbit/s, 0 when absent
+func parseKbps(value string) int {
+	bps, err := strconv.ParseFloat(value, 64)
+	if err != nil || bps <= 0 {
+		return 0
+	}
+	return int(math.Round(bps / 1000))
+}
@@ -0,0 +1,69 @@
+package services
+
+import "context"
+
+// AudioConverterIface defines the audio conversion operations used by the HTTP layer.
+// Alternative implementations (remote, cached, test doubles) can be swapped in for *AudioConverter.
+type AudioConverterIface interface {
+	// Convert converts a single audio payload to WhatsApp-compatible Opus
+	Convert(ctx context.Context, req *AudioRequest) (*AudioResponse, error)
+
+	// ConvertBatch converts multiple audio payloads, preserving request order
+	ConvertBatch(ctx context.Context, requests []*AudioRequest, opts BatchOptions) ([]*AudioResponse, error)
+
+	// GetStats returns a snapshot of conversion statistics
+	GetStats() AudioConverterStats
+}
+
+// ImageConverterIface defines the image conversion operations used by the HTTP layer.
+// Alternative implementations (remote, cached, test doubles) can be swapped in for *ImageConverter.
+type ImageConverterIface interface {
+	// Convert converts a single image payload to WhatsApp-compatible JPEG
+	Convert(ctx context.Context, req *ImageRequest) (*ImageResponse, error)
+
+	// ConvertBatch converts multiple image payloads, preserving request order
+	ConvertBatch(ctx context.Context, requests []*ImageRequest, opts BatchOptions) ([]*ImageResponse, error)
+
+	// ConvertSticker converts a single image payload to a 512x512 WebP sticker
+	ConvertSticker(ctx context.Context, req *StickerRequest) (*StickerResponse, error)
+
+	// ConvertStickerPack converts 3 to 30 images into a WhatsApp sticker pack with its manifest
+	ConvertStickerPack(ctx context.Context, req *StickerPackRequest) (*StickerPackResponse, error)
+
+	// GetStats returns a snapshot of conversion statistics
+	GetStats() ImageConverterStats
+}
+
+// VideoConverterIface defines the video conversion operations used by the HTTP layer.
+// Alternative implementations (remote, cached, test doubles) can be swapped in for *VideoConverter.
+type VideoConverterIface interface {
+	// Convert transcodes a single video payload to WhatsApp-compatible MP4
+	Convert(ctx context.Context, req *VideoRequest) (*VideoResponse, error)
+
+	// Compose encodes a still image over an audio track as a WhatsApp-compatible MP4
+	Compose(ctx context.Context, req *ComposeRequest) (*VideoResponse, error)
+
+	// GetStats returns a snapshot of conversion statistics
+	GetStats() VideoConverterStats
+}
+
+// vipsReporter is implemented by image converters that can report libvips availability
+type vipsReporter interface {
+	IsVipsAvailable() bool
+}
+
+// IsVipsAvailable reports whether the given image converter uses libvips.
+// Implementations that don't expose this are reported as not using it.
+func IsVipsAvailable(converter ImageConverterIface) bool {
+	if reporter, ok := converter.(vipsReporter); ok {
+		return reporter.IsVipsAvailable()
+	}
+	return false
+}
+
+// Compile-time checks that the concrete converters satisfy the interfaces
+var (
+	_ AudioConverterIface = (*AudioConverter)(nil)
+	_ ImageConverterIface = (*ImageConverter)(nil)
+	_ VideoConverterIface = (*VideoConverter)(nil)
+)
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"log"
+)
+
+// LoudnessTarget holds the EBU R128 targets audio is normalized to when a
+// request sets normalize
+type LoudnessTarget struct {
+	Integrated float64 // Integrated loudness in LUFS (-70 to -5)
+	Range      float64 // Loudness range in LU (1 to 50)
+	TruePeak   float64 // Maximum true peak in dBTP (-9 to 0)
+}
+
+// DefaultLoudnessTarget suits speech on phone speakers
+var DefaultLoudnessTarget = LoudnessTarget{Integrated: -16, Range: 11, TruePeak: -1.5}
+
+// SetLoudnessTarget sets the targets for normalize requests; values outside
+// the ranges FFmpeg's loudnorm accepts fall back to DefaultLoudnessTarget's
+func (ac *AudioConverter) SetLoudnessTarget(target LoudnessTarget) {
+	if target.Integrated < -70 || target.Integrated > -5 {
+		log.Printf("Warning: loudness target %g LUFS outside -70..-5, using %g", target.Integrated, DefaultLoudnessTarget.Integrated)
+		target.Integrated = DefaultLoudnessTarget.Integrated
+	}
+	if target.Range < 1 || target.Range > 50 {
+		log.Printf("Warning: loudness range %g LU outside 1..50, using %g", target.Range, DefaultLoudnessTarget.Range)
+		target.Range = DefaultLoudnessTarget.Range
+	}
+	if target.TruePeak < -9 || target.TruePeak > 0 {
+		log.Printf("Warning: true peak %g dBTP outside -9..0, using %g", target.TruePeak, DefaultLoudnessTarget.TruePeak)
+		target.TruePeak = DefaultLoudnessTarget.TruePeak
+	}
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.loudness = &target
+}
+
+// loudnessFilter returns the loudnorm filter for the configured targets.
+// loudnorm resamples to 192kHz, so callers must set the output rate.
+func (ac *AudioConverter) loudnessFilter() string {
+	ac.mu.RLock()
+	target := DefaultLoudnessTarget
+	if ac.loudness != nil {
+		target = *ac.loudness
+	}
+	ac.mu.RUnlock()
+
+	return fmt.Sprintf("loudnorm=I=%g:LRA=%g:TP=%g", target.Integrated, target.Range, target.TruePeak)
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrMaintenanceNotFound is returned when lifting maintenance from an
+	// endpoint that isn't under maintenance
+	ErrMaintenanceNotFound = errors.New("endpoint not under maintenance")
+
+	// ErrInvalidEndpoint is returned for endpoints that aren't API paths
+	ErrInvalidEndpoint = errors.New("invalid endpoint")
+)
+
+// MaintenanceWindow disables an endpoint, and every path below it, until it
+// is lifted or Until passes
+type MaintenanceWindow struct {
+	Endpoint string     `json:"endpoint" example:"/convert/video"`
+	Message  string     `json:"message,omitempty" example:"Video conversions are paused while we investigate elevated failures"`
+	Since    time.Time  `json:"since" example:"2024-03-31T12:00:00Z"`
+	Until    *time.Time `json:"until,omitempty" example:"2024-03-31T12:30:00Z"`
+}
+
+// active reports whether the window is still in force at now
+func (w MaintenanceWindow) active(now time.Time) bool {
+	return w.Until == nil || now.Before(*w.Until)
+}
+
+// Maintenance holds the endpoints disabled by operators. With a file, every
+// change is saved there and the file is reloaded every refresh, so windows
+// survive restarts and reach every process sharing it (PREFORK children,
+// replicas on a shared volume); the file can also be edited by hand.
+type Maintenance struct {
+	file    string
+	refresh time.Duration
+
+	mu        sync.RWMutex
+	windows   map[string]MaintenanceWindow
+	fileMtime time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMaintenance creates the maintenance registry, loading file if set
+func NewMaintenance(file string, refresh time.Duration) *Maintenance {
+	m := &Maintenance{
+		file:    file,
+		refresh: refresh,
+		windows: make(map[string]MaintenanceWindow),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	m.reloadFile()
+
+	if file == "" || refresh <= 0 {
+		close(m.done)
+		return m
+	}
+
+	go m.refreshLoop()
+	return m
+}
+
+// maintenanceExempt lists the endpoints that stay up under any window: health
+// probes, so orchestrators don't restart the container, and the maintenance
+// API, so operators can't lock themselves out
+var maintenanceExempt = []string{"/health", "/admin/maintenance"}
+
+// exempt reports whether path is, or is below, an exempt endpoint
+func exempt(path string) bool {
+	for _, endpoint := range maintenanceExempt {
+		if path == endpoint || strings.HasPrefix(path, endpoint+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeEndpoint turns "convert/video/" or "/v1/convert/video" into
+// "/convert/video"; "/" stands for the whole API
+func NormalizeEndpoint(endpoint string) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return "", fmt.Errorf("%w: empty path", ErrInvalidEndpoint)
+	}
+	if strings.ContainsAny(endpoint, "?#*: ") {
+		return "", fmt.Errorf("%w: %q is not a plain path", ErrInvalidEndpoint, endpoint)
+	}
+
+	endpoint = "/" + strings.Trim(endpoint, "/")
+
+	// Versioned paths are aliases of the unversioned ones
+	segment, rest, _ := strings.Cut(endpoint[1:], "/")
+	if len(segment) > 1 && segment[0] == 'v' && strings.Trim(segment[1:], "0123456789") == "" {
+		endpoint = "/" + rest
+	}
+	return endpoint, nil
+}
+
+// Enable puts endpoint under maintenance with message, lifting it
+// automatically after duration (0 = until Disable). Enabling an endpoint
+// already under maintenance replaces its window.
+func (m *Maintenance) Enable(endpoint, message string, duration time.Duration) (MaintenanceWindow, error) {
+	endpoint, err := NormalizeEndpoint(endpoint)
+	if err != nil {
+		return MaintenanceWindow{}, err
+	}
+	if exempt(endpoint) {
+		return MaintenanceWindow{}, fmt.Errorf("%w: %s can't be put under maintenance", ErrInvalidEndpoint, endpoint)
+	}
+
+	window := MaintenanceWindow{
+		Endpoint: endpoint,
+		Message:  strings.TrimSpace(message),
+		Since:    time.Now().UTC(),
+	}
+	if duration > 0 {
+		until := window.Since.Add(duration)
+		window.Until = &until
+	}
+
+	err = m.update(func(windows map[string]MaintenanceWindow) error {
+		windows[endpoint] = window
+		return nil
+	})
+	return window, err
+}
+
+// Disable lifts maintenance from endpoint
+func (m *Maintenance) Disable(endpoint string) error {
+	endpoint, err := NormalizeEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+
+	return m.update(func(windows map[string]MaintenanceWindow) error {
+		if window, ok := windows[endpoint]; !ok || !window.active(time.Now()) {
+			return fmt.Errorf("%w: %s", ErrMaintenanceNotFound, endpoint)
+		}
+		delete(windows, endpoint)
+		return nil
+	})
+}
+
+// update applies change to the windows and saves them. With a file, the
+// file is reloaded first so changes made by other processes aren't lost.
+func (m *Maintenance) update(change func(windows map[string]MaintenanceWindow) error) error {
+	if m.file != "" {
+		m.reloadFile()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	windows := make(map[string]MaintenanceWindow, len(m.windows)+1)
+	now := time.Now()
+	for endpoint, window := range m.windows {
+		if window.active(now) {
+			windows[endpoint] = window
+		}
+	}
+	if err := change(windows); err != nil {
+		return err
+	}
+
+	if m.file != "" {
+		mtime, err := m.save(windows)
+		if err != nil {
+			return fmt.Errorf("failed to save maintenance windows: %w", err)
+		}
+		m.fileMtime = mtime
+	}
+	m.windows = windows
+	return nil
+}
+
+// List returns the windows in force, sorted by endpoint
+func (m *Maintenance) List() []MaintenanceWindow {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	windows := make([]MaintenanceWindow, 0, len(m.windows))
+	for _, window := range m.windows {
+		if window.active(now) {
+			windows = append(windows, window)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].Endpoint < windows[j].Endpoint })
+	return windows
+}
+
+// Match returns the window covering path (without its version prefix),
+// the most specific one when several do
+func (m *Maintenance) Match(path string) (MaintenanceWindow, bool) {
+	if m == nil || exempt(path) {
+		return MaintenanceWindow{}, false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.windows) == 0 {
+		return MaintenanceWindow{}, false
+	}
+
+	now := time.Now()
+	var match MaintenanceWindow
+	found := false
+	for endpoint, window := range m.windows {
+		covers := endpoint == "/" || path == endpoint || strings.HasPrefix(path, endpoint+"/")
+		if covers && window.active(now) && (!found || len(endpoint) > len(match.Endpoint)) {
+			match, found = window, true
+		}
+	}
+	return match, found
+}
+
+// Close stops the refresh loop
+func (m *Maintenance) Close() {
+	select {
+	case <-m.stop:
+	default:
+		close(m.stop)
+	}
+	<-m.done
+}
+
+func (m *Maintenance) refreshLoop() {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.reloadFile()
+		}
+	}
+}
+
+// reloadFile reads the windows saved in the file when it changed. A missing
+// file means no maintenance; an invalid one keeps the previous windows.
+func (m *Maintenance) reloadFile() {
+	if m.file == "" {
+		return
+	}
+
+	info, err := os.Stat(m.file)
+	if errors.Is(err, os.ErrNotExist) {
+		m.mu.Lock()
+		if !m.fileMtime.IsZero() {
+			m.windows = make(map[string]MaintenanceWindow)
+			m.fileMtime = time.Time{}
+		}
+		m.mu.Unlock()
+		return
+	}
+	if err != nil {
+		log.Printf("Maintenance file unavailable: %v", err)
+		return
+	}
+
+	m.mu.RLock()
+	unchanged := info.ModTime().Equal(m.fileMtime)
+	m.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	data, err := os.ReadFile(m.file)
+	if err != nil {
+		log.Printf("Maintenance file unreadable: %v", err)
+		return
+	}
+
+	saved := make(map[string]MaintenanceWindow)
+	if err := json.Unmarshal(data, &saved); err != nil {
+		log.Printf("Maintenance file invalid, keeping previous windows: %v", err)
+		return
+	}
+
+	// Keys are the endpoints; hand-written entries may leave out the rest
+	windows := make(map[string]MaintenanceWindow, len(saved))
+	for key, window := range saved {
+		endpoint, err := NormalizeEndpoint(key)
+		if err != nil {
+			log.Printf("Maintenance window %q ignored: %v", key, err)
+			continue
+		}
+		window.Endpoint = endpoint
+		if window.Since.IsZero() {
+			window.Since = info.ModTime().UTC()
+		}
+		windows[endpoint] = window
+	}
+
+	m.mu.Lock()
+	m.windows = windows
+	m.fileMtime = info.ModTime()
+	m.mu.Unlock()
+}
+
+// save writes windows to the file atomically and returns its new mtime
+func (m *Maintenance) save(windows map[string]MaintenanceWindow) (time.Time, error) {
+	data, err := json.MarshalIndent(windows, "", "  ")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.file), ".maintenance-*")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return time.Time{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return time.Time{}, err
+	}
+	if err := os.Rename(tmp.Name(), m.file); err != nil {
+		return time.Time{}, err
+	}
+
+	info, err := os.Stat(m.file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
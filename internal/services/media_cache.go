@@ -0,0 +1,129 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MediaCache keeps converted renditions in memory, evicting the least
+// recently used entries once the byte budget is exceeded. A nil cache is
+// valid and never hits.
+type MediaCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	ttl      time.Duration
+	size     int64
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+	hits     int64
+	misses   int64
+}
+
+type mediaCacheEntry struct {
+	key      string
+	data     []byte
+	mimeType string
+	expires  time.Time
+}
+
+// MediaCacheStats reports cache usage
+type MediaCacheStats struct {
+	Entries  int   `json:"entries"`
+	Bytes    int64 `json:"bytes"`
+	MaxBytes int64 `json:"max_bytes"`
+	Hits     int64 `json:"hits"`
+	Misses   int64 `json:"misses"`
+}
+
+// NewMediaCache creates a cache holding up to maxBytes of renditions for ttl
+// (0 = until evicted). It returns nil when maxBytes <= 0.
+func NewMediaCache(maxBytes int64, ttl time.Duration) *MediaCache {
+	if maxBytes <= 0 {
+		return nil
+	}
+
+	return &MediaCache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached rendition and its MIME type
+func (c *MediaCache) Get(key string) ([]byte, string, bool) {
+	if c == nil {
+		return nil, "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, "", false
+	}
+
+	entry := element.Value.(*mediaCacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.remove(element)
+		c.misses++
+		return nil, "", false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits++
+	return entry.data, entry.mimeType, true
+}
+
+// Put stores a rendition; entries larger than a quarter of the budget are
+// skipped so one large file can't flush the whole cache
+func (c *MediaCache) Put(key string, data []byte, mimeType string) {
+	if c == nil || int64(len(data)) > c.maxBytes/4 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	entry := &mediaCacheEntry{key: key, data: data, mimeType: mimeType}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.size += int64(len(data))
+
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+// Stats returns current cache usage
+func (c *MediaCache) Stats() MediaCacheStats {
+	if c == nil {
+		return MediaCacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return MediaCacheStats{
+		Entries:  len(c.entries),
+		Bytes:    c.size,
+		MaxBytes: c.maxBytes,
+		Hits:     c.hits,
+		Misses:   c.misses,
+	}
+}
+
+func (c *MediaCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*mediaCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= int64(len(entry.data))
+}
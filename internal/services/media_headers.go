@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	_ "image/gif" // register decoders for image.DecodeConfig and the quality check
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"time"
+)
+
+// Outputs are measured from their headers where the format allows it, so a
+// conversion doesn't spawn ffprobe just to report what it produced. Parsers
+// report false for anything unexpected and callers fall back to ffprobe.
+
+// headerDimensions reads the pixel size of a JPEG, WebP, PNG or GIF image
+// from its header
+func headerDimensions(data []byte) (int, int, bool) {
+	if width, height, ok := jpegDimensions(data); ok {
+		return width, height, true
+	}
+	if width, height, ok := webpDimensions(data); ok {
+		return width, height, true
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		return cfg.Width, cfg.Height, true
+	}
+	return 0, 0, false
+}
+
+// jpegDimensions walks the JPEG markers up to the start of frame (SOFn),
+// which holds the image height and width
+func jpegDimensions(data []byte) (int, int, bool) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return 0, 0, false
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xff {
+			return 0, 0, false
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xff:
+			// Fill byte before a marker
+			i++
+			continue
+		case marker == 0x01 || marker >= 0xd0 && marker <= 0xd7:
+			// Standalone markers (TEM, RSTn) have no length
+			i += 2
+			continue
+		case marker == 0xd9 || marker == 0xda:
+			// End of image, or entropy-coded data before any frame header
+			return 0, 0, false
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if length < 2 {
+			return 0, 0, false
+		}
+
+		// SOF0-SOF15, except DHT (C4), JPG (C8) and DAC (CC)
+		if marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc {
+			// Length, precision, then 16-bit height and width
+			if length < 7 || i+9 > len(data) {
+				return 0, 0, false
+			}
+			height := int(binary.BigEndian.Uint16(data[i+5 : i+7]))
+			width := int(binary.BigEndian.Uint16(data[i+7 : i+9]))
+			if width == 0 || height == 0 {
+				// A zero height is defined later by a DNL marker; let ffprobe sort it out
+				return 0, 0, false
+			}
+			return width, height, true
+		}
+
+		i += 2 + length
+	}
+
+	return 0, 0, false
+}
+
+// headerDuration reads the duration of an Ogg (Opus or Vorbis) or WAV file
+// from its container
+func headerDuration(data []byte) (time.Duration, bool) {
+	if duration, ok := oggDuration(data); ok {
+		return duration, true
+	}
+	return wavDuration(data)
+}
+
+// oggDuration divides the granule position of the last Ogg page, the number
+// of samples in the stream, by the sample rate. Opus granules always count
+// 48 kHz samples and include the encoder's pre-skip. Multiplexed or chained
+// streams are left to ffprobe.
+func oggDuration(data []byte) (time.Duration, bool) {
+	const pageHeader = 27
+	if len(data) < pageHeader || string(data[0:4]) != "OggS" {
+		return 0, false
+	}
+
+	serial := binary.LittleEndian.Uint32(data[14:18])
+	start := pageHeader + int(data[26])
+	if start >= len(data) {
+		return 0, false
+	}
+	packet := data[start:]
+
+	var rate, preSkip uint64
+	switch {
+	case len(packet) >= 19 && bytes.HasPrefix(packet, []byte("OpusHead")):
+		rate = 48000
+		preSkip = uint64(binary.LittleEndian.Uint16(packet[10:12]))
+	case len(packet) >= 16 && bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		rate = uint64(binary.LittleEndian.Uint32(packet[12:16]))
+	default:
+		return 0, false
+	}
+	if rate == 0 {
+		return 0, false
+	}
+
+	// The last page carries the final granule position
+	last := bytes.LastIndex(data, []byte("OggS"))
+	if last < 0 || last+pageHeader > len(data) {
+		return 0, false
+	}
+	page := data[last:]
+	if page[4] != 0 || binary.LittleEndian.Uint32(page[14:18]) != serial {
+		return 0, false
+	}
+	granule := binary.LittleEndian.Uint64(page[6:14])
+	if granule == ^uint64(0) || granule < preSkip {
+		// No packet ends on this page
+		return 0, false
+	}
+
+	// Split into whole seconds first: samples times 1e9 overflows for
+	// granules past a few hours, and the granule is untrusted input
+	samples := granule - preSkip
+	seconds := samples / rate
+	if seconds > math.MaxInt64/uint64(time.Second)-1 {
+		return 0, false
+	}
+	return time.Duration(seconds*uint64(time.Second) + samples%rate*uint64(time.Second)/rate), true
+}
+
+// wavDuration divides the size of the data chunk of a PCM WAV file by the
+// byte rate of its fmt chunk
+func wavDuration(data []byte) (time.Duration, bool) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0, false
+	}
+
+	var byteRate uint64
+	for i := 12; i+8 <= len(data); {
+		id := string(data[i : i+4])
+		size := uint64(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		body := data[i+8:]
+
+		switch id {
+		case "fmt ":
+			if size < 16 || len(body) < 16 {
+				return 0, false
+			}
+			byteRate = uint64(binary.LittleEndian.Uint32(body[8:12]))
+		case "data":
+			if byteRate == 0 {
+				return 0, false
+			}
+			// Streamed WAVs leave the size unset (0 or 0xffffffff); trust
+			// the bytes actually present
+			if available := uint64(len(body)); size == 0 || size > available {
+				size = available
+			}
+			return time.Duration(size * uint64(time.Second) / byteRate), true
+		}
+
+		// Chunks are padded to an even size
+		next := uint64(i) + 8 + size + size%2
+		if next > uint64(len(data)) {
+			return 0, false
+		}
+		i = int(next)
+	}
+
+	return 0, false
+}
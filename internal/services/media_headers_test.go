@@ -0,0 +1,322 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"whats-convert-api/internal/samples"
+)
+
+// encodeJPEG returns a baseline JPEG from the standard library encoder
+func encodeJPEG(t testing.TB, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withSegment inserts a marker segment after the SOI of a JPEG
+func withSegment(data []byte, marker byte, payload []byte) []byte {
+	segment := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	out = append(out, payload...)
+	return append(out, data[2:]...)
+}
+
+// withSOF replaces the baseline frame marker (SOF0) with another SOFn
+func withSOF(data []byte, marker byte) []byte {
+	out := bytes.Clone(data)
+	i := bytes.Index(out, []byte{0xff, 0xc0})
+	out[i+1] = marker
+	return out
+}
+
+// sofHeight rewrites the height in a JPEG's frame header
+func sofHeight(data []byte, height uint16) []byte {
+	out := bytes.Clone(data)
+	i := bytes.Index(out, []byte{0xff, 0xc0})
+	binary.BigEndian.PutUint16(out[i+5:], height)
+	return out
+}
+
+func TestJpegDimensions(t *testing.T) {
+	baseline := encodeJPEG(t, 320, 240)
+	sample, ok := samples.Get("jpeg")
+	if !ok {
+		t.Fatal("jpeg sample missing")
+	}
+	sampleConfig, err := jpeg.DecodeConfig(bytes.NewReader(sample.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exif := append([]byte("Exif\x00\x00"), bytes.Repeat([]byte{0x2a}, 300)...)
+	sof := bytes.Index(baseline, []byte{0xff, 0xc0})
+
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+		ok            bool
+	}{
+		{"baseline", baseline, 320, 240, true},
+		{"embedded sample", sample.Data, sampleConfig.Width, sampleConfig.Height, true},
+		{"tall", encodeJPEG(t, 17, 1000), 17, 1000, true},
+		{"EXIF before the frame", withSegment(baseline, 0xe1, exif), 320, 240, true},
+		{"comment before the frame", withSegment(baseline, 0xfe, []byte("made by a test")), 320, 240, true},
+		{"fill bytes before a marker", append(append(baseline[:2:2], 0xff, 0xff), baseline[2:]...), 320, 240, true},
+		{"progressive", withSOF(baseline, 0xc2), 320, 240, true},
+		{"DHT is not a frame", withSOF(baseline, 0xc4), 0, 0, false},
+		{"height from DNL", sofHeight(baseline, 0), 0, 0, false},
+		{"truncated in the frame header", baseline[:sof+6], 0, 0, false},
+		{"truncated before the frame", baseline[:sof], 0, 0, false},
+		{"scan before the frame", append([]byte{0xff, 0xd8, 0xff, 0xda, 0x00, 0x08}, baseline[2:]...), 0, 0, false},
+		{"garbage after SOI", []byte{0xff, 0xd8, 0x00, 0x00, 0x00, 0x00}, 0, 0, false},
+		{"segment length below 2", []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x01, 0x00, 0x00}, 0, 0, false},
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0, 0, false},
+		{"empty", nil, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, ok := jpegDimensions(tt.data)
+			if ok != tt.ok || width != tt.width || height != tt.height {
+				t.Errorf("jpegDimensions = %d, %d, %v; want %d, %d, %v", width, height, ok, tt.width, tt.height, tt.ok)
+			}
+		})
+	}
+}
+
+// opusStream returns an Ogg Opus file (RFC 7845) of frames 20 ms frames of
+// silence with the given pre-skip, split over pages of 50 frames
+func opusStream(serial uint32, preSkip uint16, frames int) []byte {
+	head := []byte("OpusHead\x01\x01\x00\x00\x80\xbb\x00\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint16(head[10:], preSkip)
+	tags := []byte("OpusTags\x0b\x00\x00\x00whats-tests\x00\x00\x00\x00")
+
+	stream := oggPage(0x02, 0, serial, 0, [][]byte{head})
+	stream = append(stream, oggPage(0, 0, serial, 1, [][]byte{tags})...)
+
+	silence := []byte{0xf8, 0xff, 0xfe} // CELT fullband 20 ms frame of silence
+	sequence := uint32(2)
+	for sent := 0; sent < frames; sequence++ {
+		var packets [][]byte
+		for len(packets) < 50 && sent < frames {
+			packets = append(packets, silence)
+			sent++
+		}
+		headerType := byte(0)
+		if sent == frames {
+			headerType = 0x04 // End of stream
+		}
+		granule := uint64(preSkip) + uint64(sent)*960
+		stream = append(stream, oggPage(headerType, granule, serial, sequence, packets)...)
+	}
+	return stream
+}
+
+// vorbisStream returns the identification page of an Ogg Vorbis file and a
+// last page ending at granule
+func vorbisStream(rate uint32, granule uint64) []byte {
+	ident := make([]byte, 30)
+	copy(ident, "\x01vorbis")
+	ident[11] = 2 // Channels
+	binary.LittleEndian.PutUint32(ident[12:], rate)
+	ident[29] = 1
+
+	stream := oggPage(0x02, 0, 7, 0, [][]byte{ident})
+	return append(stream, oggPage(0x04, granule, 7, 1, [][]byte{{0x00}})...)
+}
+
+// setLastGranule overwrites the granule position of the last page
+func setLastGranule(stream []byte, granule uint64) []byte {
+	out := bytes.Clone(stream)
+	last := bytes.LastIndex(out, []byte("OggS"))
+	binary.LittleEndian.PutUint64(out[last+6:], granule)
+	return out
+}
+
+func TestOggDuration(t *testing.T) {
+	oneSecond := opusStream(1, 312, 50)
+	long := opusStream(1, 3840, 180)
+	multiplexed := append(opusStream(1, 312, 50), oggPage(0x04, 96000, 2, 5, [][]byte{{0xf8, 0xff, 0xfe}})...)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		duration time.Duration
+		ok       bool
+	}{
+		{"opus one second", oneSecond, time.Second, true},
+		{"opus over several pages", long, 3600 * time.Millisecond, true},
+		{"opus without pre-skip", opusStream(9, 0, 3), 60 * time.Millisecond, true},
+		{"vorbis 44.1 kHz", vorbisStream(44100, 88200), 2 * time.Second, true},
+		{"vorbis fractional", vorbisStream(8000, 12), 1500 * time.Microsecond, true},
+		{"vorbis zero rate", vorbisStream(0, 88200), 0, false},
+		{"headers only", opusStream(1, 312, 0), 0, false},
+		{"granule below pre-skip", setLastGranule(oneSecond, 100), 0, false},
+		{"no packet ends on the last page", setLastGranule(oneSecond, ^uint64(0)), 0, false},
+		{"granule past the duration range", setLastGranule(oneSecond, 1<<62), 0, false},
+		{"last page from another stream", multiplexed, 0, false},
+		{"truncated last page", oneSecond[:bytes.LastIndex(oneSecond, []byte("OggS"))+20], 0, false},
+		{"segment table past the end", []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xff"), 0, false},
+		{"not opus or vorbis", oggPage(0x02, 0, 1, 0, [][]byte{[]byte("Speex   1.2")}), 0, false},
+		{"WAV", pcmWAV(8000, 1, 16, 16000), 0, false},
+		{"empty", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duration, ok := oggDuration(tt.data)
+			if ok != tt.ok || duration != tt.duration {
+				t.Errorf("oggDuration = %s, %v; want %s, %v", duration, ok, tt.duration, tt.ok)
+			}
+		})
+	}
+}
+
+// wavChunk encodes a RIFF chunk, padded to an even size
+func wavChunk(id string, body []byte) []byte {
+	chunk := make([]byte, 8, 8+len(body)+1)
+	copy(chunk, id)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(body)))
+	chunk = append(chunk, body...)
+	if len(body)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// wavFile wraps chunks in a RIFF WAVE header
+func wavFile(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, chunk := range chunks {
+		body = append(body, chunk...)
+	}
+	return append(wavChunk("RIFF", body)[:8], body...)
+}
+
+// pcmFormat is the fmt chunk of linear PCM
+func pcmFormat(rate uint32, channels, bits uint16) []byte {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:], 1)
+	binary.LittleEndian.PutUint16(format[2:], channels)
+	binary.LittleEndian.PutUint32(format[4:], rate)
+	binary.LittleEndian.PutUint32(format[8:], rate*uint32(channels)*uint32(bits/8))
+	binary.LittleEndian.PutUint16(format[12:], channels*bits/8)
+	binary.LittleEndian.PutUint16(format[14:], bits)
+	return wavChunk("fmt ", format)
+}
+
+// pcmWAV returns a canonical 44-byte-header PCM WAV with size data bytes
+func pcmWAV(rate uint32, channels, bits uint16, size int) []byte {
+	return wavFile(pcmFormat(rate, channels, bits), wavChunk("data", make([]byte, size)))
+}
+
+// withDataSize overwrites the size of the data chunk
+func withDataSize(data []byte, size uint32) []byte {
+	out := bytes.Clone(data)
+	i := bytes.Index(out, []byte("data"))
+	binary.LittleEndian.PutUint32(out[i+4:], size)
+	return out
+}
+
+func TestWavDuration(t *testing.T) {
+	canonical := pcmWAV(8000, 1, 16, 16000)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		duration time.Duration
+		ok       bool
+	}{
+		{"canonical mono 8 kHz", canonical, time.Second, true},
+		{"stereo 44.1 kHz", pcmWAV(44100, 2, 16, 44100), 250 * time.Millisecond, true},
+		{"odd LIST chunk before data", wavFile(pcmFormat(8000, 1, 8), wavChunk("LIST", []byte("INFOISFT\x03\x00\x00\x00ab\x00")), wavChunk("data", make([]byte, 4000))), 500 * time.Millisecond, true},
+		{"streamed size unset", withDataSize(canonical, 0), time.Second, true},
+		{"streamed size maximal", withDataSize(canonical, 0xffffffff), time.Second, true},
+		{"truncated data", canonical[:44+8000], 500 * time.Millisecond, true},
+		{"data before fmt", wavFile(wavChunk("data", make([]byte, 100)), pcmFormat(8000, 1, 16)), 0, false},
+		{"zero byte rate", wavFile(wavChunk("fmt ", make([]byte, 16)), wavChunk("data", make([]byte, 100))), 0, false},
+		{"short fmt chunk", wavFile(wavChunk("fmt ", make([]byte, 8)), wavChunk("data", make([]byte, 100))), 0, false},
+		{"chunk past the end", wavFile(pcmFormat(8000, 1, 16), []byte("LIST\xff\xff\xff\x7f")), 0, false},
+		{"no data chunk", wavFile(pcmFormat(8000, 1, 16)), 0, false},
+		{"RIFF but not WAVE", append([]byte("RIFF\x04\x00\x00\x00AVI "), canonical[12:]...), 0, false},
+		{"Ogg", opusStream(1, 312, 1), 0, false},
+		{"empty", nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duration, ok := wavDuration(tt.data)
+			if ok != tt.ok || duration != tt.duration {
+				t.Errorf("wavDuration = %s, %v; want %s, %v", duration, ok, tt.duration, tt.ok)
+			}
+		})
+	}
+}
+
+func FuzzJpegDimensions(f *testing.F) {
+	baseline := encodeJPEG(f, 32, 24)
+	f.Add(baseline)
+	f.Add(withSegment(baseline, 0xe1, []byte("Exif\x00\x00")))
+	f.Add(withSOF(baseline, 0xc2))
+	f.Add([]byte{0xff, 0xd8, 0xff, 0xc0, 0x00, 0x11, 0x08})
+	f.Add([]byte{0xff, 0xd8, 0xff, 0xff, 0x01, 0xff, 0xd0})
+	if sample, ok := samples.Get("jpeg"); ok {
+		f.Add(sample.Data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		width, height, ok := jpegDimensions(data)
+		if ok && (width <= 0 || height <= 0) {
+			t.Errorf("jpegDimensions = %d, %d, true", width, height)
+		}
+		if !ok && (width != 0 || height != 0) {
+			t.Errorf("jpegDimensions = %d, %d, false", width, height)
+		}
+	})
+}
+
+func FuzzOggDuration(f *testing.F) {
+	f.Add(opusStream(1, 312, 50))
+	f.Add(opusStream(1, 0, 1))
+	f.Add(vorbisStream(44100, 88200))
+	f.Add(setLastGranule(opusStream(1, 312, 2), ^uint64(0)))
+	f.Add([]byte("OggS"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		duration, ok := oggDuration(data)
+		if duration < 0 || (!ok && duration != 0) {
+			t.Errorf("oggDuration = %s, %v", duration, ok)
+		}
+	})
+}
+
+func FuzzWavDuration(f *testing.F) {
+	f.Add(pcmWAV(8000, 1, 16, 160))
+	f.Add(withDataSize(pcmWAV(8000, 1, 16, 16), 0xffffffff))
+	f.Add(wavFile(pcmFormat(8000, 1, 8), wavChunk("LIST", []byte("odd")), wavChunk("data", make([]byte, 8))))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		duration, ok := wavDuration(data)
+		if duration < 0 || (!ok && duration != 0) {
+			t.Errorf("wavDuration = %s, %v", duration, ok)
+		}
+	})
+}
@@ -0,0 +1,762 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"whats-convert-api/internal/config"
+	"whats-convert-api/internal/providers"
+)
+
+var (
+	// ErrInvalidMigration is returned for migrations naming unknown
+	// providers or with out-of-range options
+	ErrInvalidMigration = errors.New("invalid migration")
+
+	// ErrMigrationRunning is returned when another migration hasn't finished
+	ErrMigrationRunning = errors.New("a migration is already running")
+
+	// ErrMigrationNotFound is returned for unknown migration IDs
+	ErrMigrationNotFound = errors.New("migration not found")
+
+	// ErrMigrationFinished is returned when cancelling a finished migration
+	ErrMigrationFinished = errors.New("migration already finished")
+)
+
+// MigrationStatus represents the status of a migration
+type MigrationStatus string
+
+const (
+	MigrationStatusRunning     MigrationStatus = "running"
+	MigrationStatusReconciling MigrationStatus = "reconciling"
+	MigrationStatusCompleted   MigrationStatus = "completed" // Every object was looked up; some may have failed
+	MigrationStatusFailed      MigrationStatus = "failed"    // The source couldn't be listed
+	MigrationStatusCancelled   MigrationStatus = "cancelled"
+)
+
+const (
+	// migrationDefaultConcurrency is how many objects are copied at once
+	// when the request doesn't say
+	migrationDefaultConcurrency = 4
+
+	// migrationMaxConcurrency bounds the objects copied at once
+	migrationMaxConcurrency = 32
+
+	// migrationMaxTranscodeSize bounds objects read into memory to transcode
+	migrationMaxTranscodeSize = 256 << 20
+
+	// migrationMaxListedKeys bounds the keys kept in a migration's failures
+	// and reconciliation report; the counts go on
+	migrationMaxListedKeys = 100
+
+	// migrationsKept is how many finished migrations stay listed
+	migrationsKept = 20
+)
+
+// MigrationOptions describes a migration
+type MigrationOptions struct {
+	Source            string // Provider objects are read from ("default" is the API's bucket)
+	Destination       string // Provider objects are written to
+	Prefix            string // Only keys starting with it ("" = the whole bucket)
+	Transcode         bool   // Convert images to WhatsApp JPEG and audio to Opus instead of copying them
+	Overwrite         bool   // Copy objects the destination already has
+	Concurrency       int    // Objects copied at once (0 = 4, at most 32)
+	MaxBytesPerSecond int64  // Read rate across all workers (0 = unthrottled)
+}
+
+// MigrationProgress counts the objects of a migration
+type MigrationProgress struct {
+	Listed     int64 `json:"listed" example:"1200"`           // Source objects found so far
+	Copied     int64 `json:"copied" example:"1100"`           // Copied unchanged
+	Transcoded int64 `json:"transcoded" example:"60"`         // Converted on the way
+	Skipped    int64 `json:"skipped" example:"30"`            // Already in the destination
+	Failed     int64 `json:"failed" example:"10"`             // See failures
+	Bytes      int64 `json:"bytes" example:"1073741824"`      // Bytes written to the destination
+	BytesRead  int64 `json:"bytes_read" example:"1100000000"` // Bytes read from the source
+}
+
+// MigrationFailure is an object that couldn't be migrated
+type MigrationFailure struct {
+	Key   string `json:"key" example:"uploads/2024/01/file.jpg"`
+	Error string `json:"error" example:"upload failed: connection reset"`
+}
+
+// MigrationReport reconciles the destination with the source once every
+// object was handled: each source object is looked up at its destination
+// key, and copies must have the source's size
+type MigrationReport struct {
+	Checked        int64    `json:"checked" example:"1200"`    // Source objects looked up
+	Matched        int64    `json:"matched" example:"1190"`    // Present in the destination (with the same size for copies)
+	Missing        int64    `json:"missing" example:"10"`      // Absent from the destination
+	Mismatched     int64    `json:"mismatched" example:"0"`    // Copies whose size differs from the source
+	MissingKeys    []string `json:"missing_keys,omitempty"`    // First 100 missing source keys
+	MismatchedKeys []string `json:"mismatched_keys,omitempty"` // First 100 mismatched source keys
+	OK             bool     `json:"ok" example:"false"`        // Every source object matched
+}
+
+// Migration tracks a bulk copy of objects from one provider to another
+type Migration struct {
+	ID                string             `json:"id" example:"5d1f3c9e-8a0b-4d51-9a9e-1f2f5f0b6c1a"`
+	Status            MigrationStatus    `json:"status" example:"running"`
+	Source            string             `json:"source" example:"default"`
+	Destination       string             `json:"destination" example:"r2"`
+	Prefix            string             `json:"prefix,omitempty" example:"uploads/2024/"`
+	Transcode         bool               `json:"transcode" example:"false"`
+	Overwrite         bool               `json:"overwrite" example:"false"`
+	Concurrency       int                `json:"concurrency" example:"4"`
+	MaxBytesPerSecond int64              `json:"max_bytes_per_second,omitempty" example:"10485760"`
+	Progress          MigrationProgress  `json:"progress"`
+	Failures          []MigrationFailure `json:"failures,omitempty"` // First 100 objects that failed
+	Error             string             `json:"error,omitempty"`    // Why a failed migration stopped
+	Report            *MigrationReport   `json:"report,omitempty"`   // Set once the migration completed
+	StartTime         time.Time          `json:"start_time"`
+	EndTime           *time.Time         `json:"end_time,omitempty"`
+
+	// Internal fields
+	renamed map[string]string // Source keys stored under another key (transcoded outputs)
+	cancel  context.CancelFunc
+	done    chan struct{}
+	mu      sync.Mutex
+}
+
+// Finished reports whether the migration reached a final status
+func (m *Migration) Finished() bool {
+	switch m.Status {
+	case MigrationStatusCompleted, MigrationStatusFailed, MigrationStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// snapshot copies the exported fields under the lock
+func (m *Migration) snapshot() *Migration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := &Migration{
+		ID:                m.ID,
+		Status:            m.Status,
+		Source:            m.Source,
+		Destination:       m.Destination,
+		Prefix:            m.Prefix,
+		Transcode:         m.Transcode,
+		Overwrite:         m.Overwrite,
+		Concurrency:       m.Concurrency,
+		MaxBytesPerSecond: m.MaxBytesPerSecond,
+		Progress:          m.Progress,
+		Failures:          append([]MigrationFailure(nil), m.Failures...),
+		Error:             m.Error,
+		StartTime:         m.StartTime,
+		EndTime:           m.EndTime,
+	}
+	if m.Report != nil {
+		report := *m.Report
+		copied.Report = &report
+	}
+	return copied
+}
+
+// fail records an object that couldn't be migrated
+func (m *Migration) fail(key string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Progress.Failed++
+	if len(m.Failures) < migrationMaxListedKeys {
+		m.Failures = append(m.Failures, MigrationFailure{Key: key, Error: err.Error()})
+	}
+}
+
+// count applies update to the progress under the lock
+func (m *Migration) count(update func(progress *MigrationProgress)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	update(&m.Progress)
+}
+
+// MigrationManager copies objects between the API's bucket and the buckets
+// of S3_MIGRATION_PROVIDERS through the provider interface, so any two
+// providers can be paired (AWS to R2, MinIO to B2…). One migration runs at
+// a time; objects are streamed through the API, as S3 has no copy across
+// providers.
+type MigrationManager struct {
+	store          *S3Service
+	configs        map[string]*config.S3Configuration
+	factory        *providers.ProviderFactory
+	audioConverter AudioConverterIface
+	imageConverter ImageConverterIface
+	providers      map[string]providers.S3Provider // Created on first use and kept, so mock buckets persist
+	migrations     map[string]*Migration
+	running        *Migration
+	mu             sync.Mutex
+	wg             sync.WaitGroup
+}
+
+// NewMigrationManager creates a manager for the API's bucket and the
+// migration providers of its configuration
+func NewMigrationManager(store *S3Service, audioConverter AudioConverterIface, imageConverter ImageConverterIface) *MigrationManager {
+	configs := make(map[string]*config.S3Configuration)
+	for name, cfg := range store.GetConfig().MigrationProviders {
+		configs[name] = cfg
+	}
+
+	return &MigrationManager{
+		store:          store,
+		configs:        configs,
+		factory:        providers.NewProviderFactory(),
+		audioConverter: audioConverter,
+		imageConverter: imageConverter,
+		providers:      make(map[string]providers.S3Provider),
+		migrations:     make(map[string]*Migration),
+	}
+}
+
+// Providers lists the names migrations can use, "default" first
+func (mm *MigrationManager) Providers() []string {
+	names := make([]string, 0, len(mm.configs))
+	for name := range mm.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{config.MigrationDefaultProvider}, names...)
+}
+
+// Start validates opts and migrates in the background
+func (mm *MigrationManager) Start(opts MigrationOptions) (*Migration, error) {
+	if opts.Source == "" {
+		opts.Source = config.MigrationDefaultProvider
+	}
+	if opts.Destination == "" {
+		opts.Destination = config.MigrationDefaultProvider
+	}
+	if opts.Source == opts.Destination {
+		return nil, fmt.Errorf("%w: source and destination are both %q", ErrInvalidMigration, opts.Source)
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = migrationDefaultConcurrency
+	}
+	if opts.Concurrency < 1 || opts.Concurrency > migrationMaxConcurrency {
+		return nil, fmt.Errorf("%w: concurrency must be between 1 and %d", ErrInvalidMigration, migrationMaxConcurrency)
+	}
+	if opts.MaxBytesPerSecond < 0 {
+		return nil, fmt.Errorf("%w: max_bytes_per_second can't be negative", ErrInvalidMigration)
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	source, err := mm.provider(opts.Source)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := mm.provider(opts.Destination)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := source.(providers.ObjectReader); !ok {
+		return nil, fmt.Errorf("%w: %s can't read objects back", providers.ErrFeatureNotSupported, opts.Source)
+	}
+
+	if mm.running != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMigrationRunning, mm.running.ID)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	migration := &Migration{
+		ID:                uuid.New().String(),
+		Status:            MigrationStatusRunning,
+		Source:            opts.Source,
+		Destination:       opts.Destination,
+		Prefix:            opts.Prefix,
+		Transcode:         opts.Transcode,
+		Overwrite:         opts.Overwrite,
+		Concurrency:       opts.Concurrency,
+		MaxBytesPerSecond: opts.MaxBytesPerSecond,
+		StartTime:         time.Now(),
+		renamed:           make(map[string]string),
+		cancel:            cancel,
+		done:              make(chan struct{}),
+	}
+	mm.migrations[migration.ID] = migration
+	mm.running = migration
+	mm.pruneLocked()
+
+	log.Printf("🚚 Migration %s started: %s → %s, prefix %q", migration.ID, opts.Source, opts.Destination, opts.Prefix)
+
+	mm.wg.Add(1)
+	go func() {
+		defer mm.wg.Done()
+		mm.run(ctx, migration, source, destination, newByteLimiter(opts.MaxBytesPerSecond))
+	}()
+
+	return migration.snapshot(), nil
+}
+
+// provider returns the provider named name, creating it on first use.
+// Called with mm.mu held.
+func (mm *MigrationManager) provider(name string) (providers.S3Provider, error) {
+	if name == config.MigrationDefaultProvider {
+		provider := mm.store.activeProvider()
+		if provider == nil {
+			return nil, fmt.Errorf("S3 provider not initialized")
+		}
+		return provider, nil
+	}
+	if provider, ok := mm.providers[name]; ok {
+		return provider, nil
+	}
+
+	cfg, ok := mm.configs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown provider %q (use %s)", ErrInvalidMigration, name, strings.Join(mm.Providers(), ", "))
+	}
+	provider, err := mm.factory.CreateProvider(cfg.ToProviderConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s provider: %w", name, err)
+	}
+	provider = providers.NewTracingProvider(provider, string(cfg.Provider))
+	mm.providers[name] = provider
+	return provider, nil
+}
+
+// pruneLocked forgets the oldest finished migrations beyond migrationsKept
+func (mm *MigrationManager) pruneLocked() {
+	var finished []*Migration
+	for _, migration := range mm.migrations {
+		if migration != mm.running {
+			finished = append(finished, migration)
+		}
+	}
+	if len(finished) <= migrationsKept {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].StartTime.Before(finished[j].StartTime) })
+	for _, migration := range finished[:len(finished)-migrationsKept] {
+		delete(mm.migrations, migration.ID)
+	}
+}
+
+// Get returns a copy of a migration
+func (mm *MigrationManager) Get(id string) (*Migration, error) {
+	mm.mu.Lock()
+	migration, ok := mm.migrations[id]
+	mm.mu.Unlock()
+
+	if !ok {
+		return nil, ErrMigrationNotFound
+	}
+	return migration.snapshot(), nil
+}
+
+// List returns copies of the kept migrations, newest first
+func (mm *MigrationManager) List() []*Migration {
+	mm.mu.Lock()
+	migrations := make([]*Migration, 0, len(mm.migrations))
+	for _, migration := range mm.migrations {
+		migrations = append(migrations, migration)
+	}
+	mm.mu.Unlock()
+
+	snapshots := make([]*Migration, len(migrations))
+	for i, migration := range migrations {
+		snapshots[i] = migration.snapshot()
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].StartTime.After(snapshots[j].StartTime) })
+	return snapshots
+}
+
+// Cancel stops a running migration. Objects already copied stay in the
+// destination.
+func (mm *MigrationManager) Cancel(id string) (*Migration, error) {
+	mm.mu.Lock()
+	migration, ok := mm.migrations[id]
+	mm.mu.Unlock()
+
+	if !ok {
+		return nil, ErrMigrationNotFound
+	}
+
+	migration.mu.Lock()
+	finished := migration.Finished()
+	migration.mu.Unlock()
+	if finished {
+		return nil, ErrMigrationFinished
+	}
+
+	migration.cancel()
+	<-migration.done
+	return migration.snapshot(), nil
+}
+
+// Stop cancels the running migration and waits for it
+func (mm *MigrationManager) Stop() {
+	mm.mu.Lock()
+	if mm.running != nil {
+		mm.running.cancel()
+	}
+	mm.mu.Unlock()
+
+	mm.wg.Wait()
+}
+
+// run copies every listed object, then reconciles the destination
+func (mm *MigrationManager) run(ctx context.Context, migration *Migration, source, destination providers.S3Provider, limiter *byteLimiter) {
+	defer func() {
+		migration.cancel()
+		close(migration.done)
+
+		mm.mu.Lock()
+		mm.running = nil
+		mm.mu.Unlock()
+	}()
+
+	err := walkObjects(ctx, source, migration.Prefix, migration.Concurrency, func(object providers.ObjectSummary) {
+		migration.count(func(progress *MigrationProgress) { progress.Listed++ })
+		if err := mm.migrateObject(ctx, migration, source, destination, object, limiter); err != nil && ctx.Err() == nil {
+			migration.fail(object.Key, err)
+		}
+	})
+	if err == nil && ctx.Err() == nil {
+		migration.mu.Lock()
+		migration.Status = MigrationStatusReconciling
+		migration.mu.Unlock()
+
+		var report *MigrationReport
+		report, err = reconcileMigration(ctx, migration, source, destination)
+		migration.mu.Lock()
+		migration.Report = report
+		migration.mu.Unlock()
+	}
+
+	migration.mu.Lock()
+	defer migration.mu.Unlock()
+
+	now := time.Now()
+	migration.EndTime = &now
+	switch {
+	case ctx.Err() != nil:
+		migration.Status = MigrationStatusCancelled
+	case err != nil:
+		migration.Status = MigrationStatusFailed
+		migration.Error = err.Error()
+	default:
+		migration.Status = MigrationStatusCompleted
+	}
+
+	log.Printf("🚚 Migration %s %s: %d listed, %d copied, %d transcoded, %d skipped, %d failed",
+		migration.ID, migration.Status, migration.Progress.Listed, migration.Progress.Copied,
+		migration.Progress.Transcoded, migration.Progress.Skipped, migration.Progress.Failed)
+}
+
+// walkObjects lists every object under prefix, a page at a time, and hands
+// them to fn on workers goroutines. It returns the listing error, if any.
+func walkObjects(ctx context.Context, provider providers.S3Provider, prefix string, workers int, fn func(object providers.ObjectSummary)) error {
+	objects := make(chan providers.ObjectSummary)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for object := range objects {
+				fn(object)
+			}
+		}()
+	}
+
+	err := func() error {
+		defer close(objects)
+
+		opts := providers.ListOptions{Prefix: prefix, Limit: providers.MaxListLimit}
+		for {
+			list, err := provider.ListObjects(ctx, opts)
+			if err != nil {
+				return fmt.Errorf("list objects: %w", err)
+			}
+			for _, object := range list.Objects {
+				select {
+				case objects <- object:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			if list.NextCursor == "" {
+				return nil
+			}
+			opts.Cursor = list.NextCursor
+		}
+	}()
+
+	wg.Wait()
+	return err
+}
+
+// migrateObject copies one object, or transcodes it when the migration
+// transcodes and it is an image or audio
+func (mm *MigrationManager) migrateObject(ctx context.Context, migration *Migration, source, destination providers.S3Provider, object providers.ObjectSummary, limiter *byteLimiter) error {
+	info, err := source.GetObjectInfo(ctx, object.Key)
+	if err != nil {
+		return err
+	}
+
+	kind := ""
+	if migration.Transcode {
+		kind = transcodeKind(info.ContentType)
+	}
+	key := object.Key
+	if kind != "" {
+		key = migratedKey(object.Key, transcodeContentType(kind))
+	}
+
+	if !migration.Overwrite {
+		// A transcoded output never has its source's size, so it only has to exist
+		if existing, err := destination.GetObjectInfo(ctx, key); err == nil && (kind != "" || existing.Size == info.Size) {
+			mm.recordKey(migration, object.Key, key)
+			migration.count(func(progress *MigrationProgress) { progress.Skipped++ })
+			return nil
+		}
+	}
+
+	body, err := source.(providers.ObjectReader).GetObject(ctx, object.Key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var read atomic.Int64
+	reader := &throttledReader{ctx: ctx, reader: body, limiter: limiter, read: &read}
+	defer func() {
+		migration.count(func(progress *MigrationProgress) { progress.BytesRead += read.Load() })
+	}()
+
+	opts := providers.UploadOptions{
+		ContentType: info.ContentType,
+		Metadata:    info.Metadata,
+		Public:      mm.publicRead(migration.Destination),
+	}
+
+	if kind == "" {
+		result, err := destination.Upload(ctx, key, reader, info.Size, opts)
+		if err != nil {
+			return err
+		}
+		migration.count(func(progress *MigrationProgress) {
+			progress.Copied++
+			progress.Bytes += result.Size
+		})
+		return nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, migrationMaxTranscodeSize+1))
+	if err != nil {
+		return fmt.Errorf("read object: %w", err)
+	}
+	if len(data) > migrationMaxTranscodeSize {
+		return fmt.Errorf("%w: objects above %d MB aren't transcoded", providers.ErrFileTooLarge, migrationMaxTranscodeSize>>20)
+	}
+
+	output, contentType, err := mm.transcode(ctx, kind, data)
+	if err != nil {
+		return err
+	}
+	opts.ContentType = contentType
+	result, err := destination.Upload(ctx, key, bytes.NewReader(output), int64(len(output)), opts)
+	if err != nil {
+		return err
+	}
+	mm.recordKey(migration, object.Key, key)
+	migration.count(func(progress *MigrationProgress) {
+		progress.Transcoded++
+		progress.Bytes += result.Size
+	})
+	return nil
+}
+
+// recordKey remembers a source key stored under another key, for reconciliation
+func (mm *MigrationManager) recordKey(migration *Migration, sourceKey, key string) {
+	if sourceKey == key {
+		return
+	}
+	migration.mu.Lock()
+	migration.renamed[sourceKey] = key
+	migration.mu.Unlock()
+}
+
+// publicRead reports whether objects written to the provider are public
+func (mm *MigrationManager) publicRead(name string) bool {
+	if cfg, ok := mm.configs[name]; ok {
+		return cfg.PublicRead
+	}
+	return mm.store.GetConfig().PublicRead
+}
+
+// transcode converts an image to WhatsApp JPEG or audio to Opus with the
+// default options, taking a conversion worker like a request would
+func (mm *MigrationManager) transcode(ctx context.Context, kind string, data []byte) ([]byte, string, error) {
+	switch kind {
+	case MediaKindImage:
+		response, err := mm.imageConverter.Convert(ctx, &ImageRequest{Input: data, RawOutput: true})
+		if err != nil {
+			return nil, "", fmt.Errorf("transcode image: %w", err)
+		}
+		return response.Output, response.MimeType, nil
+	case MediaKindAudio:
+		response, err := mm.audioConverter.Convert(ctx, &AudioRequest{Input: data, RawOutput: true})
+		if err != nil {
+			return nil, "", fmt.Errorf("transcode audio: %w", err)
+		}
+		return response.Output, response.MimeType, nil
+	}
+	return nil, "", fmt.Errorf("can't transcode %s", kind)
+}
+
+// transcodeKind returns the kind of media a content type is transcoded as,
+// or "" for objects copied as they are. GIFs stay GIFs so animations survive,
+// and SVGs aren't raster images.
+func transcodeKind(contentType string) string {
+	base, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	base = strings.TrimSpace(base)
+	switch {
+	case base == "image/gif" || base == "image/svg+xml":
+		return ""
+	case strings.HasPrefix(base, "image/"):
+		return MediaKindImage
+	case strings.HasPrefix(base, "audio/"):
+		return MediaKindAudio
+	}
+	return ""
+}
+
+// transcodeContentType is the type transcoded outputs of a kind are stored as
+func transcodeContentType(kind string) string {
+	if kind == MediaKindAudio {
+		return "audio/ogg"
+	}
+	return "image/jpeg"
+}
+
+// migratedKey replaces the extension of key by the usual one of contentType
+func migratedKey(key, contentType string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "." + extensionForContentType(contentType)
+}
+
+// reconcileMigration looks every source object up in the destination
+func reconcileMigration(ctx context.Context, migration *Migration, source, destination providers.S3Provider) (*MigrationReport, error) {
+	report := &MigrationReport{}
+	var mu sync.Mutex
+
+	err := walkObjects(ctx, source, migration.Prefix, migration.Concurrency, func(object providers.ObjectSummary) {
+		migration.mu.Lock()
+		key, renamed := migration.renamed[object.Key]
+		migration.mu.Unlock()
+		if !renamed {
+			key = object.Key
+		}
+
+		info, err := destination.GetObjectInfo(ctx, key)
+		if ctx.Err() != nil {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		report.Checked++
+		switch {
+		case err != nil:
+			report.Missing++
+			if len(report.MissingKeys) < migrationMaxListedKeys {
+				report.MissingKeys = append(report.MissingKeys, object.Key)
+			}
+		case !renamed && info.Size != object.Size:
+			report.Mismatched++
+			if len(report.MismatchedKeys) < migrationMaxListedKeys {
+				report.MismatchedKeys = append(report.MismatchedKeys, object.Key)
+			}
+		default:
+			report.Matched++
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(report.MissingKeys)
+	sort.Strings(report.MismatchedKeys)
+	report.OK = report.Missing == 0 && report.Mismatched == 0
+	return report, nil
+}
+
+// byteLimiter spreads reads so they average at most rate bytes per second
+// across every reader sharing it
+type byteLimiter struct {
+	rate int64
+	next time.Time // When the bytes read so far are paid for
+	mu   sync.Mutex
+}
+
+// newByteLimiter returns a limiter for rate bytes per second, or nil (no
+// limit) when rate is 0
+func newByteLimiter(rate int64) *byteLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &byteLimiter{rate: rate}
+}
+
+// wait blocks until n more bytes fit in the rate
+func (l *byteLimiter) wait(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledReader counts what it reads and paces it by limiter
+type throttledReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *byteLimiter
+	read    *atomic.Int64
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read.Add(int64(n))
+	if waitErr := r.limiter.wait(r.ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
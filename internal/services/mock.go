@@ -0,0 +1,426 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"mime/multipart"
+	"strings"
+	"sync"
+	"time"
+
+	"whats-convert-api/internal/providers"
+)
+
+const (
+	// mockAudioDuration is the length in seconds of the canned Opus clip
+	mockAudioDuration = 1
+
+	// mockImageSize is the edge length in pixels of the canned JPEG
+	mockImageSize = 16
+)
+
+var (
+	mockAudioOnce sync.Once
+	mockAudio     []byte
+	mockMP3Once   sync.Once
+	mockMP3       []byte
+	mockWAVOnce   sync.Once
+	mockWAV       []byte
+	mockImageOnce sync.Once
+	mockImage     []byte
+)
+
+// SetMockMode toggles deterministic canned responses instead of running FFmpeg
+func (ac *AudioConverter) SetMockMode(enabled bool) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.mockMode = enabled
+}
+
+// SetMockMode toggles deterministic canned responses instead of running vips/FFmpeg
+func (ic *ImageConverter) SetMockMode(enabled bool) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.mockMode = enabled
+}
+
+func (ac *AudioConverter) isMockMode() bool {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+
+	return ac.mockMode
+}
+
+func (ic *ImageConverter) isMockMode() bool {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	return ic.mockMode
+}
+
+// mockConvert validates the request like a real conversion and returns a
+// canned clip in the requested format
+func (ac *AudioConverter) mockConvert(ctx context.Context, req *AudioRequest) (*AudioResponse, error) {
+	start := time.Now()
+
+	format, _, err := audioOutput(req)
+	if err != nil {
+		ac.recordFailure()
+		return nil, err
+	}
+
+	if err := mockSource(ctx, ac.objectStore(), req.Source, &req.Input); err != nil {
+		ac.recordFailure()
+		return nil, err
+	}
+
+	input := req.Input
+	if req.Upload != nil {
+		// Canned output never reads the upload, only its size matters
+		input = make([]byte, min(req.Upload.Size, 1))
+	}
+	if err := validateMockInput(ctx, input, req.Data, req.IsURL); err != nil {
+		ac.recordFailure()
+		return nil, err
+	}
+	media := mockMediaType(req.Input, req.Upload, req.Data, req.IsURL)
+	if err := checkInputKind(media, "audio", MediaKindImage); err != nil {
+		ac.recordFailure()
+		return nil, err
+	}
+
+	var output []byte
+	switch format {
+	case AudioFormatMP3:
+		output = mockMP3Audio()
+	case AudioFormatWAV:
+		output = mockWAVAudio()
+	default:
+		output = mockOpusAudio()
+	}
+	ac.recordSuccess(time.Since(start), false)
+
+	response := &AudioResponse{
+		MimeType: format.MimeType(),
+		Duration: mockAudioDuration,
+		Size:     len(output),
+		Input:    &media,
+	}
+	if format == AudioFormatOpus {
+		// Split like real encodes, so /stats shows the variants
+		response.EncoderVariant, _ = ac.encoders.pick()
+		ac.encoders.record(response.EncoderVariant, time.Since(start), len(output), nil)
+	}
+	if req.IncludeWaveform {
+		// The canned clip is silence
+		response.Waveform = base64.StdEncoding.EncodeToString(waveformFromPCM(nil))
+	}
+	if req.Fingerprint && !req.IsURL {
+		// The input's checksum is real; fpcalc doesn't run, so its hash is silence's
+		source := mediaInput{data: req.Input, file: req.Upload}
+		if req.Input == nil && req.Upload == nil {
+			source.data, _ = providers.DecodeBase64(req.Data)
+		}
+		checksum, _ := inputSHA256(source)
+		response.Fingerprint = &Fingerprint{
+			Kind:      MediaKindAudio,
+			Algorithm: FingerprintChromaprint,
+			Hash:      fmt.Sprintf("%08x", 0),
+			SHA256:    checksum,
+			Duration:  mockAudioDuration,
+		}
+	}
+	if targetBytes := targetSizeBytes(req.TargetSizeMB); targetBytes > 0 && format != AudioFormatWAV {
+		// Report the bitrate a real clip of the canned length would get
+		response.Bitrate = min(bitrateForSize(targetBytes, mockAudioDuration), defaultAudioBitrate)
+		if format == AudioFormatMP3 {
+			response.Bitrate = mp3Bitrate(response.Bitrate)
+		}
+	}
+	if req.Sink != nil {
+		if _, err := req.Sink.Write(output); err != nil {
+			return nil, fmt.Errorf("write output: %w", err)
+		}
+		return response, nil
+	}
+	response.setOutput(output, req)
+
+	return response, nil
+}
+
+// mockConvert validates the request like a real conversion and returns a canned JPEG
+func (ic *ImageConverter) mockConvert(ctx context.Context, req *ImageRequest) (*ImageResponse, error) {
+	start := time.Now()
+
+	if err := mockSource(ctx, ic.objectStore(), req.Source, &req.Input); err != nil {
+		ic.recordFailure()
+		return nil, err
+	}
+	if err := validateMockInput(ctx, req.Input, req.Data, req.IsURL); err != nil {
+		ic.recordFailure()
+		return nil, err
+	}
+	media := mockMediaType(req.Input, nil, req.Data, req.IsURL)
+	if err := checkInputKind(media, "an image", MediaKindAudio); err != nil {
+		ic.recordFailure()
+		return nil, err
+	}
+
+	output := mockJPEGImage()
+
+	// The metadata policy runs for real, so its EXIF handling can be checked
+	input := req.Input
+	if input == nil && !req.IsURL {
+		input, _ = providers.DecodeBase64(req.Data)
+	}
+	exif, metadata := readImageMetadata(input, ic.imageMetadataPolicy())
+	if exif != nil {
+		output, metadata.Retained = embedImageEXIF(output, exif, true)
+	}
+	output = ic.stampOutput(ctx, output)
+	ic.recordFFmpegSuccess(time.Since(start))
+
+	response := &ImageResponse{
+		MimeType: imageMimeType,
+		Width:    mockImageSize,
+		Height:   mockImageSize,
+		Size:     len(output),
+		Input:    &media,
+		Metadata: metadata,
+	}
+	if req.Fingerprint && input != nil {
+		// Hashed for real: JPEG, PNG and GIF need no FFmpeg
+		response.Fingerprint = conversionFingerprint(ctx, mediaInput{data: input}, MediaKindImage)
+	}
+	if req.GenerateThumbnail {
+		// The canned image is already thumbnail-sized
+		response.JPEGThumbnail = base64.StdEncoding.EncodeToString(output)
+	}
+	if req.MaxFileSizeKB > 0 {
+		// The canned image fits any target at the requested quality
+		response.EncodedQuality = req.Quality
+		if response.EncodedQuality <= 0 || response.EncodedQuality > 100 {
+			response.EncodedQuality = 95
+		}
+	}
+	response.setOutput(output, req)
+
+	return response, nil
+}
+
+// mockSource reads the object a request names into input, as the bucket is
+// real even in mock mode
+func mockSource(ctx context.Context, store *S3Service, source *InputSource, input *[]byte) error {
+	if source == nil {
+		return nil
+	}
+	object, _, err := objectInput(ctx, store, nil, source.S3Key, maxImageInputSize)
+	if err != nil {
+		return err
+	}
+	*input = object.data
+	return nil
+}
+
+// mockMediaType detects the format of a mock input from its magic bytes
+// alone, as mock mode runs no ffprobe; URLs aren't fetched and stay unknown
+func mockMediaType(input []byte, upload *multipart.FileHeader, data string, isURL bool) MediaType {
+	var media MediaType
+	switch {
+	case upload != nil:
+		media, _ = SniffUpload(upload)
+	case input != nil:
+		media, _ = SniffBytes(input[:min(len(input), sniffLen)])
+	case isURL:
+		media = unknownMediaType
+	default:
+		media, _ = SniffBase64(data)
+	}
+	return media
+}
+
+// validateMockInput applies the same input checks as real conversions without
+// touching the network: URLs must be http(s), base64 payloads must decode
+func validateMockInput(ctx context.Context, input []byte, data string, isURL bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if input != nil {
+		if len(input) == 0 {
+			return fmt.Errorf("empty input data")
+		}
+		return nil
+	}
+
+	if isURL {
+		lower := strings.ToLower(strings.TrimSpace(data))
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+			return fmt.Errorf("download failed: unsupported URL: %s", data)
+		}
+		return nil
+	}
+
+	decoded, err := providers.DecodeBase64(data)
+	if err != nil {
+		return fmt.Errorf("base64 decode failed: %w", err)
+	}
+	if len(decoded) == 0 {
+		return fmt.Errorf("empty input data")
+	}
+
+	return nil
+}
+
+// mockJPEGImage returns a small, deterministic grey JPEG
+func mockJPEGImage() []byte {
+	mockImageOnce.Do(func() {
+		img := image.NewGray(image.Rect(0, 0, mockImageSize, mockImageSize))
+		for i := range img.Pix {
+			img.Pix[i] = color.Gray{Y: 0x80}.Y
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err == nil {
+			mockImage = buf.Bytes()
+		}
+	})
+
+	return mockImage
+}
+
+// mockOpusAudio returns a deterministic one-second mono Ogg/Opus clip of silence
+func mockOpusAudio() []byte {
+	mockAudioOnce.Do(func() {
+		const (
+			serial      = 0x4d4f434b // "MOCK"
+			preSkip     = 312
+			frameSize   = 960 // 20ms at 48kHz
+			frameCount  = mockAudioDuration * 50
+			granuleBase = preSkip
+		)
+
+		// OpusHead identification header (RFC 7845 §5.1)
+		head := make([]byte, 19)
+		copy(head, "OpusHead")
+		head[8] = 1 // version
+		head[9] = 1 // channels
+		binary.LittleEndian.PutUint16(head[10:], preSkip)
+		binary.LittleEndian.PutUint32(head[12:], 48000)
+
+		// OpusTags comment header (RFC 7845 §5.2)
+		vendor := "whats-convert-api mock"
+		tags := make([]byte, 8+4+len(vendor)+4)
+		copy(tags, "OpusTags")
+		binary.LittleEndian.PutUint32(tags[8:], uint32(len(vendor)))
+		copy(tags[12:], vendor)
+
+		// Silent CELT fullband 20ms mono frames
+		packets := make([][]byte, frameCount)
+		for i := range packets {
+			packets[i] = []byte{0xf8, 0xff, 0xfe}
+		}
+
+		var buf bytes.Buffer
+		buf.Write(oggPage(0x02, 0, serial, 0, [][]byte{head}))
+		buf.Write(oggPage(0x00, 0, serial, 1, [][]byte{tags}))
+		buf.Write(oggPage(0x04, granuleBase+frameCount*frameSize, serial, 2, packets))
+		mockAudio = buf.Bytes()
+	})
+
+	return mockAudio
+}
+
+// mockMP3Audio returns a deterministic one-second mono MP3 of silence
+func mockMP3Audio() []byte {
+	mockMP3Once.Do(func() {
+		const (
+			frameSize  = 417 // 128kbit/s at 44.1kHz without padding
+			frameCount = mockAudioDuration * 39
+		)
+
+		// MPEG-1 Layer III, 128kbit/s, 44.1kHz, mono; zeroed side info and
+		// main data decode to silence
+		frame := make([]byte, frameSize)
+		copy(frame, []byte{0xff, 0xfb, 0x90, 0xc0})
+
+		mockMP3 = bytes.Repeat(frame, frameCount)
+	})
+
+	return mockMP3
+}
+
+// mockWAVAudio returns a deterministic one-second 16kHz mono PCM WAV of silence
+func mockWAVAudio() []byte {
+	mockWAVOnce.Do(func() {
+		const (
+			sampleRate = 16000
+			dataSize   = mockAudioDuration * sampleRate * 2
+		)
+
+		wav := make([]byte, 44+dataSize)
+		copy(wav, "RIFF")
+		binary.LittleEndian.PutUint32(wav[4:], 36+dataSize)
+		copy(wav[8:], "WAVEfmt ")
+		binary.LittleEndian.PutUint32(wav[16:], 16)           // fmt chunk size
+		binary.LittleEndian.PutUint16(wav[20:], 1)            // PCM
+		binary.LittleEndian.PutUint16(wav[22:], 1)            // mono
+		binary.LittleEndian.PutUint32(wav[24:], sampleRate)   // sample rate
+		binary.LittleEndian.PutUint32(wav[28:], sampleRate*2) // byte rate
+		binary.LittleEndian.PutUint16(wav[32:], 2)            // block align
+		binary.LittleEndian.PutUint16(wav[34:], 16)           // bits per sample
+		copy(wav[36:], "data")
+		binary.LittleEndian.PutUint32(wav[40:], dataSize)
+
+		mockWAV = wav
+	})
+
+	return mockWAV
+}
+
+// oggPage encodes packets (each shorter than 255 bytes) into a single Ogg page
+func oggPage(headerType byte, granule uint64, serial, sequence uint32, packets [][]byte) []byte {
+	var body bytes.Buffer
+	segments := make([]byte, 0, len(packets))
+	for _, packet := range packets {
+		segments = append(segments, byte(len(packet)))
+		body.Write(packet)
+	}
+
+	page := make([]byte, 27, 27+len(segments)+body.Len())
+	copy(page, "OggS")
+	page[5] = headerType
+	binary.LittleEndian.PutUint64(page[6:], granule)
+	binary.LittleEndian.PutUint32(page[14:], serial)
+	binary.LittleEndian.PutUint32(page[18:], sequence)
+	page[26] = byte(len(segments))
+	page = append(page, segments...)
+	page = append(page, body.Bytes()...)
+
+	binary.LittleEndian.PutUint32(page[22:], oggChecksum(page))
+	return page
+}
+
+// oggChecksum computes the Ogg CRC-32 (polynomial 0x04c11db7, no reflection)
+func oggChecksum(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc ^= uint32(b) << 24
+		for i := 0; i < 8; i++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
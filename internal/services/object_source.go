@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"whats-convert-api/internal/providers"
+)
+
+// Errors of inputs read from the bucket
+var (
+	ErrInvalidSource        = errors.New("invalid source")
+	ErrSourceObjectNotFound = errors.New("source object not found")
+	ErrSourceTooLarge       = errors.New("source object too large")
+)
+
+// maxSourceKeyLen is the longest key S3 accepts
+const maxSourceKeyLen = 1024
+
+// InputSource names an input stored in the configured bucket, read by the
+// API instead of being sent in data
+type InputSource struct {
+	S3Key string `json:"s3_key" example:"uploads/2024/01/voice.ogg"` // Key of the object to convert
+}
+
+// checkSource rejects a source sent together with data, or without a key
+func checkSource(source *InputSource, data string) error {
+	if source == nil {
+		return nil
+	}
+	switch {
+	case strings.TrimSpace(data) != "":
+		return fmt.Errorf("%w: send either data or source, not both", ErrInvalidSource)
+	case strings.TrimSpace(source.S3Key) == "":
+		return fmt.Errorf("%w: s3_key is required", ErrInvalidSource)
+	case len(source.S3Key) > maxSourceKeyLen:
+		return fmt.Errorf("%w: s3_key is longer than %d bytes", ErrInvalidSource, maxSourceKeyLen)
+	}
+	return nil
+}
+
+// objectInput streams an object from the bucket into memory, moving to a
+// spill file once it reaches the spill threshold, like downloadInput. The
+// returned release func must be called once the input is no longer read; it
+// is never nil.
+func objectInput(ctx context.Context, store *S3Service, spill *SpillStore, key string, maxSize int64) (mediaInput, func(), error) {
+	if store == nil {
+		return mediaInput{}, func() {}, fmt.Errorf("%w: S3 is not enabled", ErrInvalidSource)
+	}
+
+	if spill == nil {
+		var buffer bytes.Buffer
+		if _, err := store.StreamObject(ctx, key, &buffer, maxSize); err != nil {
+			return mediaInput{}, func() {}, sourceError(key, err)
+		}
+		return mediaInput{data: buffer.Bytes()}, func() {}, nil
+	}
+
+	w := &spillWriter{store: spill}
+	n, err := store.StreamObject(ctx, key, w, maxSize)
+	if err != nil {
+		w.close()
+		return mediaInput{}, func() {}, sourceError(key, err)
+	}
+
+	if w.file == nil {
+		return mediaInput{data: w.buffer.Bytes()}, func() {}, nil
+	}
+	return mediaInput{path: w.file.Name(), n: n}, w.close, nil
+}
+
+// sourceError maps a failed read of key to the errors clients can act on
+func sourceError(key string, err error) error {
+	switch {
+	case errors.Is(err, providers.ErrObjectNotFound):
+		return fmt.Errorf("%w: %s", ErrSourceObjectNotFound, key)
+	case errors.Is(err, providers.ErrFileTooLarge):
+		return fmt.Errorf("%w: %s", ErrSourceTooLarge, key)
+	case errors.Is(err, providers.ErrFeatureNotSupported):
+		return fmt.Errorf("%w: the S3 provider can't read objects", ErrInvalidSource)
+	}
+	return fmt.Errorf("read source %s: %w", key, err)
+}
+
+// SetObjectStore lets requests name an object in the bucket as their input
+// (nil = S3 disabled)
+func (ac *AudioConverter) SetObjectStore(store *S3Service) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.objects = store
+}
+
+func (ac *AudioConverter) objectStore() *S3Service {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+
+	return ac.objects
+}
+
+// SetObjectStore lets requests name an object in the bucket as their input
+// (nil = S3 disabled)
+func (ic *ImageConverter) SetObjectStore(store *S3Service) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.objects = store
+}
+
+func (ic *ImageConverter) objectStore() *S3Service {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	return ic.objects
+}
+
+// SetObjectStore lets requests name an object in the bucket as their input
+// (nil = S3 disabled)
+func (vc *VideoConverter) SetObjectStore(store *S3Service) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.objects = store
+}
+
+func (vc *VideoConverter) objectStore() *S3Service {
+	vc.mu.RLock()
+	defer vc.mu.RUnlock()
+
+	return vc.objects
+}
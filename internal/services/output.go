@@ -0,0 +1,132 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ErrUnsupportedCompression is returned for a compress value other than br
+var ErrUnsupportedCompression = errors.New("unsupported compression")
+
+// CompressionBrotli compresses the output before base64 encoding
+const CompressionBrotli = "br"
+
+// MIME types of the converted outputs
+const (
+	audioMimeType = "audio/ogg;codecs=opus"
+	imageMimeType = "image/jpeg"
+	videoMimeType = "video/mp4"
+)
+
+// wantsDataURI reports whether the output should carry the data: prefix (the default)
+func wantsDataURI(dataURI *bool) bool {
+	return dataURI == nil || *dataURI
+}
+
+// checkCompression validates a request's compress option
+func checkCompression(compress string) error {
+	switch strings.ToLower(strings.TrimSpace(compress)) {
+	case "", CompressionBrotli:
+		return nil
+	}
+	return fmt.Errorf("%w: %q (use %s)", ErrUnsupportedCompression, compress, CompressionBrotli)
+}
+
+// compressOutput Brotli-compresses output when asked to, reporting false when
+// that doesn't make it smaller (Opus, JPEG and other compressed formats)
+func compressOutput(output []byte, compress string) ([]byte, bool) {
+	if !strings.EqualFold(strings.TrimSpace(compress), CompressionBrotli) {
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	writer := brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+	if _, err := writer.Write(output); err != nil {
+		return nil, false
+	}
+	if err := writer.Close(); err != nil {
+		return nil, false
+	}
+	if buf.Len() >= len(output) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// encodeOutput base64-encodes output, as a data URI unless plain base64 was requested
+func encodeOutput(output []byte, mimeType string, dataURI bool) string {
+	encoded := base64.StdEncoding.EncodeToString(output)
+	if !dataURI {
+		return encoded
+	}
+	return "data:" + mimeType + ";base64," + encoded
+}
+
+// decodeOutput reverses encodeOutput and compressOutput, returning the
+// converted bytes of a response's data
+func decodeOutput(data, compression string) ([]byte, error) {
+	if _, payload, found := strings.Cut(data, ";base64,"); found && strings.HasPrefix(data, "data:") {
+		data = payload
+	}
+	output, err := base64.StdEncoding.DecodeString(data)
+	if err != nil || compression != CompressionBrotli {
+		return output, err
+	}
+	return io.ReadAll(brotli.NewReader(bytes.NewReader(output)))
+}
+
+// setOutput stores the converted bytes as requested: raw in Output for the
+// HTTP layer to stream, or base64 (data URI by default) in Data
+func (r *AudioResponse) setOutput(output []byte, req *AudioRequest) {
+	if req.RawOutput {
+		r.Output = output
+		return
+	}
+	if compressed, ok := compressOutput(output, req.Compress); ok {
+		r.Data = base64.StdEncoding.EncodeToString(compressed)
+		r.Compression = CompressionBrotli
+		return
+	}
+	r.Data = encodeOutput(output, r.MimeType, wantsDataURI(req.DataURI))
+}
+
+// setOutput stores the converted bytes as requested: raw in Output for the
+// HTTP layer to stream, or base64 (data URI by default) in Data
+func (r *ImageResponse) setOutput(output []byte, req *ImageRequest) {
+	if req.RawOutput {
+		r.Output = output
+		return
+	}
+	if compressed, ok := compressOutput(output, req.Compress); ok {
+		r.Data = base64.StdEncoding.EncodeToString(compressed)
+		r.Compression = CompressionBrotli
+		return
+	}
+	r.Data = encodeOutput(output, r.MimeType, wantsDataURI(req.DataURI))
+}
+
+// setOutput stores the converted bytes as requested: raw in Output for the
+// HTTP layer to stream, or base64 (data URI by default) in Data
+func (r *VideoResponse) setOutput(output []byte, req *VideoRequest) {
+	if req.RawOutput {
+		r.Output = output
+		return
+	}
+	r.Data = encodeOutput(output, r.MimeType, wantsDataURI(req.DataURI))
+}
+
+// setOutput stores the converted bytes as requested: raw in Output for the
+// HTTP layer to stream, or base64 (data URI by default) in Data
+func (r *StickerResponse) setOutput(output []byte, req *StickerRequest) {
+	if req.RawOutput {
+		r.Output = output
+		return
+	}
+	r.Data = encodeOutput(output, r.MimeType, wantsDataURI(req.DataURI))
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PostProcessFormats are the output formats post-processors can be chained
+// for, in the order their stats are reported
+var PostProcessFormats = []string{"jpeg", "png", "webp"}
+
+// PostProcessorStats reports one post-processor's runs and the bytes it saved
+type PostProcessorStats struct {
+	Format       string  `json:"format" example:"jpeg"`
+	Command      string  `json:"command" example:"jpegoptim"`   // Program run, without its arguments
+	Runs         int64   `json:"runs" example:"980"`            // Outputs passed through it
+	Improved     int64   `json:"improved" example:"912"`        // Runs whose smaller output was kept
+	Failures     int64   `json:"failures" example:"3"`          // Errors and empty, foreign, truncated or resized outputs; the input was kept
+	BytesIn      int64   `json:"bytes_in" example:"104857600"`  // Size of every output it was given
+	BytesSaved   int64   `json:"bytes_saved" example:"9437184"` // Bytes removed by the outputs kept
+	SavedPercent float64 `json:"saved_percent" example:"9.0"`   // BytesSaved as a share of BytesIn
+	AvgTimeMS    int64   `json:"avg_time_ms" example:"35"`
+}
+
+// postProcessor is one command of a format's chain, reading the output on
+// stdin and writing the optimised file to stdout
+type postProcessor struct {
+	format  string
+	command []string
+
+	mu        sync.Mutex
+	runs      int64
+	improved  int64
+	failures  int64
+	bytesIn   int64
+	saved     int64
+	totalTime time.Duration
+}
+
+// PostProcessors runs external optimisers such as jpegoptim, oxipng or cwebp
+// over converted outputs, chained per format. A processor's output replaces
+// its input only when it is smaller and still in the same format, so a
+// misbehaving tool never fails or enlarges a conversion.
+type PostProcessors struct {
+	chains map[string][]*postProcessor
+}
+
+// ParsePostProcessors builds the chains from commands keyed by format. Each
+// value is one or more commands separated by "|", run in order like a shell
+// pipeline; {quality} is replaced by the quality the output was encoded at.
+// Unknown formats and programs missing from PATH are errors. It returns nil
+// when no command is configured.
+func ParsePostProcessors(commands map[string]string) (*PostProcessors, error) {
+	chains := make(map[string][]*postProcessor)
+	for format, spec := range commands {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		if !isPostProcessFormat(format) {
+			return nil, fmt.Errorf("unsupported post-processing format %q (use %s)", format, strings.Join(PostProcessFormats, ", "))
+		}
+		for _, stage := range strings.Split(spec, "|") {
+			command := strings.Fields(stage)
+			if len(command) == 0 {
+				return nil, fmt.Errorf("%s: empty command in %q", format, spec)
+			}
+			if _, err := exec.LookPath(command[0]); err != nil {
+				return nil, fmt.Errorf("%s: %w", format, err)
+			}
+			chains[format] = append(chains[format], &postProcessor{format: format, command: command})
+		}
+	}
+	if len(chains) == 0 {
+		return nil, nil
+	}
+
+	return &PostProcessors{chains: chains}, nil
+}
+
+func isPostProcessFormat(format string) bool {
+	for _, known := range PostProcessFormats {
+		if format == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Run passes data, an output in format encoded at quality, through the
+// format's chain and returns the smallest result. Formats without a chain
+// are returned unchanged.
+func (p *PostProcessors) Run(ctx context.Context, format string, data []byte, quality int) []byte {
+	if p == nil {
+		return data
+	}
+	for _, processor := range p.chains[format] {
+		data = processor.run(ctx, data, quality)
+	}
+	return data
+}
+
+// run returns the processor's output when it is a smaller file of the same
+// format, otherwise data
+func (pp *postProcessor) run(ctx context.Context, data []byte, quality int) []byte {
+	start := time.Now()
+
+	args := make([]string, len(pp.command)-1)
+	for i, arg := range pp.command[1:] {
+		args[i] = strings.ReplaceAll(arg, "{quality}", strconv.Itoa(quality))
+	}
+	output, stderr, err := runCommand(ctx, data, pp.command[0], args...)
+
+	switch {
+	case err != nil:
+		log.Printf("Post-processor %s failed for %s: %v, stderr: %s", pp.command[0], pp.format, err, stderr)
+		output = nil
+	case len(output) == 0:
+		log.Printf("Post-processor %s produced no %s output", pp.command[0], pp.format)
+		output = nil
+	default:
+		// A tool writing another format (or a log) must not replace the image
+		media, ok := SniffBytes(output)
+		if !ok || media.Container != pp.format {
+			log.Printf("Post-processor %s didn't write %s, keeping its input", pp.command[0], pp.format)
+			output = nil
+		} else if !completeImage(pp.format, output) {
+			log.Printf("Post-processor %s wrote a truncated %s, keeping its input", pp.command[0], pp.format)
+			output = nil
+		} else if !sameDimensions(data, output) {
+			log.Printf("Post-processor %s changed the size of its %s input, keeping it", pp.command[0], pp.format)
+			output = nil
+		}
+	}
+
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	pp.runs++
+	pp.bytesIn += int64(len(data))
+	pp.totalTime += time.Since(start)
+	if output == nil {
+		pp.failures++
+		return data
+	}
+	if len(output) >= len(data) {
+		return data
+	}
+	pp.improved++
+	pp.saved += int64(len(data) - len(output))
+	return output
+}
+
+// completeImage reports whether data ends where its format says it does:
+// the JPEG end-of-image marker, the PNG IEND chunk or the RIFF size of WebP
+func completeImage(format string, data []byte) bool {
+	switch format {
+	case "jpeg":
+		return bytes.HasSuffix(bytes.TrimRight(data, "\x00"), []byte{0xff, 0xd9})
+	case "png":
+		return bytes.HasSuffix(data, []byte("IEND\xae\x42\x60\x82"))
+	case "webp":
+		return len(data) >= 12 && int64(binary.LittleEndian.Uint32(data[4:8]))+8 == int64(len(data))
+	}
+	return true
+}
+
+// sameDimensions reports whether after has before's width and height. When
+// before's header can't be read there is nothing to compare, so it passes.
+func sameDimensions(before, after []byte) bool {
+	width, height, ok := headerDimensions(before)
+	if !ok {
+		return true
+	}
+	afterWidth, afterHeight, ok := headerDimensions(after)
+	return ok && width == afterWidth && height == afterHeight
+}
+
+// Stats reports every processor, by format and then chain order
+func (p *PostProcessors) Stats() []PostProcessorStats {
+	if p == nil {
+		return nil
+	}
+
+	stats := []PostProcessorStats{}
+	for _, format := range PostProcessFormats {
+		for _, processor := range p.chains[format] {
+			stats = append(stats, processor.stats())
+		}
+	}
+	return stats
+}
+
+func (pp *postProcessor) stats() PostProcessorStats {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	stats := PostProcessorStats{
+		Format:     pp.format,
+		Command:    pp.command[0],
+		Runs:       pp.runs,
+		Improved:   pp.improved,
+		Failures:   pp.failures,
+		BytesIn:    pp.bytesIn,
+		BytesSaved: pp.saved,
+	}
+	if pp.bytesIn > 0 {
+		stats.SavedPercent = float64(pp.saved) * 100 / float64(pp.bytesIn)
+	}
+	if pp.runs > 0 {
+		stats.AvgTimeMS = (pp.totalTime / time.Duration(pp.runs)).Milliseconds()
+	}
+	return stats
+}
+
+// SetPostProcessors optimises conversion outputs with external tools
+// (nil = outputs are returned as encoded)
+func (ic *ImageConverter) SetPostProcessors(processors *PostProcessors) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.postProcessors = processors
+}
+
+func (ic *ImageConverter) outputPostProcessors() *PostProcessors {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+
+	return ic.postProcessors
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidPresets is returned for a presets file that can't be loaded
+var ErrInvalidPresets = errors.New("invalid presets")
+
+// Preset types, the conversion a preset applies to
+const (
+	PresetTypeAudio   = "audio"
+	PresetTypeImage   = "image"
+	PresetTypeVideo   = "video"
+	PresetTypeSticker = "sticker"
+)
+
+// Preset is a named set of conversion options defined by the operator
+type Preset struct {
+	Name        string          `json:"name" example:"whatsapp_voice"`
+	Type        string          `json:"type" example:"audio"` // audio, image, video or sticker
+	Description string          `json:"description,omitempty" example:"Voice notes as WhatsApp plays them"`
+	Options     json.RawMessage `json:"options" swaggertype:"object"` // Request fields the preset sets
+
+	request any // Options decoded into the request type of Type
+}
+
+// Presets holds the operator-defined presets (PRESETS_FILE). Requests select
+// one by name in their preset field; the fields they leave unset are taken
+// from the preset, so clients share the operator's settings but can still
+// override them one by one.
+type Presets struct {
+	presets map[string]*Preset
+}
+
+// presetFile is one entry of the presets file, keyed by preset name
+type presetFile struct {
+	Type        string         `yaml:"type"`
+	Description string         `yaml:"description"`
+	Options     map[string]any `yaml:"options"`
+}
+
+// builtinPresets are the presets the converters implement themselves; a
+// file preset may build on them (options.preset) but not redefine them
+var builtinPresets = map[string][]string{
+	PresetTypeAudio: {AudioPresetWhatsApp, AudioPresetReverse},
+	PresetTypeVideo: {VideoPresetWhatsApp, VideoPresetScreencast},
+}
+
+// LoadPresets reads the presets in file, YAML or JSON. An empty file name
+// means no presets.
+func LoadPresets(file string) (*Presets, error) {
+	p := &Presets{presets: make(map[string]*Preset)}
+	if file == "" {
+		return p, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPresets, err)
+	}
+
+	// JSON is YAML, so one decoder reads both
+	var entries map[string]presetFile
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPresets, file, err)
+	}
+
+	for name, entry := range entries {
+		preset, err := newPreset(name, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: preset %q: %v", ErrInvalidPresets, file, name, err)
+		}
+		if p.presets[preset.Name] != nil {
+			return nil, fmt.Errorf("%w: %s: preset %q defined twice (names ignore case)", ErrInvalidPresets, file, preset.Name)
+		}
+		p.presets[preset.Name] = preset
+	}
+
+	return p, nil
+}
+
+func newPreset(name string, entry presetFile) (*Preset, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil, errors.New("empty name")
+	}
+
+	var request any
+	switch entry.Type {
+	case PresetTypeAudio:
+		request = &AudioRequest{}
+	case PresetTypeImage:
+		request = &ImageRequest{}
+	case PresetTypeVideo:
+		request = &VideoRequest{}
+	case PresetTypeSticker:
+		request = &StickerRequest{}
+	default:
+		return nil, fmt.Errorf("type must be %s, %s, %s or %s, got %q", PresetTypeAudio, PresetTypeImage, PresetTypeVideo, PresetTypeSticker, entry.Type)
+	}
+
+	for _, builtin := range builtinPresets[entry.Type] {
+		if name == builtin {
+			return nil, fmt.Errorf("%s is a built-in %s preset", name, entry.Type)
+		}
+	}
+
+	// The input is always the client's
+	for _, field := range []string{"data", "is_url"} {
+		if _, ok := entry.Options[field]; ok {
+			return nil, fmt.Errorf("options can't set %s", field)
+		}
+	}
+	if _, ok := entry.Options["preset"]; ok && len(builtinPresets[entry.Type]) == 0 {
+		return nil, fmt.Errorf("%s conversions have no built-in preset to set in options.preset", entry.Type)
+	}
+
+	options, err := json.Marshal(entry.Options)
+	if err != nil {
+		return nil, fmt.Errorf("options: %v", err)
+	}
+	if entry.Options == nil {
+		options = []byte("{}")
+	}
+
+	// Decode strictly so a misspelt field fails at startup, not silently
+	decoder := json.NewDecoder(bytes.NewReader(options))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		return nil, fmt.Errorf("options: %v", err)
+	}
+
+	// options.preset names the built-in preset to start from, never another
+	// file preset
+	if base := presetBase(request); base != "" {
+		known := false
+		for _, builtin := range builtinPresets[entry.Type] {
+			known = known || base == builtin
+		}
+		if !known {
+			return nil, fmt.Errorf("options.preset %q is not a built-in %s preset (%s)", base, entry.Type, strings.Join(builtinPresets[entry.Type], ", "))
+		}
+	}
+
+	return &Preset{
+		Name:        name,
+		Type:        entry.Type,
+		Description: strings.TrimSpace(entry.Description),
+		Options:     options,
+		request:     request,
+	}, nil
+}
+
+// presetBase returns the built-in preset a preset's options start from
+func presetBase(request any) string {
+	switch req := request.(type) {
+	case *AudioRequest:
+		return strings.ToLower(strings.TrimSpace(req.Preset))
+	case *VideoRequest:
+		return strings.ToLower(strings.TrimSpace(req.Preset))
+	}
+	return ""
+}
+
+// List returns the presets sorted by name
+func (p *Presets) List() []Preset {
+	if p == nil {
+		return nil
+	}
+
+	presets := make([]Preset, 0, len(p.presets))
+	for _, preset := range p.presets {
+		presets = append(presets, *preset)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// apply resolves the file preset named by *name for a presetType request:
+// the fields req leaves unset are copied from the preset, and *name becomes
+// the built-in preset it starts from. Names that aren't file presets are
+// left for the converter, which knows its built-in presets.
+func (p *Presets) apply(presetType string, name *string, req any) error {
+	if p == nil || strings.TrimSpace(*name) == "" {
+		return nil
+	}
+
+	preset, ok := p.presets[strings.ToLower(strings.TrimSpace(*name))]
+	if !ok {
+		return nil
+	}
+	if preset.Type != presetType {
+		return fmt.Errorf("%w: %q is for %s conversions", ErrUnknownPreset, *name, preset.Type)
+	}
+
+	*name = ""
+	fillUnset(reflect.ValueOf(req).Elem(), reflect.ValueOf(preset.request).Elem())
+	return nil
+}
+
+// fillUnset copies the fields of src into the zero-valued fields of dst,
+// both structs of the same type. Fields the JSON API doesn't expose are
+// skipped.
+func fillUnset(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		if dst.Field(i).IsZero() && !src.Field(i).IsZero() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+// SetPresets sets the operator-defined presets requests may select
+func (ac *AudioConverter) SetPresets(presets *Presets) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.presets = presets
+}
+
+// SetPresets sets the operator-defined presets image and sticker requests
+// may select
+func (ic *ImageConverter) SetPresets(presets *Presets) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.presets = presets
+}
+
+// SetPresets sets the operator-defined presets requests may select
+func (vc *VideoConverter) SetPresets(presets *Presets) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.presets = presets
+}
+
+func (ac *AudioConverter) applyPreset(req *AudioRequest) error {
+	ac.mu.RLock()
+	presets := ac.presets
+	ac.mu.RUnlock()
+
+	return presets.apply(PresetTypeAudio, &req.Preset, req)
+}
+
+func (vc *VideoConverter) applyPreset(req *VideoRequest) error {
+	vc.mu.RLock()
+	presets := vc.presets
+	vc.mu.RUnlock()
+
+	return presets.apply(PresetTypeVideo, &req.Preset, req)
+}
+
+// applyPreset resolves image and sticker presets, which have no built-in
+// ones, so any other name is unknown
+func (ic *ImageConverter) applyPreset(presetType string, name *string, req any) error {
+	ic.mu.RLock()
+	presets := ic.presets
+	ic.mu.RUnlock()
+
+	if err := presets.apply(presetType, name, req); err != nil {
+		return err
+	}
+	if *name != "" {
+		return fmt.Errorf("%w: %q (%s presets: %s)", ErrUnknownPreset, *name, presetType, strings.Join(presets.namesOf(presetType), ", "))
+	}
+	return nil
+}
+
+// namesOf returns the names of the presetType presets, sorted
+func (p *Presets) namesOf(presetType string) []string {
+	var names []string
+	for _, preset := range p.List() {
+		if preset.Type == presetType {
+			names = append(names, preset.Name)
+		}
+	}
+	if len(names) == 0 {
+		return []string{"none defined"}
+	}
+	return names
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRecordingNotFound is returned for unknown or expired recorded requests
+var ErrRecordingNotFound = errors.New("recorded request not found")
+
+// recordingExt is the file extension of encrypted recordings on disk
+const recordingExt = ".req"
+
+// RecordedInput identifies a media payload of a recorded request without
+// keeping it: its SHA-256 matches a file the caller still has
+type RecordedInput struct {
+	Field       string `json:"field" example:"data"` // JSON path (items.0.data) or multipart field
+	Filename    string `json:"filename,omitempty" example:"voice.ogg"`
+	ContentType string `json:"content_type,omitempty" example:"audio/ogg"`
+	URL         string `json:"url,omitempty" example:"https://example.com/media/voice.ogg"` // URL inputs, without query string
+	Size        int64  `json:"size" example:"48213"`
+	SHA256      string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"` // Of the decoded bytes (of the URL for URL inputs)
+}
+
+// RecordedRequest is the sanitized envelope of a failed request: its
+// parameters with every payload replaced by a digest, allow-listed headers
+// and, with REQUEST_RECORDING_BODIES, the exact body for replay
+type RecordedRequest struct {
+	ID         string            `json:"id" example:"3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c"`
+	RecordedAt time.Time         `json:"recorded_at"`
+	ExpiresAt  time.Time         `json:"expires_at"`
+	RequestID  string            `json:"request_id,omitempty" example:"1718000000000000000"`
+	Method     string            `json:"method" example:"POST"`
+	Path       string            `json:"path" example:"/v1/convert/audio"`
+	Query      string            `json:"query,omitempty" example:"format=binary"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Tenant     string            `json:"tenant,omitempty" example:"key_4f2a9c1b7d3e"` // Hashed X-API-Key
+	Params     json.RawMessage   `json:"params,omitempty" swaggertype:"object"`
+	Inputs     []RecordedInput   `json:"inputs,omitempty"`
+	Status     int               `json:"status" example:"500"`
+	ErrorCode  string            `json:"error_code,omitempty" example:"conversion_failed"`
+	Error      string            `json:"error,omitempty" example:"Conversion failed"`
+
+	BodyRecorded bool   `json:"body_recorded"`
+	Body         []byte `json:"-"`
+}
+
+// recordingFile is a recording as stored, with its body
+type recordingFile struct {
+	RecordedRequest
+	Body []byte `json:"body,omitempty"`
+}
+
+// RequestRecorder keeps failed requests encrypted on disk for a limited time,
+// so they can be listed and replayed once the failure is fixed
+type RequestRecorder struct {
+	dir         string
+	ttl         time.Duration
+	maxBodySize int64
+	sealer      *sealer
+	stop        chan struct{}
+}
+
+// NewRequestRecorder creates a recorder under dir. Bodies of up to
+// maxBodySize bytes are kept (0 keeps none). secret is hashed into the
+// AES-256 key; an empty secret uses a random key, so recordings don't survive
+// a restart.
+func NewRequestRecorder(dir string, ttl time.Duration, secret string, maxBodySize int64) (*RequestRecorder, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("request recording TTL must be positive")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create request recording dir: %w", err)
+	}
+
+	sealer, err := newSealer(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := &RequestRecorder{
+		dir:         dir,
+		ttl:         ttl,
+		maxBodySize: maxBodySize,
+		sealer:      sealer,
+		stop:        make(chan struct{}),
+	}
+	go expireLoop(dir, recordingExt, ttl, recorder.stop)
+
+	return recorder, nil
+}
+
+// KeepsBody reports whether a body of size bytes is recorded
+func (r *RequestRecorder) KeepsBody(size int) bool {
+	return size > 0 && int64(size) <= r.maxBodySize
+}
+
+// Save encrypts rec and returns its ID. The body is dropped unless KeepsBody
+// allows it.
+func (r *RequestRecorder) Save(rec RecordedRequest) (string, error) {
+	now := time.Now().UTC()
+	rec.ID = uuid.New().String()
+	rec.RecordedAt = now
+	rec.ExpiresAt = now.Add(r.ttl)
+	rec.BodyRecorded = r.KeepsBody(len(rec.Body))
+
+	file := recordingFile{RecordedRequest: rec}
+	if rec.BodyRecorded {
+		file.Body = rec.Body
+	}
+
+	plaintext, err := json.Marshal(file)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := r.sealer.seal(rec.ID, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(r.path(rec.ID), sealed, 0o600); err != nil {
+		return "", err
+	}
+
+	return rec.ID, nil
+}
+
+// Load decrypts a recording, with its body when one was kept
+func (r *RequestRecorder) Load(id string) (*RecordedRequest, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrRecordingNotFound
+	}
+
+	sealed, err := os.ReadFile(r.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrRecordingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := r.sealer.open(id, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("recorded request: %w", err)
+	}
+
+	var file recordingFile
+	if err := json.Unmarshal(plaintext, &file); err != nil {
+		return nil, err
+	}
+	if time.Now().After(file.ExpiresAt) {
+		_ = os.Remove(r.path(id))
+		return nil, ErrRecordingNotFound
+	}
+
+	rec := file.RecordedRequest
+	rec.Body = file.Body
+	return &rec, nil
+}
+
+// List returns the unexpired recordings without their bodies, newest first.
+// Recordings this process can't decrypt (an earlier key) are skipped.
+func (r *RequestRecorder) List() ([]RecordedRequest, error) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	recordings := []RecordedRequest{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), recordingExt)
+		if !ok {
+			continue
+		}
+		rec, err := r.Load(id)
+		if err != nil {
+			if !errors.Is(err, ErrRecordingNotFound) {
+				log.Printf("Skipping recorded request %s: %v", id, err)
+			}
+			continue
+		}
+		rec.Body = nil
+		recordings = append(recordings, *rec)
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].RecordedAt.After(recordings[j].RecordedAt)
+	})
+	return recordings, nil
+}
+
+// Close stops the expiry loop
+func (r *RequestRecorder) Close() {
+	close(r.stop)
+}
+
+func (r *RequestRecorder) path(id string) string {
+	return filepath.Join(r.dir, id+recordingExt)
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrSourceNotFound is returned for unknown or expired retained sources
+var ErrSourceNotFound = errors.New("retained source not found")
+
+// retainedSourceExt is the file extension of encrypted sources on disk
+const retainedSourceExt = ".src"
+
+// RetainedSource is a failed conversion input kept for replay
+type RetainedSource struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"` // "audio" or "image"
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Error     string          `json:"error"`
+	Request   json.RawMessage `json:"request"` // Original options, without the payload
+	Data      []byte          `json:"data"`
+}
+
+// SourceStore keeps failed conversion inputs encrypted on disk for a limited time
+type SourceStore struct {
+	dir    string
+	ttl    time.Duration
+	sealer *sealer
+	stop   chan struct{}
+}
+
+// NewSourceStore creates a store under dir. secret is hashed into the AES-256 key;
+// an empty secret uses a random key, so sources don't survive a restart.
+func NewSourceStore(dir string, ttl time.Duration, secret string) (*SourceStore, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("source retention TTL must be positive")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create source retention dir: %w", err)
+	}
+
+	sealer, err := newSealer(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &SourceStore{
+		dir:    dir,
+		ttl:    ttl,
+		sealer: sealer,
+		stop:   make(chan struct{}),
+	}
+	go expireLoop(dir, retainedSourceExt, ttl, store.stop)
+
+	return store, nil
+}
+
+// Save encrypts input together with the request options and returns its ID
+func (s *SourceStore) Save(kind string, request any, input []byte, cause error) (string, error) {
+	options, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	source := RetainedSource{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+		Error:     cause.Error(),
+		Request:   options,
+		Data:      input,
+	}
+
+	plaintext, err := json.Marshal(source)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := s.sealer.seal(source.ID, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(s.path(source.ID), sealed, 0o600); err != nil {
+		return "", err
+	}
+
+	return source.ID, nil
+}
+
+// Load decrypts a retained source
+func (s *SourceStore) Load(id string) (*RetainedSource, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrSourceNotFound
+	}
+
+	sealed, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSourceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := s.sealer.open(id, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("retained source: %w", err)
+	}
+
+	var source RetainedSource
+	if err := json.Unmarshal(plaintext, &source); err != nil {
+		return nil, err
+	}
+	if time.Now().After(source.ExpiresAt) {
+		_ = os.Remove(s.path(id))
+		return nil, ErrSourceNotFound
+	}
+
+	return &source, nil
+}
+
+// Close stops the expiry loop
+func (s *SourceStore) Close() {
+	close(s.stop)
+}
+
+func (s *SourceStore) path(id string) string {
+	return filepath.Join(s.dir, id+retainedSourceExt)
+}
+
+// RetainedError wraps a conversion failure whose input was retained for replay
+type RetainedError struct {
+	SourceID string
+	Err      error
+}
+
+func (e *RetainedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetainedError) Unwrap() error {
+	return e.Err
+}
+
+// RetainedSourceID returns the replay ID attached to err, if any
+func RetainedSourceID(err error) string {
+	var retained *RetainedError
+	if errors.As(err, &retained) {
+		return retained.SourceID
+	}
+	return ""
+}
+
+// SetSourceStore enables retention of inputs whose conversion fails
+func (ac *AudioConverter) SetSourceStore(store *SourceStore) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.sourceStore = store
+}
+
+// SetSourceStore enables retention of inputs whose conversion fails
+func (ic *ImageConverter) SetSourceStore(store *SourceStore) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.sourceStore = store
+}
+
+// retainAudio keeps the failing input when retention is enabled and tags err with its ID
+func (ac *AudioConverter) retainAudio(ctx context.Context, req *AudioRequest, input mediaInput, err error) error {
+	ac.mu.RLock()
+	store := ac.sourceStore
+	ac.mu.RUnlock()
+
+	// Uploads are only read into memory once they are known to be worth keeping
+	if store == nil || ctx.Err() != nil || isReplay(ctx) {
+		return err
+	}
+	data, readErr := input.bytes()
+	if readErr != nil {
+		return err
+	}
+
+	return retainSource(ctx, store, "audio", audioSourceOptions(*req), data, err)
+}
+
+// retainImage keeps the failing input when retention is enabled and tags err with its ID
+func (ic *ImageConverter) retainImage(ctx context.Context, req *ImageRequest, input []byte, err error) error {
+	ic.mu.RLock()
+	store := ic.sourceStore
+	ic.mu.RUnlock()
+
+	return retainSource(ctx, store, "image", imageSourceOptions(*req), input, err)
+}
+
+// retainSource skips cancelled requests (their input isn't at fault) and replays
+func retainSource(ctx context.Context, store *SourceStore, kind string, options any, input []byte, err error) error {
+	if store == nil || ctx.Err() != nil || isReplay(ctx) {
+		return err
+	}
+
+	id, saveErr := store.Save(kind, options, input, err)
+	if saveErr != nil {
+		log.Printf("Failed to retain %s source: %v", kind, saveErr)
+		return err
+	}
+
+	return &RetainedError{SourceID: id, Err: err}
+}
+
+// audioSourceOptions strips the payload from a request before it is stored
+func audioSourceOptions(req AudioRequest) AudioRequest {
+	req.Data = ""
+	req.IsURL = false
+	req.Source = nil
+	return req
+}
+
+func imageSourceOptions(req ImageRequest) ImageRequest {
+	req.Data = ""
+	req.IsURL = false
+	req.Source = nil
+	return req
+}
+
+type replayKey struct{}
+
+// WithReplay marks ctx as a replay of a retained source, so a repeated
+// failure isn't retained a second time
+func WithReplay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replayKey{}, true)
+}
+
+func isReplay(ctx context.Context) bool {
+	replay, _ := ctx.Value(replayKey{}).(bool)
+	return replay
+}
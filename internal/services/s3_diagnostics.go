@@ -0,0 +1,250 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"whats-convert-api/internal/providers"
+)
+
+// maxClockSkew is how far apart S3 tolerates client and server clocks
+const maxClockSkew = 15 * time.Minute
+
+// Diagnostic failure reasons
+const (
+	ReasonClockSkew         = "clock_skew"
+	ReasonSignatureMismatch = "signature_mismatch"
+	ReasonInvalidAccessKey  = "invalid_access_key"
+	ReasonAccessDenied      = "access_denied"
+	ReasonBucketNotFound    = "bucket_not_found"
+	ReasonWrongRegion       = "wrong_region"
+	ReasonUnreachable       = "unreachable"
+	ReasonTimeout           = "timeout"
+	ReasonContentMismatch   = "content_mismatch"
+	ReasonUnknown           = "unknown"
+)
+
+// S3DiagnosticStep is the outcome of one operation of an S3 diagnosis
+type S3DiagnosticStep struct {
+	Name       string `json:"name" example:"put"` // clock, put, get or delete
+	OK         bool   `json:"ok" example:"false"`
+	DurationMS int64  `json:"duration_ms" example:"84"`
+	Error      string `json:"error,omitempty" example:"S3 aws upload failed for key 'uploads/.diagnostics/...': api error SignatureDoesNotMatch"`
+	Code       string `json:"code,omitempty" example:"SignatureDoesNotMatch"` // S3 error code, when the provider sent one
+	StatusCode int    `json:"status_code,omitempty" example:"403"`
+	Reason     string `json:"reason,omitempty" example:"signature_mismatch"`
+	Hint       string `json:"hint,omitempty" example:"S3_SECRET_KEY doesn't match S3_ACCESS_KEY, or S3_REGION/S3_PATH_STYLE differ from what the provider expects"`
+}
+
+// S3Diagnosis reports a signed test PUT/GET/DELETE against the bucket
+type S3Diagnosis struct {
+	OK          bool               `json:"ok" example:"false"`
+	Provider    string             `json:"provider" example:"aws"`
+	Endpoint    string             `json:"endpoint" example:"https://s3.amazonaws.com"`
+	Region      string             `json:"region" example:"us-east-1"`
+	Bucket      string             `json:"bucket" example:"media"`
+	PathStyle   bool               `json:"path_style" example:"false"`
+	Key         string             `json:"key" example:"uploads/.diagnostics/5f0c....txt"` // Test object, deleted afterwards
+	ClockSkewMS *int64             `json:"clock_skew_ms,omitempty" example:"-1250"`        // Provider clock minus ours, from its Date header
+	Steps       []S3DiagnosticStep `json:"steps"`
+}
+
+// Diagnose checks the provider's clock, then writes, reads back and deletes a
+// small test object, classifying each failure so setup mistakes (clock skew,
+// wrong secret, bucket policy) can be told apart
+func (s *S3Service) Diagnose(ctx context.Context) (*S3Diagnosis, error) {
+	if !s.enabled {
+		return nil, fmt.Errorf("S3 service is disabled")
+	}
+
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return nil, fmt.Errorf("S3 provider not initialized")
+	}
+
+	diagnosis := &S3Diagnosis{
+		Provider:  string(s.config.Provider),
+		Endpoint:  s.config.Endpoint,
+		Region:    s.config.Region,
+		Bucket:    s.config.Bucket,
+		PathStyle: s.config.PathStyle,
+		Key:       path.Join(strings.Trim(s.config.KeyPrefix, "/"), ".diagnostics", uuid.New().String()+".txt"),
+	}
+
+	// The mock provider has no endpoint to ask for the time
+	if s.config.Provider != providers.ProviderMock {
+		step, skew := s.checkClock(ctx)
+		diagnosis.Steps = append(diagnosis.Steps, step)
+		diagnosis.ClockSkewMS = skew
+	}
+
+	payload := []byte("whats-convert-api S3 diagnostics " + time.Now().UTC().Format(time.RFC3339))
+
+	put := runDiagnosticStep("put", func() error {
+		_, err := provider.Upload(ctx, diagnosis.Key, bytes.NewReader(payload), int64(len(payload)),
+			providers.UploadOptions{ContentType: "text/plain"})
+		return err
+	})
+	diagnosis.Steps = append(diagnosis.Steps, put)
+
+	// Nothing to read or delete without the object
+	if put.OK {
+		diagnosis.Steps = append(diagnosis.Steps,
+			runDiagnosticStep("get", func() error { return readBack(ctx, provider, diagnosis.Key, payload) }),
+			runDiagnosticStep("delete", func() error { return provider.DeleteObject(ctx, diagnosis.Key) }),
+		)
+	}
+
+	diagnosis.OK = true
+	for _, step := range diagnosis.Steps {
+		diagnosis.OK = diagnosis.OK && step.OK
+	}
+	return diagnosis, nil
+}
+
+// checkClock compares the Date header of an unsigned request to the endpoint
+// with the local clock, correcting for half the round trip
+func (s *S3Service) checkClock(ctx context.Context) (S3DiagnosticStep, *int64) {
+	endpoint := s.config.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		scheme := "https://"
+		if !s.config.UseSSL {
+			scheme = "http://"
+		}
+		endpoint = scheme + endpoint
+	}
+
+	var skew *int64
+	step := runDiagnosticStep("clock", func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		rtt := time.Since(start)
+
+		serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+		if err != nil {
+			return fmt.Errorf("endpoint sent no usable Date header: %w", err)
+		}
+
+		offset := serverTime.Sub(start.Add(rtt / 2))
+		ms := offset.Milliseconds()
+		skew = &ms
+		if offset > maxClockSkew || offset < -maxClockSkew {
+			return fmt.Errorf("%w: clocks differ by %s", errClockSkew, offset.Round(time.Second))
+		}
+		return nil
+	})
+	return step, skew
+}
+
+// errClockSkew marks a clock check failure for classification
+var errClockSkew = errors.New("clock skew exceeds what S3 accepts")
+
+// errContentMismatch marks a read-back whose bytes differ from what was written
+var errContentMismatch = errors.New("object read back differs from the one written")
+
+// readBack reads the test object, or only its metadata when the provider can't stream objects
+func readBack(ctx context.Context, provider providers.S3Provider, key string, want []byte) error {
+	reader, ok := provider.(providers.ObjectReader)
+	if !ok {
+		_, err := provider.GetObjectInfo(ctx, key)
+		return err
+	}
+
+	body, err := reader.GetObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	got, err := io.ReadAll(io.LimitReader(body, int64(len(want))+1))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return errContentMismatch
+	}
+	return nil
+}
+
+// runDiagnosticStep times operation and classifies its failure
+func runDiagnosticStep(name string, operation func() error) S3DiagnosticStep {
+	start := time.Now()
+	err := operation()
+
+	step := S3DiagnosticStep{
+		Name:       name,
+		OK:         err == nil,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		step.Error = err.Error()
+		step.Code = providers.ErrorCode(err)
+		step.StatusCode = providers.ErrorStatusCode(err)
+		step.Reason, step.Hint = classifyS3Failure(name, step.Code, step.StatusCode, err)
+	}
+	return step
+}
+
+// diagnosticActions names the IAM action each step needs
+var diagnosticActions = map[string]string{
+	"put":    "s3:PutObject",
+	"get":    "s3:GetObject",
+	"delete": "s3:DeleteObject",
+}
+
+// classifyS3Failure maps an S3 error code, or failing that the HTTP status
+// and error type, to a reason and a hint naming the setting to check
+func classifyS3Failure(step, code string, status int, err error) (string, string) {
+	switch code {
+	case "RequestTimeTooSkewed":
+		return ReasonClockSkew, "This server's clock is more than 15 minutes off the provider's; sync it with NTP"
+	case "SignatureDoesNotMatch":
+		return ReasonSignatureMismatch, "S3_SECRET_KEY doesn't match S3_ACCESS_KEY, or S3_REGION/S3_PATH_STYLE differ from what the provider expects, or a proxy rewrites requests"
+	case "InvalidAccessKeyId":
+		return ReasonInvalidAccessKey, "The provider doesn't know S3_ACCESS_KEY; check for typos or a key from another account or region"
+	case "AccessDenied", "AllAccessDisabled", "AccountProblem":
+		return ReasonAccessDenied, fmt.Sprintf("The credentials are valid but a bucket or IAM policy denies %s on S3_BUCKET", diagnosticActions[step])
+	case "NoSuchBucket":
+		return ReasonBucketNotFound, "S3_BUCKET doesn't exist at S3_ENDPOINT"
+	case "AuthorizationHeaderMalformed", "PermanentRedirect", "IllegalLocationConstraintException":
+		return ReasonWrongRegion, "S3_REGION doesn't match the bucket's region"
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errClockSkew):
+		return ReasonClockSkew, "This server's clock is more than 15 minutes off the provider's; sync it with NTP"
+	case errors.Is(err, errContentMismatch):
+		return ReasonContentMismatch, "The object read back differs from the one written; check proxies or bucket transformations"
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return ReasonTimeout, "The provider didn't answer in time; check S3_ENDPOINT and network egress"
+	case status == http.StatusForbidden:
+		return ReasonAccessDenied, fmt.Sprintf("The provider refused the request (403) without a code; check the credentials and that policies allow %s", diagnosticActions[step])
+	case status == http.StatusNotFound:
+		return ReasonBucketNotFound, "The provider answered 404; check S3_BUCKET and S3_PATH_STYLE"
+	case status == 0 && errors.As(err, &netErr):
+		return ReasonUnreachable, "S3_ENDPOINT can't be reached; check the host, S3_USE_SSL and TLS certificates"
+	}
+	return ReasonUnknown, ""
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"whats-convert-api/internal/providers"
+)
+
+// SetExpiration makes key expire days after it was created, through the
+// bucket's lifecycle rules or, on providers without tag-matching rules, the
+// expiry sweep. Days 0 removes the expiration. It returns when the object
+// will be deleted.
+func (s *S3Service) SetExpiration(ctx context.Context, key string, days int) (*time.Time, error) {
+	if !s.enabled {
+		return nil, fmt.Errorf("S3 service is disabled")
+	}
+
+	provider, err := s.currentProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := provider.SetExpiration(ctx, key, days)
+	if err != nil {
+		if s.config.LogUploads {
+			log.Printf("❌ S3 Expiration failed for key '%s': %v", key, err)
+		}
+		return nil, err
+	}
+
+	if s.config.LogUploads {
+		if expiresAt != nil {
+			log.Printf("⏰ S3 Expiration: %s expires %s", key, expiresAt.Format(time.RFC3339))
+		} else {
+			log.Printf("⏰ S3 Expiration: %s no longer expires", key)
+		}
+	}
+	return expiresAt, nil
+}
+
+// sweepExpiredLoop deletes expired objects every interval on providers whose
+// lifecycle rules can't do it. It ends early once the provider turns out to
+// expire objects on its own.
+func (s *S3Service) sweepExpiredLoop(interval time.Duration) {
+	defer close(s.sweepDone)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !s.sweepExpired(interval) {
+				return
+			}
+		case <-s.stopSweep:
+			return
+		}
+	}
+}
+
+// sweepExpired runs one sweep bounded by timeout; it reports false when the
+// provider has nothing to sweep
+func (s *S3Service) sweepExpired(timeout time.Duration) bool {
+	provider, err := s.currentProvider()
+	if err != nil {
+		return true
+	}
+	sweeper, ok := provider.(providers.ExpirySweeper)
+	if !ok {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	deleted, err := sweeper.SweepExpired(ctx)
+	if errors.Is(err, providers.ErrFeatureNotSupported) {
+		return false
+	}
+
+	s.stats.mu.Lock()
+	s.stats.ExpiredObjects += int64(deleted)
+	s.stats.mu.Unlock()
+
+	if err != nil {
+		log.Printf("⚠️ S3 expiry sweep failed after deleting %d objects: %v", deleted, err)
+	} else if deleted > 0 {
+		log.Printf("⏰ S3 expiry sweep deleted %d expired objects", deleted)
+	}
+	return true
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"mime"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"whats-convert-api/internal/providers"
+)
+
+var (
+	// ErrObjectExists is returned when the key is taken and the collision policy is error
+	ErrObjectExists = errors.New("object already exists")
+
+	// ErrInvalidKeyTemplate is returned for templates with unknown placeholders
+	// or collision policies other than overwrite, suffix or error
+	ErrInvalidKeyTemplate = errors.New("invalid key template")
+)
+
+// Key collision policies
+const (
+	KeyCollisionOverwrite = "overwrite" // Replace the existing object (default)
+	KeyCollisionSuffix    = "suffix"    // Append -1, -2, ... before the extension
+	KeyCollisionError     = "error"     // Refuse the upload with ErrObjectExists
+)
+
+// maxKeySuffix bounds the HEAD requests the suffix policy makes
+const maxKeySuffix = 100
+
+// keyPlaceholder matches a {name} placeholder in a key template
+var keyPlaceholder = regexp.MustCompile(`\{([a-z0-9_]*)\}`)
+
+// unsafeKeyRune matches characters a source filename may not carry into a key
+var unsafeKeyRune = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// KeySource describes the object a key is generated for
+type KeySource struct {
+	Key         string // Explicit key from the request; skips the template
+	Filename    string // Source filename
+	ContentType string
+	Data        []byte      // Object bytes, for {hash} and dimensions
+	Base64      string      // Base64 object bytes, decoded only when the template needs them
+	File        io.ReaderAt // Spooled object bytes, read only when the template needs them
+	Template    string      // Overrides S3_KEY_TEMPLATE
+	Collision   string      // Overrides S3_KEY_COLLISION
+	Streamed    bool        // Content is produced during the upload, so templates can't read it
+}
+
+// ValidateKeyTemplate checks a template's placeholders and a collision policy
+func ValidateKeyTemplate(template, collision string) error {
+	for _, match := range keyPlaceholder.FindAllStringSubmatch(template, -1) {
+		switch match[1] {
+		case "name", "ext", "hash", "sha256", "width", "height", "date", "timestamp", "uuid":
+		default:
+			return fmt.Errorf("%w: unknown placeholder %s (use {name}, {ext}, {hash}, {sha256}, {width}, {height}, {date}, {timestamp} or {uuid})",
+				ErrInvalidKeyTemplate, match[0])
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(collision)) {
+	case "", KeyCollisionOverwrite, KeyCollisionSuffix, KeyCollisionError:
+		return nil
+	}
+	return fmt.Errorf("%w: collision policy %q (use %s, %s or %s)",
+		ErrInvalidKeyTemplate, collision, KeyCollisionOverwrite, KeyCollisionSuffix, KeyCollisionError)
+}
+
+// ObjectKey names a new object: the explicit key, else the key template
+// (request or S3_KEY_TEMPLATE) under S3_KEY_PREFIX, else GenerateKey. The
+// collision policy is then applied against the bucket.
+func (s *S3Service) ObjectKey(ctx context.Context, src KeySource) (string, error) {
+	template := src.Template
+	if template == "" {
+		template = s.config.KeyTemplate
+	}
+	collision := src.Collision
+	if collision == "" {
+		collision = s.config.KeyCollision
+	}
+	if err := ValidateKeyTemplate(template, collision); err != nil {
+		return "", err
+	}
+
+	key := src.Key
+	switch {
+	case key != "":
+	case src.Streamed && templateReadsContent(template):
+		return "", fmt.Errorf("%w: streamed outputs can't use {hash}, {sha256}, {width} or {height}; pass a key",
+			ErrInvalidKeyTemplate)
+	case template != "":
+		rendered, err := renderKeyTemplate(template, src)
+		if err != nil {
+			return "", err
+		}
+		key = rendered
+		if prefix := strings.Trim(s.config.KeyPrefix, "/"); prefix != "" {
+			key = prefix + "/" + key
+		}
+	default:
+		key = s.GenerateKey(src.Filename)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(collision)) {
+	case KeyCollisionSuffix:
+		return s.freeKey(ctx, key)
+	case KeyCollisionError:
+		taken, err := s.keyExists(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", fmt.Errorf("%w: %s", ErrObjectExists, key)
+		}
+	}
+	return key, nil
+}
+
+// renderKeyTemplate fills in a validated template's placeholders
+func renderKeyTemplate(template string, src KeySource) (string, error) {
+	base := path.Base(strings.ReplaceAll(src.Filename, "\\", "/"))
+	ext := path.Ext(base)
+	name := strings.Trim(unsafeKeyRune.ReplaceAllString(strings.TrimSuffix(base, ext), "-"), "-.")
+	if name == "" {
+		name = "file"
+	}
+	ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+	if ext == "" {
+		ext = extensionForContentType(src.ContentType)
+	}
+
+	// Base64 input is only decoded for placeholders that read the content;
+	// invalid data is left for the upload itself to reject
+	data := src.Data
+	if data == nil && src.Base64 != "" && templateReadsContent(template) {
+		encoded := src.Base64
+		if strings.HasPrefix(encoded, "data:") {
+			if _, payload, ok := strings.Cut(encoded, ","); ok {
+				encoded = payload
+			}
+		}
+		data, _ = providers.DecodeBase64(encoded)
+	}
+
+	var content io.ReaderAt = bytes.NewReader(data)
+	size := int64(len(data))
+	if data == nil && src.File != nil && templateReadsContent(template) {
+		content, size = src.File, math.MaxInt64 // Read to EOF
+	}
+
+	// Streamed, so spooled uploads are hashed without being read into memory
+	hash := sha256.New()
+	io.Copy(hash, io.NewSectionReader(content, 0, size))
+	digest := hex.EncodeToString(hash.Sum(nil))
+
+	// Dimensions of formats the standard library decodes; 0 otherwise
+	width, height := 0, 0
+	if config, _, err := image.DecodeConfig(io.NewSectionReader(content, 0, size)); err == nil {
+		width, height = config.Width, config.Height
+	}
+
+	now := time.Now().UTC()
+	key := keyPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		switch strings.Trim(placeholder, "{}") {
+		case "name":
+			return name
+		case "ext":
+			return ext
+		case "hash":
+			return digest[:16]
+		case "sha256":
+			return digest
+		case "width":
+			return strconv.Itoa(width)
+		case "height":
+			return strconv.Itoa(height)
+		case "date":
+			return now.Format("2006/01/02")
+		case "timestamp":
+			return strconv.FormatInt(now.Unix(), 10)
+		case "uuid":
+			return uuid.New().String()
+		}
+		return placeholder
+	})
+
+	// An empty {ext} must not leave a trailing dot
+	key = strings.Trim(strings.TrimSuffix(key, "."), "/")
+	if key == "" {
+		return "", fmt.Errorf("%w: template %q renders an empty key", ErrInvalidKeyTemplate, template)
+	}
+	return key, nil
+}
+
+// templateReadsContent reports whether a template needs the object's bytes
+func templateReadsContent(template string) bool {
+	for _, placeholder := range []string{"{hash}", "{sha256}", "{width}", "{height}"} {
+		if strings.Contains(template, placeholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputFilename names a converted file after its source: name's extension
+// (or "converted" when name is empty) is replaced by contentType's
+func OutputFilename(name, contentType string) string {
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	if base == "" || base == "." || base == "/" {
+		base = "converted"
+	}
+	return base + "." + extensionForContentType(contentType)
+}
+
+// extensionForContentType returns the usual extension (without the dot) of a
+// MIME type, or "bin"
+func extensionForContentType(contentType string) string {
+	base, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch strings.TrimSpace(base) {
+	case "audio/ogg":
+		return "ogg"
+	case "audio/mpeg":
+		return "mp3"
+	case "image/jpeg":
+		return "jpg"
+	case "video/mp4":
+		return "mp4"
+	}
+	if extensions, err := mime.ExtensionsByType(base); err == nil && len(extensions) > 0 {
+		return strings.TrimPrefix(extensions[0], ".")
+	}
+	return "bin"
+}
+
+// freeKey returns key, or key with the first free -N suffix before its extension
+func (s *S3Service) freeKey(ctx context.Context, key string) (string, error) {
+	dir, file := path.Split(key)
+	ext := path.Ext(file)
+	stem := strings.TrimSuffix(file, ext)
+
+	for n := 0; n <= maxKeySuffix; n++ {
+		candidate := key
+		if n > 0 {
+			candidate = fmt.Sprintf("%s%s-%d%s", dir, stem, n, ext)
+		}
+		taken, err := s.keyExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s and its first %d suffixes", ErrObjectExists, key, maxKeySuffix)
+}
+
+// keyExists reports whether an object is stored under key
+func (s *S3Service) keyExists(ctx context.Context, key string) (bool, error) {
+	_, err := s.GetObjectInfo(ctx, key)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, providers.ErrObjectNotFound):
+		return false, nil
+	}
+	return false, fmt.Errorf("checking key %s: %w", key, err)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// healthCheckTimeout bounds each background provider health check
+const healthCheckTimeout = 10 * time.Second
+
+// monitorProvider checks the provider every interval and, after threshold
+// consecutive failures, replaces it with a freshly created one. Fresh clients
+// pick up rotated credentials and drop broken connections. Failed reconnects
+// are retried with a backoff doubling from interval up to maxBackoff.
+func (s *S3Service) monitorProvider(interval time.Duration, threshold int, maxBackoff time.Duration) {
+	defer close(s.monitorDone)
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	failures := 0
+	backoff := interval
+
+	for {
+		select {
+		case <-s.stopMonitor:
+			return
+		case <-timer.C:
+		}
+
+		wait := interval
+
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		err := s.HealthCheck(ctx)
+		cancel()
+
+		switch {
+		case err == nil:
+			if failures > 0 {
+				log.Printf("✅ S3 provider healthy again after %d failed health checks", failures)
+			}
+			failures, backoff = 0, interval
+			s.recordHealth(true, 0)
+
+		case failures+1 < threshold:
+			failures++
+			log.Printf("⚠️ S3 health check failed (%d/%d): %v", failures, threshold, err)
+			s.recordHealth(false, failures)
+
+		default:
+			failures++
+			log.Printf("⚠️ S3 health check failed %d times in a row, reconnecting: %v", failures, err)
+			s.recordHealth(false, failures)
+
+			if err := s.reconnect(); err != nil {
+				log.Printf("❌ S3 reconnect failed, retrying in %s: %v", backoff, err)
+				wait = backoff
+				backoff = min(backoff*2, maxBackoff)
+			} else {
+				log.Printf("🔄 S3 provider reconnected after %d failed health checks", failures)
+				failures, backoff = 0, interval
+				s.recordHealth(true, 0)
+			}
+		}
+
+		timer.Reset(wait)
+	}
+}
+
+// reconnect swaps in a new provider built from the current configuration,
+// unless Reload replaced the configuration in the meantime
+func (s *S3Service) reconnect() error {
+	s.mu.RLock()
+	cfg, faults := s.config, s.faults
+	s.mu.RUnlock()
+
+	// Built without holding the lock so uploads aren't blocked on a dead endpoint
+	provider, err := s.newProvider(cfg, faults)
+
+	s.stats.mu.Lock()
+	if err != nil {
+		s.stats.FailedReconnects++
+	} else {
+		s.stats.Reconnects++
+		s.stats.LastReconnect = time.Now()
+	}
+	s.stats.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.config == cfg && s.enabled {
+		s.provider = provider
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// recordHealth publishes the outcome of the latest health check in the stats
+func (s *S3Service) recordHealth(healthy bool, failures int) {
+	s.stats.mu.Lock()
+	defer s.stats.mu.Unlock()
+
+	s.stats.ProviderHealthy = healthy
+	s.stats.HealthCheckFailures = int64(failures)
+}
+
+// Close stops the background health checks, trash purges and expiry sweeps
+func (s *S3Service) Close() {
+	for _, loop := range []struct{ stop, done chan struct{} }{
+		{s.stopMonitor, s.monitorDone},
+		{s.stopTrash, s.trashDone},
+		{s.stopSweep, s.sweepDone},
+	} {
+		if loop.stop == nil {
+			continue
+		}
+		select {
+		case <-loop.stop:
+		default:
+			close(loop.stop)
+		}
+		<-loop.done
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -20,6 +21,20 @@ type S3Service struct {
 	mu       sync.RWMutex
 	stats    *S3Stats
 	enabled  bool
+
+	faults       providers.Faults             // Chaos testing: injected provider faults
+	verification *providers.VerificationStats // Outcomes of S3_VERIFY_UPLOADS checks
+
+	stopMonitor chan struct{} // Closed by Close to end monitorProvider
+	monitorDone chan struct{}
+
+	trash     map[string]time.Time // Trash keys of objects soft-deleted by this process, by purge time
+	trashMu   sync.Mutex
+	stopTrash chan struct{} // Closed by Close to end purgeTrashLoop
+	trashDone chan struct{}
+
+	stopSweep chan struct{} // Closed by Close to end sweepExpiredLoop
+	sweepDone chan struct{}
 }
 
 // S3Stats tracks service statistics
@@ -30,7 +45,22 @@ type S3Stats struct {
 	TotalBytes        int64         `json:"total_bytes"`
 	AverageUploadTime time.Duration `json:"average_upload_time"`
 	LastUpload        time.Time     `json:"last_upload"`
-	mu                sync.RWMutex
+
+	// Provider recovery, tracked even when EnableMetrics is off
+	ProviderHealthy     bool      `json:"provider_healthy"`
+	HealthCheckFailures int64     `json:"health_check_failures"` // Consecutive
+	Reconnects          int64     `json:"reconnects"`
+	FailedReconnects    int64     `json:"failed_reconnects"`
+	LastReconnect       time.Time `json:"last_reconnect"`
+
+	// Upload verification (S3_VERIFY_UPLOADS)
+	VerifiedUploads        int64 `json:"verified_uploads"`
+	VerificationMismatches int64 `json:"verification_mismatches"` // Stored objects that didn't match, retried or not
+	VerificationFailures   int64 `json:"verification_failures"`   // Uploads failed after every retry
+
+	// Objects deleted by the expiry sweep (providers without lifecycle tag rules)
+	ExpiredObjects int64 `json:"expired_objects"`
+	mu             sync.RWMutex
 }
 
 // NewS3Service creates a new S3 service
@@ -38,17 +68,39 @@ func NewS3Service(cfg *config.S3Configuration) (*S3Service, error) {
 	service := &S3Service{
 		config:  cfg,
 		factory: providers.NewProviderFactory(),
-		stats:   &S3Stats{},
+		stats:   &S3Stats{ProviderHealthy: cfg.Enabled},
 		enabled: cfg.Enabled,
+
+		verification: &providers.VerificationStats{},
+		trash:        make(map[string]time.Time),
 	}
 
 	if cfg.Enabled {
+		if err := ValidateKeyTemplate(cfg.KeyTemplate, cfg.KeyCollision); err != nil {
+			return nil, fmt.Errorf("invalid S3_KEY_TEMPLATE or S3_KEY_COLLISION: %w", err)
+		}
 		if err := service.initializeProvider(); err != nil {
 			return nil, fmt.Errorf("failed to initialize S3 provider: %w", err)
 		}
 
 		log.Printf("✅ S3 Service initialized with provider: %s", cfg.Provider)
 		cfg.PrintS3Config()
+
+		if cfg.HealthCheckInterval > 0 {
+			service.stopMonitor = make(chan struct{})
+			service.monitorDone = make(chan struct{})
+			go service.monitorProvider(cfg.HealthCheckInterval, cfg.ReconnectThreshold, cfg.ReconnectMaxBackoff)
+		}
+		if cfg.SoftDelete {
+			service.stopTrash = make(chan struct{})
+			service.trashDone = make(chan struct{})
+			go service.purgeTrashLoop(cfg.TrashTTL)
+		}
+		if cfg.ExpirySweepInterval > 0 {
+			service.stopSweep = make(chan struct{})
+			service.sweepDone = make(chan struct{})
+			go service.sweepExpiredLoop(cfg.ExpirySweepInterval)
+		}
 	} else {
 		log.Println("📦 S3 Service: Disabled")
 	}
@@ -61,26 +113,76 @@ func (s *S3Service) initializeProvider() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if err := s.config.Validate(); err != nil {
-		return fmt.Errorf("invalid S3 configuration: %w", err)
+	provider, err := s.newProvider(s.config, s.faults)
+	if err != nil {
+		return err
+	}
+
+	s.provider = provider
+	return nil
+}
+
+// newProvider creates a provider for cfg and checks it can reach the bucket
+func (s *S3Service) newProvider(cfg *config.S3Configuration, faults providers.Faults) (providers.S3Provider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid S3 configuration: %w", err)
 	}
 
-	providerConfig := s.config.ToProviderConfig()
+	providerConfig := cfg.ToProviderConfig()
 	provider, err := s.factory.CreateProvider(providerConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create S3 provider: %w", err)
+		return nil, fmt.Errorf("failed to create S3 provider: %w", err)
 	}
+	provider = providers.NewTracingProvider(provider, string(cfg.Provider))
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	if err := provider.HealthCheck(ctx); err != nil {
-		return fmt.Errorf("S3 provider health check failed: %w", err)
+		return nil, fmt.Errorf("S3 provider health check failed: %w", err)
 	}
 
-	s.provider = provider
-	return nil
+	if faults.Enabled() {
+		provider = providers.NewFaultInjectingProvider(provider, faults)
+	}
+
+	return s.verifying(provider, cfg), nil
+}
+
+// verifying wraps provider so uploads are checked against the stored object
+// when S3_VERIFY_UPLOADS is on. It goes outermost, so injected faults are
+// caught like real ones.
+func (s *S3Service) verifying(provider providers.S3Provider, cfg *config.S3Configuration) providers.S3Provider {
+	if !cfg.VerifyUploads {
+		return provider
+	}
+	return providers.NewVerifyingProvider(provider, cfg.VerifyRetries, s.verification)
+}
+
+// SetFaultInjection makes a percentage of provider calls fail with a
+// retryable 503 and a percentage of uploads be stored truncated. The
+// setting survives Reload.
+func (s *S3Service) SetFaultInjection(faults providers.Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = faults
+	if s.provider != nil && faults.Enabled() {
+		provider := s.provider
+		if verifying, ok := provider.(*providers.VerifyingProvider); ok {
+			provider = verifying.Unwrap()
+		}
+		s.provider = s.verifying(providers.NewFaultInjectingProvider(provider, faults), s.config)
+	}
+}
+
+// activeProvider returns the provider in use, nil before it was initialized
+func (s *S3Service) activeProvider() providers.S3Provider {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.provider
 }
 
 // IsEnabled returns whether S3 service is enabled
@@ -124,7 +226,9 @@ func (s *S3Service) Upload(ctx context.Context, key string, data []byte, opts pr
 
 	// Perform upload
 	reader := &dataReader{data: data}
+	stopStage := timeStage(ctx, StageUpload)
 	result, err := provider.Upload(ctx, key, reader, int64(len(data)), opts)
+	stopStage()
 
 	// Update statistics
 	s.updateStats(startTime, int64(len(data)), err == nil)
@@ -169,7 +273,9 @@ func (s *S3Service) UploadBase64(ctx context.Context, key string, base64Data str
 	}
 
 	// Perform upload
+	stopStage := timeStage(ctx, StageUpload)
 	result, err := provider.UploadBase64(ctx, key, base64Data, opts)
+	stopStage()
 
 	// Update statistics
 	s.updateStats(startTime, result.Size, err == nil)
@@ -240,6 +346,72 @@ func (s *S3Service) GetObjectInfo(ctx context.Context, key string) (*providers.O
 	return provider.GetObjectInfo(ctx, key)
 }
 
+// ListObjects returns a page of the stored objects whose keys start with prefix
+func (s *S3Service) ListObjects(ctx context.Context, opts providers.ListOptions) (*providers.ObjectList, error) {
+	if !s.enabled {
+		return nil, fmt.Errorf("S3 service is disabled")
+	}
+
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return nil, fmt.Errorf("S3 provider not initialized")
+	}
+
+	return provider.ListObjects(ctx, opts)
+}
+
+// GetObject reads an object into memory, failing with ErrFileTooLarge past maxSize bytes
+func (s *S3Service) GetObject(ctx context.Context, key string, maxSize int64) ([]byte, error) {
+	var buffer bytes.Buffer
+	if _, err := s.StreamObject(ctx, key, &buffer, maxSize); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// StreamObject copies an object into w, failing with ErrFileTooLarge past
+// maxSize bytes, without holding it in memory
+func (s *S3Service) StreamObject(ctx context.Context, key string, w io.Writer, maxSize int64) (int64, error) {
+	if !s.enabled {
+		return 0, fmt.Errorf("S3 service is disabled")
+	}
+
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return 0, fmt.Errorf("S3 provider not initialized")
+	}
+
+	reader, ok := provider.(providers.ObjectReader)
+	if !ok {
+		return 0, providers.ErrFeatureNotSupported
+	}
+
+	defer timeStage(ctx, StageDownload)()
+
+	body, err := reader.GetObject(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.Copy(w, io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return n, fmt.Errorf("failed to read object: %w", err)
+	}
+	if n > maxSize {
+		return n, providers.ErrFileTooLarge
+	}
+
+	return n, nil
+}
+
 // HealthCheck verifies S3 service health
 func (s *S3Service) HealthCheck(ctx context.Context) error {
 	if !s.enabled {
@@ -270,6 +442,18 @@ func (s *S3Service) GetStats() *S3Stats {
 		TotalBytes:        s.stats.TotalBytes,
 		AverageUploadTime: s.stats.AverageUploadTime,
 		LastUpload:        s.stats.LastUpload,
+
+		ProviderHealthy:     s.stats.ProviderHealthy,
+		HealthCheckFailures: s.stats.HealthCheckFailures,
+		Reconnects:          s.stats.Reconnects,
+		FailedReconnects:    s.stats.FailedReconnects,
+		LastReconnect:       s.stats.LastReconnect,
+
+		VerifiedUploads:        s.verification.Verified.Load(),
+		VerificationMismatches: s.verification.Mismatches.Load(),
+		VerificationFailures:   s.verification.Failures.Load(),
+
+		ExpiredObjects: s.stats.ExpiredObjects,
 	}
 }
 
@@ -284,6 +468,7 @@ func (s *S3Service) Reload(newConfig *config.S3Configuration) error {
 	defer s.mu.Unlock()
 
 	// Store old state
+	oldConfig := s.config
 	oldEnabled := s.enabled
 	oldProvider := s.provider
 
@@ -291,12 +476,15 @@ func (s *S3Service) Reload(newConfig *config.S3Configuration) error {
 	s.enabled = newConfig.Enabled
 
 	if newConfig.Enabled {
-		if err := s.initializeProvider(); err != nil {
+		provider, err := s.newProvider(newConfig, s.faults)
+		if err != nil {
 			// Restore old state on error
+			s.config = oldConfig
 			s.enabled = oldEnabled
 			s.provider = oldProvider
 			return fmt.Errorf("failed to reload S3 service: %w", err)
 		}
+		s.provider = provider
 		log.Printf("🔄 S3 Service reloaded with provider: %s", newConfig.Provider)
 	} else {
 		s.provider = nil
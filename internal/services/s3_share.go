@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"whats-convert-api/internal/providers"
+)
+
+// ErrInvalidShareTTL is returned for share TTLs outside (0, S3_SHARE_MAX_TTL]
+var ErrInvalidShareTTL = errors.New("invalid share TTL")
+
+// ShareGrant is temporary public access to a private object
+type ShareGrant struct {
+	ID        string
+	Key       string
+	URL       string
+	TTL       time.Duration
+	ExpiresAt time.Time
+}
+
+// Share presigns a GET URL for key valid for ttl (0 = S3_SHARE_DEFAULT_TTL).
+// The URL stops working on its own, so nothing has to be revoked afterwards.
+func (s *S3Service) Share(ctx context.Context, key string, ttl time.Duration) (*ShareGrant, error) {
+	if !s.enabled {
+		return nil, fmt.Errorf("S3 service is disabled")
+	}
+
+	if ttl == 0 {
+		ttl = s.config.ShareDefaultTTL
+	}
+	if ttl <= 0 || ttl > s.config.ShareMaxTTL {
+		return nil, fmt.Errorf("%w: must be between 0s and %s", ErrInvalidShareTTL, s.config.ShareMaxTTL)
+	}
+
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return nil, fmt.Errorf("S3 provider not initialized")
+	}
+
+	presigner, ok := provider.(providers.Presigner)
+	if !ok {
+		return nil, providers.ErrFeatureNotSupported
+	}
+
+	// Presigning is offline, so check the object exists rather than hand out a dead link
+	if _, err := provider.GetObjectInfo(ctx, key); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	url, err := presigner.PresignGetURL(ctx, key, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShareGrant{
+		ID:        uuid.New().String(),
+		Key:       key,
+		URL:       url,
+		TTL:       ttl,
+		ExpiresAt: now.Add(ttl).UTC(),
+	}, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"whats-convert-api/internal/providers"
+)
+
+var (
+	// ErrObjectTooLarge is returned when a streamed object passes S3_MAX_FILE_SIZE
+	ErrObjectTooLarge = errors.New("file size exceeds maximum allowed")
+
+	// ErrContentTypeNotAllowed is returned for content types outside S3_ALLOWED_CONTENT_TYPES
+	ErrContentTypeNotAllowed = errors.New("content type not allowed")
+)
+
+// UploadStream uploads a stream of unknown size to key in multipart parts.
+// Content type restrictions apply up front; the size limit fails the upload
+// once the stream passes it.
+func (s *S3Service) UploadStream(ctx context.Context, key string, reader io.Reader, opts providers.UploadOptions) (*providers.UploadResult, error) {
+	if !s.enabled {
+		return nil, fmt.Errorf("S3 service is disabled")
+	}
+
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return nil, fmt.Errorf("S3 provider not initialized")
+	}
+
+	startTime := time.Now()
+
+	if !s.config.IsContentTypeAllowed(opts.ContentType) {
+		return nil, fmt.Errorf("%w: %s", ErrContentTypeNotAllowed, opts.ContentType)
+	}
+	if s.config.MaxFileSize > 0 {
+		reader = &sizeLimitReader{reader: reader, limit: s.config.MaxFileSize}
+	}
+
+	if opts.ExpirationDays == 0 {
+		opts.ExpirationDays = s.config.DefaultExpirationDays
+	}
+	if !opts.Public && s.config.PublicRead {
+		opts.Public = true
+	}
+
+	result, err := provider.Upload(ctx, key, reader, -1, opts)
+
+	var size int64
+	if result != nil {
+		size = result.Size
+	}
+	s.updateStats(startTime, size, err == nil)
+
+	if err != nil {
+		if s.config.LogUploads {
+			log.Printf("❌ S3 stream upload failed for key '%s': %v", key, err)
+		}
+		return nil, err
+	}
+
+	if s.config.LogUploads {
+		log.Printf("✅ S3 stream upload successful: %s (size: %d bytes, time: %v)",
+			result.Key, result.Size, result.ProcessingTime)
+	}
+
+	return result, nil
+}
+
+// PipeUpload uploads what produce writes while it writes it, through an
+// io.Pipe, so a conversion's output reaches the bucket without being held in
+// memory. produce failing aborts the upload and its error is returned; the
+// upload failing cancels produce's context and fails its writes.
+func (s *S3Service) PipeUpload(ctx context.Context, key string, opts providers.UploadOptions, produce func(ctx context.Context, w io.Writer) error) (*providers.UploadResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader, writer := io.Pipe()
+	produced := make(chan error, 1)
+	go func() {
+		err := produce(ctx, writer)
+		// Reported before the pipe closes, so a failed upload can tell
+		// whether produce's error caused it
+		produced <- err
+		writer.CloseWithError(err)
+	}()
+
+	result, err := s.UploadStream(ctx, key, reader, opts)
+	if err == nil {
+		<-produced // nil: uploads only complete at EOF
+		return result, nil
+	}
+
+	select {
+	case produceErr := <-produced:
+		if produceErr != nil {
+			return nil, produceErr
+		}
+	default:
+		// The upload gave up first: stop the producer and wait for it
+		reader.CloseWithError(err)
+		cancel()
+		<-produced
+	}
+	return nil, err
+}
+
+// sizeLimitReader fails reads once more than limit bytes went through it
+type sizeLimitReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrObjectTooLarge, r.limit)
+	}
+	return n, err
+}
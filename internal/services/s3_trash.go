@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"strings"
+	"time"
+
+	"whats-convert-api/internal/providers"
+)
+
+// Errors returned by soft deletes and restores
+var (
+	ErrNotInTrash     = errors.New("object is not in the trash")
+	ErrTrashExpired   = errors.New("object was purged from the trash")
+	ErrRestoreBlocked = errors.New("an object already exists at the original key")
+)
+
+// Metadata recorded on trashed objects; S3 lowercases user metadata keys
+const (
+	trashDeletedAtMeta = "trash-deleted-at"
+	trashExpiresAtMeta = "trash-expires-at"
+)
+
+// TrashedObject is an object moved to the trash by a soft delete
+type TrashedObject struct {
+	Key       string    // Original key
+	TrashKey  string    // Key of the copy under S3_TRASH_PREFIX
+	ExpiresAt time.Time // When the copy is purged
+}
+
+// SoftDeleteEnabled reports whether deletes move objects to the trash
+func (s *S3Service) SoftDeleteEnabled() bool {
+	return s.enabled && s.config.SoftDelete
+}
+
+// trashKey returns where key is kept while in the trash
+func (s *S3Service) trashKey(key string) string {
+	return s.config.TrashPrefix + key
+}
+
+// Trash moves key under S3_TRASH_PREFIX, where it can be restored until
+// S3_TRASH_TTL passes. Deleting an object that is already in the trash, or
+// trashing a key twice, keeps only the latest copy.
+func (s *S3Service) Trash(ctx context.Context, key string) (*TrashedObject, error) {
+	if !s.enabled {
+		return nil, fmt.Errorf("S3 service is disabled")
+	}
+	if strings.HasPrefix(key, s.config.TrashPrefix) {
+		return nil, fmt.Errorf("%q is already in the trash; delete it permanently instead", key)
+	}
+
+	now := time.Now().UTC()
+	trashed := &TrashedObject{
+		Key:       key,
+		TrashKey:  s.trashKey(key),
+		ExpiresAt: now.Add(s.config.TrashTTL),
+	}
+
+	err := s.moveObject(ctx, key, trashed.TrashKey, func(metadata map[string]string) {
+		metadata[trashDeletedAtMeta] = now.Format(time.RFC3339)
+		metadata[trashExpiresAtMeta] = trashed.ExpiresAt.Format(time.RFC3339)
+	})
+	if err != nil {
+		if s.config.LogUploads {
+			log.Printf("❌ S3 Trash failed for key '%s': %v", key, err)
+		}
+		return nil, err
+	}
+
+	s.trashMu.Lock()
+	s.trash[trashed.TrashKey] = trashed.ExpiresAt
+	s.trashMu.Unlock()
+
+	if s.config.LogUploads {
+		log.Printf("🗑️ S3 Trash: %s -> %s (until %s)", key, trashed.TrashKey, trashed.ExpiresAt.Format(time.RFC3339))
+	}
+	return trashed, nil
+}
+
+// Restore moves a trashed object back to key. It refuses to overwrite an
+// object stored at key since the delete.
+func (s *S3Service) Restore(ctx context.Context, key string) error {
+	if !s.enabled {
+		return fmt.Errorf("S3 service is disabled")
+	}
+
+	provider, err := s.currentProvider()
+	if err != nil {
+		return err
+	}
+
+	trashKey := s.trashKey(key)
+	info, err := provider.GetObjectInfo(ctx, trashKey)
+	if err != nil {
+		if errors.Is(err, providers.ErrObjectNotFound) {
+			return ErrNotInTrash
+		}
+		return err
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, info.Metadata[trashExpiresAtMeta]); err == nil && time.Now().After(expiresAt) {
+		s.purge(ctx, trashKey)
+		return ErrTrashExpired
+	}
+
+	if _, err := provider.GetObjectInfo(ctx, key); err == nil {
+		return ErrRestoreBlocked
+	} else if !errors.Is(err, providers.ErrObjectNotFound) {
+		return err
+	}
+
+	err = s.moveObject(ctx, trashKey, key, func(metadata map[string]string) {
+		delete(metadata, trashDeletedAtMeta)
+		delete(metadata, trashExpiresAtMeta)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.trashMu.Lock()
+	delete(s.trash, trashKey)
+	s.trashMu.Unlock()
+
+	if s.config.LogUploads {
+		log.Printf("♻️ S3 Restore: %s -> %s", trashKey, key)
+	}
+	return nil
+}
+
+// moveObject copies src to dst through the API, with its metadata adjusted by
+// edit, then deletes src. Providers have no common server-side copy, so the
+// body is streamed through this process.
+func (s *S3Service) moveObject(ctx context.Context, src, dst string, edit func(metadata map[string]string)) error {
+	provider, err := s.currentProvider()
+	if err != nil {
+		return err
+	}
+	reader, ok := provider.(providers.ObjectReader)
+	if !ok {
+		return providers.ErrFeatureNotSupported
+	}
+
+	info, err := provider.GetObjectInfo(ctx, src)
+	if err != nil {
+		return err
+	}
+	body, err := reader.GetObject(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	metadata := maps.Clone(info.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	edit(metadata)
+
+	opts := providers.DefaultUploadOptions()
+	opts.ContentType = info.ContentType
+	opts.Metadata = metadata
+	opts.Public = s.config.PublicRead
+	if info.StorageClass != "" {
+		opts.StorageClass = info.StorageClass
+	}
+	if _, err := provider.Upload(ctx, dst, body, info.Size, opts); err != nil {
+		return fmt.Errorf("copy to %s: %w", dst, err)
+	}
+
+	if err := provider.DeleteObject(ctx, src); err != nil {
+		return fmt.Errorf("copied to %s but failed to delete %s: %w", dst, src, err)
+	}
+	return nil
+}
+
+// currentProvider returns the provider in use
+func (s *S3Service) currentProvider() (providers.S3Provider, error) {
+	s.mu.RLock()
+	provider := s.provider
+	s.mu.RUnlock()
+
+	if provider == nil {
+		return nil, fmt.Errorf("S3 provider not initialized")
+	}
+	return provider, nil
+}
+
+// purgeTrashLoop permanently deletes objects trashed by this process once
+// their TTL passes. Objects trashed before a restart are only purged by a
+// bucket lifecycle rule on S3_TRASH_PREFIX.
+func (s *S3Service) purgeTrashLoop(ttl time.Duration) {
+	defer close(s.trashDone)
+
+	interval := min(ttl/4, 10*time.Minute)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.purgeExpiredTrash()
+		case <-s.stopTrash:
+			return
+		}
+	}
+}
+
+// purgeExpiredTrash deletes the trashed objects whose TTL has passed
+func (s *S3Service) purgeExpiredTrash() {
+	now := time.Now()
+
+	s.trashMu.Lock()
+	var expired []string
+	for key, expiresAt := range s.trash {
+		if now.After(expiresAt) {
+			expired = append(expired, key)
+		}
+	}
+	s.trashMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, key := range expired {
+		s.purge(ctx, key)
+	}
+}
+
+// purge permanently deletes a trashed object
+func (s *S3Service) purge(ctx context.Context, trashKey string) {
+	provider, err := s.currentProvider()
+	if err != nil {
+		return
+	}
+
+	err = provider.DeleteObject(ctx, trashKey)
+	if err != nil && !errors.Is(err, providers.ErrObjectNotFound) {
+		log.Printf("⚠️ S3 Trash purge of '%s' failed: %v", trashKey, err)
+		return
+	}
+
+	s.trashMu.Lock()
+	delete(s.trash, trashKey)
+	s.trashMu.Unlock()
+}
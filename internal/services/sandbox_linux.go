@@ -0,0 +1,41 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// validateNamespaceSandbox checks that namespaces can be created with cfg
+func validateNamespaceSandbox(cfg SandboxConfig) error {
+	if cfg.UID >= 0 && os.Geteuid() != 0 {
+		return fmt.Errorf("SANDBOX_UID requires running as root in namespaces mode")
+	}
+	return nil
+}
+
+// namespaceSysProcAttr isolates the child in new network, IPC and UTS namespaces.
+// As root the child drops to the configured uid/gid; otherwise an unprivileged
+// user namespace maps the API user so the namespaces can be created.
+func namespaceSysProcAttr(cfg SandboxConfig) *syscall.SysProcAttr {
+	attr := &syscall.SysProcAttr{
+		Cloneflags: syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		Setpgid:    true,
+		Pdeathsig:  syscall.SIGKILL,
+	}
+
+	if os.Geteuid() == 0 {
+		if cfg.UID >= 0 {
+			attr.Credential = &syscall.Credential{Uid: uint32(cfg.UID), Gid: uint32(cfg.GID)}
+		}
+		return attr
+	}
+
+	uid, gid := os.Getuid(), os.Getgid()
+	attr.Cloneflags |= syscall.CLONE_NEWUSER
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+
+	return attr
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package services
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// validateNamespaceSandbox rejects namespaces mode outside Linux
+func validateNamespaceSandbox(cfg SandboxConfig) error {
+	return fmt.Errorf("SANDBOX_MODE=namespaces is only supported on Linux")
+}
+
+// namespaceSysProcAttr is never used outside Linux since validation fails first
+func namespaceSysProcAttr(cfg SandboxConfig) *syscall.SysProcAttr {
+	return nil
+}
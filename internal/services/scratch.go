@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type sandboxBindsKey struct{}
+
+// withSandboxBind returns a context whose sandboxed commands can read and write dir
+func withSandboxBind(ctx context.Context, dir string) context.Context {
+	// Copy so sibling contexts never share a backing array
+	binds := append([]string(nil), sandboxBindsFrom(ctx)...)
+	return context.WithValue(ctx, sandboxBindsKey{}, append(binds, dir))
+}
+
+func sandboxBindsFrom(ctx context.Context) []string {
+	binds, _ := ctx.Value(sandboxBindsKey{}).([]string)
+	return binds
+}
+
+// scratchPrefix starts the names of scratch directories, for the temp janitor
+const scratchPrefix = "whats-convert-scratch-"
+
+// scratchDir is a per-conversion working directory for tools that need
+// seekable files rather than pipes (MP4/MOV inputs with a trailing index,
+// faststart MP4 output)
+type scratchDir struct {
+	path string
+}
+
+// newScratchDir creates a private directory under parent (os.TempDir() when
+// empty) and returns a context that exposes it to sandboxed commands
+func newScratchDir(ctx context.Context, parent string) (context.Context, *scratchDir, error) {
+	path, err := os.MkdirTemp(parent, scratchPrefix+"*")
+	if err != nil {
+		return ctx, nil, fmt.Errorf("create scratch directory: %w", err)
+	}
+
+	// Tools running as a dedicated user must be able to write their output
+	sandboxMu.RLock()
+	uid, gid := sandboxConfig.UID, sandboxConfig.GID
+	sandboxMu.RUnlock()
+	if uid >= 0 {
+		if err := os.Chown(path, uid, gid); err != nil {
+			os.RemoveAll(path)
+			return ctx, nil, fmt.Errorf("hand scratch directory to uid %d: %w", uid, err)
+		}
+	}
+
+	return withSandboxBind(ctx, path), &scratchDir{path: path}, nil
+}
+
+// file returns the path of name inside the directory
+func (d *scratchDir) file(name string) string {
+	return filepath.Join(d.path, name)
+}
+
+// writeFile stores data as name and returns its path
+func (d *scratchDir) writeFile(name string, data []byte) (string, error) {
+	path := d.file(name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write scratch file: %w", err)
+	}
+	return path, nil
+}
+
+// writeInput stores a conversion input as name and returns its path
+func (d *scratchDir) writeInput(name string, input mediaInput) (string, error) {
+	if input.file == nil && input.path == "" {
+		return d.writeFile(name, input.data)
+	}
+
+	src, err := input.open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	path := d.file(name)
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("write scratch file: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", fmt.Errorf("write scratch file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", fmt.Errorf("write scratch file: %w", err)
+	}
+	return path, nil
+}
+
+// copyFile streams the file at path into w
+func copyFile(path string, w io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// remove deletes the directory and everything the tools left in it
+func (d *scratchDir) remove() {
+	os.RemoveAll(d.path)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sealer encrypts files kept at rest with AES-256-GCM. Each file is
+// authenticated together with its ID, so it can't be swapped under another name.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+// newSealer hashes secret into the key; an empty secret uses a random key,
+// so sealed files don't survive a restart
+func newSealer(secret string) (*sealer, error) {
+	key := make([]byte, 32)
+	if secret == "" {
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	} else {
+		sum := sha256.Sum256([]byte(secret))
+		key = sum[:]
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// seal encrypts plaintext for the file named id
+func (s *sealer) seal(id string, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, plaintext, []byte(id)), nil
+}
+
+// open decrypts the file named id
+func (s *sealer) open(id string, sealed []byte) ([]byte, error) {
+	nonceSize := s.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, fmt.Errorf("%s is truncated", id)
+	}
+	plaintext, err := s.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s: %w", id, err)
+	}
+	return plaintext, nil
+}
+
+// expireLoop deletes the files ending in ext that are older than ttl from
+// dir, based on their modification time, until stop is closed
+func expireLoop(dir, ext string, ttl time.Duration, stop <-chan struct{}) {
+	interval := ttl / 4
+	if interval > 10*time.Minute {
+		interval = 10 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removeExpired(dir, ext, ttl)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func removeExpired(dir, ext string, ttl time.Duration) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("Cleanup of %s failed: %v", dir, err)
+		return
+	}
+
+	cutoff := time.Now().Add(-ttl)
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ext) {
+			continue
+		}
+		info, err := entry.Info()
+		if err == nil && info.ModTime().Before(cutoff) {
+			_ = os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedInput is returned for an input of the wrong kind, such as an
+// image sent for audio conversion
+var ErrUnsupportedInput = errors.New("unsupported input")
+
+// sniffLen is how much of an input the magic bytes are read from
+const sniffLen = 512
+
+// MediaType is the format of an input, detected from its content
+type MediaType struct {
+	MIME      string `json:"mime" example:"audio/ogg"`
+	Container string `json:"container" example:"ogg"`
+	Codec     string `json:"codec,omitempty" example:"opus"` // Main stream codec, when the header names it
+	Kind      string `json:"kind" example:"audio"`           // audio, image, video or unknown
+}
+
+// unknownMediaType is reported when neither the magic bytes nor ffprobe
+// recognise an input
+var unknownMediaType = MediaType{MIME: "application/octet-stream", Kind: MediaKindUnknown}
+
+// ftypBrands maps ISO base media brands to the format they announce; any
+// other brand is treated as MP4
+var ftypBrands = map[string]MediaType{
+	"M4A ": {MIME: "audio/mp4", Container: "m4a", Kind: MediaKindAudio},
+	"M4B ": {MIME: "audio/mp4", Container: "m4a", Kind: MediaKindAudio},
+	"qt  ": {MIME: "video/quicktime", Container: "mov", Kind: MediaKindVideo},
+	"heic": {MIME: "image/heic", Container: "heif", Codec: "hevc", Kind: MediaKindImage},
+	"heix": {MIME: "image/heic", Container: "heif", Codec: "hevc", Kind: MediaKindImage},
+	"mif1": {MIME: "image/heif", Container: "heif", Kind: MediaKindImage},
+	"msf1": {MIME: "image/heif", Container: "heif", Kind: MediaKindImage},
+	"avif": {MIME: "image/avif", Container: "avif", Codec: "av1", Kind: MediaKindImage},
+	"avis": {MIME: "image/avif", Container: "avif", Codec: "av1", Kind: MediaKindImage},
+}
+
+// SniffBytes identifies a format from the magic bytes at the start of an
+// input (the first sniffLen bytes are enough)
+func SniffBytes(head []byte) (MediaType, bool) {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xff, 0xd8, 0xff}):
+		return MediaType{MIME: "image/jpeg", Container: "jpeg", Codec: "mjpeg", Kind: MediaKindImage}, true
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return MediaType{MIME: "image/png", Container: "png", Codec: "png", Kind: MediaKindImage}, true
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return MediaType{MIME: "image/gif", Container: "gif", Codec: "gif", Kind: MediaKindImage}, true
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return MediaType{MIME: "image/webp", Container: "webp", Codec: "webp", Kind: MediaKindImage}, true
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return MediaType{MIME: "audio/wav", Container: "wav", Codec: "pcm", Kind: MediaKindAudio}, true
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "AVI ":
+		return MediaType{MIME: "video/x-msvideo", Container: "avi", Kind: MediaKindVideo}, true
+	case bytes.HasPrefix(head, []byte("BM")) && len(head) >= 14 && head[6] == 0 && head[7] == 0:
+		return MediaType{MIME: "image/bmp", Container: "bmp", Codec: "bmp", Kind: MediaKindImage}, true
+	case bytes.HasPrefix(head, []byte("II*\x00")), bytes.HasPrefix(head, []byte("MM\x00*")):
+		return MediaType{MIME: "image/tiff", Container: "tiff", Codec: "tiff", Kind: MediaKindImage}, true
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		if known, ok := ftypBrands[string(head[8:12])]; ok {
+			return known, true
+		}
+		if string(head[8:11]) == "3gp" {
+			return MediaType{MIME: "video/3gpp", Container: "3gp", Kind: MediaKindVideo}, true
+		}
+		return MediaType{MIME: "video/mp4", Container: "mp4", Kind: MediaKindVideo}, true
+	case bytes.HasPrefix(head, []byte{0x1a, 0x45, 0xdf, 0xa3}):
+		// Browsers record voice notes as audio-only WebM, which only the
+		// tracks tell apart
+		if bytes.Contains(head, []byte("webm")) {
+			return MediaType{MIME: "video/webm", Container: "webm", Kind: MediaKindVideo}, true
+		}
+		return MediaType{MIME: "video/x-matroska", Container: "matroska", Kind: MediaKindVideo}, true
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return sniffOgg(head), true
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return MediaType{MIME: "audio/flac", Container: "flac", Codec: "flac", Kind: MediaKindAudio}, true
+	case bytes.HasPrefix(head, []byte("#!AMR-WB\n")):
+		return MediaType{MIME: "audio/amr-wb", Container: "amr", Codec: "amr_wb", Kind: MediaKindAudio}, true
+	case bytes.HasPrefix(head, []byte("#!AMR\n")):
+		return MediaType{MIME: "audio/amr", Container: "amr", Codec: "amr_nb", Kind: MediaKindAudio}, true
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return MediaType{MIME: "audio/mpeg", Container: "mp3", Codec: "mp3", Kind: MediaKindAudio}, true
+	case len(head) >= 2 && head[0] == 0xff && head[1]&0xf6 == 0xf0:
+		// ADTS sync word with layer 0
+		return MediaType{MIME: "audio/aac", Container: "aac", Codec: "aac", Kind: MediaKindAudio}, true
+	case len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0 && head[1]&0x06 != 0:
+		// MPEG audio frame sync with a layer set, an MP3 without ID3 tag
+		return MediaType{MIME: "audio/mpeg", Container: "mp3", Codec: "mp3", Kind: MediaKindAudio}, true
+	}
+	return unknownMediaType, false
+}
+
+// sniffOgg names the codec of an Ogg stream from its first packet, which
+// starts right after the 27-byte page header and the segment table
+func sniffOgg(head []byte) MediaType {
+	media := MediaType{MIME: "audio/ogg", Container: "ogg", Kind: MediaKindAudio}
+	if len(head) < 27 {
+		return media
+	}
+	start := 27 + int(head[26])
+	if start >= len(head) {
+		return media
+	}
+
+	packet := head[start:]
+	switch {
+	case bytes.HasPrefix(packet, []byte("OpusHead")):
+		media.Codec = "opus"
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		media.Codec = "vorbis"
+	case bytes.HasPrefix(packet, []byte("\x7fFLAC")):
+		media.Codec = "flac"
+	case bytes.HasPrefix(packet, []byte("\x80theora")):
+		media.MIME, media.Codec, media.Kind = "video/ogg", "theora", MediaKindVideo
+	}
+	return media
+}
+
+// SniffBase64 identifies the format of a base64 payload (a data URI or plain
+// base64) from its first bytes, without decoding the rest
+func SniffBase64(data string) (MediaType, bool) {
+	data = strings.TrimSpace(data)
+	if strings.HasPrefix(strings.ToLower(data), "data:") {
+		if _, payload, ok := strings.Cut(data, ","); ok {
+			data = payload
+		}
+	}
+
+	// Enough characters for sniffLen bytes once whitespace is dropped
+	var prefix strings.Builder
+	for i := 0; i < len(data) && prefix.Len() < sniffLen/3*4; i++ {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+		default:
+			prefix.WriteByte(data[i])
+		}
+	}
+	encoded := strings.TrimRight(prefix.String(), "=")
+	encoded = encoded[:len(encoded)/4*4]
+
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(encoded, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	head, err := encoding.DecodeString(encoded)
+	if err != nil {
+		return unknownMediaType, false
+	}
+	return SniffBytes(head)
+}
+
+// SniffUpload identifies the format of a multipart upload from its first
+// bytes
+func SniffUpload(fileHeader *multipart.FileHeader) (MediaType, bool) {
+	head, err := mediaInput{file: fileHeader}.head()
+	if err != nil {
+		return unknownMediaType, false
+	}
+	return SniffBytes(head)
+}
+
+// head returns the first sniffLen bytes of the input
+func (in mediaInput) head() ([]byte, error) {
+	if in.file == nil && in.path == "" {
+		return in.data[:min(len(in.data), sniffLen)], nil
+	}
+
+	file, err := in.open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("read input: %w", err)
+	}
+	return head[:n], nil
+}
+
+// sniffInput identifies the format of an input from its magic bytes, asking
+// ffprobe when they aren't known. Formats neither recognises are reported
+// as unknown rather than failing, leaving the verdict to the converter.
+func sniffInput(ctx context.Context, input mediaInput) MediaType {
+	media, ok := unknownMediaType, false
+	if head, err := input.head(); err == nil {
+		media, ok = SniffBytes(head)
+	}
+
+	// WebM and Matroska headers don't say whether there is a video track
+	if ok && media.Container != "webm" && media.Container != "matroska" {
+		return media
+	}
+
+	probed, probeOK := probeMediaType(ctx, input)
+	switch {
+	case !probeOK:
+		return media
+	case !ok:
+		return probed
+	}
+
+	media.Kind, media.Codec = probed.Kind, probed.Codec
+	media.MIME = mimeForKind(media.MIME, media.Kind)
+	return media
+}
+
+// mimeForKind turns the video MIME type a container's magic bytes suggest
+// into its audio one (audio/webm, audio/mp4) when the streams are audio only
+func mimeForKind(mime, kind string) string {
+	if kind == MediaKindAudio {
+		return strings.Replace(mime, "video/", "audio/", 1)
+	}
+	return mime
+}
+
+// probeMediaType asks ffprobe for the container and main stream of an input
+func probeMediaType(ctx context.Context, input mediaInput) (MediaType, bool) {
+	probeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	output, _, err := input.run(probeCtx, "ffprobe",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-show_entries", "stream=codec_type,codec_name:stream_disposition=attached_pic:format=format_name",
+		"-of", "json",
+	)
+	if err != nil {
+		return unknownMediaType, false
+	}
+
+	var probe struct {
+		Streams []struct {
+			CodecType   string `json:"codec_type"`
+			CodecName   string `json:"codec_name"`
+			Disposition struct {
+				AttachedPic int `json:"attached_pic"`
+			} `json:"disposition"`
+		} `json:"streams"`
+		Format struct {
+			FormatName string `json:"format_name"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil || len(probe.Streams) == 0 {
+		return unknownMediaType, false
+	}
+
+	// Cover art doesn't make an audio file a video
+	media := MediaType{Container: strings.Split(probe.Format.FormatName, ",")[0], Kind: MediaKindUnknown}
+	for _, stream := range probe.Streams {
+		switch {
+		case stream.CodecType == "video" && stream.Disposition.AttachedPic == 0 && media.Kind != MediaKindVideo:
+			media.Kind, media.Codec = MediaKindVideo, stream.CodecName
+		case stream.CodecType == "audio" && media.Kind == MediaKindUnknown:
+			media.Kind, media.Codec = MediaKindAudio, stream.CodecName
+		}
+	}
+	if media.Kind == MediaKindVideo && isStillImage(probe.Format.FormatName) {
+		media.Kind = MediaKindImage
+	}
+	media.MIME = containerMIME(media.Container, media.Kind)
+	return media, true
+}
+
+// containerMIMEs maps the ffprobe names of containers the magic bytes miss
+// (or that ffprobe reports for inputs it reads) to their MIME types
+var containerMIMEs = map[string]string{
+	"mp3":      "audio/mpeg",
+	"aac":      "audio/aac",
+	"wav":      "audio/wav",
+	"flac":     "audio/flac",
+	"amr":      "audio/amr",
+	"ac3":      "audio/ac3",
+	"ogg":      "video/ogg",
+	"mov":      "video/mp4",
+	"matroska": "video/x-matroska",
+	"avi":      "video/x-msvideo",
+	"mpegts":   "video/mp2t",
+	"mpeg":     "video/mpeg",
+	"flv":      "video/x-flv",
+	"asf":      "video/x-ms-asf",
+	"gif":      "image/gif",
+}
+
+// audioContainerMIMEs are the MIME types of containers holding audio only
+var audioContainerMIMEs = map[string]string{
+	"ogg":      "audio/ogg",
+	"matroska": "audio/webm",
+	"mov":      "audio/mp4",
+}
+
+// containerMIME returns the MIME type of an ffprobe container name, using
+// the kind for containers that hold either audio or video
+func containerMIME(container, kind string) string {
+	container = strings.Split(container, ",")[0]
+	if mime, ok := audioContainerMIMEs[container]; ok && kind == MediaKindAudio {
+		return mime
+	}
+	if mime, ok := containerMIMEs[container]; ok {
+		return mime
+	}
+	if image, ok := strings.CutSuffix(container, "_pipe"); ok {
+		return "image/" + image
+	}
+	return unknownMediaType.MIME
+}
+
+// checkInputKind returns ErrUnsupportedInput for an input of one of the
+// rejected kinds, naming what the endpoint converts to
+func checkInputKind(media MediaType, target string, rejected ...string) error {
+	for _, kind := range rejected {
+		if media.Kind == kind {
+			return fmt.Errorf("%w: %s input can't be converted to %s", ErrUnsupportedInput, media.MIME, target)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/providers"
+)
+
+// spillPrefix starts the names of spill files, for the temp janitor
+const spillPrefix = "whats-convert-spill-"
+
+// errSpillBudget is returned when a payload doesn't fit the spill budget
+var errSpillBudget = errors.New("spill budget exhausted")
+
+// SpillStats are the spill store's metrics
+type SpillStats struct {
+	Dir         string // Directory of the spill files
+	Threshold   int64  // Size from which payloads are spilled
+	Budget      int64  // Most bytes spilled at once (0 = unlimited)
+	ActiveFiles int64  // Spill files in use
+	ActiveBytes int64  // Bytes in those files
+	Spilled     int64  // Payloads spilled since start
+	OverBudget  int64  // Payloads kept in memory because the budget was used up
+}
+
+// SpillStore moves payloads of at least threshold bytes out of memory into
+// temporary files: large base64 inputs are decoded straight to disk,
+// downloads switch to a file once they pass the threshold and big video
+// outputs wait there for the response. Pointed at a tmpfs, it trades
+// anonymous heap for page cache the kernel accounts and limits separately.
+type SpillStore struct {
+	dir       string
+	threshold int64
+	budget    int64
+
+	mu    sync.Mutex
+	stats SpillStats
+}
+
+// NewSpillStore creates a store writing to dir (os.TempDir() when empty).
+// budget caps the bytes spilled at once (0 = unlimited); payloads that don't
+// fit stay in memory.
+func NewSpillStore(dir string, threshold, budget int64) (*SpillStore, error) {
+	if threshold <= 0 {
+		return nil, fmt.Errorf("spill threshold must be positive")
+	}
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create spill dir: %w", err)
+	}
+
+	return &SpillStore{
+		dir:       dir,
+		threshold: threshold,
+		budget:    budget,
+		stats:     SpillStats{Dir: dir, Threshold: threshold, Budget: budget},
+	}, nil
+}
+
+// Dir returns the directory of the spill files
+func (s *SpillStore) Dir() string {
+	return s.dir
+}
+
+// GetStats returns the current spill metrics
+func (s *SpillStore) GetStats() SpillStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats
+}
+
+// shouldSpill reports whether a payload of size bytes belongs on disk. A nil
+// store never spills.
+func (s *SpillStore) shouldSpill(size int64) bool {
+	return s != nil && size >= s.threshold
+}
+
+// create opens a spill file for size bytes, or fails with errSpillBudget when
+// the budget has no room for them
+func (s *SpillStore) create(size int64) (*SpooledFile, error) {
+	s.mu.Lock()
+	if s.budget > 0 && s.stats.ActiveBytes+size > s.budget {
+		s.stats.OverBudget++
+		s.mu.Unlock()
+		return nil, errSpillBudget
+	}
+	s.stats.ActiveFiles++
+	s.stats.ActiveBytes += size
+	s.stats.Spilled++
+	s.mu.Unlock()
+
+	release := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.stats.ActiveFiles--
+		s.stats.ActiveBytes -= size
+	}
+
+	file, err := os.CreateTemp(s.dir, spillPrefix+"*")
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("create spill file: %w", err)
+	}
+	return &SpooledFile{File: file, release: release}, nil
+}
+
+// grow accounts for a spill file outgrowing its reservation
+func (s *SpillStore) grow(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.ActiveBytes += n
+}
+
+// decodeInput decodes a base64 payload into a pooled buffer, or straight into
+// a spill file when it's large, so the decoded copy never sits in memory.
+// The returned release func must be called once the input is no longer read;
+// it is never nil.
+func decodeInput(ctx context.Context, bufferPool *pool.BufferPool, spill *SpillStore, data string) (mediaInput, func(), error) {
+	normalized, encoding := providers.NormalizeBase64(data)
+	if size := int64(encoding.DecodedLen(len(normalized))); spill.shouldSpill(size) {
+		input, release, err := spill.decode(ctx, normalized, encoding, size)
+		if err == nil {
+			return input, release, nil
+		}
+		var corrupt base64.CorruptInputError
+		if errors.As(err, &corrupt) {
+			return mediaInput{}, func() {}, err
+		}
+		if !errors.Is(err, errSpillBudget) {
+			log.Printf("Spilling a %d byte input failed, decoding it in memory: %v", size, err)
+		}
+	}
+
+	var input mediaInput
+	var release func()
+	var err error
+	input.data, release, err = decodeBase64(ctx, bufferPool, normalized)
+	return input, release, err
+}
+
+// decode writes the decoded payload into a spill file
+func (s *SpillStore) decode(ctx context.Context, data string, encoding *base64.Encoding, size int64) (mediaInput, func(), error) {
+	defer timeStage(ctx, StageDecode)()
+
+	file, err := s.create(size)
+	if err != nil {
+		return mediaInput{}, nil, err
+	}
+
+	n, err := io.Copy(file, base64.NewDecoder(encoding, strings.NewReader(data)))
+	if err != nil {
+		file.Close()
+		return mediaInput{}, nil, err
+	}
+
+	return mediaInput{path: file.Name(), n: n}, func() { file.Close() }, nil
+}
+
+// downloadInput downloads url into memory, moving to a spill file once the
+// download reaches the spill threshold. The returned release func must be
+// called once the input is no longer read; it is never nil.
+func downloadInput(ctx context.Context, downloader *Downloader, spill *SpillStore, url string) (mediaInput, func(), error) {
+	if spill == nil {
+		data, err := downloader.Download(ctx, url)
+		return mediaInput{data: data}, func() {}, err
+	}
+
+	w := &spillWriter{store: spill}
+	n, err := downloader.DownloadTo(ctx, url, w)
+	if err != nil {
+		w.close()
+		return mediaInput{}, func() {}, err
+	}
+
+	if w.file == nil {
+		return mediaInput{data: w.buffer.Bytes()}, func() {}, nil
+	}
+	return mediaInput{path: w.file.Name(), n: n}, w.close, nil
+}
+
+// spillWriter buffers writes in memory until they reach the store's
+// threshold, then moves them to a spill file. The budget is checked once,
+// when the switch happens; a store without room keeps buffering.
+type spillWriter struct {
+	store  *SpillStore
+	buffer bytes.Buffer
+	file   *SpooledFile
+	grown  int64 // Bytes written after the switch, on top of the reservation
+	full   bool  // The budget had no room, stay in memory
+}
+
+func (w *spillWriter) Write(p []byte) (int, error) {
+	if w.file != nil {
+		n, err := w.file.Write(p)
+		w.grown += int64(n)
+		w.store.grow(int64(n))
+		return n, err
+	}
+
+	size := int64(w.buffer.Len() + len(p))
+	if w.full || !w.store.shouldSpill(size) {
+		return w.buffer.Write(p)
+	}
+
+	file, err := w.store.create(size)
+	if errors.Is(err, errSpillBudget) {
+		w.full = true
+		return w.buffer.Write(p)
+	}
+	if err != nil {
+		return 0, err
+	}
+	w.file = file
+
+	if _, err := file.Write(w.buffer.Bytes()); err != nil {
+		return 0, fmt.Errorf("write spill file: %w", err)
+	}
+	w.buffer = bytes.Buffer{}
+	return file.Write(p)
+}
+
+// close deletes the spill file, if any
+func (w *spillWriter) close() {
+	if w.file != nil {
+		w.store.grow(-w.grown)
+		w.grown = 0
+		w.file.Close()
+	}
+}
+
+// spillFile copies the file at path into a spill file when it's large enough,
+// so it can outlive the directory it's in. It returns nil when the file
+// should be read into memory instead.
+func (s *SpillStore) spillFile(path string, size int64) (*SpooledFile, error) {
+	if !s.shouldSpill(size) {
+		return nil, nil
+	}
+
+	file, err := s.create(size)
+	if errors.Is(err, errSpillBudget) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := copyFile(path, file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("write spill file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("write spill file: %w", err)
+	}
+	return file, nil
+}
+
+// TempJanitor deletes temporary files this service left behind: spill files,
+// spooled uploads and scratch directories whose request never cleaned up
+// after itself (a crash, a killed process). Only entries named by this
+// service and older than maxAge are touched, so other processes sharing the
+// directories are safe.
+type TempJanitor struct {
+	dirs   []string
+	maxAge time.Duration
+	stop   chan struct{}
+
+	mu    sync.Mutex
+	swept int64
+}
+
+// NewTempJanitor sweeps dirs now and then every interval
+func NewTempJanitor(dirs []string, maxAge, interval time.Duration) (*TempJanitor, error) {
+	if maxAge <= 0 || interval <= 0 {
+		return nil, fmt.Errorf("temp janitor max age and interval must be positive")
+	}
+
+	janitor := &TempJanitor{
+		dirs:   uniqueDirs(dirs),
+		maxAge: maxAge,
+		stop:   make(chan struct{}),
+	}
+	janitor.sweep()
+	go janitor.cleanupLoop(interval)
+
+	return janitor, nil
+}
+
+// Swept returns how many leftovers the janitor deleted
+func (j *TempJanitor) Swept() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.swept
+}
+
+// Close stops the sweep loop
+func (j *TempJanitor) Close() {
+	close(j.stop)
+}
+
+func (j *TempJanitor) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.sweep()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+func (j *TempJanitor) sweep() {
+	cutoff := time.Now().Add(-j.maxAge)
+
+	for _, dir := range j.dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			log.Printf("Temp janitor: reading %s failed: %v", dir, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			if !isTempName(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || !info.ModTime().Before(cutoff) {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				log.Printf("Temp janitor: removing %s failed: %v", entry.Name(), err)
+				continue
+			}
+
+			j.mu.Lock()
+			j.swept++
+			j.mu.Unlock()
+			log.Printf("Temp janitor: removed leftover %s (modified %s)", filepath.Join(dir, entry.Name()), info.ModTime().Format(time.RFC3339))
+		}
+	}
+}
+
+// isTempName reports whether name is one of this service's temporary files
+func isTempName(name string) bool {
+	for _, prefix := range []string{spillPrefix, spoolPrefix, scratchPrefix} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueDirs resolves empty entries to os.TempDir() and drops duplicates
+func uniqueDirs(dirs []string) []string {
+	seen := make(map[string]bool, len(dirs))
+	var unique []string
+	for _, dir := range dirs {
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			unique = append(unique, dir)
+		}
+	}
+	return unique
+}
+
+// SetSpillStore moves large inputs to temporary files
+func (ac *AudioConverter) SetSpillStore(store *SpillStore) {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	ac.spill = store
+}
+
+func (ac *AudioConverter) spillStore() *SpillStore {
+	ac.mu.RLock()
+	defer ac.mu.RUnlock()
+
+	return ac.spill
+}
+
+// SetSpillStore moves large inputs and raw outputs to temporary files
+func (vc *VideoConverter) SetSpillStore(store *SpillStore) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.spill = store
+}
+
+func (vc *VideoConverter) spillStore() *SpillStore {
+	vc.mu.RLock()
+	defer vc.mu.RUnlock()
+
+	return vc.spill
+}
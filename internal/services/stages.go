@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"whats-convert-api/internal/pool"
+)
+
+// Conversion stages timed for slow-request logging
+const (
+	StageDownload = "download" // Fetching URL or S3 inputs
+	StageDecode   = "decode"   // Decoding base64 inputs
+	StageQueue    = "queue"    // Waiting for a worker slot
+	StageProbe    = "probe"    // ffprobe runs (duration, dimensions, compliance)
+	StageEncode   = "encode"   // ffmpeg, vips and optimizer runs
+	StageUpload   = "upload"   // Storing outputs in S3
+)
+
+// stageOrder is the order stages appear in summaries
+var stageOrder = []string{StageDownload, StageDecode, StageQueue, StageProbe, StageEncode, StageUpload}
+
+// Timings reports the time a request spent per stage, so integrators can
+// tell their network from our processing (debug_timings only)
+type Timings struct {
+	DownloadMS int64 `json:"download_ms" example:"120"` // Fetching URL or S3 inputs
+	DecodeMS   int64 `json:"decode_ms" example:"3"`     // Decoding base64 inputs
+	QueueMS    int64 `json:"queue_ms" example:"0"`      // Waiting for a worker slot
+	ProbeMS    int64 `json:"probe_ms" example:"15"`     // Inspecting inputs and outputs
+	EncodeMS   int64 `json:"encode_ms" example:"240"`   // Converting
+	UploadMS   int64 `json:"upload_ms" example:"0"`     // Storing outputs in S3
+	TotalMS    int64 `json:"total_ms" example:"380"`    // Whole conversion, including the untimed steps
+}
+
+// StageTimings accumulates the time a request spends in each conversion stage.
+// It is safe for concurrent use; batch items add up into the same stages.
+type StageTimings struct {
+	mu     sync.Mutex
+	stages map[string]time.Duration
+}
+
+// NewStageTimings creates empty stage timings
+func NewStageTimings() *StageTimings {
+	return &StageTimings{stages: make(map[string]time.Duration)}
+}
+
+// Durations returns a copy of the time recorded per stage
+func (t *StageTimings) Durations() map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	durations := make(map[string]time.Duration, len(t.stages))
+	for stage, d := range t.stages {
+		durations[stage] = d
+	}
+	return durations
+}
+
+// Summary renders the stages as key=value pairs for a request that took total:
+// every stage's time, the bottleneck stage, and a flame line ranking the
+// stages by their share of total. Batch items run concurrently, so shares can
+// add up to more than 100%. It returns "" when no stage was recorded.
+func (t *StageTimings) Summary(total time.Duration) string {
+	durations := t.Durations()
+	if len(durations) == 0 {
+		return ""
+	}
+
+	ranked := make([]string, 0, len(durations))
+	fields := make([]string, 0, len(stageOrder)+2)
+	for _, stage := range stageOrder {
+		fields = append(fields, fmt.Sprintf("%s=%s", stage, durations[stage].Round(time.Millisecond)))
+		if durations[stage] > 0 {
+			ranked = append(ranked, stage)
+		}
+	}
+	if len(ranked) == 0 {
+		return ""
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return durations[ranked[i]] > durations[ranked[j]] })
+
+	// Stages under 1% are noise next to the bottleneck
+	flame := make([]string, 0, len(ranked))
+	for i, stage := range ranked {
+		share := int(100 * durations[stage] / max(total, 1))
+		if i > 0 && share < 1 {
+			break
+		}
+		flame = append(flame, fmt.Sprintf("%s %d%%", stage, share))
+	}
+
+	fields = append(fields, "bottleneck="+ranked[0], fmt.Sprintf("flame=%q", strings.Join(flame, " | ")))
+	return strings.Join(fields, " ")
+}
+
+// Report converts the stages into milliseconds for a request that took total
+func (t *StageTimings) Report(total time.Duration) *Timings {
+	durations := t.Durations()
+	return &Timings{
+		DownloadMS: durations[StageDownload].Milliseconds(),
+		DecodeMS:   durations[StageDecode].Milliseconds(),
+		QueueMS:    durations[StageQueue].Milliseconds(),
+		ProbeMS:    durations[StageProbe].Milliseconds(),
+		EncodeMS:   durations[StageEncode].Milliseconds(),
+		UploadMS:   durations[StageUpload].Milliseconds(),
+		TotalMS:    total.Milliseconds(),
+	}
+}
+
+// ServerTiming renders the stages as a Server-Timing header value
+// (durations in milliseconds), skipping stages that didn't run
+func (t *StageTimings) ServerTiming(total time.Duration) string {
+	durations := t.Durations()
+
+	metrics := make([]string, 0, len(stageOrder)+1)
+	for _, stage := range stageOrder {
+		if d, ok := durations[stage]; ok {
+			metrics = append(metrics, fmt.Sprintf("%s;dur=%.1f", stage, float64(d.Microseconds())/1000))
+		}
+	}
+	metrics = append(metrics, fmt.Sprintf("total;dur=%.1f", float64(total.Microseconds())/1000))
+
+	return strings.Join(metrics, ", ")
+}
+
+func (t *StageTimings) add(stage string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stages[stage] += d
+}
+
+type stageTimingsKey struct{}
+
+// WithStageTimings returns a context whose conversion stages are timed into timings
+func WithStageTimings(ctx context.Context, timings *StageTimings) context.Context {
+	return context.WithValue(ctx, stageTimingsKey{}, timings)
+}
+
+// StageTimingsFrom returns the timings attached to ctx, or nil
+func StageTimingsFrom(ctx context.Context) *StageTimings {
+	timings, _ := ctx.Value(stageTimingsKey{}).(*StageTimings)
+	return timings
+}
+
+// timeStage starts timing stage for the request in ctx; call the returned
+// func when the stage ends. It does nothing without attached timings.
+func timeStage(ctx context.Context, stage string) func() {
+	timings := StageTimingsFrom(ctx)
+	if timings == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() { timings.add(stage, time.Since(start)) }
+}
+
+// acquireWorker waits for an encoder slot at the priority of the caller's
+// tier, timing the wait as the queue stage
+func acquireWorker(ctx context.Context, workerPool *pool.WorkerPool, size int) (func(), error) {
+	defer timeStage(ctx, StageQueue)()
+
+	return workerPool.AcquireFor(ctx, size, workerClaim(ctx))
+}
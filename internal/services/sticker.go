@@ -0,0 +1,494 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"whats-convert-api/internal/tracing"
+)
+
+// WhatsApp sticker pack constraints
+const (
+	MinStickerPackSize = 3  // Fewest stickers WhatsApp accepts in a pack
+	MaxStickerPackSize = 30 // Most stickers WhatsApp accepts in a pack
+
+	stickerSize         = 512        // Stickers are exactly 512×512
+	trayIconSize        = 96         // Tray icons are exactly 96×96
+	maxStickerBytes     = 100 * 1024 // Static sticker file size limit
+	maxTrayIconBytes    = 50 * 1024  // Tray icon file size limit
+	maxStickerEmojis    = 3          // Emojis WhatsApp indexes per sticker
+	maxStickerPackField = 128        // Longest identifier, name or publisher
+)
+
+// stickerQualitySteps are the WebP qualities tried until a sticker fits maxStickerBytes
+var stickerQualitySteps = []int{90, 75, 60, 45, 30}
+
+var (
+	// ErrInvalidSticker is returned when a sticker's pack metadata breaks WhatsApp's limits
+	ErrInvalidSticker = errors.New("invalid sticker metadata")
+
+	// ErrInvalidStickerPack is returned when a pack request breaks WhatsApp's pack rules
+	ErrInvalidStickerPack = errors.New("invalid sticker pack")
+
+	// ErrStickerTooLarge is returned when a sticker can't be compressed under the size limit
+	ErrStickerTooLarge = errors.New("sticker exceeds the size limit")
+)
+
+// StickerRequest represents a single sticker conversion request. Setting
+// any pack field embeds WhatsApp sticker pack metadata in the WebP's EXIF.
+type StickerRequest struct {
+	Data      string   `json:"data" example:"data:image/png;base64,iVBORw0KGgo"` // base64 or URL
+	IsURL     bool     `json:"is_url" example:"false"`                           // true if data is URL
+	DataURI   *bool    `json:"data_uri,omitempty" example:"true"`                // Optional: false returns plain base64 (default true)
+	PackID    string   `json:"pack_id,omitempty" example:"coffee_break"`         // Optional: EXIF pack identifier (default derived from pack_name)
+	PackName  string   `json:"pack_name,omitempty" example:"Coffee Break"`       // Optional: EXIF pack name shown in WhatsApp
+	Publisher string   `json:"publisher,omitempty" example:"Guilherme Jansen"`   // Optional: EXIF pack publisher shown in WhatsApp
+	Emojis    []string `json:"emojis,omitempty" example:"☕,🙂"`                   // Optional: up to 3 emojis stored in the EXIF
+	Preset    string   `json:"preset,omitempty" example:"sticker"`               // Optional: PRESETS_FILE sticker preset filling the fields left unset
+
+	RawOutput bool   `json:"-"` // Set by the HTTP layer: return bytes in Output instead of encoding Data
+	Input     []byte `json:"-"` // Set by the HTTP layer: raw input bytes, used instead of Data
+}
+
+// StickerResponse represents the sticker conversion response
+type StickerResponse struct {
+	Data     string `json:"data,omitempty" example:"data:image/webp;base64,UklGRiQA"` // base64 WebP (data URI unless data_uri is false)
+	MimeType string `json:"mime_type" example:"image/webp"`                           // MIME type of the decoded data
+	Width    int    `json:"width" example:"512"`                                      // Sticker width
+	Height   int    `json:"height" example:"512"`                                     // Sticker height
+	Size     int    `json:"size" example:"48210"`                                     // Size in bytes
+	Metadata bool   `json:"metadata" example:"true"`                                  // Sticker pack EXIF metadata was embedded
+	Cached   bool   `json:"cached,omitempty" example:"false"`                         // Output was served from the conversion cache
+
+	Trace   []CommandRecord `json:"trace,omitempty"`   // External commands executed (debug trace only)
+	Timings *Timings        `json:"timings,omitempty"` // Time spent per stage (debug_timings only)
+
+	Output []byte `json:"-"` // Converted bytes when the request set RawOutput
+}
+
+// StickerInput is one image of a sticker pack request
+type StickerInput struct {
+	Data   string   `json:"data" example:"data:image/png;base64,iVBORw0KGgo"` // base64 or URL
+	IsURL  bool     `json:"is_url" example:"false"`                           // true if data is URL
+	Emojis []string `json:"emojis" example:"☕,🙂"`                             // 1 to 3 emojis describing the sticker
+}
+
+// StickerPackRequest represents a sticker pack conversion request
+type StickerPackRequest struct {
+	Identifier       string         `json:"identifier,omitempty" example:"coffee_break"`               // Optional: pack identifier (default derived from name)
+	Name             string         `json:"name" example:"Coffee Break"`                               // Pack name shown in WhatsApp
+	Publisher        string         `json:"publisher" example:"Guilherme Jansen"`                      // Pack publisher shown in WhatsApp
+	PublisherEmail   string         `json:"publisher_email,omitempty" example:"stickers@example.com"`  // Optional: copied to the manifest
+	PublisherWebsite string         `json:"publisher_website,omitempty" example:"https://example.com"` // Optional: copied to the manifest
+	TrayIndex        int            `json:"tray_index" example:"0"`                                    // Optional: sticker whose image becomes the tray icon (default 0)
+	Stickers         []StickerInput `json:"stickers"`                                                  // 3 to 30 images
+	DataURI          *bool          `json:"data_uri,omitempty" example:"true"`                         // Optional: false returns plain base64 (default true)
+
+	RawOutput bool `json:"-"` // Set by the HTTP layer: return bytes in Output instead of encoding Data
+}
+
+// StickerFile is a converted file of a sticker pack
+type StickerFile struct {
+	FileName string `json:"file_name" example:"01.webp"`                              // Name the manifest refers to
+	Data     string `json:"data,omitempty" example:"data:image/webp;base64,UklGRiQA"` // base64 file (data URI unless data_uri is false)
+	MimeType string `json:"mime_type" example:"image/webp"`                           // image/webp for stickers, image/png for the tray icon
+	Size     int    `json:"size" example:"48210"`                                     // Size in bytes
+
+	Output []byte `json:"-"` // Converted bytes when the request set RawOutput
+}
+
+// StickerPackManifest is the contents.json read by WhatsApp sticker pack apps
+type StickerPackManifest struct {
+	AndroidPlayStoreLink string             `json:"android_play_store_link"`
+	IOSAppStoreLink      string             `json:"ios_app_store_link"`
+	StickerPacks         []StickerPackEntry `json:"sticker_packs"`
+}
+
+// StickerPackEntry describes one pack of a manifest
+type StickerPackEntry struct {
+	Identifier              string          `json:"identifier" example:"coffee_break"`
+	Name                    string          `json:"name" example:"Coffee Break"`
+	Publisher               string          `json:"publisher" example:"Guilherme Jansen"`
+	TrayImageFile           string          `json:"tray_image_file" example:"tray.png"`
+	ImageDataVersion        string          `json:"image_data_version" example:"1"`
+	AvoidCache              bool            `json:"avoid_cache" example:"false"`
+	PublisherEmail          string          `json:"publisher_email"`
+	PublisherWebsite        string          `json:"publisher_website"`
+	PrivacyPolicyWebsite    string          `json:"privacy_policy_website"`
+	LicenseAgreementWebsite string          `json:"license_agreement_website"`
+	AnimatedStickerPack     bool            `json:"animated_sticker_pack" example:"false"`
+	Stickers                []StickerRecord `json:"stickers"`
+}
+
+// StickerRecord is one sticker of a manifest pack
+type StickerRecord struct {
+	ImageFile string   `json:"image_file" example:"01.webp"`
+	Emojis    []string `json:"emojis" example:"☕,🙂"`
+}
+
+// StickerPackResponse represents the sticker pack conversion response
+type StickerPackResponse struct {
+	Manifest  StickerPackManifest `json:"manifest"`   // contents.json for sticker pack apps
+	TrayImage StickerFile         `json:"tray_image"` // 96×96 PNG tray icon
+	Stickers  []StickerFile       `json:"stickers"`   // 512×512 WebP stickers in request order
+
+	Trace   []CommandRecord `json:"trace,omitempty"`   // External commands executed (debug trace only)
+	Timings *Timings        `json:"timings,omitempty"` // Time spent per stage (debug_timings only)
+}
+
+// ConvertSticker converts an image to a 512×512 WhatsApp sticker, optionally
+// tagged with sticker pack metadata
+func (ic *ImageConverter) ConvertSticker(ctx context.Context, req *StickerRequest) (resp *StickerResponse, err error) {
+	ctx, span := tracing.Start(ctx, "convert.sticker", attribute.Bool("media.is_url", req.IsURL))
+	defer func() { tracing.End(span, err) }()
+
+	if err := ic.applyPreset(PresetTypeSticker, &req.Preset, req); err != nil {
+		return nil, err
+	}
+
+	metadata, err := stickerMetadataFor(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := ic.injectFault(); err != nil {
+		return nil, err
+	}
+
+	input, release, err := ic.stickerInput(ctx, req.Input, req.Data, req.IsURL)
+	if err != nil {
+		ic.recordFailure()
+		return nil, err
+	}
+	defer release()
+
+	// Answer repeated conversions of the same image from cache
+	cache := ic.conversionCache(ctx)
+	cacheKey := cache.key("sticker", stickerCacheParams(req), mediaInput{data: input})
+	var cached StickerResponse
+	if output, ok := cache.get(ctx, cacheKey, &cached); ok {
+		cached.Cached = true
+		cached.setOutput(output, req)
+		return &cached, nil
+	}
+
+	output, err := ic.convertSticker(ctx, input, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &StickerResponse{
+		MimeType: AlphaFormatWebP.MimeType(),
+		Width:    stickerSize,
+		Height:   stickerSize,
+		Size:     len(output),
+		Metadata: metadata != nil,
+	}
+	cache.put(ctx, cacheKey, response, output)
+	response.setOutput(output, req)
+
+	return response, nil
+}
+
+// stickerMetadataFor returns the EXIF metadata the request asks for, or nil
+func stickerMetadataFor(req *StickerRequest) (*stickerMetadata, error) {
+	metadata := &stickerMetadata{
+		PackID:    strings.TrimSpace(req.PackID),
+		PackName:  strings.TrimSpace(req.PackName),
+		Publisher: strings.TrimSpace(req.Publisher),
+		Emojis:    req.Emojis,
+	}
+	if metadata.PackID == "" && metadata.PackName == "" && metadata.Publisher == "" && len(metadata.Emojis) == 0 {
+		return nil, nil
+	}
+
+	switch {
+	case len(metadata.Emojis) > maxStickerEmojis:
+		return nil, fmt.Errorf("%w: %d emojis, the limit is %d", ErrInvalidSticker, len(metadata.Emojis), maxStickerEmojis)
+	case utf8.RuneCountInString(metadata.PackName) > maxStickerPackField || utf8.RuneCountInString(metadata.Publisher) > maxStickerPackField:
+		return nil, fmt.Errorf("%w: pack_name and publisher are limited to %d characters", ErrInvalidSticker, maxStickerPackField)
+	case len(metadata.PackID) > maxStickerPackField:
+		return nil, fmt.Errorf("%w: pack_id is limited to %d characters", ErrInvalidSticker, maxStickerPackField)
+	}
+
+	if metadata.PackID == "" {
+		metadata.PackID = stickerPackIdentifier(metadata.PackName)
+	}
+
+	return metadata, nil
+}
+
+// ConvertStickerPack converts 3 to 30 images into 512×512 WebP stickers plus
+// a 96×96 PNG tray icon and describes them in a sticker app manifest
+func (ic *ImageConverter) ConvertStickerPack(ctx context.Context, req *StickerPackRequest) (resp *StickerPackResponse, err error) {
+	ctx, span := tracing.Start(ctx, "convert.sticker_pack", attribute.Int("sticker.count", len(req.Stickers)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateStickerPack(req); err != nil {
+		return nil, err
+	}
+	if err := ic.injectFault(); err != nil {
+		return nil, err
+	}
+
+	stickers := make([]StickerFile, len(req.Stickers))
+	var tray StickerFile
+	errs := make([]error, len(req.Stickers)+1)
+	var wg sync.WaitGroup
+
+	for i := range req.Stickers {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+
+			sticker := &req.Stickers[index]
+			input, release, err := ic.stickerInput(ctx, nil, sticker.Data, sticker.IsURL)
+			if err != nil {
+				errs[index] = err
+				return
+			}
+			defer release()
+
+			output, err := ic.convertSticker(ctx, input, nil)
+			if err != nil {
+				errs[index] = err
+				return
+			}
+			stickers[index] = newStickerFile(fmt.Sprintf("%02d.webp", index+1), AlphaFormatWebP.MimeType(), output, req)
+
+			if index == req.TrayIndex {
+				output, err := ic.convertTrayIcon(ctx, input)
+				if err != nil {
+					errs[len(req.Stickers)] = err
+					return
+				}
+				tray = newStickerFile("tray.png", AlphaFormatPNG.MimeType(), output, req)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if i == len(req.Stickers) {
+			return nil, fmt.Errorf("tray icon failed: %w", err)
+		}
+		return nil, fmt.Errorf("sticker %d failed: %w", i, err)
+	}
+
+	records := make([]StickerRecord, len(stickers))
+	for i, sticker := range stickers {
+		records[i] = StickerRecord{ImageFile: sticker.FileName, Emojis: req.Stickers[i].Emojis}
+	}
+
+	identifier := req.Identifier
+	if identifier == "" {
+		identifier = stickerPackIdentifier(req.Name)
+	}
+
+	return &StickerPackResponse{
+		Manifest: StickerPackManifest{
+			StickerPacks: []StickerPackEntry{{
+				Identifier:       identifier,
+				Name:             req.Name,
+				Publisher:        req.Publisher,
+				TrayImageFile:    tray.FileName,
+				ImageDataVersion: "1",
+				PublisherEmail:   req.PublisherEmail,
+				PublisherWebsite: req.PublisherWebsite,
+				Stickers:         records,
+			}},
+		},
+		TrayImage: tray,
+		Stickers:  stickers,
+	}, nil
+}
+
+// validateStickerPack enforces the pack rules WhatsApp checks on import
+func validateStickerPack(req *StickerPackRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Publisher = strings.TrimSpace(req.Publisher)
+	req.Identifier = strings.TrimSpace(req.Identifier)
+
+	switch {
+	case len(req.Stickers) < MinStickerPackSize || len(req.Stickers) > MaxStickerPackSize:
+		return fmt.Errorf("%w: %d stickers, a pack needs %d to %d",
+			ErrInvalidStickerPack, len(req.Stickers), MinStickerPackSize, MaxStickerPackSize)
+	case req.Name == "" || req.Publisher == "":
+		return fmt.Errorf("%w: name and publisher are required", ErrInvalidStickerPack)
+	case utf8.RuneCountInString(req.Name) > maxStickerPackField || utf8.RuneCountInString(req.Publisher) > maxStickerPackField:
+		return fmt.Errorf("%w: name and publisher are limited to %d characters", ErrInvalidStickerPack, maxStickerPackField)
+	case req.TrayIndex < 0 || req.TrayIndex >= len(req.Stickers):
+		return fmt.Errorf("%w: tray_index %d is out of range", ErrInvalidStickerPack, req.TrayIndex)
+	}
+
+	if req.Identifier != "" {
+		if len(req.Identifier) > maxStickerPackField || strings.TrimFunc(req.Identifier, isIdentifierRune) != "" {
+			return fmt.Errorf("%w: identifier may only contain letters, digits, '_', '-', '.' and spaces (max %d)",
+				ErrInvalidStickerPack, maxStickerPackField)
+		}
+	}
+
+	for i, sticker := range req.Stickers {
+		if strings.TrimSpace(sticker.Data) == "" {
+			return fmt.Errorf("%w: sticker %d has no data", ErrInvalidStickerPack, i)
+		}
+		if len(sticker.Emojis) == 0 || len(sticker.Emojis) > maxStickerEmojis {
+			return fmt.Errorf("%w: sticker %d has %d emojis, it needs 1 to %d",
+				ErrInvalidStickerPack, i, len(sticker.Emojis), maxStickerEmojis)
+		}
+	}
+
+	return nil
+}
+
+// isIdentifierRune reports whether r is allowed in a pack identifier
+func isIdentifierRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '_' || r == '-' || r == '.' || r == ' '
+}
+
+// stickerPackIdentifier derives an identifier from the pack name, e.g.
+// "Coffee Break!" becomes "coffee_break"
+func stickerPackIdentifier(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteByte('_')
+		}
+	}
+
+	identifier := strings.TrimSuffix(b.String(), "_")
+	if len(identifier) > maxStickerPackField {
+		identifier = identifier[:maxStickerPackField]
+	}
+	if identifier == "" {
+		identifier = fmt.Sprintf("pack_%d", time.Now().Unix())
+	}
+	return identifier
+}
+
+// stickerInput returns the raw upload, or decodes or downloads data, and
+// applies the pixel limit
+func (ic *ImageConverter) stickerInput(ctx context.Context, raw []byte, data string, isURL bool) ([]byte, func(), error) {
+	input := raw
+	release := func() {}
+	var err error
+
+	switch {
+	case raw != nil:
+		// Uploaded by the HTTP layer
+	case isURL:
+		input, err = ic.downloader.Download(ctx, data)
+		if err != nil {
+			return nil, release, fmt.Errorf("download failed: %w", err)
+		}
+	default:
+		input, release, err = decodeBase64(ctx, ic.bufferPool, data)
+		if err != nil {
+			return nil, release, fmt.Errorf("base64 decode failed: %w", err)
+		}
+	}
+
+	if len(input) == 0 {
+		release()
+		return nil, func() {}, fmt.Errorf("empty input data")
+	}
+	if err := checkInputKind(sniffInput(ctx, mediaInput{data: input}), "a sticker", MediaKindAudio); err != nil {
+		release()
+		return nil, func() {}, err
+	}
+	if err := ic.checkPixelLimit(ctx, input); err != nil {
+		release()
+		return nil, func() {}, err
+	}
+
+	return input, release, nil
+}
+
+// convertSticker fits the image into a transparent 512×512 canvas and encodes
+// WebP with the optional metadata, lowering the quality until the file fits
+// maxStickerBytes
+func (ic *ImageConverter) convertSticker(ctx context.Context, input []byte, metadata *stickerMetadata) ([]byte, error) {
+	releaseSlot, err := acquireWorker(ctx, ic.workerPool, len(input))
+	if err != nil {
+		ic.recordFailure()
+		return nil, fmt.Errorf("waiting for a worker: %w", err)
+	}
+	defer releaseSlot()
+
+	start := time.Now()
+	var size int
+	for _, quality := range stickerQualitySteps {
+		output, err := ic.convertAlphaWithFFmpeg(ctx, input, padFilter(stickerSize), quality, AlphaFormatWebP)
+		if err != nil {
+			ic.recordFailure()
+			return nil, fmt.Errorf("conversion failed: %w", err)
+		}
+		if metadata != nil {
+			if output, err = setWebPEXIF(output, metadata.exif()); err != nil {
+				ic.recordFailure()
+				return nil, err
+			}
+		}
+		if len(output) <= maxStickerBytes {
+			ic.recordFFmpegSuccess(time.Since(start))
+			return output, nil
+		}
+		size = len(output)
+	}
+
+	ic.recordFailure()
+	return nil, fmt.Errorf("%w: %d bytes at quality %d, limit is %d",
+		ErrStickerTooLarge, size, stickerQualitySteps[len(stickerQualitySteps)-1], maxStickerBytes)
+}
+
+// convertTrayIcon renders the 96×96 PNG tray icon
+func (ic *ImageConverter) convertTrayIcon(ctx context.Context, input []byte) ([]byte, error) {
+	releaseSlot, err := acquireWorker(ctx, ic.workerPool, len(input))
+	if err != nil {
+		return nil, fmt.Errorf("waiting for a worker: %w", err)
+	}
+	defer releaseSlot()
+
+	output, err := ic.convertAlphaWithFFmpeg(ctx, input, padFilter(trayIconSize), 0, AlphaFormatPNG)
+	if err != nil {
+		return nil, fmt.Errorf("conversion failed: %w", err)
+	}
+	if len(output) > maxTrayIconBytes {
+		return nil, fmt.Errorf("%w: tray icon is %d bytes, limit is %d", ErrStickerTooLarge, len(output), maxTrayIconBytes)
+	}
+
+	return output, nil
+}
+
+// padFilter scales the image to fit a size×size square, enlarging small
+// ones, and centres it on a transparent canvas
+func padFilter(size int) string {
+	return fmt.Sprintf(
+		"scale=%d:%d:force_original_aspect_ratio=decrease:flags=lanczos,format=rgba,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=black@0",
+		size, size, size, size,
+	)
+}
+
+// newStickerFile stores the converted bytes like setOutput does for single conversions
+func newStickerFile(name, mimeType string, output []byte, req *StickerPackRequest) StickerFile {
+	file := StickerFile{FileName: name, MimeType: mimeType, Size: len(output)}
+	if req.RawOutput {
+		file.Output = output
+	} else {
+		file.Data = encodeOutput(output, mimeType, wantsDataURI(req.DataURI))
+	}
+	return file
+}
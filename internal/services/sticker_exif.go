@@ -0,0 +1,126 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// stickerEXIFTag is the private TIFF tag WhatsApp reads sticker pack
+// metadata from ("AW" in little-endian)
+const stickerEXIFTag = 0x5741
+
+// stickerMetadata is the JSON WhatsApp stores in a sticker's EXIF. It shows
+// the pack name and publisher when the sticker is viewed and indexes the emojis.
+type stickerMetadata struct {
+	PackID    string   `json:"sticker-pack-id"`
+	PackName  string   `json:"sticker-pack-name"`
+	Publisher string   `json:"sticker-pack-publisher"`
+	Emojis    []string `json:"emojis,omitempty"`
+}
+
+// exif encodes the metadata as a little-endian TIFF block with a single
+// undefined-type entry holding the JSON
+func (m *stickerMetadata) exif() []byte {
+	payload, _ := json.Marshal(m)
+
+	var b bytes.Buffer
+	b.WriteString("II*\x00")                                      // Little-endian TIFF header
+	binary.Write(&b, binary.LittleEndian, uint32(8))              // Offset of the first IFD
+	binary.Write(&b, binary.LittleEndian, uint16(1))              // One entry
+	binary.Write(&b, binary.LittleEndian, uint16(stickerEXIFTag)) // Tag
+	binary.Write(&b, binary.LittleEndian, uint16(7))              // Type UNDEFINED
+	binary.Write(&b, binary.LittleEndian, uint32(len(payload)))   // Count
+	binary.Write(&b, binary.LittleEndian, uint32(8+2+12+4))       // Value offset, after the IFD
+	binary.Write(&b, binary.LittleEndian, uint32(0))              // No next IFD
+	b.Write(payload)
+
+	return b.Bytes()
+}
+
+// setWebPEXIF stores exif in the WebP's EXIF chunk, converting simple
+// (VP8/VP8L-only) files to the extended VP8X layout that can carry it and
+// replacing any EXIF already present
+func setWebPEXIF(webp, exif []byte) ([]byte, error) {
+	return setWebPChunk(webp, "EXIF", exif, 0x08)
+}
+
+// setWebPChunk stores data in a fourCC chunk after the image data, replacing
+// any chunk of that type, and sets flags (the VP8X bits announcing it) in a
+// VP8X header, converting simple files to the extended layout as needed
+func setWebPChunk(webp []byte, fourCC string, data []byte, flags byte) ([]byte, error) {
+	if len(webp) < 20 || string(webp[0:4]) != "RIFF" || string(webp[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("not a WebP file")
+	}
+
+	type chunk struct {
+		fourCC string
+		data   []byte
+	}
+	var chunks []chunk
+	for pos := 12; pos+8 <= len(webp); {
+		size := int(binary.LittleEndian.Uint32(webp[pos+4 : pos+8]))
+		if size < 0 || pos+8+size > len(webp) {
+			return nil, fmt.Errorf("truncated WebP chunk at offset %d", pos)
+		}
+		chunks = append(chunks, chunk{string(webp[pos : pos+4]), webp[pos+8 : pos+8+size]})
+		pos += 8 + size + size&1
+	}
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("WebP file has no chunks")
+	}
+
+	var header []byte
+	switch chunks[0].fourCC {
+	case "VP8X":
+		header = bytes.Clone(chunks[0].data)
+		chunks = chunks[1:]
+	case "VP8 ", "VP8L":
+		width, height, ok := webpDimensions(webp)
+		if !ok {
+			return nil, fmt.Errorf("unreadable WebP dimensions")
+		}
+		header = make([]byte, 10)
+		if webpHasAlpha(webp) {
+			header[0] |= 0x10
+		}
+		putUint24(header[4:7], width-1)
+		putUint24(header[7:10], height-1)
+	default:
+		return nil, fmt.Errorf("unexpected WebP chunk %q", chunks[0].fourCC)
+	}
+	header[0] |= flags
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	writeRIFFChunk(&body, "VP8X", header)
+	for _, c := range chunks {
+		if c.fourCC != fourCC {
+			writeRIFFChunk(&body, c.fourCC, c.data)
+		}
+	}
+	// Metadata follows the image data
+	writeRIFFChunk(&body, fourCC, data)
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// writeRIFFChunk writes a chunk header, its data and the pad byte odd sizes need
+func writeRIFFChunk(b *bytes.Buffer, fourCC string, data []byte) {
+	b.WriteString(fourCC)
+	binary.Write(b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	if len(data)&1 == 1 {
+		b.WriteByte(0)
+	}
+}
+
+func putUint24(b []byte, v int) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
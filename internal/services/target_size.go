@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrTargetSizeUnreachable is returned when an output can't be brought under
+// the requested max_file_size_kb or target_size_mb
+var ErrTargetSizeUnreachable = errors.New("output cannot fit the requested size")
+
+const (
+	// defaultAudioBitrate is the Opus and MP3 bitrate in kbit/s, also the
+	// ceiling of target_size_mb
+	defaultAudioBitrate = 128
+
+	// minOpusBitrate and minMP3Bitrate (kbit/s) are the lowest bitrates
+	// target_size_mb goes to: libopus' floor, and MP3's at 44.1kHz
+	minOpusBitrate = 6
+	minMP3Bitrate  = 32
+
+	// containerOverhead is the share of a size budget left for container
+	// framing and rate control overshoot
+	containerOverhead = 0.05
+)
+
+// targetSizeBytes converts a target_size_mb value (MiB, like WhatsApp's 16MB
+// cap) into bytes; zero or negative disables the target
+func targetSizeBytes(targetMB float64) int64 {
+	if targetMB <= 0 {
+		return 0
+	}
+	return int64(targetMB * 1024 * 1024)
+}
+
+// bitrateForSize spreads a size budget over seconds, in kbit/s
+func bitrateForSize(targetBytes int64, seconds float64) int {
+	return int(math.Floor(float64(targetBytes) * 8 / 1000 / seconds * (1 - containerOverhead)))
+}
+
+// targetAudioBitrate picks the highest bitrate up to defaultAudioBitrate whose
+// output of input fits in targetBytes. WAV has no bitrate to pick and inputs
+// of unknown duration keep the default, so both return 0 and are checked
+// once encoded.
+func targetAudioBitrate(ctx context.Context, input mediaInput, format AudioFormat, targetBytes int64) (int, error) {
+	if format == AudioFormatWAV {
+		return 0, nil
+	}
+
+	duration, err := probeDuration(ctx, input)
+	if err != nil || duration <= 0 {
+		return 0, nil
+	}
+
+	minBitrate := minOpusBitrate
+	if format == AudioFormatMP3 {
+		minBitrate = minMP3Bitrate
+	}
+
+	bitrate := min(bitrateForSize(targetBytes, duration.Seconds()), defaultAudioBitrate)
+	if bitrate < minBitrate {
+		return 0, fmt.Errorf("%w: %.0fs would get %dkbit/s (minimum %d for %s) within %d bytes",
+			ErrTargetSizeUnreachable, duration.Seconds(), bitrate, minBitrate, format, targetBytes)
+	}
+
+	if format == AudioFormatMP3 {
+		bitrate = mp3Bitrate(bitrate)
+	}
+
+	return bitrate, nil
+}
+
+// mp3Bitrates are the MPEG-1 Layer III CBR bitrates up to the default; LAME
+// rounds anything else to the nearest one, which may be above the budget
+var mp3Bitrates = []int{128, 112, 96, 80, 64, 56, 48, 40, 32}
+
+// mp3Bitrate rounds bitrate down to a valid MP3 bitrate
+func mp3Bitrate(bitrate int) int {
+	for _, valid := range mp3Bitrates {
+		if valid <= bitrate {
+			return valid
+		}
+	}
+	return minMP3Bitrate
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"runtime"
+	"strconv"
+	"sync/atomic"
+)
+
+// encoderThreads is the per-process thread count passed to ffmpeg and vips.
+// Zero leaves the choice to the tool, which grabs every core.
+var encoderThreads atomic.Int64
+
+// ConfigureEncoderThreads sets how many threads each ffmpeg/vips process may use.
+// A non-positive threads value derives the budget from the worker count so that
+// concurrent conversions share the CPUs instead of each claiming all of them.
+// It returns the resolved value.
+func ConfigureEncoderThreads(threads, workers int) int {
+	if threads <= 0 {
+		threads = deriveEncoderThreads(runtime.NumCPU(), workers)
+	}
+
+	encoderThreads.Store(int64(threads))
+	return threads
+}
+
+// EncoderThreads reports the per-process thread budget (0 = tool default)
+func EncoderThreads() int {
+	return int(encoderThreads.Load())
+}
+
+// deriveEncoderThreads splits cpus evenly across workers, rounding up
+func deriveEncoderThreads(cpus, workers int) int {
+	if workers <= 0 {
+		return cpus
+	}
+
+	threads := (cpus + workers - 1) / workers
+	if threads < 1 {
+		threads = 1
+	}
+	return threads
+}
+
+// ffmpegThreadsArg returns the value for ffmpeg's -threads option
+func ffmpegThreadsArg() string {
+	return strconv.Itoa(EncoderThreads())
+}
+
+// vipsConcurrencyArgs returns the vips thread-pool option, if a budget is set
+func vipsConcurrencyArgs() []string {
+	if threads := EncoderThreads(); threads > 0 {
+		return []string{"--vips-concurrency=" + strconv.Itoa(threads)}
+	}
+	return nil
+}
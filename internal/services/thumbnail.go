@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+)
+
+// WhatsApp renders jpegThumbnail as the blurred preview of media messages
+const (
+	thumbnailSize     = 72
+	maxThumbnailBytes = 20 * 1024
+)
+
+// thumbnailQualitySteps are tried in order until the preview fits maxThumbnailBytes
+var thumbnailQualitySteps = []int{70, 50, 30}
+
+// thumbnail renders source as a JPEG preview of at most thumbnailSize pixels
+// per side, encoded as plain base64 like WhatsApp's jpegThumbnail field.
+// The caller holds a worker slot.
+func (ic *ImageConverter) thumbnail(ctx context.Context, source []byte, background rgbColor) (string, error) {
+	var size int
+	for _, quality := range thumbnailQualitySteps {
+		output, err := ic.convertWithFFmpeg(ctx, source, fitFilter(thumbnailSize, thumbnailSize), quality, background)
+		if err != nil {
+			return "", fmt.Errorf("thumbnail failed: %w", err)
+		}
+		if len(output) <= maxThumbnailBytes {
+			return base64.StdEncoding.EncodeToString(output), nil
+		}
+		size = len(output)
+	}
+
+	return "", fmt.Errorf("thumbnail failed: %d bytes at quality %d, limit is %d",
+		size, thumbnailQualitySteps[len(thumbnailQualitySteps)-1], maxThumbnailBytes)
+}
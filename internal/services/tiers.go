@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidTiers is returned for a tiers configuration that can't be loaded
+var ErrInvalidTiers = errors.New("invalid tiers")
+
+// Built-in tiers; a tiers file may change their limits or add others
+const (
+	TierFree       = "free"
+	TierPro        = "pro"
+	TierEnterprise = "enterprise"
+)
+
+// maxRateBuckets is the number of callers whose rate limit state is kept
+// before idle ones are forgotten
+const maxRateBuckets = 10000
+
+// Tier is a service level API keys are assigned to
+type Tier struct {
+	Name         string  `json:"name" yaml:"-" example:"pro"`
+	Priority     int     `json:"priority" yaml:"priority" example:"10"`                  // Higher tiers get busy workers first
+	RateLimit    int     `json:"rate_limit" yaml:"rate_limit" example:"600"`             // Requests per minute per API key (0 = unlimited)
+	MaxFileSize  int64   `json:"max_file_size" yaml:"max_file_size" example:"104857600"` // Largest request body in bytes (0 = BODY_LIMIT)
+	MaxBatchJobs int     `json:"max_batch_jobs" yaml:"max_batch_jobs" example:"5"`       // Unfinished asynchronous batch jobs per API key (0 = BATCH_JOB_MAX_ACTIVE)
+	Weight       float64 `json:"weight,omitempty" yaml:"weight" example:"2"`             // Share of busy workers per API key against keys of the same priority (0 = 1)
+}
+
+// builtinTiers are the limits of the tiers a file doesn't change
+func builtinTiers() map[string]*Tier {
+	return map[string]*Tier{
+		TierFree:       {Name: TierFree, Priority: 0, RateLimit: 60, MaxFileSize: 16 << 20, MaxBatchJobs: 1},
+		TierPro:        {Name: TierPro, Priority: 10, RateLimit: 600, MaxFileSize: 100 << 20, MaxBatchJobs: 5},
+		TierEnterprise: {Name: TierEnterprise, Priority: 20},
+	}
+}
+
+// Tiers assigns API keys to tiers (TIERS_FILE, API_KEY_TIERS) and keeps the
+// per-key rate limit state. Keys without a tier, and requests without a key,
+// get the default tier. A nil Tiers is valid and imposes nothing.
+type Tiers struct {
+	tiers       map[string]*Tier
+	keys        map[string]*Tier
+	defaultTier *Tier
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+// tiersFile is the layout of TIERS_FILE
+type tiersFile struct {
+	Default string               `yaml:"default"`
+	Tiers   map[string]yaml.Node `yaml:"tiers"`
+	Keys    map[string]string    `yaml:"keys"`
+}
+
+// LoadTiers reads the tiers in file (YAML or JSON) and assigns keys, a map
+// of API key to tier name that adds to and overrides the file's. defaultTier
+// overrides the file's default (free when neither sets one). It returns nil
+// when file and keys are both empty: tiers are off.
+func LoadTiers(file string, keys map[string]string, defaultTier string) (*Tiers, error) {
+	if file == "" && len(keys) == 0 {
+		return nil, nil
+	}
+
+	var entries tiersFile
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTiers, err)
+		}
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidTiers, file, err)
+		}
+	}
+
+	t := &Tiers{
+		tiers:   builtinTiers(),
+		keys:    make(map[string]*Tier),
+		buckets: make(map[string]*rateBucket),
+	}
+
+	// Fields a file tier leaves out keep the built-in value, or zero (no
+	// limit) for new tiers
+	for name, node := range entries.Tiers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("%w: %s: tier with an empty name", ErrInvalidTiers, file)
+		}
+		tier := t.tiers[name]
+		if tier == nil {
+			tier = &Tier{Name: name}
+			t.tiers[name] = tier
+		}
+		if err := node.Decode(tier); err != nil {
+			return nil, fmt.Errorf("%w: %s: tier %q: %v", ErrInvalidTiers, file, name, err)
+		}
+		if tier.RateLimit < 0 || tier.MaxFileSize < 0 || tier.MaxBatchJobs < 0 || tier.Weight < 0 {
+			return nil, fmt.Errorf("%w: %s: tier %q: limits can't be negative", ErrInvalidTiers, file, name)
+		}
+	}
+
+	assign := func(source, key, name string) error {
+		tier := t.tiers[strings.ToLower(strings.TrimSpace(name))]
+		if tier == nil {
+			return fmt.Errorf("%w: %s: key assigned to unknown tier %q (%s)", ErrInvalidTiers, source, name, strings.Join(t.names(), ", "))
+		}
+		t.keys[key] = tier
+		return nil
+	}
+	for key, name := range entries.Keys {
+		if err := assign(file, key, name); err != nil {
+			return nil, err
+		}
+	}
+	for key, name := range keys {
+		if err := assign("API_KEY_TIERS", key, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if defaultTier == "" {
+		defaultTier = entries.Default
+	}
+	if defaultTier == "" {
+		defaultTier = TierFree
+	}
+	if t.defaultTier = t.tiers[strings.ToLower(strings.TrimSpace(defaultTier))]; t.defaultTier == nil {
+		return nil, fmt.Errorf("%w: unknown default tier %q (%s)", ErrInvalidTiers, defaultTier, strings.Join(t.names(), ", "))
+	}
+
+	return t, nil
+}
+
+// names returns the tier names, sorted
+func (t *Tiers) names() []string {
+	names := make([]string, 0, len(t.tiers))
+	for name := range t.tiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Tier returns the tier of apiKey
+func (t *Tiers) Tier(apiKey string) *Tier {
+	if t == nil {
+		return nil
+	}
+	if tier, ok := t.keys[apiKey]; ok {
+		return tier
+	}
+	return t.defaultTier
+}
+
+// List returns the tiers, highest priority first
+func (t *Tiers) List() []Tier {
+	if t == nil {
+		return nil
+	}
+
+	tiers := make([]Tier, 0, len(t.tiers))
+	for _, tier := range t.tiers {
+		tiers = append(tiers, *tier)
+	}
+	sort.Slice(tiers, func(i, j int) bool {
+		if tiers[i].Priority != tiers[j].Priority {
+			return tiers[i].Priority > tiers[j].Priority
+		}
+		return tiers[i].Name < tiers[j].Name
+	})
+	return tiers
+}
+
+// Summary describes the key assignments for the startup log
+func (t *Tiers) Summary() string {
+	if t == nil {
+		return "off"
+	}
+	return fmt.Sprintf("%s (default %s, %d keys assigned)", strings.Join(t.names(), ", "), t.defaultTier.Name, len(t.keys))
+}
+
+// RateDecision is the outcome of a rate limit check
+type RateDecision struct {
+	Allowed    bool
+	Limit      int           // Requests per minute (0 = unlimited)
+	Remaining  int           // Requests left right now
+	RetryAfter time.Duration // Until the next request is allowed, when refused
+}
+
+// rateBucket is a token bucket refilled at the tier's rate limit
+type rateBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Allow charges one request of caller (an API key, or the client address of
+// requests without one) to tier's rate limit
+func (t *Tiers) Allow(caller string, tier *Tier) RateDecision {
+	if t == nil || tier == nil || tier.RateLimit <= 0 {
+		return RateDecision{Allowed: true}
+	}
+
+	capacity := float64(tier.RateLimit)
+	perSecond := capacity / 60
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := tier.Name + "\x00" + caller
+	bucket, ok := t.buckets[key]
+	if !ok {
+		if len(t.buckets) >= maxRateBuckets {
+			t.pruneBuckets(now)
+		}
+		bucket = &rateBucket{tokens: capacity, updated: now}
+		t.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(capacity, bucket.tokens+now.Sub(bucket.updated).Seconds()*perSecond)
+	bucket.updated = now
+
+	decision := RateDecision{Limit: tier.RateLimit}
+	if bucket.tokens < 1 {
+		decision.RetryAfter = time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+		return decision
+	}
+	bucket.tokens--
+	decision.Allowed = true
+	decision.Remaining = int(bucket.tokens)
+	return decision
+}
+
+// pruneBuckets forgets callers idle for a minute, whose buckets have refilled
+func (t *Tiers) pruneBuckets(now time.Time) {
+	for key, bucket := range t.buckets {
+		if now.Sub(bucket.updated) >= time.Minute {
+			delete(t.buckets, key)
+		}
+	}
+}
+
+type tierKey struct{}
+
+// tierCaller is the tier a request runs under and the tenant it belongs to
+type tierCaller struct {
+	tier   *Tier
+	tenant string
+}
+
+// WithTier returns a context whose conversions run at tier's priority and
+// whose batch jobs count against tier's limit for apiKey
+func WithTier(ctx context.Context, tier *Tier, apiKey string) context.Context {
+	if tier == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tierKey{}, tierCaller{tier: tier, tenant: TenantID(apiKey)})
+}
+
+// TierFrom returns the tier attached to ctx, nil when there is none
+func TierFrom(ctx context.Context) *Tier {
+	caller, _ := ctx.Value(tierKey{}).(tierCaller)
+	return caller.tier
+}
+
+// tierTenant returns the tenant attached to ctx with its tier
+func tierTenant(ctx context.Context) string {
+	caller, _ := ctx.Value(tierKey{}).(tierCaller)
+	return caller.tenant
+}
+
+// tierPriority returns the worker priority of ctx's conversions
+func tierPriority(ctx context.Context) int {
+	if tier := TierFrom(ctx); tier != nil {
+		return tier.Priority
+	}
+	return 0
+}
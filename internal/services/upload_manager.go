@@ -2,15 +2,26 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"whats-convert-api/internal/pool"
 	"whats-convert-api/internal/providers"
 )
 
+var (
+	// ErrUploadCapacity is returned when S3_MAX_CONCURRENT_UPLOADS uploads are in flight
+	ErrUploadCapacity = errors.New("maximum concurrent uploads reached")
+
+	// ErrTenantUploadCapacity is returned when the caller's API key has its
+	// per-tenant share of upload slots in flight
+	ErrTenantUploadCapacity = errors.New("maximum concurrent uploads reached for this API key")
+)
+
 // UploadStatus represents the status of an upload
 type UploadStatus string
 
@@ -36,53 +47,74 @@ type UploadInfo struct {
 	Result           *providers.UploadResult `json:"result,omitempty"`
 	ContentType      string                  `json:"content_type"`
 	OriginalFilename string                  `json:"original_filename,omitempty"`
+	SourceURL        string                  `json:"source_url,omitempty"` // URL the file is downloaded from (POST /upload/s3/url)
 
 	// Internal fields
-	ctx          context.Context
-	cancel       context.CancelFunc
-	progressChan chan UploadProgress
-	resultChan   chan *UploadResult
-	mu           sync.RWMutex
+	tenant     string
+	released   bool // Upload slot returned; guarded by UploadManager.mu
+	ctx        context.Context
+	cancel     context.CancelFunc
+	listeners  map[chan UploadProgress]struct{} // Progress subscribers; guarded by mu
+	done       chan struct{}                    // Closed once the upload completes, fails or is cancelled
+	finishOnce sync.Once
+	mu         sync.RWMutex
 }
 
 // UploadProgress represents upload progress information
 type UploadProgress struct {
-	UploadID         string    `json:"upload_id"`
-	BytesTransferred int64     `json:"bytes_transferred"`
-	TotalBytes       int64     `json:"total_bytes"`
-	Progress         float64   `json:"progress"`
-	Timestamp        time.Time `json:"timestamp"`
+	UploadID         string       `json:"upload_id"`
+	Status           UploadStatus `json:"status"`
+	BytesTransferred int64        `json:"bytes_transferred"`
+	TotalBytes       int64        `json:"total_bytes"`
+	Progress         float64      `json:"progress"`
+	Error            string       `json:"error,omitempty"`
+	Timestamp        time.Time    `json:"timestamp"`
 }
 
-// UploadResult represents the final upload result
-type UploadResult struct {
-	UploadID string                  `json:"upload_id"`
-	Success  bool                    `json:"success"`
-	Result   *providers.UploadResult `json:"result,omitempty"`
-	Error    error                   `json:"error,omitempty"`
-}
+// defaultUploadStatusTTL is how long finished uploads are kept by default
+const defaultUploadStatusTTL = 24 * time.Hour
 
-// UploadManager manages concurrent uploads and tracks their progress
+// UploadManager manages concurrent uploads and tracks their progress. Uploads
+// run on their own worker pool so upload and conversion concurrency are tuned
+// and observed independently.
 type UploadManager struct {
 	s3Service      *S3Service
+	workers        *pool.WorkerPool
 	uploads        map[string]*UploadInfo
 	maxConcurrent  int
 	currentUploads int
+	tenantLimit    int            // Default per-tenant cap, 0 for none
+	tenantLimits   map[string]int // Per-key overrides of tenantLimit
+	tenantUploads  map[string]int // In-flight uploads per tenant
+	statusTTL      time.Duration  // How long finished uploads are kept
+	downloader     *Downloader    // Fetches URL uploads (nil = refused)
 	mu             sync.RWMutex
 	cleanupTicker  *time.Ticker
 	stopCleanup    chan bool
 }
 
-// NewUploadManager creates a new upload manager
-func NewUploadManager(s3Service *S3Service, maxConcurrent int) *UploadManager {
+// NewUploadManager creates a new upload manager. maxConcurrent bounds the
+// uploads accepted (queued or running); workers bounds those running at once
+// and defaults to maxConcurrent.
+func NewUploadManager(s3Service *S3Service, maxConcurrent, workers int) *UploadManager {
 	if maxConcurrent <= 0 {
 		maxConcurrent = 3 // Default
 	}
+	if workers <= 0 || workers > maxConcurrent {
+		workers = maxConcurrent
+	}
+
+	// Admission via acquire keeps at most maxConcurrent uploads queued
+	uploadPool := pool.NewWorkerPoolWithQueue(workers, maxConcurrent)
+	_ = uploadPool.Start() // Only fails when already started
 
 	manager := &UploadManager{
 		s3Service:     s3Service,
+		workers:       uploadPool,
 		uploads:       make(map[string]*UploadInfo),
 		maxConcurrent: maxConcurrent,
+		tenantUploads: make(map[string]int),
+		statusTTL:     defaultUploadStatusTTL,
 		stopCleanup:   make(chan bool),
 	}
 
@@ -92,20 +124,104 @@ func NewUploadManager(s3Service *S3Service, maxConcurrent int) *UploadManager {
 	return manager
 }
 
-// StartUpload initiates a new upload
-func (um *UploadManager) StartUpload(ctx context.Context, key string, reader io.Reader, size int64, opts providers.UploadOptions) (*UploadInfo, error) {
-	// Check if we're at capacity
+// SetStatusTTL sets how long finished uploads stay queryable before their
+// records are dropped. Sweeps run at least every quarter of ttl, so records
+// never outlive it by much.
+func (um *UploadManager) SetStatusTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultUploadStatusTTL
+	}
+
+	um.mu.Lock()
+	um.statusTTL = ttl
+	um.mu.Unlock()
+
+	um.cleanupTicker.Reset(uploadCleanupInterval(ttl))
+}
+
+// uploadCleanupInterval is the time between sweeps of records kept for ttl
+func uploadCleanupInterval(ttl time.Duration) time.Duration {
+	return max(min(ttl/4, time.Hour), time.Second)
+}
+
+// SetTenantLimits caps the uploads each API key may have in flight, on top of
+// the global cap: limit applies to every key (and to callers without one,
+// who share a single anonymous tenant) unless overrides names the key.
+// Limits of 0 or less mean no per-tenant cap.
+func (um *UploadManager) SetTenantLimits(limit int, overrides map[string]int) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	um.tenantLimit = limit
+	um.tenantLimits = overrides
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose uploads count against tenant's upload
+// slots and whose conversions wait for workers as tenant (FAIR_QUEUING);
+// tenant is the caller's API key
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+func tenantFrom(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// acquire takes a global upload slot and one of tenant's
+func (um *UploadManager) acquire(tenant string) error {
 	um.mu.Lock()
+	defer um.mu.Unlock()
+
 	if um.currentUploads >= um.maxConcurrent {
-		um.mu.Unlock()
-		return nil, fmt.Errorf("maximum concurrent uploads reached (%d)", um.maxConcurrent)
+		return fmt.Errorf("%w (%d)", ErrUploadCapacity, um.maxConcurrent)
 	}
+
+	limit := um.tenantLimit
+	if override, ok := um.tenantLimits[tenant]; ok {
+		limit = override
+	}
+	if limit > 0 && um.tenantUploads[tenant] >= limit {
+		return fmt.Errorf("%w (%d)", ErrTenantUploadCapacity, limit)
+	}
+
 	um.currentUploads++
-	um.mu.Unlock()
+	um.tenantUploads[tenant]++
+	return nil
+}
+
+// release returns uploadInfo's slots; later calls for the same upload are no-ops
+func (um *UploadManager) release(uploadInfo *UploadInfo) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	if uploadInfo.released {
+		return
+	}
+	uploadInfo.released = true
+
+	um.currentUploads--
+	if um.tenantUploads[uploadInfo.tenant]--; um.tenantUploads[uploadInfo.tenant] <= 0 {
+		delete(um.tenantUploads, uploadInfo.tenant)
+	}
+}
+
+// StartUpload initiates a new upload. It takes ownership of reader: an
+// io.Closer (e.g. a SpooledFile) is closed once the upload ends or is refused.
+func (um *UploadManager) StartUpload(ctx context.Context, key string, reader io.Reader, size int64, opts providers.UploadOptions) (*UploadInfo, error) {
+	// Check if we're at capacity
+	tenant := tenantFrom(ctx)
+	if err := um.acquire(tenant); err != nil {
+		closeReader(reader)
+		return nil, err
+	}
 
 	// Create upload info
 	uploadID := uuid.New().String()
-	uploadCtx, cancel := context.WithCancel(ctx)
+	// The upload outlives the request that started it, keeping its values
+	uploadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
 
 	uploadInfo := &UploadInfo{
 		ID:               uploadID,
@@ -116,10 +232,10 @@ func (um *UploadManager) StartUpload(ctx context.Context, key string, reader io.
 		TotalBytes:       size,
 		StartTime:        time.Now(),
 		ContentType:      opts.ContentType,
+		tenant:           tenant,
 		ctx:              uploadCtx,
 		cancel:           cancel,
-		progressChan:     make(chan UploadProgress, 10),
-		resultChan:       make(chan *UploadResult, 1),
+		done:             make(chan struct{}),
 	}
 
 	// Store upload info
@@ -127,26 +243,36 @@ func (um *UploadManager) StartUpload(ctx context.Context, key string, reader io.
 	um.uploads[uploadID] = uploadInfo
 	um.mu.Unlock()
 
-	// Start upload in goroutine
-	go um.performUpload(uploadInfo, reader, opts)
+	if err := um.enqueue(uploadInfo, func() error {
+		defer closeReader(reader)
+		return um.performUpload(uploadInfo, reader, opts)
+	}); err != nil {
+		closeReader(reader)
+		return nil, err
+	}
 
 	return uploadInfo, nil
 }
 
+// closeReader closes reader when it is an io.Closer
+func closeReader(reader io.Reader) {
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
 // StartBase64Upload initiates a new base64 upload
 func (um *UploadManager) StartBase64Upload(ctx context.Context, key string, base64Data string, opts providers.UploadOptions) (*UploadInfo, error) {
 	// Check if we're at capacity
-	um.mu.Lock()
-	if um.currentUploads >= um.maxConcurrent {
-		um.mu.Unlock()
-		return nil, fmt.Errorf("maximum concurrent uploads reached (%d)", um.maxConcurrent)
+	tenant := tenantFrom(ctx)
+	if err := um.acquire(tenant); err != nil {
+		return nil, err
 	}
-	um.currentUploads++
-	um.mu.Unlock()
 
 	// Create upload info
 	uploadID := uuid.New().String()
-	uploadCtx, cancel := context.WithCancel(ctx)
+	// The upload outlives the request that started it, keeping its values
+	uploadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
 
 	uploadInfo := &UploadInfo{
 		ID:               uploadID,
@@ -157,10 +283,10 @@ func (um *UploadManager) StartBase64Upload(ctx context.Context, key string, base
 		TotalBytes:       int64(len(base64Data)), // Approximate
 		StartTime:        time.Now(),
 		ContentType:      opts.ContentType,
+		tenant:           tenant,
 		ctx:              uploadCtx,
 		cancel:           cancel,
-		progressChan:     make(chan UploadProgress, 10),
-		resultChan:       make(chan *UploadResult, 1),
+		done:             make(chan struct{}),
 	}
 
 	// Store upload info
@@ -168,40 +294,179 @@ func (um *UploadManager) StartBase64Upload(ctx context.Context, key string, base
 	um.uploads[uploadID] = uploadInfo
 	um.mu.Unlock()
 
-	// Start upload in goroutine
-	go um.performBase64Upload(uploadInfo, base64Data, opts)
+	if err := um.enqueue(uploadInfo, func() error {
+		return um.performBase64Upload(uploadInfo, base64Data, opts)
+	}); err != nil {
+		return nil, err
+	}
 
 	return uploadInfo, nil
 }
 
+// enqueue hands an admitted upload to the upload workers. CancelUpload frees
+// a queued upload's slot before its task leaves the queue, so the queue can
+// briefly be full; the new upload is then refused as over capacity.
+func (um *UploadManager) enqueue(uploadInfo *UploadInfo, task pool.Task) error {
+	err := um.workers.TrySubmit(task)
+	if err == nil {
+		return nil
+	}
+
+	uploadInfo.cancel()
+	um.release(uploadInfo)
+	um.mu.Lock()
+	delete(um.uploads, uploadInfo.ID)
+	um.mu.Unlock()
+
+	if errors.Is(err, pool.ErrQueueFull) {
+		return fmt.Errorf("%w (%d queued)", ErrUploadCapacity, um.maxConcurrent)
+	}
+	return err
+}
+
 // GetUploadStatus returns the status of an upload
 func (um *UploadManager) GetUploadStatus(uploadID string) (*UploadInfo, error) {
 	um.mu.RLock()
-	defer um.mu.RUnlock()
+	uploadInfo, exists := um.uploads[uploadID]
+	um.mu.RUnlock()
 
+	if !exists {
+		return nil, fmt.Errorf("upload not found: %s", uploadID)
+	}
+
+	return uploadInfo.snapshot(), nil
+}
+
+// WaitForUpload blocks until the upload completes, fails or is cancelled, or
+// until ctx ends, and returns its status at that point. Callers tell the two
+// apart with Finished.
+func (um *UploadManager) WaitForUpload(ctx context.Context, uploadID string) (*UploadInfo, error) {
+	um.mu.RLock()
 	uploadInfo, exists := um.uploads[uploadID]
+	um.mu.RUnlock()
+
 	if !exists {
 		return nil, fmt.Errorf("upload not found: %s", uploadID)
 	}
 
-	// Return a copy to avoid race conditions
-	uploadInfo.mu.RLock()
-	defer uploadInfo.mu.RUnlock()
+	select {
+	case <-uploadInfo.done:
+	case <-ctx.Done():
+	}
+
+	return uploadInfo.snapshot(), nil
+}
+
+// Finished reports whether the upload reached a final status. Call it on the
+// copies GetUploadStatus and WaitForUpload return.
+func (ui *UploadInfo) Finished() bool {
+	switch ui.Status {
+	case UploadStatusCompleted, UploadStatusFailed, UploadStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// SubscribeProgress streams an upload's progress events. Events are dropped
+// while the subscriber's buffer is full, so readers only ever lag; done is
+// closed once the upload reaches a final status, after which GetUploadStatus
+// has the outcome. unsubscribe must be called when the caller stops reading.
+func (um *UploadManager) SubscribeProgress(uploadID string) (events <-chan UploadProgress, done <-chan struct{}, unsubscribe func(), err error) {
+	um.mu.RLock()
+	uploadInfo, exists := um.uploads[uploadID]
+	um.mu.RUnlock()
+
+	if !exists {
+		return nil, nil, nil, fmt.Errorf("upload not found: %s", uploadID)
+	}
+
+	listener := make(chan UploadProgress, 16)
+
+	uploadInfo.mu.Lock()
+	if uploadInfo.listeners == nil {
+		uploadInfo.listeners = make(map[chan UploadProgress]struct{})
+	}
+	uploadInfo.listeners[listener] = struct{}{}
+	uploadInfo.mu.Unlock()
+
+	unsubscribe = func() {
+		uploadInfo.mu.Lock()
+		delete(uploadInfo.listeners, listener)
+		uploadInfo.mu.Unlock()
+	}
+	return listener, uploadInfo.done, unsubscribe, nil
+}
+
+// ProgressEvent returns the upload's state as a progress event. Like
+// Finished, call it on the copies GetUploadStatus and WaitForUpload return.
+func (ui *UploadInfo) ProgressEvent() UploadProgress {
+	return UploadProgress{
+		UploadID:         ui.ID,
+		Status:           ui.Status,
+		BytesTransferred: ui.BytesTransferred,
+		TotalBytes:       ui.TotalBytes,
+		Progress:         ui.Progress,
+		Error:            ui.Error,
+		Timestamp:        time.Now(),
+	}
+}
+
+// publish sends the current progress to every subscriber without blocking
+func (ui *UploadInfo) publish() {
+	ui.mu.RLock()
+	defer ui.mu.RUnlock()
+
+	if len(ui.listeners) == 0 {
+		return
+	}
+
+	event := ui.ProgressEvent()
+	for listener := range ui.listeners {
+		select {
+		case listener <- event:
+		default:
+		}
+	}
+}
+
+// abandon marks an upload that never ran as cancelled and releases its waiters
+func (ui *UploadInfo) abandon() {
+	ui.mu.Lock()
+	if ui.Status == UploadStatusPending {
+		ui.Status = UploadStatusCancelled
+		now := time.Now()
+		ui.EndTime = &now
+	}
+	ui.mu.Unlock()
+
+	ui.finish()
+}
+
+// finish releases the upload's waiters; later calls are no-ops
+func (ui *UploadInfo) finish() {
+	ui.finishOnce.Do(func() { close(ui.done) })
+}
+
+// snapshot returns a copy of the upload's public fields
+func (ui *UploadInfo) snapshot() *UploadInfo {
+	ui.mu.RLock()
+	defer ui.mu.RUnlock()
 
 	return &UploadInfo{
-		ID:               uploadInfo.ID,
-		Key:              uploadInfo.Key,
-		Status:           uploadInfo.Status,
-		Progress:         uploadInfo.Progress,
-		BytesTransferred: uploadInfo.BytesTransferred,
-		TotalBytes:       uploadInfo.TotalBytes,
-		StartTime:        uploadInfo.StartTime,
-		EndTime:          uploadInfo.EndTime,
-		Error:            uploadInfo.Error,
-		Result:           uploadInfo.Result,
-		ContentType:      uploadInfo.ContentType,
-		OriginalFilename: uploadInfo.OriginalFilename,
-	}, nil
+		ID:               ui.ID,
+		Key:              ui.Key,
+		Status:           ui.Status,
+		Progress:         ui.Progress,
+		BytesTransferred: ui.BytesTransferred,
+		TotalBytes:       ui.TotalBytes,
+		StartTime:        ui.StartTime,
+		EndTime:          ui.EndTime,
+		Error:            ui.Error,
+		Result:           ui.Result,
+		ContentType:      ui.ContentType,
+		OriginalFilename: ui.OriginalFilename,
+		SourceURL:        ui.SourceURL,
+	}
 }
 
 // CancelUpload cancels an ongoing upload
@@ -226,10 +491,9 @@ func (um *UploadManager) CancelUpload(uploadID string) error {
 	now := time.Now()
 	uploadInfo.EndTime = &now
 
-	// Decrement current uploads counter
-	um.mu.Lock()
-	um.currentUploads--
-	um.mu.Unlock()
+	// Return the upload slots now rather than when the upload goroutine exits
+	um.release(uploadInfo)
+	uploadInfo.finish()
 
 	return nil
 }
@@ -283,6 +547,8 @@ func (um *UploadManager) ListUploads(status ...UploadStatus) []*UploadInfo {
 
 // GetStats returns upload manager statistics
 func (um *UploadManager) GetStats() map[string]interface{} {
+	workerStats := um.workers.Stats()
+
 	um.mu.RLock()
 	defer um.mu.RUnlock()
 
@@ -299,18 +565,27 @@ func (um *UploadManager) GetStats() map[string]interface{} {
 		"total_uploads":   totalUploads,
 		"current_uploads": um.currentUploads,
 		"max_concurrent":  um.maxConcurrent,
+		"tenant_limit":    um.tenantLimit,
+		"active_tenants":  len(um.tenantUploads),
+		"workers":         workerStats.MaxWorkers,
+		"active_workers":  int(workerStats.ActiveWorkers),
+		"queued_uploads":  workerStats.QueueSize,
+		"failed_uploads":  workerStats.FailedTasks,
+		"avg_upload_ms":   workerStats.AvgExecTimeMs,
 		"status_counts":   statusCounts,
 		"capacity_used":   float64(um.currentUploads) / float64(um.maxConcurrent) * 100,
 	}
 }
 
 // performUpload performs the actual upload
-func (um *UploadManager) performUpload(uploadInfo *UploadInfo, reader io.Reader, opts providers.UploadOptions) {
-	defer func() {
-		um.mu.Lock()
-		um.currentUploads--
-		um.mu.Unlock()
-	}()
+func (um *UploadManager) performUpload(uploadInfo *UploadInfo, reader io.Reader, opts providers.UploadOptions) error {
+	defer um.release(uploadInfo)
+
+	// Cancelled while queued
+	if err := uploadInfo.ctx.Err(); err != nil {
+		uploadInfo.abandon()
+		return err
+	}
 
 	// Update status to uploading
 	uploadInfo.mu.Lock()
@@ -342,17 +617,10 @@ func (um *UploadManager) performUpload(uploadInfo *UploadInfo, reader io.Reader,
 	}
 	uploadInfo.mu.Unlock()
 
-	// Send final result
-	select {
-	case uploadInfo.resultChan <- &UploadResult{
-		UploadID: uploadInfo.ID,
-		Success:  err == nil,
-		Result:   result,
-		Error:    err,
-	}:
-	default:
-		// Channel is full
-	}
+	// Wake WaitForUpload callers
+	uploadInfo.finish()
+
+	return err
 }
 
 func (um *UploadManager) wrapWithProgress(reader io.Reader, uploadInfo *UploadInfo, opts providers.UploadOptions) io.Reader {
@@ -374,16 +642,7 @@ func (um *UploadManager) wrapWithProgress(reader io.Reader, uploadInfo *UploadIn
 		uploadInfo.Progress = progress
 		uploadInfo.mu.Unlock()
 
-		select {
-		case uploadInfo.progressChan <- UploadProgress{
-			UploadID:         uploadInfo.ID,
-			BytesTransferred: bytesTransferred,
-			TotalBytes:       uploadInfo.TotalBytes,
-			Progress:         progress,
-			Timestamp:        time.Now(),
-		}:
-		default:
-		}
+		uploadInfo.publish()
 	}
 
 	if uploadInfo.TotalBytes > 0 {
@@ -460,12 +719,14 @@ func (prs *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
 }
 
 // performBase64Upload performs the actual base64 upload
-func (um *UploadManager) performBase64Upload(uploadInfo *UploadInfo, base64Data string, opts providers.UploadOptions) {
-	defer func() {
-		um.mu.Lock()
-		um.currentUploads--
-		um.mu.Unlock()
-	}()
+func (um *UploadManager) performBase64Upload(uploadInfo *UploadInfo, base64Data string, opts providers.UploadOptions) error {
+	defer um.release(uploadInfo)
+
+	// Cancelled while queued
+	if err := uploadInfo.ctx.Err(); err != nil {
+		uploadInfo.abandon()
+		return err
+	}
 
 	// Update status to uploading
 	uploadInfo.mu.Lock()
@@ -492,22 +753,15 @@ func (um *UploadManager) performBase64Upload(uploadInfo *UploadInfo, base64Data
 	}
 	uploadInfo.mu.Unlock()
 
-	// Send final result
-	select {
-	case uploadInfo.resultChan <- &UploadResult{
-		UploadID: uploadInfo.ID,
-		Success:  err == nil,
-		Result:   result,
-		Error:    err,
-	}:
-	default:
-		// Channel is full
-	}
+	// Wake WaitForUpload callers
+	uploadInfo.finish()
+
+	return err
 }
 
 // startCleanupRoutine starts a routine to clean up old completed uploads
 func (um *UploadManager) startCleanupRoutine() {
-	um.cleanupTicker = time.NewTicker(time.Hour) // Clean up every hour
+	um.cleanupTicker = time.NewTicker(uploadCleanupInterval(um.statusTTL))
 
 	go func() {
 		for {
@@ -522,12 +776,12 @@ func (um *UploadManager) startCleanupRoutine() {
 	}()
 }
 
-// cleanupOldUploads removes upload records older than 24 hours
+// cleanupOldUploads removes records of uploads that finished over statusTTL ago
 func (um *UploadManager) cleanupOldUploads() {
 	um.mu.Lock()
 	defer um.mu.Unlock()
 
-	cutoff := time.Now().Add(-24 * time.Hour)
+	cutoff := time.Now().Add(-um.statusTTL)
 	var toDelete []string
 
 	for id, uploadInfo := range um.uploads {
@@ -561,4 +815,14 @@ func (um *UploadManager) Stop() {
 		uploadInfo.mu.RUnlock()
 	}
 	um.mu.RUnlock()
+
+	// Wait for the upload workers to return
+	um.workers.Stop()
+
+	// Uploads still queued never ran; release their waiters and progress streams
+	um.mu.RLock()
+	for _, uploadInfo := range um.uploads {
+		uploadInfo.abandon()
+	}
+	um.mu.RUnlock()
 }
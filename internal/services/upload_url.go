@@ -0,0 +1,211 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"whats-convert-api/internal/providers"
+)
+
+// ErrInvalidUploadURL is returned for URL uploads that aren't absolute http(s) URLs
+var ErrInvalidUploadURL = errors.New("invalid upload URL")
+
+// SetDownloader lets StartURLUpload fetch remote files (nil = URL uploads refused)
+func (um *UploadManager) SetDownloader(downloader *Downloader) {
+	um.mu.Lock()
+	defer um.mu.Unlock()
+
+	um.downloader = downloader
+}
+
+// ParseUploadURL checks that rawURL can be downloaded: an absolute http or
+// https URL with a host
+func ParseUploadURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUploadURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("%w: scheme must be http or https", ErrInvalidUploadURL)
+	}
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("%w: missing host", ErrInvalidUploadURL)
+	}
+	return parsed, nil
+}
+
+// URLFilename is the last path segment of a URL, for naming its object
+func URLFilename(parsed *url.URL) string {
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// StartURLUpload downloads rawURL on an upload worker and streams it into
+// key as it arrives, so the file is never held whole in memory or on disk.
+// Without opts.ContentType the type is sniffed from the first bytes, falling
+// back to the URL's extension.
+func (um *UploadManager) StartURLUpload(ctx context.Context, key, rawURL string, opts providers.UploadOptions) (*UploadInfo, error) {
+	parsed, err := ParseUploadURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	um.mu.RLock()
+	downloader := um.downloader
+	um.mu.RUnlock()
+	if downloader == nil {
+		return nil, fmt.Errorf("URL uploads are not enabled")
+	}
+	if err := downloader.checkHost(ctx, parsed.Hostname()); err != nil {
+		return nil, err
+	}
+
+	// Check if we're at capacity
+	tenant := tenantFrom(ctx)
+	if err := um.acquire(tenant); err != nil {
+		return nil, err
+	}
+
+	// Create upload info
+	uploadID := uuid.New().String()
+	// The upload outlives the request that started it, keeping its values
+	uploadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	uploadInfo := &UploadInfo{
+		ID:          uploadID,
+		Key:         key,
+		Status:      UploadStatusPending,
+		StartTime:   time.Now(),
+		ContentType: opts.ContentType,
+		SourceURL:   parsed.Redacted(),
+		tenant:      tenant,
+		ctx:         uploadCtx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+	}
+
+	// Store upload info
+	um.mu.Lock()
+	um.uploads[uploadID] = uploadInfo
+	um.mu.Unlock()
+
+	if err := um.enqueue(uploadInfo, func() error {
+		return um.performURLUpload(uploadInfo, downloader, parsed, opts)
+	}); err != nil {
+		return nil, err
+	}
+
+	return uploadInfo, nil
+}
+
+// performURLUpload pipes the download into a streamed upload. Either side
+// failing stops the other; a failed download is reported as the cause.
+func (um *UploadManager) performURLUpload(uploadInfo *UploadInfo, downloader *Downloader, source *url.URL, opts providers.UploadOptions) error {
+	defer um.release(uploadInfo)
+
+	// Cancelled while queued
+	if err := uploadInfo.ctx.Err(); err != nil {
+		uploadInfo.abandon()
+		return err
+	}
+
+	// Update status to uploading
+	uploadInfo.mu.Lock()
+	uploadInfo.Status = UploadStatusUploading
+	uploadInfo.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(uploadInfo.ctx)
+	defer cancel()
+
+	reader, writer := io.Pipe()
+	downloaded := make(chan error, 1)
+	go func() {
+		_, err := downloader.DownloadTo(ctx, source.String(), writer)
+		if err != nil {
+			err = fmt.Errorf("download failed: %w", err)
+		}
+		downloaded <- err
+		writer.CloseWithError(err)
+	}()
+
+	result, err := um.uploadDownload(ctx, uploadInfo, reader, source, opts)
+	if err != nil {
+		// Stop the download, then prefer its error: a broken download is
+		// what made the upload fail
+		reader.CloseWithError(err)
+		cancel()
+		if downloadErr := <-downloaded; downloadErr != nil {
+			err = downloadErr
+		}
+	} else {
+		<-downloaded // nil: uploads only complete at EOF
+	}
+
+	// Update final status
+	uploadInfo.mu.Lock()
+	now := time.Now()
+	uploadInfo.EndTime = &now
+
+	if err != nil {
+		uploadInfo.Status = UploadStatusFailed
+		uploadInfo.Error = err.Error()
+	} else {
+		uploadInfo.Status = UploadStatusCompleted
+		uploadInfo.Result = result
+		uploadInfo.Progress = 100.0
+		uploadInfo.BytesTransferred = result.Size
+		uploadInfo.TotalBytes = result.Size
+	}
+	uploadInfo.mu.Unlock()
+
+	// Wake WaitForUpload callers
+	uploadInfo.finish()
+
+	return err
+}
+
+// uploadDownload reads the first bytes of the download to settle its content
+// type, then streams the rest into the bucket
+func (um *UploadManager) uploadDownload(ctx context.Context, uploadInfo *UploadInfo, reader io.Reader, source *url.URL, opts providers.UploadOptions) (*providers.UploadResult, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	if opts.ContentType == "" {
+		opts.ContentType = urlContentType(head, source)
+		uploadInfo.mu.Lock()
+		uploadInfo.ContentType = opts.ContentType
+		uploadInfo.mu.Unlock()
+	}
+
+	body := um.wrapWithProgress(io.MultiReader(bytes.NewReader(head), reader), uploadInfo, opts)
+	return um.s3Service.UploadStream(ctx, uploadInfo.Key, body, opts)
+}
+
+// urlContentType is the type sniffed from a download's first bytes, else the
+// one registered for its URL's extension, else application/octet-stream
+func urlContentType(head []byte, source *url.URL) string {
+	if media, ok := SniffBytes(head); ok {
+		return media.MIME
+	}
+	if byExtension := mime.TypeByExtension(path.Ext(source.Path)); byExtension != "" {
+		return byExtension
+	}
+	return "application/octet-stream"
+}
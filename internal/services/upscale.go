@@ -0,0 +1,155 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxUpscale bounds how far tiny inputs are enlarged: past 4× Lanczos
+// only produces bigger blur
+const defaultMaxUpscale = 4.0
+
+// upscalePlan is the size a too-small input is enlarged to
+type upscalePlan struct {
+	width, height int
+	factor        float64
+}
+
+// SetUpscaling bounds the enlargement of inputs smaller than a request's
+// min_width/min_height to maxFactor (values ≤ 1 fall back to 4) and sets an
+// optional external upscaler command, e.g.
+// "realesrgan-ncnn-vulkan -i {input} -o {output} -s {scale}". Without a
+// command, or when it fails, images are enlarged with Lanczos.
+func (ic *ImageConverter) SetUpscaling(maxFactor float64, command string) {
+	if maxFactor <= 1 {
+		maxFactor = defaultMaxUpscale
+	}
+
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	ic.maxUpscale = maxFactor
+	ic.upscaler = strings.Fields(command)
+}
+
+// planUpscale returns the size to enlarge the input to when it is smaller
+// than the request's min_width/min_height, or nil. The result keeps the aspect
+// ratio and stays within max_width/max_height and the upscale limit.
+func (ic *ImageConverter) planUpscale(ctx context.Context, req *ImageRequest, input []byte) *upscalePlan {
+	if req.MinWidth <= 0 && req.MinHeight <= 0 {
+		return nil
+	}
+
+	width, height, err := probeImageDimensions(ctx, input)
+	if err != nil || width <= 0 || height <= 0 {
+		return nil
+	}
+	if width >= req.MinWidth && height >= req.MinHeight {
+		return nil
+	}
+
+	ic.mu.RLock()
+	maxFactor := ic.maxUpscale
+	ic.mu.RUnlock()
+
+	factor := math.Max(float64(req.MinWidth)/float64(width), float64(req.MinHeight)/float64(height))
+	factor = math.Min(factor, maxFactor)
+	factor = math.Min(factor, float64(req.MaxWidth)/float64(width))
+	factor = math.Min(factor, float64(req.MaxHeight)/float64(height))
+
+	plan := &upscalePlan{
+		width:  int(math.Round(float64(width) * factor)),
+		height: int(math.Round(float64(height) * factor)),
+		factor: factor,
+	}
+	if plan.width <= width && plan.height <= height {
+		return nil
+	}
+
+	return plan
+}
+
+// upscale returns the source and scale filter the encoder should use to
+// produce the planned size: the upscaler's output fitted into the plan when
+// the plugin succeeds, otherwise the input with a Lanczos enlargement
+func (ic *ImageConverter) upscale(ctx context.Context, input []byte, plan *upscalePlan) ([]byte, string) {
+	if enlarged, err := ic.runUpscaler(ctx, input, plan); err == nil {
+		return enlarged, fitFilter(plan.width, plan.height)
+	}
+
+	return input, fmt.Sprintf("scale=%d:%d:flags=lanczos", plan.width, plan.height)
+}
+
+// runUpscaler runs the configured upscaler command on scratch files. The
+// {scale} placeholder gets the integer factor (at least 2) that reaches the plan.
+func (ic *ImageConverter) runUpscaler(ctx context.Context, input []byte, plan *upscalePlan) ([]byte, error) {
+	ic.mu.RLock()
+	command := ic.upscaler
+	ic.mu.RUnlock()
+
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no upscaler configured")
+	}
+
+	ctx, scratch, err := newScratchDir(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	defer scratch.remove()
+
+	// Upscalers pick their decoder from the file extension
+	inputPath, err := scratch.writeFile("input"+imageFileExt(input), input)
+	if err != nil {
+		return nil, err
+	}
+	outputPath := scratch.file("output.png")
+
+	replacer := strings.NewReplacer(
+		"{input}", inputPath,
+		"{output}", outputPath,
+		"{scale}", strconv.Itoa(max(2, int(math.Ceil(plan.factor)))),
+	)
+	args := make([]string, len(command)-1)
+	for i, arg := range command[1:] {
+		args[i] = replacer.Replace(arg)
+	}
+
+	if _, stderr, err := runCommand(ctx, nil, command[0], args...); err != nil {
+		return nil, fmt.Errorf("upscaler error: %v, stderr: %s", err, stderr)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("read upscaler output: %w", err)
+	}
+	if len(output) == 0 {
+		return nil, fmt.Errorf("upscaler produced no output")
+	}
+
+	return output, nil
+}
+
+// fitFilter scales into maxWidth×maxHeight with Lanczos, never enlarging
+func fitFilter(maxWidth, maxHeight int) string {
+	return fmt.Sprintf(
+		"scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease:flags=lanczos",
+		maxWidth, maxHeight,
+	)
+}
+
+// imageFileExt returns the file extension matching the image's format
+func imageFileExt(data []byte) string {
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		return "." + format
+	}
+	if _, _, ok := webpDimensions(data); ok {
+		return ".webp"
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// AnonymousTenant collects conversions sent without an API key
+	AnonymousTenant = "anonymous"
+
+	// OtherTenant collects conversions of keys beyond maxUsageTenants, so
+	// clients inventing keys can't grow the table without bound
+	OtherTenant = "other"
+
+	// maxUsageTenants is the number of API keys tracked individually
+	maxUsageTenants = 10000
+)
+
+// CPUMeter adds up the CPU time (user + system) of the external commands a
+// request runs. It is safe for concurrent use; batch items add up together.
+type CPUMeter struct {
+	nanos atomic.Int64
+}
+
+type cpuMeterKey struct{}
+
+// WithCPUMeter returns a context whose commands charge their CPU time to meter
+func WithCPUMeter(ctx context.Context, meter *CPUMeter) context.Context {
+	return context.WithValue(ctx, cpuMeterKey{}, meter)
+}
+
+// CPUMeterFrom returns the meter attached to ctx, nil when there is none
+func CPUMeterFrom(ctx context.Context) *CPUMeter {
+	meter, _ := ctx.Value(cpuMeterKey{}).(*CPUMeter)
+	return meter
+}
+
+func (m *CPUMeter) add(d time.Duration) {
+	m.nanos.Add(int64(d))
+}
+
+// Seconds returns the CPU time charged so far
+func (m *CPUMeter) Seconds() float64 {
+	return time.Duration(m.nanos.Load()).Seconds()
+}
+
+// TenantUsage is what one API key consumed since the process started
+type TenantUsage struct {
+	Tenant      string    `json:"tenant" example:"key_3f9a1c0b27de"`        // SHA-256 prefix of the API key, anonymous or other
+	Conversions int64     `json:"conversions" example:"1520"`               // Conversion requests, failed ones included (a batch or stream counts once)
+	CPUSeconds  float64   `json:"cpu_seconds" example:"412.37"`             // Estimated CPU-seconds of the FFmpeg/vips runs
+	LastSeen    time.Time `json:"last_seen" example:"2026-10-17T09:30:00Z"` // Last conversion
+}
+
+// UsageReport lists per-tenant usage, heaviest first
+type UsageReport struct {
+	Since   time.Time      `json:"since" example:"2026-10-17T08:00:00Z"` // Start of the counting period (process start)
+	Tenants []*TenantUsage `json:"tenants"`
+}
+
+// UsageTracker aggregates conversions and CPU time per API key, in memory
+type UsageTracker struct {
+	mu      sync.Mutex
+	since   time.Time
+	tenants map[string]*TenantUsage
+}
+
+// NewUsageTracker creates an empty tracker
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		since:   time.Now(),
+		tenants: make(map[string]*TenantUsage),
+	}
+}
+
+// TenantID identifies an API key in reports without revealing it
+func TenantID(apiKey string) string {
+	if apiKey == "" {
+		return AnonymousTenant
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key_" + hex.EncodeToString(sum[:6])
+}
+
+// Record charges one conversion and its CPU time to apiKey
+func (ut *UsageTracker) Record(apiKey string, cpuSeconds float64) {
+	tenant := TenantID(apiKey)
+
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	usage, ok := ut.tenants[tenant]
+	if !ok {
+		if len(ut.tenants) >= maxUsageTenants {
+			tenant = OtherTenant
+		}
+		if usage, ok = ut.tenants[tenant]; !ok {
+			usage = &TenantUsage{Tenant: tenant}
+			ut.tenants[tenant] = usage
+		}
+	}
+
+	usage.Conversions++
+	usage.CPUSeconds += cpuSeconds
+	usage.LastSeen = time.Now()
+}
+
+// Report returns the usage of every tenant, or only of apiKey when it isn't nil
+func (ut *UsageTracker) Report(apiKey *string) UsageReport {
+	ut.mu.Lock()
+	defer ut.mu.Unlock()
+
+	report := UsageReport{Since: ut.since, Tenants: []*TenantUsage{}}
+	for tenant, usage := range ut.tenants {
+		if apiKey != nil && tenant != TenantID(*apiKey) {
+			continue
+		}
+		copied := *usage
+		report.Tenants = append(report.Tenants, &copied)
+	}
+
+	sort.Slice(report.Tenants, func(i, j int) bool {
+		if report.Tenants[i].CPUSeconds != report.Tenants[j].CPUSeconds {
+			return report.Tenants[i].CPUSeconds > report.Tenants[j].CPUSeconds
+		}
+		return report.Tenants[i].Tenant < report.Tenants[j].Tenant
+	})
+
+	return report
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"whats-convert-api/internal/tracing"
+)
+
+const (
+	// composeFrameRate is the frame rate of still-image videos: the picture
+	// never changes, so a keyframe every few frames costs next to nothing
+	composeFrameRate = "2"
+
+	// composeMaxBitrate caps the video bitrate (kbit/s) of still-image
+	// videos; x264 needs a fraction of it once the first frame is sent
+	composeMaxBitrate = 500
+
+	// composeMinBitrate is the least a still picture is sent with; longer
+	// soundtracks are refused
+	composeMinBitrate = 50
+
+	// composePreset names still-image videos in VideoResponse.Preset
+	composePreset = "still"
+)
+
+// ComposeRequest pairs an audio track with a still image, such as a voice
+// note and its cover, to be encoded as one video
+type ComposeRequest struct {
+	Audio     []byte
+	Image     []byte
+	MaxWidth  int // Optional: max width, capped by VIDEO_MAX_WIDTH
+	MaxHeight int // Optional: max height, capped by VIDEO_MAX_HEIGHT
+}
+
+// Compose encodes a still image over an audio track as an MP4 WhatsApp plays
+// inline: H.264 baseline at a low frame rate and stereo AAC, as long as the
+// audio. The output is returned in Output.
+func (vc *VideoConverter) Compose(ctx context.Context, req *ComposeRequest) (resp *VideoResponse, err error) {
+	ctx, span := tracing.Start(ctx, "compose.video")
+	defer func() { tracing.End(span, err) }()
+
+	if err := vc.injectFault(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	audio, image := mediaInput{data: req.Audio}, mediaInput{data: req.Image}
+	if audio.size() == 0 || image.size() == 0 {
+		vc.recordFailure()
+		return nil, fmt.Errorf("empty input data")
+	}
+	if int64(audio.size()+image.size()) > vc.limits.MaxInputSize {
+		vc.recordFailure()
+		return nil, fmt.Errorf("inputs too large: %d bytes", audio.size()+image.size())
+	}
+
+	// Each input must be what it stands for: a video has its own picture
+	audioType, _ := SniffBytes(req.Audio)
+	if err := checkInputKind(audioType, "a video soundtrack", MediaKindImage, MediaKindVideo); err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+	imageType, _ := SniffBytes(req.Image)
+	if err := checkInputKind(imageType, "a video picture", MediaKindAudio, MediaKindVideo); err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+
+	maxWidth, maxHeight := vc.limits.MaxWidth, vc.limits.MaxHeight
+	if req.MaxWidth > 0 && req.MaxWidth < maxWidth {
+		maxWidth = req.MaxWidth
+	}
+	if req.MaxHeight > 0 && req.MaxHeight < maxHeight {
+		maxHeight = req.MaxHeight
+	}
+
+	releaseSlot, err := acquireWorker(ctx, vc.workerPool, audio.size()+image.size())
+	if err != nil {
+		vc.recordFailure()
+		return nil, fmt.Errorf("waiting for a worker: %w", err)
+	}
+	defer releaseSlot()
+
+	// Spread the output size budget over the soundtrack
+	bitrate := composeMaxBitrate
+	if duration, probeErr := probeDuration(ctx, audio); probeErr == nil && duration > 0 {
+		budget := bitrateForSize(vc.limits.MaxOutputSize, duration.Seconds()) - vc.limits.AudioBitrate
+		if budget < bitrate {
+			bitrate = budget
+		}
+		if bitrate < composeMinBitrate {
+			vc.recordFailure()
+			return nil, fmt.Errorf("%w: %s of audio would leave %dkbit/s for the picture within %d bytes",
+				ErrOutputSizeExceeded, duration.Round(time.Second), bitrate, vc.limits.MaxOutputSize)
+		}
+	}
+
+	ctx, scratch, err := newScratchDir(ctx, vc.limits.TempDir)
+	if err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+	defer scratch.remove()
+
+	audioPath, err := scratch.writeInput("audio", audio)
+	if err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+	imagePath, err := scratch.writeInput("image", image)
+	if err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+
+	outputPath := scratch.file("output.mp4")
+	_, stderr, err := runCommand(ctx, nil, "ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-y",
+		"-loop", "1", "-framerate", composeFrameRate, "-i", imagePath, // The picture, repeated
+		"-i", audioPath,
+		"-map", "0:v:0",
+		"-map", "1:a:0",
+		"-vf", fmt.Sprintf(
+			"scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease:force_divisible_by=2:flags=lanczos,format=yuv420p",
+			maxWidth, maxHeight),
+		"-c:v", "libx264",
+		"-profile:v", "baseline",
+		"-preset", "medium",
+		"-tune", "stillimage",
+		"-b:v", fmt.Sprintf("%dk", bitrate),
+		"-maxrate", fmt.Sprintf("%dk", bitrate),
+		"-bufsize", fmt.Sprintf("%dk", bitrate*2),
+		"-c:a", "aac",
+		"-b:a", fmt.Sprintf("%dk", vc.limits.AudioBitrate),
+		"-ac", "2",
+		"-ar", "44100",
+		"-shortest", // Stop with the audio; the picture loops forever
+		"-movflags", "+faststart",
+		"-threads", ffmpegThreadsArg(),
+		outputPath,
+	)
+	if err != nil {
+		vc.recordFailure()
+		return nil, fmt.Errorf("conversion failed: ffmpeg error: %v, stderr: %s", err, stderr)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		vc.recordFailure()
+		return nil, fmt.Errorf("read composed video: %w", err)
+	}
+	if len(output) == 0 {
+		vc.recordFailure()
+		return nil, fmt.Errorf("ffmpeg produced no output")
+	}
+	if int64(len(output)) > vc.limits.MaxOutputSize {
+		vc.recordFailure()
+		return nil, fmt.Errorf("%w: output is %d bytes, limit is %d",
+			ErrOutputSizeExceeded, len(output), vc.limits.MaxOutputSize)
+	}
+
+	info, _ := probeVideo(ctx, outputPath)
+	vc.recordSuccess(time.Since(start))
+
+	return &VideoResponse{
+		MimeType:     videoMimeType,
+		Width:        info.width,
+		Height:       info.height,
+		Duration:     int(info.duration),
+		Size:         len(output),
+		VideoBitrate: bitrate,
+		Preset:       composePreset,
+		Input:        &audioType,
+		Output:       output,
+	}, nil
+}
@@ -0,0 +1,680 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"whats-convert-api/internal/pool"
+	"whats-convert-api/internal/tracing"
+)
+
+var (
+	// ErrOutputSizeExceeded is returned when a video can't be encoded within the
+	// output size limit at a watchable bitrate
+	ErrOutputSizeExceeded = errors.New("video doesn't fit the output size limit")
+
+	// ErrAudioTrackNotFound is returned when audio_track names a track the input doesn't have
+	ErrAudioTrackNotFound = errors.New("audio track not found")
+)
+
+const (
+	// minVideoBitrate is the lowest video bitrate (kbit/s) worth sending; longer
+	// inputs are refused instead of being encoded into a smear
+	minVideoBitrate = 200
+
+	// maxVideoFrameRate caps high frame rate screen/phone recordings
+	maxVideoFrameRate = 30
+)
+
+// VideoLimits caps video conversions
+type VideoLimits struct {
+	MaxWidth      int   // Output bounding box width in pixels
+	MaxHeight     int   // Output bounding box height in pixels
+	MaxBitrate    int   // Video bitrate ceiling in kbit/s
+	AudioBitrate  int   // AAC bitrate in kbit/s
+	MaxOutputSize int64 // Largest output in bytes (WhatsApp sends up to 16MB inline)
+	MaxInputSize  int64 // Largest accepted input in bytes
+
+	ScreencastMaxWidth  int // Output bounding box width of the screencast preset
+	ScreencastMaxHeight int // Output bounding box height of the screencast preset
+
+	TempDir string // Parent of per-conversion scratch directories (default os.TempDir())
+}
+
+// DefaultVideoLimits returns limits matching WhatsApp's inline video constraints
+func DefaultVideoLimits() VideoLimits {
+	return VideoLimits{
+		MaxWidth:      1280,
+		MaxHeight:     1280,
+		MaxBitrate:    2000,
+		AudioBitrate:  128,
+		MaxOutputSize: 16 * 1024 * 1024,
+		MaxInputSize:  200 * 1024 * 1024,
+
+		ScreencastMaxWidth:  1920,
+		ScreencastMaxHeight: 1920,
+	}
+}
+
+// VideoConverter handles video conversion using FFmpeg
+type VideoConverter struct {
+	workerPool   *pool.WorkerPool
+	bufferPool   *pool.BufferPool
+	downloader   *Downloader
+	limits       VideoLimits
+	faultPercent int          // Chaos testing: percentage of conversions to fail
+	encoders     encoderSplit // Stable/candidate libx264 options
+	spill        *SpillStore  // Keeps large inputs and outputs out of memory (nil = disabled)
+	presets      *Presets     // Operator-defined presets (nil = built-in only)
+	objects      *S3Service   // Bucket read by requests with a source (nil = S3 disabled)
+	mu           sync.RWMutex
+	stats        VideoConverterStats
+}
+
+// VideoConverterStats tracks conversion metrics
+type VideoConverterStats struct {
+	TotalConversions  int64
+	FailedConversions int64
+	AvgConversionTime time.Duration
+
+	Variants map[string]VariantStats // Encodes per encoder variant (nil without a candidate)
+}
+
+// VideoRequest represents a video conversion request
+type VideoRequest struct {
+	Data       string       `json:"data" example:"data:video/quicktime;base64,AAAAFGZ0eXBxdCAgAAAAAHF0ICA"` // base64 or URL
+	IsURL      bool         `json:"is_url" example:"false"`                                                 // true if data is URL
+	Source     *InputSource `json:"source,omitempty"`                                                       // Optional: object in the configured bucket to convert instead of data
+	MaxWidth   int          `json:"max_width,omitempty" example:"1280"`                                     // Optional: max width, capped by VIDEO_MAX_WIDTH
+	MaxHeight  int          `json:"max_height,omitempty" example:"1280"`                                    // Optional: max height, capped by VIDEO_MAX_HEIGHT
+	DataURI    *bool        `json:"data_uri,omitempty" example:"true"`                                      // Optional: false returns plain base64 (default true)
+	Preset     string       `json:"preset,omitempty" example:"whatsapp"`                                    // Optional: whatsapp (default), screencast for screen recordings, or a PRESETS_FILE video preset
+	AudioTrack *int         `json:"audio_track,omitempty" example:"0"`                                      // Optional: audio track to keep, counted from 0 (default: the track flagged default, else the first)
+
+	TargetSizeMB float64 `json:"target_size_mb,omitempty" example:"16"` // Optional: two-pass encode sized to fit in this many MiB (capped by VIDEO_MAX_OUTPUT_SIZE)
+
+	RawOutput bool                  `json:"-"` // Set by the HTTP layer: return bytes in Output instead of encoding Data
+	Input     []byte                `json:"-"` // Set by the HTTP layer: raw input bytes, used instead of Data
+	Upload    *multipart.FileHeader `json:"-"` // Set by the HTTP layer: multipart upload copied to disk without buffering, used instead of Input
+	Sink      io.Writer             `json:"-"` // Set by convert-and-upload flows: receives the output instead of Data/Output
+}
+
+// OutputMimeType returns the MIME type of video outputs, always MP4
+func (req *VideoRequest) OutputMimeType() string {
+	return videoMimeType
+}
+
+// VideoResponse represents the conversion response
+type VideoResponse struct {
+	Data         string `json:"data,omitempty" example:"data:video/mp4;base64,AAAAIGZ0eXBpc29tAAACAGlzb20"` // base64 mp4 video (data URI unless data_uri is false)
+	MimeType     string `json:"mime_type" example:"video/mp4"`                                              // MIME type of the decoded data
+	Width        int    `json:"width" example:"1280"`                                                       // Video width
+	Height       int    `json:"height" example:"720"`                                                       // Video height
+	Duration     int    `json:"duration" example:"12"`                                                      // Duration in seconds
+	Size         int    `json:"size" example:"3145728"`                                                     // Size in bytes
+	VideoBitrate int    `json:"video_bitrate" example:"1850"`                                               // Target video bitrate in kbit/s
+	Preset       string `json:"preset" example:"whatsapp"`                                                  // Preset the video was encoded with
+
+	Input *MediaType `json:"input,omitempty"` // Format detected from the input's content
+
+	EncoderVariant string `json:"encoder_variant,omitempty" example:"stable"` // stable or candidate encoder options, set while VIDEO_CANDIDATE_PERCENT is above 0
+
+	Trace   []CommandRecord `json:"trace,omitempty"`   // External commands executed (debug trace only)
+	Timings *Timings        `json:"timings,omitempty"` // Time spent per stage (debug_timings only)
+
+	Output     []byte       `json:"-"` // Converted bytes when the request set RawOutput
+	OutputFile *SpooledFile `json:"-"` // Replaces Output when it reached the spill threshold; the caller must Close it
+}
+
+// NewVideoConverter creates a new video converter
+func NewVideoConverter(workerPool *pool.WorkerPool, bufferPool *pool.BufferPool, downloader *Downloader, limits VideoLimits) *VideoConverter {
+	defaults := DefaultVideoLimits()
+	if limits.MaxWidth <= 0 {
+		limits.MaxWidth = defaults.MaxWidth
+	}
+	if limits.MaxHeight <= 0 {
+		limits.MaxHeight = defaults.MaxHeight
+	}
+	if limits.MaxBitrate <= 0 {
+		limits.MaxBitrate = defaults.MaxBitrate
+	}
+	if limits.AudioBitrate <= 0 {
+		limits.AudioBitrate = defaults.AudioBitrate
+	}
+	if limits.MaxOutputSize <= 0 {
+		limits.MaxOutputSize = defaults.MaxOutputSize
+	}
+	if limits.MaxInputSize <= 0 {
+		limits.MaxInputSize = defaults.MaxInputSize
+	}
+	if limits.ScreencastMaxWidth <= 0 {
+		limits.ScreencastMaxWidth = defaults.ScreencastMaxWidth
+	}
+	if limits.ScreencastMaxHeight <= 0 {
+		limits.ScreencastMaxHeight = defaults.ScreencastMaxHeight
+	}
+
+	return &VideoConverter{
+		workerPool: workerPool,
+		bufferPool: bufferPool,
+		downloader: downloader,
+		limits:     limits,
+	}
+}
+
+// Convert transcodes a video to H.264 baseline + AAC in a faststart MP4
+func (vc *VideoConverter) Convert(ctx context.Context, req *VideoRequest) (resp *VideoResponse, err error) {
+	ctx, span := tracing.Start(ctx, "convert.video",
+		attribute.Bool("media.is_url", req.IsURL), attribute.String("video.preset", req.Preset))
+	defer func() { tracing.End(span, err) }()
+
+	if err := vc.injectFault(); err != nil {
+		return nil, err
+	}
+
+	if err := vc.applyPreset(req); err != nil {
+		return nil, err
+	}
+
+	if err := checkSource(req.Source, req.Data); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+
+	profile, err := vc.videoProfile(req)
+	if err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+
+	// Requests may shrink the bounding box but never exceed it
+	maxWidth, maxHeight := profile.maxWidth, profile.maxHeight
+	if req.MaxWidth > 0 && req.MaxWidth < maxWidth {
+		maxWidth = req.MaxWidth
+	}
+	if req.MaxHeight > 0 && req.MaxHeight < maxHeight {
+		maxHeight = req.MaxHeight
+	}
+
+	// Get input data
+	var input mediaInput
+
+	if req.Upload != nil {
+		input.file = req.Upload
+	} else if req.Input != nil {
+		input.data = req.Input
+	} else if req.Source != nil {
+		// Stream the object from the bucket, to a spill file once it's large
+		var release func()
+		input, release, err = objectInput(ctx, vc.objectStore(), vc.spillStore(), req.Source.S3Key, vc.limits.MaxInputSize)
+		if err != nil {
+			vc.recordFailure()
+			return nil, err
+		}
+		defer release()
+	} else if req.IsURL {
+		// Download from URL, to a spill file once it's large
+		var release func()
+		input, release, err = downloadInput(ctx, vc.downloader, vc.spillStore(), req.Data)
+		if err != nil {
+			vc.recordFailure()
+			return nil, fmt.Errorf("download failed: %w", err)
+		}
+		defer release()
+	} else {
+		// Decode base64 into a pooled buffer (or a spill file when large),
+		// returned once it's on disk
+		var release func()
+		input, release, err = decodeInput(ctx, vc.bufferPool, vc.spillStore(), req.Data)
+		if err != nil {
+			vc.recordFailure()
+			return nil, fmt.Errorf("base64 decode failed: %w", err)
+		}
+		defer release()
+	}
+
+	// Validate input size
+	if input.size() == 0 {
+		vc.recordFailure()
+		return nil, fmt.Errorf("empty input data")
+	}
+
+	if int64(input.size()) > vc.limits.MaxInputSize {
+		vc.recordFailure()
+		return nil, fmt.Errorf("video file too large: %d bytes", input.size())
+	}
+
+	// Wait for an encoder slot
+	releaseSlot, err := acquireWorker(ctx, vc.workerPool, input.size())
+	if err != nil {
+		vc.recordFailure()
+		return nil, fmt.Errorf("waiting for a worker: %w", err)
+	}
+	defer releaseSlot()
+
+	// An audio file has nothing to encode
+	media := sniffInput(ctx, input)
+	if err := checkInputKind(media, "video", MediaKindAudio); err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+
+	// MOV/MP4 inputs often keep their index at the end, which FFmpeg can't
+	// reach through a pipe, and faststart output needs a seekable file
+	ctx, scratch, err := newScratchDir(ctx, vc.limits.TempDir)
+	if err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+	defer scratch.remove()
+
+	inputPath, err := scratch.writeInput("input", input)
+	if err != nil {
+		vc.recordFailure()
+		return nil, err
+	}
+
+	// target_size_mb tightens the output size limit and switches to two-pass
+	// encoding, which lands much closer to the budget than single-pass ABR
+	sizeLimit, sizeErr := vc.limits.MaxOutputSize, ErrOutputSizeExceeded
+	if targetBytes := targetSizeBytes(req.TargetSizeMB); targetBytes > 0 && targetBytes < sizeLimit {
+		sizeLimit, sizeErr = targetBytes, ErrTargetSizeUnreachable
+	}
+
+	opts := encodeOptions{
+		maxWidth:     maxWidth,
+		maxHeight:    maxHeight,
+		bitrate:      vc.limits.MaxBitrate,
+		maxFrameRate: profile.maxFrameRate,
+		tune:         profile.tune,
+		videoStream:  -1,
+		audioStream:  -1,
+	}
+	if req.TargetSizeMB > 0 {
+		opts.passLog = scratch.file("passlog")
+	}
+	var variant string
+	variant, opts.encoderArgs = vc.encoders.pick()
+	span.SetAttributes(attribute.String("media.encoder_variant", variant))
+	if input, probeErr := probeVideo(ctx, inputPath); probeErr == nil {
+		opts.videoStream = input.videoStream
+		opts.frameRate = math.Min(input.frameRate, profile.maxFrameRate)
+
+		opts.audioStream, err = input.selectAudio(req.AudioTrack)
+		if err != nil {
+			vc.recordFailure()
+			return nil, err
+		}
+
+		// Spread the output size budget over the input's duration
+		if input.duration > 0 {
+			budget := bitrateForSize(sizeLimit, input.duration) - vc.limits.AudioBitrate
+			if budget < opts.bitrate {
+				opts.bitrate = budget
+			}
+			if opts.bitrate < minVideoBitrate {
+				vc.recordFailure()
+				return nil, fmt.Errorf("%w: %.0fs would get %dkbit/s (minimum %d) within %d bytes",
+					sizeErr, input.duration, opts.bitrate, minVideoBitrate, sizeLimit)
+			}
+		}
+	} else if req.AudioTrack != nil {
+		vc.recordFailure()
+		return nil, fmt.Errorf("%w: the input's tracks couldn't be read", ErrAudioTrackNotFound)
+	}
+
+	outputPath := scratch.file("output.mp4")
+	encodeStart := time.Now()
+	if err := vc.convertToMP4(ctx, inputPath, outputPath, opts); err != nil {
+		vc.encoders.record(variant, time.Since(encodeStart), 0, err)
+		vc.recordFailure()
+		return nil, fmt.Errorf("conversion failed: %w", err)
+	}
+	encodeTime := time.Since(encodeStart)
+
+	stat, err := os.Stat(outputPath)
+	if err != nil {
+		vc.recordFailure()
+		return nil, fmt.Errorf("read converted video: %w", err)
+	}
+	size := stat.Size()
+	if size == 0 {
+		err = fmt.Errorf("ffmpeg produced no output")
+		vc.encoders.record(variant, encodeTime, 0, err)
+		vc.recordFailure()
+		return nil, err
+	}
+	vc.encoders.record(variant, encodeTime, int(size), nil)
+
+	// Rate control can overshoot on very short or very noisy inputs
+	if size > sizeLimit {
+		vc.recordFailure()
+		return nil, fmt.Errorf("%w: output is %d bytes, limit is %d",
+			sizeErr, size, sizeLimit)
+	}
+
+	// Get output dimensions and duration (optional)
+	info, _ := probeVideo(ctx, outputPath)
+
+	// faststart moves the index to the front once encoding ends, so the MP4
+	// can't be piped out of FFmpeg; a sink gets it streamed from scratch
+	var outputData []byte
+	var outputFile *SpooledFile
+	switch {
+	case req.Sink != nil:
+		err = copyFile(outputPath, req.Sink)
+	case req.RawOutput:
+		// Large outputs wait for the response in a spill file, outliving scratch
+		if outputFile, err = vc.spillStore().spillFile(outputPath, size); err == nil && outputFile == nil {
+			outputData, err = os.ReadFile(outputPath)
+		}
+	default:
+		outputData, err = os.ReadFile(outputPath)
+	}
+	if err != nil {
+		vc.recordFailure()
+		return nil, fmt.Errorf("read converted video: %w", err)
+	}
+
+	vc.recordSuccess(time.Since(start))
+
+	response := &VideoResponse{
+		MimeType:     videoMimeType,
+		Width:        info.width,
+		Height:       info.height,
+		Duration:     int(info.duration),
+		Size:         int(size),
+		VideoBitrate: opts.bitrate,
+		Preset:       profile.preset,
+		Input:        &media,
+
+		EncoderVariant: variant,
+		OutputFile:     outputFile,
+	}
+	if req.Sink == nil && outputFile == nil {
+		response.setOutput(outputData, req)
+	}
+
+	return response, nil
+}
+
+// encodeOptions are the per-input settings of convertToMP4
+type encodeOptions struct {
+	maxWidth     int
+	maxHeight    int
+	bitrate      int     // Video bitrate in kbit/s
+	frameRate    float64 // Constant output frame rate (0 = the input's, capped at maxFrameRate)
+	maxFrameRate float64
+	tune         string   // x264 -tune, empty for none
+	videoStream  int      // Absolute input stream index (-1 = first video stream)
+	audioStream  int      // Absolute input stream index (-1 = first audio stream, if any)
+	passLog      string   // x264 two-pass statistics file prefix, empty for single-pass
+	encoderArgs  []string // Encoder variant options, overriding the built-in ones
+}
+
+// convertToMP4 encodes H.264 baseline + AAC-LC, the combination every
+// WhatsApp client plays inline. Browser MediaRecorder WebM has variable frame
+// rate video and audio timestamps with gaps, so frames are resampled to a
+// constant rate and audio is stretched back into sync. With a passLog, a
+// video-only analysis pass runs first so the bitrate is spread by complexity.
+func (vc *VideoConverter) convertToMP4(ctx context.Context, inputPath, outputPath string, opts encodeOptions) error {
+	scaleFilter := fmt.Sprintf(
+		"scale='min(%d,iw)':'min(%d,ih)':force_original_aspect_ratio=decrease:force_divisible_by=2:flags=lanczos",
+		opts.maxWidth, opts.maxHeight,
+	)
+
+	videoMap, audioMap := "0:v:0", "0:a:0?"
+	if opts.videoStream >= 0 {
+		videoMap = fmt.Sprintf("0:%d", opts.videoStream)
+	}
+	if opts.audioStream >= 0 {
+		audioMap = fmt.Sprintf("0:%d", opts.audioStream)
+	}
+
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-y",
+		"-i", inputPath,
+		"-map", videoMap, // Selected video stream
+		"-vf", scaleFilter + ",format=yuv420p", // Bounded size, 4:2:0 for baseline
+		"-vsync", "cfr", // Duplicate/drop frames to a constant rate
+	}
+	if opts.frameRate > 0 {
+		args = append(args, "-r", strconv.FormatFloat(opts.frameRate, 'f', -1, 64))
+	} else {
+		args = append(args, "-fpsmax", strconv.FormatFloat(opts.maxFrameRate, 'f', -1, 64)) // Cap high frame rate screen/phone recordings
+	}
+	if opts.tune != "" {
+		args = append(args, "-tune", opts.tune)
+	}
+	args = append(args,
+		"-c:v", "libx264",
+		"-profile:v", "baseline", // No B-frames/CABAC: plays on every client
+		"-preset", "medium",
+		"-b:v", fmt.Sprintf("%dk", opts.bitrate),
+		"-maxrate", fmt.Sprintf("%dk", opts.bitrate),
+		"-bufsize", fmt.Sprintf("%dk", opts.bitrate*2),
+	)
+	args = append(args, opts.encoderArgs...)
+
+	if opts.passLog != "" {
+		pass1 := append(slices.Clone(args),
+			"-pass", "1",
+			"-passlogfile", opts.passLog,
+			"-an",
+			"-f", "null",
+			"-threads", ffmpegThreadsArg(),
+			os.DevNull,
+		)
+		if _, stderr, err := runCommand(ctx, nil, "ffmpeg", pass1...); err != nil {
+			return fmt.Errorf("ffmpeg first pass error: %v, stderr: %s", err, stderr)
+		}
+		args = append(args, "-pass", "2", "-passlogfile", opts.passLog)
+	}
+
+	args = append(args,
+		"-map", audioMap, // Selected audio stream
+		"-af", "aresample=async=1:first_pts=0", // Fill timestamp gaps, start at zero
+		"-c:a", "aac",
+		"-b:a", fmt.Sprintf("%dk", vc.limits.AudioBitrate),
+		"-ac", "2",
+		"-ar", "44100",
+		"-movflags", "+faststart", // Index first so playback starts while downloading
+		"-threads", ffmpegThreadsArg(), // Per-process thread budget
+		outputPath,
+	)
+
+	_, stderr, err := runCommand(ctx, nil, "ffmpeg", args...)
+	if err != nil {
+		return fmt.Errorf("ffmpeg error: %v, stderr: %s", err, stderr)
+	}
+
+	return nil
+}
+
+// videoInfo is what probeVideo reads from a container
+type videoInfo struct {
+	width        int
+	height       int
+	duration     float64 // seconds
+	frameRate    float64 // Average frames per second (0 = unknown)
+	videoStream  int     // Absolute index of the video stream
+	audioStreams []int   // Absolute indexes of the audio streams
+	defaultAudio int     // Position in audioStreams of the track flagged default
+}
+
+// probeVideo reads the first video stream (skipping cover art), the audio
+// streams and the duration. MediaRecorder WebM has no duration in its header,
+// so it falls back to the last packet's timestamp.
+func probeVideo(ctx context.Context, path string) (videoInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	output, _, err := runCommand(ctx, nil, "ffprobe",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-show_entries", "stream=index,codec_type,width,height,avg_frame_rate,r_frame_rate:stream_disposition=default,attached_pic:format=duration",
+		"-of", "json",
+		path,
+	)
+	if err != nil {
+		return videoInfo{}, err
+	}
+
+	var probe struct {
+		Streams []struct {
+			Index        int    `json:"index"`
+			CodecType    string `json:"codec_type"`
+			Width        int    `json:"width"`
+			Height       int    `json:"height"`
+			AvgFrameRate string `json:"avg_frame_rate"`
+			RFrameRate   string `json:"r_frame_rate"`
+			Disposition  struct {
+				Default     int `json:"default"`
+				AttachedPic int `json:"attached_pic"`
+			} `json:"disposition"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return videoInfo{}, fmt.Errorf("unexpected ffprobe output: %w", err)
+	}
+
+	info := videoInfo{videoStream: -1}
+	defaultSeen := false
+	for _, stream := range probe.Streams {
+		switch {
+		case stream.CodecType == "video" && stream.Disposition.AttachedPic == 0 && info.videoStream < 0:
+			info.videoStream = stream.Index
+			info.width, info.height = stream.Width, stream.Height
+			// avg_frame_rate is 0/0 without a duration; r_frame_rate is a
+			// timebase guess (1000/1 for WebM) that the 30fps cap absorbs
+			info.frameRate = parseFrameRate(stream.AvgFrameRate)
+			if info.frameRate == 0 {
+				info.frameRate = parseFrameRate(stream.RFrameRate)
+			}
+		case stream.CodecType == "audio":
+			if stream.Disposition.Default == 1 && !defaultSeen {
+				info.defaultAudio = len(info.audioStreams)
+				defaultSeen = true
+			}
+			info.audioStreams = append(info.audioStreams, stream.Index)
+		}
+	}
+	if info.videoStream < 0 {
+		return videoInfo{}, fmt.Errorf("no video stream")
+	}
+
+	info.duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	if info.duration <= 0 {
+		info.duration = lastPacketTime(ctx, path, info.videoStream)
+	}
+
+	return info, nil
+}
+
+// selectAudio returns the absolute stream index of the requested audio track,
+// or of the default one when track is nil (-1 when the input has no audio)
+func (info videoInfo) selectAudio(track *int) (int, error) {
+	if track == nil {
+		if len(info.audioStreams) == 0 {
+			return -1, nil
+		}
+		return info.audioStreams[info.defaultAudio], nil
+	}
+
+	if *track < 0 || *track >= len(info.audioStreams) {
+		return -1, fmt.Errorf("%w: track %d requested, input has %d audio track(s)",
+			ErrAudioTrackNotFound, *track, len(info.audioStreams))
+	}
+	return info.audioStreams[*track], nil
+}
+
+// parseFrameRate parses ffprobe's "num/den" frame rates, returning 0 for
+// unknown (0/0) or implausible values
+func parseFrameRate(rate string) float64 {
+	num, den, ok := strings.Cut(rate, "/")
+	if !ok {
+		den = "1"
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || n <= 0 || d <= 0 {
+		return 0
+	}
+	return n / d
+}
+
+// lastPacketTime returns the timestamp in seconds of the stream's last packet,
+// or 0 when it can't be read
+func lastPacketTime(ctx context.Context, path string, stream int) float64 {
+	output, _, err := runCommand(ctx, nil, "ffprobe",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-select_streams", strconv.Itoa(stream),
+		"-show_entries", "packet=pts_time",
+		"-of", "csv=p=0",
+		path,
+	)
+	if err != nil {
+		return 0
+	}
+
+	lines := strings.Fields(string(output))
+	for i := len(lines) - 1; i >= 0; i-- {
+		if seconds, err := strconv.ParseFloat(strings.Trim(lines[i], ","), 64); err == nil {
+			return seconds
+		}
+	}
+	return 0
+}
+
+// Stats recording
+func (vc *VideoConverter) recordSuccess(duration time.Duration) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.stats.TotalConversions++
+
+	// Update average conversion time
+	if vc.stats.AvgConversionTime == 0 {
+		vc.stats.AvgConversionTime = duration
+	} else {
+		vc.stats.AvgConversionTime = (vc.stats.AvgConversionTime*9 + duration) / 10
+	}
+}
+
+func (vc *VideoConverter) recordFailure() {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	vc.stats.TotalConversions++
+	vc.stats.FailedConversions++
+}
+
+// GetStats returns conversion statistics
+func (vc *VideoConverter) GetStats() VideoConverterStats {
+	vc.mu.RLock()
+	stats := vc.stats
+	vc.mu.RUnlock()
+
+	stats.Variants = vc.encoders.snapshot()
+	return stats
+}
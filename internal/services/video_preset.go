@@ -0,0 +1,52 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Video presets
+const (
+	// VideoPresetWhatsApp suits camera footage: VIDEO_MAX_WIDTH×VIDEO_MAX_HEIGHT
+	// at up to 30fps (default)
+	VideoPresetWhatsApp = "whatsapp"
+
+	// VideoPresetScreencast keeps text in screen recordings readable: a larger
+	// box (VIDEO_SCREENCAST_MAX_WIDTH×VIDEO_SCREENCAST_MAX_HEIGHT), at most
+	// 15fps so the bitrate goes to detail, and x264 tuned for still content
+	VideoPresetScreencast = "screencast"
+)
+
+// screencastFrameRate is enough for cursor movement and scrolling
+const screencastFrameRate = 15
+
+// videoProfile is the encoder settings a preset selects
+type videoProfile struct {
+	preset       string
+	maxWidth     int
+	maxHeight    int
+	maxFrameRate float64
+	tune         string // x264 -tune, empty for none
+}
+
+// videoProfile resolves the request's preset
+func (vc *VideoConverter) videoProfile(req *VideoRequest) (videoProfile, error) {
+	switch preset := strings.ToLower(strings.TrimSpace(req.Preset)); preset {
+	case "", VideoPresetWhatsApp:
+		return videoProfile{
+			preset:       VideoPresetWhatsApp,
+			maxWidth:     vc.limits.MaxWidth,
+			maxHeight:    vc.limits.MaxHeight,
+			maxFrameRate: maxVideoFrameRate,
+		}, nil
+	case VideoPresetScreencast:
+		return videoProfile{
+			preset:       VideoPresetScreencast,
+			maxWidth:     vc.limits.ScreencastMaxWidth,
+			maxHeight:    vc.limits.ScreencastMaxHeight,
+			maxFrameRate: screencastFrameRate,
+			tune:         "stillimage",
+		}, nil
+	}
+	return videoProfile{}, fmt.Errorf("%w: %q (use %s or %s)", ErrUnknownPreset, req.Preset, VideoPresetWhatsApp, VideoPresetScreencast)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// Warmup converts a tiny image and a tiny audio clip through the real
+// pipelines, so a missing or broken encoder shows up at startup instead of on
+// the first request. It also loads the encoders into the page cache.
+func Warmup(ctx context.Context, audioConverter *AudioConverter, imageConverter *ImageConverter) error {
+	if _, err := imageConverter.Convert(ctx, &ImageRequest{Input: tinyPNG(), RawOutput: true}); err != nil {
+		return fmt.Errorf("image conversion: %w", err)
+	}
+	if _, err := audioConverter.Convert(ctx, &AudioRequest{Input: tinyWAV(), RawOutput: true}); err != nil {
+		return fmt.Errorf("audio conversion: %w", err)
+	}
+	return nil
+}
+
+// tinyPNG encodes a 64x64 gradient
+func tinyPNG() []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// tinyWAV encodes 250ms of 16-bit mono silence at 48kHz
+func tinyWAV() []byte {
+	const sampleRate = 48000
+	samples := make([]byte, sampleRate/4*2)
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(36+len(samples)))
+	buf.WriteString("WAVEfmt ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(16))         // fmt chunk size
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))          // PCM
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(1))          // mono
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(sampleRate)) // sample rate
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(sampleRate*2))
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(2))  // block align
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(16)) // bits per sample
+	buf.WriteString("data")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(samples)))
+	buf.Write(samples)
+
+	return buf.Bytes()
+}
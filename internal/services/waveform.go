@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// waveformSamples is the number of bars WhatsApp draws for a voice note
+	waveformSamples = 64
+
+	// waveformMax is the loudest bar; WhatsApp expects values from 0 to 100
+	waveformMax = 100
+
+	// waveformSampleRate is enough resolution for 64 bars of any length
+	waveformSampleRate = "8000"
+)
+
+// waveform decodes audio to PCM and returns WhatsApp's voice note waveform as
+// plain base64 of waveformSamples bytes. The caller holds a worker slot.
+func (ac *AudioConverter) waveform(ctx context.Context, audio []byte) (string, error) {
+	pcm, stderr, err := runCommand(ctx, audio, "ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-i", "pipe:0",
+		"-vn",
+		"-map", "0:a:0",
+		"-ac", "1",
+		"-ar", waveformSampleRate,
+		"-f", "s16le",
+		"-threads", ffmpegThreadsArg(),
+		"pipe:1",
+	)
+	if err != nil {
+		return "", fmt.Errorf("waveform failed: %v, stderr: %s", err, stderr)
+	}
+
+	return base64.StdEncoding.EncodeToString(waveformFromPCM(pcm)), nil
+}
+
+// waveformFromPCM averages the absolute amplitude of 16-bit little-endian
+// mono samples over waveformSamples equal blocks and scales the loudest block
+// to waveformMax. Silence and empty input give all zeros.
+func waveformFromPCM(pcm []byte) []byte {
+	samples := len(pcm) / 2
+	levels := make([]float64, waveformSamples)
+
+	if samples > 0 {
+		for i := range levels {
+			from := i * samples / waveformSamples
+			to := (i + 1) * samples / waveformSamples
+			if to == from {
+				// Shorter than waveformSamples samples: repeat the nearest one
+				to = min(from+1, samples)
+			}
+
+			var sum float64
+			for s := from; s < to; s++ {
+				sample := int16(binary.LittleEndian.Uint16(pcm[s*2:]))
+				sum += abs16(sample)
+			}
+			levels[i] = sum / float64(to-from)
+		}
+	}
+
+	var peak float64
+	for _, level := range levels {
+		peak = max(peak, level)
+	}
+
+	waveform := make([]byte, waveformSamples)
+	if peak == 0 {
+		return waveform
+	}
+	for i, level := range levels {
+		waveform[i] = byte(level / peak * waveformMax)
+	}
+	return waveform
+}
+
+func abs16(sample int16) float64 {
+	if sample < 0 {
+		return -float64(sample)
+	}
+	return float64(sample)
+}
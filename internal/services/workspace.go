@@ -0,0 +1,775 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"whats-convert-api/internal/providers"
+)
+
+var (
+	// ErrWorkspaceNotFound is returned for unknown, expired or deleted workspaces
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+
+	// ErrWorkspaceCapacity is returned when WORKSPACE_MAX_ACTIVE workspaces are open
+	ErrWorkspaceCapacity = errors.New("maximum active workspaces reached")
+
+	// ErrWorkspaceFull is returned when a file would exceed WORKSPACE_MAX_FILES
+	// or WORKSPACE_MAX_SIZE
+	ErrWorkspaceFull = errors.New("workspace is full")
+
+	// ErrWorkspaceFileNotFound is returned for files a workspace doesn't hold
+	ErrWorkspaceFileNotFound = errors.New("workspace file not found")
+
+	// ErrInvalidWorkspaceRequest is returned for invalid file names, options
+	// or commit prefixes
+	ErrInvalidWorkspaceRequest = errors.New("invalid workspace request")
+
+	// ErrWorkspaceStoreDisabled is returned when committing without S3
+	ErrWorkspaceStoreDisabled = errors.New("S3 storage is not enabled")
+)
+
+// workspacePrefix starts the names of workspace directories
+const workspacePrefix = "whats-convert-workspace-"
+
+// Origins of workspace files
+const (
+	WorkspaceFileUploaded  = "upload"
+	WorkspaceFileConverted = "convert"
+	WorkspaceFileComposed  = "compose"
+)
+
+// workspaceFileName allows names that are safe as file and key names
+var workspaceFileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// WorkspaceLimits bound the workspaces of a WorkspaceManager
+type WorkspaceLimits struct {
+	TTL          time.Duration // Idle time after which a workspace is discarded
+	MaxActive    int           // Workspaces open at once
+	MaxFiles     int           // Files per workspace
+	MaxSize      int64         // Bytes of files per workspace
+	Dir          string        // Parent of workspace directories (default os.TempDir())
+	CommitPrefix string        // Key prefix of committed files, followed by the workspace ID
+}
+
+// DefaultWorkspaceLimits returns the limits of WorkspaceManagers built with zero values
+func DefaultWorkspaceLimits() WorkspaceLimits {
+	return WorkspaceLimits{
+		TTL:          30 * time.Minute,
+		MaxActive:    100,
+		MaxFiles:     20,
+		MaxSize:      200 * 1024 * 1024,
+		CommitPrefix: "workspaces/",
+	}
+}
+
+// WorkspaceFile describes a file held by a workspace
+type WorkspaceFile struct {
+	Name      string    `json:"name" example:"cover.jpg"`
+	MimeType  string    `json:"mime_type" example:"image/jpeg"` // Detected from the content
+	Size      int64     `json:"size" example:"48213"`
+	Origin    string    `json:"origin" example:"convert"`              // upload, convert or compose
+	Sources   []string  `json:"sources,omitempty" example:"cover.png"` // Files it was made from
+	Width     int       `json:"width,omitempty" example:"800"`         // Converted images and composed videos
+	Height    int       `json:"height,omitempty" example:"600"`        // Converted images and composed videos
+	Duration  int       `json:"duration,omitempty" example:"8"`        // Converted audio and composed videos, in seconds
+	CreatedAt time.Time `json:"created_at" example:"2024-03-31T12:00:00Z"`
+}
+
+// Workspace holds related files while they are converted and composed,
+// until they are committed to S3 or the workspace expires
+type Workspace struct {
+	ID        string          `json:"id" example:"6f2d3c1a-9b8e-4f7d-a6c5-4b3a2d1e0f9c"`
+	CreatedAt time.Time       `json:"created_at" example:"2024-03-31T12:00:00Z"`
+	ExpiresAt time.Time       `json:"expires_at" example:"2024-03-31T12:30:00Z"` // Pushed back by every request on the workspace
+	Size      int64           `json:"size" example:"1048576"`                    // Bytes of its files
+	Files     []WorkspaceFile `json:"files"`
+
+	// Internal fields
+	dir     string
+	busy    int  // Operations in progress; expiry waits for them
+	deleted bool // Discarded while an operation was running
+	mu      sync.Mutex
+}
+
+// WorkspaceConvertRequest converts a workspace file into another one
+type WorkspaceConvertRequest struct {
+	File    string          `json:"file" example:"voice.wav"`               // Workspace file to convert
+	Output  string          `json:"output,omitempty" example:"voice.ogg"`   // Name of the converted file (default: file with the output's extension)
+	Options json.RawMessage `json:"options,omitempty" swaggertype:"object"` // Fields of a /convert/audio or /convert/image request, without data
+}
+
+// WorkspaceComposeRequest encodes a workspace image over a workspace audio
+// file as a video
+type WorkspaceComposeRequest struct {
+	Audio     string `json:"audio" example:"voice.ogg"`
+	Image     string `json:"image" example:"cover.jpg"`
+	Output    string `json:"output,omitempty" example:"voice.mp4"` // Default: the audio file's name with .mp4
+	MaxWidth  int    `json:"max_width,omitempty" example:"1280"`   // Optional: capped by VIDEO_MAX_WIDTH
+	MaxHeight int    `json:"max_height,omitempty" example:"1280"`  // Optional: capped by VIDEO_MAX_HEIGHT
+}
+
+// WorkspaceCommitRequest uploads workspace files to the bucket
+type WorkspaceCommitRequest struct {
+	Files          []string `json:"files,omitempty" example:"voice.mp4"`           // Default: every file
+	Prefix         string   `json:"prefix,omitempty" example:"campaigns/2024-03/"` // Default: WORKSPACE_COMMIT_PREFIX plus the workspace ID
+	Public         bool     `json:"public" example:"false"`
+	ExpirationDays int      `json:"expires_days,omitempty" example:"7"`
+	Keep           bool     `json:"keep,omitempty" example:"false"` // Keep the workspace open after the commit (default: it is deleted)
+}
+
+// WorkspaceObject is a workspace file stored in the bucket
+type WorkspaceObject struct {
+	File        string `json:"file" example:"voice.mp4"`
+	Key         string `json:"key" example:"workspaces/6f2d3c1a-9b8e-4f7d-a6c5-4b3a2d1e0f9c/voice.mp4"`
+	URL         string `json:"url" example:"https://bucket.s3.amazonaws.com/workspaces/6f2d3c1a-9b8e-4f7d-a6c5-4b3a2d1e0f9c/voice.mp4"`
+	Size        int64  `json:"size" example:"1048576"`
+	ContentType string `json:"content_type" example:"video/mp4"`
+}
+
+// WorkspaceCommit reports the files a commit stored
+type WorkspaceCommit struct {
+	WorkspaceID string            `json:"workspace_id" example:"6f2d3c1a-9b8e-4f7d-a6c5-4b3a2d1e0f9c"`
+	Objects     []WorkspaceObject `json:"objects"`
+	Deleted     bool              `json:"deleted" example:"true"` // The workspace was discarded after the commit
+}
+
+// WorkspaceManager keeps temporary workspaces on disk. Files are uploaded,
+// converted and composed inside a workspace, then committed to S3; a
+// workspace left untouched for the TTL is discarded with its files.
+type WorkspaceManager struct {
+	audioConverter AudioConverterIface
+	imageConverter ImageConverterIface
+	videoConverter VideoConverterIface
+	downloader     *Downloader
+	store          *S3Service
+	limits         WorkspaceLimits
+	workspaces     map[string]*Workspace
+	expired        int64
+	committed      int64
+	mu             sync.RWMutex
+	cleanupTicker  *time.Ticker
+	stopCleanup    chan bool
+}
+
+// NewWorkspaceManager creates a workspace manager; zero limits take their default
+func NewWorkspaceManager(audioConverter AudioConverterIface, imageConverter ImageConverterIface, videoConverter VideoConverterIface, downloader *Downloader, limits WorkspaceLimits) *WorkspaceManager {
+	defaults := DefaultWorkspaceLimits()
+	if limits.TTL <= 0 {
+		limits.TTL = defaults.TTL
+	}
+	if limits.MaxActive <= 0 {
+		limits.MaxActive = defaults.MaxActive
+	}
+	if limits.MaxFiles <= 0 {
+		limits.MaxFiles = defaults.MaxFiles
+	}
+	if limits.MaxSize <= 0 {
+		limits.MaxSize = defaults.MaxSize
+	}
+	if limits.CommitPrefix == "" {
+		limits.CommitPrefix = defaults.CommitPrefix
+	}
+
+	manager := &WorkspaceManager{
+		audioConverter: audioConverter,
+		imageConverter: imageConverter,
+		videoConverter: videoConverter,
+		downloader:     downloader,
+		limits:         limits,
+		workspaces:     make(map[string]*Workspace),
+		stopCleanup:    make(chan bool),
+	}
+
+	// Start cleanup routine for abandoned workspaces
+	manager.startCleanupRoutine()
+
+	return manager
+}
+
+// SetStore enables commits to the bucket
+func (wm *WorkspaceManager) SetStore(store *S3Service) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	wm.store = store
+}
+
+// Limits returns the limits workspaces are held to
+func (wm *WorkspaceManager) Limits() WorkspaceLimits {
+	return wm.limits
+}
+
+// Create opens an empty workspace
+func (wm *WorkspaceManager) Create() (*Workspace, error) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	if len(wm.workspaces) >= wm.limits.MaxActive {
+		return nil, fmt.Errorf("%w (%d)", ErrWorkspaceCapacity, wm.limits.MaxActive)
+	}
+
+	dir, err := os.MkdirTemp(wm.limits.Dir, workspacePrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("create workspace directory: %w", err)
+	}
+
+	now := time.Now()
+	workspace := &Workspace{
+		ID:        uuid.New().String(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(wm.limits.TTL),
+		Files:     []WorkspaceFile{},
+		dir:       dir,
+	}
+	wm.workspaces[workspace.ID] = workspace
+
+	return workspace.snapshot(), nil
+}
+
+// Get returns a copy of a workspace and pushes back its expiry
+func (wm *WorkspaceManager) Get(id string) (*Workspace, error) {
+	workspace, release, err := wm.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return workspace.snapshot(), nil
+}
+
+// Delete discards a workspace and its files
+func (wm *WorkspaceManager) Delete(id string) error {
+	wm.mu.Lock()
+	workspace, exists := wm.workspaces[id]
+	delete(wm.workspaces, id)
+	wm.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrWorkspaceNotFound, id)
+	}
+
+	workspace.discard()
+	return nil
+}
+
+// Fetch decodes base64 data (plain or data URI) or downloads a URL, for
+// files added from a JSON body
+func (wm *WorkspaceManager) Fetch(ctx context.Context, data string, isURL bool) ([]byte, error) {
+	if isURL {
+		return wm.downloader.Download(ctx, strings.TrimSpace(data))
+	}
+
+	if _, payload, found := strings.Cut(data, ";base64,"); found && strings.HasPrefix(data, "data:") {
+		data = payload
+	}
+	data, encoding := providers.NormalizeBase64(data)
+	decoded, err := encoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: base64 decode failed: %v", ErrInvalidWorkspaceRequest, err)
+	}
+	return decoded, nil
+}
+
+// AddFile stores data as name in a workspace, replacing any file of that name
+func (wm *WorkspaceManager) AddFile(id, name string, data []byte) (*WorkspaceFile, error) {
+	workspace, release, err := wm.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := checkWorkspaceFileName(name); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrInvalidWorkspaceRequest, name)
+	}
+
+	return wm.save(workspace, WorkspaceFile{Name: name, Origin: WorkspaceFileUploaded}, data)
+}
+
+// OpenFile opens a workspace file for reading; the caller closes it
+func (wm *WorkspaceManager) OpenFile(id, name string) (*os.File, *WorkspaceFile, error) {
+	workspace, release, err := wm.acquire(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+
+	index := workspace.find(name)
+	if index < 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrWorkspaceFileNotFound, name)
+	}
+	file, err := os.Open(filepath.Join(workspace.dir, name))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := workspace.Files[index]
+	return file, &info, nil
+}
+
+// DeleteFile removes a file from a workspace
+func (wm *WorkspaceManager) DeleteFile(id, name string) error {
+	workspace, release, err := wm.acquire(id)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+
+	index := workspace.find(name)
+	if index < 0 {
+		return fmt.Errorf("%w: %s", ErrWorkspaceFileNotFound, name)
+	}
+	if err := os.Remove(filepath.Join(workspace.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	workspace.Size -= workspace.Files[index].Size
+	workspace.Files = slices.Delete(workspace.Files, index, index+1)
+	return nil
+}
+
+// Convert converts a workspace file to WhatsApp audio or image and stores
+// the output as another file. The input's content picks the converter.
+func (wm *WorkspaceManager) Convert(ctx context.Context, id string, req *WorkspaceConvertRequest) (*WorkspaceFile, error) {
+	workspace, release, err := wm.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	input, err := workspace.read(req.File)
+	if err != nil {
+		return nil, err
+	}
+	if req.Output != "" {
+		if err := checkWorkspaceFileName(req.Output); err != nil {
+			return nil, err
+		}
+	}
+
+	media, _ := SniffBytes(input)
+	file := WorkspaceFile{Name: req.Output, Origin: WorkspaceFileConverted, Sources: []string{req.File}}
+	var output []byte
+
+	switch media.Kind {
+	case MediaKindAudio:
+		var audioReq AudioRequest
+		if err := decodeWorkspaceOptions(req.Options, &audioReq); err != nil {
+			return nil, err
+		}
+		audioReq.Data, audioReq.IsURL, audioReq.Source = "", false, nil
+		audioReq.Input, audioReq.RawOutput = input, true
+
+		response, err := wm.audioConverter.Convert(ctx, &audioReq)
+		if err != nil {
+			return nil, err
+		}
+		output, file.Duration = response.Output, response.Duration
+
+	case MediaKindImage:
+		var imageReq ImageRequest
+		if err := decodeWorkspaceOptions(req.Options, &imageReq); err != nil {
+			return nil, err
+		}
+		imageReq.Data, imageReq.IsURL, imageReq.Source = "", false, nil
+		imageReq.Input, imageReq.RawOutput = input, true
+
+		response, err := wm.imageConverter.Convert(ctx, &imageReq)
+		if err != nil {
+			return nil, err
+		}
+		output, file.Width, file.Height = response.Output, response.Width, response.Height
+
+	default:
+		return nil, fmt.Errorf("%w: %s is %s; workspaces convert audio and images", ErrUnsupportedInput, req.File, media.MIME)
+	}
+
+	if file.Name == "" {
+		file.Name = replaceExtension(req.File, output)
+	}
+	return wm.save(workspace, file, output)
+}
+
+// Compose encodes a workspace image over a workspace audio file as an MP4
+// and stores it as another file
+func (wm *WorkspaceManager) Compose(ctx context.Context, id string, req *WorkspaceComposeRequest) (*WorkspaceFile, error) {
+	workspace, release, err := wm.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	audio, err := workspace.read(req.Audio)
+	if err != nil {
+		return nil, err
+	}
+	image, err := workspace.read(req.Image)
+	if err != nil {
+		return nil, err
+	}
+	if req.Output != "" {
+		if err := checkWorkspaceFileName(req.Output); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := wm.videoConverter.Compose(ctx, &ComposeRequest{
+		Audio:     audio,
+		Image:     image,
+		MaxWidth:  req.MaxWidth,
+		MaxHeight: req.MaxHeight,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	file := WorkspaceFile{
+		Name:     req.Output,
+		Origin:   WorkspaceFileComposed,
+		Sources:  []string{req.Audio, req.Image},
+		Width:    response.Width,
+		Height:   response.Height,
+		Duration: response.Duration,
+	}
+	if file.Name == "" {
+		file.Name = replaceExtension(req.Audio, response.Output)
+	}
+	return wm.save(workspace, file, response.Output)
+}
+
+// Commit uploads workspace files to the bucket, under the request's prefix
+// or WORKSPACE_COMMIT_PREFIX plus the workspace ID, and then discards the
+// workspace unless asked to keep it. A failed upload leaves the workspace
+// open so the commit can be retried; objects already stored are overwritten.
+func (wm *WorkspaceManager) Commit(ctx context.Context, id string, req *WorkspaceCommitRequest) (*WorkspaceCommit, error) {
+	wm.mu.RLock()
+	store := wm.store
+	wm.mu.RUnlock()
+
+	if store == nil || !store.IsEnabled() {
+		return nil, ErrWorkspaceStoreDisabled
+	}
+
+	workspace, release, err := wm.acquire(id)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	prefix, err := commitPrefix(req.Prefix, wm.limits.CommitPrefix+id+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	names := req.Files
+	if len(names) == 0 {
+		for _, file := range workspace.snapshot().Files {
+			names = append(names, file.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: the workspace has no files", ErrInvalidWorkspaceRequest)
+	}
+
+	commit := &WorkspaceCommit{WorkspaceID: id, Objects: make([]WorkspaceObject, 0, len(names))}
+	for _, name := range names {
+		data, err := workspace.read(name)
+		if err != nil {
+			return nil, err
+		}
+		file, _ := workspace.file(name)
+
+		result, err := store.Upload(ctx, prefix+name, data, providers.UploadOptions{
+			ContentType:    file.MimeType,
+			Metadata:       map[string]string{"workspace-id": id},
+			Public:         req.Public,
+			ExpirationDays: req.ExpirationDays,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("upload %s: %w", name, err)
+		}
+		commit.Objects = append(commit.Objects, WorkspaceObject{
+			File:        name,
+			Key:         result.Key,
+			URL:         result.PublicURL,
+			Size:        result.Size,
+			ContentType: file.MimeType,
+		})
+	}
+
+	wm.mu.Lock()
+	wm.committed++
+	wm.mu.Unlock()
+
+	if !req.Keep {
+		if wm.Delete(id) == nil {
+			commit.Deleted = true
+		}
+	}
+	return commit, nil
+}
+
+// acquire finds a workspace, pushes back its expiry and holds it open until
+// release is called
+func (wm *WorkspaceManager) acquire(id string) (*Workspace, func(), error) {
+	wm.mu.RLock()
+	workspace, exists := wm.workspaces[id]
+	wm.mu.RUnlock()
+
+	if !exists {
+		return nil, nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, id)
+	}
+
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+
+	if workspace.deleted {
+		return nil, nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, id)
+	}
+	workspace.busy++
+	workspace.ExpiresAt = time.Now().Add(wm.limits.TTL)
+
+	release := func() {
+		workspace.mu.Lock()
+		workspace.busy--
+		workspace.ExpiresAt = time.Now().Add(wm.limits.TTL)
+		workspace.mu.Unlock()
+	}
+	return workspace, release, nil
+}
+
+// save writes a file into a workspace within its limits
+func (wm *WorkspaceManager) save(workspace *Workspace, file WorkspaceFile, data []byte) (*WorkspaceFile, error) {
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+
+	if workspace.deleted {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, workspace.ID)
+	}
+
+	size := workspace.Size
+	index := workspace.find(file.Name)
+	if index >= 0 {
+		size -= workspace.Files[index].Size
+	} else if len(workspace.Files) >= wm.limits.MaxFiles {
+		return nil, fmt.Errorf("%w: at most %d files", ErrWorkspaceFull, wm.limits.MaxFiles)
+	}
+	if size+int64(len(data)) > wm.limits.MaxSize {
+		return nil, fmt.Errorf("%w: %s would bring it to %d bytes, limit is %d",
+			ErrWorkspaceFull, file.Name, size+int64(len(data)), wm.limits.MaxSize)
+	}
+
+	if err := os.WriteFile(filepath.Join(workspace.dir, file.Name), data, 0o600); err != nil {
+		return nil, fmt.Errorf("write workspace file: %w", err)
+	}
+
+	media, _ := SniffBytes(data)
+	file.MimeType = media.MIME
+	file.Size = int64(len(data))
+	file.CreatedAt = time.Now()
+
+	if index >= 0 {
+		workspace.Files[index] = file
+	} else {
+		workspace.Files = append(workspace.Files, file)
+	}
+	workspace.Size = size + file.Size
+	return &file, nil
+}
+
+// read returns the content of a workspace file
+func (workspace *Workspace) read(name string) ([]byte, error) {
+	if _, ok := workspace.file(name); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceFileNotFound, name)
+	}
+	data, err := os.ReadFile(filepath.Join(workspace.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceFileNotFound, name)
+	}
+	return data, err
+}
+
+// file returns the description of a workspace file
+func (workspace *Workspace) file(name string) (WorkspaceFile, bool) {
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+
+	if index := workspace.find(name); index >= 0 {
+		return workspace.Files[index], true
+	}
+	return WorkspaceFile{}, false
+}
+
+// find returns the index of a file in Files, or -1; the caller holds mu
+func (workspace *Workspace) find(name string) int {
+	return slices.IndexFunc(workspace.Files, func(file WorkspaceFile) bool {
+		return file.Name == name
+	})
+}
+
+// discard removes the workspace's files
+func (workspace *Workspace) discard() {
+	workspace.mu.Lock()
+	workspace.deleted = true
+	workspace.mu.Unlock()
+
+	if err := os.RemoveAll(workspace.dir); err != nil {
+		log.Printf("Workspace %s: failed to remove %s: %v", workspace.ID, workspace.dir, err)
+	}
+}
+
+// snapshot returns a copy of the workspace's public fields
+func (workspace *Workspace) snapshot() *Workspace {
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+
+	return &Workspace{
+		ID:        workspace.ID,
+		CreatedAt: workspace.CreatedAt,
+		ExpiresAt: workspace.ExpiresAt,
+		Size:      workspace.Size,
+		Files:     slices.Clone(workspace.Files),
+	}
+}
+
+// checkWorkspaceFileName accepts names of letters, digits, dots, dashes and
+// underscores, so they are safe on disk and as object keys
+func checkWorkspaceFileName(name string) error {
+	if !workspaceFileName.MatchString(name) {
+		return fmt.Errorf("%w: file name %q must be 1 to 128 letters, digits, '.', '-' or '_' and start with a letter or digit",
+			ErrInvalidWorkspaceRequest, name)
+	}
+	return nil
+}
+
+// decodeWorkspaceOptions reads the conversion options of a convert request
+func decodeWorkspaceOptions(options json.RawMessage, req any) error {
+	if len(options) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(options, req); err != nil {
+		return fmt.Errorf("%w: options: %v", ErrInvalidWorkspaceRequest, err)
+	}
+	return nil
+}
+
+// replaceExtension names the output of name after the type of output
+func replaceExtension(name string, output []byte) string {
+	media, _ := SniffBytes(output)
+	return strings.TrimSuffix(name, path.Ext(name)) + "." + extensionForContentType(media.MIME)
+}
+
+// commitPrefix validates a commit's key prefix, defaulting to fallback
+func commitPrefix(prefix, fallback string) (string, error) {
+	prefix = strings.TrimLeft(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return fallback, nil
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == "." || segment == ".." {
+			return "", fmt.Errorf("%w: prefix %q can't contain . or .. segments", ErrInvalidWorkspaceRequest, prefix)
+		}
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix, nil
+}
+
+// startCleanupRoutine discards workspaces left untouched for the TTL
+func (wm *WorkspaceManager) startCleanupRoutine() {
+	wm.cleanupTicker = time.NewTicker(max(min(wm.limits.TTL/2, time.Minute), time.Second))
+
+	go func() {
+		for {
+			select {
+			case <-wm.cleanupTicker.C:
+				wm.cleanupExpired()
+			case <-wm.stopCleanup:
+				return
+			}
+		}
+	}()
+}
+
+// cleanupExpired discards expired workspaces that no request is using
+func (wm *WorkspaceManager) cleanupExpired() {
+	now := time.Now()
+	var expired []*Workspace
+
+	wm.mu.Lock()
+	for id, workspace := range wm.workspaces {
+		workspace.mu.Lock()
+		if workspace.busy == 0 && now.After(workspace.ExpiresAt) {
+			expired = append(expired, workspace)
+			delete(wm.workspaces, id)
+		}
+		workspace.mu.Unlock()
+	}
+	wm.expired += int64(len(expired))
+	wm.mu.Unlock()
+
+	for _, workspace := range expired {
+		workspace.discard()
+	}
+}
+
+// GetStats returns workspace manager statistics
+func (wm *WorkspaceManager) GetStats() map[string]interface{} {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+
+	var size int64
+	for _, workspace := range wm.workspaces {
+		workspace.mu.Lock()
+		size += workspace.Size
+		workspace.mu.Unlock()
+	}
+
+	return map[string]interface{}{
+		"active":     len(wm.workspaces),
+		"max_active": wm.limits.MaxActive,
+		"bytes":      size,
+		"expired":    wm.expired,
+		"committed":  wm.committed,
+	}
+}
+
+// Stop stops the cleanup routine and discards every workspace: they don't
+// survive a restart
+func (wm *WorkspaceManager) Stop() {
+	if wm.cleanupTicker != nil {
+		wm.cleanupTicker.Stop()
+	}
+	close(wm.stopCleanup)
+
+	wm.mu.Lock()
+	workspaces := wm.workspaces
+	wm.workspaces = make(map[string]*Workspace)
+	wm.mu.Unlock()
+
+	for _, workspace := range workspaces {
+		workspace.discard()
+	}
+}
@@ -0,0 +1,573 @@
+// Package soak drives a running API with a steady mix of conversions,
+// uploads and batch jobs for hours, sampling /stats as it goes, and fails
+// when goroutines, heap or the upload status map keep growing instead of
+// levelling off once the load is steady.
+//
+// In-memory maps (upload statuses, batch jobs, usage, circuit breakers) and
+// goroutines started outside the worker pools only leak slowly, so the
+// checks compare the start of the steady state with its end rather than
+// absolute values, and the idle process before the load with the idle
+// process after it.
+package soak
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"whats-convert-api/internal/models"
+	"whats-convert-api/internal/samples"
+)
+
+// Options tunes a soak run
+type Options struct {
+	// APIURL is the base URL of the API under test
+	APIURL string
+
+	// Duration is how long the load runs
+	Duration time.Duration
+
+	// Concurrency is the number of clients sending requests back to back
+	Concurrency int
+
+	// SampleInterval is the time between samples of /stats
+	SampleInterval time.Duration
+
+	// Warmup is ignored by the growth checks. Keep it above
+	// S3_UPLOAD_STATUS_TTL and BATCH_JOB_RETENTION, so the maps they bound
+	// have reached their steady size when the checks start looking.
+	Warmup time.Duration
+
+	// Settle is how long the API idles after the load before the final sample
+	Settle time.Duration
+
+	// MaxHeapGrowth is the largest accepted growth of the heap floor between
+	// the first and the last quarter of the steady state (0.5 = 50%)
+	MaxHeapGrowth float64
+
+	// MaxUploadGrowth is the same bound for the upload status map
+	MaxUploadGrowth float64
+
+	// MaxGoroutineGrowth is the most goroutines the idle API may have after
+	// the load on top of those it had before
+	MaxGoroutineGrowth int
+
+	// MaxErrorRate is the largest accepted share of unexpected answers
+	MaxErrorRate float64
+
+	// KeyPrefix is prepended to the keys uploads are written to
+	KeyPrefix string
+
+	// Progress, when set, is called with every sample
+	Progress func(Sample)
+
+	// HTTPClient sends the run's requests
+	HTTPClient *http.Client
+}
+
+// Sample is one reading of the API's stats
+type Sample struct {
+	At         time.Time `json:"at"`
+	Phase      string    `json:"phase"` // idle, warmup, steady or settled
+	Goroutines int       `json:"goroutines"`
+	HeapAlloc  uint64    `json:"heap_alloc_bytes"`
+	Uploads    int       `json:"uploads"` // Upload status records (-1 while S3 is disabled)
+	Requests   int64     `json:"requests"`
+	Errors     int64     `json:"errors"`
+}
+
+// Check is the outcome of one leak check
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// OperationStats counts the requests of one operation
+type OperationStats struct {
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+}
+
+// Report aggregates a soak run
+type Report struct {
+	Started    time.Time                 `json:"started"`
+	Duration   time.Duration             `json:"duration"`
+	Operations map[string]OperationStats `json:"operations"`
+	Samples    []Sample                  `json:"samples"`
+	Checks     []Check                   `json:"checks"`
+	Error      string                    `json:"error,omitempty"` // Set when the run couldn't start
+}
+
+// OK reports whether the run started and no check failed
+func (r *Report) OK() bool {
+	if r.Error != "" {
+		return false
+	}
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// operation is one kind of request the clients send
+type operation struct {
+	name   string
+	weight int
+	s3     bool // Needs S3 enabled
+	run    func(ctx context.Context, r *runner, client int) error
+}
+
+// operations are picked at random in proportion to their weight
+var operations = []operation{
+	{"convert_audio", 4, false, convertAudio},
+	{"convert_image", 4, false, convertImage},
+	{"convert_image_url", 2, false, convertImageURL},
+	{"failing_download", 1, false, failingDownload},
+	{"batch_job", 1, false, batchJob},
+	{"upload_base64", 2, true, uploadBase64},
+}
+
+// runner holds the state shared by the clients of a run
+type runner struct {
+	opts  Options
+	s3    bool
+	audio string
+	image string
+
+	mu    sync.Mutex
+	stats map[string]*OperationStats
+}
+
+// Run loads the API for opts.Duration and checks the samples taken meanwhile
+func Run(ctx context.Context, opts Options) *Report {
+	opts = withDefaults(opts)
+	report := &Report{Started: time.Now().UTC(), Operations: map[string]OperationStats{}}
+
+	r, err := newRunner(ctx, opts)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	idle, err := r.sample(ctx, "idle")
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	r.record(report, idle)
+
+	loadCtx, stopLoad := context.WithTimeout(ctx, opts.Duration)
+	defer stopLoad()
+
+	var clients sync.WaitGroup
+	for i := range opts.Concurrency {
+		clients.Go(func() { r.client(loadCtx, i) })
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(opts.SampleInterval)
+sampling:
+	for {
+		select {
+		case <-loadCtx.Done():
+			break sampling
+		case <-ticker.C:
+			phase := "steady"
+			if time.Since(start) < opts.Warmup {
+				phase = "warmup"
+			}
+			// A missed sample only thins the series out
+			if s, err := r.sample(ctx, phase); err == nil {
+				r.record(report, s)
+			}
+		}
+	}
+	ticker.Stop()
+	clients.Wait()
+	// Kept-alive connections hold goroutines on the server
+	r.opts.HTTPClient.CloseIdleConnections()
+	report.Duration = time.Since(start)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(opts.Settle):
+	}
+	settled, err := r.sample(context.WithoutCancel(ctx), "settled")
+	if err == nil {
+		r.record(report, settled)
+	}
+
+	report.Operations = r.operationStats()
+	report.Checks = evaluate(opts, report, err)
+	return report
+}
+
+func withDefaults(opts Options) Options {
+	opts.APIURL = strings.TrimRight(opts.APIURL, "/")
+	if opts.Duration <= 0 {
+		opts.Duration = time.Hour
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+	if opts.SampleInterval <= 0 {
+		opts.SampleInterval = 30 * time.Second
+	}
+	if opts.Warmup < 0 || opts.Warmup >= opts.Duration {
+		opts.Warmup = opts.Duration / 4
+	}
+	if opts.Settle <= 0 {
+		opts.Settle = time.Minute
+	}
+	if opts.MaxHeapGrowth <= 0 {
+		opts.MaxHeapGrowth = 0.5
+	}
+	if opts.MaxUploadGrowth <= 0 {
+		opts.MaxUploadGrowth = 0.5
+	}
+	if opts.MaxGoroutineGrowth <= 0 {
+		opts.MaxGoroutineGrowth = 50
+	}
+	if opts.MaxErrorRate <= 0 {
+		opts.MaxErrorRate = 0.01
+	}
+	if opts.KeyPrefix == "" {
+		opts.KeyPrefix = "soak/"
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 2 * time.Minute}
+	}
+	return opts
+}
+
+func newRunner(ctx context.Context, opts Options) (*runner, error) {
+	audio, ok := samples.Get("webm")
+	if !ok {
+		return nil, fmt.Errorf("no webm sample embedded")
+	}
+	image, ok := samples.Get("jpeg")
+	if !ok {
+		return nil, fmt.Errorf("no jpeg sample embedded")
+	}
+
+	r := &runner{
+		opts:  opts,
+		audio: base64.StdEncoding.EncodeToString(audio.Data),
+		image: base64.StdEncoding.EncodeToString(image.Data),
+		stats: map[string]*OperationStats{},
+	}
+
+	status, err := r.do(ctx, http.MethodGet, "/health", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("API unreachable: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("GET /health: status %d", status)
+	}
+	// Upload operations and the upload map check need S3
+	status, err = r.do(ctx, http.MethodGet, "/upload/s3/stats", nil, nil)
+	r.s3 = err == nil && status == http.StatusOK
+	return r, nil
+}
+
+// client sends operations back to back until ctx is done
+func (r *runner) client(ctx context.Context, id int) {
+	rng := rand.New(rand.NewPCG(uint64(id), uint64(time.Now().UnixNano())))
+
+	total := 0
+	for _, op := range operations {
+		if !op.s3 || r.s3 {
+			total += op.weight
+		}
+	}
+
+	for ctx.Err() == nil {
+		pick := rng.IntN(total)
+		for _, op := range operations {
+			if op.s3 && !r.s3 {
+				continue
+			}
+			if pick -= op.weight; pick >= 0 {
+				continue
+			}
+			err := op.run(ctx, r, id)
+			// Requests cut off by the end of the run aren't the API's fault
+			if ctx.Err() == nil {
+				r.count(op.name, err)
+			}
+			break
+		}
+	}
+}
+
+func (r *runner) count(name string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.stats[name]
+	if !ok {
+		stats = &OperationStats{}
+		r.stats[name] = stats
+	}
+	stats.Requests++
+	if err != nil {
+		stats.Errors++
+	}
+}
+
+func (r *runner) operationStats() map[string]OperationStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make(map[string]OperationStats, len(r.stats))
+	for name, s := range r.stats {
+		stats[name] = *s
+	}
+	return stats
+}
+
+func (r *runner) totals() (requests, errors int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.stats {
+		requests += s.Requests
+		errors += s.Errors
+	}
+	return requests, errors
+}
+
+// sample reads /stats, and /upload/s3/stats while S3 is enabled
+func (r *runner) sample(ctx context.Context, phase string) (Sample, error) {
+	var stats models.StatsResponse
+	if err := r.expect(ctx, http.StatusOK, http.MethodGet, "/stats", nil, &stats); err != nil {
+		return Sample{}, err
+	}
+
+	s := Sample{
+		At:         time.Now().UTC(),
+		Phase:      phase,
+		Goroutines: stats.Runtime.Goroutines,
+		HeapAlloc:  stats.Runtime.HeapAllocBytes,
+		Uploads:    -1,
+	}
+	if r.s3 {
+		var uploads models.S3StatsResponse
+		if err := r.expect(ctx, http.StatusOK, http.MethodGet, "/upload/s3/stats", nil, &uploads); err != nil {
+			return Sample{}, err
+		}
+		s.Uploads = uploads.UploadManager.TotalUploads
+	}
+	s.Requests, s.Errors = r.totals()
+	return s, nil
+}
+
+func (r *runner) record(report *Report, s Sample) {
+	report.Samples = append(report.Samples, s)
+	if r.opts.Progress != nil {
+		r.opts.Progress(s)
+	}
+}
+
+// do sends a request, encoding body as JSON when it isn't nil, and decodes a
+// JSON answer into out when it isn't nil
+func (r *runner) do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.opts.APIURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := r.opts.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("%s %s: status %d, body isn't JSON: %w", method, path, resp.StatusCode, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// expect sends a request and fails unless it is answered with status
+func (r *runner) expect(ctx context.Context, status int, method, path string, body, out any) error {
+	got, err := r.do(ctx, method, path, body, out)
+	if err != nil {
+		return err
+	}
+	if got != status {
+		return fmt.Errorf("%s %s: status %d, want %d", method, path, got, status)
+	}
+	return nil
+}
+
+func convertAudio(ctx context.Context, r *runner, _ int) error {
+	return r.expect(ctx, http.StatusOK, http.MethodPost, "/convert/audio", map[string]any{"data": r.audio}, nil)
+}
+
+func convertImage(ctx context.Context, r *runner, _ int) error {
+	return r.expect(ctx, http.StatusOK, http.MethodPost, "/convert/image", map[string]any{"data": r.image}, nil)
+}
+
+// convertImageURL has the API download its own sample, through the downloader
+func convertImageURL(ctx context.Context, r *runner, _ int) error {
+	return r.expect(ctx, http.StatusOK, http.MethodPost, "/convert/image",
+		map[string]any{"data": r.opts.APIURL + "/samples/jpeg", "is_url": true}, nil)
+}
+
+// failingDownload keeps a host's circuit breaker busy; nothing listens on
+// port 9 of the loopback interface
+func failingDownload(ctx context.Context, r *runner, _ int) error {
+	status, err := r.do(ctx, http.MethodPost, "/convert/image",
+		map[string]any{"data": "http://127.0.0.1:9/soak.jpg", "is_url": true}, nil)
+	if err != nil {
+		return err
+	}
+	// Mock mode doesn't fetch URLs, so it answers 200 like a real download
+	switch status {
+	case http.StatusOK, http.StatusBadRequest, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return nil
+	}
+	return fmt.Errorf("failing download: status %d", status)
+}
+
+// batchJob starts an asynchronous batch job and leaves it to
+// BATCH_JOB_RETENTION, like clients that never fetch their results
+func batchJob(ctx context.Context, r *runner, _ int) error {
+	return r.expect(ctx, http.StatusAccepted, http.MethodPost, "/convert/batch/image/async",
+		[]map[string]any{{"data": r.image}, {"data": r.image}}, nil)
+}
+
+// uploadBase64 starts a background upload. Each client overwrites the same
+// key, so the bucket itself doesn't grow.
+func uploadBase64(ctx context.Context, r *runner, client int) error {
+	key := fmt.Sprintf("%sclient-%d.jpg", r.opts.KeyPrefix, client)
+	status, err := r.do(ctx, http.MethodPost, "/upload/s3/base64", map[string]any{
+		"data": "data:image/jpeg;base64," + r.image, "key": key, "on_collision": "overwrite",
+	}, nil)
+	if err != nil {
+		return err
+	}
+	// 429 is the upload slots being taken, which clients are expected to see
+	if status != http.StatusAccepted && status != http.StatusTooManyRequests {
+		return fmt.Errorf("upload: status %d", status)
+	}
+	return nil
+}
+
+// evaluate runs the leak checks over the samples of a run; settleErr is the
+// failure of the final sample, if any
+func evaluate(opts Options, report *Report, settleErr error) []Check {
+	var idle, settled *Sample
+	var steady []Sample
+	for i := range report.Samples {
+		switch s := &report.Samples[i]; s.Phase {
+		case "idle":
+			idle = s
+		case "steady":
+			steady = append(steady, *s)
+		case "settled":
+			settled = s
+		}
+	}
+
+	checks := []Check{goroutineCheck(opts, idle, settled, settleErr)}
+	checks = append(checks, growthCheck("heap", steady, opts.MaxHeapGrowth, 16<<20, func(s Sample) float64 {
+		return float64(s.HeapAlloc)
+	}))
+	if idle != nil && idle.Uploads >= 0 {
+		// A few records of slack, for uploads finishing around the edges
+		checks = append(checks, growthCheck("upload_map", steady, opts.MaxUploadGrowth, 10, func(s Sample) float64 {
+			return float64(s.Uploads)
+		}))
+	}
+
+	var requests, errors int64
+	for _, stats := range report.Operations {
+		requests += stats.Requests
+		errors += stats.Errors
+	}
+	check := Check{Name: "error_rate", Passed: requests > 0}
+	if requests > 0 {
+		rate := float64(errors) / float64(requests)
+		check.Passed = rate <= opts.MaxErrorRate
+		check.Detail = fmt.Sprintf("%d of %d requests failed (%.2f%%, max %.2f%%)", errors, requests, rate*100, opts.MaxErrorRate*100)
+	} else {
+		check.Detail = "no requests completed"
+	}
+	return append(checks, check)
+}
+
+// goroutineCheck compares the idle API before the load with the idle API
+// after it: goroutines left over are leaked
+func goroutineCheck(opts Options, idle, settled *Sample, settleErr error) Check {
+	check := Check{Name: "goroutines"}
+	switch {
+	case settleErr != nil:
+		check.Detail = fmt.Sprintf("final sample failed: %v", settleErr)
+	case idle == nil || settled == nil:
+		check.Detail = "missing idle sample"
+	default:
+		growth := settled.Goroutines - idle.Goroutines
+		check.Passed = growth <= opts.MaxGoroutineGrowth
+		check.Detail = fmt.Sprintf("%d idle before, %d after %s settling (+%d, max +%d)",
+			idle.Goroutines, settled.Goroutines, opts.Settle, growth, opts.MaxGoroutineGrowth)
+	}
+	return check
+}
+
+// growthCheck compares the floor of a metric over the first quarter of the
+// steady state with its floor over the last quarter. Floors skip the peaks
+// of garbage waiting for collection and of bursts, so what is left is
+// growth that outlives both. slack is absolute headroom for small values.
+func growthCheck(name string, steady []Sample, maxGrowth, slack float64, metric func(Sample) float64) Check {
+	check := Check{Name: name}
+	if len(steady) < 4 {
+		check.Detail = fmt.Sprintf("%d steady samples, need at least 4: run longer or sample more often", len(steady))
+		return check
+	}
+
+	window := len(steady) / 4
+	first := floor(steady[:window], metric)
+	last := floor(steady[len(steady)-window:], metric)
+	limit := first*(1+maxGrowth) + slack
+
+	check.Passed = last <= limit
+	check.Detail = fmt.Sprintf("floor %.0f in the first quarter, %.0f in the last (limit %.0f)", first, last, limit)
+	return check
+}
+
+func floor(samples []Sample, metric func(Sample) float64) float64 {
+	lowest := metric(samples[0])
+	for _, s := range samples[1:] {
+		lowest = min(lowest, metric(s))
+	}
+	return lowest
+}
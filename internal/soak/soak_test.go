@@ -0,0 +1,48 @@
+package soak
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestSoak loads the API at SOAK_API_URL for SOAK_DURATION (default 30m) and
+// fails on any growth check. Skipped when unset or with -short; run it with
+// -timeout 0 or a timeout above the duration.
+func TestSoak(t *testing.T) {
+	apiURL := os.Getenv("SOAK_API_URL")
+	if apiURL == "" {
+		t.Skip("SOAK_API_URL not set")
+	}
+	if testing.Short() {
+		t.Skip("soak run skipped in short mode")
+	}
+
+	duration := 30 * time.Minute
+	if value := os.Getenv("SOAK_DURATION"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			t.Fatalf("SOAK_DURATION: %v", err)
+		}
+		duration = parsed
+	}
+
+	opts := withDefaults(Options{APIURL: apiURL, Duration: duration, Warmup: -1})
+	if deadline, ok := t.Deadline(); ok && time.Until(deadline) < opts.Duration+opts.Settle+time.Minute {
+		t.Fatalf("test timeout ends before the %s run; use -timeout 0", opts.Duration+opts.Settle)
+	}
+	opts.Progress = func(s Sample) {
+		t.Logf("%-8s goroutines=%-5d heap=%-6.1fMB uploads=%-6d requests=%d errors=%d",
+			s.Phase, s.Goroutines, float64(s.HeapAlloc)/(1<<20), s.Uploads, s.Requests, s.Errors)
+	}
+
+	report := Run(t.Context(), opts)
+	if report.Error != "" {
+		t.Fatalf("soak run failed to start: %s", report.Error)
+	}
+	for _, check := range report.Checks {
+		if !check.Passed {
+			t.Errorf("%s: %s", check.Name, check.Detail)
+		}
+	}
+}
@@ -0,0 +1,701 @@
+#!/bin/bash
+
+# Contract smoke test - exercises every route of the built binary against
+# MOCK_MODE servers and asserts status codes and response shapes. Optional:
+# handler behaviour is covered by the Go tests (internal/handlers/*_test.go),
+# which run under `make test` and CI; this checks the wiring of a real build.
+
+set -euo pipefail
+
+# Colors
+RED='\033[0;31m'
+GREEN='\033[0;32m'
+YELLOW='\033[1;33m'
+BLUE='\033[0;34m'
+NC='\033[0m'
+
+# Configuration
+BINARY=${BINARY:-"./media-converter"}
+BASE_PORT=${BASE_PORT:-18080}
+MAIN_URL="http://localhost:${BASE_PORT}"
+TIMEOUT_URL="http://localhost:$((BASE_PORT + 1))"
+NO_S3_URL="http://localhost:$((BASE_PORT + 2))"
+TIERS_URL="http://localhost:$((BASE_PORT + 3))"
+VERIFY_URL="http://localhost:$((BASE_PORT + 4))"
+READ_ONLY_URL="http://localhost:$((BASE_PORT + 5))"
+
+PASSED=0
+FAILED=0
+PIDS=()
+WORKDIR=$(mktemp -d)
+
+for tool in curl jq; do
+    if ! command -v "$tool" &> /dev/null; then
+        echo -e "${RED}${tool} is required to run the contract tests.${NC}"
+        exit 1
+    fi
+done
+
+cleanup() {
+    for pid in "${PIDS[@]}"; do
+        kill "$pid" 2> /dev/null || true
+    done
+    rm -rf "$WORKDIR"
+}
+trap cleanup EXIT
+
+# Test data
+AUDIO_BASE64="UklGRiQAAABXQVZFZm10IBAAAAABAAEARKwAAIhYAQACABAAZGF0YQAAAAA="
+IMAGE_BASE64="/9j/4AAQSkZJRgABAQEAYABgAAD/2wBDAP/bAEMAAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQH/wAARCAABAAEDASIAAhEBAxEB/8QAFQABAQAAAAAAAAAAAAAAAAAAAAv/xAAUEAEAAAAAAAAAAAAAAAAAAAAA/9oADAMBAAIRAxEAPwCwAA8A/9k="
+# IMAGE_BASE64 with an EXIF block holding a GPS IFD (GPSLatitudeRef "N");
+# outputs carrying EXIF start with an APP1 segment, "RXhpZgAA" in base64
+GPS_IMAGE_BASE64="/9j/4AAQSkZJRgABAQEAYABgAAD/4QA0RXhpZgAASUkqAAgAAAABACWIBAABAAAAGgAAAAAAAAABAAEAAgACAAAATgAAAAAAAAD/2wBDAP/bAEMAAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQH/wAARCAABAAEDASIAAhEBAxEB/8QAFQABAQAAAAAAAAAAAAAAAAAAAAv/xAAUEAEAAAAAAAAAAAAAAAAAAAAA/9oADAMBAAIRAxEAPwCwAA8A/9k="
+
+start_server() {
+    local port=$1
+    shift
+    env PORT="$port" MOCK_MODE=true ENABLE_SWAGGER=false "$@" "$BINARY" > "${WORKDIR}/server-${port}.log" 2>&1 &
+    PIDS+=($!)
+
+    for _ in $(seq 1 50); do
+        if curl -s -o /dev/null "http://localhost:${port}/health"; then
+            return 0
+        fi
+        sleep 0.2
+    done
+
+    echo -e "${RED}Server on port ${port} did not start:${NC}"
+    cat "${WORKDIR}/server-${port}.log"
+    exit 1
+}
+
+# request METHOD URL [curl args...] - stores status in STATUS and body in BODY
+request() {
+    local method=$1 url=$2
+    shift 2
+    STATUS=$(curl -s -D "${WORKDIR}/headers" -o "${WORKDIR}/body" -w '%{http_code}' -X "$method" "$url" "$@")
+    BODY=$(cat "${WORKDIR}/body")
+}
+
+# expect_header NAME HEADER VALUE - checks a header of the last response
+expect_header() {
+    local name=$1 header=$2 expected=$3 actual
+    actual=$(grep -i "^${header}:" "${WORKDIR}/headers" | head -n 1 | cut -d: -f2- | tr -d ' \r')
+
+    if [ "$actual" != "$expected" ]; then
+        echo -e "${RED}✗ ${name}: expected ${header} ${expected}, got '${actual}'${NC}"
+        FAILED=$((FAILED + 1))
+        return
+    fi
+
+    echo -e "${GREEN}✓ ${name}${NC}"
+    PASSED=$((PASSED + 1))
+}
+
+# expect NAME STATUS [jq assertions...]
+expect() {
+    local name=$1 expected=$2
+    shift 2
+
+    if [ "$STATUS" != "$expected" ]; then
+        echo -e "${RED}✗ ${name}: expected HTTP ${expected}, got ${STATUS}${NC}"
+        echo "  body: ${BODY:0:300}"
+        FAILED=$((FAILED + 1))
+        return
+    fi
+
+    for assertion in "$@"; do
+        if ! echo "$BODY" | jq -e "$assertion" > /dev/null 2>&1; then
+            echo -e "${RED}✗ ${name}: assertion failed: ${assertion}${NC}"
+            echo "  body: ${BODY:0:300}"
+            FAILED=$((FAILED + 1))
+            return
+        fi
+    done
+
+    echo -e "${GREEN}✓ ${name}${NC}"
+    PASSED=$((PASSED + 1))
+}
+
+# expect_archive NAME [jq assertions...] - checks the last response is a
+# tar.zst archive and runs the assertions on its manifest.json (the
+# Zstandard magic number only, when zstd isn't installed)
+expect_archive() {
+    local name=$1 magic
+    shift
+    magic=$(head -c 4 "${WORKDIR}/body" | od -An -tx1 | tr -d ' \n')
+
+    if [ "$STATUS" != "200" ] || [ "$magic" != "28b52ffd" ]; then
+        echo -e "${RED}✗ ${name}: expected a tar.zst archive, got HTTP ${STATUS} starting with ${magic}${NC}"
+        FAILED=$((FAILED + 1))
+        return
+    fi
+
+    if command -v zstd &> /dev/null; then
+        BODY=$(zstd -dc "${WORKDIR}/body" | tar -xOf - manifest.json)
+        expect "$name" 200 "$@"
+        return
+    fi
+
+    echo -e "${GREEN}✓ ${name}${NC}"
+    PASSED=$((PASSED + 1))
+}
+
+json() {
+    request POST "$1" -H "Content-Type: application/json" -d "$2"
+}
+
+echo -e "${BLUE}========================================${NC}"
+echo -e "${GREEN}WhatsApp Media Converter Contract Tests${NC}"
+echo -e "${BLUE}========================================${NC}"
+
+if [ ! -x "$BINARY" ]; then
+    echo -e "${RED}Binary ${BINARY} not found. Run 'make build' first or set BINARY.${NC}"
+    exit 1
+fi
+
+start_server "$BASE_PORT" IMAGE_TIMEOUT=45s PRESETS_FILE="$(dirname "$0")/../presets.example.yaml" S3_VERIFY_UPLOADS=true S3_SOFT_DELETE=true \
+    ADMIN_TOKEN=contract-admin S3_MIGRATION_PROVIDERS=backup S3_MIGRATION_BACKUP_PROVIDER=mock DOWNLOAD_ALLOW_PRIVATE_HOSTS=true
+start_server "$((BASE_PORT + 1))" REQUEST_TIMEOUT=1ns
+start_server "$((BASE_PORT + 2))" S3_ENABLED=false ENABLE_WEB_UI=false \
+    AUDIO_CANDIDATE_ENCODER_ARGS="-frame_duration 40" AUDIO_CANDIDATE_PERCENT=100 \
+    POST_PROCESSORS_PNG="cat | cat" \
+    IMAGE_METADATA_POLICY=strip_gps_only REQUEST_RECORDING=true REQUEST_RECORDING_BODIES=true REQUEST_RECORDING_DIR="${WORKDIR}/recordings" ADMIN_TOKEN=contract-admin AUDIT_STAMP_SECRET=contract-stamp
+printf 'tiers:\n  free:\n    rate_limit: 2\n    max_file_size: 128\n' > "${WORKDIR}/tiers.yaml"
+start_server "$((BASE_PORT + 3))" TIERS_FILE="${WORKDIR}/tiers.yaml" API_KEY_TIERS=contract-pro=pro
+start_server "$((BASE_PORT + 4))" CHAOS_ENABLED=true CHAOS_S3_TRUNCATE_PERCENT=100 S3_VERIFY_UPLOADS=true S3_VERIFY_RETRIES=1 IMAGE_METADATA_POLICY=retain
+start_server "$((BASE_PORT + 5))" READ_ONLY=true
+
+# Metadata and monitoring
+echo -e "\n${YELLOW}Metadata & monitoring${NC}"
+request GET "${MAIN_URL}/api"
+expect "GET /api" 200 '.name and .version and (.api_versions | type == "array") and (.endpoints | type == "object")' '.endpoints.inspect == "/inspect"'
+request GET "${MAIN_URL}/api" -H "Accept-Language: pt-PT, en;q=0.5"
+expect "GET /api in Portuguese" 200 '.language == "pt-BR"' '(.description | test("WhatsApp"))'
+request GET "${MAIN_URL}/capabilities"
+expect "GET /capabilities" 200 '.tools | has("ffmpeg")' '.sandbox.mode == "none"' '.mock_mode == true' '.s3_enabled == true' '.features.video == false'
+request GET "${MAIN_URL}/version"
+expect "GET /version" 200 '.version' '(.go_version | startswith("go"))' '(.tool_versions | type == "object")' '.features.video == false'
+request GET "${MAIN_URL}/samples"
+expect "GET /samples" 200 '(.samples | map(.type)) == ["jpeg", "mp3", "webm"]' '.samples[0].url == "/samples/jpeg"'
+request GET "${MAIN_URL}/v1/samples/jpg"
+expect "GET /v1/samples/jpg" 200
+expect_header "GET /v1/samples/jpg content type" Content-Type image/jpeg
+request GET "${MAIN_URL}/samples/mp3?encoding=base64"
+expect "GET /samples/mp3 as base64" 200 '.type == "mp3"' '(.data | startswith("data:audio/mpeg;base64,"))' '.endpoint == "/convert/audio"'
+request GET "${MAIN_URL}/samples/flac"
+expect "GET /samples unknown type" 404 '.code == "sample_not_found"'
+
+request GET "${MAIN_URL}/presets"
+expect "GET /presets" 200 '.count == 4' '(.presets | map(.name)) == ["sticker", "whatsapp_image", "whatsapp_status_video", "whatsapp_voice"]' '.presets[1].options.max_width == 1600'
+request GET "${MAIN_URL}/health"
+expect "GET /health" 200 '.status == "healthy"' '.timestamp' '.audio.success_rate' '.image | has("vips_available")'
+request GET "${MAIN_URL}/stats"
+expect "GET /stats" 200 '.audio | has("total_conversions")' '.image | has("vips_conversions")' '.timestamp' '.temp_files.spill_enabled == false' '.temp_files | has("swept")' '.conversion_cache.max_bytes == 67108864' '.queue.fair_queuing == true' '.queue.waiting_by_tenant == {}' '.queue.workers > 0' '.downloads.circuit_breaker == true' '.downloads.circuits == []' '.runtime.goroutines > 0' '.runtime.heap_alloc_bytes > 0' '. | has("post_processors") | not'
+request GET "${NO_S3_URL}/stats"
+expect "GET /stats post-processors" 200 '.post_processors | length == 2' '.post_processors[0].format == "png"' '.post_processors[0].command == "cat"' '.post_processors[0].runs == 0'
+request GET "${MAIN_URL}/cache/stats"
+expect "GET /cache/stats" 200 '.conversion.max_object_size == 8388608' '.conversion.ttl_seconds == 3600' '.media.max_bytes' '.redis == null'
+request GET "${MAIN_URL}/v1/health"
+expect "GET /v1/health" 200 '.status == "healthy"'
+request GET "${MAIN_URL}/health" -H "X-API-Version: 99"
+expect "Unsupported API version" 400 '.error == "Unsupported API version"'
+request GET "${MAIN_URL}/does-not-exist"
+expect "Unknown route" 404 '.error == "Endpoint not found"' '.path == "/does-not-exist"'
+request GET "${MAIN_URL}/does-not-exist" -H "Accept-Language: es"
+expect "Unknown route in Spanish" 404 '.error == "Endpoint no encontrado"' '.path == "/does-not-exist"'
+expect_header "Error Content-Language" Content-Language es
+
+# Single conversions
+echo -e "\n${YELLOW}Single conversions${NC}"
+json "${MAIN_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\"}"
+expect "POST /convert/audio" 200 '.data | startswith("data:audio/ogg;codecs=opus;base64,")' '.mime_type == "audio/ogg;codecs=opus"' '.duration | type == "number"' '.size > 0' '.skipped == false' '.input.mime == "audio/wav"' '.input.kind == "audio"'
+json "${MAIN_URL}/convert/audio" "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /convert/audio with an image" 415 '.code == "unsupported_input"'
+json "${MAIN_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\",\"data_uri\":false}"
+expect "POST /convert/audio plain base64" 200 '(.data | startswith("data:") | not)' '.mime_type == "audio/ogg;codecs=opus"'
+json "${MAIN_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\",\"include_waveform\":true}"
+expect "POST /convert/audio with waveform" 200 '(.waveform | @base64d | length) == 64'
+json "${MAIN_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\",\"target_size_mb\":1}"
+expect "POST /convert/audio with target_size_mb" 200 '.bitrate == 128' '.size <= 1048576'
+json "${MAIN_URL}/convert/audio" '{"data":""}'
+expect "POST /convert/audio missing data" 400 '.error == "Missing '"'"'data'"'"' field"'
+json "${MAIN_URL}/convert/audio" '{"data":'
+expect "POST /convert/audio malformed JSON" 400 '.error == "Invalid request body"'
+json "${MAIN_URL}/convert/audio" '{"data":"%%%"}'
+expect "POST /convert/audio invalid base64" 500 '.error == "Conversion failed"' '.details'
+printf 'RIFF' > "${WORKDIR}/sample.wav"
+request POST "${MAIN_URL}/convert/audio" -F "file=@${WORKDIR}/sample.wav"
+expect "POST /convert/audio multipart" 200 '.data | startswith("data:audio/ogg")'
+request POST "${MAIN_URL}/convert/audio" -F "other=value"
+expect "POST /convert/audio multipart without file" 400 '.error == "Missing file"'
+request POST "${MAIN_URL}/convert/audio" -F "file=@${WORKDIR}/sample.wav" -F "target_size_mb=big"
+expect "POST /convert/audio multipart invalid target_size_mb" 400 '.error == "Invalid target_size_mb value"'
+request POST "${MAIN_URL}/convert/audio" -H "Content-Type: application/json" -H "Accept-Language: pt-BR" -d '{"data":""}'
+expect "POST /convert/audio error in Portuguese" 400 '.error == "Campo '"'"'data'"'"' ausente"'
+request POST "${MAIN_URL}/convert/audio" -H "Accept-Language: es;q=0.8, pt-BR" -F "file=@${WORKDIR}/sample.wav" -F "target_size_mb=big"
+expect "POST /convert/audio templated error in Portuguese" 400 '.error == "Valor inválido para target_size_mb"'
+request POST "${MAIN_URL}/convert/audio" -H "Content-Type: application/json" -H "Accept-Language: es" -d "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /convert/audio error code stays stable" 415 '.error == "Entrada no admitida"' '.code == "unsupported_input"'
+
+# Inspection (validation only: ffprobe isn't mocked)
+json "${MAIN_URL}/inspect" '{"data":""}'
+expect "POST /inspect missing data" 400 '.error == "Missing '"'"'data'"'"' field"'
+request POST "${MAIN_URL}/inspect" -F "other=value"
+expect "POST /inspect multipart without file" 400 '.error == "Missing file"'
+
+# Fingerprints (images are hashed in process, so mock mode computes them)
+request GET "${MAIN_URL}/samples/jpeg?encoding=base64"
+SAMPLE_JPEG=$(echo "$BODY" | jq -r '.data')
+json "${MAIN_URL}/inspect/fingerprint" "{\"data\":\"${SAMPLE_JPEG}\"}"
+expect "POST /inspect/fingerprint image" 200 '.kind == "image"' '.algorithm == "phash"' '.hash == "a05bf0254f654f67"' '(.sha256 | length) == 64' '. | has("raw") | not'
+json "${MAIN_URL}/inspect/fingerprint" '{"data":""}'
+expect "POST /inspect/fingerprint missing data" 400 '.error == "Missing '"'"'data'"'"' field"'
+json "${MAIN_URL}/convert/image" "{\"data\":\"${SAMPLE_JPEG}\",\"fingerprint\":true}"
+expect "POST /convert/image fingerprint" 200 '.fingerprint.algorithm == "phash"' '.fingerprint.hash == "a05bf0254f654f67"'
+json "${MAIN_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\",\"fingerprint\":true}"
+expect "POST /convert/audio fingerprint" 200 '.fingerprint.algorithm == "chromaprint"' '(.fingerprint.hash | length) == 8' '(.fingerprint.sha256 | length) == 64'
+
+json "${MAIN_URL}/convert/image" "{\"data\":\"${IMAGE_BASE64}\",\"quality\":80}"
+expect "POST /convert/image" 200 '.data | startswith("data:image/jpeg;base64,")' '.mime_type == "image/jpeg"' '.width > 0' '.height > 0' '.size > 0' '.skipped == false' '.input.mime == "image/jpeg"' '.metadata.policy == "strip_all"' '.metadata.exif == false'
+json "${MAIN_URL}/convert/image" "{\"data\":\"${GPS_IMAGE_BASE64}\"}"
+expect "POST /convert/image strips EXIF" 200 '.metadata.exif and .metadata.gps' '.metadata.retained == false' '.metadata.gps_removed == true' '(.data | contains("RXhpZgAA") | not)'
+json "${NO_S3_URL}/convert/image" "{\"data\":\"${GPS_IMAGE_BASE64}\"}"
+expect "POST /convert/image strips GPS only" 200 '.metadata.policy == "strip_gps_only"' '.metadata.retained == true' '.metadata.gps_removed == true' '(.data | contains("RXhpZgAA"))'
+json "${VERIFY_URL}/convert/image" "{\"data\":\"${GPS_IMAGE_BASE64}\"}"
+expect "POST /convert/image retains EXIF" 200 '.metadata.policy == "retain"' '.metadata.retained == true' '.metadata.gps == true' '.metadata.gps_removed == false'
+json "${MAIN_URL}/convert/image" "{\"data\":\"${AUDIO_BASE64}\"}"
+expect "POST /convert/image with audio" 415 '.code == "unsupported_input"'
+expect_header "POST /convert/image route timeout" X-Request-Timeout 45
+expect_header "POST /convert/image queue depth" X-Queue-Depth 0
+expect_header "POST /convert/image active workers" X-Active-Workers 0
+json "${MAIN_URL}/convert/image" "{\"data\":\"${IMAGE_BASE64}\",\"preset\":\"whatsapp_image\"}"
+expect "POST /convert/image with a preset" 200 '(.jpeg_thumbnail | startswith("/9j/"))'
+
+json "${MAIN_URL}/convert/image" "{\"data\":\"${IMAGE_BASE64}\",\"preset\":\"whatsapp_voice\"}"
+expect "POST /convert/image with an audio preset" 400 '.code == "unknown_preset"'
+
+json "${MAIN_URL}/convert/image" "{\"data\":\"${IMAGE_BASE64}\",\"generate_thumbnail\":true}"
+expect "POST /convert/image with thumbnail" 200 '(.jpeg_thumbnail | startswith("/9j/"))'
+json "${MAIN_URL}/convert/image?debug_timings=true" "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /convert/image with debug_timings" 200 '(.timings | has("download_ms") and has("decode_ms") and has("probe_ms") and has("encode_ms") and has("upload_ms"))' '.timings.total_ms >= 0'
+json "${MAIN_URL}/convert/image" "{\"data\":\"${IMAGE_BASE64}\",\"quality\":80,\"max_file_size_kb\":100}"
+expect "POST /convert/image with max_file_size_kb" 200 '.encoded_quality == 80' '.size <= 102400'
+request POST "${MAIN_URL}/convert/image" -F "file=@${WORKDIR}/sample.wav" -F "data_uri=false"
+expect "POST /convert/image multipart plain base64" 200 '(.data | startswith("data:") | not)' '.mime_type == "image/jpeg"'
+json "${MAIN_URL}/convert/image" '{"data":"https://example.com/a.png","is_url":true}'
+expect "POST /convert/image from URL" 200 '.data | startswith("data:image/jpeg")'
+request POST "${MAIN_URL}/convert/image" -F "file=@${WORKDIR}/sample.wav" -F "quality=abc"
+expect "POST /convert/image multipart invalid quality" 400 '.error == "Invalid quality value"'
+
+# Usage accounting
+echo -e "\n${YELLOW}Usage accounting${NC}"
+request POST "${MAIN_URL}/convert/audio" -H "Content-Type: application/json" -H "X-API-Key: contract-key" -d "{\"data\":\"${AUDIO_BASE64}\"}"
+expect_header "POST /convert/audio CPU seconds" X-CPU-Seconds 0.000
+request GET "${MAIN_URL}/usage" -H "X-API-Key: contract-key"
+expect "GET /usage own key" 200 '.since' '(.tenants | length) == 1' '.tenants[0].conversions == 1' '(.tenants[0].tenant | startswith("key_"))' '.tenants[0].cpu_seconds == 0'
+request GET "${MAIN_URL}/usage" -H "X-Admin-Token: wrong"
+expect "GET /usage invalid admin token" 401 '.error == "Invalid admin token"'
+
+# API key tiers (free tier limited to 2 requests per minute and 128 bytes)
+echo -e "\n${YELLOW}API key tiers${NC}"
+request GET "${TIERS_URL}/capabilities"
+expect "GET /capabilities tier" 200 '.tier.name == "free"' '.tier.rate_limit == 2' '.tier.max_file_size == 128' '.tier.max_batch_jobs == 1'
+expect_header "Tier header" X-API-Tier free
+json "${TIERS_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\"}"
+expect "POST /convert/audio within the tier rate limit" 200
+expect_header "Rate limit header" X-RateLimit-Remaining 1
+json "${TIERS_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\"}"
+json "${TIERS_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\"}"
+expect "POST /convert/audio over the tier rate limit" 429 '.code == "rate_limited"'
+expect_header "Rate limit Retry-After" Retry-After 30
+expect_header "Rate limited queue depth" X-Queue-Depth 0
+request GET "${TIERS_URL}/health"
+expect "GET /health not rate limited" 200
+request POST "${TIERS_URL}/convert/image" -H "Content-Type: application/json" -H "X-API-Key: contract-free" -d "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /convert/image over the tier file size" 413 '.code == "tier_file_too_large"'
+request POST "${TIERS_URL}/convert/image" -H "Content-Type: application/json" -H "X-API-Key: contract-pro" -d "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /convert/image as pro" 200
+expect_header "Pro tier header" X-API-Tier pro
+
+# Batch conversions
+echo -e "\n${YELLOW}Batch conversions${NC}"
+json "${MAIN_URL}/convert/batch/audio" "[{\"data\":\"${AUDIO_BASE64}\"},{\"data\":\"${AUDIO_BASE64}\"}]"
+expect "POST /convert/batch/audio" 200 '.count == 2' '(.results | length) == 2' '.results[0].data | startswith("data:audio/ogg")'
+expect_header "POST /convert/batch/audio concurrency" X-Batch-Concurrency 2
+json "${MAIN_URL}/convert/batch/audio?concurrency=1&item_timeout=90s" "[{\"data\":\"${AUDIO_BASE64}\"},{\"data\":\"${AUDIO_BASE64}\"}]"
+expect "POST /convert/batch/audio with options" 200 '.count == 2'
+expect_header "POST /convert/batch/audio lowered concurrency" X-Batch-Concurrency 1
+expect_header "POST /convert/batch/audio capped item timeout" X-Batch-Item-Timeout 60
+json "${MAIN_URL}/convert/batch/audio?concurrency=0" "[{\"data\":\"${AUDIO_BASE64}\"}]"
+expect "POST /convert/batch/audio invalid options" 400 '.code == "invalid_batch_options"'
+json "${MAIN_URL}/convert/batch/audio" '[]'
+expect "POST /convert/batch/audio empty" 400 '.error == "Empty batch"'
+ELEVEN=$(jq -nc --arg d "$AUDIO_BASE64" '[range(11) | {data: $d}]')
+json "${MAIN_URL}/convert/batch/audio" "$ELEVEN"
+expect "POST /convert/batch/audio too large" 400 '.error == "Batch too large"' '.code == "batch_too_large"'
+json "${MAIN_URL}/convert/batch/image" "[{\"data\":\"${IMAGE_BASE64}\"}]"
+expect "POST /convert/batch/image" 200 '.count == 1' '.results[0].width > 0'
+json "${MAIN_URL}/convert/batch/image?archive=tar.zst" "[{\"data\":\"${IMAGE_BASE64}\"},{\"data\":\"${IMAGE_BASE64}\"}]"
+expect_archive "POST /convert/batch/image?archive=tar.zst" '.kind == "image"' '.count == 2' '.completed == 2' '.items[1].file == "001.jpg"' '.items[0].width > 0'
+expect_header "POST /convert/batch/image archive content type" Content-Type application/zstd
+json "${MAIN_URL}/convert/batch/audio?archive=zip" "[{\"data\":\"${AUDIO_BASE64}\"}]"
+expect "POST /convert/batch/audio invalid archive" 400 '.code == "invalid_batch_options"'
+json "${MAIN_URL}/convert/batch/image" '{"data":"not-an-array"}'
+expect "POST /convert/batch/image invalid body" 400 '.error == "Invalid request body"'
+
+# Asynchronous batch jobs
+echo -e "\n${YELLOW}Asynchronous batch jobs${NC}"
+json "${MAIN_URL}/convert/batch/audio/async" "[{\"data\":\"${AUDIO_BASE64}\"},{\"data\":\"\"}]"
+expect "POST /convert/batch/audio/async" 202 '.job_id' '.kind == "audio"' '.total == 2' '(.items | length) == 2' '.status_url == "/convert/batch/jobs/\(.job_id)"'
+JOB_URL=$(echo "$BODY" | jq -r '.status_url')
+sleep 0.3
+request GET "${MAIN_URL}${JOB_URL}"
+expect "GET /convert/batch/jobs/:id" 200 '.status == "completed"' '.completed == 1' '.failed == 1' '.progress == 100' '.items[0].result_url' '.items[1].error' '.report_url == "\(.status_url)/report"' '.archive_url == "\(.status_url)/archive"'
+request GET "${MAIN_URL}${JOB_URL}/items/0"
+expect "GET /convert/batch/jobs/:id/items/:index" 200 '.data | startswith("data:audio/ogg")'
+request GET "${MAIN_URL}${JOB_URL}/items/1"
+expect "GET /convert/batch/jobs/:id/items/:index failed" 500 '.error == "Conversion failed"' '.details'
+request GET "${MAIN_URL}${JOB_URL}/items/2"
+expect "GET /convert/batch/jobs/:id/items/:index unknown" 404 '.error == "Batch item not found"'
+request GET "${MAIN_URL}${JOB_URL}/report"
+expect "GET /convert/batch/jobs/:id/report" 200 '[., inputs] | length == 2' '[., inputs] | .[0].status == "completed" and .[0].input == "base64" and .[0].output_size > 0 and (.[0].result_url | endswith("/items/0"))' '[., inputs] | .[1].status == "failed" and .[1].error != null'
+expect_header "GET /convert/batch/jobs/:id/report content type" Content-Type application/x-ndjson
+request GET "${MAIN_URL}${JOB_URL}/report?format=csv"
+expect_header "GET /convert/batch/jobs/:id/report?format=csv" Content-Type "text/csv;charset=utf-8"
+request GET "${MAIN_URL}${JOB_URL}/archive"
+expect_archive "GET /convert/batch/jobs/:id/archive" '.job_id' '.completed == 1' '.failed == 1' '.items[0].file == "000.ogg"' '.items[1].file == null' '.items[1].error'
+request GET "${MAIN_URL}${JOB_URL}/report?format=xml"
+expect "GET /convert/batch/jobs/:id/report invalid format" 400 '.code == "unsupported_report_format"'
+request DELETE "${MAIN_URL}${JOB_URL}"
+expect "DELETE /convert/batch/jobs/:id" 200 '.success == true'
+request GET "${MAIN_URL}${JOB_URL}"
+expect "GET /convert/batch/jobs/:id deleted" 404 '.error == "Batch job not found"'
+json "${MAIN_URL}/v1/convert/batch/image/async?concurrency=1" "[{\"data\":\"${IMAGE_BASE64}\"}]"
+expect "POST /v1/convert/batch/image/async" 202 '.kind == "image"' '.status_url | startswith("/v1/convert/batch/jobs/")'
+expect_header "POST /convert/batch/image/async concurrency" X-Batch-Concurrency 1
+JOB_URL=$(echo "$BODY" | jq -r '.status_url')
+sleep 0.5
+request GET "${MAIN_URL}${JOB_URL}"
+expect "GET /convert/batch/jobs/:id report not requested" 200 '.status == "completed"' '.report == null'
+json "${MAIN_URL}/convert/batch/image/async?report=csv" "[{\"data\":\"${IMAGE_BASE64}\"}]"
+JOB_URL=$(echo "$BODY" | jq -r '.status_url')
+sleep 0.5
+request GET "${MAIN_URL}${JOB_URL}"
+expect "GET /convert/batch/jobs/:id uploaded report" 200 '.report.status == "uploaded"' '.report.format == "csv"' '.report.key == "batch-reports/\(.job_id).csv"' '.report.url'
+json "${MAIN_URL}/convert/batch/image/async?archive=tar.zst" "[{\"data\":\"${IMAGE_BASE64}\"}]"
+JOB_URL=$(echo "$BODY" | jq -r '.status_url')
+sleep 0.5
+request GET "${MAIN_URL}${JOB_URL}"
+expect "GET /convert/batch/jobs/:id uploaded archive" 200 '.archive.status == "uploaded"' '.archive.format == "tar.zst"' '.archive.key == "batch-reports/\(.job_id).tar.zst"' '.archive.url'
+json "${MAIN_URL}/convert/batch/image/async?report=pdf" "[{\"data\":\"${IMAGE_BASE64}\"}]"
+expect "POST /convert/batch/image/async invalid report" 400 '.code == "invalid_batch_options"'
+json "${MAIN_URL}/convert/batch/image/async" '[]'
+expect "POST /convert/batch/image/async empty" 400 '.error == "Empty batch"'
+
+# Timeouts
+echo -e "\n${YELLOW}Timeouts${NC}"
+json "${TIMEOUT_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\"}"
+expect "POST /convert/audio timeout" 408 '.error == "Request timeout"'
+expect_header "POST /convert/audio timeout header" X-Request-Timeout 0.000000001
+json "${TIMEOUT_URL}/convert/image" "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /convert/image timeout" 408 '.error == "Request timeout"'
+
+# S3 uploads
+echo -e "\n${YELLOW}S3 uploads${NC}"
+json "${MAIN_URL}/upload/s3/base64" "{\"data\":\"data:image/jpeg;base64,${IMAGE_BASE64}\",\"key\":\"contract/sample.jpg\"}"
+expect "POST /upload/s3/base64" 202 '.success == true' '.upload_id' '.message'
+UPLOAD_ID=$(echo "$BODY" | jq -r '.upload_id')
+sleep 0.3
+request GET "${MAIN_URL}/upload/s3/status/${UPLOAD_ID}"
+expect "GET /upload/s3/status/:id" 200 '.upload_id' '.status == "completed"' '.result.key == "contract/sample.jpg"' '.result.url'
+json "${MAIN_URL}/upload/s3/base64" "{\"data\":\"${IMAGE_BASE64}\",\"key\":\"contract/sniffed\"}"
+expect "POST /upload/s3/base64 without a type" 202 '.success == true'
+sleep 0.3
+request GET "${MAIN_URL}/upload/s3/object/contract/sniffed"
+expect "GET /upload/s3/object sniffed type" 200 '.content_type == "image/jpeg"'
+json "${MAIN_URL}/upload/s3/base64" '{}'
+expect "POST /upload/s3/base64 missing data" 400 '.success == false' '.error'
+request POST "${MAIN_URL}/upload/s3" -F "file=@${WORKDIR}/sample.wav" -F 'options={"key":"contract/sample.wav"}'
+expect "POST /upload/s3 multipart" 202 '.success == true' '.upload_id'
+request POST "${MAIN_URL}/upload/s3" -F "other=value"
+expect "POST /upload/s3 without file" 400 '.success == false'
+json "${MAIN_URL}/convert/audio/s3" "{\"data\":\"${AUDIO_BASE64}\",\"upload\":{\"key\":\"contract/streamed.ogg\"}}"
+expect "POST /convert/audio/s3" 200 '.success == true' '.audio.mime_type' '.audio.size > 0' '.upload.key == "contract/streamed.ogg"' '.upload.url'
+request GET "${MAIN_URL}/upload/s3/object/contract/streamed.ogg"
+expect "GET /upload/s3/object streamed output" 200 '.key == "contract/streamed.ogg"'
+json "${MAIN_URL}/convert/audio/s3" "{\"data\":\"${AUDIO_BASE64}\",\"upload\":{\"key_template\":\"{hash}.ogg\"}}"
+expect "POST /convert/audio/s3 content key_template" 400 '.code == "invalid_key_template"'
+json "${MAIN_URL}/upload/s3/url" "{\"url\":\"${MAIN_URL}/samples/jpeg\",\"key\":\"contract/from-url.jpg\"}"
+expect "POST /upload/s3/url" 202 '.success == true' '.upload_id'
+UPLOAD_ID=$(echo "$BODY" | jq -r '.upload_id')
+request GET "${MAIN_URL}/upload/s3/status/${UPLOAD_ID}/wait?timeout=10s"
+expect "GET /upload/s3/status URL upload" 200 '.status == "completed"' '.result.key == "contract/from-url.jpg"' '.source_url | endswith("/samples/jpeg")'
+request GET "${MAIN_URL}/upload/s3/object/contract/from-url.jpg"
+expect "GET /upload/s3/object URL upload type" 200 '.content_type == "image/jpeg"'
+json "${MAIN_URL}/upload/s3/url" '{"url":"ftp://example.com/file.jpg"}'
+expect "POST /upload/s3/url invalid scheme" 400 '.success == false' '.error'
+json "${VERIFY_URL}/upload/s3/url" "{\"url\":\"${MAIN_URL}/samples/jpeg\"}"
+expect "POST /upload/s3/url loopback host" 400 '.success == false' '(.error | contains("download address not allowed"))'
+json "${VERIFY_URL}/upload/s3/url" '{"url":"http://169.254.169.254/latest/meta-data/"}'
+expect "POST /upload/s3/url link-local host" 400 '.success == false' '(.error | contains("download address not allowed"))'
+request GET "${MAIN_URL}/upload/s3/status/unknown"
+expect "GET /upload/s3/status unknown" 404 '.error == "Upload not found"'
+request GET "${MAIN_URL}/upload/s3/status/unknown/events"
+expect "GET /upload/s3/status/:id/events unknown" 404 '.error == "Upload not found"'
+request GET "${MAIN_URL}/upload/s3/list?limit=10"
+expect "GET /upload/s3/list" 200 '.count >= 1' '(.uploads | type == "array")'
+request GET "${MAIN_URL}/upload/s3/object/sample.jpg"
+expect "GET /upload/s3/object/:key missing" 404 '.error'
+# Binary responses: assert on the response headers instead of the body
+for cache in miss hit; do
+    STATUS=$(curl -s -o /dev/null -D "${WORKDIR}/headers" -w '%{http_code}' "${MAIN_URL}/media/contract/sample.jpg?w=64")
+    BODY=$(jq -Rn '[inputs | rtrimstr("\r") | ascii_downcase]' "${WORKDIR}/headers")
+    expect "GET /media/:key (${cache})" 200 'any(.[]; startswith("content-type: image/jpeg"))' "any(.[]; . == \"x-cache: ${cache}\")" 'any(.[]; startswith("etag: "))'
+done
+request GET "${MAIN_URL}/media/contract/missing.jpg"
+expect "GET /media/:key missing" 404 '.error == "Object not found"'
+request GET "${MAIN_URL}/media/contract/sample.jpg?format=gif"
+expect "GET /media/:key bad format" 400 '.error == "Unsupported format"'
+json "${MAIN_URL}/convert/image" '{"source":{"s3_key":"contract/sample.jpg"}}'
+expect "POST /convert/image s3 source" 200 '.data | startswith("data:image/jpeg;base64,")' '.input.mime == "image/jpeg"'
+json "${MAIN_URL}/convert/image" '{"source":{"s3_key":"contract/missing.jpg"}}'
+expect "POST /convert/image missing s3 source" 404 '.code == "source_not_found"'
+json "${MAIN_URL}/convert/audio" "{\"data\":\"data:audio/mpeg;base64,${AUDIO_BASE64}\",\"source\":{\"s3_key\":\"contract/sample.jpg\"}}"
+expect "POST /convert/audio data and source" 400 '.code == "invalid_source"'
+json "${NO_S3_URL}/convert/image" '{"source":{"s3_key":"contract/sample.jpg"}}'
+expect "POST /convert/image source without S3" 400 '.code == "invalid_source"'
+request GET "${MAIN_URL}/upload/s3/object/contract/sample.jpg"
+expect "GET /upload/s3/object nested key" 200 '.key == "contract/sample.jpg"'
+request GET "${MAIN_URL}/upload/s3/object/contract%2Fsample.jpg"
+expect "GET /upload/s3/object encoded key" 200 '.key == "contract/sample.jpg"'
+request GET "${MAIN_URL}/upload/s3/objects?prefix=contract/&limit=1"
+expect "GET /upload/s3/objects first page" 200 '.count == 1' '.prefix == "contract/"' '.objects[0].key | startswith("contract/")' '.objects[0].size > 0' '.next_cursor'
+FIRST_KEY=$(echo "${BODY}" | jq -r '.objects[0].key')
+CURSOR=$(echo "${BODY}" | jq -r '.next_cursor')
+request GET "${MAIN_URL}/upload/s3/objects?prefix=contract/&limit=1000&cursor=${CURSOR}"
+expect "GET /upload/s3/objects next page" 200 '.count >= 1' "all(.objects[]; .key > \"${FIRST_KEY}\")" '(has("next_cursor") | not)'
+request GET "${MAIN_URL}/upload/s3/objects?limit=0"
+expect "GET /upload/s3/objects bad limit" 400 '.error == "Invalid limit"'
+json "${MAIN_URL}/upload/s3/object/contract/sample.jpg/share" '{"ttl":"60s"}'
+expect "POST /upload/s3/object nested key share" 200 '.key == "contract/sample.jpg"' '.ttl_seconds == 60' '.url'
+request PUT "${MAIN_URL}/upload/s3/object/contract/sample.jpg/expiration" -H "Content-Type: application/json" -d '{"days":7}'
+expect "PUT /upload/s3/object/:key/expiration" 200 '.key == "contract/sample.jpg"' '.expiration_days == 7' '.expires_at | endswith("T00:00:00Z")'
+request GET "${MAIN_URL}/upload/s3/object/contract/sample.jpg"
+expect "GET /upload/s3/object expiring" 200 '.metadata["expires-at"]'
+request PUT "${MAIN_URL}/upload/s3/object/contract/sample.jpg/expiration" -H "Content-Type: application/json" -d '{"days":0}'
+expect "PUT /upload/s3/object/:key/expiration removed" 200 '.expiration_days == 0' '(has("expires_at") | not)'
+request PUT "${MAIN_URL}/upload/s3/object/contract/sample.jpg/expiration" -H "Content-Type: application/json" -d '{"days":-1}'
+expect "PUT /upload/s3/object/:key/expiration bad days" 400 '.error == "Invalid days"'
+request PUT "${MAIN_URL}/upload/s3/object/contract/missing.jpg/expiration" -H "Content-Type: application/json" -d '{"days":7}'
+expect "PUT /upload/s3/object/:key/expiration missing" 404 '.error == "Object not found"'
+request DELETE "${MAIN_URL}/upload/s3/object/contract/sample.wav"
+expect "DELETE /upload/s3/object nested key" 200 '.success == true' '.trashed == true' '.trash_key == "trash/contract/sample.wav"' '.expires_at'
+request GET "${MAIN_URL}/upload/s3/object/contract/sample.wav"
+expect "GET /upload/s3/object deleted nested key" 404 '.error'
+request GET "${MAIN_URL}/upload/s3/object/trash/contract/sample.wav"
+expect "GET /upload/s3/object trashed copy" 200 '.metadata["trash-expires-at"]'
+request POST "${MAIN_URL}/upload/s3/object/contract/sample.wav/restore"
+expect "POST /upload/s3/object/:key/restore" 200 '.key == "contract/sample.wav"' '(.metadata | has("trash-expires-at") | not)'
+request POST "${MAIN_URL}/upload/s3/object/contract/sample.wav/restore"
+expect "POST /upload/s3/object/:key/restore not in trash" 404 '.code == "not_in_trash"'
+request DELETE "${MAIN_URL}/upload/s3/object/contract/sample.wav"
+expect "DELETE /upload/s3/object again" 200 '.trashed == true'
+request POST "${MAIN_URL}/upload/s3" -F "file=@${WORKDIR}/sample.wav" -F 'options={"key":"contract/sample.wav"}'
+sleep 0.3
+request POST "${MAIN_URL}/upload/s3/object/contract/sample.wav/restore"
+expect "POST /upload/s3/object/:key/restore over a new object" 409 '.code == "restore_conflict"'
+request DELETE "${MAIN_URL}/upload/s3/object/contract/sample.wav?permanent=true"
+expect "DELETE /upload/s3/object permanent" 200 '.trashed == false'
+request DELETE "${MAIN_URL}/upload/s3/object/trash/contract/sample.wav"
+expect "DELETE /upload/s3/object in the trash" 200 '.trashed == false'
+request GET "${MAIN_URL}/upload/s3/object/trash/contract/sample.wav"
+expect "GET /upload/s3/object purged copy" 404 '.error'
+request POST "${NO_S3_URL}/upload/s3/object/contract/sample.wav/restore"
+expect "POST /upload/s3/object/:key/restore with S3 disabled" 404 '.error == "Endpoint not found"'
+request GET "${MAIN_URL}/upload/s3/stats"
+expect "GET /upload/s3/stats" 200 '.s3_service.enabled == true' '.upload_manager | has("max_concurrent")' '.s3_service.verified_uploads > 0' '.s3_service.verification_mismatches == 0'
+request GET "${MAIN_URL}/upload/s3/health"
+expect "GET /upload/s3/health" 200 '.healthy == true'
+
+# Provider migrations into a second mock bucket
+echo -e "\n${YELLOW}Provider migrations${NC}"
+request POST "${MAIN_URL}/admin/migrations" -H "Content-Type: application/json" -d '{"destination":"backup"}'
+expect "POST /admin/migrations without token" 401 '.error == "Invalid admin token"'
+request POST "${MAIN_URL}/admin/migrations" -H "X-Admin-Token: contract-admin" -H "Content-Type: application/json" -d '{"destination":"r2"}'
+expect "POST /admin/migrations unknown provider" 400 '.code == "invalid_migration"'
+for run in first second; do
+    request POST "${MAIN_URL}/admin/migrations" -H "X-Admin-Token: contract-admin" -H "Content-Type: application/json" -d '{"destination":"backup","prefix":"contract/","concurrency":2}'
+    expect "POST /admin/migrations (${run} run)" 202 '.status == "running"' '.source == "default"' '.destination == "backup"'
+    MIGRATION_ID=$(echo "${BODY}" | jq -r '.id')
+    for _ in $(seq 1 50); do
+        request GET "${MAIN_URL}/admin/migrations/${MIGRATION_ID}" -H "X-Admin-Token: contract-admin"
+        [ "$(echo "${BODY}" | jq -r '.status')" = "completed" ] && break
+        sleep 0.1
+    done
+    if [ "$run" = "first" ]; then
+        expect "GET /admin/migrations/:id (${run} run)" 200 '.status == "completed"' '.progress.copied > 0' '.progress.failed == 0' '.report.ok == true' '.report.checked == .progress.listed'
+    else
+        expect "GET /admin/migrations/:id (${run} run)" 200 '.status == "completed"' '.progress.copied == 0' '.progress.skipped == .progress.listed' '.report.ok == true'
+    fi
+done
+request GET "${MAIN_URL}/admin/migrations" -H "X-Admin-Token: contract-admin"
+expect "GET /admin/migrations" 200 '.count == 2' '.providers == ["default","backup"]'
+request DELETE "${MAIN_URL}/admin/migrations/${MIGRATION_ID}" -H "X-Admin-Token: contract-admin"
+expect "DELETE /admin/migrations/:id finished" 409 '.code == "migration_finished"'
+
+# Upload verification against a bucket that truncates every object
+echo -e "\n${YELLOW}S3 upload verification${NC}"
+json "${VERIFY_URL}/upload/s3/base64" "{\"data\":\"${IMAGE_BASE64}\",\"key\":\"contract/truncated.jpg\"}"
+expect "POST /upload/s3/base64 to a truncating bucket" 202 '.upload_id'
+UPLOAD_ID=$(echo "$BODY" | jq -r '.upload_id')
+sleep 1
+request GET "${VERIFY_URL}/upload/s3/status/${UPLOAD_ID}"
+expect "Truncated upload fails verification" 200 '.status == "failed"' '(.error | test("upload verification failed"))'
+json "${VERIFY_URL}/convert/audio/s3" "{\"data\":\"${AUDIO_BASE64}\",\"upload\":{\"key\":\"contract/truncated.ogg\"}}"
+expect "POST /convert/audio/s3 to a truncating bucket" 502 '.code == "upload_verification_failed"'
+request GET "${VERIFY_URL}/upload/s3/stats"
+expect "GET /upload/s3/stats verification counters" 200 '.s3_service.verification_mismatches >= 3' '.s3_service.verification_failures >= 2' '.s3_service.verified_uploads == 0'
+
+# Temporary workspaces
+echo -e "\n${YELLOW}Workspaces${NC}"
+request POST "${MAIN_URL}/workspaces"
+expect "POST /workspaces" 201 '.id' '.files == []' '.expires_at'
+WORKSPACE_ID=$(echo "$BODY" | jq -r '.id')
+expect_header "POST /workspaces location" Location "/workspaces/${WORKSPACE_ID}"
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/files" "{\"name\":\"cover.jpeg\",\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /workspaces/:id/files base64" 201 '.name == "cover.jpeg"' '.mime_type == "image/jpeg"' '.origin == "upload"' '.size > 0'
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/files" "{\"name\":\"voice.wav\",\"data\":\"data:audio/wav;base64,${AUDIO_BASE64}\"}"
+expect "POST /workspaces/:id/files data URI" 201 '.name == "voice.wav"' '.mime_type == "audio/wav"'
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/files" "{\"name\":\"../escape.jpg\",\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /workspaces/:id/files invalid name" 400 '.code == "invalid_workspace_request"'
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/convert" '{"file":"cover.jpeg","options":{"quality":80}}'
+expect "POST /workspaces/:id/convert image" 201 '.name == "cover.jpg"' '.origin == "convert"' '.sources == ["cover.jpeg"]'
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/convert" '{"file":"voice.wav","output":"voice.ogg"}'
+expect "POST /workspaces/:id/convert audio" 201 '.name == "voice.ogg"' '.sources == ["voice.wav"]'
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/convert" '{"file":"missing.wav"}'
+expect "POST /workspaces/:id/convert missing file" 404 '.error == "Workspace file not found"'
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/compose" '{"audio":"voice.ogg","image":"cover.jpg"}'
+expect "POST /workspaces/:id/compose without the video feature" 404 '.error == "Endpoint not found"' '(.path | endswith("/compose"))' 'has("code") | not'
+json "${MAIN_URL}/convert/video" '{}'
+expect "POST /convert/video without the video feature" 404 '.error == "Endpoint not found"' '.path == "/convert/video"'
+request GET "${MAIN_URL}/workspaces/${WORKSPACE_ID}"
+expect "GET /workspaces/:id" 200 '(.files | length) == 4' '.size > 0'
+request GET "${MAIN_URL}/workspaces/${WORKSPACE_ID}/files/cover.jpg"
+expect_header "GET /workspaces/:id/files/:name" Content-Type image/jpeg
+request DELETE "${MAIN_URL}/workspaces/${WORKSPACE_ID}/files/voice.wav"
+expect "DELETE /workspaces/:id/files/:name" 204
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/commit" '{"prefix":"../outside"}'
+expect "POST /workspaces/:id/commit invalid prefix" 400 '.code == "invalid_workspace_request"'
+json "${MAIN_URL}/workspaces/${WORKSPACE_ID}/commit" '{"files":["cover.jpg","voice.ogg"]}'
+expect "POST /workspaces/:id/commit" 200 '.deleted == true' '(.objects | length) == 2' ".objects[0].key == \"workspaces/${WORKSPACE_ID}/cover.jpg\"" '.objects[0].content_type == "image/jpeg"'
+request GET "${MAIN_URL}/upload/s3/object/workspaces/${WORKSPACE_ID}/voice.ogg"
+expect "GET /upload/s3/object committed workspace file" 200
+request GET "${MAIN_URL}/workspaces/${WORKSPACE_ID}"
+expect "GET /workspaces/:id after commit" 404 '.error == "Workspace not found"'
+request POST "${NO_S3_URL}/workspaces"
+WORKSPACE_ID=$(echo "$BODY" | jq -r '.id')
+json "${NO_S3_URL}/workspaces/${WORKSPACE_ID}/files" "{\"name\":\"cover.jpg\",\"data\":\"${IMAGE_BASE64}\"}"
+json "${NO_S3_URL}/workspaces/${WORKSPACE_ID}/commit" '{}'
+expect "POST /workspaces/:id/commit with S3 disabled" 501
+request DELETE "${NO_S3_URL}/workspaces/${WORKSPACE_ID}"
+expect "DELETE /workspaces/:id" 204
+request DELETE "${NO_S3_URL}/workspaces/${WORKSPACE_ID}"
+expect "DELETE /workspaces/:id unknown" 404
+
+# S3 and web console disabled
+echo -e "\n${YELLOW}S3 and web console disabled${NC}"
+json "${NO_S3_URL}/upload/s3/base64" "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /upload/s3/base64 with S3 disabled" 404 '.error == "Endpoint not found"'
+request GET "${NO_S3_URL}/api"
+expect "GET /api with S3 disabled" 200 '.endpoints | has("s3_upload_base64") | not'
+request GET "${NO_S3_URL}/"
+expect "GET / with web console disabled" 404 '.error == "Endpoint not found"'
+
+# Encoder candidate on every Opus encode (same server)
+echo -e "\n${YELLOW}Encoder variants${NC}"
+json "${NO_S3_URL}/convert/audio" "{\"data\":\"${AUDIO_BASE64}\"}"
+expect "POST /convert/audio with a candidate encoder" 200 '.encoder_variant == "candidate"'
+expect_header "X-Encoder-Variant header" "X-Encoder-Variant" "candidate"
+request GET "${NO_S3_URL}/stats"
+expect "GET /stats per encoder variant" 200 '.audio.variants.candidate.conversions == 1' '.audio.variants.stable.conversions == 0' '.video | has("variants") | not'
+
+# Request recording
+echo -e "\n${YELLOW}Request recording${NC}"
+json "${NO_S3_URL}/convert/audio" '{"data":"%%%"}'
+expect "Failed conversion is recorded" 500
+RECORDING_ID=$(grep -i "^X-Recording-ID:" "${WORKDIR}/headers" | cut -d: -f2- | tr -d ' \r')
+request GET "${NO_S3_URL}/admin/recordings" -H "X-Admin-Token: contract-admin"
+expect "GET /admin/recordings" 200 '.count == 1' ".recordings[0].id == \"${RECORDING_ID}\"" '(.recordings[0].params.data | startswith("sha256:"))' '.recordings[0].inputs[0].sha256' '.recordings[0].body_recorded == true'
+request POST "${NO_S3_URL}/admin/replay/${RECORDING_ID}" -H "X-Admin-Token: contract-admin"
+expect "POST /admin/replay" 200 '.original_status == 500' '.status == 500' '.fixed == false' '.response.error == "Conversion failed"'
+request GET "${NO_S3_URL}/admin/recordings" -H "X-Admin-Token: contract-admin"
+expect "Replays are not recorded" 200 '.count == 1'
+request GET "${NO_S3_URL}/admin/recordings" -H "X-Admin-Token: wrong"
+expect "GET /admin/recordings invalid admin token" 401 '.error == "Invalid admin token"'
+request POST "${NO_S3_URL}/admin/replay/3f1c9a52-8d7e-4b0a-9c61-2f4e5d6a7b8c" -H "X-Admin-Token: contract-admin"
+expect "POST /admin/replay unknown recording" 404 '.code == "recording_not_found"'
+
+# Maintenance windows (same server)
+echo -e "\n${YELLOW}Maintenance windows${NC}"
+request PUT "${NO_S3_URL}/admin/maintenance/samples" -H "X-Admin-Token: contract-admin" -H "Content-Type: application/json" -d '{"message":"Samples are paused","duration":"10m"}'
+expect "PUT /admin/maintenance" 200 '.endpoint == "/samples"' '.message == "Samples are paused"' '.until'
+request GET "${NO_S3_URL}/v1/samples/mp3"
+expect "Endpoint under maintenance" 503 '.code == "maintenance"' '.details == "Samples are paused"'
+expect_header "Maintenance Retry-After" Retry-After 600
+request GET "${NO_S3_URL}/health"
+expect "GET /health during maintenance" 200
+request GET "${NO_S3_URL}/admin/maintenance" -H "X-Admin-Token: contract-admin"
+expect "GET /admin/maintenance" 200 '.count == 1' '.endpoints[0].endpoint == "/samples"'
+request PUT "${NO_S3_URL}/admin/maintenance/health" -H "X-Admin-Token: contract-admin"
+expect "PUT /admin/maintenance exempt endpoint" 400 '.code == "invalid_endpoint"'
+request DELETE "${NO_S3_URL}/admin/maintenance/samples" -H "X-Admin-Token: contract-admin"
+expect "DELETE /admin/maintenance" 200 '.success == true'
+request GET "${NO_S3_URL}/samples"
+expect "Endpoint back after maintenance" 200
+request DELETE "${NO_S3_URL}/admin/maintenance/samples" -H "X-Admin-Token: contract-admin"
+expect "DELETE /admin/maintenance not under maintenance" 404 '.code == "maintenance_not_found"'
+
+# Audit stamps: converted images name the caller's tenant and request
+request POST "${NO_S3_URL}/convert/image" -H "Content-Type: application/json" -H "X-API-Key: stamp-key" -H "X-Request-ID: stamp-request-1" -d "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "Image converted with an audit stamp" 200 '.data | startswith("data:image/jpeg;base64,")'
+STAMPED_IMAGE=$(echo "$BODY" | jq -r '.data')
+json "${NO_S3_URL}/admin/audit-stamp" "{\"data\":\"${STAMPED_IMAGE}\"}"
+expect "POST /admin/audit-stamp without token" 401
+request POST "${NO_S3_URL}/admin/audit-stamp" -H "Content-Type: application/json" -H "X-Admin-Token: contract-admin" -d "{\"data\":\"${STAMPED_IMAGE}\"}"
+expect "POST /admin/audit-stamp" 200 '.valid == true' '.reference == "stamp-request-1"' '.tenant | startswith("key_")' '.issued_at != null'
+request POST "${NO_S3_URL}/admin/audit-stamp" -H "Content-Type: application/json" -H "X-Admin-Token: contract-admin" -d "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /admin/audit-stamp unstamped image" 404 '.code == "audit_stamp_not_found"'
+request POST "${MAIN_URL}/admin/audit-stamp" -H "Content-Type: application/json" -d "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /admin/audit-stamp without AUDIT_STAMP_SECRET" 404
+
+# Read-only mode: conversions and uploads refused, reads served
+echo -e "\n${YELLOW}Read-only mode${NC}"
+request GET "${READ_ONLY_URL}/health"
+expect "GET /health read-only" 200 '.read_only == true'
+json "${READ_ONLY_URL}/convert/image" "{\"data\":\"${IMAGE_BASE64}\"}"
+expect "POST /convert/image read-only" 503 '.code == "read_only"'
+json "${READ_ONLY_URL}/v1/convert/audio" "{\"data\":\"${AUDIO_BASE64}\"}"
+expect "POST /v1/convert/audio read-only" 503 '.code == "read_only"'
+json "${READ_ONLY_URL}/upload/s3/base64" "{\"data\":\"${IMAGE_BASE64}\",\"key\":\"contract/read-only.jpg\"}"
+expect "POST /upload/s3/base64 read-only" 503 '.code == "read_only"'
+request DELETE "${READ_ONLY_URL}/upload/s3/object/contract/read-only.jpg"
+expect "DELETE /upload/s3/object read-only" 503 '.code == "read_only"'
+request GET "${READ_ONLY_URL}/upload/s3/list"
+expect "GET /upload/s3/list read-only" 200
+request GET "${READ_ONLY_URL}/upload/s3/objects"
+expect "GET /upload/s3/objects read-only" 200 '.objects | type == "array"'
+request POST "${READ_ONLY_URL}/workspaces"
+expect "POST /workspaces read-only" 503 '.code == "read_only"'
+json "${READ_ONLY_URL}/admin/migrations" '{"target":"secondary"}'
+expect "POST /admin/migrations read-only" 503 '.code == "read_only"'
+request GET "${MAIN_URL}/health"
+expect "GET /health not read-only" 200 '.read_only == null'
+
+echo -e "\n${BLUE}========================================${NC}"
+echo -e "Passed: ${GREEN}${PASSED}${NC}  Failed: ${RED}${FAILED}${NC}"
+echo -e "${BLUE}========================================${NC}"
+
+if [ "$FAILED" -gt 0 ]; then
+    exit 1
+fi
@@ -0,0 +1,51 @@
+#!/bin/bash
+
+# S3 provider conformance suite
+# Default: runs against a throwaway MinIO bucket from docker-compose.
+# S3_CONFORMANCE_LIVE=true: runs against the provider configured in the environment/.env.
+
+set -e
+
+# Colors
+RED='\033[0;31m'
+GREEN='\033[0;32m'
+YELLOW='\033[1;33m'
+NC='\033[0m'
+
+BUCKET=${S3_CONFORMANCE_BUCKET:-"conformance"}
+
+if [ "${S3_CONFORMANCE_LIVE:-false}" = "true" ]; then
+    echo -e "${YELLOW}Running conformance suite against live provider ${S3_PROVIDER:-from .env}...${NC}"
+    go run ./cmd/s3-conformance "$@"
+    exit $?
+fi
+
+if ! command -v docker-compose &> /dev/null; then
+    echo -e "${RED}docker-compose is required to start MinIO (or set S3_CONFORMANCE_LIVE=true).${NC}"
+    exit 1
+fi
+
+echo -e "${GREEN}Starting MinIO...${NC}"
+docker-compose up -d minio
+
+for _ in $(seq 1 30); do
+    if curl -sf http://localhost:9000/minio/health/live > /dev/null; then
+        break
+    fi
+    sleep 1
+done
+
+echo -e "${GREEN}Creating bucket ${BUCKET}...${NC}"
+docker-compose exec -T minio sh -c \
+    "mc alias set local http://localhost:9000 minioadmin minioadmin123 > /dev/null && mc mb --ignore-existing local/${BUCKET}"
+
+echo -e "${GREEN}Running conformance suite against MinIO...${NC}"
+S3_PROVIDER=minio \
+S3_ENDPOINT=http://localhost:9000 \
+S3_PUBLIC_ENDPOINT=http://localhost:9000 \
+S3_REGION=us-east-1 \
+S3_BUCKET="$BUCKET" \
+S3_ACCESS_KEY=minioadmin \
+S3_SECRET_KEY=minioadmin123 \
+S3_PATH_STYLE=true \
+    go run ./cmd/s3-conformance "$@"
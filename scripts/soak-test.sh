@@ -0,0 +1,60 @@
+#!/bin/bash
+
+# Soak test - loads a MOCK_MODE server for hours with conversions, uploads and
+# batch jobs, and fails when goroutines, heap or the upload status map keep
+# growing (see cmd/soak). Mock mode keeps FFmpeg and S3 out of the picture, so
+# only the API's own state is measured; run `go run ./cmd/soak -api <url>`
+# against a real deployment to include them.
+
+set -euo pipefail
+
+# Colors
+RED='\033[0;31m'
+GREEN='\033[0;32m'
+NC='\033[0m'
+
+# Configuration
+BINARY=${BINARY:-"./media-converter"}
+PORT=${SOAK_PORT:-18280}
+DURATION=${SOAK_DURATION:-2h}
+WARMUP=${SOAK_WARMUP:-10m}
+# Short retentions, so the maps they bound reach their steady size within WARMUP
+STATUS_TTL=${SOAK_STATUS_TTL:-5m}
+
+WORKDIR=$(mktemp -d)
+SERVER_PID=""
+
+cleanup() {
+    if [ -n "$SERVER_PID" ]; then
+        kill "$SERVER_PID" 2> /dev/null || true
+    fi
+    rm -rf "$WORKDIR"
+}
+trap cleanup EXIT
+
+if [ ! -x "$BINARY" ]; then
+    echo -e "${RED}${BINARY} not found; run make build first.${NC}"
+    exit 1
+fi
+
+env PORT="$PORT" MOCK_MODE=true ENABLE_SWAGGER=false DOWNLOAD_ALLOW_PRIVATE_HOSTS=true \
+    S3_UPLOAD_STATUS_TTL="$STATUS_TTL" BATCH_JOB_RETENTION="$STATUS_TTL" \
+    "$BINARY" > "${WORKDIR}/server.log" 2>&1 &
+SERVER_PID=$!
+
+for _ in $(seq 1 50); do
+    if curl -s -o /dev/null "http://localhost:${PORT}/health"; then
+        break
+    fi
+    sleep 0.2
+done
+
+echo -e "${GREEN}Soaking http://localhost:${PORT} for ${DURATION} (${WARMUP} warmup)...${NC}"
+if go run ./cmd/soak -api "http://localhost:${PORT}" -duration "$DURATION" -warmup "$WARMUP" "$@"; then
+    echo -e "${GREEN}Soak test passed${NC}"
+else
+    status=$?
+    echo -e "${RED}Soak test failed; last server log lines:${NC}"
+    tail -n 50 "${WORKDIR}/server.log"
+    exit $status
+fi
@@ -6,6 +6,7 @@ class S3Uploader {
         this.files = [];
         this.uploads = new Map(); // Track active uploads
         this.polling = new Map(); // Track polling intervals
+        this.sockets = new Map(); // Track progress WebSockets
         this.s3Available = false;
         this.init();
     }
@@ -218,8 +219,8 @@ class S3Uploader {
             if (uploadResponse.success && uploadResponse.upload_id) {
                 fileInfo.uploadId = uploadResponse.upload_id;
 
-                // Start polling for progress
-                await this.pollUploadProgress(fileInfo);
+                // Follow progress live, polling if WebSockets are unavailable
+                await this.watchUploadProgress(fileInfo);
             } else {
                 throw new Error(uploadResponse.error || 'Upload failed');
             }
@@ -458,8 +459,8 @@ class S3Uploader {
                 fileInfo.uploadId = uploadResponse.upload_id;
                 this.uploads.set(fileInfo.id, fileInfo);
 
-                // Start polling for progress
-                await this.pollUploadProgress(fileInfo);
+                // Follow progress live, polling if WebSockets are unavailable
+                await this.watchUploadProgress(fileInfo);
             } else {
                 throw new Error(uploadResponse.error || 'Upload failed');
             }
@@ -544,6 +545,85 @@ class S3Uploader {
         });
     }
 
+    async watchUploadProgress(fileInfo) {
+        if (!('WebSocket' in window)) {
+            return this.pollUploadProgress(fileInfo);
+        }
+
+        return new Promise((resolve, reject) => {
+            const scheme = location.protocol === 'https:' ? 'wss://' : 'ws://';
+            const socket = new WebSocket(`${scheme}${location.host}/upload/s3/progress/${fileInfo.uploadId}`);
+            let opened = false;
+            let settled = false;
+
+            const finish = (error) => {
+                if (settled) return;
+                settled = true;
+                this.sockets.delete(fileInfo.id);
+                this.uploads.delete(fileInfo.id);
+                this.updateFileDisplay(fileInfo);
+                error ? reject(error) : resolve();
+            };
+
+            socket.onopen = () => {
+                opened = true;
+            };
+
+            socket.onmessage = async (message) => {
+                const event = JSON.parse(message.data);
+
+                fileInfo.progress = event.progress || 0;
+                this.updateFileDisplay(fileInfo);
+
+                switch (event.status) {
+                    case 'completed':
+                        fileInfo.status = 'completed';
+                        fileInfo.progress = 100;
+                        try {
+                            // Progress events don't carry the object URL
+                            const status = await MediaConverter.apiRequest(`/upload/s3/status/${fileInfo.uploadId}`);
+                            fileInfo.publicUrl = status.result?.url;
+                        } catch (error) {
+                            console.error('Could not fetch result for upload:', fileInfo.uploadId, error);
+                        }
+                        finish();
+                        break;
+
+                    case 'failed':
+                        fileInfo.status = 'failed';
+                        fileInfo.error = event.error || 'Upload failed';
+                        finish(new Error(fileInfo.error));
+                        break;
+
+                    case 'cancelled':
+                        fileInfo.status = 'cancelled';
+                        fileInfo.error = event.error || 'Upload cancelled';
+                        finish();
+                        break;
+                }
+            };
+
+            socket.onclose = () => {
+                if (settled) return;
+                if (this.sockets.get(fileInfo.id) !== socket) {
+                    // Closed by cancelUpload or clearFiles
+                    finish();
+                    return;
+                }
+                if (!opened) {
+                    // Proxies may refuse the upgrade; fall back to polling
+                    this.sockets.delete(fileInfo.id);
+                    settled = true;
+                    this.pollUploadProgress(fileInfo).then(resolve, reject);
+                    return;
+                }
+                finish(new Error('Progress connection lost'));
+            };
+
+            this.sockets.set(fileInfo.id, socket);
+        });
+    }
+
     // ================================
     // FILE MANAGEMENT
     // ================================
@@ -599,6 +679,10 @@ class S3Uploader {
                 clearInterval(this.polling.get(fileId));
                 this.polling.delete(fileId);
             }
+            if (this.sockets.has(fileId)) {
+                this.sockets.get(fileId).close();
+                this.sockets.delete(fileId);
+            }
 
             // Update file status
             fileInfo.status = 'cancelled';
@@ -627,6 +711,8 @@ class S3Uploader {
         // Clear polling intervals
         this.polling.forEach((interval) => clearInterval(interval));
         this.polling.clear();
+        this.sockets.forEach((socket) => socket.close());
+        this.sockets.clear();
         this.uploads.clear();
 
         this.files = [];
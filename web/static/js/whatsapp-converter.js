@@ -46,6 +46,10 @@ class WhatsAppConverter {
         document.getElementById('whatsapp-clear-btn').addEventListener('click', () => {
             this.clearFiles();
         });
+
+        document.getElementById('whatsapp-sample-btn').addEventListener('click', () => {
+            this.loadSamples();
+        });
     }
 
     // ================================
@@ -94,6 +98,25 @@ class WhatsAppConverter {
         }
     }
 
+    // Adds the API's embedded sample audio and image, so the converter can be
+    // tried without test files at hand
+    async loadSamples() {
+        try {
+            const files = await Promise.all(['mp3', 'jpeg'].map(async (type) => {
+                const response = await fetch(`/samples/${type}`);
+                if (!response.ok) {
+                    throw new Error(`HTTP ${response.status}: ${response.statusText}`);
+                }
+                const blob = await response.blob();
+                const ext = type === 'jpeg' ? 'jpg' : type;
+                return new File([blob], `sample.${ext}`, { type: blob.type });
+            }));
+            this.handleFiles(files);
+        } catch (error) {
+            MediaConverter.showToast('error', 'Samples Unavailable', error.message);
+        }
+    }
+
     // ================================
     // CONVERSION PROCESS
     // ================================